@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adoptAll    bool
+	adoptBranch string
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path]",
+	Short: "Move worktrees created with 'git worktree add' to where sesh expects them",
+	Long: `Adopt worktrees of sesh projects that weren't created by sesh.
+
+A worktree added with 'git worktree add' can be anywhere and have no branch
+checked out. 'sesh list' marks such worktrees as external. Adopting one moves it
+to the path sesh uses for its branch and sets up tracking of origin/<branch>, like
+worktrees sesh creates have. A worktree without a branch needs one from --branch.
+
+Examples:
+  sesh adopt                       # Adopt the worktree in the current directory
+  sesh adopt ../repo-hotfix        # Adopt the worktree at a path
+  sesh adopt --branch fix ../tmp   # Create branch "fix" in a detached worktree first
+  sesh adopt --all                 # Adopt every external worktree with a branch`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAdopt,
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+	adoptCmd.Flags().
+		BoolVar(&adoptAll, "all", false, "Adopt the external worktrees of every project")
+	adoptCmd.Flags().
+		StringVarP(&adoptBranch, "branch", "b", "", "Branch to create in a worktree that has none checked out")
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	if adoptAll {
+		if len(args) > 0 || adoptBranch != "" {
+			return eris.New("--all can't be combined with a path or --branch")
+		}
+		return adoptAllWorktrees(cfg, disp)
+	}
+
+	path := "."
+	if len(args) == 1 {
+		path = args[0]
+	}
+	worktreePath, commonDir, err := git.GetWorktreeRoot(path)
+	if err != nil {
+		return err
+	}
+
+	proj, err := findProjectByRepoPath(cfg, commonDir)
+	if err != nil {
+		return err
+	}
+
+	branch, err := git.GetWorktreeBranch(worktreePath)
+	if err != nil {
+		return err
+	}
+	if branch == "(detached)" {
+		if adoptBranch == "" {
+			return eris.Errorf("%s has no branch checked out (give it one with --branch)", worktreePath)
+		}
+		disp.Printf("%s Creating branch %s\n", disp.InfoText("✨"), disp.Bold(adoptBranch))
+		if err := git.CheckoutNewBranch(worktreePath, adoptBranch); err != nil {
+			return err
+		}
+		branch = adoptBranch
+	} else if adoptBranch != "" && adoptBranch != branch {
+		return eris.Errorf("%s already has branch %s checked out", worktreePath, branch)
+	}
+
+	return adoptWorktree(cfg, proj, worktreePath, branch, disp)
+}
+
+// adoptAllWorktrees adopts the external worktrees of every project
+// Worktrees without a branch are skipped, since a branch name has to be chosen for each.
+func adoptAllWorktrees(cfg *config.Config, disp display.Printer) error {
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return eris.Wrap(err, "failed to discover projects")
+	}
+
+	adopted := 0
+	for _, proj := range projects {
+		worktrees, err := state.DiscoverWorktrees(proj)
+		if err != nil {
+			continue
+		}
+		workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+		for _, wt := range worktrees {
+			if wt.Path == proj.LocalPath || !workspace.IsExternalWorktree(workspaceDir, proj.Name, wt.Branch, wt.Path) {
+				continue
+			}
+			if wt.Branch == "(detached)" || wt.Branch == "" {
+				disp.Warningf("Skipping %s, which has no branch (run 'sesh adopt --branch <name> %s')", wt.Path, wt.Path)
+				continue
+			}
+			if err := adoptWorktree(cfg, proj, wt.Path, wt.Branch, disp); err != nil {
+				return err
+			}
+			adopted++
+		}
+	}
+
+	if adopted == 0 {
+		disp.Info("No external worktrees to adopt.")
+	}
+	return nil
+}
+
+// findProjectByRepoPath returns the project whose bare repository is at repoPath
+func findProjectByRepoPath(cfg *config.Config, repoPath string) (*models.Project, error) {
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to discover projects")
+	}
+
+	resolved, _ := filepath.EvalSymlinks(repoPath)
+	for _, proj := range projects {
+		localPath, _ := filepath.EvalSymlinks(proj.LocalPath)
+		if proj.LocalPath == repoPath || (localPath != "" && localPath == resolved) {
+			return proj, nil
+		}
+	}
+	return nil, eris.Errorf("%s is not a worktree of a sesh project", repoPath)
+}
+
+// adoptWorktree moves a worktree to the path of its branch and sets up tracking of the primary remote
+func adoptWorktree(cfg *config.Config, proj *models.Project, worktreePath, branch string, disp display.Printer) error {
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if !git.HasUpstream(worktreePath, branch) {
+		remote := cfg.Remote.For(proj.Name)
+		if exists, err := git.DoesBranchExistRemotely(proj.LocalPath, remote, branch); err == nil && exists {
+			if err := git.SetUpstream(worktreePath, remote, branch); err != nil {
+				return err
+			}
+		}
+	}
+
+	workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	if !workspace.IsExternalWorktree(workspaceDir, proj.Name, branch, worktreePath) {
+		disp.Successf("%s is already where sesh expects it", worktreePath)
+		return nil
+	}
+
+	target := workspace.ProjectWorktreePath(workspaceDir, proj.Name, branch)
+	if _, err := os.Stat(target); err == nil {
+		return eris.Errorf("can't move %s to %s, which already exists", worktreePath, target)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create directory: %s", filepath.Dir(target))
+	}
+
+	disp.Printf("%s Moving worktree of %s\n", disp.InfoText("→"), disp.Bold(branch))
+	err = git.MoveWorktree(proj.LocalPath, worktreePath, target)
+	recordOperation("adopt", proj.Name, branch, err)
+	if err != nil {
+		return err
+	}
+	zoxideRemove(cfg, worktreePath, disp)
+	prepare.ZoxideAdd(cfg, target, disp)
+
+	disp.Successf("Adopted %s", disp.Bold(branch))
+	disp.Printf("  %s %s\n", disp.Faint("Worktree:"), target)
+	disp.Printf("  %s %s\n", disp.Faint("Moved from:"), worktreePath)
+
+	// A session started in the old directory keeps it as its working directory
+	if sessionMgr, err := session.NewSessionManager(cfg.SessionBackend); err == nil {
+		sessionName := workspace.GenerateSessionName(proj.Name, branch)
+		if exists, err := sessionMgr.Exists(sessionName); err == nil && exists {
+			disp.Warningf("Session %s was started in the old directory; restart it to use the new one", sessionName)
+		}
+	}
+	if cwd, err := os.Getwd(); err == nil && (cwd == worktreePath || strings.HasPrefix(cwd, worktreePath+string(filepath.Separator))) {
+		disp.Printf("  %s cd %s\n", disp.Faint("Your shell is in the old directory:"), target)
+	}
+	return nil
+}
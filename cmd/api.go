@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+var (
+	// errInvalidRequest marks errors caused by a malformed request from an API client
+	errInvalidRequest = eris.New("invalid request")
+
+	// errNotFound marks errors caused by a project that doesn't exist in the workspace
+	errNotFound = eris.New("not found")
+)
+
+// api implements the operations that 'sesh serve' exposes to editor plugins and status bars,
+// over HTTP or the unix socket
+// Progress messages are discarded, since there is no terminal to show them on
+type api struct {
+	cfg        *config.Config
+	sessionMgr session.SessionManager
+	disp       display.Printer
+}
+
+// newAPI creates the API for the configured workspace and session backend
+func newAPI(cfg *config.Config) (*api, error) {
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	return &api{
+		cfg:        cfg,
+		sessionMgr: sessionMgr,
+		disp:       display.New(io.Discard),
+	}, nil
+}
+
+// switchResult is the outcome of switching to a branch through the API
+type switchResult struct {
+	SessionName  string `json:"session_name"`
+	WorktreePath string `json:"worktree_path"`
+	// Whether the client was switched to the session; otherwise the caller attaches itself
+	Switched bool `json:"switched"`
+}
+
+// worktreeResult is the outcome of creating a worktree through the API
+type worktreeResult struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	// Whether the worktree was created, as opposed to already existing
+	Created bool `json:"created"`
+}
+
+// worktreeInfo describes the worktree and session of a branch, as shown by 'sesh info'
+type worktreeInfo struct {
+	SessionName  string `json:"session_name"`
+	Project      string `json:"project"`
+	Branch       string `json:"branch"`
+	WorktreePath string `json:"worktree_path,omitempty"`
+	// Whether the branch has a local worktree; otherwise it only exists on the remote
+	HasWorktree bool `json:"has_worktree"`
+	IsRunning   bool `json:"is_running"`
+	// Summary of uncommitted changes, e.g. "2 modified, 1 untracked" or "clean"
+	GitStatus  string `json:"git_status,omitempty"`
+	LastCommit string `json:"last_commit,omitempty"`
+	// Commits ahead of and behind the upstream branch, when the branch has one
+	Tracking *git.AheadBehind `json:"tracking,omitempty"`
+	// Lines changed since the branch forked from its upstream, including uncommitted changes
+	DiffStat *git.DiffStat `json:"diff_stat,omitempty"`
+	Stashes  int           `json:"stashes"`
+	// What the project's ready_check probes, e.g. "localhost:3000", for running sessions, and
+	// whether their services answer it
+	ReadyCheck string `json:"ready_check,omitempty"`
+	Ready      bool   `json:"ready,omitempty"`
+}
+
+// projects lists all projects in the workspace
+func (a *api) projects() ([]*models.Project, error) {
+	projects, err := state.DiscoverProjects(a.cfg.WorkspaceDir)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to discover projects")
+	}
+	if projects == nil {
+		projects = []*models.Project{}
+	}
+	return projects, nil
+}
+
+// sessions lists the session of every worktree, optionally only those of one project
+func (a *api) sessions(projectName string) ([]sessionDetail, error) {
+	if projectName != "" {
+		proj, err := a.resolveProject(projectName)
+		if err != nil {
+			return nil, err
+		}
+		projectName = proj.Name
+	}
+
+	projects, err := a.projects()
+	if err != nil {
+		return nil, err
+	}
+
+	runningSessions, err := state.DiscoverSessions(a.sessionMgr)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to discover sessions")
+	}
+
+	sessions := collectSessionDetails(projects, runningSessions, projectName, false)
+	if sessions == nil {
+		sessions = []sessionDetail{}
+	}
+	return sessions, nil
+}
+
+// switchTo prepares the worktree and session of a branch, like 'sesh switch --detach'
+// When sesh runs inside a session, the client is switched to it as well
+func (a *api) switchTo(ctx context.Context, projectName, branch string) (*switchResult, error) {
+	if branch == "" {
+		return nil, eris.Wrap(errInvalidRequest, "branch is required")
+	}
+
+	proj, err := a.resolveProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionName, pending, err := prepareSession(ctx, a.cfg, proj, a.sessionMgr, branch, a.disp)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepare.WaitForStartup(ctx, a.sessionMgr, pending, a.disp); err != nil {
+		return nil, err
+	}
+	recordSessionHistory(sessionName, proj.Name, branch)
+
+	if a.cfg.Tmux.WindowTitles {
+		setSessionTitle(a.sessionMgr, sessionName, proj.Name, branch)
+	}
+
+	result := &switchResult{SessionName: sessionName}
+	if wt, err := state.GetWorktree(proj, branch); err == nil {
+		result.WorktreePath = wt.Path
+	}
+
+	if a.sessionMgr.IsInsideSession() {
+		if err := a.sessionMgr.Switch(sessionName); err != nil {
+			return nil, eris.Wrap(err, "failed to switch session")
+		}
+		result.Switched = true
+	}
+
+	return result, nil
+}
+
+// createWorktree creates the worktree of a branch without a session
+func (a *api) createWorktree(ctx context.Context, projectName, branch string) (*worktreeResult, error) {
+	if branch == "" {
+		return nil, eris.Wrap(errInvalidRequest, "branch is required")
+	}
+
+	proj, err := a.resolveProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockProject(a.cfg, proj.Name, a.disp)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if wt, err := state.GetWorktree(proj, branch); err == nil {
+		return &worktreeResult{Branch: branch, Path: wt.Path}, nil
+	}
+
+	path, err := newPreparer(a.cfg, a.sessionMgr, a.disp).CreateWorktree(ctx, proj, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &worktreeResult{Branch: branch, Path: path, Created: true}, nil
+}
+
+// info describes the worktree and session of a branch
+func (a *api) info(projectName, branch string) (*worktreeInfo, error) {
+	if branch == "" {
+		return nil, eris.Wrap(errInvalidRequest, "branch is required")
+	}
+
+	proj, err := a.resolveProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &worktreeInfo{
+		SessionName: workspace.GenerateSessionName(proj.Name, branch),
+		Project:     proj.Name,
+		Branch:      branch,
+	}
+
+	wt, err := state.GetWorktree(proj, branch)
+	if err != nil {
+		// Not checked out, so describe the remote branch
+		info.LastCommit = strings.TrimSpace(getRemoteBranchLastCommit(proj.LocalPath, a.cfg.Remote.For(proj.Name), branch))
+		return info, nil
+	}
+
+	info.WorktreePath = wt.Path
+	info.HasWorktree = true
+	info.LastCommit = strings.TrimSpace(getLastCommit(wt.Path))
+	if summary, err := getGitStatusSummary(wt.Path); err == nil {
+		info.GitStatus = summary
+	}
+	if tracking, err := git.GetAheadBehind(wt.Path); err == nil {
+		info.Tracking = tracking
+	}
+	if diffStat, err := git.GetDiffStat(wt.Path); err == nil {
+		info.DiffStat = diffStat
+	}
+	if stashes, err := git.CountStashes(wt.Path, branch); err == nil {
+		info.Stashes = stashes
+	}
+
+	info.IsRunning, err = a.sessionMgr.Exists(info.SessionName)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to check session status")
+	}
+	if info.IsRunning {
+		info.ReadyCheck, info.Ready = probeReady(context.Background(), wt.Path)
+	}
+
+	return info, nil
+}
+
+// resolveProject looks up a project by full or short name
+func (a *api) resolveProject(projectName string) (*models.Project, error) {
+	if projectName == "" {
+		return nil, eris.Wrap(errInvalidRequest, "project is required")
+	}
+
+	proj, err := project.ResolveProject(a.cfg.WorkspaceDir, projectName, "")
+	if err != nil {
+		return nil, eris.Wrapf(errNotFound, "project %s: %v", projectName, err)
+	}
+
+	return proj, nil
+}
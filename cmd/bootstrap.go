@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/manifest"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap <manifest>",
+	Short: "Clone the projects of a manifest written by 'sesh export'",
+	Long: `Clone the projects of a manifest written by 'sesh export', e.g. to set up a new
+machine like the old one.
+
+Each project is cloned into its workspace, or the primary one when this machine
+has no workspace of that name, with its other remotes added and fetched. The
+worktrees of the default branch and of the branches of the manifest are created
+again; branches that aren't on origin are skipped. No sessions are started.
+
+Projects that are already in the workspace get the remotes and worktrees they
+miss, after a fetch, so bootstrap can be run again, e.g. after a failure. Use - to read the
+manifest from stdin.
+
+Examples:
+  sesh bootstrap manifest.yaml
+  ssh old-machine sesh export | sesh bootstrap -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runBootstrap,
+}
+
+func init() {
+	rootCmd.AddCommand(bootstrapCmd)
+}
+
+func runBootstrap(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	m, err := readManifest(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Keep going when a project can't be cloned, so the others still are
+	failed := 0
+	for _, project := range m.Projects {
+		if err := bootstrapProject(cmd.Context(), cfg, project, disp); err != nil {
+			disp.Errorf("Failed to bootstrap %s: %v", project.Name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return eris.Errorf("failed to bootstrap %d of %d projects", failed, len(m.Projects))
+	}
+	disp.Successf("Bootstrapped %d projects", len(m.Projects))
+	return nil
+}
+
+// readManifest reads the manifest in a file, or stdin for "-"
+func readManifest(path string) (*manifest.Manifest, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to open manifest: %s", path)
+		}
+		defer file.Close()
+		r = file
+	}
+	return manifest.Read(r)
+}
+
+// bootstrapProject clones a project of a manifest, unless it is already in the workspace, and
+// adds the remotes and creates the worktrees it misses
+func bootstrapProject(ctx context.Context, cfg *config.Config, project manifest.Project, disp display.Printer) error {
+	unlock, err := lockProject(cfg, project.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	workspaceDir := cfg.WorkspaceDir
+	if project.Workspace != "" {
+		if ws, err := config.GetWorkspace(project.Workspace); err == nil {
+			workspaceDir = ws.Path
+		} else {
+			disp.Warningf("%s: %v, cloning into the primary workspace", project.Name, err)
+		}
+	}
+
+	bareRepoPath := workspace.GetBareRepoPath(workspaceDir, project.Name)
+	if existing, err := state.GetProject(cfg.WorkspaceDir, project.Name); err == nil && existing != nil {
+		disp.Printf("%s %s is already in the workspace\n", disp.Faint("○"), disp.Bold(project.Name))
+		bareRepoPath = existing.LocalPath
+		workspaceDir = workspace.GetProjectWorkspaceDir(bareRepoPath, project.Name)
+		// Branches pushed since the last fetch are in the manifest too
+		if err := git.Fetch(ctx, bareRepoPath); err != nil {
+			disp.Warningf("Failed to fetch %s, using the last fetch: %v", project.Name, err)
+		}
+	} else {
+		if err := os.MkdirAll(workspaceDir, 0o755); err != nil {
+			return eris.Wrapf(err, "failed to create workspace directory: %s", workspaceDir)
+		}
+		disp.Infof("Cloning %s", disp.Bold(project.Remotes["origin"]))
+		defaults := cfg.Clone.For(project.Name)
+		opts := git.CloneOptions{Depth: defaults.Depth, Filter: defaults.Filter, Dissociate: true}
+		if dirs, err := cfg.Clone.ReferencePaths(); err == nil {
+			opts.Reference = git.FindReference(dirs, project.Name)
+		}
+		progress := display.NewProgress(os.Stderr, "Cloning")
+		opts.Progress = progress.Update
+		err := git.Clone(ctx, project.Remotes["origin"], bareRepoPath, opts)
+		progress.Stop()
+		recordOperation("clone", project.Name, "", err)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := addMissingRemotes(ctx, bareRepoPath, project, disp); err != nil {
+		return err
+	}
+
+	defaultBranch, err := git.GetDefaultBranch(bareRepoPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to get default branch")
+	}
+	branches := append([]string{defaultBranch}, project.Branches...)
+	for _, branch := range slices.Compact(branches) {
+		if err := bootstrapWorktree(ctx, workspaceDir, bareRepoPath, project.Name, branch, disp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMissingRemotes adds the remotes of a manifest project the repository doesn't have, and
+// fetches their branches
+func addMissingRemotes(ctx context.Context, repoPath string, project manifest.Project, disp display.Printer) error {
+	remotes, err := git.ListRemotes(repoPath)
+	if err != nil {
+		return err
+	}
+
+	added := false
+	for _, remote := range project.OtherRemotes() {
+		if slices.Contains(remotes, remote) {
+			continue
+		}
+		disp.Printf("  %s Adding remote %s\n", disp.Faint("→"), remote)
+		if err := git.AddRemote(repoPath, remote, project.Remotes[remote]); err != nil {
+			return err
+		}
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	return eris.Wrap(git.Fetch(ctx, repoPath), "failed to fetch the added remotes")
+}
+
+// bootstrapWorktree creates the worktree of a branch, unless it exists or the branch no longer does
+func bootstrapWorktree(
+	ctx context.Context,
+	workspaceDir, repoPath, projectName, branch string,
+	disp display.Printer,
+) error {
+	worktrees, err := git.ListWorktrees(ctx, repoPath)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(worktrees, func(wt git.WorktreeInfo) bool { return wt.Branch == branch }) {
+		return nil
+	}
+
+	source, err := git.GetBranchSource(repoPath, config.DefaultRemote, branch)
+	if err != nil {
+		return err
+	}
+	if source == git.BranchNew {
+		disp.Warningf("%s: branch %s isn't on origin, skipping its worktree", projectName, branch)
+		return nil
+	}
+	worktreePath := workspace.ProjectWorktreePath(workspaceDir, projectName, branch)
+	disp.Printf("  %s Creating worktree for branch %s\n", disp.Faint("→"), disp.Bold(branch))
+	err = git.CreateWorktreeForBranch(ctx, repoPath, config.DefaultRemote, branch, worktreePath, source)
+	recordOperation("create-worktree", projectName, branch, err)
+	if err != nil {
+		return err
+	}
+	prepare.UpdateSubmodules(ctx, worktreePath, disp)
+	prepare.PullLFS(ctx, worktreePath, disp)
+	return nil
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/benoctopus/sesh/internal/browse"
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	browseProjectName string
+	browsePR          bool
+	browseRepo        bool
+	browsePrint       bool
+)
+
+var browseCmd = &cobra.Command{
+	Use:   "browse [branch]",
+	Short: "Open a branch or its pull request in the browser",
+	Long: `Open the web page of a branch, its pull request, or the repository in the
+default browser. The URL is built from the project's remote URL and supports
+GitHub, GitLab, and Bitbucket, including self-hosted instances.
+
+Without a branch, the branch of the current worktree is used, and the repository
+page is opened outside a worktree.
+
+With --pr, the branch's open pull request is opened (GitHub, through the gh CLI).
+When the branch has none, the page to open a new pull request is opened instead.
+
+Examples:
+  sesh browse                    # Current branch
+  sesh browse feature-foo        # Another branch
+  sesh browse --pr               # Pull request of the current branch
+  sesh browse --repo             # Repository home page
+  sesh browse --print            # Print the URL instead of opening it`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeBranches,
+	RunE:              runBrowse,
+}
+
+func init() {
+	rootCmd.AddCommand(browseCmd)
+	browseCmd.Flags().
+		StringVarP(&browseProjectName, "project", "p", "", "Specify project explicitly")
+	browseCmd.Flags().
+		BoolVar(&browsePR, "pr", false, "Open the branch's pull request")
+	browseCmd.Flags().
+		BoolVar(&browseRepo, "repo", false, "Open the repository home page")
+	browseCmd.Flags().
+		BoolVar(&browsePrint, "print", false, "Print the URL instead of opening it")
+	browseCmd.MarkFlagsMutuallyExclusive("pr", "repo")
+}
+
+func runBrowse(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return eris.Wrap(err, "failed to get current working directory")
+	}
+
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, browseProjectName, cwd)
+	if err != nil {
+		return eris.Wrap(err, "failed to resolve project")
+	}
+
+	remoteURL, err := git.GetRemoteURL(proj.LocalPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to get remote URL")
+	}
+	repo, err := browse.ParseRemote(remoteURL)
+	if err != nil {
+		return err
+	}
+
+	branch := ""
+	if len(args) > 0 {
+		branch = args[0]
+	} else if projectName, current, ok := findCurrentWorktree(cwd); ok && projectName == proj.Name {
+		branch = current
+	}
+
+	target := repo.URL
+	switch {
+	case browseRepo:
+		// The repository page is already the target
+	case branch == "" && browsePR:
+		return eris.New("branch argument required outside a worktree (usage: sesh browse --pr <branch>)")
+	case branch == "":
+		// Outside a worktree, fall back to the repository page
+	case browsePR:
+		target, err = pullRequestURL(cmd, proj, repo, branch)
+		if err != nil {
+			disp.Warningf("failed to list pull requests: %v", err)
+		}
+	default:
+		target = repo.BranchURL(branch)
+	}
+
+	if browsePrint {
+		fmt.Println(target)
+		return nil
+	}
+
+	disp.Printf("%s Opening %s\n", disp.InfoText("→"), target)
+	return browse.Open(target)
+}
+
+// pullRequestURL returns the URL of the branch's open pull request, or of the page to open one
+// Only GitHub pull requests can be looked up, so other hosts always get the new pull request page.
+func pullRequestURL(cmd *cobra.Command, proj *models.Project, repo *browse.Repo, branch string) (string, error) {
+	newURL := repo.NewPullRequestURL(branch)
+	if repo.Host != browse.HostGitHub {
+		return newURL, nil
+	}
+
+	prs, err := listOpenPRs(cmd.Context(), proj)
+	if err != nil {
+		return newURL, err
+	}
+	for _, pullRequest := range prs {
+		if pullRequest.Branch == branch {
+			return pullRequest.URL, nil
+		}
+	}
+	return newURL, nil
+}
@@ -2,16 +2,21 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
 	"github.com/benoctopus/sesh/internal/fuzzy"
 	"github.com/benoctopus/sesh/internal/git"
 	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/benoctopus/sesh/internal/prepare"
 	"github.com/benoctopus/sesh/internal/project"
 	"github.com/benoctopus/sesh/internal/session"
 	"github.com/benoctopus/sesh/internal/state"
@@ -22,10 +27,16 @@ import (
 )
 
 var (
-	cleanOrphaned      bool
-	cleanRemoteDeleted bool
-	cleanForce         bool
-	cleanProjectName   string
+	cleanOrphaned       bool
+	cleanRemoteDeleted  bool
+	cleanMerged         bool
+	cleanForce          bool
+	cleanDryRun         bool
+	cleanDiscardChanges bool
+	cleanDeleteBranch   bool
+	cleanDeleteRemote   bool
+	cleanProjectName    string
+	cleanIdle           string
 )
 
 var cleanCmd = &cobra.Command{
@@ -35,10 +46,21 @@ var cleanCmd = &cobra.Command{
 
 By default, presents a multi-select interface to choose which worktrees/sessions to delete.
 
+Worktrees with uncommitted changes or unpushed commits are skipped unless
+--discard-changes is given. Removed worktrees are moved to the trash and can
+be restored with 'sesh undo' or 'sesh trash restore'.
+
 Options:
   --orphaned         Delete worktrees that don't have active sessions
+  --idle[=duration]  Kill sessions no client has been attached to for session_idle_ttl,
+                     or the given duration; their worktrees are kept (tmux only)
   --remote-deleted   Delete local worktrees for branches that have been deleted on the remote
+  --merged           Delete worktrees for branches merged into the default branch (or with merged PRs)
   --force            Skip confirmation prompts
+  --dry-run          Print what would be removed without deleting anything
+  --discard-changes  Remove worktrees even if they have uncommitted changes or unpushed commits
+  --delete-branch    Also delete the local branch of each removed worktree
+  --delete-remote-branch  Also delete the branch on its upstream remote
 
 The project is automatically detected from the current working directory,
 or can be specified explicitly with the --project flag.
@@ -47,7 +69,11 @@ Examples:
   sesh clean                           # Interactive multi-select to delete worktrees
   sesh clean --orphaned                # Delete worktrees without active sessions
   sesh clean --remote-deleted          # Delete local worktrees for remote-deleted branches
+  sesh clean --merged                  # Delete worktrees for merged branches
   sesh clean --orphaned --force        # Delete orphaned worktrees without confirmation
+  sesh clean --idle=48h                # Kill sessions not attached to for two days
+  sesh clean --merged --dry-run        # Show what would be removed for merged branches
+  sesh clean --merged --delete-branch  # Also delete the merged local branches
   sesh clean --project myproject       # Clean specific project`,
 	RunE: runClean,
 }
@@ -57,8 +83,35 @@ func init() {
 	cleanCmd.Flags().BoolVar(&cleanOrphaned, "orphaned", false, "Delete worktrees without active sessions")
 	cleanCmd.Flags().
 		BoolVar(&cleanRemoteDeleted, "remote-deleted", false, "Delete local worktrees for remote-deleted branches")
+	cleanCmd.Flags().
+		BoolVar(&cleanMerged, "merged", false, "Delete worktrees for branches merged into the default branch")
 	cleanCmd.Flags().BoolVarP(&cleanForce, "force", "f", false, "Skip confirmation prompts")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "Print what would be removed without deleting anything")
+	cleanCmd.Flags().
+		BoolVar(&cleanDiscardChanges, "discard-changes", false, "Remove worktrees even if they have local changes")
+	cleanCmd.Flags().BoolVar(&cleanDeleteBranch, "delete-branch", false, "Also delete the local branch")
+	cleanCmd.Flags().
+		BoolVar(&cleanDeleteRemote, "delete-remote-branch", false, "Also delete the branch on its upstream remote")
 	cleanCmd.Flags().StringVarP(&cleanProjectName, "project", "p", "", "Specify project explicitly")
+	cleanCmd.Flags().
+		StringVar(&cleanIdle, "idle", "", "Kill sessions not attached to for session_idle_ttl, or the given duration")
+	cleanCmd.Flags().Lookup("idle").NoOptDefVal = "default"
+}
+
+// removeOptions controls what is removed along with a worktree
+type removeOptions struct {
+	discardChanges     bool // Remove even with uncommitted changes or unpushed commits
+	deleteBranch       bool // Delete the local branch ref
+	deleteRemoteBranch bool // Delete the branch on the remote it tracks
+}
+
+// cleanRemoveOptions builds the remove options from the clean command flags
+func cleanRemoveOptions() removeOptions {
+	return removeOptions{
+		discardChanges:     cleanDiscardChanges,
+		deleteBranch:       cleanDeleteBranch,
+		deleteRemoteBranch: cleanDeleteRemote,
+	}
 }
 
 func runClean(cmd *cobra.Command, args []string) error {
@@ -89,20 +142,32 @@ func runClean(cmd *cobra.Command, args []string) error {
 	}
 
 	// Handle different clean modes
+	if cleanIdle != "" {
+		return cleanIdleSessions(cfg, proj, sessionMgr, disp)
+	}
+
 	if cleanOrphaned {
-		return cleanOrphanedWorktrees(cfg, proj, sessionMgr, disp)
+		return cleanOrphanedWorktrees(cmd.Context(), cfg, proj, sessionMgr, disp)
 	}
 
 	if cleanRemoteDeleted {
-		return cleanRemoteDeletedBranches(cfg, proj, sessionMgr, disp)
+		if isOffline() {
+			return eris.New("can't check which branches were deleted from the remote offline")
+		}
+		return cleanRemoteDeletedBranches(cmd.Context(), cfg, proj, sessionMgr, disp)
+	}
+
+	if cleanMerged {
+		return cleanMergedBranches(cmd.Context(), cfg, proj, sessionMgr, disp)
 	}
 
 	// Default: interactive multi-select
-	return cleanInteractive(cfg, proj, sessionMgr, disp)
+	return cleanInteractive(cmd.Context(), cfg, proj, sessionMgr, disp)
 }
 
 // cleanInteractive presents a multi-select interface to choose worktrees to delete
 func cleanInteractive(
+	ctx context.Context,
 	cfg *config.Config,
 	proj *models.Project,
 	sessionMgr session.SessionManager,
@@ -150,7 +215,9 @@ func cleanInteractive(
 
 	// In noninteractive mode, fuzzy finder won't work - require specific flags
 	if !tty.IsInteractive() {
-		return eris.New("interactive mode required for default clean (use --orphaned or --remote-deleted in noninteractive mode)")
+		return eris.New(
+			"interactive mode required for default clean (use --orphaned, --remote-deleted or --merged in noninteractive mode)",
+		)
 	}
 
 	// Present multi-select interface
@@ -186,6 +253,11 @@ func cleanInteractive(
 		}
 	}
 
+	// In dry-run mode, only report what would be removed
+	if cleanDryRun {
+		return printCleanPlan(cfg, proj, toDelete, sessionMgr, cleanRemoveOptions(), display.NewStdout())
+	}
+
 	// Confirm deletion
 	if !cleanForce {
 		disp.Printf("\nThis will delete %d worktree(s) and their associated sessions:\n", len(toDelete))
@@ -208,17 +280,20 @@ func cleanInteractive(
 	}
 
 	// Delete selected worktrees
+	deletedCount := 0
 	for _, wt := range toDelete {
-		if err := deleteWorktreeAndSession(cfg, proj, wt, sessionMgr, disp); err != nil {
-			disp.Printf("Warning: failed to delete worktree %s: %v\n", wt.Branch, err)
+		if err := deleteWorktreeAndSession(ctx, cfg, proj, wt, sessionMgr, cleanRemoveOptions(), disp); err != nil {
+			slog.Warn("failed to delete worktree", "branch", wt.Branch, "error", err)
+			continue
 		}
+		deletedCount++
 	}
 
-	disp.Printf("\nSuccessfully deleted %d worktree(s).\n", len(toDelete))
+	disp.Printf("\nSuccessfully deleted %d worktree(s).\n", deletedCount)
 
 	// Also clean up any orphaned sessions
 	if err := cleanOrphanedSessions(proj, sessionMgr, disp); err != nil {
-		disp.Printf("Warning: failed to clean orphaned sessions: %v\n", err)
+		slog.Warn("failed to clean orphaned sessions", "project", proj.Name, "error", err)
 	}
 
 	return nil
@@ -226,6 +301,7 @@ func cleanInteractive(
 
 // cleanOrphanedWorktrees deletes worktrees that don't have active sessions
 func cleanOrphanedWorktrees(
+	ctx context.Context,
 	cfg *config.Config,
 	proj *models.Project,
 	sessionMgr session.SessionManager,
@@ -247,7 +323,7 @@ func cleanOrphanedWorktrees(
 		sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
 		hasSession, err := sessionMgr.Exists(sessionName)
 		if err != nil {
-			disp.Printf("Warning: failed to check session for %s: %v\n", wt.Branch, err)
+			slog.Warn("failed to check session", "branch", wt.Branch, "error", err)
 			continue
 		}
 
@@ -267,6 +343,11 @@ func cleanOrphanedWorktrees(
 		disp.Printf("  - %s (%s)\n", wt.Branch, wt.Path)
 	}
 
+	// In dry-run mode, only report what would be removed
+	if cleanDryRun {
+		return printCleanPlan(cfg, proj, orphaned, sessionMgr, cleanRemoveOptions(), display.NewStdout())
+	}
+
 	// In noninteractive mode, require --force flag
 	if !cleanForce {
 		if !tty.IsInteractive() {
@@ -290,24 +371,99 @@ func cleanOrphanedWorktrees(
 	}
 
 	// Delete orphaned worktrees
+	deletedCount := 0
 	for _, wt := range orphaned {
-		if err := deleteWorktreeAndSession(cfg, proj, wt, sessionMgr, disp); err != nil {
-			disp.Printf("Warning: failed to delete worktree %s: %v\n", wt.Branch, err)
+		if err := deleteWorktreeAndSession(ctx, cfg, proj, wt, sessionMgr, cleanRemoveOptions(), disp); err != nil {
+			slog.Warn("failed to delete worktree", "branch", wt.Branch, "error", err)
+			continue
 		}
+		deletedCount++
 	}
 
-	disp.Printf("\nSuccessfully deleted %d orphaned worktree(s).\n", len(orphaned))
+	disp.Printf("\nSuccessfully deleted %d orphaned worktree(s).\n", deletedCount)
 
 	// Also clean up any orphaned sessions
 	if err := cleanOrphanedSessions(proj, sessionMgr, disp); err != nil {
-		disp.Printf("Warning: failed to clean orphaned sessions: %v\n", err)
+		slog.Warn("failed to clean orphaned sessions", "project", proj.Name, "error", err)
 	}
 
 	return nil
 }
 
+// cleanIdleSessions kills the sessions of the project that no client has been attached to for
+// the idle time of --idle, keeping their worktrees
+func cleanIdleSessions(
+	cfg *config.Config,
+	proj *models.Project,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) error {
+	ttl := cfg.SessionIdleTTL
+	if cleanIdle != "default" {
+		var err error
+		if ttl, err = time.ParseDuration(cleanIdle); err != nil || ttl <= 0 {
+			return eris.Errorf("invalid --idle: %s (must be a positive duration like 12h)", cleanIdle)
+		}
+	} else if ttl <= 0 {
+		return eris.New("no idle time set: set session_idle_ttl, or use --idle=<duration>")
+	}
+
+	idle, err := findIdleSessions([]*models.Project{proj}, sessionMgr, ttl)
+	if err != nil {
+		return err
+	}
+	if len(idle) == 0 {
+		disp.Printf("No sessions idle for over %s found.\n", ttl)
+		return nil
+	}
+
+	disp.Printf("Found %d session(s) idle for over %s:\n", len(idle), ttl)
+	for _, s := range idle {
+		disp.Printf("  - %s (last used %s)\n", s.name, formatTimeAgo(s.lastAttached))
+	}
+
+	// In dry-run mode, only report what would be killed
+	if cleanDryRun {
+		printIdlePlan(idle, display.NewStdout())
+		return nil
+	}
+
+	// In noninteractive mode, require --force flag
+	if !cleanForce {
+		if !tty.IsInteractive() {
+			return eris.New("--force flag required for deletion in noninteractive mode")
+		}
+
+		disp.Print("\nKill these sessions? Their worktrees are kept. (yes/no): ")
+		response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return eris.Wrap(err, "failed to read confirmation")
+		}
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" && response != "y" {
+			disp.Println("Cleanup cancelled.")
+			return nil
+		}
+	}
+
+	killed := 0
+	for _, s := range idle {
+		err := killSession(sessionMgr, s.name)
+		recordOperation("kill-idle-session", proj.Name, s.name, err)
+		if err != nil {
+			slog.Warn("failed to kill session", "session", s.name, "error", err)
+			continue
+		}
+		killed++
+	}
+
+	disp.Printf("\nSuccessfully killed %d idle session(s).\n", killed)
+	return nil
+}
+
 // cleanRemoteDeletedBranches deletes local worktrees for branches that have been deleted on the remote
 func cleanRemoteDeletedBranches(
+	ctx context.Context,
 	cfg *config.Config,
 	proj *models.Project,
 	sessionMgr session.SessionManager,
@@ -321,7 +477,16 @@ func cleanRemoteDeletedBranches(
 
 	// Get all branches that actually exist on the remote server
 	disp.Println("Checking remote branches...")
-	remoteBranches, err := git.ListActualRemoteBranches(proj.LocalPath)
+
+	// Fetching also prunes the remote-tracking branches of deleted branches (unless fetch_prune
+	// is off), so the branch switcher stops offering them
+	if err := git.Fetch(ctx, proj.LocalPath); err != nil {
+		slog.Warn("fetch failed", "project", proj.Name, "error", err)
+	} else {
+		markProjectFetched(proj)
+	}
+
+	remoteBranches, err := git.ListActualRemoteBranches(ctx, proj.LocalPath, cfg.Remote.For(proj.Name))
 	if err != nil {
 		return eris.Wrap(err, "failed to list remote branches")
 	}
@@ -356,6 +521,11 @@ func cleanRemoteDeletedBranches(
 		disp.Printf("  - %s (%s)\n", wt.Branch, wt.Path)
 	}
 
+	// In dry-run mode, only report what would be removed
+	if cleanDryRun {
+		return printCleanPlan(cfg, proj, deleted, sessionMgr, cleanRemoveOptions(), display.NewStdout())
+	}
+
 	// In noninteractive mode, require --force flag
 	if !cleanForce {
 		if !tty.IsInteractive() {
@@ -379,49 +549,425 @@ func cleanRemoteDeletedBranches(
 	}
 
 	// Delete worktrees for remote-deleted branches
+	deletedCount := 0
 	for _, wt := range deleted {
-		if err := deleteWorktreeAndSession(cfg, proj, wt, sessionMgr, disp); err != nil {
-			disp.Printf("Warning: failed to delete worktree %s: %v\n", wt.Branch, err)
+		if err := deleteWorktreeAndSession(ctx, cfg, proj, wt, sessionMgr, cleanRemoveOptions(), disp); err != nil {
+			slog.Warn("failed to delete worktree", "branch", wt.Branch, "error", err)
+			continue
 		}
+		deletedCount++
 	}
 
-	disp.Printf("\nSuccessfully deleted %d worktree(s) for remote-deleted branches.\n", len(deleted))
+	disp.Printf("\nSuccessfully deleted %d worktree(s) for remote-deleted branches.\n", deletedCount)
 
 	// Also clean up any orphaned sessions
 	if err := cleanOrphanedSessions(proj, sessionMgr, disp); err != nil {
-		disp.Printf("Warning: failed to clean orphaned sessions: %v\n", err)
+		slog.Warn("failed to clean orphaned sessions", "project", proj.Name, "error", err)
 	}
 
 	return nil
 }
 
-// deleteWorktreeAndSession deletes a worktree and its associated session
+// cleanMergedBranches deletes worktrees for branches that have been merged into the default branch
+// A branch also counts as merged when its pull request was merged (e.g. squash or rebase merges),
+// which is checked on a best-effort basis when a PR provider is available
+func cleanMergedBranches(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) error {
+	// Get all local worktrees
+	worktrees, err := state.DiscoverWorktrees(proj)
+	if err != nil {
+		return eris.Wrap(err, "failed to discover worktrees")
+	}
+
+	defaultBranch, err := git.GetDefaultBranch(proj.LocalPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to determine default branch")
+	}
+
+	disp.Printf("Checking branches merged into %s...\n", defaultBranch)
+	mergedBranches, err := git.ListMergedBranches(proj.LocalPath, cfg.Remote.For(proj.Name), defaultBranch)
+	if err != nil {
+		return eris.Wrap(err, "failed to list merged branches")
+	}
+
+	// Build a set of merged branches for fast lookup
+	mergedSet := make(map[string]string)
+	for _, branch := range mergedBranches {
+		mergedSet[branch] = "merged into " + defaultBranch
+	}
+
+	// Squash and rebase merges don't show up in the git history, so also ask the PR provider
+	for branch, reason := range listMergedPRBranches(ctx, proj, worktrees) {
+		if _, ok := mergedSet[branch]; !ok {
+			mergedSet[branch] = reason
+		}
+	}
+
+	// Find worktrees whose branches have been merged
+	var merged []*models.Worktree
+	for _, wt := range worktrees {
+		if wt.IsMain || wt.Branch == defaultBranch {
+			continue
+		}
+
+		if _, ok := mergedSet[wt.Branch]; ok {
+			merged = append(merged, wt)
+		}
+	}
+
+	if len(merged) == 0 {
+		disp.Println("No worktrees found for merged branches.")
+		return nil
+	}
+
+	// Show worktrees for merged branches
+	disp.Printf("Found %d worktree(s) for merged branches:\n", len(merged))
+	for _, wt := range merged {
+		disp.Printf("  - %s (%s)\n", wt.Branch, mergedSet[wt.Branch])
+	}
+
+	// In dry-run mode, only report what would be removed
+	if cleanDryRun {
+		return printCleanPlan(cfg, proj, merged, sessionMgr, cleanRemoveOptions(), display.NewStdout())
+	}
+
+	// In noninteractive mode, require --force flag
+	if !cleanForce {
+		if !tty.IsInteractive() {
+			return eris.New("--force flag required for deletion in noninteractive mode")
+		}
+
+		// Ask for confirmation in interactive mode
+		disp.Print("\nDelete these worktrees? (yes/no): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return eris.Wrap(err, "failed to read confirmation")
+		}
+
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" && response != "y" {
+			disp.Println("Cleanup cancelled.")
+			return nil
+		}
+	}
+
+	// Delete worktrees for merged branches
+	deletedCount := 0
+	for _, wt := range merged {
+		if err := deleteWorktreeAndSession(ctx, cfg, proj, wt, sessionMgr, cleanRemoveOptions(), disp); err != nil {
+			slog.Warn("failed to delete worktree", "branch", wt.Branch, "error", err)
+			continue
+		}
+		deletedCount++
+	}
+
+	disp.Printf("\nSuccessfully deleted %d worktree(s) for merged branches.\n", deletedCount)
+
+	// Also clean up any orphaned sessions
+	if err := cleanOrphanedSessions(proj, sessionMgr, disp); err != nil {
+		slog.Warn("failed to clean orphaned sessions", "project", proj.Name, "error", err)
+	}
+
+	return nil
+}
+
+// listMergedPRBranches returns the branches of worktrees that were the head of a merged pull
+// request, mapped to a short reason. A branch only counts when it has no commits beyond the head
+// of the pull request, since another pull request may have reused its name, or work may have
+// continued on it after the merge.
+// Errors are ignored since PR detection is optional (no provider, gh not installed, etc.)
+func listMergedPRBranches(ctx context.Context, proj *models.Project, worktrees []*models.Worktree) map[string]string {
+	result := make(map[string]string)
+
+	remoteURL, err := git.GetRemoteURL(proj.LocalPath)
+	if err != nil {
+		return result
+	}
+
+	provider, err := pr.NewProvider(remoteURL)
+	if err != nil {
+		return result
+	}
+
+	if provider.Name() == "github" {
+		if err := pr.CheckGHCLI(remoteURL); err != nil {
+			return result
+		}
+	}
+
+	prs, err := provider.ListMergedPRs(ctx, proj.LocalPath)
+	if err != nil {
+		return result
+	}
+
+	branches := make(map[string]bool, len(worktrees))
+	for _, wt := range worktrees {
+		branches[wt.Branch] = true
+	}
+	for _, p := range prs {
+		if _, ok := result[p.Branch]; ok || !branches[p.Branch] || p.HeadSHA == "" {
+			continue
+		}
+		if git.IsBranchInCommit(proj.LocalPath, p.Branch, p.HeadSHA) {
+			result[p.Branch] = fmt.Sprintf("PR #%d merged", p.Number)
+		}
+	}
+
+	return result
+}
+
+// deleteWorktreeAndSession deletes a worktree and its associated session, and optionally its branches
+// Worktrees with uncommitted changes or unpushed commits are refused unless changes are discarded
 func deleteWorktreeAndSession(
+	ctx context.Context,
 	cfg *config.Config,
 	proj *models.Project,
 	wt *models.Worktree,
 	sessionMgr session.SessionManager,
+	opts removeOptions,
 	disp display.Printer,
 ) error {
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Refuse before touching the session so nothing is lost
+	if err := ensureNoLocalChanges(wt, opts.discardChanges, disp); err != nil {
+		recordOperation("remove-worktree", proj.Name, wt.Branch, err)
+		return err
+	}
+
 	// Generate session name
 	sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
 
 	// Kill session if it exists
 	exists, err := sessionMgr.Exists(sessionName)
 	if err != nil {
-		disp.Printf("Warning: failed to check session existence for %s: %v\n", wt.Branch, err)
+		slog.Warn("failed to check session existence", "branch", wt.Branch, "error", err)
 	} else if exists {
 		disp.Printf("Killing %s session: %s\n", sessionMgr.Name(), sessionName)
-		if err := sessionMgr.Delete(sessionName); err != nil {
-			disp.Printf("Warning: failed to kill session: %v\n", err)
+		if err := killSession(sessionMgr, sessionName); err != nil {
+			slog.Warn("failed to kill session", "session", sessionName, "error", err)
 		}
 	}
 
 	// Remove worktree
 	disp.Printf("Removing worktree: %s\n", wt.Path)
+	err = removeWorktree(cfg, proj, wt, opts.discardChanges, disp)
+	recordOperation("remove-worktree", proj.Name, wt.Branch, err)
+	if err != nil {
+		return err
+	}
+
+	deleteBranches(ctx, cfg, proj, wt.Branch, opts, disp)
+	return nil
+}
+
+// deleteBranches deletes the local and/or remote branch after its worktree was removed
+// Failures are reported as warnings since the worktree itself is already gone
+func deleteBranches(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	branch string,
+	opts removeOptions,
+	disp display.Printer,
+) {
+	if !opts.deleteBranch && !opts.deleteRemoteBranch {
+		return
+	}
+
+	// Never delete the default branch
+	if defaultBranch, err := git.GetDefaultBranch(proj.LocalPath); err == nil && branch == defaultBranch {
+		slog.Warn("not deleting default branch", "branch", branch)
+		return
+	}
+
+	// The remote is looked up first, since it is forgotten with the local branch
+	remote := branchRemote(cfg, proj, branch)
+
+	if opts.deleteBranch {
+		disp.Printf("Deleting branch: %s\n", branch)
+		err := git.DeleteBranch(proj.LocalPath, branch)
+		recordOperation("delete-branch", proj.Name, branch, err)
+		if err != nil {
+			slog.Warn("failed to delete branch", "branch", branch, "error", err)
+		}
+	}
+
+	if opts.deleteRemoteBranch {
+		disp.Printf("Deleting remote branch: %s/%s\n", remote, branch)
+		err := git.DeleteRemoteBranch(ctx, proj.LocalPath, remote, branch)
+		recordOperation("delete-remote-branch", proj.Name, branch, err)
+		if err != nil {
+			slog.Warn("failed to delete remote branch", "branch", branch, "error", err)
+		}
+	}
+}
+
+// branchRemote returns the remote a branch is deleted on: the remote it tracks, or else the
+// project's primary remote
+func branchRemote(cfg *config.Config, proj *models.Project, branch string) string {
+	if remote := git.GetUpstreamRemote(proj.LocalPath, branch); remote != "" {
+		return remote
+	}
+	return cfg.Remote.For(proj.Name)
+}
+
+// removeWorktree kills the sessions of the sub-projects of a worktree, stops its compose
+// services, and removes it from the bare repository
+// Clean worktrees are recycled when the project's pool has room. Otherwise, when the trash is
+// enabled the worktree is moved there so it can be restored with "sesh undo", or else it is
+// deleted, forcing removal when local changes are being discarded
+func removeWorktree(
+	cfg *config.Config,
+	proj *models.Project,
+	wt *models.Worktree,
+	discard bool,
+	disp display.Printer,
+) error {
+	killSubprojectSessions(cfg, proj, wt, disp)
+	stopCompose(workspace.GenerateSessionName(proj.Name, wt.Branch), wt.Path, disp)
+
+	var err error
+	switch {
+	case recycleWorktree(cfg, proj, wt, disp):
+	case cfg.TrashRetentionDays > 0:
+		err = trashWorktree(cfg, proj, wt, disp)
+		if errors.Is(err, errTrashOtherFilesystem) {
+			slog.Warn("worktree is on another filesystem than the trash, deleting it instead", "path", wt.Path)
+			err = deleteWorktree(proj, wt, discard)
+		}
+	default:
+		err = deleteWorktree(proj, wt, discard)
+	}
+	if err != nil {
+		return err
+	}
+
+	zoxideRemove(cfg, wt.Path, disp)
+	releasePorts(proj.Name, wt.Branch)
+	return nil
+}
+
+// deleteWorktree permanently deletes a worktree, bypassing the trash
+func deleteWorktree(proj *models.Project, wt *models.Worktree, discard bool) error {
+	if discard {
+		if err := git.RemoveWorktreeForce(proj.LocalPath, wt.Path); err != nil {
+			return eris.Wrap(err, "failed to remove worktree")
+		}
+		return nil
+	}
+
 	if err := git.RemoveWorktree(proj.LocalPath, wt.Path); err != nil {
 		return eris.Wrap(err, "failed to remove worktree")
 	}
+	return nil
+}
+
+// ensureNoLocalChanges returns an error if the worktree has uncommitted changes or unpushed
+// commits, after summarizing what would be lost. With discard set, it only warns.
+func ensureNoLocalChanges(wt *models.Worktree, discard bool, disp display.Printer) error {
+	changes, err := git.GetLocalChanges(wt.Path)
+	if err != nil {
+		if discard {
+			return nil
+		}
+		return eris.Wrapf(
+			err,
+			"failed to check %s for local changes (use --discard-changes to remove it anyway)",
+			wt.Branch,
+		)
+	}
+
+	if !changes.HasChanges() {
+		return nil
+	}
+
+	printLocalChanges(wt, changes, disp)
+
+	if discard {
+		disp.Warningf("Discarding %s in %s", changes.Summary(), wt.Branch)
+		return nil
+	}
+
+	return eris.Errorf(
+		"worktree %s has %s (use --discard-changes to remove it anyway)",
+		wt.Branch,
+		changes.Summary(),
+	)
+}
+
+// printLocalChanges summarizes the uncommitted files and unpushed commits of a worktree
+func printLocalChanges(wt *models.Worktree, changes *git.LocalChanges, disp display.Printer) {
+	const maxItems = 5
+
+	disp.Printf("%s %s has %s:\n", disp.WarningText("⚠"), disp.Bold(wt.Branch), changes.Summary())
+	for i, file := range changes.UncommittedFiles {
+		if i == maxItems {
+			disp.Printf("    ... and %d more file(s)\n", len(changes.UncommittedFiles)-maxItems)
+			break
+		}
+		disp.Printf("    %s\n", file)
+	}
+	for i, commit := range changes.UnpushedCommits {
+		if i == maxItems {
+			disp.Printf("    ... and %d more commit(s)\n", len(changes.UnpushedCommits)-maxItems)
+			break
+		}
+		disp.Printf("    %s\n", commit)
+	}
+}
+
+// printCleanPlan prints the worktrees, sessions, and branches a clean would remove without touching anything
+// The plan is written to out, stdout for the clean command, one item per line, so it can be consumed by scripts
+func printCleanPlan(
+	cfg *config.Config,
+	proj *models.Project,
+	worktrees []*models.Worktree,
+	sessionMgr session.SessionManager,
+	opts removeOptions,
+	out display.Printer,
+) error {
+	for _, wt := range worktrees {
+		if !opts.discardChanges {
+			if changes, err := git.GetLocalChanges(wt.Path); err == nil && changes.HasChanges() {
+				out.Printf("would skip worktree with local changes: %s (%s)\n", wt.Branch, changes.Summary())
+				continue
+			}
+		}
+
+		out.Printf("would remove worktree: %s (%s)\n", wt.Branch, wt.Path)
+
+		sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
+		if exists, err := sessionMgr.Exists(sessionName); err == nil && exists {
+			out.Printf("would kill session: %s\n", sessionName)
+		}
+
+		if opts.deleteBranch {
+			out.Printf("would delete branch: %s\n", wt.Branch)
+		}
+		if opts.deleteRemoteBranch {
+			out.Printf("would delete remote branch: %s/%s\n", branchRemote(cfg, proj, wt.Branch), wt.Branch)
+		}
+	}
+
+	orphanedSessions, err := findOrphanedSessions(proj, sessionMgr)
+	if err != nil {
+		return eris.Wrap(err, "failed to find orphaned sessions")
+	}
+	for _, sessionName := range orphanedSessions {
+		out.Printf("would kill orphaned session: %s\n", sessionName)
+	}
 
 	return nil
 }
@@ -432,32 +978,71 @@ func cleanOrphanedSessions(
 	sessionMgr session.SessionManager,
 	disp display.Printer,
 ) error {
+	orphanedSessions, err := findOrphanedSessions(proj, sessionMgr)
+	if err != nil {
+		return err
+	}
+
+	if len(orphanedSessions) == 0 {
+		return nil
+	}
+
+	// Delete orphaned sessions
+	disp.Printf("Found %d orphaned session(s) without worktrees:\n", len(orphanedSessions))
+	for _, sessionName := range orphanedSessions {
+		disp.Printf("  Killing session: %s\n", sessionName)
+		err := killSession(sessionMgr, sessionName)
+		recordOperation("kill-orphaned-session", proj.Name, sessionName, err)
+		if err != nil {
+			slog.Warn("failed to kill session", "session", sessionName, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// printIdlePlan prints the idle sessions a clean would kill, in the format of printCleanPlan
+func printIdlePlan(idle []idleSession, out display.Printer) {
+	for _, s := range idle {
+		out.Printf("would kill idle session: %s\n", s.name)
+	}
+}
+
+// findOrphanedSessions returns sessions of this project whose worktree no longer exists
+func findOrphanedSessions(proj *models.Project, sessionMgr session.SessionManager) ([]string, error) {
 	// Get all existing worktrees
 	worktrees, err := state.DiscoverWorktrees(proj)
 	if err != nil {
-		return eris.Wrap(err, "failed to discover worktrees")
+		return nil, eris.Wrap(err, "failed to discover worktrees")
 	}
 
 	// Build a set of existing branches for fast lookup
 	existingBranches := make(map[string]bool)
+	subprojectSessions := make(map[string]bool)
 	for _, wt := range worktrees {
 		existingBranches[wt.Branch] = true
+		for _, name := range subprojectSessionNames(proj.Name, wt) {
+			subprojectSessions[name] = true
+		}
+		// The session of a renamed branch keeps the old name until it is renamed to match
+		if oldName := renamedFrom(proj, wt); oldName != "" {
+			existingBranches[oldName] = true
+		}
 	}
 
 	// Get all active sessions
 	sessions, err := sessionMgr.List()
 	if err != nil {
-		return eris.Wrap(err, "failed to list sessions")
+		return nil, eris.Wrap(err, "failed to list sessions")
 	}
 
 	// Find orphaned sessions (sessions for this project where worktree doesn't exist)
-	repoName := filepath.Base(proj.Name)
-	prefix := repoName + "-"
+	prefix := workspace.SessionPrefix(proj.Name) + "-"
 
 	var orphanedSessions []string
 	for _, sessionName := range sessions {
 		// Check if this session belongs to this project
-		if !strings.HasPrefix(sessionName, prefix) {
+		if !strings.HasPrefix(sessionName, prefix) || subprojectSessions[sessionName] {
 			continue
 		}
 
@@ -483,18 +1068,21 @@ func cleanOrphanedSessions(
 		}
 	}
 
-	if len(orphanedSessions) == 0 {
-		return nil
-	}
+	return orphanedSessions, nil
+}
 
-	// Delete orphaned sessions
-	disp.Printf("Found %d orphaned session(s) without worktrees:\n", len(orphanedSessions))
-	for _, sessionName := range orphanedSessions {
-		disp.Printf("  Killing session: %s\n", sessionName)
-		if err := sessionMgr.Delete(sessionName); err != nil {
-			disp.Printf("Warning: failed to kill session %s: %v\n", sessionName, err)
+// killSession stops the processes of a session, giving them the chance to shut down cleanly,
+// and kills the session. The logs of the processes go with it.
+func killSession(sessionMgr session.SessionManager, sessionName string) error {
+	if supervisor, ok := sessionMgr.(session.ProcessSupervisor); ok {
+		if err := supervisor.StopProcesses(sessionName); err != nil {
+			slog.Warn("failed to stop processes", "session", sessionName, "error", err)
 		}
 	}
-
-	return nil
+	if logDir, err := prepare.ProcessLogDir(sessionName); err == nil {
+		if err := os.RemoveAll(logDir); err != nil {
+			slog.Debug("failed to remove process logs", "path", logDir, "error", err)
+		}
+	}
+	return sessionMgr.Delete(sessionName)
 }
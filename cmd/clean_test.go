@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+func TestPrintCleanPlan(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	proj := &models.Project{
+		Name:      "example.com/user/repo",
+		LocalPath: filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo.git"),
+	}
+
+	// The branches are all at main, which is on the remote, so they have no unpushed commits
+	args := []string{"-C", proj.LocalPath, "update-ref", "refs/remotes/origin/main", "main"}
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git update-ref failed: %v: %s", err, out)
+	}
+
+	worktrees := map[string]*models.Worktree{}
+	for _, branch := range []string{"merged", "orphaned", "dirty"} {
+		path := filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo", branch)
+		args := []string{"-C", proj.LocalPath, "worktree", "add", "--quiet", "-b", branch, path}
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git worktree add failed: %v: %s", err, out)
+		}
+		worktrees[branch] = &models.Worktree{Branch: branch, Path: path}
+	}
+	if err := os.WriteFile(filepath.Join(worktrees["dirty"].Path, "file.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mergedSession := workspace.GenerateSessionName(proj.Name, "merged")
+	goneSession := workspace.GenerateSessionName(proj.Name, "gone")
+
+	tests := []struct {
+		name      string
+		worktrees []*models.Worktree
+		opts      removeOptions
+		want      []string
+	}{
+		{
+			name:      "merged",
+			worktrees: []*models.Worktree{worktrees["merged"]},
+			opts:      removeOptions{deleteBranch: true, deleteRemoteBranch: true},
+			want: []string{
+				"would remove worktree: merged (" + worktrees["merged"].Path + ")",
+				"would kill session: " + mergedSession,
+				"would delete branch: merged",
+				"would delete remote branch: origin/merged",
+				"would kill orphaned session: " + goneSession,
+			},
+		},
+		{
+			name:      "orphaned",
+			worktrees: []*models.Worktree{worktrees["orphaned"], worktrees["dirty"]},
+			want: []string{
+				"would remove worktree: orphaned (" + worktrees["orphaned"].Path + ")",
+				"would skip worktree with local changes: dirty (1 uncommitted file(s))",
+				"would kill orphaned session: " + goneSession,
+			},
+		},
+		{
+			name:      "discarding changes",
+			worktrees: []*models.Worktree{worktrees["dirty"]},
+			opts:      removeOptions{discardChanges: true},
+			want: []string{
+				"would remove worktree: dirty (" + worktrees["dirty"].Path + ")",
+				"would kill orphaned session: " + goneSession,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionMgr := &fakeSessionManager{sessions: []string{mergedSession, goneSession}}
+			var buf bytes.Buffer
+			if err := printCleanPlan(cfg, proj, tt.worktrees, sessionMgr, tt.opts, display.New(&buf)); err != nil {
+				t.Fatalf("printCleanPlan() returned error: %v", err)
+			}
+			if got, want := buf.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("printCleanPlan() printed:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestPrintIdlePlan(t *testing.T) {
+	idle := []idleSession{{name: "repo-old"}, {name: "repo-older"}}
+
+	var buf bytes.Buffer
+	printIdlePlan(idle, display.New(&buf))
+	want := "would kill idle session: repo-old\nwould kill idle session: repo-older\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printIdlePlan() printed:\n%s\nwant:\n%s", got, want)
+	}
+}
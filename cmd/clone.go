@@ -1,12 +1,13 @@
 package cmd
 
 import (
-	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
 	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/prepare"
 	"github.com/benoctopus/sesh/internal/session"
 	"github.com/benoctopus/sesh/internal/state"
 	"github.com/benoctopus/sesh/internal/workspace"
@@ -14,7 +15,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var cloneDetach bool
+var (
+	cloneDetach     bool
+	cloneWorkspace  string
+	cloneDepth      int
+	cloneFilter     string
+	cloneReference  string
+	cloneDissociate bool
+)
 
 var cloneCmd = &cobra.Command{
 	Use:     "clone <remote-url>",
@@ -26,7 +34,15 @@ create the main worktree, and set up a session.
 Examples:
   sesh clone git@github.com:user/repo.git
   sesh clone https://github.com/user/repo.git
-  sesh clone -d https://github.com/user/repo.git     # Clone without attaching`,
+  sesh clone -d https://github.com/user/repo.git     # Clone without attaching
+  sesh clone --workspace work git@github.com:org/repo.git  # Clone into the "work" workspace
+  sesh clone --depth 1 --filter blob:none https://github.com/org/monorepo.git  # Shallow and partial
+  sesh clone --reference ~/src/repo git@github.com:user/repo.git  # Reuse the objects of a local clone
+
+Without --depth and --filter, the clone options of the config file are used. Without
+--reference, an existing clone of the repository is looked for in the directories of
+clone.reference_dirs. The objects of the reference are copied into the new clone, so it
+keeps working after the reference is deleted; --dissociate=false borrows them instead.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runClone,
 }
@@ -35,6 +51,67 @@ func init() {
 	rootCmd.AddCommand(cloneCmd)
 	cloneCmd.Flags().
 		BoolVarP(&cloneDetach, "detach", "d", false, "Create session without attaching to it")
+	cloneCmd.Flags().
+		StringVar(&cloneWorkspace, "workspace", "", "Name of the workspace to clone into (default: the first one)")
+	cloneCmd.Flags().
+		IntVar(&cloneDepth, "depth", 0, "Clone only the last N commits of each branch (0 clones the full history)")
+	cloneCmd.Flags().
+		StringVar(&cloneFilter, "filter", "", "Download objects only when needed, e.g. blob:none (empty downloads all)")
+	cloneCmd.Flags().
+		StringVar(&cloneReference, "reference", "", "Reuse the objects of an existing local clone of the repository")
+	cloneCmd.Flags().
+		BoolVar(&cloneDissociate, "dissociate", true, "Copy the objects of the reference instead of borrowing them")
+	//nolint:errcheck // The flag is defined above
+	cloneCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
+	//nolint:errcheck // The flag is defined above
+	cloneCmd.MarkFlagDirname("reference")
+}
+
+// cloneOptions returns the shallow, partial, and reference clone options of a project
+// The --depth, --filter, and --reference flags take precedence over the clone options of the
+// config file.
+func cloneOptions(cmd *cobra.Command, cfg *config.Config, projectName string) (git.CloneOptions, error) {
+	defaults := cfg.Clone.For(projectName)
+	opts := git.CloneOptions{Depth: defaults.Depth, Filter: defaults.Filter}
+
+	if cmd.Flags().Changed("depth") {
+		if cloneDepth < 0 {
+			return git.CloneOptions{}, eris.Errorf("invalid --depth: %d (must be 0 or greater)", cloneDepth)
+		}
+		opts.Depth = cloneDepth
+	}
+	if cmd.Flags().Changed("filter") {
+		if cloneFilter != "" {
+			if err := config.ValidateCloneFilter(cloneFilter); err != nil {
+				return git.CloneOptions{}, eris.Wrap(err, "invalid --filter")
+			}
+		}
+		opts.Filter = cloneFilter
+	}
+
+	opts.Reference = cloneReference
+	if opts.Reference == "" {
+		dirs, err := cfg.Clone.ReferencePaths()
+		if err != nil {
+			return git.CloneOptions{}, err
+		}
+		opts.Reference = git.FindReference(dirs, projectName)
+	}
+	opts.Dissociate = cloneDissociate
+	return opts, nil
+}
+
+// completeWorkspaces completes the names of the configured workspaces
+func completeWorkspaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	workspaces, err := config.GetWorkspaces()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		names = append(names, ws.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
 }
 
 func runClone(cmd *cobra.Command, args []string) error {
@@ -47,9 +124,15 @@ func runClone(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to load configuration")
 	}
 
+	// Clone into the primary workspace unless another one was chosen
+	workspaceDir, err := selectWorkspaceDir(cfg, cloneWorkspace)
+	if err != nil {
+		return err
+	}
+
 	// Ensure workspace directory exists
-	if err := config.EnsureWorkspaceDir(); err != nil {
-		return eris.Wrap(err, "failed to ensure workspace directory")
+	if err := os.MkdirAll(workspaceDir, 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create workspace directory: %s", workspaceDir)
 	}
 
 	// Generate project name from remote URL
@@ -58,20 +141,40 @@ func runClone(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to generate project name from remote URL")
 	}
 
-	// Check if project already exists by checking filesystem
+	cloneOpts, err := cloneOptions(cmd, cfg, projectName)
+	if err != nil {
+		return err
+	}
+
+	// Hold the project lock until the worktree and session exist
+	unlock, err := lockProject(cfg, projectName, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Check if project already exists by checking filesystem, in any workspace
 	existingProject, err := state.GetProject(cfg.WorkspaceDir, projectName)
 	if err == nil && existingProject != nil {
 		return eris.Errorf("project %s already exists in workspace", projectName)
 	}
+	sharedPrefixes := sharedSessionPrefixes(cfg, projectName)
 
 	// Get paths for bare repo and worktrees
-	bareRepoPath := workspace.GetBareRepoPath(cfg.WorkspaceDir, projectName)
-	worktreeBasePath := workspace.GetWorktreeBasePath(cfg.WorkspaceDir, projectName)
+	bareRepoPath := workspace.GetBareRepoPath(workspaceDir, projectName)
 
 	// Clone repository as bare repo
 	disp.Infof("Cloning %s", disp.Bold(remoteURL))
 	disp.Printf("  %s %s\n", disp.Faint("→"), bareRepoPath)
-	if err := git.Clone(remoteURL, bareRepoPath); err != nil {
+	if cloneOpts.Reference != "" {
+		disp.Printf("  %s %s\n", disp.Faint("Reusing objects of"), cloneOpts.Reference)
+	}
+	progress := display.NewProgress(os.Stderr, "Cloning")
+	cloneOpts.Progress = progress.Update
+	err = git.Clone(cmd.Context(), remoteURL, bareRepoPath, cloneOpts)
+	progress.Stop()
+	recordOperation("clone", projectName, "", err)
+	if err != nil {
 		return eris.Wrap(err, "failed to clone repository")
 	}
 
@@ -82,11 +185,17 @@ func runClone(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create main worktree
-	worktreePath := workspace.GetWorktreePath(worktreeBasePath, defaultBranch)
+	worktreePath := workspace.ProjectWorktreePath(workspaceDir, projectName, defaultBranch)
 	disp.Infof("Creating worktree for branch %s", disp.Bold(defaultBranch))
-	if err := git.CreateWorktree(bareRepoPath, defaultBranch, worktreePath); err != nil {
+	// A new clone only has origin
+	err = git.CreateWorktree(cmd.Context(), bareRepoPath, config.DefaultRemote, defaultBranch, worktreePath)
+	recordOperation("create-worktree", projectName, defaultBranch, err)
+	if err != nil {
 		return eris.Wrap(err, "failed to clone worktree")
 	}
+	prepare.UpdateSubmodules(cmd.Context(), worktreePath, disp)
+	prepare.PullLFS(cmd.Context(), worktreePath, disp)
+	prepare.ZoxideAdd(cfg, worktreePath, disp)
 
 	// Initialize session manager
 	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
@@ -94,31 +203,45 @@ func runClone(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to initialize session manager")
 	}
 
+	// Rediscover projects, since the session name depends on whether another one has the same
+	// repository name; without that, it could take over the session of the other project
+	if _, err := state.DiscoverProjects(cfg.WorkspaceDir); err != nil {
+		slog.Debug("failed to rediscover projects", "error", err)
+	}
+	warnRenamedSessions(sharedPrefixes)
+
 	// Generate session name
 	sessionName := workspace.GenerateSessionName(projectName, defaultBranch)
+	prepare.WarnSharedRepoName(projectName, sessionName)
 
 	// Create session
+	preparer := newPreparer(cfg, sessionMgr, disp)
+	shell, err := preparer.DevcontainerShell(cmd.Context(), worktreePath)
+	if err != nil {
+		return err
+	}
 	disp.Infof("Creating %s session %s", sessionMgr.Name(), disp.Bold(sessionName))
-	if err := sessionMgr.Create(sessionName, worktreePath); err != nil {
+	err = preparer.CreateSessionWithOptions(projectName, defaultBranch, sessionName, worktreePath, shell)
+	recordOperation("create-session", projectName, defaultBranch, err)
+	if err != nil {
 		return eris.Wrap(err, "failed to create session")
 	}
 
+	if cfg.Tmux.WindowTitles {
+		setSessionTitle(sessionMgr, sessionName, projectName, defaultBranch)
+	}
+
 	disp.Successf("Successfully cloned %s", disp.Bold(projectName))
 	disp.Printf("  %s %s\n", disp.Faint("Worktree:"), worktreePath)
 	disp.Printf("  %s %s\n", disp.Faint("Session:"), sessionName)
 
 	// Execute startup command if configured
-	startupCmd, err := config.GetStartupCommand(worktreePath)
-	if err == nil && startupCmd != "" && sessionMgr.Name() == "tmux" {
-		disp.Infof("Running startup command: %s", disp.Faint(startupCmd))
-		if tmuxMgr, ok := sessionMgr.(*session.TmuxManager); ok {
-			if err := tmuxMgr.SendKeys(sessionName, startupCmd); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to run startup command: %v\n", err)
-			}
-		}
+	if startupCmd, err := config.GetStartupCommand(worktreePath); err == nil {
+		prepare.RunStartupCommand(sessionMgr, sessionName, startupCmd, false, disp)
 	}
 
 	// Attach to the new session if not detached
+	unlock()
 	if !cloneDetach {
 		disp.Infof("Attaching to session...")
 		if err := sessionMgr.Attach(sessionName); err != nil {
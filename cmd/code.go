@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	codeProjectName string
+	codeWorkspace   bool
+	codeEditor      string
+)
+
+var codeCmd = &cobra.Command{
+	Use:   "code [branch...]",
+	Short: "Open the worktree of a branch in VS Code (create worktree if needed)",
+	Long: `Open the worktree of a branch in VS Code, creating the worktree if it doesn't exist.
+No session is created, so this works without tmux or zellij.
+If no branch is specified, an interactive fuzzy finder will show all available branches.
+
+With --workspace, a <repo>.code-workspace file listing every worktree of the project
+is written next to the worktrees and opened instead, so all of them show up in one
+window. Settings and other fields of an existing workspace file are kept.
+
+Use --editor for VS Code forks with the same command line, like code-insiders or cursor.
+
+Examples:
+  sesh code feature-foo                     # Open the worktree of feature-foo
+  sesh code                                 # Interactive fuzzy branch selection
+  sesh code -p myproject main               # Explicit project
+  sesh code --workspace feature-foo main    # Open both in a multi-root workspace
+  sesh code --editor cursor feature-foo     # Open in Cursor`,
+	ValidArgsFunction: completeBranches,
+	RunE:              runCode,
+}
+
+func init() {
+	rootCmd.AddCommand(codeCmd)
+	codeCmd.Flags().
+		StringVarP(&codeProjectName, "project", "p", "", "Specify project explicitly")
+	codeCmd.Flags().
+		BoolVarP(&codeWorkspace, "workspace", "w", false, "Open a .code-workspace with all worktrees of the project")
+	codeCmd.Flags().
+		StringVar(&codeEditor, "editor", "code", "VS Code command to open the worktree with")
+}
+
+func runCode(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return eris.Wrap(err, "failed to get current working directory")
+	}
+
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, codeProjectName, cwd)
+	if err != nil {
+		return eris.Wrap(err, "failed to resolve project")
+	}
+
+	editorPath, err := exec.LookPath(codeEditor)
+	if err != nil {
+		return eris.Errorf("%s not found in PATH (install the VS Code shell command, or use --editor)", codeEditor)
+	}
+
+	branches := args
+	if len(branches) == 0 {
+		if !tty.IsInteractive() {
+			return eris.New("branch argument required in noninteractive mode (usage: sesh code <branch>)")
+		}
+
+		branch, err := selectBranch(cmd, cfg, proj)
+		if err != nil {
+			return err
+		}
+		branches = []string{branch}
+	}
+
+	var paths []string
+	for _, branch := range branches {
+		worktreePath, err := ensureWorktree(cmd.Context(), cfg, proj, branch, disp)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, worktreePath)
+	}
+
+	if codeWorkspace {
+		workspaceFile, err := writeCodeWorkspace(cfg, proj)
+		if err != nil {
+			return err
+		}
+		paths = []string{workspaceFile}
+	}
+
+	disp.Printf("%s Opening %s in %s\n", disp.InfoText("→"), disp.Bold(paths[0]), codeEditor)
+
+	//nolint:gosec // The editor is chosen by the user
+	editorCmd := exec.Command(editorPath, paths...)
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return eris.Wrapf(err, "failed to run %s", codeEditor)
+	}
+
+	return nil
+}
+
+// codeWorkspaceFolder is a folder entry of a .code-workspace file
+type codeWorkspaceFolder struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// writeCodeWorkspace writes a .code-workspace file with a folder for every worktree of the project
+// Returns the path of the workspace file.
+func writeCodeWorkspace(cfg *config.Config, proj *models.Project) (string, error) {
+	worktrees, err := state.DiscoverWorktrees(proj)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to discover worktrees")
+	}
+
+	worktreeBasePath := workspace.GetWorktreeBasePath(workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name), proj.Name)
+	path := filepath.Join(worktreeBasePath, workspace.GetRepoNameFromProject(proj.Name)+".code-workspace")
+	// The directory only exists already when worktrees are kept in it (see worktree_path_template)
+	if err := os.MkdirAll(worktreeBasePath, 0o755); err != nil {
+		return "", eris.Wrapf(err, "failed to create directory: %s", worktreeBasePath)
+	}
+
+	// Keep settings, extensions, and anything else from an existing workspace file
+	fields := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", eris.Wrapf(err, "failed to parse workspace file: %s", path)
+		}
+	}
+
+	folders := make([]codeWorkspaceFolder, 0, len(worktrees))
+	for _, wt := range worktrees {
+		// The bare repository is listed without a branch
+		if wt.Branch == "" {
+			continue
+		}
+		folders = append(folders, codeWorkspaceFolder{Name: wt.Branch, Path: wt.Path})
+	}
+	fields["folders"] = folders
+
+	data, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return "", eris.Wrap(err, "failed to encode workspace file")
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", eris.Wrapf(err, "failed to write workspace file: %s", path)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/state"
+)
+
+func TestWriteCodeWorkspace(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	proj, err := state.GetProject(cfg.WorkspaceDir, "example.com/user/repo")
+	if err != nil {
+		t.Fatalf("GetProject() failed: %v", err)
+	}
+
+	disp := display.NewStderr()
+	var paths []string
+	for _, branch := range []string{"main", "feature"} {
+		path, err := ensureWorktree(t.Context(), cfg, proj, branch, disp)
+		if err != nil {
+			t.Fatalf("ensureWorktree(%q) failed: %v", branch, err)
+		}
+		paths = append(paths, path)
+	}
+
+	// Settings of an existing workspace file are kept
+	workspaceFile := filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo", "repo.code-workspace")
+	if err := os.WriteFile(workspaceFile, []byte(`{"settings": {"editor.tabSize": 4}}`), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	got, err := writeCodeWorkspace(cfg, proj)
+	if err != nil {
+		t.Fatalf("writeCodeWorkspace() failed: %v", err)
+	}
+	if got != workspaceFile {
+		t.Errorf("writeCodeWorkspace() = %q, want %q", got, workspaceFile)
+	}
+
+	data, err := os.ReadFile(workspaceFile)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	var fields struct {
+		Folders  []codeWorkspaceFolder `json:"folders"`
+		Settings map[string]any        `json:"settings"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("invalid workspace file: %v", err)
+	}
+
+	// The bare repository isn't a folder, and worktrees are listed in git's order
+	want := []codeWorkspaceFolder{{Name: "feature", Path: paths[1]}, {Name: "main", Path: paths[0]}}
+	if len(fields.Folders) != len(want) {
+		t.Fatalf("folders = %+v, want %+v", fields.Folders, want)
+	}
+	for i := range want {
+		if fields.Folders[i] != want[i] {
+			t.Errorf("folders[%d] = %+v, want %+v", i, fields.Folders[i], want[i])
+		}
+	}
+	if fields.Settings["editor.tabSize"] != float64(4) {
+		t.Errorf("settings = %v, want editor.tabSize kept", fields.Settings)
+	}
+}
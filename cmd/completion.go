@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/lock"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// refreshBranchCacheCmd lists the branches on a repository's remote into the completion cache
+// Completion starts it in the background, so a slow remote never blocks the shell.
+var refreshBranchCacheCmd = &cobra.Command{
+	Use:    "__refresh-branch-cache <repo-path> [remote]",
+	Hidden: true,
+	Args:   cobra.RangeArgs(1, 2),
+	// Skip the root setup, which only matters for commands that discover projects
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE:             runRefreshBranchCache,
+}
+
+func init() {
+	rootCmd.AddCommand(refreshBranchCacheCmd)
+}
+
+func runRefreshBranchCache(cmd *cobra.Command, args []string) error {
+	repoPath := args[0]
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return eris.Wrap(err, "failed to get cache directory")
+	}
+
+	// Completions on every keystroke may start several refreshes; one is enough
+	l, err := lock.TryAcquire(git.RemoteBranchCachePath(cacheDir, repoPath) + ".lock")
+	if errors.Is(err, lock.ErrLocked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // The lock is released by the OS on exit anyway
+	defer l.Release()
+
+	remote := config.DefaultRemote
+	if len(args) > 1 {
+		remote = args[1]
+	}
+	branches, err := git.ListActualRemoteBranches(cmd.Context(), repoPath, remote)
+	if err != nil {
+		return err
+	}
+	return git.SaveCachedRemoteBranches(cacheDir, repoPath, branches)
+}
+
+// completeBranches completes branch arguments with the project's worktrees, local branches,
+// and the cached branches on its remote. A stale cache is refreshed in the background for the
+// next completion, unless offline. The project comes from the --project flag or the current
+// directory.
+func completeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	projectName, _ := cmd.Flags().GetString("project")
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, projectName, cwd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var candidates []string
+	if worktrees, err := state.DiscoverWorktrees(proj); err == nil {
+		for _, wt := range worktrees {
+			candidates = append(candidates, wt.Branch)
+		}
+	}
+	if branches, err := git.ListRemoteBranches(proj.LocalPath); err == nil {
+		candidates = append(candidates, branches...)
+	}
+	if cacheDir, err := config.GetCacheDir(); err == nil {
+		branches, fresh := git.LoadCachedRemoteBranches(cacheDir, proj.LocalPath, git.RemoteBranchCacheMaxAge)
+		candidates = append(candidates, branches...)
+		if !fresh && !isOffline() {
+			refreshBranchCacheInBackground(proj.LocalPath, cfg.Remote.For(proj.Name))
+		}
+	}
+
+	var completions []string
+	for _, branch := range candidates {
+		// The bare repository is listed as a worktree without a branch
+		if branch == "" || !strings.HasPrefix(branch, toComplete) {
+			continue
+		}
+		if slices.Contains(args, branch) || slices.Contains(completions, branch) {
+			continue
+		}
+		completions = append(completions, branch)
+	}
+	slices.Sort(completions)
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProjects completes --project with the names of the projects in the workspace and
+// the configured aliases, each described with the project it names
+func completeProjects(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, alias := range slices.Sorted(maps.Keys(cfg.ProjectAliases)) {
+		if strings.HasPrefix(alias, toComplete) {
+			completions = append(completions, alias+"\t"+cfg.ProjectAliases[alias])
+		}
+	}
+	if projects, err := state.DiscoverProjects(cfg.WorkspaceDir); err == nil {
+		for _, proj := range projects {
+			if strings.HasPrefix(proj.Name, toComplete) {
+				completions = append(completions, proj.Name)
+			}
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerProjectCompletions completes the --project flag of every command that has one
+func registerProjectCompletions(cmd *cobra.Command) {
+	if cmd.Flags().Lookup("project") != nil {
+		//nolint:errcheck // The flag exists, and commands have one completion each
+		cmd.RegisterFlagCompletionFunc("project", completeProjects)
+	}
+	for _, child := range cmd.Commands() {
+		registerProjectCompletions(child)
+	}
+}
+
+// refreshBranchCacheInBackground starts a detached sesh process that refreshes the remote
+// branch cache of a repository from one of its remotes, and returns without waiting for it
+func refreshBranchCacheInBackground(repoPath, remote string) {
+	bin, err := os.Executable()
+	if err != nil {
+		return
+	}
+
+	// Stdin, stdout, and stderr are /dev/null, so the shell reading the completions doesn't wait
+	refresh := exec.Command(bin, refreshBranchCacheCmd.Name(), repoPath, remote)
+	if err := refresh.Start(); err != nil {
+		return
+	}
+	//nolint:errcheck // The refresh outlives this process, which doesn't wait for it
+	refresh.Process.Release()
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/benoctopus/sesh/internal/compose"
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+)
+
+// stopCompose stops and removes the compose services of a worktree that is being removed, when
+// its project config declares a compose file
+func stopCompose(sessionName, worktreePath string, disp display.Printer) {
+	projectConfig, err := config.LoadProjectConfig(worktreePath)
+	if err != nil || projectConfig.ComposeFile == "" {
+		return
+	}
+
+	composeProject := compose.ProjectName(sessionName)
+	disp.Printf("Stopping compose services: %s\n", composeProject)
+	if err := compose.Down(context.Background(), composeProject); err != nil {
+		disp.Warningf("Failed to stop compose services: %v", err)
+	}
+}
+
+// composeStatus returns the state of the compose services of a running session, when its
+// project config declares a compose file
+func composeStatus(ctx context.Context, sessionName, worktreePath string) []compose.ServiceStatus {
+	projectConfig, err := config.LoadProjectConfig(worktreePath)
+	if err != nil || projectConfig.ComposeFile == "" {
+		return nil
+	}
+	services, _ := compose.Status(ctx, compose.ProjectName(sessionName))
+	return services
+}
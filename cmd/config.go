@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read, change, edit, and validate the configuration",
+	Long: `Read and change settings of config.yaml without editing YAML by hand.
+
+Settings are named like in config.yaml, with nested ones joined by dots
+(theme.info). 'sesh config get' shows the values in effect, after environment
+variables and defaults are applied; 'sesh config set' checks the value and the
+rest of the file before writing it, and keeps the file's comments.
+
+Examples:
+  sesh config get                          # Show the whole configuration
+  sesh config get workspace_dir            # Show one setting
+  sesh config set fuzzy_finder fzf         # Change a setting
+  sesh config set theme.info blue          # Change a nested setting
+  sesh config edit                         # Open config.yaml in $EDITOR
+  sesh config validate                     # Check config.yaml for errors`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get [key]",
+	Short:             "Print the value of a setting, or the whole configuration",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <key> <value>",
+	Short:             "Change a setting in the config file",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeConfigKeys,
+	RunE:              runConfigSet,
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Open the config file in your editor and validate it (same as 'sesh edit')",
+	Args:  cobra.NoArgs,
+	RunE:  runEdit,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for errors and unknown settings",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigValidate,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configEditCmd)
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	key := ""
+	if len(args) > 0 {
+		key = args[0]
+	}
+
+	value, err := config.GetValue(key)
+	if err != nil {
+		return err
+	}
+
+	display.NewStdout().Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+	key, value := args[0], args[1]
+
+	if err := config.SetValue(key, value); err != nil {
+		return eris.Wrapf(err, "failed to set %s", key)
+	}
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return eris.Wrap(err, "failed to get config path")
+	}
+	disp.Successf("Set %s in %s", disp.Bold(key), configPath)
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return eris.Wrap(err, "failed to get config path")
+	}
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		disp.Infof("No config file at %s, the defaults are used", configPath)
+		return nil
+	}
+
+	if err := config.ValidateConfigFile(configPath); err != nil {
+		return eris.Wrapf(err, "invalid config file: %s", configPath)
+	}
+
+	unknown, err := config.UnknownKeys(configPath)
+	if err != nil {
+		return err
+	}
+	for _, fieldErr := range unknown {
+		disp.Warningf("%s: %s (ignored)", configPath, fieldErr)
+	}
+
+	disp.Successf("%s is valid", configPath)
+	return nil
+}
+
+// completeConfigKeys completes the names of settings for the key argument
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.Keys(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/daemon"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/lock"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonInterval time.Duration
+	daemonOnce     bool
+	daemonPrewarm  int
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep projects fetched and sessions tidy in the background",
+	Long: `Run a background loop that keeps the workspace fresh.
+
+Every interval, the daemon:
+  - fetches every project in the workspace
+  - refreshes the cached open pull requests of GitHub projects
+  - kills sessions whose worktree no longer exists, in every project (unless
+    reap_sessions is "never")
+  - kills sessions no client has been attached to for session_idle_ttl, when
+    it is set (tmux only)
+  - with --prewarm N, creates worktrees for the N most frecent branches of
+    every project, like 'sesh prewarm'
+
+While it runs, 'sesh switch' lists up-to-date remote branches and pull requests
+without waiting on the network. The daemon runs in the foreground; start it from
+your init system, a login script, or with 'sesh daemon &'.
+
+Examples:
+  sesh daemon                  # Refresh every 5 minutes
+  sesh daemon --interval 1m    # Refresh every minute
+  sesh daemon --once           # Refresh once and exit
+  sesh daemon --prewarm 3      # Also prepare the 3 most frecent branches
+  sesh daemon status           # Show what the daemon is doing`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running and when it last refreshed",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.Flags().
+		DurationVar(&daemonInterval, "interval", daemon.DefaultInterval, "Time between refreshes")
+	daemonCmd.Flags().BoolVar(&daemonOnce, "once", false, "Refresh once and exit")
+	daemonCmd.Flags().
+		IntVar(&daemonPrewarm, "prewarm", 0, "Create worktrees for this many frecent branches of each project")
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	if daemonInterval <= 0 {
+		return eris.Errorf("invalid interval: %s (must be positive)", daemonInterval)
+	}
+	if daemonPrewarm < 0 {
+		return eris.Errorf("invalid --prewarm: %d (must not be negative)", daemonPrewarm)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return eris.Wrap(err, "failed to get cache directory")
+	}
+
+	// Only one daemon refreshes the workspace at a time
+	l, err := daemon.Lock(cacheDir)
+	if errors.Is(err, lock.ErrLocked) {
+		return eris.New("daemon is already running (see 'sesh daemon status')")
+	}
+	if err != nil {
+		return eris.Wrap(err, "failed to lock daemon")
+	}
+	//nolint:errcheck // The lock is released by the OS on exit anyway
+	defer l.Release()
+
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status := &daemon.Status{
+		PID:       os.Getpid(),
+		StartedAt: time.Now(),
+		Interval:  daemonInterval,
+	}
+
+	logDaemon(disp, "Daemon started (refreshing every %s)", daemonInterval)
+	for {
+		refreshWorkspace(ctx, cfg, sessionMgr, cacheDir, status, disp)
+		if daemonOnce {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			logDaemon(disp, "Daemon stopped")
+			return nil
+		case <-time.After(time.Until(status.NextRun)):
+		}
+	}
+}
+
+// refreshWorkspace runs one daemon pass over every project and publishes the resulting status
+// Failures are logged and recorded per project rather than stopping the daemon
+func refreshWorkspace(
+	ctx context.Context,
+	cfg *config.Config,
+	sessionMgr session.SessionManager,
+	cacheDir string,
+	status *daemon.Status,
+	disp display.Printer,
+) {
+	start := time.Now()
+
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		logDaemon(disp, "Failed to discover projects: %v", err)
+	}
+
+	// Offline, projects keep their last fetch, and the cached pull requests stay as they are
+	skipRemotes := isOffline()
+	if skipRemotes {
+		logDaemon(disp, "Offline, skipping fetches")
+	}
+
+	previous := status.Projects
+	status.Projects = make([]daemon.ProjectStatus, 0, len(projects))
+	for _, proj := range projects {
+		if ctx.Err() != nil {
+			break
+		}
+
+		projStatus := daemon.ProjectStatus{Name: proj.Name, OpenPRs: -1}
+		for _, prev := range previous {
+			if prev.Name == proj.Name {
+				projStatus.LastFetched = prev.LastFetched
+			}
+		}
+
+		if skipRemotes {
+			status.Projects = append(status.Projects, projStatus)
+			continue
+		}
+
+		if err := git.Fetch(ctx, proj.LocalPath); err != nil {
+			projStatus.FetchError = err.Error()
+			logDaemon(disp, "Failed to fetch %s: %v", proj.Name, err)
+		} else {
+			projStatus.LastFetched = time.Now()
+			markProjectFetched(proj)
+		}
+
+		// Open pull requests are cached for 'sesh switch --pr'; unsupported providers are skipped
+		if prs, err := fetchOpenPRs(ctx, proj); err == nil {
+			projStatus.OpenPRs = len(prs)
+			if err := pr.SaveCachedOpenPRs(cacheDir, proj.LocalPath, prs); err != nil {
+				logDaemon(disp, "Failed to cache pull requests of %s: %v", proj.Name, err)
+			}
+		}
+
+		status.Projects = append(status.Projects, projStatus)
+	}
+
+	// Worktrees are prepared after fetching, so remote branches switched to before are found
+	if daemonPrewarm > 0 && !skipRemotes && ctx.Err() == nil {
+		history := recentSessionHistory()
+		for _, proj := range projects {
+			branches, err := prewarmProject(ctx, cfg, proj, history, daemonPrewarm, display.New(io.Discard))
+			for _, branch := range branches {
+				logDaemon(disp, "Prepared worktree for %s in %s", branch, proj.Name)
+			}
+			if err != nil && ctx.Err() == nil {
+				logDaemon(disp, "Failed to prepare worktrees of %s: %v", proj.Name, err)
+			}
+		}
+	}
+
+	if cfg.ReapSessions != config.ReapSessionsNever && ctx.Err() == nil {
+		for _, sessionName := range reapStaleSessions(cfg, sessionMgr) {
+			logDaemon(disp, "Killed orphaned session %s", sessionName)
+			status.PrunedSessions++
+		}
+	}
+
+	if cfg.SessionIdleTTL > 0 && ctx.Err() == nil {
+		killed, err := reapIdleSessions(projects, sessionMgr, cfg.SessionIdleTTL)
+		if err != nil {
+			logDaemon(disp, "Failed to kill idle sessions: %v", err)
+		}
+		for _, sessionName := range killed {
+			logDaemon(disp, "Killed session %s, idle for over %s", sessionName, cfg.SessionIdleTTL)
+			status.IdleSessions++
+		}
+	}
+
+	status.LastRun = start
+	status.LastDuration = time.Since(start)
+	status.NextRun = start.Add(status.Interval)
+	if err := daemon.WriteStatus(cacheDir, status); err != nil {
+		logDaemon(disp, "Failed to write status: %v", err)
+	}
+
+	logDaemon(disp, "Refreshed %d project(s) in %s", len(status.Projects), status.LastDuration.Round(time.Millisecond))
+}
+
+// logDaemon prints a timestamped daemon log line
+func logDaemon(disp display.Printer, format string, args ...any) {
+	disp.Printf("%s %s\n", disp.Faint(time.Now().Format("2006-01-02 15:04:05")), fmt.Sprintf(format, args...))
+}
+
+// daemonFetchedRecently reports whether a running daemon fetched a project within its interval,
+// in which case there is no need to fetch it again before listing branches
+func daemonFetchedRecently(proj *models.Project) bool {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil || !daemon.IsRunning(cacheDir) {
+		return false
+	}
+
+	status, err := daemon.ReadStatus(cacheDir)
+	if err != nil || status == nil {
+		return false
+	}
+
+	fetched, ok := status.LastFetched(proj.Name)
+	return ok && time.Since(fetched) < status.Interval
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return eris.Wrap(err, "failed to get cache directory")
+	}
+
+	status, err := daemon.ReadStatus(cacheDir)
+	if err != nil {
+		return err
+	}
+
+	out := display.NewStdout()
+	running := daemon.IsRunning(cacheDir)
+
+	switch {
+	case running && status != nil:
+		out.Printf(
+			"%s Daemon is running (pid %d, since %s)\n",
+			out.SuccessText("●"),
+			status.PID,
+			formatTimeAgo(status.StartedAt),
+		)
+	case running:
+		out.Printf("%s Daemon is running\n", out.SuccessText("●"))
+	default:
+		out.Printf("%s Daemon is not running\n", out.Faint("○"))
+	}
+
+	if status == nil {
+		return nil
+	}
+
+	out.Printf("Interval: %s\n", status.Interval)
+	if !status.LastRun.IsZero() {
+		out.Printf(
+			"Last refresh: %s (took %s)\n",
+			formatTimeAgo(status.LastRun),
+			status.LastDuration.Round(time.Millisecond),
+		)
+	}
+	if running && !status.NextRun.IsZero() {
+		out.Printf("Next refresh: in %s\n", time.Until(status.NextRun).Round(time.Second))
+	}
+	out.Printf("Pruned sessions: %d\n", status.PrunedSessions)
+	out.Printf("Idle sessions killed: %d\n", status.IdleSessions)
+
+	if len(status.Projects) == 0 {
+		return nil
+	}
+
+	out.Println()
+	for _, proj := range status.Projects {
+		fetched := out.Faint("never fetched")
+		if !proj.LastFetched.IsZero() {
+			fetched = out.Faint("fetched " + formatTimeAgo(proj.LastFetched))
+		}
+
+		prs := ""
+		if proj.OpenPRs >= 0 {
+			prs = out.Faint(fmt.Sprintf(", %d open PR%s", proj.OpenPRs, pluralize(proj.OpenPRs)))
+		}
+
+		marker := out.SuccessText("✓")
+		if proj.FetchError != "" {
+			marker = out.ErrorText("✗")
+		}
+
+		out.Printf("%s %s %s%s\n", marker, out.Bold(proj.Name), fetched, prs)
+		if proj.FetchError != "" {
+			out.Printf("    %s\n", out.ErrorText(proj.FetchError))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bufio"
+	"database/sql"
+	"os"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dbMigrateTo    int
+	dbMigrateForce bool
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the sesh database schema",
+	Long: `Inspect and migrate the schema of the sesh database.
+
+Pending migrations are applied automatically whenever sesh opens the database,
+so 'sesh db migrate' is mainly needed to roll back migrations, e.g. before
+downgrading to an older version of sesh.
+
+Examples:
+  sesh db status               # Show applied and pending migrations
+  sesh db migrate              # Apply all pending migrations
+  sesh db migrate --to 2       # Roll back to schema version 2`,
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show applied and pending migrations",
+	RunE:  runDBStatus,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply or roll back migrations",
+	Long: `Apply or roll back migrations to reach a schema version.
+
+Without --to, all pending migrations are applied. With --to, migrations above
+that version are rolled back using their down scripts, which drops the data
+stored in the affected tables. Rolling back requires confirmation, or --force
+in noninteractive mode.
+
+Note that any other sesh command re-applies pending migrations, so roll back
+immediately before switching to the older sesh binary.`,
+	RunE: runDBMigrate,
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbMigrateCmd.Flags().IntVar(&dbMigrateTo, "to", 0, "Target schema version (default: latest)")
+	dbMigrateCmd.Flags().BoolVarP(&dbMigrateForce, "force", "f", false, "Skip confirmation prompt when rolling back")
+}
+
+func runDBStatus(cmd *cobra.Command, args []string) error {
+	dbPath, database, err := connectDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	statuses, err := db.GetMigrationStatus(database)
+	if err != nil {
+		return eris.Wrap(err, "failed to get migration status")
+	}
+
+	current, err := db.CurrentVersion(database)
+	if err != nil {
+		return eris.Wrap(err, "failed to get schema version")
+	}
+
+	out := display.NewStdout()
+	out.Printf("Database: %s\n", dbPath)
+	out.Printf("Schema version: %d (latest: %d)\n\n", current, db.LatestVersion())
+
+	for _, status := range statuses {
+		var marker, state string
+		switch {
+		case status.Unknown:
+			marker = out.WarningText("?")
+			state = out.WarningText("unknown (applied by a newer version of sesh)")
+		case status.Modified:
+			marker = out.ErrorText("!")
+			state = out.ErrorText("modified since it was applied")
+		case status.Applied:
+			marker = out.SuccessText("✓")
+			state = out.Faint("applied " + status.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+		default:
+			marker = out.Faint("·")
+			state = "pending"
+		}
+
+		out.Printf("%s %03d %-20s %s\n", marker, status.Version, status.Name, state)
+	}
+
+	return nil
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	_, database, err := connectDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	current, err := db.CurrentVersion(database)
+	if err != nil {
+		return eris.Wrap(err, "failed to get schema version")
+	}
+
+	target := db.LatestVersion()
+	if cmd.Flags().Changed("to") {
+		target = dbMigrateTo
+	}
+
+	if target == current {
+		disp.Printf("Database is already at schema version %d.\n", current)
+		return nil
+	}
+
+	// Rolling back drops tables, so confirm first
+	if target < current && !dbMigrateForce {
+		if !tty.IsInteractive() {
+			return eris.New("--force flag required to roll back migrations in noninteractive mode")
+		}
+
+		disp.Printf(
+			"This will roll back the database from schema version %d to %d, dropping the data in the affected tables.\n",
+			current,
+			target,
+		)
+		disp.Print("Are you sure? (yes/no): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return eris.Wrap(err, "failed to read confirmation")
+		}
+
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" && response != "y" {
+			disp.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	ran, err := db.MigrateTo(database, target)
+	for _, m := range ran {
+		if target < current {
+			disp.Printf("Rolled back %03d %s\n", m.Version, m.Name)
+		} else {
+			disp.Printf("Applied %03d %s\n", m.Version, m.Name)
+		}
+	}
+	if err != nil {
+		return eris.Wrap(err, "failed to migrate database")
+	}
+
+	disp.Printf("\nDatabase is now at schema version %d.\n", target)
+	return nil
+}
+
+// connectDB opens the sesh database without applying migrations
+func connectDB() (string, *sql.DB, error) {
+	dbPath, err := config.GetDBPath()
+	if err != nil {
+		return "", nil, eris.Wrap(err, "failed to get database path")
+	}
+
+	if err := config.EnsureConfigDir(); err != nil {
+		return "", nil, eris.Wrap(err, "failed to ensure config directory")
+	}
+
+	database, err := db.Connect(dbPath)
+	if err != nil {
+		return "", nil, eris.Wrap(err, "failed to open database")
+	}
+
+	return dbPath, database, nil
+}
@@ -2,12 +2,13 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"log/slog"
 	"os"
 	"strings"
 
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
-	"github.com/benoctopus/sesh/internal/git"
 	"github.com/benoctopus/sesh/internal/models"
 	"github.com/benoctopus/sesh/internal/project"
 	"github.com/benoctopus/sesh/internal/session"
@@ -19,9 +20,12 @@ import (
 )
 
 var (
-	deleteAll         bool
-	deleteForce       bool
-	deleteProjectName string
+	deleteAll            bool
+	deleteForce          bool
+	deleteDiscardChanges bool
+	deleteBranchRef      bool
+	deleteRemoteBranch   bool
+	deleteProjectName    string
 )
 
 var deleteCmd = &cobra.Command{
@@ -31,7 +35,14 @@ var deleteCmd = &cobra.Command{
 	Long: `Delete a worktree and its associated session, or delete an entire project.
 
 By default, deletes the specified branch's worktree and session.
-Use --all to delete the entire project including all worktrees.
+Use --all, or --project without a branch, to delete the entire project: all of its
+sessions are killed, all worktrees and the bare repository are removed, and its
+history and trash are purged. Deleting a project requires typing the project name
+to confirm, or --force for scripts.
+
+Worktrees with uncommitted changes or unpushed commits are not deleted unless
+--discard-changes is given. A deleted worktree is moved to the trash and can be
+restored with 'sesh undo'; deleting an entire project is permanent.
 
 The project is automatically detected from the current working directory,
 or can be specified explicitly with the --project flag.
@@ -40,7 +51,9 @@ Examples:
   sesh delete feature-foo          # Delete feature-foo worktree/session
   sesh delete --all                # Delete entire project (requires confirmation)
   sesh delete --all --force        # Delete entire project without confirmation
-  sesh delete --project myproject --all  # Delete specific project`,
+  sesh delete --discard-changes feature-foo  # Delete even with uncommitted/unpushed work
+  sesh delete --delete-branch feature-foo    # Also delete the local branch
+  sesh delete --project myproject  # Delete specific project`,
 	RunE: runDelete,
 }
 
@@ -48,6 +61,11 @@ func init() {
 	rootCmd.AddCommand(deleteCmd)
 	deleteCmd.Flags().BoolVar(&deleteAll, "all", false, "Delete entire project")
 	deleteCmd.Flags().BoolVarP(&deleteForce, "force", "f", false, "Skip confirmation prompt")
+	deleteCmd.Flags().
+		BoolVar(&deleteDiscardChanges, "discard-changes", false, "Delete worktrees even if they have local changes")
+	deleteCmd.Flags().BoolVar(&deleteBranchRef, "delete-branch", false, "Also delete the local branch")
+	deleteCmd.Flags().
+		BoolVar(&deleteRemoteBranch, "delete-remote-branch", false, "Also delete the branch on its upstream remote")
 	deleteCmd.Flags().
 		StringVarP(&deleteProjectName, "project", "p", "", "Specify project explicitly")
 }
@@ -73,7 +91,11 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to resolve project")
 	}
 
-	if deleteAll {
+	// An explicit --project without a branch means the whole project
+	if deleteAll || (deleteProjectName != "" && len(args) == 0) {
+		if deleteRemoteBranch {
+			return eris.New("--delete-remote-branch cannot be used when deleting an entire project")
+		}
 		return deleteProject(cfg, proj, disp)
 	}
 
@@ -83,7 +105,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	branch := args[0]
-	return deleteBranch(cfg, proj, branch, disp)
+	return deleteBranch(cmd.Context(), cfg, proj, branch, disp)
 }
 
 func deleteProject(cfg *config.Config, proj *models.Project, disp display.Printer) error {
@@ -93,20 +115,35 @@ func deleteProject(cfg *config.Config, proj *models.Project, disp display.Printe
 		return eris.Wrap(err, "failed to discover worktrees")
 	}
 
+	// Refuse to delete the project if any worktree has work that would be lost
+	var dirty []string
+	for _, wt := range worktrees {
+		if err := ensureNoLocalChanges(wt, deleteDiscardChanges, disp); err != nil {
+			dirty = append(dirty, wt.Branch)
+		}
+	}
+	if len(dirty) > 0 {
+		return eris.Errorf(
+			"%d worktree(s) have uncommitted changes or unpushed commits: %s (use --discard-changes to delete anyway)",
+			len(dirty),
+			strings.Join(dirty, ", "),
+		)
+	}
+
 	// In noninteractive mode, require --force flag
 	if !deleteForce {
 		if !tty.IsInteractive() {
 			return eris.New("--force flag required for deletion in noninteractive mode")
 		}
 
-		// Ask for confirmation in interactive mode
+		// Ask for typed confirmation in interactive mode, since this can't be undone
 		disp.Printf(
-			"This will delete project '%s' with %d worktree(s) and all associated sessions.\n",
+			"This will permanently delete project '%s' with %d worktree(s) and all associated sessions.\n",
 			proj.Name,
 			len(worktrees),
 		)
 		disp.Printf("Project path: %s\n", proj.LocalPath)
-		disp.Print("Are you sure? (yes/no): ")
+		disp.Printf("Type the project name (%s) to confirm: ", disp.Bold(proj.Name))
 
 		reader := bufio.NewReader(os.Stdin)
 		response, err := reader.ReadString('\n')
@@ -114,13 +151,20 @@ func deleteProject(cfg *config.Config, proj *models.Project, disp display.Printe
 			return eris.Wrap(err, "failed to read confirmation")
 		}
 
-		response = strings.TrimSpace(strings.ToLower(response))
-		if response != "yes" && response != "y" {
+		response = strings.TrimSpace(response)
+		if response != proj.Name {
 			disp.Println("Deletion cancelled.")
 			return nil
 		}
 	}
 
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	sharedPrefixes := sharedSessionPrefixes(cfg, proj.Name)
+
 	// Initialize session manager
 	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
 	if err != nil {
@@ -138,26 +182,37 @@ func deleteProject(cfg *config.Config, proj *models.Project, disp display.Printe
 			disp.Printf("Warning: failed to check session existence: %v\n", err)
 		} else if exists {
 			disp.Printf("Killing %s session: %s\n", sessionMgr.Name(), sessionName)
-			if err := sessionMgr.Delete(sessionName); err != nil {
+			if err := killSession(sessionMgr, sessionName); err != nil {
 				disp.Printf("Warning: failed to kill session: %v\n", err)
 			}
 		}
 
 		// Remove worktree
+		killSubprojectSessions(cfg, proj, wt, disp)
+		stopCompose(sessionName, wt.Path, disp)
 		disp.Printf("Removing worktree: %s\n", wt.Path)
-		if err := git.RemoveWorktree(proj.LocalPath, wt.Path); err != nil {
+		if err := deleteWorktree(proj, wt, deleteDiscardChanges); err != nil {
 			disp.Printf("Warning: failed to remove worktree: %v\n", err)
+		} else {
+			zoxideRemove(cfg, wt.Path, disp)
 		}
 	}
 
+	// Kill any leftover sessions whose worktrees were already gone
+	if err := cleanOrphanedSessions(proj, sessionMgr, disp); err != nil {
+		disp.Printf("Warning: failed to clean orphaned sessions: %v\n", err)
+	}
+
 	// Delete bare repository
 	disp.Printf("Removing bare repository: %s\n", proj.LocalPath)
-	if err := os.RemoveAll(proj.LocalPath); err != nil {
+	err = os.RemoveAll(proj.LocalPath)
+	recordOperation("delete-project", proj.Name, "", err)
+	if err != nil {
 		return eris.Wrap(err, "failed to remove bare repository")
 	}
 
 	// Delete worktrees base directory (sibling to bare repo)
-	worktreeBasePath := workspace.GetWorktreeBasePath(cfg.WorkspaceDir, proj.Name)
+	worktreeBasePath := workspace.GetWorktreeBasePath(workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name), proj.Name)
 	if _, err := os.Stat(worktreeBasePath); err == nil {
 		disp.Printf("Removing worktrees directory: %s\n", worktreeBasePath)
 		if err := os.RemoveAll(worktreeBasePath); err != nil {
@@ -165,11 +220,28 @@ func deleteProject(cfg *config.Config, proj *models.Project, disp display.Printe
 		}
 	}
 
+	// Recycled worktrees are kept apart, in the workspace pool
+	poolDir := workspace.GetPoolDir(workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name), proj.Name)
+	if _, err := os.Stat(poolDir); err == nil {
+		disp.Printf("Removing recycled worktrees: %s\n", poolDir)
+		if err := os.RemoveAll(poolDir); err != nil {
+			return eris.Wrap(err, "failed to remove recycled worktrees")
+		}
+	}
+
+	purgeProjectRecords(cfg, proj, disp)
+
+	// The projects sharing its repository name may no longer need as long session names
+	if _, err := state.DiscoverProjects(cfg.WorkspaceDir); err != nil {
+		slog.Debug("failed to rediscover projects", "error", err)
+	}
+	warnRenamedSessions(sharedPrefixes)
+
 	disp.Printf("\nSuccessfully deleted project: %s\n", proj.Name)
 	return nil
 }
 
-func deleteBranch(cfg *config.Config, proj *models.Project, branch string, disp display.Printer) error {
+func deleteBranch(ctx context.Context, cfg *config.Config, proj *models.Project, branch string, disp display.Printer) error {
 	// Get worktree from filesystem state
 	worktree, err := state.GetWorktree(proj, branch)
 	if err != nil {
@@ -181,6 +253,12 @@ func deleteBranch(cfg *config.Config, proj *models.Project, branch string, disp
 		return eris.Errorf("cannot delete main worktree, use --all to delete the entire project")
 	}
 
+	// Refuse to delete a worktree with work that would be lost
+	if err := ensureNoLocalChanges(worktree, deleteDiscardChanges, disp); err != nil {
+		recordOperation("remove-worktree", proj.Name, branch, err)
+		return err
+	}
+
 	// In noninteractive mode, require --force flag
 	if !deleteForce {
 		if !tty.IsInteractive() {
@@ -208,6 +286,12 @@ func deleteBranch(cfg *config.Config, proj *models.Project, branch string, disp
 		}
 	}
 
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Initialize session manager
 	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
 	if err != nil {
@@ -223,17 +307,62 @@ func deleteBranch(cfg *config.Config, proj *models.Project, branch string, disp
 		disp.Printf("Warning: failed to check session existence: %v\n", err)
 	} else if exists {
 		disp.Printf("Killing %s session: %s\n", sessionMgr.Name(), sessionName)
-		if err := sessionMgr.Delete(sessionName); err != nil {
+		if err := killSession(sessionMgr, sessionName); err != nil {
 			disp.Printf("Warning: failed to kill session: %v\n", err)
 		}
 	}
 
 	// Remove worktree
 	disp.Printf("Removing worktree: %s\n", worktree.Path)
-	if err := git.RemoveWorktree(proj.LocalPath, worktree.Path); err != nil {
-		return eris.Wrap(err, "failed to remove worktree")
+	err = removeWorktree(cfg, proj, worktree, deleteDiscardChanges, disp)
+	recordOperation("remove-worktree", proj.Name, branch, err)
+	if err != nil {
+		return err
 	}
 
+	deleteBranches(ctx, cfg, proj, branch, removeOptions{
+		deleteBranch:       deleteBranchRef,
+		deleteRemoteBranch: deleteRemoteBranch,
+	}, disp)
+
 	disp.Printf("\nSuccessfully deleted worktree for branch: %s\n", branch)
 	return nil
 }
+
+// purgeProjectRecords removes the database rows and trashed worktrees that belong to a deleted project
+// The audit log is kept so the deletion itself can still be looked up
+func purgeProjectRecords(cfg *config.Config, proj *models.Project, disp display.Printer) {
+	database, err := openDB()
+	if err != nil {
+		disp.Printf("Warning: failed to open database: %v\n", err)
+		return
+	}
+	defer database.Close()
+
+	if err := database.DeleteSessionHistoryByProject(proj.Name); err != nil {
+		disp.Printf("Warning: %v\n", err)
+	}
+	if err := database.DeletePortBlocksByProject(proj.Name); err != nil {
+		disp.Printf("Warning: %v\n", err)
+	}
+	if err := database.DeleteProjectTagsByProject(proj.Name); err != nil {
+		disp.Printf("Warning: %v\n", err)
+	}
+	if err := database.DeletePinnedSessionsByProject(proj.Name); err != nil {
+		disp.Printf("Warning: %v\n", err)
+	}
+	if err := database.DeleteSessionPrefix(proj.Name); err != nil {
+		disp.Printf("Warning: %v\n", err)
+	}
+
+	if entries, err := database.GetTrashEntriesByProject(proj.Name); err == nil && len(entries) > 0 {
+		disp.Printf("Removing %d trashed worktree(s)\n", len(entries))
+		purgeTrashEntries(cfg, database, entries, disp)
+	}
+
+	if dbProj, err := database.GetProject(proj.Name); err == nil {
+		if err := database.DeleteProject(dbProj.ID); err != nil {
+			disp.Printf("Warning: %v\n", err)
+		}
+	}
+}
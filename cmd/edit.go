@@ -145,10 +145,11 @@ func getEditor() string {
 func createDefaultConfig(configPath string) error {
 	// Load default config
 	cfg := &config.Config{
-		WorkspaceDir:   "~/.sesh",
-		SessionBackend: "auto",
-		StartupCommand: "",
-		FuzzyFinder:    "auto",
+		WorkspaceDir:       "~/.sesh",
+		SessionBackend:     "auto",
+		StartupCommand:     "",
+		FuzzyFinder:        "auto",
+		TrashRetentionDays: config.DefaultTrashRetentionDays,
 	}
 
 	return config.SaveConfig(cfg)
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/manifest"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print a manifest of the projects in the workspace",
+	Long: `Print a manifest of the projects in the workspace as YAML: the remotes of each
+project, the workspace it is in, and the branches it has worktrees of.
+
+'sesh bootstrap' clones the projects of a manifest again, e.g. on a new machine.
+
+Examples:
+  sesh export > manifest.yaml         # Save the manifest
+  sesh bootstrap manifest.yaml        # On the new machine`,
+	Args: cobra.NoArgs,
+	RunE: runExport,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+	workspaces, err := config.GetWorkspaces()
+	if err != nil {
+		return err
+	}
+
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return eris.Wrap(err, "failed to discover projects")
+	}
+	slices.SortFunc(projects, func(a, b *models.Project) int { return strings.Compare(a.Name, b.Name) })
+
+	m := &manifest.Manifest{Version: manifest.Version}
+	for _, proj := range projects {
+		project, err := exportProject(proj, workspaces)
+		if err != nil {
+			// A project without remotes can't be cloned again, but the others still can
+			disp.Warningf("Skipping %s: %v", proj.Name, err)
+			continue
+		}
+		m.Projects = append(m.Projects, project)
+	}
+
+	return manifest.Write(os.Stdout, m)
+}
+
+// exportProject returns the manifest entry of a project
+func exportProject(proj *models.Project, workspaces []config.Workspace) (manifest.Project, error) {
+	remotes, err := git.ListRemoteURLs(proj.LocalPath)
+	if err != nil {
+		return manifest.Project{}, err
+	}
+	if remotes["origin"] == "" {
+		return manifest.Project{}, eris.New("it has no origin remote")
+	}
+
+	project := manifest.Project{
+		Name:      proj.Name,
+		Workspace: projectWorkspaceName(proj, workspaces),
+		Remotes:   remotes,
+	}
+
+	// The default branch's worktree is always created, so it isn't listed
+	defaultBranch, _ := git.GetDefaultBranch(proj.LocalPath)
+	worktrees, err := state.DiscoverWorktrees(proj)
+	if err != nil {
+		return manifest.Project{}, err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == "" || wt.Branch == "(detached)" || wt.Branch == defaultBranch {
+			continue
+		}
+		project.Branches = append(project.Branches, wt.Branch)
+	}
+	slices.Sort(project.Branches)
+	return project, nil
+}
+
+// projectWorkspaceName returns the name of the workspace a project is in, or "" for the primary
+// workspace
+func projectWorkspaceName(proj *models.Project, workspaces []config.Workspace) string {
+	for i, ws := range workspaces {
+		if strings.HasPrefix(proj.LocalPath, ws.Path+string(filepath.Separator)) {
+			if i == 0 {
+				return ""
+			}
+			return ws.Name
+		}
+	}
+	return ""
+}
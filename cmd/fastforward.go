@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+// fastForwardDefault fast-forwards the worktree of the default branch to its upstream when
+// fast_forward_default is on, so it doesn't drift behind the remote while the work happens in
+// other worktrees. Worktrees with uncommitted changes or commits of their own are left alone.
+// The upstream is as of the last fetch, and failures are only reported.
+func fastForwardDefault(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	branch, worktreePath string,
+	disp display.Printer,
+) {
+	if !cfg.FastForwardDefault {
+		return
+	}
+	if defaultBranch, err := git.GetDefaultBranch(proj.LocalPath); err != nil || branch != defaultBranch {
+		return
+	}
+
+	ab, err := git.GetAheadBehind(worktreePath)
+	if err != nil || ab == nil || ab.Behind == 0 {
+		return
+	}
+	if ab.Ahead > 0 {
+		disp.Warningf("%s has diverged from %s, so it isn't fast-forwarded", branch, ab.Upstream)
+		return
+	}
+	if dirty, err := git.HasUncommittedChanges(worktreePath); err != nil || dirty {
+		disp.Warningf("%s is %d commit(s) behind %s, but has uncommitted changes, so it isn't fast-forwarded",
+			branch, ab.Behind, ab.Upstream)
+		return
+	}
+
+	err = git.FastForward(ctx, worktreePath)
+	recordOperation("fast-forward", proj.Name, branch, err)
+	if err != nil {
+		disp.Warningf("Failed to fast-forward %s: %v", branch, err)
+		return
+	}
+	disp.Printf(
+		"%s Fast-forwarded %s by %d commit(s) to %s\n",
+		disp.SuccessText("✓"),
+		disp.Bold(branch),
+		ab.Behind,
+		ab.Upstream,
+	)
+}
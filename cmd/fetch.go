@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
@@ -50,7 +54,7 @@ func runFetch(cmd *cobra.Command, args []string) error {
 	}
 
 	if fetchAll {
-		return fetchAllProjects(cfg, disp)
+		return fetchAllProjects(cmd.Context(), cfg, disp)
 	}
 
 	// Get current working directory
@@ -65,22 +69,26 @@ func runFetch(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to resolve project")
 	}
 
-	return fetchProject(proj, disp)
+	return fetchProject(cmd.Context(), proj, disp)
 }
 
-func fetchProject(proj *models.Project, disp display.Printer) error {
+func fetchProject(ctx context.Context, proj *models.Project, disp display.Printer) error {
 	disp.Printf("Fetching %s...\n", proj.Name)
 
 	// Run git fetch
-	if err := git.Fetch(proj.LocalPath); err != nil {
+	progress := display.NewProgress(os.Stderr, "Fetching")
+	err := git.FetchProgress(ctx, proj.LocalPath, progress.Update)
+	progress.Stop()
+	if err != nil {
 		return eris.Wrap(err, "failed to fetch repository")
 	}
+	markProjectFetched(proj)
 
 	disp.Printf("Successfully fetched %s\n", proj.Name)
 	return nil
 }
 
-func fetchAllProjects(cfg *config.Config, disp display.Printer) error {
+func fetchAllProjects(ctx context.Context, cfg *config.Config, disp display.Printer) error {
 	// Discover all projects from filesystem
 	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
 	if err != nil {
@@ -98,13 +106,18 @@ func fetchAllProjects(cfg *config.Config, disp display.Printer) error {
 	failCount := 0
 
 	for _, proj := range projects {
-		disp.Printf("Fetching %s...", proj.Name)
+		// The progress takes the line until the fetch is done, then the result is printed
+		progress := display.NewProgress(os.Stderr, fmt.Sprintf("Fetching %s...", proj.Name))
+		err := git.FetchProgress(ctx, proj.LocalPath, progress.Update)
+		progress.Stop()
 
-		if err := git.Fetch(proj.LocalPath); err != nil {
+		disp.Printf("Fetching %s...", proj.Name)
+		if err != nil {
 			disp.Printf(" failed: %v\n", err)
 			failCount++
 			continue
 		}
+		markProjectFetched(proj)
 
 		disp.Printf(" done\n")
 		successCount++
@@ -118,3 +131,57 @@ func fetchAllProjects(cfg *config.Config, disp display.Printer) error {
 
 	return nil
 }
+
+// markProjectFetched records the fetch time of a project in the database, which the auto-fetch
+// policy reads. Projects the database doesn't track yet are added to it.
+func markProjectFetched(proj *models.Project) {
+	database, err := openDB()
+	if err != nil {
+		return
+	}
+	defer database.Close()
+
+	dbProj, err := database.GetProject(proj.Name)
+	if err != nil {
+		dbProj = &models.Project{Name: proj.Name, RemoteURL: proj.RemoteURL, LocalPath: proj.LocalPath}
+		if err := database.CreateProject(dbProj); err != nil {
+			slog.Debug("failed to add project to the database", "project", proj.Name, "error", err)
+			return
+		}
+	}
+	if err := database.UpdateProjectFetchTime(dbProj.ID); err != nil {
+		slog.Debug("failed to record fetch time", "project", proj.Name, "error", err)
+	}
+}
+
+// projectLastFetched returns when a project was last fetched, as recorded in the database
+func projectLastFetched(proj *models.Project) (time.Time, bool) {
+	database, err := openDB()
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer database.Close()
+
+	dbProj, err := database.GetProject(proj.Name)
+	if err != nil || dbProj.LastFetched == nil {
+		return time.Time{}, false
+	}
+	return *dbProj.LastFetched, true
+}
+
+// needsFetch reports whether a project is due to be fetched under the auto-fetch policy: it
+// was fetched longer ago than fetch_if_older_than, or never, and the daemon isn't keeping it
+// fresh. Nothing is due offline.
+func needsFetch(cfg *config.Config, proj *models.Project) bool {
+	if isOffline() {
+		return false
+	}
+	if cfg.FetchIfOlderThan == 0 {
+		return true
+	}
+	if daemonFetchedRecently(proj) {
+		return false
+	}
+	fetched, ok := projectLastFetched(proj)
+	return !ok || time.Since(fetched) >= cfg.FetchIfOlderThan
+}
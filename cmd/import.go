@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importInPlace   bool
+	importWorkspace string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <path>...",
+	Short: "Adopt existing clones into the workspace",
+	Long: `Adopt existing clones into the workspace without cloning them again.
+
+The .git directory of each clone becomes the project's bare repository in the
+workspace, and the clone's files become the worktree of the branch it has checked
+out. Uncommitted and staged changes are kept. By default the files are moved to
+where sesh puts the worktree; with --in-place they stay where they are.
+
+Examples:
+  sesh import ~/src/repo                 # Move the clone into the workspace
+  sesh import --in-place ~/src/repo      # Keep the files in ~/src/repo
+  sesh import ~/src/*/                   # Import several clones at once
+  sesh import --workspace work ~/src/api # Import into the "work" workspace`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().
+		BoolVar(&importInPlace, "in-place", false, "Keep the files of the clone where they are")
+	importCmd.Flags().
+		StringVar(&importWorkspace, "workspace", "", "Name of the workspace to import into (default: the first one)")
+	//nolint:errcheck // The flag is defined above
+	importCmd.RegisterFlagCompletionFunc("workspace", completeWorkspaces)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	workspaceDir, err := selectWorkspaceDir(cfg, importWorkspace)
+	if err != nil {
+		return err
+	}
+
+	// Keep going when a clone can't be imported, so the others still are
+	failed := 0
+	for _, path := range args {
+		if err := importClone(cmd.Context(), cfg, workspaceDir, path, disp); err != nil {
+			disp.Errorf("Failed to import %s: %v", path, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return eris.Errorf("failed to import %d of %d repositories", failed, len(args))
+	}
+	return nil
+}
+
+// selectWorkspaceDir returns the directory of the named workspace, or the primary one
+func selectWorkspaceDir(cfg *config.Config, name string) (string, error) {
+	if name == "" {
+		return cfg.WorkspaceDir, nil
+	}
+	ws, err := config.GetWorkspace(name)
+	if err != nil {
+		return "", err
+	}
+	return ws.Path, nil
+}
+
+// importClone turns a regular clone into a project of the workspace
+func importClone(ctx context.Context, cfg *config.Config, workspaceDir, path string, disp display.Printer) error {
+	clonePath, err := filepath.Abs(path)
+	if err != nil {
+		return eris.Wrapf(err, "failed to resolve path: %s", path)
+	}
+	if !git.IsWorkingClone(clonePath) {
+		return eris.Errorf("%s is not the top directory of a git clone", clonePath)
+	}
+
+	remoteURL, err := git.GetRemoteURL(clonePath)
+	if err != nil {
+		return eris.Wrap(err, "the clone needs an origin remote to name the project")
+	}
+	projectName, err := git.GenerateProjectName(remoteURL)
+	if err != nil {
+		return eris.Wrap(err, "failed to generate project name from remote URL")
+	}
+
+	branch, err := git.GetWorktreeBranch(clonePath)
+	if err != nil {
+		return err
+	}
+	if branch == "(detached)" {
+		return eris.New("the clone has no branch checked out (check one out first)")
+	}
+
+	unlock, err := lockProject(cfg, projectName, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if existing, err := state.GetProject(cfg.WorkspaceDir, projectName); err == nil && existing != nil {
+		return eris.Errorf("project %s already exists in workspace", projectName)
+	}
+	sharedPrefixes := sharedSessionPrefixes(cfg, projectName)
+
+	bareRepoPath := workspace.GetBareRepoPath(workspaceDir, projectName)
+	worktreePath := clonePath
+	if !importInPlace {
+		worktreePath = workspace.ProjectWorktreePath(workspaceDir, projectName, branch)
+		if _, err := os.Stat(worktreePath); err == nil {
+			return eris.Errorf("a worktree already exists at %s", worktreePath)
+		}
+	}
+
+	disp.Infof("Importing %s", disp.Bold(projectName))
+	disp.Printf("  %s %s\n", disp.Faint("→"), bareRepoPath)
+
+	// The clone is moved first, so nothing has changed if it can't be, e.g. across filesystems
+	if worktreePath != clonePath {
+		if err := os.MkdirAll(filepath.Dir(worktreePath), 0o755); err != nil {
+			return eris.Wrapf(err, "failed to create directory: %s", filepath.Dir(worktreePath))
+		}
+		if err := os.Rename(clonePath, worktreePath); err != nil {
+			return eris.Wrapf(err, "failed to move %s (use --in-place to keep it where it is)", clonePath)
+		}
+	}
+
+	err = git.ConvertToBare(ctx, worktreePath, bareRepoPath)
+	if err == nil {
+		err = git.AttachWorktree(bareRepoPath, branch, worktreePath)
+	}
+	recordOperation("import", projectName, branch, err)
+	if err != nil {
+		return err
+	}
+	prepare.ZoxideAdd(cfg, worktreePath, disp)
+
+	// Rediscover projects, since the session names depend on whether another one has the same
+	// repository name; the imported clone keeps its age, so it mustn't take over their sessions
+	if _, err := state.DiscoverProjects(cfg.WorkspaceDir); err != nil {
+		slog.Debug("failed to rediscover projects", "error", err)
+	}
+	warnRenamedSessions(sharedPrefixes)
+	prepare.WarnSharedRepoName(projectName, workspace.GenerateSessionName(projectName, branch))
+
+	disp.Successf("Successfully imported %s", disp.Bold(projectName))
+	disp.Printf("  %s %s\n", disp.Faint("Worktree:"), worktreePath)
+	if worktreePath != clonePath {
+		disp.Printf("  %s %s\n", disp.Faint("Moved from:"), clonePath)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+func TestSharedSessionPrefixesKeepsPrefixes(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	state.SetPrefixStore(openDB)
+	t.Cleanup(func() {
+		state.SetPrefixStore(nil)
+		workspace.SetProjectNames(nil)
+		workspace.SetSessionPrefixes(nil)
+	})
+
+	prefixes := sharedSessionPrefixes(cfg, "github.com/other/repo")
+	if got := prefixes["example.com/user/repo"]; got != "repo" {
+		t.Fatalf("sharedSessionPrefixes() = %v, want repo for example.com/user/repo", prefixes)
+	}
+
+	// An imported clone keeps its age, so it is older than the project already in the workspace
+	bare := filepath.Join(cfg.WorkspaceDir, "github.com", "other", "repo.git")
+	src := filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo.git")
+	if out, err := exec.Command("git", "clone", "--quiet", "--bare", src, bare).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v: %s", err, out)
+	}
+	old := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(bare, "description"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.DiscoverProjects(cfg.WorkspaceDir); err != nil {
+		t.Fatalf("DiscoverProjects() failed: %v", err)
+	}
+
+	if got := workspace.SessionPrefix("example.com/user/repo"); got != "repo" {
+		t.Errorf("SessionPrefix(example.com/user/repo) = %q, want repo", got)
+	}
+	if got := workspace.SessionPrefix("github.com/other/repo"); got != "other-repo" {
+		t.Errorf("SessionPrefix(github.com/other/repo) = %q, want other-repo", got)
+	}
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
@@ -13,8 +17,6 @@ import (
 	"github.com/benoctopus/sesh/internal/pr"
 	"github.com/benoctopus/sesh/internal/project"
 	"github.com/benoctopus/sesh/internal/session"
-	"github.com/benoctopus/sesh/internal/state"
-	"github.com/benoctopus/sesh/internal/workspace"
 	"github.com/rotisserie/eris"
 	"github.com/spf13/cobra"
 )
@@ -22,6 +24,8 @@ import (
 var (
 	infoProjectName string
 	infoPRMode      bool
+	infoJSON        bool
+	infoPlain       bool
 )
 
 var infoCmd = &cobra.Command{
@@ -33,14 +37,25 @@ This command shows:
 - Project name and branch
 - Session status (running/stopped)
 - Git status summary
+- Commits ahead of and behind the upstream branch
+- Lines changed since the branch forked from its upstream, and stash count
 - Last commit message
 - Last used time
 - Worktree path
 
+The layout can be changed with preview_template in config.yaml (see the README).
+
+With --json, the same fields are printed as a JSON object, with a summary of
+the git status (e.g. "2 modified, 1 untracked"), for scripts and custom previews.
+With --plain, they're printed one per line, as the field name and its value
+separated by a tab.
+
 Examples:
   sesh info myproject-main                     # Show info for a session
   sesh info --project myproject feature-branch # Show info for project and branch
   sesh info --pr "#123│Title│..."              # Show info for a pull request
+  sesh info --json -p myproject main           # Machine-readable output for scripts
+  sesh info --plain -p myproject main | awk -F'\t' '$1 == "git_status" { print $2 }'
   sesh list --plain | fzf --preview 'sesh info {}'  # Use in fzf preview`,
 	Args: cobra.ExactArgs(1),
 	RunE: runInfo,
@@ -50,6 +65,9 @@ func init() {
 	rootCmd.AddCommand(infoCmd)
 	infoCmd.Flags().StringVarP(&infoProjectName, "project", "p", "", "Project name (when passing branch as argument)")
 	infoCmd.Flags().BoolVar(&infoPRMode, "pr", false, "Show pull request info instead of session info")
+	infoCmd.Flags().BoolVar(&infoJSON, "json", false, "Output in JSON format")
+	infoCmd.Flags().BoolVar(&infoPlain, "plain", false, "Output tab-separated field names and values")
+	infoCmd.MarkFlagsMutuallyExclusive("json", "plain")
 }
 
 func runInfo(cmd *cobra.Command, args []string) error {
@@ -70,19 +88,16 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to initialize session manager")
 	}
 
-	var projectName, branchName, sessionName string
+	var projectName, branchName string
 
 	// Handle --project flag mode
 	if infoProjectName != "" {
 		// In this mode, args[0] is the branch name
 		branchName = args[0]
 		projectName = infoProjectName
-
-		// Generate session name using the same logic as the switch command
-		sessionName = workspace.GenerateSessionName(projectName, branchName)
 	} else {
 		// Original mode: args[0] is the session name
-		sessionName = args[0]
+		sessionName := args[0]
 
 		// Parse session name to get project and branch
 		// Session names are in format: project-branch
@@ -101,110 +116,74 @@ func runInfo(cmd *cobra.Command, args []string) error {
 		return eris.Wrapf(err, "failed to resolve project: %s", projectName)
 	}
 
-	// Find the worktree for this branch
-	worktrees, err := state.DiscoverWorktrees(proj)
+	a := &api{cfg: cfg, sessionMgr: sessionMgr, disp: display.New(io.Discard)}
+	info, err := a.info(proj.Name, branchName)
 	if err != nil {
-		return eris.Wrap(err, "failed to discover worktrees")
+		return err
 	}
 
-	var worktreePath string
-	var worktreeExists bool
-	for _, wt := range worktrees {
-		if wt.Branch == branchName {
-			worktreePath = wt.Path
-			worktreeExists = true
-			break
-		}
+	if infoJSON {
+		return printJSON(info)
+	}
+	if infoPlain {
+		printPlain(worktreeInfoFields(info)...)
+		return nil
 	}
 
-	// Display using stdout (for fzf preview)
-	disp := display.NewStdout()
-
-	disp.Printf("\n")
-	disp.Printf("%s %s\n", disp.InfoText("Session:"), disp.Bold(sessionName))
-	disp.Printf("%s %s\n", disp.InfoText("Project:"), projectName)
-	disp.Printf("%s %s\n", disp.InfoText("Branch:"), disp.Bold(branchName))
-
-	if worktreeExists {
-		// Worktree exists - show full information
-		disp.Printf("%s %s\n", disp.InfoText("Path:"), disp.Faint(worktreePath))
-
-		// Check if session is running
-		isRunning, err := sessionMgr.Exists(sessionName)
-		if err != nil {
-			return eris.Wrap(err, "failed to check session status")
-		}
-
-		if isRunning {
-			disp.Printf("%s %s\n", disp.InfoText("Status:"), disp.SuccessText("● Running"))
-		} else {
-			disp.Printf("%s %s\n", disp.InfoText("Status:"), disp.Faint("○ Stopped"))
-		}
-
-		// Get git status
-		gitStatus := getGitStatus(worktreePath)
-		lastCommit := getLastCommit(worktreePath)
-
-		disp.Printf("\n")
-		disp.Printf("%s\n", disp.Bold("Git Status:"))
-		if gitStatus != "" {
-			disp.Print(gitStatus)
-		} else {
-			disp.Printf("%s\n", disp.SuccessText("  ✓ Clean"))
-		}
-
-		disp.Printf("\n")
-		disp.Printf("%s\n", disp.Bold("Last Commit:"))
-		if lastCommit != "" {
-			disp.Print(lastCommit)
-		} else {
-			disp.Printf("%s\n", disp.Faint("  (no commits)"))
-		}
-	} else {
-		// Worktree doesn't exist - show remote branch information
-		disp.Printf("%s %s\n", disp.InfoText("Status:"), disp.WarningText("○ Remote branch (no local worktree)"))
+	data := &previewData{worktreeInfo: *info}
+	if info.HasWorktree {
+		data.StatusLines = getGitStatusLines(info.WorktreePath)
+	}
 
-		// Try to get information about the remote branch
-		lastCommit := getRemoteBranchLastCommit(proj.LocalPath, branchName)
+	// Display using stdout (for fzf preview)
+	return renderPreview(os.Stdout, display.NewStdout(), cfg.PreviewTemplate, data)
+}
 
-		disp.Printf("\n")
-		disp.Printf("%s\n", disp.Bold("Remote Branch Info:"))
-		if lastCommit != "" {
-			disp.Print(lastCommit)
-		} else {
-			disp.Printf("%s\n", disp.Faint("  (no commit information available)"))
-		}
+// formatAheadBehind formats the commits ahead of and behind the upstream, e.g. "↑3 ↓1"
+func formatAheadBehind(tracking *git.AheadBehind, disp display.Printer) string {
+	if tracking.Ahead == 0 && tracking.Behind == 0 {
+		return disp.SuccessText("✓ up to date")
+	}
 
-		disp.Printf("\n")
-		disp.Printf("%s\n", disp.InfoText("→ Run 'sesh switch' to create a worktree for this branch."))
+	var parts []string
+	if tracking.Ahead > 0 {
+		parts = append(parts, disp.InfoText(fmt.Sprintf("↑%d", tracking.Ahead)))
+	}
+	if tracking.Behind > 0 {
+		parts = append(parts, disp.WarningText(fmt.Sprintf("↓%d", tracking.Behind)))
 	}
+	return strings.Join(parts, " ")
+}
 
-	return nil
+// formatDiffStat formats a diff stat, e.g. "3 files +10 -2"
+func formatDiffStat(stat *git.DiffStat, disp display.Printer) string {
+	files := "files"
+	if stat.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf(
+		"%d %s %s %s",
+		stat.Files,
+		files,
+		disp.SuccessText(fmt.Sprintf("+%d", stat.Insertions)),
+		disp.ErrorText(fmt.Sprintf("-%d", stat.Deletions)),
+	)
 }
 
-// getGitStatus returns a formatted git status summary
-func getGitStatus(worktreePath string) string {
+// getGitStatusLines returns the lines of git status --short
+func getGitStatusLines(worktreePath string) []string {
 	cmd := exec.Command("git", "-C", worktreePath, "status", "--short")
 	output, err := cmd.Output()
 	if err != nil {
-		return ""
+		return nil
 	}
 
-	statusStr := strings.TrimSpace(string(output))
+	statusStr := strings.TrimRight(string(output), "\n")
 	if statusStr == "" {
-		return ""
-	}
-
-	// Indent each line
-	lines := strings.Split(statusStr, "\n")
-	var result strings.Builder
-	for _, line := range lines {
-		result.WriteString("  ")
-		result.WriteString(line)
-		result.WriteString("\n")
+		return nil
 	}
 
-	return result.String()
+	return strings.Split(statusStr, "\n")
 }
 
 // getLastCommit returns the last commit message
@@ -229,16 +208,16 @@ func getLastCommit(worktreePath string) string {
 	return "  " + commitStr + "\n"
 }
 
-// getRemoteBranchLastCommit returns the last commit message for a remote branch
-func getRemoteBranchLastCommit(repoPath, branch string) string {
+// getRemoteBranchLastCommit returns the last commit message for a branch of a remote
+func getRemoteBranchLastCommit(repoPath, remote, branch string) string {
 	// Try to get the last commit from the remote branch
-	// Use origin/<branch> format
-	remoteBranch := "origin/" + branch
+	// Use <remote>/<branch> format
+	remoteBranch := remote + "/" + branch
 
 	cmd := exec.Command("git", "-C", repoPath, "log", "-1", remoteBranch, "--pretty=format:%h %s (%ar)")
 	output, err := cmd.Output()
 	if err != nil {
-		// Try without origin/ prefix in case it's a different remote format
+		// Try without the remote prefix in case it's a different remote format
 		cmd = exec.Command("git", "-C", repoPath, "log", "-1", branch, "--pretty=format:%h %s (%ar)")
 		output, err = cmd.Output()
 		if err != nil {
@@ -300,6 +279,23 @@ func runPRInfo(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to get pull request details")
 	}
 
+	if infoJSON {
+		return printJSON(pullRequest)
+	}
+	if infoPlain {
+		printPlain(
+			[]string{"number", strconv.Itoa(pullRequest.Number)},
+			[]string{"title", pullRequest.Title},
+			[]string{"author", pullRequest.Author},
+			[]string{"branch", pullRequest.Branch},
+			[]string{"base_branch", pullRequest.BaseBranch},
+			[]string{"state", pullRequest.State},
+			[]string{"labels", strings.Join(pullRequest.Labels, ",")},
+			[]string{"url", pullRequest.URL},
+		)
+		return nil
+	}
+
 	// Display PR information
 	disp := display.NewStdout()
 
@@ -339,6 +335,86 @@ func runPRInfo(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printJSON prints a value as indented JSON to stdout, where it can be piped
+func printJSON(v any) error {
+	return fprintJSON(os.Stdout, v)
+}
+
+// fprintJSON writes a value as indented JSON, followed by a newline
+func fprintJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return eris.Wrap(err, "failed to marshal JSON")
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return eris.Wrap(err, "failed to write JSON")
+	}
+	return nil
+}
+
+// plainFieldReplacer replaces what would split a value of plain output into columns or rows
+var plainFieldReplacer = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+// printPlain prints rows of tab-separated columns to stdout, where they can be piped to awk or
+// cut. Tabs and newlines in values are replaced with spaces, so every row is one line.
+func printPlain(rows ...[]string) {
+	for _, row := range rows {
+		fmt.Println(plainRow(row))
+	}
+}
+
+// plainRow joins the columns of a row of plain output with tabs
+func plainRow(row []string) string {
+	fields := make([]string, len(row))
+	for i, field := range row {
+		fields[i] = plainFieldReplacer.Replace(field)
+	}
+	return strings.Join(fields, "\t")
+}
+
+// plainTime formats a time of plain output, or returns "" for the zero time
+func plainTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// worktreeInfoFields returns the fields of 'sesh info --plain', named like the JSON ones
+func worktreeInfoFields(info *worktreeInfo) [][]string {
+	fields := [][]string{
+		{"session_name", info.SessionName},
+		{"project", info.Project},
+		{"branch", info.Branch},
+		{"worktree_path", info.WorktreePath},
+		{"has_worktree", strconv.FormatBool(info.HasWorktree)},
+		{"is_running", strconv.FormatBool(info.IsRunning)},
+		{"git_status", info.GitStatus},
+		{"last_commit", info.LastCommit},
+	}
+	if info.Tracking != nil {
+		fields = append(fields,
+			[]string{"ahead", strconv.Itoa(info.Tracking.Ahead)},
+			[]string{"behind", strconv.Itoa(info.Tracking.Behind)},
+		)
+	}
+	if info.DiffStat != nil {
+		fields = append(fields,
+			[]string{"files_changed", strconv.Itoa(info.DiffStat.Files)},
+			[]string{"insertions", strconv.Itoa(info.DiffStat.Insertions)},
+			[]string{"deletions", strconv.Itoa(info.DiffStat.Deletions)},
+		)
+	}
+	fields = append(fields, []string{"stashes", strconv.Itoa(info.Stashes)})
+	if info.ReadyCheck != "" {
+		fields = append(fields,
+			[]string{"ready_check", info.ReadyCheck},
+			[]string{"ready", strconv.FormatBool(info.Ready)},
+		)
+	}
+	return fields
+}
+
 // getPRStateDisplay returns a colorized state display
 func getPRStateDisplay(state string, disp display.Printer) string {
 	switch strings.ToLower(state) {
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+)
+
+func TestPlainRow(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []string
+		want string
+	}{
+		{name: "columns", row: []string{"api", "3", "/ws/api.git"}, want: "api\t3\t/ws/api.git"},
+		{name: "empty columns", row: []string{"api", "", "main"}, want: "api\t\tmain"},
+		{
+			name: "tabs and newlines",
+			row:  []string{"fix\tlogin", "line one\nline two\r\n"},
+			want: "fix login\tline one line two ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plainRow(tt.row); got != tt.want {
+				t.Errorf("plainRow(%q) = %q, want %q", tt.row, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeInfoFields(t *testing.T) {
+	info := &worktreeInfo{
+		SessionName: "api-main",
+		Project:     "api",
+		Branch:      "main",
+		HasWorktree: true,
+		GitStatus:   "clean",
+		Tracking:    &git.AheadBehind{Ahead: 2},
+	}
+
+	var got []string
+	for _, field := range worktreeInfoFields(info) {
+		got = append(got, plainRow(field))
+	}
+	want := []string{
+		"session_name\tapi-main",
+		"project\tapi",
+		"branch\tmain",
+		"worktree_path\t",
+		"has_worktree\ttrue",
+		"is_running\tfalse",
+		"git_status\tclean",
+		"last_commit\t",
+		"ahead\t2",
+		"behind\t0",
+		"stashes\t0",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("worktreeInfoFields() = %q, want %q", got, want)
+	}
+}
+
+func TestInfoJSON(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	sessionMgr := &fakeSessionManager{}
+	a := &api{cfg: cfg, sessionMgr: sessionMgr, disp: display.New(io.Discard)}
+	if _, err := a.switchTo(t.Context(), "example.com/user/repo", "main"); err != nil {
+		t.Fatalf("switchTo() failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		branch string
+		want   map[string]any
+	}{
+		{
+			name:   "running session",
+			branch: "main",
+			want:   map[string]any{"session_name": "repo-main", "has_worktree": true, "is_running": true},
+		},
+		{
+			name:   "remote branch",
+			branch: "feature",
+			want:   map[string]any{"session_name": "repo-feature", "has_worktree": false, "is_running": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := a.info("example.com/user/repo", tt.branch)
+			if err != nil {
+				t.Fatalf("info() failed: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := fprintJSON(&buf, info); err != nil {
+				t.Fatalf("fprintJSON() failed: %v", err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("output isn't JSON: %v: %s", err, buf.String())
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %v, want %v", key, got[key], want)
+				}
+			}
+		})
+	}
+}
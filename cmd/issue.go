@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/rotisserie/eris"
+)
+
+// issueBranch returns the branch of an issue, named after its number and title following the
+// issue branch template. prepareSession creates the branch when it doesn't exist yet.
+func issueBranch(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	number int,
+	disp display.Printer,
+) (string, error) {
+	if number < 0 {
+		return "", eris.Errorf("invalid issue number: %d", number)
+	}
+
+	remoteURL, err := git.GetRemoteURL(proj.LocalPath)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to get remote URL")
+	}
+
+	issue, err := pr.GetIssue(ctx, remoteURL, proj.LocalPath, number)
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to get issue #%d", number)
+	}
+
+	branch := pr.IssueBranchName(cfg.IssueBranchTemplate, issue)
+	disp.Printf(
+		"%s Switching to issue #%d (%s) branch: %s\n",
+		disp.InfoText("→"),
+		issue.Number,
+		issue.Title,
+		disp.Bold(branch),
+	)
+	return branch, nil
+}
@@ -1,18 +1,25 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/benoctopus/sesh/internal/compose"
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
 	"github.com/benoctopus/sesh/internal/git"
-	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/benoctopus/sesh/internal/models"
 	"github.com/benoctopus/sesh/internal/project"
 	"github.com/benoctopus/sesh/internal/session"
 	"github.com/benoctopus/sesh/internal/state"
@@ -30,11 +37,19 @@ var (
 	listCurrentProject bool
 	listRunning        bool
 	listAll            bool
+	listNoFetch        bool
+	listTags           []string
+	listGroup          bool
+	listWatch          bool
+	listWatchInterval  time.Duration
 )
 
+// listWatchHighlight is how long 'sesh list --watch' highlights the sessions that changed state
+const listWatchHighlight = 10 * time.Second
+
 var listCmd = &cobra.Command{
 	Use:     "list",
-	Aliases: []string{"ls", "status"},
+	Aliases: []string{"ls"},
 	Short:   "List projects, worktrees, sessions, or pull requests",
 	Long: `Display all projects, worktrees, sessions, or pull requests.
 
@@ -43,13 +58,18 @@ By default, shows all sessions with their project and branch information.
 Examples:
   sesh list                        # List all sessions
   sesh list --projects             # List only projects
+  sesh list --projects --group     # List projects grouped by host and owner
   sesh list --sessions             # List only sessions
   sesh list --pr                   # List open pull requests
+  sesh list --pr --no-fetch        # List open pull requests without fetching first
   sesh list --json                 # Output in JSON format
   sesh list --plain                # Output session names only (for piping to fzf)
+  sesh list --projects --plain     # Output tab-separated project columns (for awk)
   sesh list --current-project      # List sessions for current project only
   sesh list --running              # List only running sessions
-  sesh list --all                  # List all sessions (running and stopped)`,
+  sesh list --all                  # List all sessions (running and stopped)
+  sesh list --watch                # Refresh the sessions every few seconds
+  sesh list --tag backend          # List sessions of projects tagged backend`,
 	RunE: runList,
 }
 
@@ -58,11 +78,20 @@ func init() {
 	listCmd.Flags().BoolVar(&listProjects, "projects", false, "Show only projects")
 	listCmd.Flags().BoolVar(&listSessions, "sessions", false, "Show only sessions (default)")
 	listCmd.Flags().BoolVar(&listPRs, "pr", false, "Show open pull requests")
+	listCmd.Flags().BoolVar(&listNoFetch, "no-fetch", false, "With --pr, don't fetch even when the last fetch is stale")
 	listCmd.Flags().BoolVar(&listJSON, "json", false, "Output in JSON format")
-	listCmd.Flags().BoolVar(&listPlain, "plain", false, "Output session names only (for piping)")
+	listCmd.Flags().
+		BoolVar(&listPlain, "plain", false, "Output session names only, or tab-separated columns with --projects")
 	listCmd.Flags().BoolVar(&listCurrentProject, "current-project", false, "Filter to sessions for current project")
 	listCmd.Flags().BoolVar(&listRunning, "running", false, "Show only running sessions")
 	listCmd.Flags().BoolVar(&listAll, "all", false, "Show all sessions (running and stopped)")
+	listCmd.Flags().StringArrayVar(&listTags, "tag", nil, "Show only projects with this tag (repeatable)")
+	listCmd.Flags().BoolVarP(&listWatch, "watch", "w", false, "Refresh the sessions until interrupted")
+	listCmd.Flags().
+		DurationVar(&listWatchInterval, "interval", 2*time.Second, "With --watch, time between refreshes")
+	listCmd.MarkFlagsMutuallyExclusive("watch", "json")
+	listCmd.MarkFlagsMutuallyExclusive("watch", "plain")
+	listCmd.Flags().BoolVar(&listGroup, "group", false, "With --projects, group projects by host and owner")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -72,6 +101,19 @@ func runList(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to load configuration")
 	}
 
+	for _, tag := range listTags {
+		if err := config.ValidateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	if listWatch && (listProjects || listPRs) {
+		return eris.New("--watch only applies to sessions")
+	}
+	if listWatch && listWatchInterval <= 0 {
+		return eris.New("--interval must be positive")
+	}
+
 	if listProjects {
 		return listAllProjects(cfg)
 	}
@@ -81,7 +123,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Default: list sessions
-	return listAllSessions(cfg)
+	return listAllSessions(cmd.Context(), cfg)
 }
 
 func listAllProjects(cfg *config.Config) error {
@@ -92,8 +134,19 @@ func listAllProjects(cfg *config.Config) error {
 	if err != nil {
 		return eris.Wrap(err, "failed to discover projects")
 	}
+	tags := loadProjectTags(cfg, projects)
+	if len(listTags) > 0 {
+		projects = filterProjectsByTags(projects, tags, listTags)
+	}
 
 	if len(projects) == 0 {
+		if listPlain {
+			return nil
+		}
+		if len(listTags) > 0 {
+			disp.Infof("No projects tagged %s.", strings.Join(listTags, ", "))
+			return nil
+		}
 		disp.Info("No projects found.")
 		disp.Printf(
 			"  %s Clone a repository with: %s\n",
@@ -113,58 +166,33 @@ func listAllProjects(cfg *config.Config) error {
 		return nil
 	}
 
+	if listPlain {
+		// Plain output: the name, worktree count, remote URL, bare repository, and tags of each project
+		for _, proj := range projects {
+			worktrees, err := state.DiscoverWorktrees(proj)
+			if err != nil {
+				continue
+			}
+			printPlain([]string{
+				proj.Name,
+				strconv.Itoa(len(worktrees)),
+				proj.RemoteURL,
+				proj.LocalPath,
+				strings.Join(tags[proj.Name], ","),
+			})
+		}
+		return nil
+	}
+
 	// Print tree header
 	disp.Printf("\n%s\n", disp.Bold("Projects"))
 	disp.Println()
 
-	for i, proj := range projects {
-		// Get worktree count
-		worktrees, err := state.DiscoverWorktrees(proj)
-		if err != nil {
-			// Skip projects with errors
-			continue
-		}
-
-		isLast := i == len(projects)-1
-		created := formatTimeAgo(proj.CreatedAt)
-
-		// Print project node
-		prefix := "├──"
-		childPrefix := "│   "
-		if isLast {
-			prefix = "└──"
-			childPrefix = "    "
-		}
-
-		disp.Printf(
-			"%s %s %s\n",
-			disp.Faint(prefix),
-			disp.Bold(proj.Name),
-			disp.Faint(
-				fmt.Sprintf(
-					"(%d worktree%s, created %s)",
-					len(worktrees),
-					pluralize(len(worktrees)),
-					created,
-				),
-			),
-		)
-
-		// Print worktrees as children
-		for j, wt := range worktrees {
-			isLastWorktree := j == len(worktrees)-1
-			wtPrefix := "├──"
-			if isLastWorktree {
-				wtPrefix = "└──"
-			}
-
-			lastUsed := formatTimeAgo(wt.LastUsed)
-			disp.Printf("%s%s %s %s\n",
-				disp.Faint(childPrefix),
-				disp.Faint(wtPrefix),
-				disp.InfoText(wt.Branch),
-				disp.Faint(fmt.Sprintf("(last used %s)", lastUsed)),
-			)
+	if listGroup {
+		printProjectGroups(disp, groupProjects(projects).children, "", tags)
+	} else {
+		for i, proj := range projects {
+			printProject(disp, proj, proj.Name, "", i == len(projects)-1, tags[proj.Name])
 		}
 	}
 	disp.Println()
@@ -172,92 +200,145 @@ func listAllProjects(cfg *config.Config) error {
 	return nil
 }
 
-func listAllSessions(cfg *config.Config) error {
-	disp := display.NewStderr()
-
-	// Initialize session manager
-	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+// printProject prints the node of a project in the tree of 'sesh list --projects', under the
+// given label, and its worktrees as children. indent is what the node's parents put before it.
+func printProject(
+	disp display.Printer,
+	proj *models.Project,
+	label, indent string,
+	isLast bool,
+	tags []string,
+) {
+	// Get worktree count
+	worktrees, err := state.DiscoverWorktrees(proj)
 	if err != nil {
-		return eris.Wrap(err, "failed to initialize session manager")
-	}
+		// Skip projects with errors
+		return
+	}
+
+	created := formatTimeAgo(proj.CreatedAt)
+
+	// Print project node
+	prefix := "├──"
+	childPrefix := indent + "│   "
+	if isLast {
+		prefix = "└──"
+		childPrefix = indent + "    "
+	}
+
+	disp.Printf(
+		"%s%s %s %s%s\n",
+		disp.Faint(indent),
+		disp.Faint(prefix),
+		disp.Bold(label),
+		disp.Faint(
+			fmt.Sprintf(
+				"(%d worktree%s, created %s)",
+				len(worktrees),
+				pluralize(len(worktrees)),
+				created,
+			),
+		),
+		formatTags(disp, tags),
+	)
+
+	// Print worktrees as children
+	for j, wt := range worktrees {
+		isLastWorktree := j == len(worktrees)-1
+		wtPrefix := "├──"
+		if isLastWorktree {
+			wtPrefix = "└──"
+		}
 
-	// Discover all projects and worktrees
-	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
-	if err != nil {
-		return eris.Wrap(err, "failed to discover projects")
+		lastUsed := formatTimeAgo(wt.LastUsed)
+		disp.Printf("%s%s %s %s\n",
+			disp.Faint(childPrefix),
+			disp.Faint(wtPrefix),
+			disp.InfoText(wt.Branch),
+			disp.Faint(fmt.Sprintf("(last used %s)", lastUsed)),
+		)
 	}
+}
 
-	// Clean up orphaned sessions for all projects
-	for _, proj := range projects {
-		_ = cleanOrphanedSessions(proj, sessionMgr, disp)
-	}
+// projectGroup is a node of the tree of 'sesh list --projects --group': a host, an owner, or
+// a project, named by the segments of project names it stands for
+type projectGroup struct {
+	name     string
+	project  *models.Project
+	children []*projectGroup
+}
 
-	// Get all running sessions
-	runningSessions, err := state.DiscoverSessions(sessionMgr)
-	if err != nil {
-		return eris.Wrap(err, "failed to discover sessions")
+// groupProjects arranges projects in a tree by the segments of their names, e.g. github.com,
+// then the owner, then the repository. Groups with a single child are merged with it, so
+// "github.com/acme" is one node when acme is the only owner on github.com.
+func groupProjects(projects []*models.Project) *projectGroup {
+	root := &projectGroup{}
+	for _, proj := range projects {
+		node := root
+		for _, segment := range strings.Split(proj.Name, "/") {
+			i := slices.IndexFunc(node.children, func(child *projectGroup) bool {
+				return child.name == segment && child.project == nil
+			})
+			if i < 0 {
+				node.children = append(node.children, &projectGroup{name: segment})
+				i = len(node.children) - 1
+			}
+			node = node.children[i]
+		}
+		node.project = proj
 	}
 
-	// Detect current project if --current-project flag is set
-	var currentProjectName string
-	if listCurrentProject {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return eris.Wrap(err, "failed to get current working directory")
+	var collapse func(node *projectGroup)
+	collapse = func(node *projectGroup) {
+		for node.project == nil && len(node.children) == 1 {
+			child := node.children[0]
+			node.name += "/" + child.name
+			node.project, node.children = child.project, child.children
 		}
-
-		// Resolve project from current directory
-		currentProj, err := project.ResolveProject(cfg.WorkspaceDir, "", cwd)
-		if err != nil {
-			return eris.Wrap(err, "failed to resolve current project - are you in a sesh workspace?")
+		for _, child := range node.children {
+			collapse(child)
 		}
-		currentProjectName = currentProj.Name
 	}
-
-	type SessionDetail struct {
-		SessionName  string
-		ProjectName  string
-		Branch       string
-		WorktreePath string
-		LastUsed     time.Time
-		IsRunning    bool
+	for _, child := range root.children {
+		collapse(child)
 	}
+	return root
+}
 
-	var sessions []SessionDetail
-
-	// Build session details by matching worktrees to running sessions
-	for _, proj := range projects {
-		// Filter by current project if requested
-		if listCurrentProject && proj.Name != currentProjectName {
+// printProjectGroups prints the groups of 'sesh list --projects --group', and the projects in them
+func printProjectGroups(disp display.Printer, groups []*projectGroup, indent string, tags map[string][]string) {
+	for i, group := range groups {
+		isLast := i == len(groups)-1
+		if group.project != nil {
+			printProject(disp, group.project, group.name, indent, isLast, tags[group.project.Name])
 			continue
 		}
 
-		worktrees, err := state.DiscoverWorktrees(proj)
-		if err != nil {
-			continue
+		prefix, childIndent := "├──", indent+"│   "
+		if isLast {
+			prefix, childIndent = "└──", indent+"    "
 		}
+		disp.Printf("%s%s %s\n", disp.Faint(indent), disp.Faint(prefix), disp.Bold(group.name+"/"))
+		printProjectGroups(disp, group.children, childIndent, tags)
+	}
+}
 
-		for _, wt := range worktrees {
-			// Generate expected session name
-			sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
+func listAllSessions(ctx context.Context, cfg *config.Config) error {
+	disp := display.NewStderr()
 
-			// Check if this session is running
-			isRunning := slices.Contains(runningSessions, sessionName)
+	// Initialize session manager
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
 
-			// Filter by running state if requested
-			if listRunning && !isRunning {
-				continue
-			}
+	if listWatch {
+		return watchSessions(ctx, cfg, sessionMgr)
+	}
 
-			sessions = append(sessions, SessionDetail{
-				SessionName:  sessionName,
-				ProjectName:  proj.Name,
-				Branch:       wt.Branch,
-				WorktreePath: wt.Path,
-				LastUsed:     wt.LastUsed,
-				IsRunning:    isRunning,
-			})
-		}
+	sessions, err := loadSessionDetails(ctx, cfg, sessionMgr, disp)
+	if err != nil {
+		return err
 	}
 
 	if len(sessions) == 0 {
@@ -265,6 +346,10 @@ func listAllSessions(cfg *config.Config) error {
 		if listPlain {
 			return nil
 		}
+		if len(listTags) > 0 {
+			disp.Infof("No worktrees of projects tagged %s.", strings.Join(listTags, ", "))
+			return nil
+		}
 		disp.Info("No worktrees found.")
 		disp.Printf(
 			"  %s Clone a repository with: %s\n",
@@ -280,6 +365,13 @@ func listAllSessions(cfg *config.Config) error {
 	}
 
 	if listJSON {
+		// Comparing with the upstream runs git in every worktree, so only JSON consumers pay for it
+		for i := range sessions {
+			if tracking, err := git.GetAheadBehind(sessions[i].WorktreePath); err == nil {
+				sessions[i].Tracking = tracking
+			}
+		}
+
 		data, err := json.MarshalIndent(sessions, "", "  ")
 		if err != nil {
 			return eris.Wrap(err, "failed to marshal sessions to JSON")
@@ -297,8 +389,80 @@ func listAllSessions(cfg *config.Config) error {
 		return nil
 	}
 
+	printSessionTree(disp, sessions, nil)
+	return nil
+}
+
+// loadSessionDetails returns the session details of the worktrees 'sesh list' shows, with the
+// state of their running sessions, after cleaning up orphaned sessions
+func loadSessionDetails(
+	ctx context.Context,
+	cfg *config.Config,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) ([]sessionDetail, error) {
+	// Discover all projects and worktrees
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to discover projects")
+	}
+
+	// Clean up orphaned sessions for all projects
+	for _, proj := range projects {
+		_ = cleanOrphanedSessions(proj, sessionMgr, disp)
+	}
+
+	if len(listTags) > 0 {
+		projects = filterProjectsByTags(projects, loadProjectTags(cfg, projects), listTags)
+	}
+
+	// Get all running sessions
+	runningSessions, err := state.DiscoverSessions(sessionMgr)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to discover sessions")
+	}
+
+	// Detect current project if --current-project flag is set
+	var currentProjectName string
+	if listCurrentProject {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to get current working directory")
+		}
+
+		// Resolve project from current directory
+		currentProj, err := project.ResolveProject(cfg.WorkspaceDir, "", cwd)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to resolve current project - are you in a sesh workspace?")
+		}
+		currentProjectName = currentProj.Name
+	}
+
+	sessions := collectSessionDetails(projects, runningSessions, currentProjectName, listRunning)
+	sortPinnedFirst(sessions, pinnedSessions())
+	for i := range sessions {
+		if !sessions[i].IsRunning {
+			continue
+		}
+		if clients, err := sessionMgr.AttachedClients(sessions[i].SessionName); err == nil {
+			sessions[i].Attached = len(clients) > 0
+		}
+		sessions[i].ReadyCheck, sessions[i].Ready = probeReady(ctx, sessions[i].WorktreePath)
+		if supervisor, ok := sessionMgr.(session.ProcessSupervisor); ok {
+			sessions[i].Processes, _ = supervisor.ListProcesses(sessions[i].SessionName)
+		}
+		sessions[i].Services = composeStatus(ctx, sessions[i].SessionName, sessions[i].WorktreePath)
+	}
+
+	return sessions, nil
+}
+
+// printSessionTree prints the sessions of 'sesh list' as a tree of projects and their worktrees.
+// transitions holds the state sessions were in before changing, or "" for new ones, for
+// 'sesh list --watch' to highlight them.
+func printSessionTree(disp display.Printer, sessions []sessionDetail, transitions map[string]string) {
 	// Group sessions by project for tree rendering
-	projectMap := make(map[string][]SessionDetail)
+	projectMap := make(map[string][]sessionDetail)
 	var projectOrder []string
 	projectSeen := make(map[string]bool)
 
@@ -314,6 +478,8 @@ func listAllSessions(cfg *config.Config) error {
 	disp.Printf("\n%s\n", disp.Bold("Sessions"))
 	disp.Println()
 
+	hasExternal := false
+
 	for i, projName := range projectOrder {
 		isLastProject := i == len(projectOrder)-1
 		projSessions := projectMap[projName]
@@ -344,20 +510,280 @@ func listAllSessions(cfg *config.Config) error {
 			if sess.IsRunning {
 				statusIcon = disp.SuccessText("●")
 				statusText = disp.SuccessText("running")
+				if sess.Attached {
+					statusText = disp.SuccessText("attached")
+				}
+			}
+
+			if sess.ReadyCheck != "" {
+				if sess.Ready {
+					statusText += " " + disp.SuccessText("✓ ready")
+				} else {
+					statusText += " " + disp.WarningText("✗ not ready")
+				}
+			}
+			for _, proc := range sess.Processes {
+				if proc.Running {
+					statusText += " " + proc.Name + " " + disp.SuccessText("●")
+				} else {
+					statusText += " " + proc.Name + " " + disp.ErrorText(fmt.Sprintf("✗(%d)", proc.ExitStatus))
+				}
+			}
+			for _, service := range sess.Services {
+				switch {
+				case service.Running():
+					statusText += " " + service.Name + " " + disp.SuccessText("●")
+				case service.State == "exited":
+					statusText += " " + service.Name + " " + disp.ErrorText(fmt.Sprintf("✗(%d)", service.ExitCode))
+				default:
+					statusText += " " + service.Name + " " + disp.Faint(service.State)
+				}
+			}
+
+			if from, ok := transitions[sess.SessionName]; ok {
+				change := "new"
+				if from != "" {
+					change = "was " + from
+				}
+				statusText += " " + disp.WarningText("("+change+")")
+			}
+
+			pinned := ""
+			if sess.Pinned {
+				pinned = " " + disp.WarningText("★")
+			}
+
+			external := ""
+			if sess.External {
+				external = " " + disp.WarningText("external")
+				hasExternal = true
 			}
 
-			disp.Printf("%s%s %s %s %s\n",
+			branch := disp.InfoText(sess.Branch)
+			if sess.Subproject != "" {
+				branch += " " + disp.Faint("("+sess.Subproject+")")
+			}
+
+			disp.Printf("%s%s %s%s %s %s%s\n",
 				disp.Faint(childPrefix),
 				disp.Faint(sessPrefix),
-				disp.InfoText(sess.Branch),
+				branch,
+				pinned,
 				statusIcon,
 				statusText,
+				external,
 			)
 		}
 	}
 	disp.Println()
 
-	return nil
+	if hasExternal {
+		disp.Printf(
+			"  %s External worktrees weren't created by sesh; move them where it expects with: %s\n\n",
+			disp.Faint("→"),
+			disp.Bold("sesh adopt --all"),
+		)
+	}
+}
+
+// watchSessions prints the session tree of 'sesh list' again every --interval, until interrupted.
+// The sessions that changed state are highlighted for a while with the state they had before.
+func watchSessions(ctx context.Context, cfg *config.Config, sessionMgr session.SessionManager) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(listWatchInterval)
+	defer ticker.Stop()
+
+	var transitions sessionTransitions
+	for {
+		// The frame is rendered before clearing the screen, so the tree doesn't flicker
+		var frame bytes.Buffer
+		disp := display.New(&frame)
+		sessions, err := loadSessionDetails(ctx, cfg, sessionMgr, disp)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		changed := transitions.update(sessions, now)
+
+		if len(sessions) == 0 {
+			disp.Println()
+			disp.Info("No worktrees found.")
+			disp.Println()
+		} else {
+			printSessionTree(disp, sessions, changed)
+		}
+		disp.Printf(
+			"  %s\n",
+			disp.Faint(fmt.Sprintf("Every %s, updated %s · ctrl+c to quit", listWatchInterval, now.Format("15:04:05"))),
+		)
+		// Move to the top left and clear the screen
+		fmt.Fprint(os.Stderr, "\033[H\033[2J"+frame.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sessionTransitions tracks the sessions of 'sesh list --watch' that changed state recently
+type sessionTransitions struct {
+	// previous is the state of each session at the last update, nil before the first one
+	previous map[string]string
+	from     map[string]string
+	at       map[string]time.Time
+}
+
+// update records the state of the sessions, and returns the ones that changed state in the last
+// listWatchHighlight, with the state they had before, or "" for new ones
+func (t *sessionTransitions) update(sessions []sessionDetail, now time.Time) map[string]string {
+	if t.from == nil {
+		t.from, t.at = make(map[string]string), make(map[string]time.Time)
+	}
+
+	current := make(map[string]string, len(sessions))
+	for _, sess := range sessions {
+		current[sess.SessionName] = sessionState(sess)
+		if from, ok := t.previous[sess.SessionName]; t.previous != nil && (!ok || from != current[sess.SessionName]) {
+			t.from[sess.SessionName], t.at[sess.SessionName] = from, now
+		}
+	}
+	for name, at := range t.at {
+		if now.Sub(at) > listWatchHighlight {
+			delete(t.from, name)
+			delete(t.at, name)
+		}
+	}
+	t.previous = current
+	return t.from
+}
+
+// sessionState returns the state of a session highlighted by 'sesh list --watch' when it changes
+func sessionState(sess sessionDetail) string {
+	switch {
+	case sess.Attached:
+		return "attached"
+	case sess.IsRunning:
+		return "running"
+	default:
+		return "stopped"
+	}
+}
+
+// sessionDetail describes the session of one worktree, as output by 'sesh list --json'
+type sessionDetail struct {
+	SessionName string
+	ProjectName string
+	Branch      string
+	// The sub-project of the worktree the session is of, for sessions opened with --subproject
+	Subproject   string `json:",omitempty"`
+	WorktreePath string
+	LastUsed     time.Time
+	IsRunning    bool
+	// Whether a client is attached to the running session, e.g. in another terminal
+	Attached bool
+	// What the project's ready_check probes for the running session, and whether it answers
+	ReadyCheck string `json:",omitempty"`
+	Ready      bool   `json:",omitempty"`
+	// The processes of the project config started in the running session, and whether they still run
+	Processes []session.ProcessStatus `json:",omitempty"`
+	// The services of the project's compose file for the running session, and their state
+	Services []compose.ServiceStatus `json:",omitempty"`
+	// Whether the worktree wasn't created by sesh, like ones added with 'git worktree add'
+	External bool
+	// Whether the session was pinned with 'sesh pin', which lists it first
+	Pinned bool
+	// Commits ahead of and behind the upstream branch; only filled in for --json
+	Tracking *git.AheadBehind `json:",omitempty"`
+}
+
+// collectSessionDetails builds the session details of every worktree in the projects
+// Only the named project is included when projectName is set, and only running sessions
+// when runningOnly is set
+func collectSessionDetails(
+	projects []*models.Project,
+	runningSessions []string,
+	projectName string,
+	runningOnly bool,
+) []sessionDetail {
+	var sessions []sessionDetail
+
+	// Build session details by matching worktrees to running sessions
+	for _, proj := range projects {
+		// Filter by project if requested
+		if projectName != "" && proj.Name != projectName {
+			continue
+		}
+
+		worktrees, err := state.DiscoverWorktrees(proj)
+		if err != nil {
+			continue
+		}
+
+		workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+		for _, wt := range worktrees {
+			// The bare repository is listed by git but has no branch or session
+			if wt.Path == proj.LocalPath {
+				continue
+			}
+
+			// Generate expected session name
+			sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
+
+			// Check if this session is running
+			isRunning := slices.Contains(runningSessions, sessionName)
+
+			// Filter by running state if requested
+			if runningOnly && !isRunning {
+				continue
+			}
+
+			sessions = append(sessions, sessionDetail{
+				SessionName:  sessionName,
+				ProjectName:  proj.Name,
+				Branch:       wt.Branch,
+				WorktreePath: wt.Path,
+				LastUsed:     wt.LastUsed,
+				IsRunning:    isRunning,
+				External:     workspace.IsExternalWorktree(workspaceDir, proj.Name, wt.Branch, wt.Path),
+			})
+			sessions = append(sessions, runningSubprojectSessions(proj.Name, wt, runningSessions)...)
+		}
+	}
+
+	return sessions
+}
+
+// runningSubprojectSessions returns the session details of the running sessions of the
+// sub-projects of a worktree. Sub-projects without a running session aren't listed, since
+// their sessions are only opened on demand.
+func runningSubprojectSessions(projectName string, wt *models.Worktree, runningSessions []string) []sessionDetail {
+	projectConfig, err := config.LoadProjectConfig(wt.Path)
+	if err != nil || len(projectConfig.Subprojects) == 0 {
+		return nil
+	}
+
+	var sessions []sessionDetail
+	for _, name := range slices.Sorted(maps.Keys(projectConfig.Subprojects)) {
+		sub := projectConfig.Subprojects[name]
+		sessionName := workspace.GenerateSubprojectSessionName(projectName, name, sub.SessionName, wt.Branch)
+		if !slices.Contains(runningSessions, sessionName) {
+			continue
+		}
+		sessions = append(sessions, sessionDetail{
+			SessionName:  sessionName,
+			ProjectName:  projectName,
+			Branch:       wt.Branch,
+			Subproject:   name,
+			WorktreePath: filepath.Join(wt.Path, sub.Path),
+			LastUsed:     wt.LastUsed,
+			IsRunning:    true,
+		})
+	}
+	return sessions
 }
 
 // formatTimeAgo formats a time as a human-readable "time ago" string
@@ -414,6 +840,14 @@ func truncate(s string, maxLen int) string {
 }
 
 // pluralize returns "s" if count is not 1, otherwise empty string
+// formatTags formats the tags of a project after its name, or nothing when it has none
+func formatTags(disp display.Printer, tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return " " + disp.InfoText("#"+strings.Join(tags, " #"))
+}
+
 func pluralize(count int) string {
 	if count == 1 {
 		return ""
@@ -437,29 +871,20 @@ func listAllPRs(ctx context.Context, cfg *config.Config) error {
 		return eris.Wrap(err, "failed to resolve project from current directory")
 	}
 
-	// Get remote URL
-	remoteURL, err := git.GetRemoteURL(proj.LocalPath)
-	if err != nil {
-		return eris.Wrap(err, "failed to get remote URL")
-	}
-
-	// Create PR provider
-	provider, err := pr.NewProvider(remoteURL)
-	if err != nil {
-		return eris.Wrap(err, "failed to create PR provider")
-	}
-
-	// Check if gh CLI is installed and authenticated (for GitHub)
-	if provider.Name() == "github" {
-		if err := pr.CheckGHCLI(); err != nil {
-			return err
+	// Fetch first when the last fetch is stale, so the branches of the PRs can be switched to
+	if !listNoFetch && needsFetch(cfg, proj) {
+		disp.Printf("%s Fetching %s...\n", disp.InfoText("→"), proj.Name)
+		if err := git.Fetch(ctx, proj.LocalPath); err != nil {
+			disp.Warningf("Failed to fetch %s: %v", proj.Name, err)
+		} else {
+			markProjectFetched(proj)
 		}
 	}
 
 	// List open PRs
-	prs, err := provider.ListOpenPRs(ctx, proj.LocalPath)
+	prs, err := listOpenPRs(ctx, proj)
 	if err != nil {
-		return eris.Wrap(err, "failed to list pull requests")
+		return err
 	}
 
 	if len(prs) == 0 {
@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"maps"
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/benoctopus/sesh/internal/models"
 )
 
 func TestFormatTimeAgo(t *testing.T) {
@@ -154,3 +158,131 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterProjectsByTags(t *testing.T) {
+	projects := []*models.Project{
+		{Name: "github.com/acme/api"},
+		{Name: "github.com/acme/web"},
+		{Name: "github.com/acme/worker"},
+	}
+	tags := map[string][]string{
+		"github.com/acme/api":    {"backend", "go"},
+		"github.com/acme/web":    {"frontend"},
+		"github.com/acme/worker": {"backend"},
+	}
+
+	tests := []struct {
+		name     string
+		required []string
+		want     []string
+	}{
+		{name: "one tag", required: []string{"backend"}, want: []string{"github.com/acme/api", "github.com/acme/worker"}},
+		{name: "all tags", required: []string{"backend", "go"}, want: []string{"github.com/acme/api"}},
+		{name: "unknown tag", required: []string{"mobile"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, proj := range filterProjectsByTags(projects, tags, tt.required) {
+				got = append(got, proj.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterProjectsByTags(%v) = %v, want %v", tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupProjects(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects []string
+		want     []string
+	}{
+		{
+			name:     "hosts and owners",
+			projects: []string{"github.com/acme/api", "github.com/acme/web", "github.com/octo/cli", "gitlab.com/team/app"},
+			want: []string{
+				"github.com/",
+				"  acme/",
+				"    api github.com/acme/api",
+				"    web github.com/acme/web",
+				"  octo/cli github.com/octo/cli",
+				"gitlab.com/team/app gitlab.com/team/app",
+			},
+		},
+		{
+			name:     "single owner",
+			projects: []string{"github.com/acme/api", "github.com/acme/web"},
+			want: []string{
+				"github.com/acme/",
+				"  api github.com/acme/api",
+				"  web github.com/acme/web",
+			},
+		},
+		{
+			name:     "project at a group's path",
+			projects: []string{"local/tools", "local/tools/lint"},
+			want: []string{
+				"local/",
+				"  tools local/tools",
+				"  tools/lint local/tools/lint",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var projects []*models.Project
+			for _, name := range tt.projects {
+				projects = append(projects, &models.Project{Name: name})
+			}
+
+			var got []string
+			var walk func(groups []*projectGroup, indent string)
+			walk = func(groups []*projectGroup, indent string) {
+				for _, group := range groups {
+					if group.project != nil {
+						got = append(got, indent+group.name+" "+group.project.Name)
+						continue
+					}
+					got = append(got, indent+group.name+"/")
+					walk(group.children, indent+"  ")
+				}
+			}
+			walk(groupProjects(projects).children, "")
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("groupProjects(%v) = %q, want %q", tt.projects, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionTransitions(t *testing.T) {
+	start := time.Now()
+	var transitions sessionTransitions
+
+	if got := transitions.update([]sessionDetail{{SessionName: "api-main"}}, start); len(got) != 0 {
+		t.Errorf("first update = %v, want no transitions", got)
+	}
+
+	got := transitions.update([]sessionDetail{
+		{SessionName: "api-main", IsRunning: true},
+		{SessionName: "api-feature"},
+	}, start.Add(2*time.Second))
+	want := map[string]string{"api-main": "stopped", "api-feature": ""}
+	if !maps.Equal(got, want) {
+		t.Errorf("update after changes = %v, want %v", got, want)
+	}
+
+	got = transitions.update([]sessionDetail{
+		{SessionName: "api-main", IsRunning: true, Attached: true},
+		{SessionName: "api-feature"},
+	}, start.Add(listWatchHighlight+3*time.Second))
+	want = map[string]string{"api-main": "running"}
+	if !maps.Equal(got, want) {
+		t.Errorf("update after the highlight expired = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/lock"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// projectLockTimeout is how long to wait for another sesh process working on the same project
+const projectLockTimeout = 2 * time.Minute
+
+// lockProject takes the per-project lock that serializes changes to a project's git state
+// (clone, worktree creation and removal) across concurrent sesh invocations, e.g. a tmux
+// keybinding and a terminal running 'sesh switch' at the same time.
+// The returned unlock function is safe to call more than once.
+func lockProject(cfg *config.Config, projectName string, disp display.Printer) (func(), error) {
+	path := workspace.GetLockPath(cfg.WorkspaceDir, projectName)
+
+	l, err := lock.TryAcquire(path)
+	if errors.Is(err, lock.ErrLocked) {
+		disp.Printf(
+			"%s Waiting for another sesh process working on %s...\n",
+			disp.InfoText("⏳"),
+			disp.Bold(projectName),
+		)
+		l, err = lock.Acquire(path, projectLockTimeout)
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to lock project %s", projectName)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			//nolint:errcheck // The lock is released by the OS on exit anyway
+			l.Release()
+		})
+	}, nil
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLimit       int
+	logProjectName string
+	logBranch      string
+	logAll         bool
+	logJSON        bool
+	logPlain       bool
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the log of operations that changed worktrees and sessions",
+	Long: `Show the audit log of mutating operations, most recent first.
+
+Every clone, worktree/session creation, removal, and restore is recorded with
+its timestamp, the command line it was run from, and whether it succeeded.
+This helps answer "what happened to my worktree?".
+
+By default, the log is limited to the current project when run from inside one.
+
+Examples:
+  sesh log                         # Operations for the current project
+  sesh log --all                   # Operations for all projects
+  sesh log --branch feature-foo    # Operations that touched a branch
+  sesh log -n 100                  # Show more entries
+  sesh log --plain | awk -F'\t' '$2 == "failed"'   # Failed operations
+
+With --plain, each operation is printed on a line of tab-separated columns: the
+time (RFC 3339), the outcome, the operation, the project, the branch, the command
+line, and the error.`,
+	RunE: runLog,
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().IntVarP(&logLimit, "limit", "n", 50, "Maximum number of entries to show")
+	logCmd.Flags().StringVarP(&logProjectName, "project", "p", "", "Only show operations for a project")
+	logCmd.Flags().StringVarP(&logBranch, "branch", "b", "", "Only show operations for a branch")
+	logCmd.Flags().BoolVarP(&logAll, "all", "a", false, "Show operations for all projects")
+	logCmd.Flags().BoolVar(&logJSON, "json", false, "Output in JSON format")
+	logCmd.Flags().BoolVar(&logPlain, "plain", false, "Output tab-separated columns (for piping)")
+	logCmd.MarkFlagsMutuallyExclusive("json", "plain")
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	projectName := ""
+	if !logAll {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return eris.Wrap(err, "failed to load configuration")
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return eris.Wrap(err, "failed to get current working directory")
+		}
+
+		// Only an explicit --project is an error; outside a project, show everything
+		proj, err := project.ResolveProject(cfg.WorkspaceDir, logProjectName, cwd)
+		if err == nil {
+			projectName = proj.Name
+		} else if logProjectName != "" {
+			return eris.Wrap(err, "failed to resolve project")
+		}
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	entries, err := database.GetOperationLog(projectName, logBranch, logLimit)
+	if err != nil {
+		return eris.Wrap(err, "failed to read operation log")
+	}
+
+	if logJSON {
+		if entries == nil {
+			entries = []*models.OperationLogEntry{}
+		}
+		return printJSON(entries)
+	}
+	if logPlain {
+		for _, entry := range entries {
+			printPlain([]string{
+				plainTime(entry.CreatedAt),
+				entry.Outcome,
+				entry.Operation,
+				entry.ProjectName,
+				entry.Branch,
+				entry.Args,
+				entry.Error,
+			})
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		display.NewStderr().Println("No operations recorded.")
+		return nil
+	}
+
+	out := display.NewStdout()
+	for _, entry := range entries {
+		outcome := out.SuccessText("✓")
+		if entry.Outcome != "ok" {
+			outcome = out.ErrorText("✗")
+		}
+
+		target := entry.ProjectName
+		if entry.Branch != "" {
+			target += " " + out.Bold(entry.Branch)
+		}
+
+		out.Printf(
+			"%s %s  %-16s %s\n",
+			outcome,
+			out.Faint(entry.CreatedAt.Local().Format("2006-01-02 15:04:05")),
+			entry.Operation,
+			target,
+		)
+		if entry.Args != "" {
+			out.Printf("    %s\n", out.Faint("$ sesh "+entry.Args))
+		}
+		if entry.Error != "" {
+			out.Printf("    %s\n", out.ErrorText(entry.Error))
+		}
+	}
+
+	return nil
+}
+
+// recordOperation appends a mutating operation to the audit log along with the
+// command line it was run from. Failures are ignored since the log is not critical.
+func recordOperation(operation, projectName, branch string, opErr error) {
+	prepare.RecordOperation(openDB, operation, projectName, branch, opErr)
+}
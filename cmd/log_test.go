@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+// mockLogStore records operation log entries in memory
+// Embedding db.Store means any method the test doesn't expect panics
+type mockLogStore struct {
+	db.Store
+	entries []*models.OperationLogEntry
+	cleared bool
+}
+
+func (m *mockLogStore) AddOperationLog(entry *models.OperationLogEntry) error {
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *mockLogStore) ClearOldOperationLog(daysToKeep int) error {
+	m.cleared = true
+	return nil
+}
+
+func (m *mockLogStore) Close() error {
+	return nil
+}
+
+func TestRecordOperation(t *testing.T) {
+	tests := []struct {
+		name        string
+		opErr       error
+		wantOutcome string
+		wantError   string
+	}{
+		{
+			name:        "success",
+			opErr:       nil,
+			wantOutcome: "ok",
+			wantError:   "",
+		},
+		{
+			name:        "failure",
+			opErr:       errors.New("worktree is dirty"),
+			wantOutcome: "failed",
+			wantError:   "worktree is dirty",
+		},
+	}
+
+	originalOpenDB := openDB
+	defer func() { openDB = originalOpenDB }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &mockLogStore{}
+			openDB = func() (db.Store, error) { return store, nil }
+
+			recordOperation("remove-worktree", "github.com/user/repo", "feature", tt.opErr)
+
+			if len(store.entries) != 1 {
+				t.Fatalf("recorded %d entries, want 1", len(store.entries))
+			}
+			entry := store.entries[0]
+			if entry.Operation != "remove-worktree" {
+				t.Errorf("Operation = %q, want %q", entry.Operation, "remove-worktree")
+			}
+			if entry.ProjectName != "github.com/user/repo" || entry.Branch != "feature" {
+				t.Errorf("target = %q %q, want %q %q", entry.ProjectName, entry.Branch, "github.com/user/repo", "feature")
+			}
+			if entry.Outcome != tt.wantOutcome {
+				t.Errorf("Outcome = %q, want %q", entry.Outcome, tt.wantOutcome)
+			}
+			if entry.Error != tt.wantError {
+				t.Errorf("Error = %q, want %q", entry.Error, tt.wantError)
+			}
+			if !store.cleared {
+				t.Error("expected old operation log entries to be cleared")
+			}
+		})
+	}
+}
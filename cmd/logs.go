@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// logsPollInterval is how often followed logs are checked for new output
+const logsPollInterval = 250 * time.Millisecond
+
+var (
+	logsProjectName string
+	logsBranch      string
+	logsFollow      bool
+	logsLines       int
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [process]",
+	Short: "Show the output of the processes of a worktree",
+	Long: `Show the output of the processes declared in .sesh.yaml, which sesh starts in
+windows of their own when it creates a session.
+
+Without a process, the output of all of them is shown, each line preceded by
+the name of its process. The output is captured from the start of the session,
+and removed when the session is killed.
+
+Without --branch, the processes of the worktree in the current directory are shown.
+
+Examples:
+  sesh logs                        # Last lines of every process
+  sesh logs server -f              # Follow the output of the server
+  sesh logs -n 200 watch           # More of the output of the watcher
+  sesh logs -b feature-foo         # Processes of another worktree`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLogs,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().StringVarP(&logsProjectName, "project", "p", "", "Specify project explicitly")
+	logsCmd.Flags().StringVarP(&logsBranch, "branch", "b", "", "Show the processes of another branch's worktree")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep showing new output until interrupted")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "Number of lines to show of each process")
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	proj, worktreePath, branch, err := resolveLogsWorktree(cfg)
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := config.LoadProjectConfig(worktreePath)
+	if err != nil {
+		return err
+	}
+	processes := projectConfig.Processes
+	if len(processes) == 0 {
+		return eris.Errorf("%s declares no processes (see 'processes' in .sesh.yaml)", branch)
+	}
+	if len(args) == 1 {
+		i := slices.IndexFunc(processes, func(p session.Process) bool { return p.Name == args[0] })
+		if i < 0 {
+			return eris.Errorf("no process named %s (processes: %s)", args[0], processNames(processes))
+		}
+		processes = processes[i : i+1]
+	}
+
+	sessionName := workspace.GenerateSessionName(proj.Name, branch)
+	logDir, err := prepare.ProcessLogDir(sessionName)
+	if err != nil {
+		return err
+	}
+
+	var logs []*processLog
+	for _, proc := range processes {
+		path := prepare.ProcessLogPath(logDir, proc.Name)
+		if _, err := os.Stat(path); err == nil {
+			logs = append(logs, &processLog{name: proc.Name, path: path})
+		}
+	}
+	if len(logs) == 0 {
+		return eris.Errorf("no output captured for session %s (is it running?)", sessionName)
+	}
+
+	out := newLogsPrinter(display.NewStdout(), logs, len(args) == 0)
+	for _, log := range logs {
+		lines, err := log.readNew()
+		if err != nil {
+			return err
+		}
+		if !logsFollow && log.partial != "" {
+			lines = append(lines, log.partial)
+		}
+		out.print(log, lastLines(lines, logsLines))
+	}
+	if !logsFollow {
+		return nil
+	}
+	return followLogs(cmd.Context(), logs, out)
+}
+
+// followLogs prints the new output of processes as it comes, until the context is done
+func followLogs(ctx context.Context, logs []*processLog, out *logsPrinter) error {
+	ticker := time.NewTicker(logsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		for _, log := range logs {
+			lines, err := log.readNew()
+			if err != nil {
+				return err
+			}
+			out.print(log, lines)
+		}
+	}
+}
+
+// resolveLogsWorktree returns the project, worktree, and branch whose processes to show: those
+// of --branch, or of the worktree in the current directory
+func resolveLogsWorktree(cfg *config.Config) (*models.Project, string, string, error) {
+	if logsBranch == "" {
+		if logsProjectName != "" {
+			return nil, "", "", eris.New("--branch required with --project (usage: sesh logs -p <project> -b <branch>)")
+		}
+		worktreePath, commonDir, err := git.GetWorktreeRoot(".")
+		if err != nil {
+			return nil, "", "", eris.Wrap(err, "not in a worktree (give the branch with --branch)")
+		}
+		proj, err := findProjectByRepoPath(cfg, commonDir)
+		if err != nil {
+			return nil, "", "", err
+		}
+		branch, err := git.GetWorktreeBranch(worktreePath)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return proj, worktreePath, branch, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, "", "", eris.Wrap(err, "failed to get current working directory")
+	}
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, logsProjectName, cwd)
+	if err != nil {
+		return nil, "", "", eris.Wrap(err, "failed to resolve project")
+	}
+	wt, err := state.GetWorktree(proj, logsBranch)
+	if err != nil {
+		return nil, "", "", eris.Wrapf(err, "branch %s has no worktree", logsBranch)
+	}
+	return proj, wt.Path, logsBranch, nil
+}
+
+// processNames returns the names of processes, separated by commas
+func processNames(processes []session.Process) string {
+	names := make([]string, len(processes))
+	for i, proc := range processes {
+		names[i] = proc.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// processLog is the captured output of a process, read as it grows
+type processLog struct {
+	name string
+	path string
+	// offset is how much of the file was read
+	offset int64
+	// partial is the end of the output read after its last newline
+	partial string
+}
+
+// readNew returns the lines of output added since the last read. A file that shrank was
+// replaced by a restart of the process, and is read again from the start.
+func (l *processLog) readNew() ([]string, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open the output of process %s", l.name)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read the output of process %s", l.name)
+	}
+	if info.Size() < l.offset {
+		l.offset, l.partial = 0, ""
+	}
+	if _, err := file.Seek(l.offset, io.SeekStart); err != nil {
+		return nil, eris.Wrapf(err, "failed to read the output of process %s", l.name)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read the output of process %s", l.name)
+	}
+	l.offset += int64(len(data))
+
+	lines := strings.Split(l.partial+string(data), "\n")
+	l.partial = lines[len(lines)-1]
+	lines = lines[:len(lines)-1]
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines, nil
+}
+
+// lastLines returns the last n lines, or all of them when n isn't positive
+func lastLines(lines []string, n int) []string {
+	if n <= 0 || len(lines) <= n {
+		return lines
+	}
+	return lines[len(lines)-n:]
+}
+
+// logsPrinter prints the output of processes, each line preceded by the name of its process
+// when several are shown
+type logsPrinter struct {
+	disp     display.Printer
+	prefixed bool
+	width    int
+}
+
+func newLogsPrinter(disp display.Printer, logs []*processLog, prefixed bool) *logsPrinter {
+	out := &logsPrinter{disp: disp, prefixed: prefixed}
+	for _, log := range logs {
+		out.width = max(out.width, len(log.name))
+	}
+	return out
+}
+
+func (p *logsPrinter) print(log *processLog, lines []string) {
+	for _, line := range lines {
+		if p.prefixed {
+			p.disp.Printf("%s %s\n", p.disp.InfoText(log.name+strings.Repeat(" ", p.width-len(log.name))+" |"), line)
+		} else {
+			p.disp.Println(line)
+		}
+	}
+}
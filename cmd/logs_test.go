@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProcessLogReadNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	log := &processLog{name: "server", path: path}
+
+	steps := []struct {
+		name  string
+		write func() error
+		want  []string
+	}{
+		{
+			name:  "complete and partial lines",
+			write: func() error { return os.WriteFile(path, []byte("starting\r\nlistening on 3000\r\nGET /"), 0o644) },
+			want:  []string{"starting", "listening on 3000"},
+		},
+		{
+			name:  "rest of the partial line",
+			write: func() error { return appendFile(path, "health 200\n") },
+			want:  []string{"GET /health 200"},
+		},
+		{
+			name:  "no new output",
+			write: func() error { return nil },
+			want:  nil,
+		},
+		{
+			name:  "restarted",
+			write: func() error { return os.WriteFile(path, []byte("again\n"), 0o644) },
+			want:  []string{"again"},
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.write(); err != nil {
+			t.Fatal(err)
+		}
+		got, err := log.readNew()
+		if err != nil {
+			t.Fatalf("%s: readNew() error = %v", step.name, err)
+		}
+		if !slices.Equal(got, step.want) {
+			t.Errorf("%s: readNew() = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func appendFile(path, text string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(text)
+	return err
+}
+
+func TestLastLines(t *testing.T) {
+	lines := []string{"a", "b", "c"}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 2, want: []string{"b", "c"}},
+		{n: 5, want: []string{"a", "b", "c"}},
+		{n: 0, want: []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		if got := lastLines(lines, tt.n); !slices.Equal(got, tt.want) {
+			t.Errorf("lastLines(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
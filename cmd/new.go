@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newProjectName string
+	newType        string
+	newDetach      bool
+	newNoFetch     bool
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new [-p project] [description...]",
+	Short: "Start a new branch, named following the team's branch conventions",
+	Long: `Create a new branch with a worktree and session, and switch to it.
+
+The project is fetched first, and the branch starts at the tip of the remote's
+default branch, e.g. origin/main, rather than at whatever the local default
+branch was last updated to. Use --no-fetch to start from the last fetch.
+
+With branch_template set, e.g. to "{{.User}}/{{.Type}}/{{.Slug}}", the branch is
+named following it: the description becomes the slug, like "fix-login" for
+"Fix login", the user is the name of your git email address, and the type is
+one of branch_types (feat, fix, chore, docs, refactor, or test by default).
+The type and description are asked for when they aren't given.
+
+Without branch_template, the name is used as it is, like with 'sesh switch'.
+
+Examples:
+  sesh new                           # Ask for the type and description
+  sesh new -t fix Login fails        # e.g. jdoe/fix/login-fails
+  sesh new -p myproject add-metrics  # Explicit project
+  sesh new -d cleanup                # Create session without attaching
+  sesh new --no-fetch hotfix         # Start from the last fetch, e.g. offline`,
+	RunE: runNew,
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd)
+	newCmd.Flags().
+		StringVarP(&newProjectName, "project", "p", "", "Specify project explicitly")
+	newCmd.Flags().
+		StringVarP(&newType, "type", "t", "", "Type of the branch, one of branch_types")
+	newCmd.Flags().
+		BoolVarP(&newDetach, "detach", "d", false, "Create session without attaching to it")
+	newCmd.Flags().
+		BoolVar(&newDetach, "no-attach", false, "Same as --detach")
+	newCmd.Flags().
+		BoolVar(&newNoFetch, "no-fetch", false, "Don't fetch, and start from the last fetch of the default branch")
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return eris.Wrap(err, "failed to get current working directory")
+	}
+
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, newProjectName, cwd)
+	if err != nil {
+		return eris.Wrap(err, "failed to resolve project")
+	}
+
+	branch, err := newBranchName(cfg, proj, strings.Join(args, " "), bufio.NewReader(os.Stdin), disp)
+	if err != nil {
+		return err
+	}
+	if err := git.CheckBranchName(branch); err != nil {
+		return err
+	}
+
+	// The remote branches are checked after the fetch, so branches pushed meanwhile are found
+	if !newNoFetch && !isOffline() {
+		if err := fetchProject(cmd.Context(), proj, disp); err != nil {
+			disp.Warningf("Failed to fetch, starting from the last fetch: %v", err)
+		}
+	}
+
+	// A new branch mustn't silently become a switch to someone else's work
+	if exists, _, err := git.DoesBranchExist(proj.LocalPath, branch); err != nil {
+		return err
+	} else if exists {
+		return eris.Errorf("branch %s already exists (use 'sesh switch %s')", branch, branch)
+	}
+	remote := cfg.Remote.For(proj.Name)
+	if exists, err := git.DoesBranchExistRemotely(proj.LocalPath, remote, branch); err != nil {
+		return err
+	} else if exists {
+		return eris.Errorf("branch %s already exists on %s (use 'sesh switch %s')", branch, remote, branch)
+	}
+
+	if err := createNewBranch(cfg, proj, branch, disp); err != nil {
+		return err
+	}
+
+	return enterBranch(cmd.Context(), cfg, proj, branch, newDetach, attachMode{}, disp)
+}
+
+// createNewBranch creates a branch at the tip of the remote's default branch, so its worktree
+// is created from it. The local default branch is only used when the remote has none.
+func createNewBranch(cfg *config.Config, proj *models.Project, branch string, disp display.Printer) error {
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	defaultBranch, err := git.GetDefaultBranch(proj.LocalPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to get default branch")
+	}
+
+	remote := cfg.Remote.For(proj.Name)
+	startPoint := defaultBranch
+	if exists, err := git.DoesBranchExistRemotely(proj.LocalPath, remote, defaultBranch); err != nil {
+		return err
+	} else if exists {
+		startPoint = remote + "/" + defaultBranch
+	} else {
+		disp.Warningf("%s has no branch %s, starting from the local one", remote, defaultBranch)
+	}
+
+	disp.Printf("%s Creating branch %s from %s\n", disp.InfoText("→"), disp.Bold(branch), startPoint)
+	err = git.CreateBranch(proj.LocalPath, branch, startPoint)
+	recordOperation("create-branch", proj.Name, branch, err)
+	return err
+}
+
+// newBranchName names a new branch following the branch template, asking for the type and
+// description when they aren't given. Without a template, the description is the name.
+func newBranchName(
+	cfg *config.Config,
+	proj *models.Project,
+	description string,
+	reader *bufio.Reader,
+	disp display.Printer,
+) (string, error) {
+	var err error
+	if description == "" && !tty.IsInteractive() {
+		return "", eris.New("description argument required in noninteractive mode (usage: sesh new <description>)")
+	}
+	if cfg.BranchTemplate == "" {
+		if description == "" {
+			description, err = askLine(reader, disp, "Branch name: ")
+		}
+		return description, err
+	}
+
+	data := workspace.BranchTemplateData{User: branchUser(proj)}
+	if workspace.BranchTemplateUses(cfg.BranchTemplate, "Type") {
+		if data.Type, err = newBranchType(cfg.BranchTypes, reader, disp); err != nil {
+			return "", err
+		}
+	}
+
+	if description == "" {
+		if description, err = askLine(reader, disp, "Description: "); err != nil {
+			return "", err
+		}
+	}
+	data.Slug = workspace.Slugify(description)
+	if data.Slug == "" {
+		return "", eris.Errorf("the description must contain letters or digits: %q", description)
+	}
+
+	return workspace.ExpandBranchTemplate(cfg.BranchTemplate, data)
+}
+
+// newBranchType returns the type given with --type, or asks for one of the branch types
+func newBranchType(types []string, reader *bufio.Reader, disp display.Printer) (string, error) {
+	if newType != "" {
+		if !slices.Contains(types, newType) {
+			return "", eris.Errorf("invalid type: %s (must be one of: %s)", newType, strings.Join(types, ", "))
+		}
+		return newType, nil
+	}
+	if !tty.IsInteractive() {
+		return "", eris.Errorf("--type required in noninteractive mode (one of: %s)", strings.Join(types, ", "))
+	}
+
+	for {
+		answer, err := askLine(reader, disp, "Type ("+strings.Join(types, ", ")+"): ")
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(types, answer) {
+			return answer, nil
+		}
+		disp.Warningf("%s isn't one of the branch types", answer)
+	}
+}
+
+// askLine asks for a line of input, which mustn't be empty
+func askLine(reader *bufio.Reader, disp display.Printer, prompt string) (string, error) {
+	for {
+		disp.Print(prompt)
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			return line, nil
+		}
+		if err == io.EOF {
+			return "", eris.New("cancelled")
+		}
+		if err != nil {
+			return "", eris.Wrap(err, "failed to read input")
+		}
+	}
+}
+
+// branchUser returns the name of the user in branch names: the name of their git email
+// address, like "jdoe" for jdoe@example.com, or their login name
+func branchUser(proj *models.Project) string {
+	if email := git.GetUserEmail(proj.LocalPath); email != "" {
+		name, _, _ := strings.Cut(email, "@")
+		return workspace.Slugify(name)
+	}
+	if u, err := user.Current(); err == nil {
+		return workspace.Slugify(u.Username)
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+func TestCreateNewBranch(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	proj := &models.Project{
+		Name:      "example.com/user/repo",
+		LocalPath: filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo.git"),
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", proj.LocalPath}, args...)...).Output()
+		if err != nil {
+			t.Fatalf("git %s failed: %v", strings.Join(args, " "), err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// The local default branch and the remote one have diverged since the last fetch
+	tree := run("rev-parse", "main^{tree}")
+	remoteCommit := run("commit-tree", tree, "-p", "main", "-m", "remote")
+	localCommit := run("commit-tree", tree, "-p", "main", "-m", "local")
+	run("update-ref", "refs/remotes/origin/main", remoteCommit)
+	run("update-ref", "refs/heads/main", localCommit)
+
+	if err := createNewBranch(cfg, proj, "feature", display.New(io.Discard)); err != nil {
+		t.Fatalf("createNewBranch() returned error: %v", err)
+	}
+	if got := run("rev-parse", "feature"); got != remoteCommit {
+		t.Errorf("feature is at %s, want origin/main at %s", got, remoteCommit)
+	}
+	if out, err := exec.Command("git", "-C", proj.LocalPath, "config", "branch.feature.merge").Output(); err == nil {
+		t.Errorf("feature tracks %s, want no upstream", strings.TrimSpace(string(out)))
+	}
+
+	// Without the default branch on the remote, the branch starts from the local one
+	run("update-ref", "-d", "refs/remotes/origin/main")
+	if err := createNewBranch(cfg, proj, "local-feature", display.New(io.Discard)); err != nil {
+		t.Fatalf("createNewBranch() without origin/main returned error: %v", err)
+	}
+	if got := run("rev-parse", "local-feature"); got != localCommit {
+		t.Errorf("local-feature is at %s, want main at %s", got, localCommit)
+	}
+}
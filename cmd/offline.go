@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+// offlineEnv forces offline mode on when true, like --offline, or off when false, skipping
+// the detection
+const offlineEnv = "SESH_OFFLINE"
+
+// offline skips the network for this invocation: fetches, pull requests, and listing the
+// branches of remotes
+var offline bool
+
+// isOffline reports whether sesh works from local data only: with --offline or SESH_OFFLINE, or
+// when no network interface is up
+func isOffline() bool {
+	return offlineMode(offline, os.Getenv(offlineEnv), hasNetwork)
+}
+
+// offlineMode decides whether sesh is offline from --offline, SESH_OFFLINE, and, when neither
+// decides, whether there is a network
+func offlineMode(flag bool, env string, network func() bool) bool {
+	if flag {
+		return true
+	}
+	if env != "" {
+		if on, err := strconv.ParseBool(env); err == nil {
+			return on
+		}
+		slog.Warn("invalid "+offlineEnv+", expected true or false", "value", env)
+	}
+	return !network()
+}
+
+// hasNetwork reports whether a network interface other than loopback is up and has an address
+// other than a link-local one, which a machine in airplane mode has none of. When interfaces
+// can't be listed, the network is assumed to be there.
+func hasNetwork() bool {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return true
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagRunning == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsGlobalUnicast() {
+				return true
+			}
+		}
+	}
+	slog.Debug("no network interface is up, working offline")
+	return false
+}
+
+// streamBranches returns a reader of the branches of a project for the fuzzy finder: the pinned
+// ones, the local ones, then those on its remote. Offline, the branches of the remote are those
+// it had when last fetched or listed for completion.
+func streamBranches(ctx context.Context, cfg *config.Config, proj *models.Project) (io.ReadCloser, error) {
+	pinned := pinnedBranches(proj.Name)
+	if !isOffline() {
+		return git.StreamRemoteBranches(ctx, proj.LocalPath, cfg.Remote.For(proj.Name), pinned)
+	}
+
+	branches := pinned
+	if local, err := git.ListLocalBranches(proj.LocalPath); err == nil {
+		branches = append(branches, local...)
+	}
+	if remote, err := git.ListRemoteBranches(proj.LocalPath); err == nil {
+		branches = append(branches, remote...)
+	}
+	if cacheDir, err := config.GetCacheDir(); err == nil {
+		cached, _ := git.LoadCachedRemoteBranches(cacheDir, proj.LocalPath, git.RemoteBranchCacheMaxAge)
+		branches = append(branches, cached...)
+	}
+
+	var unique []string
+	for _, branch := range branches {
+		if branch != "" && !slices.Contains(unique, branch) {
+			unique = append(unique, branch)
+		}
+	}
+	return io.NopCloser(strings.NewReader(strings.Join(unique, "\n") + "\n")), nil
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import "testing"
+
+func TestOfflineMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    bool
+		env     string
+		network bool
+		want    bool
+	}{
+		{name: "online", network: true, want: false},
+		{name: "no network", network: false, want: true},
+		{name: "flag", flag: true, network: true, want: true},
+		{name: "env", env: "1", network: true, want: true},
+		{name: "env off skips detection", env: "false", network: false, want: false},
+		{name: "flag overrides env", flag: true, env: "false", network: true, want: true},
+		{name: "invalid env detects", env: "maybe", network: false, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := offlineMode(tt.flag, tt.env, func() bool { return tt.network }); got != tt.want {
+				t.Errorf("offlineMode(%v, %q) = %v, want %v", tt.flag, tt.env, got, tt.want)
+			}
+		})
+	}
+}
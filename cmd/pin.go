@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var pinProjectName string
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [session-or-branch]",
+	Short: "Pin a session, listing it before the others",
+	Long: `Pin a session, as a favorite. Pinned sessions come first in 'sesh list', in
+the tmux menu, and in the branch pickers of 'sesh switch' and 'sesh code'.
+
+The session is given by name, or by branch with --project or in a project's
+worktree. Without an argument, the pinned sessions are listed.
+
+Pins are kept when the worktree of a branch is removed, so the branch is
+pinned again when it gets a new one.
+
+Examples:
+  sesh pin myproject-main          # Pin a session
+  sesh pin -p myproject feature    # Pin the session of a branch
+  sesh pin                         # List pinned sessions
+  sesh unpin myproject-main        # Unpin it`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeSessionArg,
+	RunE:              runPin,
+}
+
+var unpinCmd = &cobra.Command{
+	Use:               "unpin <session-or-branch>",
+	Short:             "Unpin a session",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSessionArg,
+	RunE:              runUnpin,
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+	pinCmd.Flags().StringVarP(&pinProjectName, "project", "p", "", "Project of the branch to pin")
+	unpinCmd.Flags().StringVarP(&pinProjectName, "project", "p", "", "Project of the branch to unpin")
+}
+
+func runPin(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return listPins()
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+	sess, err := resolvePinTarget(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := database.PinSession(sess.SessionName, sess.ProjectName, sess.Branch); err != nil {
+		return err
+	}
+	display.NewStderr().Successf("Pinned %s", sess.SessionName)
+	return nil
+}
+
+func runUnpin(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	pins, err := database.GetPinnedSessions()
+	if err != nil {
+		return err
+	}
+
+	// The worktree of a pinned session may be gone, so its name is looked up in the pins first
+	var projectName, branch string
+	if i := slices.IndexFunc(pins, func(pin *models.PinnedSession) bool {
+		return pinProjectName == "" && pin.SessionName == args[0]
+	}); i >= 0 {
+		projectName, branch = pins[i].ProjectName, pins[i].Branch
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return eris.Wrap(err, "failed to get current working directory")
+		}
+		proj, err := project.ResolveProject(cfg.WorkspaceDir, pinProjectName, cwd)
+		if err != nil {
+			if pinProjectName == "" {
+				return eris.Errorf("%s isn't pinned", args[0])
+			}
+			return eris.Wrap(err, "failed to resolve project")
+		}
+		projectName, branch = proj.Name, args[0]
+	}
+
+	if !slices.ContainsFunc(pins, func(pin *models.PinnedSession) bool {
+		return pin.ProjectName == projectName && pin.Branch == branch
+	}) {
+		return eris.Errorf("%s isn't pinned", args[0])
+	}
+	if err := database.UnpinSession(projectName, branch); err != nil {
+		return err
+	}
+	display.NewStderr().Successf("Unpinned %s", workspace.GenerateSessionName(projectName, branch))
+	return nil
+}
+
+// listPins prints the pinned sessions, in the order they were pinned
+func listPins() error {
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	pins, err := database.GetPinnedSessions()
+	if err != nil {
+		return err
+	}
+	if len(pins) == 0 {
+		display.NewStderr().Println("No pinned sessions.")
+		return nil
+	}
+
+	out := display.NewStdout()
+	for _, pin := range pins {
+		out.Printf("%s  %s\n", out.Bold(pin.SessionName), out.Faint(pin.ProjectName+" "+pin.Branch))
+	}
+	return nil
+}
+
+// resolvePinTarget returns the worktree of the session or branch given to 'sesh pin': the branch
+// of the --project flag, the session with that name, or the branch of the current project
+func resolvePinTarget(cfg *config.Config, target string) (sessionDetail, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return sessionDetail{}, eris.Wrap(err, "failed to get current working directory")
+	}
+
+	if pinProjectName == "" {
+		projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+		if err != nil {
+			return sessionDetail{}, eris.Wrap(err, "failed to discover projects")
+		}
+		sessions := collectSessionDetails(projects, nil, "", false)
+		if i := slices.IndexFunc(sessions, func(s sessionDetail) bool { return s.SessionName == target }); i >= 0 {
+			return sessions[i], nil
+		}
+	}
+
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, pinProjectName, cwd)
+	if err != nil {
+		if pinProjectName == "" {
+			return sessionDetail{}, eris.Errorf("no session named %s (give the project of a branch with --project)", target)
+		}
+		return sessionDetail{}, eris.Wrap(err, "failed to resolve project")
+	}
+	if _, err := state.GetWorktree(proj, target); err != nil {
+		return sessionDetail{}, eris.Wrapf(err, "branch %s has no worktree", target)
+	}
+	return sessionDetail{
+		SessionName: workspace.GenerateSessionName(proj.Name, target),
+		ProjectName: proj.Name,
+		Branch:      target,
+	}, nil
+}
+
+// pinKey identifies the pinned session of a branch
+type pinKey struct {
+	projectName string
+	branch      string
+}
+
+// pinnedSessions returns the pinned sessions. They're only listed first, so failures are
+// only logged.
+func pinnedSessions() map[pinKey]bool {
+	database, err := openDB()
+	if err != nil {
+		slog.Debug("failed to open database for pinned sessions", "error", err)
+		return nil
+	}
+	defer database.Close()
+
+	pins, err := database.GetPinnedSessions()
+	if err != nil {
+		slog.Debug("failed to get pinned sessions", "error", err)
+		return nil
+	}
+	pinned := make(map[pinKey]bool, len(pins))
+	for _, pin := range pins {
+		pinned[pinKey{pin.ProjectName, pin.Branch}] = true
+	}
+	return pinned
+}
+
+// pinnedBranches returns the pinned branches of a project, for the branch pickers to list first
+func pinnedBranches(projectName string) []string {
+	var branches []string
+	for key := range pinnedSessions() {
+		if key.projectName == projectName {
+			branches = append(branches, key.branch)
+		}
+	}
+	slices.Sort(branches)
+	return branches
+}
+
+// sortPinnedFirst marks the pinned sessions and moves them before the others, keeping the order
+// of both
+func sortPinnedFirst(sessions []sessionDetail, pinned map[pinKey]bool) {
+	for i := range sessions {
+		sessions[i].Pinned = pinned[pinKey{sessions[i].ProjectName, sessions[i].Branch}]
+	}
+	slices.SortStableFunc(sessions, func(a, b sessionDetail) int {
+		switch {
+		case a.Pinned == b.Pinned:
+			return 0
+		case a.Pinned:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
+
+// completeSessionArg completes the session argument of 'sesh pin' and 'sesh unpin'
+func completeSessionArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || pinProjectName != "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, sess := range collectSessionDetails(projects, nil, "", false) {
+		names = append(names, sess.SessionName)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+// recycleWorktree returns a worktree being removed to the pool of its project, to be reused for
+// the next new branch, when worktree_pool is set and the pool has room. Worktrees with
+// uncommitted changes are never recycled, so they go to the trash and can be restored, and
+// neither are worktrees on another filesystem than the pool, which can't be moved there. Returns
+// whether the worktree was recycled; failures are only logged, and the worktree is removed as
+// usual.
+func recycleWorktree(cfg *config.Config, proj *models.Project, wt *models.Worktree, disp display.Printer) bool {
+	if cfg.WorktreePool <= 0 {
+		return false
+	}
+	pooled := state.DiscoverPooledWorktrees(proj)
+	if len(pooled) >= cfg.WorktreePool {
+		return false
+	}
+	if dirty, err := git.HasUncommittedChanges(wt.Path); err != nil || dirty {
+		return false
+	}
+
+	projectWorkspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	if !workspace.SameFilesystem(wt.Path, projectWorkspaceDir) {
+		return false
+	}
+	poolPath := freePoolPath(workspace.GetPoolDir(projectWorkspaceDir, proj.Name))
+	if err := git.RecycleWorktree(proj.LocalPath, wt.Path, poolPath); err != nil {
+		slog.Warn("failed to recycle worktree", "path", wt.Path, "error", err)
+		return false
+	}
+	disp.Printf("Recycled worktree into the pool (%d/%d)\n", len(pooled)+1, cfg.WorktreePool)
+	return true
+}
+
+// freePoolPath returns the first path in a pool directory that no recycled worktree has
+func freePoolPath(poolDir string) string {
+	for i := 1; ; i++ {
+		path := filepath.Join(poolDir, strconv.Itoa(i))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+	}
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"github.com/benoctopus/sesh/internal/config"
-	"github.com/benoctopus/sesh/internal/db"
 	"github.com/benoctopus/sesh/internal/display"
 	"github.com/benoctopus/sesh/internal/session"
 	"github.com/rotisserie/eris"
@@ -54,21 +53,15 @@ func runPop(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Get database path
-	dbPath, err := config.GetDBPath()
+	// Open database
+	database, err := openDB()
 	if err != nil {
-		return eris.Wrap(err, "failed to get database path")
-	}
-
-	// Initialize database
-	database, err := db.InitDB(dbPath)
-	if err != nil {
-		return eris.Wrap(err, "failed to initialize database")
+		return err
 	}
 	defer database.Close()
 
 	// Get previous session from history
-	previousSession, err := db.GetPreviousSession(database, currentSessionName)
+	previousSession, err := database.GetPreviousSession(currentSessionName)
 	if err != nil {
 		return eris.Wrap(err, "no previous session found in history")
 	}
@@ -101,6 +94,10 @@ func runPop(cmd *cobra.Command, args []string) error {
 	// Record this as a session access (so we can pop back)
 	recordSessionHistory(previousSession.SessionName, previousSession.ProjectName, previousSession.Branch)
 
+	if cfg.Tmux.WindowTitles {
+		setSessionTitle(sessionMgr, previousSession.SessionName, previousSession.ProjectName, previousSession.Branch)
+	}
+
 	// Attach to the previous session
 	return sessionMgr.Attach(previousSession.SessionName)
 }
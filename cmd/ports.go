@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"log/slog"
+)
+
+// releasePorts releases the block of ports assigned to a removed worktree, so other worktrees
+// can use it. This is a best-effort operation - errors are logged but don't fail the command.
+func releasePorts(projectName, branch string) {
+	database, err := openDB()
+	if err != nil {
+		slog.Debug("failed to open database to release ports", "error", err)
+		return
+	}
+	defer database.Close()
+
+	if err := database.DeletePortBlock(projectName, branch); err != nil {
+		slog.Debug("failed to release ports", "project", projectName, "branch", branch, "error", err)
+	}
+}
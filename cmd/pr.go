@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"math"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/rotisserie/eris"
+)
+
+// listOpenPRs lists the open pull requests of a project
+// Results cached by 'sesh daemon' or a previous call are used while fresh, unless --no-cache is given.
+// Offline, cached results are used however old they are.
+func listOpenPRs(ctx context.Context, proj *models.Project) ([]*pr.PullRequest, error) {
+	cacheDir, err := config.GetCacheDir()
+	if isOffline() {
+		if err == nil {
+			if prs, ok := pr.LoadCachedOpenPRs(cacheDir, proj.LocalPath, math.MaxInt64); ok {
+				return prs, nil
+			}
+		}
+		return nil, eris.Errorf("can't list the pull requests of %s offline, and none are cached", proj.Name)
+	}
+
+	useCache := err == nil && !noCache
+	if useCache {
+		if prs, ok := pr.LoadCachedOpenPRs(cacheDir, proj.LocalPath, pr.CacheMaxAge); ok {
+			return prs, nil
+		}
+	}
+
+	prs, err := fetchOpenPRs(ctx, proj)
+	if err != nil {
+		return nil, err
+	}
+
+	if useCache {
+		//nolint:errcheck // The cache is only an optimization
+		pr.SaveCachedOpenPRs(cacheDir, proj.LocalPath, prs)
+	}
+
+	return prs, nil
+}
+
+// fetchOpenPRs lists the open pull requests of a project from its provider, bypassing the cache
+func fetchOpenPRs(ctx context.Context, proj *models.Project) ([]*pr.PullRequest, error) {
+	// Get remote URL
+	remoteURL, err := git.GetRemoteURL(proj.LocalPath)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get remote URL")
+	}
+
+	// Create PR provider
+	provider, err := pr.NewProvider(remoteURL)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create PR provider")
+	}
+
+	// Check if gh CLI is installed and authenticated (for GitHub)
+	if provider.Name() == "github" {
+		if err := pr.CheckGHCLI(remoteURL); err != nil {
+			return nil, err
+		}
+	}
+
+	prs, err := provider.ListOpenPRs(ctx, proj.LocalPath)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list pull requests")
+	}
+
+	return prs, nil
+}
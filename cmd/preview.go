@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"io"
+	"text/template"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/rotisserie/eris"
+)
+
+// previewData is what the 'sesh info' preview template is rendered with
+type previewData struct {
+	worktreeInfo
+	// StatusLines are the lines of git status --short of the worktree
+	StatusLines []string
+}
+
+// previewSections are the building blocks of the preview, which preview_template can
+// include in any order with {{ template "name" . }}
+const previewSections = `
+{{- define "header" -}}
+{{ info "Session:" }} {{ bold .SessionName }}
+{{ info "Project:" }} {{ .Project }}
+{{ info "Branch:" }} {{ bold .Branch }}
+{{ end -}}
+
+{{- define "status" -}}
+{{ if .HasWorktree -}}
+{{ info "Path:" }} {{ faint .WorktreePath }}
+{{ info "Status:" }} {{ if .IsRunning }}{{ success "● Running" }}{{ else }}{{ faint "○ Stopped" }}{{ end }}
+{{ if .ReadyCheck }}{{ info "Ready:" }} {{ if .Ready }}{{ success "✓" }}{{ else }}{{ warning "✗" }}{{ end }} {{ .ReadyCheck }}
+{{ end -}}
+{{ else -}}
+{{ info "Status:" }} {{ warning "○ Remote branch (no local worktree)" }}
+{{ end -}}
+{{ end -}}
+
+{{- define "upstream" -}}
+{{ with .Tracking }}{{ info "Upstream:" }} {{ .Upstream }} {{ aheadBehind . }}
+{{ end -}}
+{{ with .DiffStat }}{{ if .Files }}{{ info "Changes:" }} {{ diffStat . }}
+{{ end }}{{ end -}}
+{{ if .Stashes }}{{ info "Stashes:" }} {{ .Stashes }}
+{{ end -}}
+{{ end -}}
+
+{{- define "git" -}}
+{{ if .HasWorktree }}
+{{ bold "Git Status:" }}
+{{ range .StatusLines }}  {{ . }}
+{{ else }}{{ success "  ✓ Clean" }}
+{{ end }}{{ end -}}
+{{ end -}}
+
+{{- define "commit" -}}
+{{ if .HasWorktree }}
+{{ bold "Last Commit:" }}
+{{ if .LastCommit }}  {{ .LastCommit }}{{ else }}{{ faint "  (no commits)" }}{{ end }}
+{{ else }}
+{{ bold "Remote Branch Info:" }}
+{{ if .LastCommit }}  {{ .LastCommit }}{{ else }}{{ faint "  (no commit information available)" }}{{ end }}
+
+{{ info "→ Run 'sesh switch' to create a worktree for this branch." }}
+{{ end -}}
+{{ end -}}
+`
+
+// defaultPreviewTemplate is the preview layout when preview_template isn't configured
+const defaultPreviewTemplate = `
+{{ template "header" . }}{{ template "status" . }}{{ template "upstream" . }}{{ template "git" . }}{{ template "commit" . }}`
+
+// renderPreview renders the preview of a branch with a template, or the default layout
+// when tmplText is empty
+func renderPreview(w io.Writer, disp display.Printer, tmplText string, data *previewData) error {
+	if tmplText == "" {
+		tmplText = defaultPreviewTemplate
+	}
+
+	tmpl, err := template.New("preview").Funcs(previewFuncs(disp)).Parse(previewSections)
+	if err != nil {
+		return eris.Wrap(err, "failed to parse preview sections")
+	}
+	if _, err := tmpl.Parse(tmplText); err != nil {
+		return eris.Wrap(err, "invalid preview_template")
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		return eris.Wrap(err, "failed to render preview_template")
+	}
+	return nil
+}
+
+// previewFuncs are the functions available in preview templates
+func previewFuncs(disp display.Printer) template.FuncMap {
+	return template.FuncMap{
+		"bold":    func(s string) string { return disp.Bold(s) },
+		"faint":   func(s string) string { return disp.Faint(s) },
+		"info":    func(s string) string { return disp.InfoText(s) },
+		"success": func(s string) string { return disp.SuccessText(s) },
+		"warning": func(s string) string { return disp.WarningText(s) },
+		"error":   func(s string) string { return disp.ErrorText(s) },
+		"aheadBehind": func(tracking *git.AheadBehind) string {
+			return formatAheadBehind(tracking, disp)
+		},
+		"diffStat": func(stat *git.DiffStat) string {
+			return formatDiffStat(stat, disp)
+		},
+	}
+}
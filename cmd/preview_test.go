@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+)
+
+func TestRenderPreview(t *testing.T) {
+	worktree := &previewData{
+		worktreeInfo: worktreeInfo{
+			SessionName:  "repo-feature",
+			Project:      "github.com/user/repo",
+			Branch:       "feature",
+			WorktreePath: "/workspace/github.com/user/repo/feature",
+			HasWorktree:  true,
+			IsRunning:    true,
+			LastCommit:   "abc1234 Add feature (2 hours ago)",
+			Tracking:     &git.AheadBehind{Upstream: "origin/feature", Ahead: 3, Behind: 1},
+			Stashes:      2,
+			ReadyCheck:   "localhost:3000",
+			Ready:        true,
+		},
+		StatusLines: []string{" M main.go"},
+	}
+	remote := &previewData{
+		worktreeInfo: worktreeInfo{SessionName: "repo-other", Project: "github.com/user/repo", Branch: "other"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		data     *previewData
+		want     []string
+		wantNot  []string
+		wantErr  bool
+	}{
+		{
+			name: "default layout of a worktree",
+			data: worktree,
+			want: []string{
+				"Session: repo-feature\n",
+				"Status: ● Running\n",
+				"Ready: ✓ localhost:3000\n",
+				"Upstream: origin/feature ↑3 ↓1\n",
+				"Stashes: 2\n",
+				"\nGit Status:\n   M main.go\n",
+				"\nLast Commit:\n  abc1234 Add feature (2 hours ago)\n",
+			},
+		},
+		{
+			name:    "default layout of a remote branch",
+			data:    remote,
+			want:    []string{"Status: ○ Remote branch (no local worktree)\n", "(no commit information available)"},
+			wantNot: []string{"Git Status:", "Upstream:", "Ready:"},
+		},
+		{
+			name:     "sections in a custom order",
+			template: `{{ template "commit" . }}{{ template "header" . }}`,
+			data:     worktree,
+			want:     []string{"\nLast Commit:\n  abc1234 Add feature (2 hours ago)\nSession: repo-feature\n"},
+			wantNot:  []string{"Git Status:", "Status:"},
+		},
+		{
+			name:     "custom fields",
+			template: `{{ .Branch }} {{ len .StatusLines }} {{ with .Tracking }}{{ .Ahead }}{{ end }}`,
+			data:     worktree,
+			want:     []string{"feature 1 3"},
+		},
+		{
+			name:     "invalid template",
+			template: `{{ .Branch `,
+			data:     worktree,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := renderPreview(&buf, display.New(&buf), tt.template, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderPreview() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := buf.String()
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("preview does not contain %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.wantNot {
+				if strings.Contains(got, notWant) {
+					t.Errorf("preview contains %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
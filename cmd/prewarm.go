@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// defaultPrewarmTop is how many branches of a project 'sesh prewarm' prepares by default
+const defaultPrewarmTop = 5
+
+// prewarmOperationLogLimit is how many operations of a project are read to find the worktrees
+// removed since their branches were last switched to
+const prewarmOperationLogLimit = 1000
+
+var (
+	prewarmTop         int
+	prewarmProjectName string
+	prewarmAll         bool
+)
+
+var prewarmCmd = &cobra.Command{
+	Use:   "prewarm",
+	Short: "Create worktrees for the branches you switch to most",
+	Long: `Create worktrees ahead of time for the most frecent branches of a project:
+those switched to most often and most recently. Switching to them later is
+then instant, even in repositories where checking out takes minutes.
+
+Only branches that still exist, locally or on the remote, and that aren't
+merged into the default branch are prepared. Branches whose worktree was
+removed with delete or clean since they were last switched to are left
+alone until they are switched to again. No sessions are started.
+
+'sesh daemon --prewarm N' does the same for every project on each refresh.
+
+The project is automatically detected from the current working directory,
+or can be specified explicitly with the --project flag.
+
+Examples:
+  sesh prewarm               # Prepare the 5 most frecent branches
+  sesh prewarm --top 10      # Prepare the 10 most frecent branches
+  sesh prewarm --all         # Prepare branches of every project
+  sesh prewarm -p myproject  # Prepare branches of a given project`,
+	Args: cobra.NoArgs,
+	RunE: runPrewarm,
+}
+
+func init() {
+	rootCmd.AddCommand(prewarmCmd)
+	prewarmCmd.Flags().IntVarP(&prewarmTop, "top", "n", defaultPrewarmTop, "Number of branches to prepare per project")
+	prewarmCmd.Flags().StringVarP(&prewarmProjectName, "project", "p", "", "Project to prepare branches of")
+	prewarmCmd.Flags().BoolVar(&prewarmAll, "all", false, "Prepare branches of every project")
+	prewarmCmd.MarkFlagsMutuallyExclusive("project", "all")
+}
+
+func runPrewarm(cmd *cobra.Command, args []string) error {
+	if prewarmTop <= 0 {
+		return eris.Errorf("invalid --top: %d (must be positive)", prewarmTop)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	var projects []*models.Project
+	if prewarmAll {
+		if projects, err = state.DiscoverProjects(cfg.WorkspaceDir); err != nil {
+			return eris.Wrap(err, "failed to discover projects")
+		}
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return eris.Wrap(err, "failed to get current working directory")
+		}
+		proj, err := project.ResolveProject(cfg.WorkspaceDir, prewarmProjectName, cwd)
+		if err != nil {
+			return eris.Wrap(err, "failed to resolve project")
+		}
+		projects = append(projects, proj)
+	}
+
+	disp := display.NewStderr()
+	history := recentSessionHistory()
+	created := 0
+	for _, proj := range projects {
+		branches, err := prewarmProject(cmd.Context(), cfg, proj, history, prewarmTop, disp)
+		created += len(branches)
+		if err != nil {
+			return err
+		}
+	}
+
+	if created == 0 {
+		disp.Println("Every frecent branch already has a worktree.")
+		return nil
+	}
+	disp.Successf("Prepared %d worktree(s)", created)
+	return nil
+}
+
+// prewarmProject creates worktrees for the most frecent branches of a project that don't have
+// one, skipping branches that are gone or merged, or whose worktree was removed since they were
+// last switched to. Returns the branches it created worktrees for.
+func prewarmProject(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	history []*models.SessionHistory,
+	top int,
+	disp display.Printer,
+) ([]string, error) {
+	candidates := frecentBranches(history, worktreeRemovals(proj.Name), proj.Name, time.Now(), top)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	remote := cfg.Remote.For(proj.Name)
+	merged := make(map[string]bool)
+	if defaultBranch, err := git.GetDefaultBranch(proj.LocalPath); err != nil {
+		slog.Debug("failed to determine default branch", "project", proj.Name, "error", err)
+	} else if branches, err := git.ListMergedBranches(proj.LocalPath, remote, defaultBranch); err != nil {
+		slog.Debug("failed to list merged branches", "project", proj.Name, "error", err)
+	} else {
+		for _, branch := range branches {
+			merged[branch] = true
+		}
+	}
+
+	var created []string
+	for _, branch := range candidates {
+		if ctx.Err() != nil {
+			return created, ctx.Err()
+		}
+		if wt, err := state.GetWorktree(proj, branch); err == nil && wt != nil {
+			continue
+		}
+		if merged[branch] {
+			slog.Debug("skipping merged branch", "project", proj.Name, "branch", branch)
+			continue
+		}
+		// Branches deleted since they were switched to aren't brought back
+		local, _, _ := git.DoesBranchExist(proj.LocalPath, branch)
+		if !local {
+			if exists, _ := git.DoesBranchExistRemotely(proj.LocalPath, remote, branch); !exists {
+				slog.Debug("skipping deleted branch", "project", proj.Name, "branch", branch)
+				continue
+			}
+		}
+
+		if _, err := ensureWorktree(ctx, cfg, proj, branch, disp); err != nil {
+			return created, eris.Wrapf(err, "failed to create worktree for %s", branch)
+		}
+		created = append(created, branch)
+	}
+	return created, nil
+}
+
+// worktreeRemovals returns when the worktree of each branch of a project was last removed, from
+// the operation log
+func worktreeRemovals(projectName string) map[string]time.Time {
+	database, err := openDB()
+	if err != nil {
+		slog.Debug("failed to open database for the operation log", "error", err)
+		return nil
+	}
+	defer database.Close()
+
+	entries, err := database.GetOperationLog(projectName, "", prewarmOperationLogLimit)
+	if err != nil {
+		slog.Debug("failed to get operation log", "project", projectName, "error", err)
+		return nil
+	}
+	removals := make(map[string]time.Time)
+	for _, entry := range entries {
+		if entry.Operation == "remove-worktree" && entry.Outcome == "ok" && entry.CreatedAt.After(removals[entry.Branch]) {
+			removals[entry.Branch] = entry.CreatedAt
+		}
+	}
+	return removals
+}
+
+// frecentBranches returns up to top branches of a project, by the frecency of their sessions,
+// most frecent first. Branches whose worktree was removed after they were last switched to, by
+// delete or clean, are left out, since they were put away on purpose.
+func frecentBranches(
+	history []*models.SessionHistory,
+	removals map[string]time.Time,
+	projectName string,
+	now time.Time,
+	top int,
+) []string {
+	lastSwitched := make(map[string]time.Time)
+	for _, entry := range history {
+		if entry.ProjectName == projectName && entry.AccessedAt.After(lastSwitched[entry.Branch]) {
+			lastSwitched[entry.Branch] = entry.AccessedAt
+		}
+	}
+
+	var entries []*models.SessionHistory
+	branchOf := make(map[string]string)
+	for _, entry := range history {
+		if entry.ProjectName != projectName || entry.Branch == "" {
+			continue
+		}
+		if removedAt, ok := removals[entry.Branch]; ok && removedAt.After(lastSwitched[entry.Branch]) {
+			continue
+		}
+		entries = append(entries, entry)
+		branchOf[entry.SessionName] = entry.Branch
+	}
+
+	// The sessions of the sub-projects of a branch count towards the branch
+	scores := make(map[string]float64)
+	for sessionName, score := range frecencyScores(entries, now) {
+		scores[branchOf[sessionName]] += score
+	}
+
+	branches := make([]string, 0, len(scores))
+	for branch := range scores {
+		branches = append(branches, branch)
+	}
+	slices.SortFunc(branches, func(a, b string) int {
+		if c := cmp.Compare(scores[b], scores[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if len(branches) > top {
+		branches = branches[:top]
+	}
+	return branches
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+func TestFrecentBranches(t *testing.T) {
+	now := time.Now()
+	history := []*models.SessionHistory{
+		{SessionName: "repo-daily", ProjectName: "repo", Branch: "daily", AccessedAt: now.Add(-2 * time.Hour)},
+		{SessionName: "repo-daily", ProjectName: "repo", Branch: "daily", AccessedAt: now.Add(-3 * time.Hour)},
+		{SessionName: "repo-recent", ProjectName: "repo", Branch: "recent", AccessedAt: now.Add(-time.Minute)},
+		{SessionName: "repo-old", ProjectName: "repo", Branch: "old", AccessedAt: now.Add(-30 * 24 * time.Hour)},
+		{SessionName: "repo-docs-old", ProjectName: "repo", Branch: "old", AccessedAt: now.Add(-2 * time.Hour)},
+		{SessionName: "repo-tied", ProjectName: "repo", Branch: "tied", AccessedAt: now.Add(-2 * time.Hour)},
+		{SessionName: "other-main", ProjectName: "other", Branch: "main", AccessedAt: now},
+		{SessionName: "repo-unknown", ProjectName: "repo", AccessedAt: now},
+		{SessionName: "repo-removed", ProjectName: "repo", Branch: "removed", AccessedAt: now.Add(-time.Hour)},
+		{SessionName: "repo-reopened", ProjectName: "repo", Branch: "reopened", AccessedAt: now.Add(-time.Hour)},
+	}
+	removals := map[string]time.Time{
+		"removed":  now.Add(-time.Minute),
+		"reopened": now.Add(-2 * time.Hour),
+	}
+
+	tests := []struct {
+		name string
+		top  int
+		want []string
+	}{
+		{name: "all", top: 10, want: []string{"daily", "recent", "old", "reopened", "tied"}},
+		{name: "top", top: 2, want: []string{"daily", "recent"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frecentBranches(history, removals, "repo", now, tt.top); !slices.Equal(got, tt.want) {
+				t.Errorf("frecentBranches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := frecentBranches(history, removals, "missing", now, 5); len(got) != 0 {
+		t.Errorf("frecentBranches() of a project without history = %v, want none", got)
+	}
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Tag projects",
+	Long: `Tag projects, to list the projects of a large workspace by topic.
+
+Tags are given to projects with 'sesh project tag', or to every project matching
+a pattern with project_tags in the config file. 'sesh list --tag' then shows the
+projects, or the sessions of the projects, having a tag.
+
+Examples:
+  sesh project tag api backend go    # Tag the api project
+  sesh project untag api go          # Remove a tag
+  sesh project tags                  # List the tags of every project
+  sesh list --tag backend            # Sessions of the backend projects`,
+}
+
+var projectTagCmd = &cobra.Command{
+	Use:               "tag <project> <tag>...",
+	Short:             "Add tags to a project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeProjectArg,
+	RunE:              runProjectTag,
+}
+
+var projectUntagCmd = &cobra.Command{
+	Use:               "untag <project> <tag>...",
+	Short:             "Remove tags from a project",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeProjectArg,
+	RunE:              runProjectUntag,
+}
+
+var projectTagsCmd = &cobra.Command{
+	Use:               "tags [project]",
+	Short:             "List the tags of projects",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeProjectArg,
+	RunE:              runProjectTags,
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectTagCmd)
+	projectCmd.AddCommand(projectUntagCmd)
+	projectCmd.AddCommand(projectTagsCmd)
+}
+
+func runProjectTag(cmd *cobra.Command, args []string) error {
+	proj, database, err := openProjectTags(args)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	for _, tag := range args[1:] {
+		if err := database.AddProjectTag(proj.Name, tag); err != nil {
+			return err
+		}
+	}
+	display.NewStderr().Successf("Tagged %s with %s", proj.Name, strings.Join(args[1:], ", "))
+	return nil
+}
+
+func runProjectUntag(cmd *cobra.Command, args []string) error {
+	proj, database, err := openProjectTags(args)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+	tags, err := database.GetProjectTags()
+	if err != nil {
+		return err
+	}
+	for _, tag := range args[1:] {
+		if slices.Contains(tags[proj.Name], tag) {
+			continue
+		}
+		if slices.Contains(cfg.ProjectTags.For(proj.Name), tag) {
+			return eris.Errorf("tag %s of %s comes from project_tags in the config file", tag, proj.Name)
+		}
+		return eris.Errorf("project %s has no tag %s", proj.Name, tag)
+	}
+
+	for _, tag := range args[1:] {
+		if err := database.RemoveProjectTag(proj.Name, tag); err != nil {
+			return err
+		}
+	}
+	display.NewStderr().Successf("Removed %s from %s", strings.Join(args[1:], ", "), proj.Name)
+	return nil
+}
+
+func runProjectTags(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	var projects []*models.Project
+	if len(args) == 1 {
+		proj, err := resolveTaggedProject(cfg, args[0])
+		if err != nil {
+			return err
+		}
+		projects = append(projects, proj)
+	} else if projects, err = state.DiscoverProjects(cfg.WorkspaceDir); err != nil {
+		return eris.Wrap(err, "failed to discover projects")
+	}
+
+	tags := loadProjectTags(cfg, projects)
+	out := display.NewStdout()
+	for _, proj := range projects {
+		if len(tags[proj.Name]) > 0 {
+			out.Printf("%s  %s\n", out.Bold(proj.Name), strings.Join(tags[proj.Name], " "))
+		}
+	}
+	return nil
+}
+
+// openProjectTags resolves the project of the arguments of 'sesh project tag' and 'untag', checks
+// their tags, and opens the database storing them
+func openProjectTags(args []string) (*models.Project, db.Store, error) {
+	for _, tag := range args[1:] {
+		if err := config.ValidateTag(tag); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "failed to load configuration")
+	}
+	proj, err := resolveTaggedProject(cfg, args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	database, err := openDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return proj, database, nil
+}
+
+// resolveTaggedProject resolves the project given to the project commands
+func resolveTaggedProject(cfg *config.Config, name string) (*models.Project, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get current working directory")
+	}
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, name, cwd)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to resolve project")
+	}
+	return proj, nil
+}
+
+// loadProjectTags returns the tags of projects, sorted, by project name: those of project_tags
+// in the config file, and those given with 'sesh project tag'. A database that can't be read
+// only loses the latter.
+func loadProjectTags(cfg *config.Config, projects []*models.Project) map[string][]string {
+	var stored map[string][]string
+	if database, err := openDB(); err != nil {
+		slog.Warn("failed to open database for project tags", "error", err)
+	} else {
+		defer database.Close()
+		if stored, err = database.GetProjectTags(); err != nil {
+			slog.Warn("failed to read project tags", "error", err)
+		}
+	}
+
+	tags := make(map[string][]string, len(projects))
+	for _, proj := range projects {
+		projectTags := append(cfg.ProjectTags.For(proj.Name), stored[proj.Name]...)
+		slices.Sort(projectTags)
+		tags[proj.Name] = slices.Compact(projectTags)
+	}
+	return tags
+}
+
+// filterProjectsByTags returns the projects having all of the given tags
+func filterProjectsByTags(
+	projects []*models.Project,
+	tags map[string][]string,
+	required []string,
+) []*models.Project {
+	var filtered []*models.Project
+	for _, proj := range projects {
+		if !slices.ContainsFunc(required, func(tag string) bool { return !slices.Contains(tags[proj.Name], tag) }) {
+			filtered = append(filtered, proj)
+		}
+	}
+	return filtered
+}
+
+// completeProjectArg completes the project argument of the project commands
+func completeProjectArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProjects(cmd, args, toComplete)
+}
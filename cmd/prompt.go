@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// defaultPromptFormat prints fields that never contain spaces, so the line splits on whitespace
+const defaultPromptFormat = "{project} {branch} {session}"
+
+var promptFormat string
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print the current project, branch, and session for shell prompts",
+	Long: `Print the project, branch, and session of the worktree containing the current
+directory on a single line, for embedding in a shell prompt.
+
+It only reads a few files and the project discovery cache, without running git, so it is
+fast enough to run on every prompt; the cache is only rebuilt after the workspace changes.
+Nothing is printed outside a sesh worktree.
+
+Format placeholders:
+  {project}   Full project name (github.com/user/repo)
+  {name}      Repository name (repo)
+  {branch}    Checked out branch
+  {session}   Session name (repo-branch)
+
+Examples:
+  sesh prompt                                   # github.com/user/repo feature repo-feature
+  sesh prompt --format '{name}:{branch}'        # repo:feature
+  PS1='$(sesh prompt --format "[{name}:{branch}] ")\$ '
+
+Starship (~/.config/starship.toml):
+  [custom.sesh]
+  command = "sesh prompt --format '{name}:{branch}'"
+  when = true
+  format = "[$output]($style) "`,
+	Args: cobra.NoArgs,
+	// Skip the root setup, which only matters for commands that discover projects
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	RunE:             runPrompt,
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.Flags().StringVar(&promptFormat, "format", defaultPromptFormat, "Output format (see placeholders above)")
+}
+
+func runPrompt(cmd *cobra.Command, args []string) error {
+	line, ok := promptLine(promptFormat)
+	if ok {
+		fmt.Println(line)
+	}
+	// A prompt must never break the shell, so failures just print nothing
+	return nil
+}
+
+// promptLine formats the prompt for the worktree containing the current directory
+// Returns false outside a sesh worktree.
+func promptLine(format string) (string, bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+
+	projectName, branch, ok := findCurrentWorktree(cwd)
+	if !ok {
+		return "", false
+	}
+
+	if strings.Contains(format, "{session}") {
+		loadProjectNames()
+	}
+
+	return strings.NewReplacer(
+		"{project}", projectName,
+		"{name}", workspace.GetRepoNameFromProject(projectName),
+		"{branch}", branch,
+		"{session}", workspace.GenerateSessionName(projectName, branch),
+	).Replace(format), true
+}
+
+// loadProjectNames discovers the projects of all workspaces, from the cache when it is still valid,
+// since session names include the owner of projects that share a repository name
+func loadProjectNames() {
+	workspaces, err := config.GetWorkspaces()
+	if err != nil {
+		return
+	}
+	if cacheDir, err := config.GetCacheDir(); err == nil && !noCache {
+		state.SetCacheDir(cacheDir)
+	}
+	paths := config.WorkspacePaths(workspaces)
+	state.SetWorkspaceDirs(paths)
+	//nolint:errcheck // Without the other projects, session names just aren't disambiguated
+	state.DiscoverProjects(paths[0])
+}
+
+// findCurrentWorktree finds the project and branch of the worktree containing dir
+// Only the filesystem is read, so it is cheap enough for prompts and status lines.
+func findCurrentWorktree(dir string) (projectName, branch string, ok bool) {
+	workspaces, err := config.GetWorkspaces()
+	if err != nil {
+		return "", "", false
+	}
+
+	// Compare real paths, since either may be reached through a symlink
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	for _, ws := range workspaces {
+		workspaceDir := ws.Path
+		if resolved, err := filepath.EvalSymlinks(workspaceDir); err == nil {
+			workspaceDir = resolved
+		}
+
+		projectName, worktreePath := workspace.FindWorktree(workspaceDir, dir)
+		if projectName == "" {
+			continue
+		}
+
+		branch, err = git.ReadWorktreeBranch(worktreePath)
+		if err != nil {
+			return "", "", false
+		}
+		return projectName, branch, true
+	}
+
+	return "", "", false
+}
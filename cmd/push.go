@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushProjectName    string
+	pushRemote         string
+	pushForceWithLease bool
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push [branch]",
+	Short: "Push a branch and set its upstream",
+	Long: `Push the branch of a worktree to the remote, and make the remote branch its
+upstream, like 'git push -u origin HEAD'. Branches created by 'sesh switch' start
+without a remote branch, so their first push needs this.
+
+Without a branch, the branch of the current worktree is pushed. The branch is
+pushed to the remote it tracks, or the project's primary remote (see 'remote' in
+the config file) when it tracks none.
+
+Examples:
+  sesh push                        # Push the current worktree's branch
+  sesh push feature-foo            # Push another branch of the project
+  sesh push --force-with-lease     # Push a rebased branch
+  sesh push --remote fork          # Push to another remote`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeBranches,
+	RunE:              runPush,
+}
+
+func init() {
+	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().
+		StringVarP(&pushProjectName, "project", "p", "", "Specify project explicitly")
+	pushCmd.Flags().
+		StringVar(&pushRemote, "remote", "", "Remote to push to (default: the remote the branch tracks)")
+	pushCmd.Flags().
+		BoolVar(&pushForceWithLease, "force-with-lease", false, "Overwrite the remote branch unless it has commits that weren't fetched")
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	proj, worktreePath, branch, err := resolvePushWorktree(cfg, args)
+	if err != nil {
+		return err
+	}
+
+	remote := pushRemote
+	if remote == "" {
+		remote = git.GetUpstreamRemote(worktreePath, branch)
+	}
+	if remote == "" {
+		remote = cfg.Remote.For(proj.Name)
+	}
+
+	disp.Printf("%s Pushing %s to %s\n", disp.InfoText("→"), disp.Bold(branch), remote)
+	progress := display.NewProgress(os.Stderr, "Pushing")
+	err = git.Push(cmd.Context(), worktreePath, remote, git.PushOptions{
+		ForceWithLease: pushForceWithLease,
+		Progress:       progress.Update,
+	})
+	progress.Stop()
+	recordOperation("push", proj.Name, branch, err)
+	if err != nil {
+		return err
+	}
+
+	disp.Successf("Pushed %s to %s/%s", disp.Bold(branch), remote, branch)
+	return nil
+}
+
+// resolvePushWorktree returns the project, worktree, and branch to push: those of the branch
+// argument, or of the worktree in the current directory
+func resolvePushWorktree(cfg *config.Config, args []string) (*models.Project, string, string, error) {
+	if len(args) == 0 && pushProjectName == "" {
+		worktreePath, commonDir, err := git.GetWorktreeRoot(".")
+		if err != nil {
+			return nil, "", "", eris.Wrap(err, "not in a worktree (give the branch to push)")
+		}
+		proj, err := findProjectByRepoPath(cfg, commonDir)
+		if err != nil {
+			return nil, "", "", err
+		}
+		branch, err := git.GetWorktreeBranch(worktreePath)
+		if err != nil {
+			return nil, "", "", err
+		}
+		if branch == "(detached)" {
+			return nil, "", "", eris.Errorf("%s has no branch checked out", worktreePath)
+		}
+		return proj, worktreePath, branch, nil
+	}
+
+	if len(args) == 0 {
+		return nil, "", "", eris.New("branch argument required with --project (usage: sesh push -p <project> <branch>)")
+	}
+	branch := args[0]
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, "", "", eris.Wrap(err, "failed to get current working directory")
+	}
+	proj, err := project.ResolveProject(cfg.WorkspaceDir, pushProjectName, cwd)
+	if err != nil {
+		return nil, "", "", eris.Wrap(err, "failed to resolve project")
+	}
+	wt, err := state.GetWorktree(proj, branch)
+	if err != nil {
+		return nil, "", "", eris.Wrapf(err, "branch %s has no worktree", branch)
+	}
+	return proj, wt.Path, branch, nil
+}
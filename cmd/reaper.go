@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/lock"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// reapInterval is how often commands reap stale sessions with reap_sessions set to "always"
+const reapInterval = time.Minute
+
+// reapSessionsCmd kills the sessions whose worktree no longer exists, in every project.
+// Commands start it in the background when reap_sessions is "always".
+var reapSessionsCmd = &cobra.Command{
+	Use:    "__reap-sessions",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runReapSessions,
+}
+
+func init() {
+	rootCmd.AddCommand(reapSessionsCmd)
+}
+
+func runReapSessions(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return eris.Wrap(err, "failed to get cache directory")
+	}
+
+	// Commands finishing at the same time may start several reapers; one is enough
+	l, err := lock.TryAcquire(filepath.Join(cacheDir, "reaper.lock"))
+	if errors.Is(err, lock.ErrLocked) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // The lock is released by the OS on exit anyway
+	defer l.Release()
+
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	for _, sessionName := range reapStaleSessions(cfg, sessionMgr) {
+		slog.Info("killed stale session", "session", sessionName)
+	}
+	return nil
+}
+
+// reapStaleSessions kills the sessions whose worktree no longer exists, in every project, and
+// returns their names. Sessions are recognized by name for the projects in the workspaces, and
+// by their directory when the backend knows it, which also catches those of deleted projects.
+func reapStaleSessions(cfg *config.Config, sessionMgr session.SessionManager) []string {
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		slog.Warn("failed to discover projects", "error", err)
+		return nil
+	}
+
+	// Projects share session name prefixes, e.g. api and api-gateway, so a session is only
+	// stale when it isn't the session of any worktree
+	live, err := worktreeSessions(projects)
+	if err != nil {
+		// Without all worktrees, live sessions could be taken for stale ones
+		slog.Warn("failed to discover worktrees", "error", err)
+		return nil
+	}
+
+	stale := map[string]string{}
+	for _, proj := range projects {
+		orphaned, err := findOrphanedSessions(proj, sessionMgr)
+		if err != nil {
+			slog.Warn("failed to find orphaned sessions", "project", proj.Name, "error", err)
+			continue
+		}
+		for _, sessionName := range orphaned {
+			if live[sessionName] == "" {
+				stale[sessionName] = proj.Name
+			}
+		}
+	}
+
+	if lister, ok := sessionMgr.(session.PathLister); ok {
+		paths, err := lister.ListPaths()
+		if err != nil {
+			slog.Warn("failed to list session paths", "error", err)
+		}
+		for sessionName, path := range paths {
+			if live[sessionName] != "" || !inWorkspace(cfg, path) {
+				continue
+			}
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				projectName, _ := workspace.GetProjectFromFullPath(cfg.WorkspaceDir, path)
+				stale[sessionName] = projectName
+			}
+		}
+	}
+
+	var killed []string
+	for _, sessionName := range slices.Sorted(maps.Keys(stale)) {
+		err := killSession(sessionMgr, sessionName)
+		recordOperation("kill-orphaned-session", stale[sessionName], sessionName, err)
+		if err != nil {
+			slog.Warn("failed to kill session", "session", sessionName, "error", err)
+			continue
+		}
+		killed = append(killed, sessionName)
+	}
+	return killed
+}
+
+// idleSession is a session of a worktree that no client is attached to
+type idleSession struct {
+	name         string
+	projectName  string
+	lastAttached time.Time
+}
+
+// findIdleSessions returns the sessions of worktrees of the projects that no client has been
+// attached to for at least ttl, longest idle first. Only backends that know when sessions were
+// last attached to support it.
+func findIdleSessions(
+	projects []*models.Project,
+	sessionMgr session.SessionManager,
+	ttl time.Duration,
+) ([]idleSession, error) {
+	lister, ok := sessionMgr.(session.IdleLister)
+	if !ok {
+		return nil, eris.Errorf("the %s session backend doesn't know when sessions were last attached to", sessionMgr.Name())
+	}
+
+	// Only sessions sesh created for a worktree are idle ones, never other sessions of the user
+	sessions, err := worktreeSessions(projects)
+	if err != nil {
+		return nil, err
+	}
+	lastAttached, err := lister.ListIdle()
+	if err != nil {
+		return nil, err
+	}
+
+	var idle []idleSession
+	for sessionName, last := range lastAttached {
+		if projectName := sessions[sessionName]; projectName != "" && time.Since(last) >= ttl {
+			idle = append(idle, idleSession{name: sessionName, projectName: projectName, lastAttached: last})
+		}
+	}
+	slices.SortFunc(idle, func(a, b idleSession) int {
+		return a.lastAttached.Compare(b.lastAttached)
+	})
+	return idle, nil
+}
+
+// reapIdleSessions kills the sessions of worktrees that no client has been attached to for at
+// least ttl, in the given projects, and returns their names
+func reapIdleSessions(projects []*models.Project, sessionMgr session.SessionManager, ttl time.Duration) ([]string, error) {
+	idle, err := findIdleSessions(projects, sessionMgr, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	var killed []string
+	for _, s := range idle {
+		err := killSession(sessionMgr, s.name)
+		recordOperation("kill-idle-session", s.projectName, s.name, err)
+		if err != nil {
+			slog.Warn("failed to kill session", "session", s.name, "error", err)
+			continue
+		}
+		killed = append(killed, s.name)
+	}
+	return killed, nil
+}
+
+// worktreeSessions returns the project of each session name a worktree of the projects has,
+// including the old names of sessions of renamed branches
+func worktreeSessions(projects []*models.Project) (map[string]string, error) {
+	sessions := map[string]string{}
+	for _, proj := range projects {
+		worktrees, err := state.DiscoverWorktrees(proj)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to discover worktrees of %s", proj.Name)
+		}
+		for _, wt := range worktrees {
+			sessions[workspace.GenerateSessionName(proj.Name, wt.Branch)] = proj.Name
+			if oldName := renamedFrom(proj, wt); oldName != "" {
+				sessions[workspace.GenerateSessionName(proj.Name, oldName)] = proj.Name
+			}
+		}
+	}
+	return sessions, nil
+}
+
+// inWorkspace reports whether a path is inside one of the workspaces, so sessions started
+// elsewhere are never taken for sesh's
+func inWorkspace(cfg *config.Config, path string) bool {
+	for _, ws := range cfg.Workspaces {
+		if strings.HasPrefix(filepath.Clean(path), filepath.Clean(ws.Path)+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// reapSessionsAfter starts the reaper in the background after a command when reap_sessions is
+// "always", at most once per reapInterval. Commands run on every shell prompt or status line
+// refresh, and the daemon, which reaps on its own, don't start it.
+func reapSessionsAfter(cmd *cobra.Command) {
+	if cmd.Hidden || cmd == promptCmd || cmd == statuslineCmd || cmd == daemonCmd {
+		return
+	}
+	if policy, err := config.GetReapSessions(); err != nil || policy != config.ReapSessionsAlways {
+		return
+	}
+
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return
+	}
+	stamp := filepath.Join(cacheDir, "reaper.stamp")
+	if info, err := os.Stat(stamp); err == nil && time.Since(info.ModTime()) < reapInterval {
+		return
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return
+	}
+	if err := os.WriteFile(stamp, []byte(time.Now().Format(time.RFC3339)+"\n"), 0o644); err != nil {
+		return
+	}
+
+	bin, err := os.Executable()
+	if err != nil {
+		return
+	}
+	// Stdin, stdout, and stderr are /dev/null, so the command returns without waiting
+	reaper := exec.Command(bin, reapSessionsCmd.Name())
+	if err := reaper.Start(); err != nil {
+		return
+	}
+	//nolint:errcheck // The reaper outlives this process, which doesn't wait for it
+	reaper.Process.Release()
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var reconcileDryRun bool
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Sync the database with the projects, worktrees, and sessions on disk",
+	Long: `Scan the workspace and bring the database in line with it.
+
+Projects, worktrees, and running sessions found in the workspace but missing
+from the database are added. Rows for projects and worktrees whose paths no
+longer exist, and for sessions that are no longer running, are removed.
+
+Examples:
+  sesh reconcile               # Sync the database with the workspace
+  sesh reconcile --dry-run     # Show what would change`,
+	Args: cobra.NoArgs,
+	RunE: runReconcile,
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+	reconcileCmd.Flags().
+		BoolVar(&reconcileDryRun, "dry-run", false, "Show what would change without modifying the database")
+}
+
+func runReconcile(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	snap, err := state.Discover(cfg.WorkspaceDir, sessionMgr)
+	if err != nil {
+		return eris.Wrap(err, "failed to scan workspace")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := state.Reconcile(database, snap, reconcileDryRun)
+	if !reconcileDryRun {
+		recordOperation("reconcile", "", "", err)
+	}
+
+	// In dry-run mode the plan is the output, so it goes to stdout
+	out := disp
+	verbs := [2]string{"Added", "Removed"}
+	if reconcileDryRun {
+		out = display.NewStdout()
+		verbs = [2]string{"would add", "would remove"}
+	}
+
+	changes := []struct {
+		verb  string
+		kind  string
+		names []string
+	}{
+		{verbs[1], "project", result.RemovedProjects},
+		{verbs[1], "worktree", result.RemovedWorktrees},
+		{verbs[1], "session", result.RemovedSessions},
+		{verbs[0], "project", result.AddedProjects},
+		{verbs[0], "worktree", result.AddedWorktrees},
+		{verbs[0], "session", result.AddedSessions},
+	}
+	for _, change := range changes {
+		for _, name := range change.names {
+			out.Printf("%s %s %s\n", change.verb, change.kind, out.Bold(name))
+		}
+	}
+
+	if err != nil {
+		return eris.Wrap(err, "failed to reconcile database")
+	}
+
+	if !result.Changed() {
+		disp.Println("Database is already in sync with the workspace.")
+	} else if !reconcileDryRun {
+		disp.Successf("Database reconciled with the workspace")
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/spf13/cobra"
+)
+
+// remoteFailedExitCode is what ssh exits with when it can't reach the host
+const remoteFailedExitCode = 255
+
+// isLocalCommand reports whether a command manages this machine's sesh rather than a workspace,
+// so it isn't run on the host of a remote profile
+func isLocalCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c {
+		case configCmd, versionCmd, shellInitCmd, shellWidgetCmd, tmuxCmd:
+			return true
+		}
+	}
+	return cmd.Name() == "help"
+}
+
+// runOnProfileHost runs the command on the host of the active profile, when it has one, and
+// exits with the command's exit status. The workspace, git, and sessions of remote profiles live
+// on the host, so sesh there does the work, and attaching to a session attaches over ssh -t.
+func runOnProfileHost(cmd *cobra.Command) {
+	if isLocalCommand(cmd) {
+		return
+	}
+	// An unknown profile is reported by commands that load the full configuration
+	host, remoteCommand, err := config.GetProfileHost()
+	if err != nil || host == "" {
+		return
+	}
+
+	ssh := exec.Command("ssh", sshArgs(host, remoteCommand, os.Args[1:], tty.IsInteractive())...)
+	ssh.Stdin = os.Stdin
+	ssh.Stdout = os.Stdout
+	ssh.Stderr = os.Stderr
+	err = ssh.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		os.Exit(0)
+	case errors.As(err, &exitErr):
+		os.Exit(exitErr.ExitCode())
+	default:
+		display.NewStderr().Errorf("Failed to run ssh: %v", err)
+		os.Exit(remoteFailedExitCode)
+	}
+}
+
+// sshArgs returns the arguments of ssh running sesh with args on a host. --profile is dropped,
+// since it names a profile of this machine: sesh on the host uses its own configuration. A
+// terminal is requested when sesh has one, e.g. for the fuzzy finder and attaching.
+func sshArgs(host, remoteCommand string, args []string, interactive bool) []string {
+	command := []string{remoteCommand}
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--profile":
+			i++
+		case strings.HasPrefix(args[i], "--profile="):
+		default:
+			command = append(command, session.ShellQuote(args[i]))
+		}
+	}
+
+	sshArgs := []string{}
+	if interactive {
+		sshArgs = append(sshArgs, "-t")
+	}
+	return append(sshArgs, host, "--", strings.Join(command, " "))
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		interactive bool
+		want        []string
+	}{
+		{
+			name:        "interactive",
+			args:        []string{"switch", "feature/login"},
+			interactive: true,
+			want:        []string{"-t", "devbox", "--", "sesh switch feature/login"},
+		},
+		{
+			name: "piped",
+			args: []string{"list", "--json"},
+			want: []string{"devbox", "--", "sesh list --json"},
+		},
+		{
+			name: "profile dropped",
+			args: []string{"--profile", "remote", "list", "--profile=remote", "-p", "it's"},
+			want: []string{"devbox", "--", `sesh list -p 'it'\''s'`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sshArgs("devbox", "sesh", tt.args, tt.interactive); !slices.Equal(got, tt.want) {
+				t.Errorf("sshArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
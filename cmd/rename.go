@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// renamedFrom returns the name a worktree and its session were created with, when its branch
+// was renamed since, e.g. with 'git branch -m'. Returns "" when the worktree is named after its
+// branch, wasn't created by sesh, or was switched to another branch, e.g. with 'git switch'.
+func renamedFrom(proj *models.Project, wt *models.Worktree) string {
+	if wt.IsMain || wt.Branch == "" || wt.Branch == "(detached)" {
+		return ""
+	}
+
+	// The worktrees sesh creates are named after the sanitized branch
+	oldName := filepath.Base(wt.Path)
+	if oldName == workspace.SanitizeBranchName(wt.Branch) {
+		return ""
+	}
+	workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	if workspace.IsExternalWorktree(workspaceDir, proj.Name, oldName, wt.Path) {
+		return ""
+	}
+
+	// The reflog of the branch tells whether it was renamed from the old name
+	renames, ok, err := git.GetBranchRenames(proj.LocalPath, wt.Branch)
+	if err != nil {
+		slog.Debug("failed to read branch renames", "branch", wt.Branch, "error", err)
+		return ""
+	}
+	if ok {
+		for _, old := range renames {
+			if workspace.SanitizeBranchName(old) == oldName {
+				return oldName
+			}
+		}
+		return ""
+	}
+
+	// Without a reflog, the branch is only taken to be renamed when the old one is gone
+	branches, err := git.ListLocalBranches(proj.LocalPath)
+	if err != nil {
+		slog.Debug("failed to list branches", "error", err)
+		return ""
+	}
+	for _, branch := range branches {
+		if workspace.SanitizeBranchName(branch) == oldName {
+			return ""
+		}
+	}
+	return oldName
+}
+
+// followBranchRename moves the worktree of a renamed branch to the path of its new name, and
+// renames its session to match, so the session is found by GenerateSessionName again. The
+// caller holds the project lock.
+func followBranchRename(
+	cfg *config.Config,
+	proj *models.Project,
+	wt *models.Worktree,
+	oldName string,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) error {
+	workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	newPath := workspace.ProjectWorktreePath(workspaceDir, proj.Name, wt.Branch)
+	if _, err := os.Stat(newPath); err == nil {
+		return eris.Errorf("can't move worktree to %s: it already exists", newPath)
+	}
+
+	err := git.MoveWorktree(proj.LocalPath, wt.Path, newPath)
+	recordOperation("move-worktree", proj.Name, wt.Branch, err)
+	if err != nil {
+		return err
+	}
+	disp.Printf("Moved worktree %s to %s\n", wt.Path, newPath)
+	zoxideRemove(cfg, wt.Path, disp)
+	prepare.ZoxideAdd(cfg, newPath, disp)
+	wt.Path = newPath
+
+	oldSession := workspace.GenerateSessionName(proj.Name, oldName)
+	newSession := workspace.GenerateSessionName(proj.Name, wt.Branch)
+	if exists, err := sessionMgr.Exists(oldSession); err != nil || !exists {
+		return err
+	}
+	if err := sessionMgr.Rename(oldSession, newSession); err != nil {
+		if eris.Is(err, session.ErrNotSupported) {
+			return nil
+		}
+		return err
+	}
+	disp.Printf("Renamed session %s to %s\n", oldSession, newSession)
+	disp.Printf("  %s\n", disp.Faint("Shells already open in it are still in the old directory"))
+	return nil
+}
+
+// offerBranchRename offers to follow the rename of a branch whose worktree is about to be
+// switched to. Without a terminal to ask in, it only points at 'sesh verify'.
+func offerBranchRename(
+	cfg *config.Config,
+	proj *models.Project,
+	wt *models.Worktree,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) {
+	oldName := renamedFrom(proj, wt)
+	if oldName == "" {
+		return
+	}
+
+	disp.Printf("Branch %s was renamed from %s since its worktree was created.\n", disp.Bold(wt.Branch), oldName)
+	if !tty.IsInteractive() {
+		disp.Printf("  %s\n", disp.Faint("Run 'sesh verify --fix' to rename its worktree directory and session"))
+		return
+	}
+
+	disp.Print("Rename its worktree directory and session to match? (yes/no): ")
+	response, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "yes" && response != "y" {
+		return
+	}
+
+	if err := followBranchRename(cfg, proj, wt, oldName, sessionMgr, disp); err != nil {
+		disp.Warningf("Failed to rename worktree of %s: %v", wt.Branch, err)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+func TestRenamedFrom(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	proj := &models.Project{
+		Name:      "example.com/user/repo",
+		LocalPath: filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo.git"),
+	}
+	path := func(name string) string {
+		return workspace.ProjectWorktreePath(cfg.WorkspaceDir, proj.Name, name)
+	}
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	// Renamed with git branch -m in the worktree, which logs the rename
+	git("-C", proj.LocalPath, "worktree", "add", "--quiet", "-b", "feature/foo", path("feature/foo"))
+	git("-C", path("feature/foo"), "branch", "-m", "feature/bar")
+	// Switched to another branch, so the branch it was created for is still there
+	git("-C", proj.LocalPath, "worktree", "add", "--quiet", "-b", "switched", path("switched"))
+	git("-C", path("switched"), "switch", "--quiet", "-c", "other")
+	// Renamed in the bare repository, which has no reflogs
+	git("-C", proj.LocalPath, "branch", "old")
+	git("-C", proj.LocalPath, "worktree", "add", "--quiet", path("old"), "old")
+	git("-C", proj.LocalPath, "branch", "-m", "old", "new")
+
+	tests := []struct {
+		name string
+		wt   *models.Worktree
+		want string
+	}{
+		{
+			name: "named after its branch",
+			wt:   &models.Worktree{Branch: "feature/foo", Path: path("feature/foo")},
+		},
+		{
+			name: "renamed branch",
+			wt:   &models.Worktree{Branch: "feature/bar", Path: path("feature/foo")},
+			want: "feature-foo",
+		},
+		{
+			name: "switched to another branch",
+			wt:   &models.Worktree{Branch: "other", Path: path("switched")},
+		},
+		{
+			name: "renamed without a reflog",
+			wt:   &models.Worktree{Branch: "new", Path: path("old")},
+			want: "old",
+		},
+		{
+			name: "detached",
+			wt:   &models.Worktree{Branch: "(detached)", Path: path("feature/foo")},
+		},
+		{
+			name: "bare repository",
+			wt:   &models.Worktree{Path: proj.LocalPath, IsMain: true},
+		},
+		{
+			name: "added with git worktree add",
+			wt:   &models.Worktree{Branch: "feature/bar", Path: filepath.Join(t.TempDir(), "feature-foo")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renamedFrom(proj, tt.wt); got != tt.want {
+				t.Errorf("renamedFrom() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var repairDryRun bool
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Fix the links between repositories and worktrees broken by moving them",
+	Long: `Find worktrees whose links to their bare repository are broken and fix them.
+
+A worktree and its repository point at each other by path, so moving the
+workspace directory, a project, or a worktree breaks git commands in the
+worktree. Every worktree found in the workspaces is repaired with
+'git worktree repair', and the information git keeps about worktrees whose
+directories are gone is pruned.
+
+Worktrees whose bare repository was deleted can't be repaired; they are
+reported so they can be deleted, or the project cloned again.
+
+Examples:
+  sesh repair              # Fix the worktrees of every workspace
+  sesh repair --dry-run    # Show what is broken`,
+	Args: cobra.NoArgs,
+	RunE: runRepair,
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+	repairCmd.Flags().
+		BoolVar(&repairDryRun, "dry-run", false, "Show what is broken without changing anything")
+}
+
+// repairScan holds the worktrees found in a workspace, by the bare repository they belong to
+type repairScan struct {
+	repos []string
+	// worktrees are the worktree directories of each bare repository
+	worktrees map[string][]string
+	// orphans are worktree directories that don't belong to any repository in the workspace
+	orphans []string
+}
+
+func runRepair(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	// In dry-run mode the problems are the output, so they go to stdout
+	out := disp
+	if repairDryRun {
+		out = display.NewStdout()
+	}
+
+	fixed, orphaned := 0, 0
+	for _, ws := range cfg.Workspaces {
+		// Nothing can be broken in a workspace that hasn't been created yet
+		if _, err := os.Stat(ws.Path); os.IsNotExist(err) {
+			continue
+		}
+		scan, err := scanWorktrees(ws.Path)
+		if err != nil {
+			return eris.Wrapf(err, "failed to scan workspace: %s", ws.Path)
+		}
+
+		for _, repo := range scan.repos {
+			projectName, _ := workspace.GetProjectFromFullPath(ws.Path, repo)
+			fixed += repairRepository(projectName, repo, scan.worktrees[repo], out, disp)
+		}
+
+		for _, dir := range scan.orphans {
+			problem := git.CheckWorktreeLink(dir)
+			if problem == "" {
+				continue
+			}
+			orphaned++
+			disp.Warningf("%s can't be repaired: %s", dir, problem)
+			disp.Printf("  Delete it, or clone its project again and rerun 'sesh repair'\n")
+		}
+	}
+
+	switch {
+	case fixed == 0 && orphaned == 0:
+		disp.Println("No broken worktrees found.")
+	case fixed > 0 && !repairDryRun:
+		disp.Successf("Fixed %d broken worktree link(s)", fixed)
+		disp.Printf("Run 'sesh reconcile' to update the database with the repaired worktrees.\n")
+	}
+	return nil
+}
+
+// repairRepository repairs the links between a bare repository and its worktrees, and prunes
+// the information about worktrees whose directories are gone. Returns how many problems were
+// fixed, or would be fixed in dry-run mode.
+func repairRepository(projectName, repo string, worktrees []string, out, disp display.Printer) int {
+	// The worktrees that are repaired get their information in the repository back, so it
+	// mustn't be reported as prunable
+	linked := map[string]bool{}
+	var broken []string
+	for _, dir := range worktrees {
+		if adminDir, err := git.WorktreeLinkAdminDir(dir); err == nil {
+			linked["worktrees/"+filepath.Base(adminDir)] = true
+		}
+		if problem := git.CheckWorktreeLink(dir); problem != "" {
+			broken = append(broken, dir)
+			if repairDryRun {
+				out.Printf("would repair %s: %s\n", out.Bold(dir), problem)
+			}
+		}
+	}
+
+	if repairDryRun {
+		prunable, err := git.ListPrunableWorktrees(repo)
+		if err != nil {
+			disp.Warningf("%v", err)
+			return len(broken)
+		}
+		count := len(broken)
+		for _, p := range prunable {
+			if linked[p.Name] {
+				continue
+			}
+			count++
+			out.Printf("would prune %s: %s\n", out.Bold(filepath.Join(repo, p.Name)), p.Reason)
+		}
+		return count
+	}
+
+	repairs, err := git.RepairWorktrees(repo, worktrees)
+	recordOperation("repair-worktrees", projectName, "", err)
+	for _, repair := range repairs {
+		out.Printf("Repaired %s\n", repair)
+	}
+	if err != nil {
+		disp.Warningf("%v", err)
+	}
+
+	prunable, err := git.ListPrunableWorktrees(repo)
+	if err == nil && len(prunable) > 0 {
+		err = git.PruneWorktrees(repo)
+		if err == nil {
+			for _, p := range prunable {
+				out.Printf("Pruned %s: %s\n", out.Bold(filepath.Join(repo, p.Name)), p.Reason)
+			}
+		}
+	}
+	if err != nil {
+		disp.Warningf("%v", err)
+	}
+
+	// What git couldn't repair, e.g. a worktree of another repository in its place
+	for _, dir := range broken {
+		if problem := git.CheckWorktreeLink(dir); problem != "" {
+			disp.Warningf("%s is still broken: %s", dir, problem)
+		}
+	}
+
+	return len(repairs) + len(prunable)
+}
+
+// scanWorktrees finds the bare repositories and worktrees in a workspace. Worktrees are
+// assigned to a repository by where they are in the workspace layout, or by their .git file.
+func scanWorktrees(workspaceDir string) (*repairScan, error) {
+	scan := &repairScan{worktrees: map[string][]string{}}
+
+	var dirs []string
+	err := workspace.WalkBareRepos(workspaceDir, func(path string) {
+		scan.repos = append(scan.repos, path)
+	}, func(path string) {
+		// Directories inside a worktree, like submodules, have .git files too
+		for _, dir := range dirs {
+			if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+				return
+			}
+		}
+		if info, err := os.Stat(filepath.Join(path, ".git")); err == nil && info.Mode().IsRegular() {
+			dirs = append(dirs, path)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		projectName, _ := workspace.FindWorktree(workspaceDir, dir)
+		repo := workspace.GetBareRepoPath(workspaceDir, projectName)
+		if projectName == "" || !slices.Contains(scan.repos, repo) {
+			scan.orphans = append(scan.orphans, dir)
+			continue
+		}
+		scan.worktrees[repo] = append(scan.worktrees[repo], dir)
+	}
+	return scan, nil
+}
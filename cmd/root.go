@@ -4,6 +4,13 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/logging"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
 	"github.com/rotisserie/eris"
 	"github.com/spf13/cobra"
 )
@@ -27,17 +34,175 @@ Shell Completion:
   sesh completion zsh          # Generate zsh completion
   sesh completion fish         # Generate fish completion
   sesh completion powershell   # Generate powershell completion`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Commands of remote profiles run on their host, and don't return
+		runOnProfileHost(cmd)
+
+		// An invalid depth is reported by commands that load the full configuration
+		if depth, err := config.GetDiscoveryMaxDepth(); err == nil {
+			workspace.SetMaxDepth(depth)
+		}
+
+		// Likewise for an invalid worktree path template
+		if template, err := config.GetWorktreePathTemplate(); err == nil {
+			workspace.SetWorktreePathTemplate(template)
+		}
+
+		// Likewise for an invalid fetch pruning setting
+		if prune, err := config.GetFetchPrune(); err == nil {
+			git.SetFetchPrune(prune)
+		}
+
+		// And for an invalid git timeout
+		if timeout, err := config.GetGitTimeout(); err == nil {
+			git.SetTimeout(timeout)
+		}
+		if retries, err := config.GetGitRetries(); err == nil {
+			git.SetRetries(retries)
+		}
+
+		// Aliases are accepted wherever a project is given; invalid ones are reported the same way
+		if aliases, err := config.GetProjectAliases(); err == nil {
+			project.SetAliases(aliases)
+		}
+
+		// Projects are discovered in every workspace; an invalid list is reported the same way
+		if workspaces, err := config.GetWorkspaces(); err == nil {
+			state.SetWorkspaceDirs(config.WorkspacePaths(workspaces))
+		}
+
+		// Session names of projects sharing a repository name are kept in the database
+		state.SetPrefixStore(openDB)
+
+		// Project discovery is cached unless disabled; failing to locate the
+		// cache directory just means discovery always walks the workspace
+		if noCache {
+			return
+		}
+		if cacheDir, err := config.GetCacheDir(); err == nil {
+			state.SetCacheDir(cacheDir)
+		}
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		reapSessionsAfter(cmd)
+	},
+}
+
+var (
+	// noCache disables the project discovery cache for this invocation
+	noCache bool
+	// noColor disables colors in the output of this invocation
+	noColor bool
+	// logLevel overrides the configured lowest level of diagnostics printed to stderr
+	logLevel string
+	// verbose prints debug diagnostics, like --log-level debug
+	verbose bool
+	// selectedProfile selects the configuration profile, like SESH_PROFILE
+	selectedProfile string
+)
+
+// colorDisabled reports whether colors were turned off with --no-color or NO_COLOR
+// (https://no-color.org)
+func colorDisabled() bool {
+	return noColor || os.Getenv("NO_COLOR") != ""
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	registerProjectCompletions(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "%+v\n", eris.ToString(err, true))
 		os.Exit(1)
 	}
 }
 
+// setupProfile selects the profile given with --profile and sets its environment variables
+// SESH_PROFILE is exported, so sesh processes started by this one use the same profile.
+func setupProfile() {
+	if selectedProfile != "" {
+		//nolint:errcheck // Only fails for invalid names, which can't come from a flag
+		os.Setenv("SESH_PROFILE", selectedProfile)
+	}
+
+	// An unknown profile is reported by commands that load the full configuration
+	env, err := config.GetProfileEnv()
+	if err != nil {
+		return
+	}
+	for key, value := range env {
+		//nolint:errcheck // Only fails for invalid names, which git and gh couldn't use anyway
+		os.Setenv(key, value)
+	}
+}
+
+// setupDisplay applies the configured theme and --no-color to all output
+func setupDisplay() {
+	display.SetNoColor(colorDisabled())
+	// An invalid theme is reported by commands that load the full configuration
+	if theme, err := config.GetTheme(); err == nil {
+		//nolint:errcheck // GetTheme only returns valid themes
+		display.SetTheme(theme.DisplayTheme())
+	}
+}
+
+// setupLogging configures the slog logger from the flags and configuration
+func setupLogging() {
+	level := logLevel
+	if verbose {
+		level = "debug"
+	}
+	if level == "" {
+		// An invalid configured level is reported by commands that load the full configuration
+		level = logging.DefaultLevel
+		if configured, err := config.GetLogLevel(); err == nil {
+			level = configured
+		}
+	}
+	parsed, err := logging.ParseLevel(level)
+	if err != nil {
+		display.NewStderr().Warningf("invalid --log-level: %v", err)
+		parsed, _ = logging.ParseLevel(logging.DefaultLevel)
+	}
+
+	path := ""
+	if enabled, err := config.GetLogFile(); err == nil && enabled {
+		path, _ = config.GetLogPath()
+		//nolint:errcheck // Setup reports a missing directory below
+		config.EnsureConfigDir()
+	}
+
+	if err := logging.Setup(os.Stderr, parsed, path); err != nil {
+		display.NewStderr().Warning(err.Error())
+		//nolint:errcheck // Without a file, Setup can't fail
+		logging.Setup(os.Stderr, parsed, "")
+	}
+}
+
+// completeProfiles completes the names of the profiles in the config file
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	profiles, err := config.ListProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return profiles, cobra.ShellCompDirectiveNoFileComp
+}
+
 func init() {
-	// Global flags can be defined here
+	// Initializers run for every command, including those that skip the root setup
+	cobra.OnInitialize(setupProfile, setupDisplay, setupLogging)
+	rootCmd.PersistentFlags().
+		BoolVar(&noCache, "no-cache", false, "Rediscover projects instead of using the discovery cache")
+	rootCmd.PersistentFlags().
+		BoolVar(&noColor, "no-color", false, "Disable colors (also set by the NO_COLOR environment variable)")
+	rootCmd.PersistentFlags().
+		StringVar(&logLevel, "log-level", "", "Lowest level of diagnostics to print: debug, info, warn, or error")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print debug diagnostics")
+	rootCmd.PersistentFlags().BoolVar(
+		&offline, "offline", false, "Skip fetches, pull requests, and remote branches (also set by SESH_OFFLINE)",
+	)
+	rootCmd.PersistentFlags().
+		StringVar(&selectedProfile, "profile", "", "Configuration profile to use (also set by SESH_PROFILE)")
+	//nolint:errcheck // The flag is defined above
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 }
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/rotisserie/eris"
+)
+
+// rpcMaxLineSize bounds a single request line on the unix socket
+const rpcMaxLineSize = 1 << 20
+
+// rpcRequest is one line sent by a client over the unix socket
+// The ID is echoed back so clients can match responses to pipelined requests
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params rpcParams       `json:"params"`
+}
+
+// rpcParams are the parameters of every RPC method; each method uses the ones it needs
+type rpcParams struct {
+	Project string `json:"project"`
+	Branch  string `json:"branch"`
+}
+
+// rpcResponse is one line sent back to the client, holding either a result or an error
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result any             `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// handleRPC runs one RPC request against the API
+func (a *api) handleRPC(ctx context.Context, req *rpcRequest) rpcResponse {
+	var result any
+	var err error
+
+	switch req.Method {
+	case "projects":
+		result, err = a.projects()
+	case "list":
+		result, err = a.sessions(req.Params.Project)
+	case "info":
+		result, err = a.info(req.Params.Project, req.Params.Branch)
+	case "switch":
+		result, err = a.switchTo(ctx, req.Params.Project, req.Params.Branch)
+	case "worktree":
+		result, err = a.createWorktree(ctx, req.Params.Project, req.Params.Branch)
+	default:
+		err = eris.Wrapf(errInvalidRequest, "unknown method: %q", req.Method)
+	}
+
+	if err != nil {
+		return rpcResponse{ID: req.ID, Error: err.Error()}
+	}
+	return rpcResponse{ID: req.ID, Result: result}
+}
+
+// serveRPCConn answers the JSON-lines requests of one connection in order until it is closed
+func (a *api) serveRPCConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), rpcMaxLineSize)
+	encoder := json.NewEncoder(conn)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp rpcResponse
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = rpcResponse{Error: eris.Wrapf(errInvalidRequest, "malformed request: %v", err).Error()}
+		} else {
+			resp = a.handleRPC(ctx, &req)
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// listenSocket listens on the unix socket at path, which only the current user can connect to
+// A socket left behind by a crashed server is replaced, but one that is in use is not
+func listenSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, eris.Wrapf(err, "failed to create socket directory: %s", filepath.Dir(path))
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			//nolint:errcheck // Only probing
+			conn.Close()
+			return nil, eris.Errorf("another sesh server is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, eris.Wrapf(err, "failed to remove stale socket: %s", path)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to listen on %s", path)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		//nolint:errcheck // Close in error path
+		listener.Close()
+		return nil, eris.Wrapf(err, "failed to restrict socket permissions: %s", path)
+	}
+
+	return listener, nil
+}
+
+// serveRPC accepts socket connections until the context is canceled
+// The socket file is removed when the listener closes. Open connections are not waited for,
+// since editor plugins keep them open for as long as they run.
+func (a *api) serveRPC(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		//nolint:errcheck // Shutting down anyway
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return eris.Wrap(err, "failed to accept connection")
+		}
+
+		go a.serveRPCConn(ctx, conn)
+	}
+}
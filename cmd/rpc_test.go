@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/display"
+)
+
+func TestServeRPC(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	a := &api{cfg: cfg, sessionMgr: &fakeSessionManager{}, disp: display.New(io.Discard)}
+
+	// Unix socket paths are limited to about 100 bytes, so avoid the long test temp dir
+	dir, err := os.MkdirTemp("", "sesh")
+	if err != nil {
+		t.Fatalf("MkdirTemp() failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "sesh.sock")
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("listenSocket() failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- a.serveRPC(ctx, listener) }()
+
+	if _, err := listenSocket(path); err == nil {
+		t.Error("listenSocket() succeeded on a socket in use")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	tests := []struct {
+		name      string
+		request   string
+		wantID    string
+		wantError string
+		wantBody  string
+	}{
+		{
+			name:     "projects",
+			request:  `{"id": 1, "method": "projects"}`,
+			wantID:   "1",
+			wantBody: `"name":"example.com/user/repo"`,
+		},
+		{
+			name:     "info of a remote branch",
+			request:  `{"id": "a", "method": "info", "params": {"project": "repo", "branch": "main"}}`,
+			wantID:   `"a"`,
+			wantBody: `"has_worktree":false`,
+		},
+		{
+			name:     "switch",
+			request:  `{"id": 2, "method": "switch", "params": {"project": "repo", "branch": "main"}}`,
+			wantID:   "2",
+			wantBody: `"session_name":"repo-main"`,
+		},
+		{
+			name:     "info of a worktree",
+			request:  `{"id": 3, "method": "info", "params": {"project": "repo", "branch": "main"}}`,
+			wantID:   "3",
+			wantBody: `"is_running":true`,
+		},
+		{
+			name:     "list",
+			request:  `{"id": 4, "method": "list"}`,
+			wantID:   "4",
+			wantBody: `"SessionName":"repo-main"`,
+		},
+		{
+			name:      "unknown method",
+			request:   `{"id": 5, "method": "explode"}`,
+			wantID:    "5",
+			wantError: "unknown method",
+		},
+		{
+			name:      "malformed request",
+			request:   `{"id": 6,`,
+			wantError: "malformed request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := conn.Write([]byte(tt.request + "\n")); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				t.Fatalf("ReadBytes() failed: %v", err)
+			}
+
+			var resp struct {
+				ID     json.RawMessage `json:"id"`
+				Result json.RawMessage `json:"result"`
+				Error  string          `json:"error"`
+			}
+			if err := json.Unmarshal(line, &resp); err != nil {
+				t.Fatalf("malformed response %s: %v", line, err)
+			}
+
+			if string(resp.ID) != tt.wantID {
+				t.Errorf("id = %s, want %s", resp.ID, tt.wantID)
+			}
+			if tt.wantError != "" {
+				if !strings.Contains(resp.Error, tt.wantError) {
+					t.Errorf("error = %q, want it to contain %q", resp.Error, tt.wantError)
+				}
+				return
+			}
+			if resp.Error != "" {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if !strings.Contains(string(resp.Result), tt.wantBody) {
+				t.Errorf("result = %s, want it to contain %s", resp.Result, tt.wantBody)
+			}
+		})
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("serveRPC() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after shutdown: %v", err)
+	}
+}
+
+func TestListenSocket_ReplacesStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sesh")
+	if err != nil {
+		t.Fatalf("MkdirTemp() failed: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "sesh.sock")
+
+	// Leave the socket file behind, as a crashed server would
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("ListenUnix() failed: %v", err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		t.Fatalf("listenSocket() failed on a stale socket: %v", err)
+	}
+	listener.Close()
+}
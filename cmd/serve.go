@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen string
+	serveSocket string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local HTTP/JSON API for editor plugins and status bars",
+	Long: `Serve a local HTTP API so editor plugins and status bars can integrate
+with sesh without running a new sesh process for every request.
+
+The address the server listens on is printed to stdout once it is ready, so
+the default port 0 (any free port) can be used by clients that start the server
+themselves.
+
+Endpoints:
+  GET  /api/projects                          List projects
+  GET  /api/sessions[?project=NAME]           List worktree sessions (as 'sesh list --json')
+  GET  /api/info?project=NAME&branch=BRANCH   Describe a branch's worktree and session
+  POST /api/switch     {"project", "branch"}  Prepare a worktree and session
+  POST /api/worktrees  {"project", "branch"}  Create a worktree
+
+POST requests must have a JSON body. Errors are returned as {"error": "..."}.
+
+The API is unauthenticated, so it only listens on loopback addresses.
+
+With --socket, sesh listens on a unix socket in the config directory instead,
+for editor plugins that need the lowest latency. Each line sent is a JSON request
+and is answered by one JSON line, in order:
+
+  {"id": 1, "method": "info", "params": {"project": "repo", "branch": "main"}}
+  {"id": 1, "result": {"session_name": "repo-main", ...}}
+
+Methods are projects, list (optional project), info, switch, and worktree (project
+and branch). Failed requests are answered with {"id": 1, "error": "..."}.
+
+Examples:
+  sesh serve                           # Listen on a free port on 127.0.0.1
+  sesh serve --listen 127.0.0.1:7878   # Listen on a fixed port
+  sesh serve --socket                  # Listen on the unix socket`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:0", "Loopback address to listen on")
+	serveCmd.Flags().StringVar(&serveSocket, "socket", "", "Serve JSON-lines RPC on a unix socket (default path in the config dir)")
+	serveCmd.Flags().Lookup("socket").NoOptDefVal = "default"
+	serveCmd.MarkFlagsMutuallyExclusive("listen", "socket")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	if serveSocket == "" {
+		if err := checkLoopback(serveListen); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	a, err := newAPI(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if serveSocket != "" {
+		return serveUnixSocket(ctx, a, disp)
+	}
+
+	listener, err := net.Listen("tcp", serveListen)
+	if err != nil {
+		return eris.Wrapf(err, "failed to listen on %s", serveListen)
+	}
+
+	server := &http.Server{
+		Handler:           a.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		//nolint:errcheck // Shutting down anyway
+		server.Shutdown(shutdownCtx)
+	}()
+
+	// The address is the output clients wait for, so it goes to stdout
+	fmt.Printf("http://%s\n", listener.Addr())
+	disp.Printf("%s Serving the sesh API (Ctrl+C to stop)\n", disp.InfoText("→"))
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return eris.Wrap(err, "server failed")
+	}
+	return nil
+}
+
+// serveUnixSocket serves the JSON-lines RPC on the unix socket until the context is canceled
+func serveUnixSocket(ctx context.Context, a *api, disp display.Printer) error {
+	path := serveSocket
+	if path == "default" {
+		var err error
+		path, err = config.GetSocketPath()
+		if err != nil {
+			return eris.Wrap(err, "failed to get socket path")
+		}
+	}
+
+	listener, err := listenSocket(path)
+	if err != nil {
+		return err
+	}
+
+	// The socket path is the output clients wait for, so it goes to stdout
+	fmt.Println(path)
+	disp.Printf("%s Serving the sesh RPC socket (Ctrl+C to stop)\n", disp.InfoText("→"))
+
+	return a.serveRPC(ctx, listener)
+}
+
+// checkLoopback refuses listen addresses that would expose the API beyond this machine
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return eris.Wrapf(err, "invalid listen address: %s", addr)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return eris.Errorf("listen address must be a loopback address (e.g. 127.0.0.1:0), got: %s", addr)
+}
+
+// handler routes the API endpoints
+func (a *api) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/projects", func(w http.ResponseWriter, r *http.Request) {
+		projects, err := a.projects()
+		writeJSON(w, projects, err)
+	})
+
+	mux.HandleFunc("GET /api/sessions", func(w http.ResponseWriter, r *http.Request) {
+		sessions, err := a.sessions(r.URL.Query().Get("project"))
+		writeJSON(w, sessions, err)
+	})
+
+	mux.HandleFunc("GET /api/info", func(w http.ResponseWriter, r *http.Request) {
+		info, err := a.info(r.URL.Query().Get("project"), r.URL.Query().Get("branch"))
+		writeJSON(w, info, err)
+	})
+
+	mux.HandleFunc("POST /api/switch", func(w http.ResponseWriter, r *http.Request) {
+		var req branchRequest
+		if err := decodeJSON(r, &req); err != nil {
+			writeJSON(w, nil, err)
+			return
+		}
+		result, err := a.switchTo(r.Context(), req.Project, req.Branch)
+		writeJSON(w, result, err)
+	})
+
+	mux.HandleFunc("POST /api/worktrees", func(w http.ResponseWriter, r *http.Request) {
+		var req branchRequest
+		if err := decodeJSON(r, &req); err != nil {
+			writeJSON(w, nil, err)
+			return
+		}
+		result, err := a.createWorktree(r.Context(), req.Project, req.Branch)
+		writeJSON(w, result, err)
+	})
+
+	return checkHost(mux)
+}
+
+// branchRequest is the body of API requests that act on a branch of a project
+type branchRequest struct {
+	Project string `json:"project"`
+	Branch  string `json:"branch"`
+}
+
+// checkHost rejects requests addressed to a name other than a loopback address,
+// so web pages can't reach the API through DNS rebinding
+func checkHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if host != "localhost" {
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				writeJSON(w, nil, eris.Wrapf(errInvalidRequest, "unexpected host: %s", r.Host))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeJSON decodes a JSON request body
+// Requiring the JSON content type keeps browsers from sending requests without a CORS preflight
+func decodeJSON(r *http.Request, v any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return eris.Wrap(errInvalidRequest, "content type must be application/json")
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return eris.Wrapf(errInvalidRequest, "malformed JSON body: %v", err)
+	}
+	return nil
+}
+
+// writeJSON writes v as the JSON response, or err as a JSON error with a matching status code
+func writeJSON(w http.ResponseWriter, v any, err error) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case eris.Is(err, errInvalidRequest):
+			status = http.StatusBadRequest
+		case eris.Is(err, errNotFound):
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		v = map[string]string{"error": err.Error()}
+	}
+
+	//nolint:errcheck // The client went away
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/session"
+)
+
+// setupTestWorkspace creates a workspace holding one project, example.com/user/repo, with a
+// main branch, and isolates the config directory and database from the user's
+func setupTestWorkspace(t *testing.T) *config.Config {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	src := filepath.Join(t.TempDir(), "src")
+	workspaceDir := t.TempDir()
+	bare := filepath.Join(workspaceDir, "example.com", "user", "repo.git")
+
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main", src},
+		{"-C", src, "commit", "--quiet", "--allow-empty", "-m", "initial"},
+		{"clone", "--quiet", "--bare", src, bare},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	return &config.Config{WorkspaceDir: workspaceDir, SessionBackend: "none"}
+}
+
+// fakeSessionManager keeps sessions in memory
+// Embedding session.SessionManager means any method the test doesn't expect panics
+type fakeSessionManager struct {
+	session.SessionManager
+	sessions []string
+}
+
+func (f *fakeSessionManager) Name() string { return "fake" }
+
+func (f *fakeSessionManager) Create(name, path string) error {
+	f.sessions = append(f.sessions, name)
+	return nil
+}
+
+func (f *fakeSessionManager) Exists(name string) (bool, error) {
+	return slices.Contains(f.sessions, name), nil
+}
+
+func (f *fakeSessionManager) List() ([]string, error) { return f.sessions, nil }
+
+func (f *fakeSessionManager) IsInsideSession() bool { return false }
+
+func TestServeAPI(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+
+	a := &api{cfg: cfg, sessionMgr: &fakeSessionManager{}, disp: display.New(io.Discard)}
+	server := httptest.NewServer(a.handler())
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:       "list projects",
+			method:     http.MethodGet,
+			path:       "/api/projects",
+			wantStatus: http.StatusOK,
+			wantBody:   `"name":"example.com/user/repo"`,
+		},
+		{
+			name:        "create worktree",
+			method:      http.MethodPost,
+			path:        "/api/worktrees",
+			contentType: "application/json",
+			body:        `{"project": "repo", "branch": "main"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `"created":true`,
+		},
+		{
+			name:        "create existing worktree",
+			method:      http.MethodPost,
+			path:        "/api/worktrees",
+			contentType: "application/json",
+			body:        `{"project": "repo", "branch": "main"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `"created":false`,
+		},
+		{
+			name:       "list sessions of a project",
+			method:     http.MethodGet,
+			path:       "/api/sessions?project=repo",
+			wantStatus: http.StatusOK,
+			wantBody:   `"SessionName":"repo-main","ProjectName":"example.com/user/repo","Branch":"main"`,
+		},
+		{
+			name:        "switch",
+			method:      http.MethodPost,
+			path:        "/api/switch",
+			contentType: "application/json",
+			body:        `{"project": "example.com/user/repo", "branch": "main"}`,
+			wantStatus:  http.StatusOK,
+			wantBody:    `"session_name":"repo-main"`,
+		},
+		{
+			name:       "running session",
+			method:     http.MethodGet,
+			path:       "/api/sessions",
+			wantStatus: http.StatusOK,
+			wantBody:   `"IsRunning":true`,
+		},
+		{
+			name:        "unknown project",
+			method:      http.MethodPost,
+			path:        "/api/switch",
+			contentType: "application/json",
+			body:        `{"project": "missing", "branch": "main"}`,
+			wantStatus:  http.StatusNotFound,
+			wantBody:    `"error"`,
+		},
+		{
+			name:        "missing branch",
+			method:      http.MethodPost,
+			path:        "/api/worktrees",
+			contentType: "application/json",
+			body:        `{"project": "repo"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    "branch is required",
+		},
+		{
+			name:        "not JSON",
+			method:      http.MethodPost,
+			path:        "/api/switch",
+			contentType: "text/plain",
+			body:        `{"project": "repo", "branch": "main"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBody:    "content type must be application/json",
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			path:       "/api/switch",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("NewRequest() failed: %v", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body json.RawMessage
+			//nolint:errcheck // Non-JSON bodies are checked through the status code
+			json.NewDecoder(resp.Body).Decode(&body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantBody)
+			}
+		})
+	}
+
+	worktreePath := filepath.Join(cfg.WorkspaceDir, "example.com", "user", "repo", "main")
+	if _, err := os.Stat(worktreePath); err != nil {
+		t.Errorf("worktree was not created at %s: %v", worktreePath, err)
+	}
+}
+
+func TestServeAPI_RejectsForeignHost(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+
+	a, err := newAPI(cfg)
+	if err != nil {
+		t.Fatalf("newAPI() failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/projects", nil)
+	req.Host = "attacker.example.com"
+	rec := httptest.NewRecorder()
+	a.handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCheckLoopback(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{addr: "127.0.0.1:0"},
+		{addr: "localhost:7878"},
+		{addr: "[::1]:7878"},
+		{addr: "0.0.0.0:7878", wantErr: true},
+		{addr: ":7878", wantErr: true},
+		{addr: "192.168.1.10:7878", wantErr: true},
+		{addr: "127.0.0.1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			err := checkLoopback(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkLoopback(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeTitler records the titles set on its sessions
+type fakeTitler struct {
+	fakeSessionManager
+	titles map[string]string
+}
+
+func (f *fakeTitler) SetTitle(name, title string) error {
+	f.titles[name] = title
+	return nil
+}
+
+func TestAPISwitchToSetsTitle(t *testing.T) {
+	cfg := setupTestWorkspace(t)
+	cfg.Tmux.WindowTitles = true
+
+	titler := &fakeTitler{titles: map[string]string{}}
+	a := &api{cfg: cfg, sessionMgr: titler, disp: display.New(io.Discard)}
+	if _, err := a.switchTo(t.Context(), "example.com/user/repo", "main"); err != nil {
+		t.Fatalf("switchTo() failed: %v", err)
+	}
+	if got := titler.titles["repo-main"]; got != "repo:main" {
+		t.Errorf("title of repo-main = %q, want repo:main", got)
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// shellCDFileEnv names the file the shell function from 'sesh shell-init' reads a directory
+// to cd into from after sesh exits
+const shellCDFileEnv = "SESH_CD_FILE"
+
+// posixShellInit wraps sesh in a bash/zsh function that changes to the directory sesh asks for
+const posixShellInit = `sesh() {
+  local cd_file ret
+  cd_file="$(mktemp "${TMPDIR:-/tmp}/sesh-cd.XXXXXX")" || return
+  SESH_CD_FILE="$cd_file" command sesh "$@"
+  ret=$?
+  if [ -s "$cd_file" ]; then
+    cd -- "$(cat "$cd_file")" || ret=$?
+  fi
+  rm -f -- "$cd_file"
+  return $ret
+}
+`
+
+// fishShellInit wraps sesh in a fish function that changes to the directory sesh asks for
+const fishShellInit = `function sesh --wraps sesh --description 'sesh, changing directory on switch'
+    set -l tmpdir /tmp
+    set -q TMPDIR; and set tmpdir $TMPDIR
+    set -l cd_file (mktemp "$tmpdir/sesh-cd.XXXXXX"); or return
+    env SESH_CD_FILE=$cd_file sesh $argv
+    set -l ret $status
+    if test -s $cd_file
+        cd (cat $cd_file); or set ret $status
+    end
+    rm -f -- $cd_file
+    return $ret
+end
+`
+
+// bashShellWidget binds ctrl-g to the fuzzy switcher in bash
+// bind -x runs in the current shell, so the widget can cd.
+const bashShellWidget = `_sesh_widget() {
+  local cd_file
+  cd_file="$(mktemp "${TMPDIR:-/tmp}/sesh-cd.XXXXXX")" || return
+  SESH_CD_FILE="$cd_file" command sesh switch
+  if [ -s "$cd_file" ]; then
+    cd -- "$(cat "$cd_file")" || true
+  fi
+  rm -f -- "$cd_file"
+}
+bind -x '"\C-g": _sesh_widget'
+`
+
+// zshShellWidget binds ctrl-g to the fuzzy switcher in zsh
+// Widgets have no terminal on stdin, so the switcher reads from /dev/tty.
+const zshShellWidget = `_sesh_widget() {
+  local cd_file
+  cd_file="$(mktemp "${TMPDIR:-/tmp}/sesh-cd.XXXXXX")" || return
+  SESH_CD_FILE="$cd_file" command sesh switch </dev/tty
+  if [ -s "$cd_file" ]; then
+    cd -- "$(cat "$cd_file")" || true
+  fi
+  rm -f -- "$cd_file"
+  zle reset-prompt
+}
+zle -N _sesh_widget
+bindkey '^G' _sesh_widget
+`
+
+// fishShellWidget binds ctrl-g to the fuzzy switcher in fish, in both emacs and vi mode
+const fishShellWidget = `function _sesh_widget --description 'Switch branches with sesh'
+    set -l tmpdir /tmp
+    set -q TMPDIR; and set tmpdir $TMPDIR
+    set -l cd_file (mktemp "$tmpdir/sesh-cd.XXXXXX"); or return
+    env SESH_CD_FILE=$cd_file sesh switch </dev/tty
+    if test -s $cd_file
+        cd (cat $cd_file)
+    end
+    rm -f -- $cd_file
+    commandline -f repaint
+end
+bind \cg _sesh_widget
+if bind -M insert >/dev/null 2>&1
+    bind -M insert \cg _sesh_widget
+end
+`
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init <bash|zsh|fish>",
+	Short: "Print shell integration that changes directory on switch",
+	Long: `Print a shell function that wraps sesh so 'sesh switch' can change the directory
+of your shell. It is used when there is no session to attach to: when the session
+backend is "none", or when --detach (--no-attach) is passed.
+
+Add it to your shell's startup file:
+
+  bash (~/.bashrc):                eval "$(sesh shell-init bash)"
+  zsh (~/.zshrc):                  eval "$(sesh shell-init zsh)"
+  fish (~/.config/fish/config.fish): sesh shell-init fish | source`,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:      runShellInit,
+}
+
+var shellWidgetCmd = &cobra.Command{
+	Use:   "shell-widget <bash|zsh|fish>",
+	Short: "Print a ctrl-g keybinding that opens the fuzzy switcher",
+	Long: `Print a shell widget that binds ctrl-g to 'sesh switch', the fuzzy branch
+switcher for the current project. The selected branch is attached to, or when there
+is no session to attach to (the "none" session backend), your shell changes
+directory to its worktree.
+
+Add it to your shell's startup file:
+
+  bash (~/.bashrc):                eval "$(sesh shell-widget bash)"
+  zsh (~/.zshrc):                  eval "$(sesh shell-widget zsh)"
+  fish (~/.config/fish/config.fish): sesh shell-widget fish | source
+
+To use another key, bind the _sesh_widget function yourself after sourcing it.`,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:      runShellWidget,
+}
+
+func init() {
+	rootCmd.AddCommand(shellInitCmd)
+	rootCmd.AddCommand(shellWidgetCmd)
+}
+
+func runShellInit(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash", "zsh":
+		fmt.Print(posixShellInit)
+	case "fish":
+		fmt.Print(fishShellInit)
+	default:
+		return eris.Errorf("unsupported shell: %s", args[0])
+	}
+	return nil
+}
+
+func runShellWidget(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case "bash":
+		fmt.Print(bashShellWidget)
+	case "zsh":
+		fmt.Print(zshShellWidget)
+	case "fish":
+		fmt.Print(fishShellWidget)
+	default:
+		return eris.Errorf("unsupported shell: %s", args[0])
+	}
+	return nil
+}
+
+// requestShellCD asks the shell function from 'sesh shell-init' to change to dir once sesh exits
+// Reports whether the shell integration is in use.
+func requestShellCD(dir string) (bool, error) {
+	path := os.Getenv(shellCDFileEnv)
+	if path == "" {
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, []byte(dir), 0o600); err != nil {
+		return false, eris.Wrapf(err, "failed to write directory for the shell: %s", path)
+	}
+	return true, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPosixShellInit(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh"} {
+		t.Run(shell, func(t *testing.T) {
+			shellPath, err := exec.LookPath(shell)
+			if err != nil {
+				t.Skipf("%s is not installed", shell)
+			}
+
+			// A stand-in for the sesh binary that asks the shell to cd, then fails
+			binDir := t.TempDir()
+			target := t.TempDir()
+			fake := "#!/bin/sh\nprintf '%s' \"$1\" > \"$SESH_CD_FILE\"\nexit 3\n"
+			if err := os.WriteFile(filepath.Join(binDir, "sesh"), []byte(fake), 0o755); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			script := posixShellInit + `sesh "$TARGET"; echo "$? $PWD"`
+			cmd := exec.Command(shellPath, "-c", script)
+			cmd.Env = append(os.Environ(), "PATH="+binDir+":"+os.Getenv("PATH"), "TARGET="+target)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("%s failed: %v: %s", shell, err, out)
+			}
+
+			// The exit status of sesh is kept, and the directory is changed even when it fails
+			want := "3 " + target
+			if got := strings.TrimSpace(string(out)); got != want {
+				t.Errorf("output = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestRequestShellCD(t *testing.T) {
+	t.Setenv(shellCDFileEnv, "")
+	if integrated, err := requestShellCD("/tmp/worktree"); err != nil || integrated {
+		t.Errorf("requestShellCD() without integration = %v, %v; want false, nil", integrated, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cd")
+	t.Setenv(shellCDFileEnv, path)
+	if integrated, err := requestShellCD("/tmp/worktree"); err != nil || !integrated {
+		t.Fatalf("requestShellCD() = %v, %v; want true, nil", integrated, err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if string(got) != "/tmp/worktree" {
+		t.Errorf("directory = %q, want %q", got, "/tmp/worktree")
+	}
+}
+
+func TestBashShellWidget(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	// A stand-in for the sesh binary that records its arguments and asks the shell to cd
+	binDir := t.TempDir()
+	target := t.TempDir()
+	fake := "#!/bin/sh\necho \"$@\" > \"$TARGET/args\"\nprintf '%s' \"$TARGET\" > \"$SESH_CD_FILE\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "sesh"), []byte(fake), 0o755); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	// bind complains without line editing, which doesn't matter for calling the widget directly
+	script := bashShellWidget + `_sesh_widget; echo "$PWD"`
+	cmd := exec.Command(bash, "-c", script)
+	cmd.Env = append(os.Environ(), "PATH="+binDir+":"+os.Getenv("PATH"), "TARGET="+target)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("bash failed: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(out)); got != target {
+		t.Errorf("directory = %q, want %q", got, target)
+	}
+	args, err := os.ReadFile(filepath.Join(target, "args"))
+	if err != nil {
+		t.Fatalf("sesh was not run: %v", err)
+	}
+	if got := strings.TrimSpace(string(args)); got != "switch" {
+		t.Errorf("sesh arguments = %q, want %q", got, "switch")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
@@ -17,6 +19,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	statusJSON  bool
+	statusPlain bool
+)
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current session and project information",
@@ -29,18 +36,53 @@ Shows details about:
 - Git status summary
 - Other available sessions for this project
 
+With --json, the same fields are printed as a JSON object. With --plain, they're
+printed one per line, as the field name and its value separated by a tab, and
+each other session as an other_session line with its name, branch, state, and
+last use.
+
 Examples:
-  sesh status`,
+  sesh status
+  sesh status --json
+  sesh status --plain | awk -F'\t' '$1 == "branch" { print $2 }'`,
 	RunE: runStatus,
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output in JSON format")
+	statusCmd.Flags().BoolVar(&statusPlain, "plain", false, "Output tab-separated field names and values")
+	statusCmd.MarkFlagsMutuallyExclusive("json", "plain")
 }
 
-func runStatus(cmd *cobra.Command, args []string) error {
-	disp := display.NewStderr()
+// statusInfo is what 'sesh status' shows, as output by --json
+type statusInfo struct {
+	// CurrentSession is the session sesh runs in, if any
+	CurrentSession string `json:"current_session,omitempty"`
+	// The project, branch, and worktree of the current directory, when it's in one
+	Project      string     `json:"project,omitempty"`
+	RemoteURL    string     `json:"remote_url,omitempty"`
+	Branch       string     `json:"branch,omitempty"`
+	WorktreePath string     `json:"worktree_path,omitempty"`
+	LastUsed     *time.Time `json:"last_used,omitempty"`
+	// The session of the worktree, and whether it's running
+	SessionName string `json:"session_name,omitempty"`
+	IsRunning   bool   `json:"is_running"`
+	// Summary of uncommitted changes, e.g. "2 modified, 1 untracked" or "clean"
+	GitStatus string `json:"git_status,omitempty"`
+	// The sessions of the other worktrees of the project
+	OtherSessions []statusSession `json:"other_sessions,omitempty"`
+}
+
+// statusSession is the session of another worktree of the current project
+type statusSession struct {
+	SessionName string    `json:"session_name"`
+	Branch      string    `json:"branch"`
+	IsRunning   bool      `json:"is_running"`
+	LastUsed    time.Time `json:"last_used"`
+}
 
+func runStatus(cmd *cobra.Command, args []string) error {
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -53,118 +95,182 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to initialize session manager")
 	}
 
+	info, err := collectStatus(cfg, sessionMgr)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case statusJSON:
+		return printJSON(info)
+	case statusPlain:
+		printPlain(statusFields(info)...)
+	default:
+		printStatus(display.NewStderr(), info)
+	}
+	return nil
+}
+
+// collectStatus returns the session, project, and worktree of the current directory
+func collectStatus(cfg *config.Config, sessionMgr session.SessionManager) (*statusInfo, error) {
+	info := &statusInfo{}
+
 	// Get current session
 	currentSessionName, err := sessionMgr.GetCurrentSessionName()
 	if err != nil {
-		return eris.Wrap(err, "failed to get current session name")
+		return nil, eris.Wrap(err, "failed to get current session name")
 	}
+	info.CurrentSession = currentSessionName
 
 	// Get current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
-		return eris.Wrap(err, "failed to get current working directory")
+		return nil, eris.Wrap(err, "failed to get current working directory")
 	}
 
 	// Try to resolve project from CWD using filesystem state
 	proj, err := project.ResolveProject(cfg.WorkspaceDir, "", cwd)
 	if err != nil {
 		// Not in a project directory
-		if currentSessionName != "" {
-			disp.Printf("Current Session: %s\n", currentSessionName)
-			disp.Println("\nNot currently in a sesh-managed project directory.")
-			return nil
-		}
-		disp.Println("Not currently in a sesh-managed project directory.")
-		disp.Println("Not inside a session.")
-		disp.Println("\nUse 'sesh list' to see all available sessions.")
-		return nil
+		return info, nil
 	}
-
-	// Display project information
-	disp.Printf("Project: %s\n", proj.Name)
-	disp.Printf("Remote: %s\n", proj.RemoteURL)
+	info.Project = proj.Name
+	info.RemoteURL = proj.RemoteURL
 
 	// Get current branch
 	gitRoot, err := project.FindGitRoot(cwd)
 	if err == nil {
 		branch, err := git.GetCurrentBranch(gitRoot)
 		if err == nil {
-			disp.Printf("Branch: %s\n", branch)
+			info.Branch = branch
 		}
 
 		// Get worktree info from filesystem state
 		worktree, err := state.GetWorktree(proj, branch)
 		if err == nil {
-			disp.Printf("Worktree: %s\n", worktree.Path)
-			disp.Printf("Last Used: %s\n", formatTimeAgo(worktree.LastUsed))
-
-			// Generate session name
-			sessionName := workspace.GenerateSessionName(proj.Name, branch)
-			disp.Printf("Session: %s\n", sessionName)
-
-			// Check if session is running
-			exists, err := sessionMgr.Exists(sessionName)
-			if err == nil {
-				if exists {
-					disp.Printf("Session Status: Running\n")
-				} else {
-					disp.Printf("Session Status: Not Running\n")
-				}
-			}
-
-			if currentSessionName != "" && currentSessionName == sessionName {
-				disp.Printf("(You are currently in this session)\n")
-			}
+			info.WorktreePath = worktree.Path
+			info.LastUsed = &worktree.LastUsed
+			info.SessionName = workspace.GenerateSessionName(proj.Name, branch)
+			info.IsRunning, _ = sessionMgr.Exists(info.SessionName)
 		}
 
 		// Get git status summary
-		disp.Println()
-		gitStatus, err := getGitStatusSummary(gitRoot)
-		if err == nil {
-			disp.Printf("Git Status: %s\n", gitStatus)
+		if gitStatus, err := getGitStatusSummary(gitRoot); err == nil {
+			info.GitStatus = gitStatus
 		}
 	}
 
 	// List other sessions for this project
 	worktrees, err := state.DiscoverWorktrees(proj)
 	if err != nil {
-		return eris.Wrap(err, "failed to discover worktrees")
+		return nil, eris.Wrap(err, "failed to discover worktrees")
+	}
+	for _, wt := range worktrees {
+		// Skip current worktree
+		if gitRoot != "" && wt.Branch == info.Branch {
+			continue
+		}
+
+		sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
+		running, _ := sessionMgr.Exists(sessionName)
+		info.OtherSessions = append(info.OtherSessions, statusSession{
+			SessionName: sessionName,
+			Branch:      wt.Branch,
+			IsRunning:   running,
+			LastUsed:    wt.LastUsed,
+		})
 	}
 
-	if len(worktrees) > 1 {
-		disp.Println("\nOther Sessions:")
-		for _, wt := range worktrees {
-			// Skip current worktree
-			if gitRoot != "" {
-				currentBranch, err := git.GetCurrentBranch(gitRoot)
-				if err == nil && wt.Branch == currentBranch {
-					continue
-				}
-			}
+	return info, nil
+}
 
-			// Generate session name
-			sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
-
-			// Check if session is running
-			status := ""
-			exists, err := sessionMgr.Exists(sessionName)
-			if err == nil {
-				if exists {
-					status = " (running)"
-				} else {
-					status = " (not running)"
-				}
-			}
+// printStatus prints the status for people to read
+func printStatus(disp display.Printer, info *statusInfo) {
+	if info.Project == "" {
+		if info.CurrentSession != "" {
+			disp.Printf("Current Session: %s\n", info.CurrentSession)
+			disp.Println("\nNot currently in a sesh-managed project directory.")
+			return
+		}
+		disp.Println("Not currently in a sesh-managed project directory.")
+		disp.Println("Not inside a session.")
+		disp.Println("\nUse 'sesh list' to see all available sessions.")
+		return
+	}
+
+	// Display project information
+	disp.Printf("Project: %s\n", info.Project)
+	disp.Printf("Remote: %s\n", info.RemoteURL)
+	if info.Branch != "" {
+		disp.Printf("Branch: %s\n", info.Branch)
+	}
 
+	if info.WorktreePath != "" {
+		disp.Printf("Worktree: %s\n", info.WorktreePath)
+		disp.Printf("Last Used: %s\n", formatTimeAgo(*info.LastUsed))
+		disp.Printf("Session: %s\n", info.SessionName)
+		if info.IsRunning {
+			disp.Printf("Session Status: Running\n")
+		} else {
+			disp.Printf("Session Status: Not Running\n")
+		}
+		if info.CurrentSession != "" && info.CurrentSession == info.SessionName {
+			disp.Printf("(You are currently in this session)\n")
+		}
+	}
+
+	if info.GitStatus != "" {
+		disp.Println()
+		disp.Printf("Git Status: %s\n", info.GitStatus)
+	}
+
+	if len(info.OtherSessions) > 0 {
+		disp.Println("\nOther Sessions:")
+		for _, other := range info.OtherSessions {
+			status := " (not running)"
+			if other.IsRunning {
+				status = " (running)"
+			}
 			disp.Printf("  %s%s - last used %s\n",
-				sessionName,
+				other.SessionName,
 				status,
-				formatTimeAgo(wt.LastUsed),
+				formatTimeAgo(other.LastUsed),
 			)
 		}
 	}
+}
 
-	return nil
+// statusFields returns the fields of 'sesh status --plain', named like the JSON ones
+func statusFields(info *statusInfo) [][]string {
+	fields := [][]string{
+		{"current_session", info.CurrentSession},
+		{"project", info.Project},
+		{"remote_url", info.RemoteURL},
+		{"branch", info.Branch},
+		{"worktree_path", info.WorktreePath},
+	}
+	if info.LastUsed != nil {
+		fields = append(fields, []string{"last_used", plainTime(*info.LastUsed)})
+	}
+	fields = append(fields,
+		[]string{"session_name", info.SessionName},
+		[]string{"is_running", strconv.FormatBool(info.IsRunning)},
+		[]string{"git_status", info.GitStatus},
+	)
+	for _, other := range info.OtherSessions {
+		running := "stopped"
+		if other.IsRunning {
+			running = "running"
+		}
+		fields = append(fields, []string{
+			"other_session",
+			other.SessionName,
+			other.Branch,
+			running,
+			plainTime(other.LastUsed),
+		})
+	}
+	return fields
 }
 
 // getGitStatusSummary returns a summary of the git status
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var statuslineMaxAge time.Duration
+
+var statuslineCmd = &cobra.Command{
+	Use:   "statusline [path]",
+	Short: "Print a tmux status line segment with the current branch and running sessions",
+	Long: `Print a compact tmux status line segment with the project and branch of the
+worktree at path (by default the current tmux pane), followed by the number of
+running sesh sessions.
+
+The number of running sessions is cached for --max-age, so tmux can refresh the
+status line often without slowing down.
+
+Add it to ~/.tmux.conf:
+  set -g status-right '#(sesh statusline)'
+
+Passing the pane's path avoids asking tmux for it on every refresh:
+  set -g status-right '#(sesh statusline "#{pane_current_path}")'
+
+With --no-color or NO_COLOR, plain text is printed instead of tmux style markup.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStatusline,
+}
+
+func init() {
+	rootCmd.AddCommand(statuslineCmd)
+	statuslineCmd.Flags().
+		DurationVar(&statuslineMaxAge, "max-age", 10*time.Second, "How long the running session count is cached")
+}
+
+func runStatusline(cmd *cobra.Command, args []string) error {
+	dir := ""
+	if len(args) > 0 {
+		dir = args[0]
+	} else {
+		dir = currentPanePath()
+	}
+
+	plain := colorDisabled()
+	var segments []string
+	if projectName, branch, ok := findCurrentWorktree(dir); ok {
+		name := workspace.GetRepoNameFromProject(projectName)
+		if plain {
+			segments = append(segments, fmt.Sprintf("%s:%s", name, branch))
+		} else {
+			segments = append(segments, fmt.Sprintf("#[fg=cyan]%s#[default]:#[fg=green,bold]%s#[default]", name, branch))
+		}
+	}
+
+	// A status line must never show an error, so the count is just left out
+	if running, err := cachedRunningSessionCount(statuslineMaxAge); err == nil {
+		if plain {
+			segments = append(segments, fmt.Sprintf("● %d", running))
+		} else {
+			segments = append(segments, fmt.Sprintf("#[fg=colour244]● %d#[default]", running))
+		}
+	}
+
+	if len(segments) > 0 {
+		fmt.Println(strings.Join(segments, " "))
+	}
+	return nil
+}
+
+// currentPanePath returns the directory of the active tmux pane, or the working directory
+// outside tmux. tmux runs status line commands from the server's directory, not the pane's.
+func currentPanePath() string {
+	if session.IsInsideTmux() {
+		output, err := exec.Command("tmux", "display-message", "-p", "#{pane_current_path}").Output()
+		if path := strings.TrimSpace(string(output)); err == nil && path != "" {
+			return path
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return cwd
+}
+
+// statuslineCache holds the running session count between status line refreshes
+type statuslineCache struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Running   int       `json:"running"`
+}
+
+// cachedRunningSessionCount returns the number of running sessions of sesh worktrees,
+// counting them again only when the cached count is older than maxAge
+func cachedRunningSessionCount(maxAge time.Duration) (int, error) {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to get cache directory")
+	}
+	path := filepath.Join(cacheDir, "statusline.json")
+
+	if data, err := os.ReadFile(path); err == nil {
+		var cache statuslineCache
+		if err := json.Unmarshal(data, &cache); err == nil && time.Since(cache.UpdatedAt) < maxAge {
+			return cache.Running, nil
+		}
+	}
+
+	running, err := countRunningSessions()
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := json.Marshal(statuslineCache{UpdatedAt: time.Now(), Running: running})
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to encode status line cache")
+	}
+	// Failing to cache only makes the next refresh slower
+	if err := os.MkdirAll(cacheDir, 0o755); err == nil {
+		_ = os.WriteFile(path, data, 0o644)
+	}
+
+	return running, nil
+}
+
+// countRunningSessions counts the running sessions that belong to a worktree in the workspace
+func countRunningSessions() (int, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to load configuration")
+	}
+
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to discover projects")
+	}
+
+	runningSessions, err := state.DiscoverSessions(sessionMgr)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to discover sessions")
+	}
+
+	return len(collectSessionDetails(projects, runningSessions, "", true)), nil
+}
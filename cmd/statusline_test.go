@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedRunningSessionCount(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SESH_WORKSPACE", t.TempDir())
+	t.Setenv("SESH_SESSION_BACKEND", "none")
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		t.Fatalf("UserCacheDir() failed: %v", err)
+	}
+	path := filepath.Join(cacheDir, "sesh", "statusline.json")
+
+	tests := []struct {
+		name      string
+		updatedAt time.Time
+		want      int
+	}{
+		// The cached count is used while it is fresh, even though nothing is running
+		{name: "fresh cache", updatedAt: time.Now(), want: 7},
+		{name: "expired cache", updatedAt: time.Now().Add(-time.Hour), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(statuslineCache{UpdatedAt: tt.updatedAt, Running: 7})
+			if err != nil {
+				t.Fatalf("Marshal() failed: %v", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatalf("MkdirAll() failed: %v", err)
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			got, err := cachedRunningSessionCount(time.Minute)
+			if err != nil {
+				t.Fatalf("cachedRunningSessionCount() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("cachedRunningSessionCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
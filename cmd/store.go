@@ -0,0 +1,9 @@
+package cmd
+
+import (
+	"github.com/benoctopus/sesh/internal/prepare"
+)
+
+// openDB ensures the config directory exists and opens the sesh database
+// It is a variable so tests can substitute a mock db.Store
+var openDB = prepare.OpenDB
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// prepareSubprojectSession ensures a worktree exists for the branch, and a session for one of
+// the sub-projects of its .sesh.yaml, which starts in the sub-project's directory and runs its
+// startup command. The processes and compose services of the project belong to the session of
+// the worktree itself, so they aren't started. Returns the session name, and what to wait for
+// before attaching, like prepareSession.
+func prepareSubprojectSession(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	sessionMgr session.SessionManager,
+	branch, name string,
+	disp display.Printer,
+) (string, *prepare.Startup, error) {
+	worktreePath, err := ensureWorktree(ctx, cfg, proj, branch, disp)
+	if err != nil {
+		return "", nil, err
+	}
+	projectConfig, err := config.LoadProjectConfig(worktreePath)
+	if err != nil {
+		return "", nil, err
+	}
+	sub, err := projectConfig.Subproject(name)
+	if err != nil {
+		return "", nil, err
+	}
+	dir := filepath.Join(worktreePath, sub.Path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", nil, eris.Errorf("sub-project %s has no directory %s in branch %s", name, sub.Path, branch)
+	}
+
+	sessionName := workspace.GenerateSubprojectSessionName(proj.Name, name, sub.SessionName, branch)
+	if isNoneBackend(sessionMgr) {
+		return sessionName, nil, nil
+	}
+
+	// Hold the project lock while checking for and creating the session
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return "", nil, err
+	}
+	defer unlock()
+
+	exists, err := sessionMgr.Exists(sessionName)
+	if err != nil {
+		return "", nil, eris.Wrap(err, "failed to check session existence")
+	}
+	if exists {
+		disp.Printf("%s Session %s already exists\n", disp.SuccessText("✓"), disp.Bold(sessionName))
+		return sessionName, nil, nil
+	}
+
+	preparer := newPreparer(cfg, sessionMgr, disp)
+	shell, err := preparer.DevcontainerShell(ctx, worktreePath)
+	if err != nil {
+		return "", nil, err
+	}
+	disp.Printf(
+		"%s Creating %s session %s for sub-project %s\n",
+		disp.InfoText("✨"),
+		sessionMgr.Name(),
+		disp.Bold(sessionName),
+		disp.Bold(name),
+	)
+	if creator, ok := sessionMgr.(session.OptionsCreator); ok {
+		opts := preparer.SessionOptions(proj.Name, branch, worktreePath, shell)
+		opts.Env["SESH_SUBPROJECT"] = name
+		err = creator.CreateWithOptions(sessionName, dir, opts)
+	} else {
+		err = sessionMgr.Create(sessionName, dir)
+	}
+	recordOperation("create-session", proj.Name, branch, err)
+	if err != nil {
+		return "", nil, eris.Wrap(err, "failed to create session")
+	}
+
+	startupCmd := switchStartupCommand
+	if startupCmd == "" {
+		startupCmd = sub.StartupCommand
+	}
+	if startupCmd == "" {
+		startupCmd = preparer.StartupCommand(worktreePath)
+	}
+	wait := (switchWait || projectConfig.WaitForStartup) && shell == ""
+	ran, notifies := prepare.RunStartupCommand(sessionMgr, sessionName, startupCmd, wait, disp)
+
+	disp.Printf("%s Session %s created successfully\n", disp.SuccessText("✓"), disp.Bold(sessionName))
+	if !ran || !notifies {
+		return sessionName, nil, nil
+	}
+	return sessionName, &prepare.Startup{SessionName: sessionName, Command: true}, nil
+}
+
+// subprojectSessionNames returns the names of the sessions the sub-projects of a worktree
+// have, or would have, sorted
+func subprojectSessionNames(projectName string, wt *models.Worktree) []string {
+	projectConfig, err := config.LoadProjectConfig(wt.Path)
+	if err != nil {
+		slog.Debug("failed to load project config", "path", wt.Path, "error", err)
+		return nil
+	}
+
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(projectConfig.Subprojects)) {
+		sub := projectConfig.Subprojects[name]
+		names = append(names, workspace.GenerateSubprojectSessionName(projectName, name, sub.SessionName, wt.Branch))
+	}
+	return names
+}
+
+// killSubprojectSessions kills the running sessions of the sub-projects of a worktree that is
+// being removed. Failures are only logged, since the worktree goes anyway.
+func killSubprojectSessions(cfg *config.Config, proj *models.Project, wt *models.Worktree, disp display.Printer) {
+	names := subprojectSessionNames(proj.Name, wt)
+	if len(names) == 0 {
+		return
+	}
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		slog.Warn("failed to initialize session manager", "error", err)
+		return
+	}
+
+	for _, sessionName := range names {
+		if exists, err := sessionMgr.Exists(sessionName); err != nil || !exists {
+			continue
+		}
+		disp.Printf("Killing %s session: %s\n", sessionMgr.Name(), sessionName)
+		if err := killSession(sessionMgr, sessionName); err != nil {
+			slog.Warn("failed to kill session", "session", sessionName, "error", err)
+		}
+	}
+}
@@ -1,18 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/benoctopus/sesh/internal/config"
-	"github.com/benoctopus/sesh/internal/db"
 	"github.com/benoctopus/sesh/internal/display"
 	"github.com/benoctopus/sesh/internal/fuzzy"
 	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
 	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/benoctopus/sesh/internal/prepare"
 	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/ready"
 	"github.com/benoctopus/sesh/internal/session"
 	"github.com/benoctopus/sesh/internal/state"
 	"github.com/benoctopus/sesh/internal/tty"
@@ -25,7 +32,16 @@ var (
 	switchProjectName    string
 	switchStartupCommand string
 	switchPR             bool
+	switchIssue          int
 	switchDetach         bool
+	switchReadOnly       bool
+	switchDetachOthers   bool
+	switchNewWindow      bool
+	switchMulti          bool
+	switchNoFetch        bool
+	switchWait           bool
+	switchDevcontainer   bool
+	switchSubproject     string
 )
 
 var switchCmd = &cobra.Command{
@@ -35,6 +51,33 @@ var switchCmd = &cobra.Command{
 	Long: `Switch to a branch or pull request, creating a worktree and session if they don't exist.
 If no branch is specified, an interactive fuzzy finder will show all available branches.
 Use --pr to select from open pull requests instead.
+Use --issue to start working on an issue: the branch is named after the issue's number and
+title following issue_branch_template ({type}/{number}-{title} by default), and created
+when it doesn't exist yet.
+Use --multi to select several branches at once; a worktree and detached session is
+prepared for each of them and the first one is attached.
+
+Remote branches are fetched in the background when the project was last fetched longer
+ago than fetch_if_older_than (15m by default); use --no-fetch to skip the fetch.
+
+Use --read-only to follow a session without typing into it, e.g. while pairing, and
+--detach-others to take a session over from the clients attached to it elsewhere, e.g. on
+another monitor (tmux only). Use --new-window to open the session in a new window of
+terminal_command, e.g. kitty, leaving the current terminal as it is.
+
+Use --wait, or wait_for_startup in the project's .sesh.yaml, to wait for the startup command
+of a new session to finish before attaching, so dependencies are installed by then.
+
+Use --devcontainer, or the devcontainer setting, to open a new session inside the dev
+container of a worktree with a .devcontainer: sesh brings the container up with the
+devcontainer CLI, and the windows of the session open a shell in it.
+
+Use --subproject to open the session of a sub-project of a monorepo, declared under
+subprojects in the project's .sesh.yaml: it starts in the sub-project's directory, runs its
+startup command, and is named after it, e.g. monorepo-api-main, while sharing the worktree.
+
+When no session is attached (--detach, or the "none" session backend), the shell
+function from 'sesh shell-init' changes your shell's directory to the worktree.
 
 The project is automatically detected from the current working directory,
 or can be specified explicitly with the --project flag.
@@ -49,12 +92,24 @@ Examples:
   sesh sw new-feature                                        # Create new branch automatically
   sesh switch                                                # Interactive fuzzy branch selection
   sesh switch --pr                                           # Interactive PR selection
+  sesh switch --issue 452                                    # Branch for issue #452, e.g. feat/452-fix-login
   sesh switch --project myproject feature-bar                # Explicit project
   sesh switch -p git@github.com:user/repo.git main           # Auto-clone and switch
   sesh switch -p https://github.com/user/repo.git feature    # Auto-clone HTTPS URL
   sesh switch -c "direnv allow" feature-baz                  # Run startup command
-  sesh switch -d feature-test                                # Create session without attaching`,
-	RunE: runSwitch,
+  sesh switch --wait -c "npm install" feature-baz            # Attach once the startup command finished
+  sesh switch --devcontainer feature-baz                     # Open the session in the dev container
+  sesh switch --subproject api feature-baz                   # Session of the api sub-project
+  sesh switch -d feature-test                                # Create session without attaching
+  sesh switch --no-attach feature-test                       # cd into the worktree (with 'sesh shell-init')
+  sesh switch --read-only feature-foo                        # Watch a pairing session
+  sesh switch --detach-others feature-foo                    # Detach the session's other clients
+  sesh switch --new-window feature-foo                       # Open the session in a new terminal window
+  sesh switch --multi                                        # Select several branches, attach to the first
+  sesh switch -m review-1 review-2                           # Prepare sessions for several branches
+  sesh switch --no-fetch                                     # Select from branches without fetching`,
+	ValidArgsFunction: completeBranches,
+	RunE:              runSwitch,
 }
 
 func init() {
@@ -65,13 +120,37 @@ func init() {
 		StringVarP(&switchStartupCommand, "command", "c", "", "Command to run after switching to session")
 	switchCmd.Flags().
 		BoolVar(&switchPR, "pr", false, "Select from open pull requests")
+	switchCmd.Flags().
+		IntVar(&switchIssue, "issue", 0, "Switch to the branch of an issue, creating it from the issue title")
 	switchCmd.Flags().
 		BoolVarP(&switchDetach, "detach", "d", false, "Create session without attaching to it")
+	switchCmd.Flags().
+		BoolVar(&switchDetach, "no-attach", false, "Same as --detach")
+	switchCmd.Flags().
+		BoolVarP(&switchReadOnly, "read-only", "r", false, "Attach read-only, without input reaching the session")
+	switchCmd.Flags().
+		BoolVar(&switchDetachOthers, "detach-others", false, "Detach the other clients attached to the session")
+	switchCmd.Flags().
+		BoolVar(&switchNewWindow, "new-window", false, "Open the session in a new window of terminal_command")
+	switchCmd.Flags().
+		BoolVarP(&switchMulti, "multi", "m", false, "Select multiple branches and prepare a session for each")
+	switchCmd.Flags().
+		BoolVar(&switchNoFetch, "no-fetch", false, "Don't fetch remote branches, even when the last fetch is stale")
+	switchCmd.Flags().
+		BoolVar(&switchWait, "wait", false, "Wait for the startup command of a new session to finish before attaching")
+	switchCmd.Flags().
+		BoolVar(&switchDevcontainer, "devcontainer", false, "Open a new session inside the worktree's dev container")
+	switchCmd.Flags().
+		StringVarP(&switchSubproject, "subproject", "s", "", "Open the session of a sub-project of .sesh.yaml")
 }
 
 func runSwitch(cmd *cobra.Command, args []string) error {
 	disp := display.NewStderr()
 
+	if switchDetach && (switchReadOnly || switchDetachOthers || switchNewWindow) {
+		return eris.New("cannot combine --detach with --read-only, --detach-others, or --new-window")
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -94,14 +173,21 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 			return eris.Wrap(err, "failed to generate project name from remote URL")
 		}
 
-		// Check if project already exists
+		// Check if project already exists, holding the project lock so that
+		// concurrent invocations don't clone it twice
+		unlock, err := lockProject(cfg, projectName, disp)
+		if err != nil {
+			return err
+		}
 		existingProject, err := state.GetProject(cfg.WorkspaceDir, projectName)
 		if err != nil || existingProject == nil {
 			// Project doesn't exist, clone it
-			if err := cloneRepository(cfg, remoteURL, projectName); err != nil {
+			if err := cloneRepository(cmd.Context(), cfg, remoteURL, projectName); err != nil {
+				unlock()
 				return eris.Wrap(err, "failed to clone repository")
 			}
 		}
+		unlock()
 
 		// Update switchProjectName to use the generated project name
 		switchProjectName = projectName
@@ -113,39 +199,42 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 		return eris.Wrap(err, "failed to resolve project")
 	}
 
-	var branch string
+	if switchMulti {
+		if switchPR {
+			return eris.New("cannot combine --multi with --pr")
+		}
+		if switchIssue != 0 {
+			return eris.New("cannot combine --multi with --issue")
+		}
+		return runSwitchMulti(cmd, args, cfg, proj, disp)
+	}
 
-	// Handle PR selection if --pr flag is set
-	if switchPR {
-		disp := display.NewStderr()
+	var branch string
 
-		if len(args) > 0 {
-			return eris.New("cannot specify branch name with --pr flag")
+	// Handle issue and PR selection if --issue or --pr is set
+	if switchIssue != 0 {
+		if switchPR {
+			return eris.New("cannot combine --issue with --pr")
 		}
-
-		// Get remote URL
-		remoteURL, err := git.GetRemoteURL(proj.LocalPath)
-		if err != nil {
-			return eris.Wrap(err, "failed to get remote URL")
+		if len(args) > 0 {
+			return eris.New("cannot specify branch name with --issue flag")
 		}
 
-		// Create PR provider
-		provider, err := pr.NewProvider(remoteURL)
+		branch, err = issueBranch(cmd.Context(), cfg, proj, switchIssue, disp)
 		if err != nil {
-			return eris.Wrap(err, "failed to create PR provider")
+			return err
 		}
+	} else if switchPR {
+		disp := display.NewStderr()
 
-		// Check if gh CLI is installed and authenticated (for GitHub)
-		if provider.Name() == "github" {
-			if err := pr.CheckGHCLI(); err != nil {
-				return err
-			}
+		if len(args) > 0 {
+			return eris.New("cannot specify branch name with --pr flag")
 		}
 
-		// List open PRs
-		prs, err := provider.ListOpenPRs(cmd.Context(), proj.LocalPath)
+		// List open PRs, served from the cache when the daemon keeps it fresh
+		prs, err := listOpenPRs(cmd.Context(), proj)
 		if err != nil {
-			return eris.Wrap(err, "failed to list pull requests")
+			return err
 		}
 
 		if len(prs) == 0 {
@@ -186,9 +275,14 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 		}
 
 		// Get the branch for this PR
-		branch, err = provider.GetPRBranch(cmd.Context(), proj.LocalPath, prNum)
-		if err != nil {
-			return eris.Wrap(err, "failed to get PR branch")
+		for _, pullRequest := range prs {
+			if pullRequest.Number == prNum {
+				branch = pullRequest.Branch
+				break
+			}
+		}
+		if branch == "" {
+			return eris.Errorf("pull request #%d not found", prNum)
 		}
 
 		disp.Printf(
@@ -198,46 +292,260 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 			disp.Bold(branch),
 		)
 	} else if len(args) > 0 {
-		branch = args[0]
+		branch, err = resolveRemoteBranch(proj, args[0], disp)
+		if err != nil {
+			return err
+		}
 	} else {
 		// No branch specified
 		if !tty.IsInteractive() {
 			return eris.New("branch argument required in noninteractive mode (usage: sesh switch <branch>)")
 		}
 
-		// Use streaming fuzzy finder in interactive mode
+		branch, err = selectBranch(cmd, cfg, proj)
+		if err != nil {
+			return err
+		}
+	}
+
+	return enterBranch(cmd.Context(), cfg, proj, branch, switchDetach, switchAttachMode(), disp)
+}
+
+// attachMode is how a session is attached to once it is prepared
+type attachMode struct {
+	session.AttachOptions
+	// NewWindow opens the session in a new window of the terminal command, leaving the
+	// current terminal alone
+	NewWindow bool
+}
+
+// switchAttachMode returns how to attach to the session, following the attach flags
+func switchAttachMode() attachMode {
+	return attachMode{
+		AttachOptions: session.AttachOptions{ReadOnly: switchReadOnly, DetachOthers: switchDetachOthers},
+		NewWindow:     switchNewWindow,
+	}
+}
+
+// checkAttachMode fails when the session can't be attached to as asked, before anything is
+// prepared
+func checkAttachMode(cfg *config.Config, sessionMgr session.SessionManager, mode attachMode) error {
+	if mode.AttachOptions != (session.AttachOptions{}) {
+		if _, ok := sessionMgr.(session.OptionsAttacher); !ok {
+			return eris.Errorf("the %s session backend doesn't support --read-only or --detach-others", sessionMgr.Name())
+		}
+		// Switching clients inside the session backend can't attach read-only, a new window can
+		if mode.ReadOnly && !mode.NewWindow && sessionMgr.IsInsideSession() {
+			return eris.New("--read-only needs a new client: run it outside of the session, or with --new-window")
+		}
+	}
+	if mode.NewWindow {
+		if _, ok := sessionMgr.(session.AttachCommander); !ok {
+			return eris.Errorf("the %s session backend can't open sessions in a new window", sessionMgr.Name())
+		}
+		if cfg.TerminalCommand == "" {
+			return eris.Errorf(
+				"no terminal_command set: set it to one of %s, or a command like \"foot {command}\"",
+				strings.Join(session.TerminalNames, ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// attachSession attaches to a session in the current terminal, or opens it in a new window
+func attachSession(
+	cfg *config.Config,
+	sessionMgr session.SessionManager,
+	name string,
+	mode attachMode,
+	disp display.Printer,
+) error {
+	if commander, ok := sessionMgr.(session.AttachCommander); ok && mode.NewWindow {
+		if err := session.OpenInTerminal(cfg.TerminalCommand, commander.AttachCommand(name, mode.AttachOptions)); err != nil {
+			return err
+		}
+		disp.Printf("%s Opened session %s in a new window\n", disp.SuccessText("✓"), disp.Bold(name))
+		return nil
+	}
+	if attacher, ok := sessionMgr.(session.OptionsAttacher); ok && mode.AttachOptions != (session.AttachOptions{}) {
+		return attacher.AttachWithOptions(name, mode.AttachOptions)
+	}
+	return sessionMgr.Attach(name)
+}
+
+// enterBranch prepares the worktree and session of a branch and attaches to the session.
+// When detached, or without sessions, the shell integration changes into the worktree instead.
+func enterBranch(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	branch string,
+	detach bool,
+	attach attachMode,
+	disp display.Printer,
+) error {
+	// Initialize session manager
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+	if err := checkAttachMode(cfg, sessionMgr, attach); err != nil {
+		return err
+	}
+
+	_ = cleanOrphanedSessions(proj, sessionMgr, disp)
+
+	sessionName, pending, err := prepareSession(ctx, cfg, proj, sessionMgr, branch, disp)
+	if err != nil {
+		return err
+	}
+	if err := prepare.WaitForStartup(ctx, sessionMgr, pending, disp); err != nil {
+		return err
+	}
+
+	// Record session history before attaching
+	recordSessionHistory(sessionName, proj.Name, branch)
+
+	if cfg.Tmux.WindowTitles {
+		setSessionTitle(sessionMgr, sessionName, proj.Name, branch)
+	}
+
+	// Without a session to attach to, the shell integration changes into the worktree instead
+	if detach || isNoneBackend(sessionMgr) {
+		return changeShellDir(proj, sessionMgr, branch, disp)
+	}
+
+	// In noninteractive mode, don't attach; a new window doesn't need the current terminal
+	if !attach.NewWindow && !tty.IsInteractive() {
+		return nil
+	}
+
+	// Attach to session
+	if !attach.NewWindow {
+		disp.Printf("\n%s Attaching to session...\n", disp.InfoText("→"))
+	}
+	return attachSession(cfg, sessionMgr, sessionName, attach, disp)
+}
+
+// setSessionTitle titles the window of a session "<repo>:<branch>", for backends that support
+// it. Titles are best effort, since they only show where the session is.
+func setSessionTitle(sessionMgr session.SessionManager, sessionName, projectName, branch string) {
+	titler, ok := sessionMgr.(session.Titler)
+	if !ok {
+		return
+	}
+	title := workspace.GetRepoNameFromProject(projectName) + ":" + branch
+	if err := titler.SetTitle(sessionName, title); err != nil {
+		slog.Warn("failed to set session title", "session", sessionName, "error", err)
+	}
+}
+
+// resolveRemoteBranch turns a branch of a named remote, like "upstream/main", into the local
+// branch of the same name, creating it to track the remote branch when it doesn't exist yet.
+// Other names are returned unchanged.
+func resolveRemoteBranch(proj *models.Project, name string, disp display.Printer) (string, error) {
+	if exists, _, err := git.DoesBranchExist(proj.LocalPath, name); err != nil || exists {
+		return name, err
+	}
+	remote, branch, ok := git.SplitRemoteBranch(proj.LocalPath, name)
+	if !ok {
+		return name, nil
+	}
+
+	exists, _, err := git.DoesBranchExist(proj.LocalPath, branch)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		// Like git switch, the local branch is used when there is one
+		if upstream := git.GetUpstreamRemote(proj.LocalPath, branch); upstream != "" && upstream != remote {
+			disp.Warningf("using the local branch %s, which tracks %s/%s rather than %s", branch, upstream, branch, name)
+		}
+		return branch, nil
+	}
+
+	disp.Printf("%s Creating branch %s from %s\n", disp.InfoText("→"), disp.Bold(branch), name)
+	if err := git.CreateTrackingBranch(proj.LocalPath, remote, branch); err != nil {
+		return "", err
+	}
+	return branch, nil
+}
+
+// selectBranch selects a branch of the project with the streaming fuzzy finder
+// Remote branches are fetched in the background while the finder is open.
+func selectBranch(cmd *cobra.Command, cfg *config.Config, proj *models.Project) (string, error) {
+	// Start git fetch in background - don't wait for it
+	if !switchNoFetch {
+		fetchInBackground(cmd.Context(), cfg, proj)
+	}
+
+	// Stream branches directly from git to fzf for instant UI
+	branchReader, err := streamBranches(cmd.Context(), cfg, proj)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to start branch listing")
+	}
+
+	// Get binary path for preview command
+	bin, err := os.Executable()
+	if err != nil {
+		// Fallback to simple selection without preview if we can't get binary path
+		selectedBranch, err := fuzzy.SelectBranchFromReader(branchReader)
+		if err != nil {
+			return "", eris.Wrap(err, "failed to select branch")
+		}
+		return selectedBranch, nil
+	}
+
+	// Use preview command with absolute binary path
+	// Pass the project name and branch to the info command
+	// The info command will generate the proper session name internally
+	previewCmd := fmt.Sprintf("%s info --project %s {}", bin, proj.Name)
+	selectedBranch, err := fuzzy.SelectBranchFromReaderWithPreview(branchReader, previewCmd)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to select branch")
+	}
+	return selectedBranch, nil
+}
+
+// runSwitchMulti prepares worktrees and detached sessions for several branches at once
+// and attaches to the first one. Branches come from the arguments, or from a multi-select
+// fuzzy finder when no arguments are given.
+func runSwitchMulti(
+	cmd *cobra.Command,
+	args []string,
+	cfg *config.Config,
+	proj *models.Project,
+	disp display.Printer,
+) error {
+	branches := args
+	if len(branches) == 0 {
+		if !tty.IsInteractive() {
+			return eris.New("branch arguments required in noninteractive mode (usage: sesh switch --multi <branch>...)")
+		}
+
 		// Start git fetch in background - don't wait for it
-		go func() {
-			if err := git.Fetch(proj.LocalPath); err != nil {
-				fmt.Fprintf(os.Stderr, "warning: git fetch failed: %s\n", eris.ToString(err, true))
-			}
-		}()
+		if !switchNoFetch {
+			fetchInBackground(cmd.Context(), cfg, proj)
+		}
 
-		// Stream branches directly from git to fzf for instant UI
-		branchReader, err := git.StreamRemoteBranches(cmd.Context(), proj.LocalPath)
+		branchReader, err := streamBranches(cmd.Context(), cfg, proj)
 		if err != nil {
 			return eris.Wrap(err, "failed to start branch listing")
 		}
 
-		// Get binary path for preview command
-		bin, err := os.Executable()
+		previewCmd := ""
+		if bin, err := os.Executable(); err == nil {
+			previewCmd = fmt.Sprintf("%s info --project %s {}", bin, proj.Name)
+		}
+
+		branches, err = fuzzy.MultiSelectFromReaderWithPreview(branchReader, "Select branches> ", previewCmd)
 		if err != nil {
-			// Fallback to simple selection without preview if we can't get binary path
-			selectedBranch, err := fuzzy.SelectBranchFromReader(branchReader)
-			if err != nil {
-				return eris.Wrap(err, "failed to select branch")
-			}
-			branch = selectedBranch
-		} else {
-			// Use preview command with absolute binary path
-			// Pass the project name and branch to the info command
-			// The info command will generate the proper session name internally
-			previewCmd := fmt.Sprintf("%s info --project %s {}", bin, proj.Name)
-			selectedBranch, err := fuzzy.SelectBranchFromReaderWithPreview(branchReader, previewCmd)
-			if err != nil {
-				return eris.Wrap(err, "failed to select branch")
+			if strings.Contains(err.Error(), "cancelled") {
+				disp.Println("Switch cancelled.")
+				return nil
 			}
-			branch = selectedBranch
+			return eris.Wrap(err, "failed to select branches")
 		}
 	}
 
@@ -246,218 +554,328 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return eris.Wrap(err, "failed to initialize session manager")
 	}
+	if err := checkAttachMode(cfg, sessionMgr, switchAttachMode()); err != nil {
+		return err
+	}
 
 	_ = cleanOrphanedSessions(proj, sessionMgr, disp)
 
+	prepared := prepareSessions(branches, func(branch string) (string, *prepare.Startup, error) {
+		return prepareSession(cmd.Context(), cfg, proj, sessionMgr, branch, disp)
+	}, disp)
+
+	// The startup commands run meanwhile, so waiting for them one by one takes the longest one
+	waitForStartups(cmd.Context(), sessionMgr, prepared, disp)
+
+	if len(prepared) == 0 {
+		return eris.New("no sessions could be prepared")
+	}
+
+	disp.Printf(
+		"%s Prepared %d of %d session(s)\n",
+		disp.SuccessText("✓"),
+		len(prepared),
+		len(branches),
+	)
+
+	// Record history in reverse so the first selection is the most recent entry
+	for _, p := range slices.Backward(prepared) {
+		recordSessionHistory(p.sessionName, proj.Name, p.branch)
+		if cfg.Tmux.WindowTitles {
+			setSessionTitle(sessionMgr, p.sessionName, proj.Name, p.branch)
+		}
+	}
+
+	if switchDetach || isNoneBackend(sessionMgr) {
+		return changeShellDir(proj, sessionMgr, prepared[0].branch, disp)
+	}
+
+	// In noninteractive mode, don't attach; a new window doesn't need the current terminal
+	mode := switchAttachMode()
+	if !mode.NewWindow && !tty.IsInteractive() {
+		return nil
+	}
+
+	if !mode.NewWindow {
+		disp.Printf("\n%s Attaching to session %s...\n", disp.InfoText("→"), disp.Bold(prepared[0].sessionName))
+	}
+	return attachSession(cfg, sessionMgr, prepared[0].sessionName, mode, disp)
+}
+
+// preparedSession is a session 'sesh switch --multi' prepared, with the branch it belongs to
+type preparedSession struct {
+	sessionName string
+	branch      string
+	pending     *prepare.Startup
+}
+
+// prepareSessions prepares the session of each branch, in order. Branches that fail are only
+// warned about and left out, so the others still get their sessions.
+func prepareSessions(
+	branches []string,
+	prepareBranch func(branch string) (string, *prepare.Startup, error),
+	disp display.Printer,
+) []preparedSession {
+	var prepared []preparedSession
+	for _, branch := range branches {
+		sessionName, pending, err := prepareBranch(branch)
+		if err != nil {
+			slog.Warn("failed to prepare session", "branch", branch, "error", err)
+			continue
+		}
+		prepared = append(prepared, preparedSession{sessionName: sessionName, branch: branch, pending: pending})
+		disp.Println()
+	}
+	return prepared
+}
+
+// waitForStartups waits for the startup of each prepared session. Sessions that fail to start
+// are only warned about, like in prepareSessions, since the others are usable anyway.
+func waitForStartups(
+	ctx context.Context,
+	sessionMgr session.SessionManager,
+	prepared []preparedSession,
+	disp display.Printer,
+) {
+	for _, p := range prepared {
+		if err := prepare.WaitForStartup(ctx, sessionMgr, p.pending, disp); err != nil {
+			slog.Warn("failed to wait for session startup", "session", p.sessionName, "error", err)
+		}
+	}
+}
+
+// prepareSession ensures a worktree and a session exist for the branch without attaching.
+// The worktree is created from the local branch, the remote branch, or as a new branch from HEAD,
+// and the startup command is run when a new session is created. No session is created with the
+// "none" backend. Returns the session name, and what to wait for with prepare.WaitForStartup before
+// attaching, which happens without holding the project lock.
+func prepareSession(
+	ctx context.Context,
+	cfg *config.Config,
+	proj *models.Project,
+	sessionMgr session.SessionManager,
+	branch string,
+	disp display.Printer,
+) (string, *prepare.Startup, error) {
+	if switchSubproject != "" {
+		return prepareSubprojectSession(ctx, cfg, proj, sessionMgr, branch, switchSubproject, disp)
+	}
+	sessionName := workspace.GenerateSessionName(proj.Name, branch)
+
+	// Hold the project lock while checking for and creating the worktree and session
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return "", nil, err
+	}
+	defer unlock()
+	preparer := newPreparer(cfg, sessionMgr, disp)
+
 	// Check if worktree already exists in filesystem
 	existingWorktree, err := state.GetWorktree(proj, branch)
 	if err == nil && existingWorktree != nil {
-		// Worktree exists, attach to existing or create new session
+		offerBranchRename(cfg, proj, existingWorktree, sessionMgr, disp)
 		disp.Printf(
 			"%s %s\n",
 			disp.InfoText("→"),
 			disp.Bold(fmt.Sprintf("Switching to existing worktree: %s", existingWorktree.Path)),
 		)
 
-		// Generate session name
-		sessionName := workspace.GenerateSessionName(proj.Name, branch)
+		prepare.ZoxideAdd(cfg, existingWorktree.Path, disp)
+		fastForwardDefault(ctx, cfg, proj, branch, existingWorktree.Path, disp)
+
+		if isNoneBackend(sessionMgr) {
+			return sessionName, nil, nil
+		}
 
 		// Check if session is running
 		exists, err := sessionMgr.Exists(sessionName)
 		if err != nil {
-			return eris.Wrap(err, "failed to check session existence")
+			return "", nil, eris.Wrap(err, "failed to check session existence")
 		}
 
 		if exists {
-			// Record session history before attaching
-			recordSessionHistory(sessionName, proj.Name, branch)
-
-			// In noninteractive mode or detached mode, don't attach
-			if !tty.IsInteractive() || switchDetach {
-				disp.Printf(
-					"%s Session %s already exists\n",
-					disp.SuccessText("✓"),
-					disp.Bold(sessionName),
-				)
-				return nil
-			}
-
-			// Attach to existing session
-			return sessionMgr.Attach(sessionName)
-		}
-
-		// Session doesn't exist, create it
-		disp.Printf(
-			"%s Creating %s session %s\n",
-			disp.InfoText("✨"),
-			sessionMgr.Name(),
-			disp.Bold(sessionName),
-		)
-		if err := sessionMgr.Create(sessionName, existingWorktree.Path); err != nil {
-			return eris.Wrap(err, "failed to create session")
-		}
-
-		// Execute startup command if configured
-		startupCmd := getStartupCommand(cfg, existingWorktree.Path)
-		if startupCmd != "" && sessionMgr.Name() == "tmux" {
-			disp.Printf(
-				"%s Running startup command: %s\n",
-				disp.InfoText("⚙"),
-				disp.Faint(startupCmd),
-			)
-			if tmuxMgr, ok := sessionMgr.(*session.TmuxManager); ok {
-				if err := tmuxMgr.SendKeys(sessionName, startupCmd); err != nil {
-					fmt.Fprintf(os.Stderr, "Warning: failed to run startup command: %v\n", err)
-				}
-			}
-		}
-
-		// Record session history before attaching
-		recordSessionHistory(sessionName, proj.Name, branch)
-
-		// In noninteractive mode or detached mode, don't attach
-		if !tty.IsInteractive() || switchDetach {
 			disp.Printf(
-				"%s Session %s created successfully\n",
+				"%s Session %s already exists\n",
 				disp.SuccessText("✓"),
 				disp.Bold(sessionName),
 			)
-			return nil
+			return sessionName, nil, nil
 		}
 
-		return sessionMgr.Attach(sessionName)
+		pending, err := preparer.CreateSession(ctx, proj.Name, branch, sessionName, existingWorktree.Path)
+		if err != nil {
+			return "", nil, err
+		}
+
+		disp.Printf(
+			"%s Session %s created successfully\n",
+			disp.SuccessText("✓"),
+			disp.Bold(sessionName),
+		)
+		return sessionName, pending, nil
 	}
 
-	// Worktree doesn't exist, check branch existence
-	exists, _, err := git.DoesBranchExist(proj.LocalPath, branch)
+	worktreePath, err := preparer.CreateWorktree(ctx, proj, branch)
 	if err != nil {
-		return eris.Wrap(err, "failed to check branch existence")
+		return "", nil, err
 	}
+	fastForwardDefault(ctx, cfg, proj, branch, worktreePath, disp)
 
-	// Get worktree path
-	worktreeBasePath := workspace.GetWorktreeBasePath(cfg.WorkspaceDir, proj.Name)
-	worktreePath := workspace.GetWorktreePath(worktreeBasePath, branch)
-
-	// Create worktree based on branch state
-	if exists {
-		// Branch exists locally, create worktree from it
-		// In bare repos (which sesh uses), this automatically sets up tracking to origin
-		disp.Printf("%s Creating worktree for branch: %s\n", disp.InfoText("✨"), disp.Bold(branch))
-		if err := git.CreateWorktree(proj.LocalPath, branch, worktreePath); err != nil {
-			return eris.Wrap(err, "failed to create worktree from branch")
-		}
-	} else {
-		// Branch doesn't exist locally, check if it exists on the remote
-		existsRemotely, err := git.DoesBranchExistRemotely(proj.LocalPath, branch)
-		if err != nil {
-			return eris.Wrap(err, "failed to check remote branch existence")
-		}
-
-		if existsRemotely {
-			// Branch exists on remote, create worktree from remote branch
-			disp.Printf("%s Creating worktree from remote branch: %s\n", disp.InfoText("✨"), disp.Bold(branch))
-			if err := git.CreateWorktreeFromRemoteBranch(proj.LocalPath, branch, worktreePath); err != nil {
-				return eris.Wrap(err, "failed to create worktree from remote branch")
-			}
-		} else {
-			// Branch doesn't exist anywhere, create new branch and worktree from HEAD
-			disp.Printf("%s Creating new branch and worktree: %s\n", disp.SuccessText("✨"), disp.Bold(branch))
-			if err := git.CreateWorktreeNewBranch(proj.LocalPath, branch, worktreePath, "HEAD"); err != nil {
-				return eris.Wrap(err, "failed to create worktree with new branch")
-			}
-		}
+	if isNoneBackend(sessionMgr) {
+		disp.Printf("\n%s Successfully created worktree for %s\n", disp.SuccessText("✓"), disp.Bold(branch))
+		return sessionName, nil, nil
 	}
 
-	// Create session
-	sessionName := workspace.GenerateSessionName(proj.Name, branch)
-	disp.Printf(
-		"%s Creating %s session %s\n",
-		disp.InfoText("✨"),
-		sessionMgr.Name(),
-		disp.Bold(sessionName),
-	)
-	if err := sessionMgr.Create(sessionName, worktreePath); err != nil {
-		return eris.Wrap(err, "failed to create session")
+	pending, err := preparer.CreateSession(ctx, proj.Name, branch, sessionName, worktreePath)
+	if err != nil {
+		return "", nil, err
 	}
 
 	disp.Printf("\n%s Successfully switched to %s\n", disp.SuccessText("✓"), disp.Bold(branch))
 	disp.Printf("  %s %s\n", disp.Faint("Worktree:"), worktreePath)
 	disp.Printf("  %s %s\n", disp.Faint("Session:"), sessionName)
 
-	// Execute startup command if configured
-	startupCmd := getStartupCommand(cfg, worktreePath)
-	if startupCmd != "" && sessionMgr.Name() == "tmux" {
-		disp.Printf("%s Running startup command: %s\n", disp.InfoText("⚙"), disp.Faint(startupCmd))
-		if tmuxMgr, ok := sessionMgr.(*session.TmuxManager); ok {
-			if err := tmuxMgr.SendKeys(sessionName, startupCmd); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to run startup command: %v\n", err)
-			}
-		}
+	return sessionName, pending, nil
+}
+
+// ensureWorktree returns the worktree path of a branch, creating the worktree if needed
+// Unlike prepareSession, no session is created.
+func ensureWorktree(ctx context.Context, cfg *config.Config, proj *models.Project, branch string, disp display.Printer) (string, error) {
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return "", err
 	}
+	defer unlock()
 
-	// Record session history before attaching
-	recordSessionHistory(sessionName, proj.Name, branch)
+	worktreePath, _, err := newPreparer(cfg, nil, disp).EnsureWorktree(ctx, proj, branch)
+	return worktreePath, err
+}
 
-	// In noninteractive mode or detached mode, don't attach
-	if !tty.IsInteractive() || switchDetach {
-		return nil
-	}
+// newPreparer returns what creates worktrees and sessions, with the flags of 'sesh switch'
+// The session manager isn't needed to only create worktrees.
+func newPreparer(cfg *config.Config, sessionMgr session.SessionManager, disp display.Printer) *prepare.Preparer {
+	return prepare.New(prepare.Options{
+		Config:         cfg,
+		Sessions:       sessionMgr,
+		Display:        disp,
+		Progress:       os.Stderr,
+		OpenDB:         openDB,
+		Devcontainer:   switchDevcontainer,
+		StartupCommand: switchStartupCommand,
+		Wait:           switchWait,
+	})
+}
 
-	// Attach to session
-	disp.Printf("\n%s Attaching to session...\n", disp.InfoText("→"))
-	return sessionMgr.Attach(sessionName)
+// isNoneBackend reports whether sessions are disabled, so switching only prepares the worktree
+func isNoneBackend(sessionMgr session.SessionManager) bool {
+	return sessionMgr.Name() == string(session.BackendNone)
 }
 
-// recordSessionHistory records the session access in the database for session history (pop command)
-// This is a best-effort operation - errors are logged but don't fail the command
-func recordSessionHistory(sessionName, projectName, branch string) {
-	// Get database path
-	dbPath, err := config.GetDBPath()
+// changeShellDir asks the shell integration to change into the worktree of a branch
+// Without the shell integration, the worktree is only shown when there is no session either.
+func changeShellDir(
+	proj *models.Project,
+	sessionMgr session.SessionManager,
+	branch string,
+	disp display.Printer,
+) error {
+	wt, err := state.GetWorktree(proj, branch)
 	if err != nil {
-		// Silently fail - session history is not critical
-		return
+		return eris.Wrap(err, "failed to find worktree")
 	}
 
-	// Ensure config directory exists (for database file)
-	if err := config.EnsureConfigDir(); err != nil {
-		return
+	integrated, err := requestShellCD(wt.Path)
+	if err != nil || integrated || !isNoneBackend(sessionMgr) {
+		return err
+	}
+
+	disp.Printf("%s Worktree: %s\n", disp.InfoText("→"), wt.Path)
+	disp.Printf("  %s\n", disp.Faint("Set up 'sesh shell-init' to cd into it automatically"))
+	return nil
+}
+
+// sharedSessionPrefixes returns the session prefixes of the other projects with the same
+// repository name as a project, to tell with warnRenamedSessions whether adding or removing it
+// renamed their sessions. The prefixes are kept in the database, so a project being added can't
+// take them over, even when it looks older, like an imported repository.
+func sharedSessionPrefixes(cfg *config.Config, projectName string) map[string]string {
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		slog.Debug("failed to discover projects", "error", err)
+		return nil
+	}
+	prefixes := make(map[string]string)
+	for _, proj := range projects {
+		if proj.Name != projectName && filepath.Base(proj.Name) == filepath.Base(projectName) {
+			prefixes[proj.Name] = workspace.SessionPrefix(proj.Name)
+		}
+	}
+	if len(prefixes) == 0 {
+		return prefixes
 	}
 
-	// Initialize database
-	database, err := db.InitDB(dbPath)
+	database, err := openDB()
 	if err != nil {
-		return
+		slog.Debug("failed to open database", "error", err)
+		return prefixes
 	}
+	//nolint:errcheck // Best-effort close
 	defer database.Close()
+	for name, prefix := range prefixes {
+		if err := database.SetSessionPrefix(name, prefix); err != nil {
+			slog.Debug("failed to keep session prefix", "project", name, "error", err)
+		}
+	}
+	return prefixes
+}
 
-	// Add session to history
-	_ = db.AddSessionHistory(database, sessionName, projectName, branch)
+// warnRenamedSessions warns about the projects whose session prefix is no longer the one taken by
+// sharedSessionPrefixes, once the projects were rediscovered. Their running sessions keep the old
+// names, so sesh no longer finds them.
+func warnRenamedSessions(before map[string]string) {
+	for _, projectName := range slices.Sorted(maps.Keys(before)) {
+		if prefix := workspace.SessionPrefix(projectName); prefix != before[projectName] {
+			slog.Warn(
+				"the session names of a project with the same repository name changed; "+
+					"its running sessions keep the old names",
+				"project", projectName,
+				"was", before[projectName]+"-<branch>",
+				"now", prefix+"-<branch>",
+			)
+		}
+	}
 }
 
-// getStartupCommand returns the startup command following the priority hierarchy:
-// 1. Command-line flag (highest priority)
-// 2. Per-project config (.sesh.yaml in worktree)
-// 3. Global config
-// 4. Empty string (no command)
-func getStartupCommand(cfg *config.Config, worktreePath string) string {
-	// 1. Check command-line flag
-	if switchStartupCommand != "" {
-		return switchStartupCommand
+// probeReady reports what the ready_check of a worktree probes, e.g. "localhost:3000", and
+// whether the services of its running session answer it. The check is empty without one.
+func probeReady(ctx context.Context, worktreePath string) (check string, isReady bool) {
+	projectConfig, err := config.LoadProjectConfig(worktreePath)
+	if err != nil || projectConfig.ReadyCheck == nil {
+		return "", false
 	}
+	return projectConfig.ReadyCheck.String(), ready.Probe(ctx, projectConfig.ReadyCheck) == nil
+}
 
-	// 2. Check per-project config
-	startupCmd, err := config.GetStartupCommand(worktreePath)
-	if err == nil && startupCmd != "" {
-		return startupCmd
+// recordSessionHistory records the session access in the database for session history (pop command)
+// This is a best-effort operation - errors are logged but don't fail the command
+func recordSessionHistory(sessionName, projectName, branch string) {
+	// Session history is not critical, so failures are only logged
+	database, err := openDB()
+	if err != nil {
+		slog.Debug("failed to open database for session history", "error", err)
+		return
 	}
+	defer database.Close()
 
-	// 3. Return global config (already loaded in cfg)
-	return cfg.StartupCommand
+	// Add session to history
+	if err := database.AddSessionHistory(sessionName, projectName, branch); err != nil {
+		slog.Debug("failed to record session history", "session", sessionName, "error", err)
+	}
 }
 
 // cloneRepository clones a repository into the workspace
 // This is used when auto-cloning a repository specified by git URL
-func cloneRepository(cfg *config.Config, remoteURL, projectName string) error {
+func cloneRepository(ctx context.Context, cfg *config.Config, remoteURL, projectName string) error {
 	disp := display.NewStderr()
 
 	// Ensure workspace directory exists
@@ -467,12 +885,23 @@ func cloneRepository(cfg *config.Config, remoteURL, projectName string) error {
 
 	// Get paths for bare repo and worktrees
 	bareRepoPath := workspace.GetBareRepoPath(cfg.WorkspaceDir, projectName)
-	worktreeBasePath := workspace.GetWorktreeBasePath(cfg.WorkspaceDir, projectName)
 
 	// Clone repository as bare repo
 	disp.Printf("%s Cloning %s\n", disp.InfoText("⬇"), disp.Bold(remoteURL))
 	disp.Printf("  %s %s\n", disp.Faint("→"), bareRepoPath)
-	if err := git.Clone(remoteURL, bareRepoPath); err != nil {
+	defaults := cfg.Clone.For(projectName)
+	cloneOpts := git.CloneOptions{Depth: defaults.Depth, Filter: defaults.Filter, Dissociate: true}
+	if dirs, err := cfg.Clone.ReferencePaths(); err == nil {
+		cloneOpts.Reference = git.FindReference(dirs, projectName)
+	}
+	if cloneOpts.Reference != "" {
+		disp.Printf("  %s %s\n", disp.Faint("Reusing objects of"), cloneOpts.Reference)
+	}
+	progress := display.NewProgress(os.Stderr, "Cloning")
+	cloneOpts.Progress = progress.Update
+	err := git.Clone(ctx, remoteURL, bareRepoPath, cloneOpts)
+	progress.Stop()
+	if err != nil {
 		return eris.Wrap(err, "failed to clone repository")
 	}
 
@@ -483,17 +912,40 @@ func cloneRepository(cfg *config.Config, remoteURL, projectName string) error {
 	}
 
 	// Create main worktree
-	worktreePath := workspace.GetWorktreePath(worktreeBasePath, defaultBranch)
+	worktreePath := workspace.ProjectWorktreePath(cfg.WorkspaceDir, projectName, defaultBranch)
 	disp.Printf(
 		"%s Creating worktree for branch %s\n",
 		disp.InfoText("✨"),
 		disp.Bold(defaultBranch),
 	)
-	if err := git.CreateWorktree(bareRepoPath, defaultBranch, worktreePath); err != nil {
+	// A new clone only has origin
+	if err := git.CreateWorktree(ctx, bareRepoPath, config.DefaultRemote, defaultBranch, worktreePath); err != nil {
 		return eris.Wrap(err, "failed to create worktree")
 	}
+	prepare.UpdateSubmodules(ctx, worktreePath, disp)
+	prepare.PullLFS(ctx, worktreePath, disp)
+	prepare.ZoxideAdd(cfg, worktreePath, disp)
 
 	disp.Printf("%s Successfully cloned %s\n", disp.SuccessText("✓"), disp.Bold(projectName))
 
 	return nil
 }
+
+// fetchInBackground starts fetching a project without waiting for it, so branches can be listed
+// immediately. The fetch is skipped offline, and when the project was fetched recently enough
+// under the auto-fetch policy.
+func fetchInBackground(ctx context.Context, cfg *config.Config, proj *models.Project) {
+	if !needsFetch(cfg, proj) {
+		slog.Debug("skipping fetch, the project was fetched recently or sesh is offline", "project", proj.Name)
+		return
+	}
+
+	slog.Debug("fetching in background", "project", proj.Name)
+	go func() {
+		if err := git.Fetch(ctx, proj.LocalPath); err != nil {
+			slog.Warn("background fetch failed", "project", proj.Name, "error", err)
+			return
+		}
+		markProjectFetched(proj)
+	}()
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/session"
+)
+
+func TestPrepareSessions(t *testing.T) {
+	prepareBranch := func(branch string) (string, *prepare.Startup, error) {
+		if branch == "broken" {
+			return "", nil, errors.New("checkout failed")
+		}
+		return "repo-" + branch, nil, nil
+	}
+
+	prepared := prepareSessions([]string{"first", "broken", "last"}, prepareBranch, display.New(io.Discard))
+
+	want := []preparedSession{
+		{sessionName: "repo-first", branch: "first"},
+		{sessionName: "repo-last", branch: "last"},
+	}
+	if !slices.Equal(prepared, want) {
+		t.Errorf("prepareSessions() = %+v, want %+v", prepared, want)
+	}
+}
+
+func TestCheckAttachMode(t *testing.T) {
+	cfg := &config.Config{TerminalCommand: "foot"}
+	tmux := session.NewTmuxManager()
+	readOnly := session.AttachOptions{ReadOnly: true}
+
+	tests := []struct {
+		name       string
+		sessionMgr session.SessionManager
+		insideTmux bool
+		mode       attachMode
+		wantErr    bool
+	}{
+		{name: "plain attach", sessionMgr: &fakeSessionManager{}, mode: attachMode{}},
+		{
+			name:       "options without support",
+			sessionMgr: &fakeSessionManager{},
+			mode:       attachMode{AttachOptions: readOnly},
+			wantErr:    true,
+		},
+		{name: "read-only outside tmux", sessionMgr: tmux, mode: attachMode{AttachOptions: readOnly}},
+		{
+			name:       "read-only inside tmux",
+			sessionMgr: tmux,
+			insideTmux: true,
+			mode:       attachMode{AttachOptions: readOnly},
+			wantErr:    true,
+		},
+		{
+			name:       "read-only inside tmux in a new window",
+			sessionMgr: tmux,
+			insideTmux: true,
+			mode:       attachMode{AttachOptions: readOnly, NewWindow: true},
+		},
+		{
+			name:       "detach others inside tmux",
+			sessionMgr: tmux,
+			insideTmux: true,
+			mode:       attachMode{AttachOptions: session.AttachOptions{DetachOthers: true}},
+		},
+		{
+			name:       "new window without support",
+			sessionMgr: &fakeSessionManager{},
+			mode:       attachMode{NewWindow: true},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.insideTmux {
+				t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+			} else {
+				t.Setenv("TMUX", "")
+			}
+			err := checkAttachMode(cfg, tt.sessionMgr, tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkAttachMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeCommandWaiter records the sessions whose startup command is waited for
+type fakeCommandWaiter struct {
+	fakeSessionManager
+	waited []string
+	// failing is the session whose command can't be waited for
+	failing string
+}
+
+func (f *fakeCommandWaiter) RunCommandNotify(name, command string) error {
+	return nil
+}
+
+func (f *fakeCommandWaiter) WaitForCommand(ctx context.Context, name string) error {
+	f.waited = append(f.waited, name)
+	if name == f.failing {
+		return errors.New("no server running")
+	}
+	return nil
+}
+
+func TestWaitForStartups(t *testing.T) {
+	waiter := &fakeCommandWaiter{failing: "repo-broken"}
+	prepared := []preparedSession{
+		{sessionName: "repo-first", pending: &prepare.Startup{SessionName: "repo-first", Command: true}},
+		{sessionName: "repo-broken", pending: &prepare.Startup{SessionName: "repo-broken", Command: true}},
+		{sessionName: "repo-idle"},
+		{sessionName: "repo-last", pending: &prepare.Startup{SessionName: "repo-last", Command: true}},
+	}
+
+	// A session that fails to start doesn't keep the ones after it from being waited for
+	waitForStartups(t.Context(), waiter, prepared, display.New(io.Discard))
+	if want := []string{"repo-first", "repo-broken", "repo-last"}; !slices.Equal(waiter.waited, want) {
+		t.Errorf("waited for %v, want %v", waiter.waited, want)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/benoctopus/sesh/internal/config"
 	"github.com/benoctopus/sesh/internal/display"
 	"github.com/rotisserie/eris"
 	"github.com/spf13/cobra"
@@ -28,7 +29,8 @@ var tmuxKeybindingsCmd = &cobra.Command{
 	Long: `Display recommended tmux keybindings for sesh integration.
 
 These keybindings can be manually copied to your ~/.tmux.conf or
-automatically installed using 'sesh tmux install'.`,
+automatically installed using 'sesh tmux install'. The keys and popup size
+follow the tmux section of the config file, and the flags.`,
 	RunE: runTmuxKeybindings,
 }
 
@@ -45,62 +47,221 @@ This command will:
 
 The installed keybindings include:
   - prefix + f: Fuzzy session switcher with preview
+  - prefix + F: Fuzzy pull request switcher with preview
   - prefix + L: Switch to last/previous session
 
+The keys and popup size are set in the tmux section of the config file
+(switcher_key, pr_key, last_key, popup_width, and popup_height), or with the
+flags, which win. Installing again replaces the keybindings installed before.
+
 Examples:
-  sesh tmux install        # Install keybindings
-  sesh tmux keybindings    # Show keybindings without installing`,
+  sesh tmux install                                 # Install keybindings
+  sesh tmux install --switcher-key s --last-key l   # Use other keys
+  sesh tmux install --popup-width 120 --popup-height 40
+  sesh tmux keybindings                             # Show keybindings without installing`,
 	RunE: runTmuxInstall,
 }
 
+var tmuxUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the sesh keybindings from tmux.conf",
+	Long: `Remove the keybindings 'sesh tmux install' added to your tmux configuration,
+between the "# BEGIN sesh tmux integration" and "# END sesh tmux integration"
+markers, leaving the rest of the file as it is.
+
+The previous file is kept next to it, as tmux.conf.sesh-backup. Reloading tmux.conf
+doesn't unbind keys, so the keys stay bound in the running tmux server until it
+restarts or they are unbound with the printed commands.
+
+Examples:
+  sesh tmux uninstall`,
+	Args: cobra.NoArgs,
+	RunE: runTmuxUninstall,
+}
+
+var (
+	tmuxPluginDir   string
+	tmuxSwitcherKey string
+	tmuxPRKey       string
+	tmuxLastKey     string
+	tmuxPopupWidth  string
+	tmuxPopupHeight string
+)
+
+var tmuxPluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Generate a TPM-compatible tmux plugin",
+	Long: `Generate a tmux plugin directory for sesh that the tmux plugin manager (TPM)
+loads like any other plugin. The plugin binds the popup switchers and the last
+session key, and adds 'sesh statusline' to status-right.
+
+The plugin is written to <TPM plugin directory>/sesh (~/.tmux/plugins/sesh by
+default). Then add one line to your tmux.conf, before TPM is initialized:
+
+  set -g @plugin 'benoctopus/sesh'
+
+Options (set in tmux.conf before TPM is initialized):
+  @sesh-switch-key     Key for the branch switcher popup (default: f)
+  @sesh-pr-key         Key for the pull request switcher popup (default: F)
+  @sesh-last-key       Key to switch to the previous session (default: L)
+  @sesh-popup-width    Popup width (default: 80%)
+  @sesh-popup-height   Popup height (default: 60%)
+  @sesh-statusline     Add the sesh segment to status-right, on or off (default: on)
+
+Without TPM, run the plugin directly from tmux.conf instead:
+
+  run-shell ~/.tmux/plugins/sesh/sesh.tmux
+
+Examples:
+  sesh tmux plugin                        # Write to the TPM plugin directory
+  sesh tmux plugin --dir ~/dotfiles/tmux  # Write to <dir>/sesh`,
+	Args: cobra.NoArgs,
+	RunE: runTmuxPlugin,
+}
+
 func init() {
 	rootCmd.AddCommand(tmuxCmd)
 	tmuxCmd.AddCommand(tmuxKeybindingsCmd)
 	tmuxCmd.AddCommand(tmuxInstallCmd)
+	tmuxCmd.AddCommand(tmuxUninstallCmd)
+	tmuxCmd.AddCommand(tmuxPluginCmd)
+	for _, cmd := range []*cobra.Command{tmuxKeybindingsCmd, tmuxInstallCmd} {
+		cmd.Flags().StringVar(&tmuxSwitcherKey, "switcher-key", "", "Key of the branch switcher popup (default: f)")
+		cmd.Flags().StringVar(&tmuxPRKey, "pr-key", "", "Key of the pull request switcher popup (default: F)")
+		cmd.Flags().StringVar(&tmuxLastKey, "last-key", "", "Key switching to the previous session (default: L)")
+		cmd.Flags().StringVar(&tmuxPopupWidth, "popup-width", "", "Popup width, in columns or percent (default: 80%)")
+		cmd.Flags().StringVar(&tmuxPopupHeight, "popup-height", "", "Popup height, in lines or percent (default: 60%)")
+	}
+	tmuxPluginCmd.Flags().
+		StringVar(&tmuxPluginDir, "dir", "", "Plugin directory to write the sesh plugin into (default: TPM's)")
 }
 
 var bin, _ = os.Executable()
 
 const tmuxKeybindingsContent = `# BEGIN sesh tmux integration
-# Fuzzy session switcher with preview (prefix + f)
-bind-key f display-popup -E -w 80% -h 60% \
+# Fuzzy session switcher with preview (prefix + {{ .SwitcherKey }})
+bind-key {{ .SwitcherKey }} display-popup -E -w {{ .PopupWidth }} -h {{ .PopupHeight }} \
   "{{ .Bin }} switch"
 
-# Fuzzy pull request switcher with preview (prefix + F)
-bind-key F display-popup -E -w 80% -h 60% \
+# Fuzzy pull request switcher with preview (prefix + {{ .PRKey }})
+bind-key {{ .PRKey }} display-popup -E -w {{ .PopupWidth }} -h {{ .PopupHeight }} \
   "{{ .Bin }} switch --pr"
 
-# Quick switch to last/previous session (prefix + L)
-bind-key L run-shell "{{ .Bin }} last"
+# Quick switch to last/previous session (prefix + {{ .LastKey }})
+bind-key {{ .LastKey }} run-shell "{{ .Bin }} last"
 # END sesh tmux integration
 `
 
+// tmuxPluginContent is the entry point TPM runs for the plugin
+// Options are read when the plugin loads, so they must be set before TPM is initialized.
+const tmuxPluginContent = `#!/usr/bin/env bash
+# sesh tmux plugin, generated by 'sesh tmux plugin'
+
+SESH_BIN="{{ .Bin }}"
+if [ ! -x "$SESH_BIN" ]; then
+  SESH_BIN="$(command -v sesh)"
+fi
+
+get_option() {
+  local value
+  value="$(tmux show-option -gqv "$1")"
+  echo "${value:-$2}"
+}
+
+popup_width="$(get_option @sesh-popup-width 80%)"
+popup_height="$(get_option @sesh-popup-height 60%)"
+
+# Fuzzy branch and pull request switchers with preview
+tmux bind-key "$(get_option @sesh-switch-key f)" \
+  display-popup -E -w "$popup_width" -h "$popup_height" "$SESH_BIN switch"
+tmux bind-key "$(get_option @sesh-pr-key F)" \
+  display-popup -E -w "$popup_width" -h "$popup_height" "$SESH_BIN switch --pr"
+
+# Quick switch to last/previous session
+tmux bind-key "$(get_option @sesh-last-key L)" run-shell "$SESH_BIN last"
+
+# Project, branch, and running session count of the current pane
+if [ "$(get_option @sesh-statusline on)" = on ]; then
+  status_right="$(tmux show-option -gqv status-right)"
+  case "$status_right" in
+    *"statusline"*) ;;
+    *) tmux set-option -g status-right "#($SESH_BIN statusline \"#{pane_current_path}\") $status_right" ;;
+  esac
+fi
+`
+
 const (
 	seshMarkerBegin = "# BEGIN sesh tmux integration"
 	seshMarkerEnd   = "# END sesh tmux integration"
 )
 
-// renderKeybindings executes the keybindings template with the binary path
-func renderKeybindings() (string, error) {
-	tmpl, err := template.New("keybindings").Parse(tmuxKeybindingsContent)
+// renderKeybindings executes the keybindings template with the binary path and keys
+func renderKeybindings(keys config.TmuxConfig) (string, error) {
+	return renderTmuxTemplate("keybindings", tmuxKeybindingsContent, keys)
+}
+
+// renderTmuxTemplate executes a tmux configuration template with the binary path and keys
+func renderTmuxTemplate(name, content string, keys config.TmuxConfig) (string, error) {
+	tmpl, err := template.New(name).Parse(content)
 	if err != nil {
-		return "", eris.Wrap(err, "failed to parse keybindings template")
+		return "", eris.Wrapf(err, "failed to parse %s template", name)
 	}
 
 	var buf bytes.Buffer
 	data := struct {
 		Bin string
+		config.TmuxConfig
 	}{
-		Bin: bin,
+		Bin:        bin,
+		TmuxConfig: keys,
 	}
 
 	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", eris.Wrap(err, "failed to execute keybindings template")
+		return "", eris.Wrapf(err, "failed to execute %s template", name)
 	}
 
 	return buf.String(), nil
 }
 
+// tmuxKeys returns the keys and popup size of the keybindings: the flags, then the config
+// file, then the defaults
+func tmuxKeys() (config.TmuxConfig, error) {
+	keys, err := config.GetTmuxConfig()
+	if err != nil {
+		return config.TmuxConfig{}, eris.Wrap(err, "failed to load tmux keybindings")
+	}
+
+	for _, flag := range []struct {
+		name     string
+		value    string
+		field    *string
+		validate func(string) error
+	}{
+		{"--switcher-key", tmuxSwitcherKey, &keys.SwitcherKey, config.ValidateTmuxKey},
+		{"--pr-key", tmuxPRKey, &keys.PRKey, config.ValidateTmuxKey},
+		{"--last-key", tmuxLastKey, &keys.LastKey, config.ValidateTmuxKey},
+		{"--popup-width", tmuxPopupWidth, &keys.PopupWidth, config.ValidateTmuxPopupSize},
+		{"--popup-height", tmuxPopupHeight, &keys.PopupHeight, config.ValidateTmuxPopupSize},
+	} {
+		if flag.value == "" {
+			continue
+		}
+		if err := flag.validate(flag.value); err != nil {
+			return config.TmuxConfig{}, eris.Wrapf(err, "invalid %s", flag.name)
+		}
+		*flag.field = flag.value
+	}
+
+	keys = keys.WithDefaults()
+	if keys.SwitcherKey == keys.PRKey || keys.SwitcherKey == keys.LastKey || keys.PRKey == keys.LastKey {
+		return config.TmuxConfig{}, eris.Errorf(
+			"the keybindings must use different keys (switcher %s, pull requests %s, last %s)",
+			keys.SwitcherKey, keys.PRKey, keys.LastKey,
+		)
+	}
+	return keys, nil
+}
+
 // removeSeshBlock removes the existing sesh keybindings block from the content
 func removeSeshBlock(content string) string {
 	// Find the start of the sesh block
@@ -141,8 +302,13 @@ func runTmuxKeybindings(cmd *cobra.Command, args []string) error {
 	disp.Printf("\n%s\n", disp.Bold("Recommended tmux keybindings for sesh:"))
 	disp.Println()
 
+	keys, err := tmuxKeys()
+	if err != nil {
+		return err
+	}
+
 	// Render keybindings with actual binary path
-	keybindings, err := renderKeybindings()
+	keybindings, err := renderKeybindings(keys)
 	if err != nil {
 		return err
 	}
@@ -160,6 +326,11 @@ func runTmuxKeybindings(cmd *cobra.Command, args []string) error {
 func runTmuxInstall(cmd *cobra.Command, args []string) error {
 	disp := display.NewStderr()
 
+	keys, err := tmuxKeys()
+	if err != nil {
+		return err
+	}
+
 	// Find tmux.conf location
 	tmuxConfPath, err := findTmuxConf()
 	if err != nil {
@@ -202,7 +373,7 @@ func runTmuxInstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Render keybindings with actual binary path
-	keybindings, err := renderKeybindings()
+	keybindings, err := renderKeybindings(keys)
 	if err != nil {
 		return err
 	}
@@ -256,8 +427,9 @@ func runTmuxInstall(cmd *cobra.Command, args []string) error {
 	}
 	disp.Println()
 	disp.Printf("%s\n", disp.Bold("Installed keybindings:"))
-	disp.Printf("  %s %s\n", disp.InfoText("prefix + f"), "Fuzzy session switcher with preview")
-	disp.Printf("  %s %s\n", disp.InfoText("prefix + L"), "Switch to last/previous session")
+	disp.Printf("  %s %s\n", disp.InfoText("prefix + "+keys.SwitcherKey), "Fuzzy session switcher with preview")
+	disp.Printf("  %s %s\n", disp.InfoText("prefix + "+keys.PRKey), "Fuzzy pull request switcher with preview")
+	disp.Printf("  %s %s\n", disp.InfoText("prefix + "+keys.LastKey), "Switch to last/previous session")
 	disp.Println()
 	disp.Info("To apply the changes, reload your tmux configuration:")
 	disp.Printf("  %s\n\n", disp.Bold("tmux source-file "+tmuxConfPath))
@@ -265,6 +437,144 @@ func runTmuxInstall(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runTmuxUninstall(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	tmuxConfPath, err := findTmuxConf()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(tmuxConfPath)
+	if os.IsNotExist(err) {
+		disp.Infof("No tmux config found at %s, nothing to uninstall", tmuxConfPath)
+		return nil
+	}
+	if err != nil {
+		return eris.Wrapf(err, "failed to read tmux config: %s", tmuxConfPath)
+	}
+
+	contentBytes, err := os.ReadFile(tmuxConfPath)
+	if err != nil {
+		return eris.Wrapf(err, "failed to read tmux config: %s", tmuxConfPath)
+	}
+	content := string(contentBytes)
+
+	if !strings.Contains(content, seshMarkerBegin) {
+		disp.Infof("No sesh keybindings found in %s", tmuxConfPath)
+		return nil
+	}
+	if !strings.Contains(content, seshMarkerEnd) {
+		return eris.Errorf(
+			"%s has %q but no %q: remove the sesh keybindings by hand",
+			tmuxConfPath, seshMarkerBegin, seshMarkerEnd,
+		)
+	}
+
+	backupPath := tmuxConfPath + ".sesh-backup"
+	if err := os.WriteFile(backupPath, contentBytes, info.Mode().Perm()); err != nil {
+		return eris.Wrapf(err, "failed to back up tmux config to %s", backupPath)
+	}
+
+	if err := os.WriteFile(tmuxConfPath, []byte(removeSeshBlock(content)), info.Mode().Perm()); err != nil {
+		return eris.Wrapf(err, "failed to write tmux config: %s", tmuxConfPath)
+	}
+
+	disp.Successf("Removed the sesh keybindings from %s", tmuxConfPath)
+	disp.Printf("  %s\n", disp.Faint("Previous file saved as "+backupPath))
+
+	// Reloading tmux.conf leaves the keys bound, so print how to unbind them now
+	if keys := seshBlockKeys(content); len(keys) > 0 {
+		disp.Println()
+		disp.Info("The keys stay bound until tmux restarts. To unbind them now, run:")
+		for _, key := range keys {
+			disp.Printf("  %s\n", disp.Bold("tmux unbind-key "+key))
+		}
+	}
+	disp.Println()
+
+	return nil
+}
+
+// seshBlockKeys returns the keys bound in the sesh keybindings block of a tmux config
+func seshBlockKeys(content string) []string {
+	start := strings.Index(content, seshMarkerBegin)
+	end := strings.Index(content, seshMarkerEnd)
+	if start == -1 || end < start {
+		return nil
+	}
+
+	var keys []string
+	for _, line := range strings.Split(content[start:end], "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "bind-key" {
+			keys = append(keys, fields[1])
+		}
+	}
+	return keys
+}
+
+func runTmuxPlugin(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	dir := tmuxPluginDir
+	if dir == "" {
+		var err error
+		dir, err = findTmuxPluginDir()
+		if err != nil {
+			return err
+		}
+	}
+	pluginDir := filepath.Join(dir, "sesh")
+
+	plugin, err := renderTmuxTemplate("plugin", tmuxPluginContent, config.TmuxConfig{})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(pluginDir, 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create plugin directory: %s", pluginDir)
+	}
+	pluginPath := filepath.Join(pluginDir, "sesh.tmux")
+	//nolint:gosec // TPM runs the plugin, so it must be executable
+	if err := os.WriteFile(pluginPath, []byte(plugin), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to write plugin: %s", pluginPath)
+	}
+
+	disp.Successf("Generated the sesh tmux plugin in %s", pluginDir)
+	disp.Println()
+	disp.Info("Add it to your tmux.conf before TPM is initialized:")
+	disp.Printf("  %s\n", disp.Bold("set -g @plugin 'benoctopus/sesh'"))
+	disp.Printf("  %s\n", disp.Faint("(or without TPM: run-shell "+pluginPath+")"))
+	disp.Println()
+
+	return nil
+}
+
+// findTmuxPluginDir locates the directory TPM installs plugins into
+// TPM uses ~/.config/tmux/plugins when tmux.conf lives there, and ~/.tmux/plugins otherwise
+func findTmuxPluginDir() (string, error) {
+	if envPath := os.Getenv("TMUX_PLUGIN_MANAGER_PATH"); envPath != "" {
+		return envPath, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", eris.Wrap(err, "failed to get home directory")
+	}
+
+	tmuxConfPath, err := findTmuxConf()
+	if err != nil {
+		return "", err
+	}
+	xdgTmuxDir := filepath.Join(homeDir, ".config", "tmux")
+	if filepath.Dir(tmuxConfPath) == xdgTmuxDir {
+		return filepath.Join(xdgTmuxDir, "plugins"), nil
+	}
+
+	return filepath.Join(homeDir, ".tmux", "plugins"), nil
+}
+
 // findTmuxConf locates the tmux configuration file
 func findTmuxConf() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -281,7 +591,7 @@ func findTmuxConf() (string, error) {
 	// If TMUX_CONF env var is set, use that
 	if envPath := os.Getenv("TMUX_CONF"); envPath != "" {
 		// Expand tilde if present
-		if strings.HasPrefix(envPath, "~/") {
+		if strings.HasPrefix(envPath, "~/") || strings.HasPrefix(envPath, "~"+string(filepath.Separator)) {
 			envPath = filepath.Join(homeDir, envPath[2:])
 		}
 		candidates = append([]string{envPath}, candidates...)
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+func TestRunTmuxPlugin(t *testing.T) {
+	dir := t.TempDir()
+	tmuxPluginDir = dir
+	t.Cleanup(func() { tmuxPluginDir = "" })
+
+	if err := runTmuxPlugin(tmuxPluginCmd, nil); err != nil {
+		t.Fatalf("runTmuxPlugin() failed: %v", err)
+	}
+
+	// TPM sources every executable *.tmux file in the plugin directory
+	pluginPath := filepath.Join(dir, "sesh", "sesh.tmux")
+	info, err := os.Stat(pluginPath)
+	if err != nil {
+		t.Fatalf("plugin was not written: %v", err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("plugin mode = %v, want executable", info.Mode().Perm())
+	}
+
+	content, err := os.ReadFile(pluginPath)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	for _, want := range []string{
+		`SESH_BIN="` + bin + `"`,
+		"@sesh-switch-key f",
+		"@sesh-pr-key F",
+		"@sesh-last-key L",
+		"statusline",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("plugin does not contain %q", want)
+		}
+	}
+}
+
+func TestFindTmuxPluginDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TMUX_CONF", "")
+	t.Setenv("TMUX_PLUGIN_MANAGER_PATH", "")
+
+	got, err := findTmuxPluginDir()
+	if err != nil {
+		t.Fatalf("findTmuxPluginDir() failed: %v", err)
+	}
+	if want := filepath.Join(home, ".tmux", "plugins"); got != want {
+		t.Errorf("findTmuxPluginDir() = %q, want %q", got, want)
+	}
+
+	t.Setenv("TMUX_PLUGIN_MANAGER_PATH", "/custom/plugins")
+	if got, _ := findTmuxPluginDir(); got != "/custom/plugins" {
+		t.Errorf("findTmuxPluginDir() = %q, want %q", got, "/custom/plugins")
+	}
+}
+
+func TestRunTmuxUninstall(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	confPath := filepath.Join(home, ".tmux.conf")
+	t.Setenv("TMUX_CONF", confPath)
+
+	keybindings, err := renderKeybindings(config.TmuxConfig{}.WithDefaults())
+	if err != nil {
+		t.Fatalf("renderKeybindings() failed: %v", err)
+	}
+	original := "set -g mouse on\n\n" + keybindings + "set -g base-index 1\n"
+	if err := os.WriteFile(confPath, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runTmuxUninstall(tmuxUninstallCmd, nil); err != nil {
+		t.Fatalf("runTmuxUninstall() failed: %v", err)
+	}
+
+	content, err := os.ReadFile(confPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "set -g mouse on\nset -g base-index 1\n"; string(content) != want {
+		t.Errorf("tmux.conf = %q, want %q", content, want)
+	}
+
+	backup, err := os.ReadFile(confPath + ".sesh-backup")
+	if err != nil {
+		t.Fatalf("backup was not written: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("backup = %q, want the previous file", backup)
+	}
+
+	if keys := seshBlockKeys(original); !slices.Equal(keys, []string{"f", "F", "L"}) {
+		t.Errorf("seshBlockKeys() = %v, want [f F L]", keys)
+	}
+
+	// Uninstalling again leaves the file alone
+	if err := runTmuxUninstall(tmuxUninstallCmd, nil); err != nil {
+		t.Fatalf("second runTmuxUninstall() failed: %v", err)
+	}
+	if again, _ := os.ReadFile(confPath); string(again) != string(content) {
+		t.Errorf("second uninstall changed tmux.conf to %q", again)
+	}
+}
+
+func TestTmuxKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if err := os.MkdirAll(filepath.Join(home, ".config", "sesh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configFile := "tmux:\n  switcher_key: s\n  popup_height: \"50%\"\n"
+	if err := os.WriteFile(filepath.Join(home, ".config", "sesh", "config.yaml"), []byte(configFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmuxPRKey, tmuxPopupHeight = "P", "40"
+	t.Cleanup(func() { tmuxPRKey, tmuxPopupHeight = "", "" })
+
+	keys, err := tmuxKeys()
+	if err != nil {
+		t.Fatalf("tmuxKeys() failed: %v", err)
+	}
+	keybindings, err := renderKeybindings(keys)
+	if err != nil {
+		t.Fatalf("renderKeybindings() failed: %v", err)
+	}
+	for _, want := range []string{
+		"bind-key s display-popup -E -w 80% -h 40 ",
+		"bind-key P display-popup -E -w 80% -h 40 ",
+		"bind-key L run-shell",
+	} {
+		if !strings.Contains(keybindings, want) {
+			t.Errorf("keybindings do not contain %q:\n%s", want, keybindings)
+		}
+	}
+
+	tmuxPRKey = "s"
+	if _, err := tmuxKeys(); err == nil {
+		t.Error("tmuxKeys() with the same key twice should fail")
+	}
+}
+
+func TestTmuxMenuArgs(t *testing.T) {
+	sessions := []sessionDetail{
+		{SessionName: "repo-feature", ProjectName: "github.com/user/repo", Branch: "feature"},
+		{SessionName: "repo-main", ProjectName: "github.com/user/repo", Branch: "main", IsRunning: true},
+		{SessionName: "repo-fix-#12", ProjectName: "github.com/user/repo", Branch: "fix/#12"},
+	}
+
+	sortByFrecency(sessions, nil, time.Now())
+	args := tmuxMenuArgs(sessions, 1, len(tmuxMenuKeys))
+	if args[0] != "display-menu" {
+		t.Fatalf("tmuxMenuArgs()[0] = %q, want display-menu", args[0])
+	}
+
+	// Items are name, key, and command triples after the menu options
+	items := args[len(args)-9:]
+	want := [][2]string{
+		{"● repo-main", "switch-client -t \"=repo-main\""},
+		{"○ repo-feature", "feature -p github.com/user/repo"},
+		{"○ repo-fix-##12", "'fix/##12' -p github.com/user/repo"},
+	}
+	for i, w := range want {
+		name, key, command := items[i*3], items[i*3+1], items[i*3+2]
+		if name != w[0] {
+			t.Errorf("item %d name = %q, want %q", i, name, w[0])
+		}
+		if key != string(tmuxMenuKeys[i]) {
+			t.Errorf("item %d key = %q, want %q", i, key, string(tmuxMenuKeys[i]))
+		}
+		if !strings.Contains(command, w[1]) {
+			t.Errorf("item %d command = %q, want it to contain %q", i, command, w[1])
+		}
+	}
+	if !strings.HasPrefix(items[5], "display-popup -E") {
+		t.Errorf("stopped session command = %q, want a display-popup", items[5])
+	}
+}
+
+func TestTmuxMenuArgsPages(t *testing.T) {
+	var sessions []sessionDetail
+	for i := range 40 {
+		sessions = append(sessions, sessionDetail{SessionName: fmt.Sprintf("repo-%02d", i), Branch: "b"})
+	}
+
+	first := tmuxMenuArgs(sessions, 1, 36)
+	if first[2] != "#[align=centre]sesh (1/2)" {
+		t.Errorf("first page title = %q", first[2])
+	}
+	if !slices.Contains(first, "Next page") || slices.Contains(first, "Previous page") {
+		t.Errorf("first page should only link to the next page: %q", first)
+	}
+	if !slices.Contains(first, "○ repo-35") || slices.Contains(first, "○ repo-36") {
+		t.Errorf("first page should hold the first 36 sessions: %q", first)
+	}
+
+	// Pages past the end show the last one
+	last := tmuxMenuArgs(sessions, 5, 36)
+	if !slices.Contains(last, "○ repo-39") || !slices.Contains(last, "Previous page") || slices.Contains(last, "Next page") {
+		t.Errorf("last page = %q", last)
+	}
+	if i := slices.Index(last, "Previous page"); !strings.HasSuffix(last[i+2], "tmux menu --page 1\"") {
+		t.Errorf("previous page command = %q", last[i+2])
+	}
+
+	if single := tmuxMenuArgs(sessions[:3], 1, 36); slices.Contains(single, "Next page") || single[2] != "#[align=centre]sesh" {
+		t.Errorf("single page = %q", single)
+	}
+}
+
+func TestSortByFrecency(t *testing.T) {
+	now := time.Now()
+	history := []*models.SessionHistory{
+		{SessionName: "repo-daily", AccessedAt: now.Add(-2 * time.Hour)},
+		{SessionName: "repo-daily", AccessedAt: now.Add(-3 * time.Hour)},
+		{SessionName: "repo-recent", AccessedAt: now.Add(-time.Minute)},
+		{SessionName: "repo-old", AccessedAt: now.Add(-30 * 24 * time.Hour)},
+	}
+	sessions := []sessionDetail{
+		{SessionName: "repo-old"},
+		{SessionName: "repo-unused"},
+		{SessionName: "repo-running", IsRunning: true},
+		{SessionName: "repo-daily"},
+		{SessionName: "repo-recent"},
+	}
+
+	sortByFrecency(sessions, history, now)
+
+	var got []string
+	for _, sess := range sessions {
+		got = append(got, sess.SessionName)
+	}
+	want := []string{"repo-daily", "repo-recent", "repo-old", "repo-running", "repo-unused"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sortByFrecency() = %v, want %v", got, want)
+	}
+}
+
+func TestSortPinnedFirst(t *testing.T) {
+	sessions := []sessionDetail{
+		{SessionName: "repo-a", ProjectName: "repo", Branch: "a"},
+		{SessionName: "repo-b", ProjectName: "repo", Branch: "b"},
+		{SessionName: "other-a", ProjectName: "other", Branch: "a"},
+		{SessionName: "repo-c", ProjectName: "repo", Branch: "c"},
+	}
+	pinned := map[pinKey]bool{{"repo", "c"}: true, {"other", "a"}: true}
+
+	sortPinnedFirst(sessions, pinned)
+
+	var got []string
+	for _, sess := range sessions {
+		got = append(got, sess.SessionName)
+		if want := pinned[pinKey{sess.ProjectName, sess.Branch}]; sess.Pinned != want {
+			t.Errorf("%s marked pinned = %v, want %v", sess.SessionName, sess.Pinned, want)
+		}
+	}
+	want := []string{"other-a", "repo-c", "repo-a", "repo-b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sortPinnedFirst() = %v, want %v", got, want)
+	}
+}
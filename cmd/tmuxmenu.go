@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// tmuxMenuKeys are the keys of the menu items, in order; a page has at most one item per key
+const tmuxMenuKeys = "1234567890abcdefghijklmnopqrstuvwxyz"
+
+// tmuxMenuHistoryLimit is how many session history entries frecency is computed from
+const tmuxMenuHistoryLimit = 1000
+
+var tmuxMenuPage int
+
+var tmuxMenuCmd = &cobra.Command{
+	Use:   "menu",
+	Short: "Show the worktrees in a native tmux menu",
+	Long: `Show the worktrees of all projects in a tmux menu (display-menu), to switch
+without a popup. Running sessions (●) are switched to directly; choosing a
+stopped worktree (○) runs 'sesh switch' in a popup, which starts its session.
+
+Worktrees are sorted by frecency: the ones switched to often and recently come
+first, after the ones pinned with 'sesh pin' (★). When they don't fit in one menu, the menu is split into pages, with
+items to go to the next (>) and previous (<) page.
+
+Bind it to a key in tmux.conf:
+
+  bind-key s run-shell "sesh tmux menu"`,
+	Args: cobra.NoArgs,
+	RunE: runTmuxMenu,
+}
+
+func init() {
+	tmuxCmd.AddCommand(tmuxMenuCmd)
+	tmuxMenuCmd.Flags().IntVar(&tmuxMenuPage, "page", 1, "Page of the menu to show")
+}
+
+func runTmuxMenu(cmd *cobra.Command, args []string) error {
+	if !session.IsInsideTmux() {
+		return eris.New("sesh tmux menu must be run inside tmux")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	sessionMgr, err := session.NewSessionManager(string(session.BackendTmux))
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return eris.Wrap(err, "failed to discover projects")
+	}
+
+	runningSessions, err := state.DiscoverSessions(sessionMgr)
+	if err != nil {
+		return eris.Wrap(err, "failed to discover sessions")
+	}
+
+	sessions := collectSessionDetails(projects, runningSessions, "", false)
+	if len(sessions) == 0 {
+		return eris.New("no worktrees found")
+	}
+	sortByFrecency(sessions, recentSessionHistory(), time.Now())
+	sortPinnedFirst(sessions, pinnedSessions())
+
+	menuArgs := tmuxMenuArgs(sessions, tmuxMenuPage, tmuxMenuPageSize())
+	output, err := exec.Command("tmux", menuArgs...).CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to display tmux menu: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// tmuxMenuArgs returns the tmux display-menu arguments listing a page of the sessions, with
+// items going to the previous and next pages when there are more
+func tmuxMenuArgs(sessions []sessionDetail, page, pageSize int) []string {
+	pages := (len(sessions) + pageSize - 1) / pageSize
+	page = min(max(page, 1), pages)
+
+	title := "#[align=centre]sesh"
+	if pages > 1 {
+		title += fmt.Sprintf(" (%d/%d)", page, pages)
+	}
+	args := []string{"display-menu", "-T", title, "-x", "C", "-y", "C"}
+
+	start := (page - 1) * pageSize
+	for i, sess := range sessions[start:min(start+pageSize, len(sessions))] {
+		args = append(args, tmuxMenuItem(sess), string(tmuxMenuKeys[i]), tmuxMenuCommand(sess))
+	}
+
+	if pages > 1 {
+		// An empty item is a separator
+		args = append(args, "")
+		if page < pages {
+			args = append(args, "Next page", ">", tmuxMenuPageCommand(page+1))
+		}
+		if page > 1 {
+			args = append(args, "Previous page", "<", tmuxMenuPageCommand(page-1))
+		}
+	}
+	return args
+}
+
+// tmuxMenuPageSize returns how many sessions fit in a page of the menu: one per key, and no
+// more than the client is tall, since tmux doesn't show menus that don't fit
+func tmuxMenuPageSize() int {
+	output, err := exec.Command("tmux", "display-message", "-p", "#{client_height}").Output()
+	if err != nil {
+		return len(tmuxMenuKeys)
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return len(tmuxMenuKeys)
+	}
+	// The border, the separator, and the page items take 5 lines
+	return min(max(height-5, 1), len(tmuxMenuKeys))
+}
+
+// tmuxMenuPageCommand returns the tmux command showing another page of the menu
+func tmuxMenuPageCommand(page int) string {
+	return "run-shell " + tmuxQuote(session.ShellQuote(seshBin())+" tmux menu --page "+strconv.Itoa(page))
+}
+
+// recentSessionHistory returns the session history the menu is sorted by, most recent first. The
+// menu is still shown without history, so failures are only logged.
+func recentSessionHistory() []*models.SessionHistory {
+	database, err := openDB()
+	if err != nil {
+		slog.Debug("failed to open database for session history", "error", err)
+		return nil
+	}
+	defer database.Close()
+
+	history, err := database.GetRecentSessionHistory(tmuxMenuHistoryLimit)
+	if err != nil {
+		slog.Debug("failed to get session history", "error", err)
+	}
+	return history
+}
+
+// frecencyScores scores sessions by how often and how recently they were switched to, like
+// zoxide: each switch counts more the more recent it is
+func frecencyScores(history []*models.SessionHistory, now time.Time) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, entry := range history {
+		age := now.Sub(entry.AccessedAt)
+		switch {
+		case age < time.Hour:
+			scores[entry.SessionName] += 4
+		case age < 24*time.Hour:
+			scores[entry.SessionName] += 2
+		case age < 7*24*time.Hour:
+			scores[entry.SessionName] += 0.5
+		default:
+			scores[entry.SessionName] += 0.25
+		}
+	}
+	return scores
+}
+
+// sortByFrecency sorts sessions by frecency, then running ones first, then by name
+func sortByFrecency(sessions []sessionDetail, history []*models.SessionHistory, now time.Time) {
+	scores := frecencyScores(history, now)
+	slices.SortStableFunc(sessions, func(a, b sessionDetail) int {
+		if c := cmp.Compare(scores[b.SessionName], scores[a.SessionName]); c != 0 {
+			return c
+		}
+		if a.IsRunning != b.IsRunning {
+			if a.IsRunning {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.SessionName, b.SessionName)
+	})
+}
+
+// tmuxMenuItem returns the label of a session in the menu, marked as running or stopped, and
+// as pinned
+func tmuxMenuItem(sess sessionDetail) string {
+	icon := "○"
+	if sess.IsRunning {
+		icon = "●"
+	}
+	label := icon + " " + sess.SessionName
+	if sess.Pinned {
+		label += " ★"
+	}
+	// Menu items are formats, so a literal # is doubled
+	return strings.ReplaceAll(label, "#", "##")
+}
+
+// tmuxMenuCommand returns the tmux command run when a session is chosen: running sessions are
+// switched to, and stopped ones are started by 'sesh switch' in a popup
+func tmuxMenuCommand(sess sessionDetail) string {
+	if sess.IsRunning {
+		return "switch-client -t " + tmuxQuote("="+sess.SessionName)
+	}
+
+	switchCmd := strings.Join([]string{
+		session.ShellQuote(seshBin()), "switch", session.ShellQuote(sess.Branch),
+		"-p", session.ShellQuote(sess.ProjectName),
+	}, " ")
+	return "display-popup -E -w 80% -h 60% " + tmuxQuote(switchCmd)
+}
+
+// seshBin returns the path of the running sesh, for commands tmux runs later
+func seshBin() string {
+	if bin == "" {
+		return "sesh"
+	}
+	return bin
+}
+
+// tmuxQuote quotes an argument of a tmux command, which is also expanded as a format
+func tmuxQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "#", "##").Replace(arg) + `"`
+}
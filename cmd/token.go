@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/benoctopus/sesh/internal/secrets"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Store API tokens of git hosts in the OS keyring",
+	Long: `Store the API tokens of git hosts in the keyring of the OS: the macOS Keychain,
+the Secret Service on Linux, or the Windows Credential Manager.
+
+provider_tokens in the config file names the token of each host, so the config
+file never holds one. sesh passes it to gh (GH_TOKEN) and glab (GITLAB_TOKEN).
+
+  provider_tokens:
+    github.com: github-work
+
+Examples:
+  sesh token set github-work             # Prompt for a token and store it
+  gh auth token | sesh token set gh      # Store a token read from stdin
+  sesh token list                        # Hosts and whether their token is stored
+  sesh token delete github-work          # Remove a token from the keyring`,
+}
+
+var tokenSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Store a token in the keyring",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runTokenSet,
+}
+
+var tokenDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Remove a token from the keyring",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTokenNames,
+	RunE:              runTokenDelete,
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the tokens of provider_tokens, and whether they are stored",
+	Args:  cobra.NoArgs,
+	RunE:  runTokenList,
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenSetCmd)
+	tokenCmd.AddCommand(tokenDeleteCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+
+	pr.SetTokenSource(providerToken)
+}
+
+func runTokenSet(cmd *cobra.Command, args []string) error {
+	if err := secrets.ValidateName(args[0]); err != nil {
+		return err
+	}
+	token, err := readToken(args[0])
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return eris.New("no token given")
+	}
+
+	if err := secrets.Set(args[0], token); err != nil {
+		return err
+	}
+	display.NewStderr().Successf("Stored token %s", args[0])
+	return nil
+}
+
+func runTokenDelete(cmd *cobra.Command, args []string) error {
+	err := secrets.Delete(args[0])
+	if errors.Is(err, secrets.ErrNotFound) {
+		return eris.Errorf("no token named %s in the keyring", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	display.NewStderr().Successf("Deleted token %s", args[0])
+	return nil
+}
+
+func runTokenList(cmd *cobra.Command, args []string) error {
+	tokens, err := config.GetProviderTokens()
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		display.NewStderr().Println("No provider_tokens in the config file.")
+		return nil
+	}
+
+	out := display.NewStdout()
+	for _, host := range slices.Sorted(maps.Keys(tokens)) {
+		status := out.SuccessText("stored")
+		if _, err := secrets.Get(tokens[host]); errors.Is(err, secrets.ErrNotFound) {
+			status = out.WarningText("missing")
+		} else if err != nil {
+			status = out.ErrorText("unavailable")
+			slog.Debug("failed to read token", "name", tokens[host], "error", err)
+		}
+		out.Printf("%s  %s  %s\n", out.Bold(host), tokens[host], status)
+	}
+	return nil
+}
+
+// readToken reads a token from stdin: typed at a prompt without echo, or piped in
+func readToken(name string) (string, error) {
+	if tty.IsInteractive() {
+		display.NewStderr().Printf("Token for %s: ", name)
+		token, err := term.ReadPassword(int(os.Stdin.Fd()))
+		display.NewStderr().Println()
+		if err != nil {
+			return "", eris.Wrap(err, "failed to read token")
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	token, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", eris.Wrap(err, "failed to read token")
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// providerToken returns the API token of a git host from the keyring, as named by
+// provider_tokens. Without one, the CLI of the provider authenticates as usual, so failures
+// are only logged.
+func providerToken(host string) string {
+	tokens, err := config.GetProviderTokens()
+	if err != nil || tokens[host] == "" {
+		return ""
+	}
+	token, err := secrets.Get(tokens[host])
+	if err != nil {
+		slog.Warn("failed to read provider token", "host", host, "name", tokens[host], "error", err)
+		return ""
+	}
+	return token
+}
+
+// completeTokenNames completes the token names of provider_tokens
+func completeTokenNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	tokens, err := config.GetProviderTokens()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := slices.Sorted(maps.Values(tokens))
+	return slices.Compact(names), cobra.ShellCompDirectiveNoFileComp
+}
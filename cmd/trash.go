@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// trashOperationID groups all worktrees trashed by a single sesh invocation, so that
+// "sesh undo" restores everything removed by the last clean or delete at once
+var trashOperationID = time.Now().UTC().Format("20060102T150405.000000000")
+
+// errTrashOtherFilesystem marks worktrees that can't be moved into the trash, since they are on
+// another filesystem than the workspace, like with a worktree_path_template on another disk
+var errTrashOtherFilesystem = eris.New("worktree is on another filesystem than the trash")
+
+var (
+	trashProjectName string
+	trashEmptyForce  bool
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "List and restore removed worktrees",
+	Long: `List and restore worktrees removed by clean or delete.
+
+Removed worktrees are moved to the workspace trash instead of being deleted
+immediately, and are purged after the retention period (trash_retention_days,
+7 days by default; 0 disables the trash).
+
+Examples:
+  sesh trash                         # List worktrees in the trash
+  sesh trash restore feature-foo     # Restore the feature-foo worktree
+  sesh trash empty                   # Permanently delete everything in the trash`,
+	RunE: runTrashList,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List worktrees in the trash",
+	RunE:    runTrashList,
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <branch>",
+	Short: "Restore a removed worktree from the trash",
+	Long: `Restore a removed worktree from the trash.
+
+The worktree is restored to its original location, including uncommitted changes.
+If the branch was deleted it is recreated at the commit the worktree had checked out.
+
+The project is automatically detected from the current working directory,
+or can be specified explicitly with the --project flag.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTrashRestore,
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete everything in the trash",
+	RunE:  runTrashEmpty,
+}
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the worktrees removed by the last clean or delete",
+	Long: `Restore the worktrees removed by the last clean or delete.
+
+All worktrees removed by the most recent operation are restored from the trash
+to their original locations. Sessions are not recreated; use 'sesh switch' to
+start a session for a restored worktree.
+
+Examples:
+  sesh clean --merged --force   # Oops, removed too much
+  sesh undo                     # Bring the worktrees back`,
+	RunE: runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	rootCmd.AddCommand(undoCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+	trashRestoreCmd.Flags().
+		StringVarP(&trashProjectName, "project", "p", "", "Specify project explicitly")
+	trashEmptyCmd.Flags().BoolVarP(&trashEmptyForce, "force", "f", false, "Skip confirmation prompt")
+}
+
+func runTrashList(cmd *cobra.Command, args []string) error {
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	entries, err := database.GetTrashEntries()
+	if err != nil {
+		return eris.Wrap(err, "failed to list trash")
+	}
+
+	if len(entries) == 0 {
+		display.NewStderr().Println("Trash is empty.")
+		return nil
+	}
+
+	out := display.NewStdout()
+	for _, entry := range entries {
+		out.Printf(
+			"%s  %s  %s\n",
+			out.Bold(entry.Branch),
+			out.Faint(entry.ProjectName),
+			out.Faint("removed "+formatTimeAgo(entry.TrashedAt)),
+		)
+	}
+
+	return nil
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+	branch := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	entries, err := database.GetTrashEntries()
+	if err != nil {
+		return eris.Wrap(err, "failed to list trash")
+	}
+
+	// Restrict to the current project when it can be resolved
+	projectName := ""
+	if cwd, err := os.Getwd(); err == nil {
+		if proj, err := project.ResolveProject(cfg.WorkspaceDir, trashProjectName, cwd); err == nil {
+			projectName = proj.Name
+		} else if trashProjectName != "" {
+			return eris.Wrap(err, "failed to resolve project")
+		}
+	}
+
+	// Entries are ordered most recent first, so the first match is the latest removal
+	for _, entry := range entries {
+		if entry.Branch != branch || (projectName != "" && entry.ProjectName != projectName) {
+			continue
+		}
+
+		err := restoreTrashEntry(cfg, database, entry, disp)
+		recordOperation("restore-worktree", entry.ProjectName, entry.Branch, err)
+		if err != nil {
+			return err
+		}
+		disp.Printf("\n%s Restored %s to %s\n", disp.SuccessText("✓"), disp.Bold(branch), entry.OriginalPath)
+		return nil
+	}
+
+	return eris.Errorf("no worktree for branch %s found in the trash", branch)
+}
+
+func runTrashEmpty(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	entries, err := database.GetTrashEntries()
+	if err != nil {
+		return eris.Wrap(err, "failed to list trash")
+	}
+
+	if len(entries) == 0 {
+		disp.Println("Trash is empty.")
+		return nil
+	}
+
+	// In noninteractive mode, require --force flag
+	if !trashEmptyForce {
+		if !tty.IsInteractive() {
+			return eris.New("--force flag required to empty the trash in noninteractive mode")
+		}
+
+		disp.Printf("This will permanently delete %d worktree(s) from the trash.\n", len(entries))
+		disp.Print("Are you sure? (yes/no): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return eris.Wrap(err, "failed to read confirmation")
+		}
+
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "yes" && response != "y" {
+			disp.Println("Cancelled.")
+			return nil
+		}
+	}
+
+	purged := purgeTrashEntries(cfg, database, entries, disp)
+	disp.Printf("Permanently deleted %d worktree(s) from the trash.\n", purged)
+	return nil
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	entries, err := database.GetLastTrashOperation()
+	if err != nil {
+		return eris.Wrap(err, "nothing to undo")
+	}
+
+	restored := 0
+	for _, entry := range entries {
+		err := restoreTrashEntry(cfg, database, entry, disp)
+		recordOperation("restore-worktree", entry.ProjectName, entry.Branch, err)
+		if err != nil {
+			disp.Printf("Warning: failed to restore %s: %v\n", entry.Branch, err)
+			continue
+		}
+		disp.Printf("%s Restored %s\n", disp.SuccessText("✓"), disp.Bold(entry.Branch))
+		restored++
+	}
+
+	if restored == 0 {
+		return eris.New("no worktrees could be restored")
+	}
+
+	disp.Printf("\nRestored %d of %d worktree(s).\n", restored, len(entries))
+	return nil
+}
+
+// trashWorktree moves a worktree into the workspace trash and records it so it can be restored
+// Expired trash entries are purged on a best-effort basis afterwards. Returns
+// errTrashOtherFilesystem, without touching the worktree, when it can't be moved there.
+func trashWorktree(cfg *config.Config, proj *models.Project, wt *models.Worktree, disp display.Printer) error {
+	// The trash is in the project's own workspace, since worktrees can't be moved across disks
+	projectWorkspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	if !workspace.SameFilesystem(wt.Path, projectWorkspaceDir) {
+		return errTrashOtherFilesystem
+	}
+
+	commit, err := git.GetHeadCommit(wt.Path)
+	if err != nil {
+		return eris.Wrap(err, "failed to record worktree commit")
+	}
+
+	database, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	trashPath := workspace.GetTrashPath(projectWorkspaceDir, trashOperationID, proj.Name, wt.Branch)
+	if err := os.MkdirAll(filepath.Dir(trashPath), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create trash directory: %s", filepath.Dir(trashPath))
+	}
+
+	if err := os.Rename(wt.Path, trashPath); err != nil {
+		return eris.Wrapf(err, "failed to move worktree to trash: %s", wt.Path)
+	}
+
+	// Let git forget the worktree now that its directory is gone
+	if err := git.PruneWorktrees(proj.LocalPath); err != nil {
+		disp.Printf("Warning: failed to prune worktrees: %v\n", err)
+	}
+
+	entry := &models.TrashEntry{
+		OperationID:  trashOperationID,
+		ProjectName:  proj.Name,
+		Branch:       wt.Branch,
+		CommitSHA:    commit,
+		OriginalPath: wt.Path,
+		TrashPath:    trashPath,
+	}
+	if err := database.AddTrashEntry(entry); err != nil {
+		return eris.Wrap(err, "failed to record trash entry")
+	}
+
+	purgeExpiredTrash(cfg, database, disp)
+	return nil
+}
+
+// restoreTrashEntry moves a trashed worktree back to its original location
+// The worktree is re-registered with git without a checkout, then the saved files are moved back
+// and the index is rebuilt, which keeps uncommitted changes as unstaged modifications
+func restoreTrashEntry(cfg *config.Config, database db.Store, entry *models.TrashEntry, disp display.Printer) error {
+	proj, err := state.GetProject(cfg.WorkspaceDir, entry.ProjectName)
+	if err != nil {
+		return eris.Wrapf(err, "project %s no longer exists", entry.ProjectName)
+	}
+
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := os.Stat(entry.OriginalPath); err == nil {
+		return eris.Errorf("a worktree already exists at %s", entry.OriginalPath)
+	}
+
+	// Recreate the branch if it was deleted along with the worktree
+	exists, _, err := git.DoesBranchExist(proj.LocalPath, entry.Branch)
+	if err != nil {
+		return eris.Wrap(err, "failed to check branch existence")
+	}
+	if !exists {
+		disp.Printf("Recreating branch %s at %s\n", entry.Branch, entry.CommitSHA[:min(7, len(entry.CommitSHA))])
+		if err := git.CreateBranch(proj.LocalPath, entry.Branch, entry.CommitSHA); err != nil {
+			return err
+		}
+	}
+
+	if err := git.CreateWorktreeNoCheckout(proj.LocalPath, entry.Branch, entry.OriginalPath); err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(entry.TrashPath)
+	if err != nil {
+		return eris.Wrapf(err, "failed to read trashed worktree: %s", entry.TrashPath)
+	}
+
+	for _, f := range files {
+		// The stale .git file points at worktree metadata that was pruned
+		if f.Name() == ".git" {
+			continue
+		}
+		src := filepath.Join(entry.TrashPath, f.Name())
+		dst := filepath.Join(entry.OriginalPath, f.Name())
+		if err := os.Rename(src, dst); err != nil {
+			return eris.Wrapf(err, "failed to restore %s", dst)
+		}
+	}
+
+	if err := git.ResetIndex(entry.OriginalPath); err != nil {
+		return err
+	}
+	prepare.ZoxideAdd(cfg, entry.OriginalPath, disp)
+
+	if err := os.RemoveAll(entry.TrashPath); err != nil {
+		disp.Printf("Warning: failed to remove trash directory: %v\n", err)
+	}
+	removeEmptyTrashDirs(cfg, entry.TrashPath)
+
+	return database.DeleteTrashEntry(entry.ID)
+}
+
+// purgeExpiredTrash permanently deletes trash entries older than the retention period
+func purgeExpiredTrash(cfg *config.Config, database db.Store, disp display.Printer) {
+	cutoff := time.Now().AddDate(0, 0, -cfg.TrashRetentionDays)
+	entries, err := database.GetTrashEntriesOlderThan(cutoff)
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	purgeTrashEntries(cfg, database, entries, disp)
+}
+
+// purgeTrashEntries permanently deletes trashed worktrees and their records
+// Returns the number of entries that were purged
+func purgeTrashEntries(
+	cfg *config.Config,
+	database db.Store,
+	entries []*models.TrashEntry,
+	disp display.Printer,
+) int {
+	purged := 0
+	for _, entry := range entries {
+		err := os.RemoveAll(entry.TrashPath)
+		recordOperation("purge-trash", entry.ProjectName, entry.Branch, err)
+		if err != nil {
+			disp.Printf("Warning: failed to delete %s: %v\n", entry.TrashPath, err)
+			continue
+		}
+		removeEmptyTrashDirs(cfg, entry.TrashPath)
+
+		if err := database.DeleteTrashEntry(entry.ID); err != nil {
+			disp.Printf("Warning: failed to delete trash record for %s: %v\n", entry.Branch, err)
+			continue
+		}
+		purged++
+	}
+	return purged
+}
+
+// removeEmptyTrashDirs removes the now-empty parent directories of a trash path
+// up to (but not including) the trash directory of its workspace
+func removeEmptyTrashDirs(cfg *config.Config, trashPath string) {
+	for _, workspaceDir := range append([]string{cfg.WorkspaceDir}, config.WorkspacePaths(cfg.Workspaces)...) {
+		trashRoot := filepath.Join(workspaceDir, workspace.TrashDirName)
+		if !strings.HasPrefix(trashPath, trashRoot+string(filepath.Separator)) {
+			continue
+		}
+		for dir := filepath.Dir(trashPath); dir != trashRoot && strings.HasPrefix(dir, trashRoot); dir = filepath.Dir(dir) {
+			// os.Remove fails on non-empty directories, which ends the walk
+			if err := os.Remove(dir); err != nil {
+				return
+			}
+		}
+		return
+	}
+}
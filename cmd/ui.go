@@ -0,0 +1,391 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+// uiRefreshInterval is how often the dashboard reloads the state of the sessions
+const uiRefreshInterval = 2 * time.Second
+
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Browse projects, worktrees, and sessions in an interactive dashboard",
+	Long: `Show the worktrees of all projects, and the state of their sessions, in an
+interactive dashboard that refreshes as sessions start and stop.
+
+Keys:
+  ↑/k, ↓/j   Move between worktrees
+  enter      Attach to the session of the worktree, starting it if needed
+  n          Create a worktree for a new branch of the project, and attach to it
+  d          Delete the worktree and its session (asks for confirmation)
+  f          Fetch the project
+  o          Open the pull request of the branch in the browser
+  r          Refresh
+  q          Quit
+
+The actions run the sesh commands they stand for, like 'sesh switch' and
+'sesh delete', so they behave the same as on the command line.`,
+	Args: cobra.NoArgs,
+	RunE: runUI,
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+func runUI(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	model := &uiModel{cfg: cfg, sessionMgr: sessionMgr, disp: display.NewStdout()}
+	if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+		return eris.Wrap(err, "failed to run dashboard")
+	}
+	return nil
+}
+
+// uiModel is the state of the dashboard of 'sesh ui'
+type uiModel struct {
+	cfg        *config.Config
+	sessionMgr session.SessionManager
+	disp       display.Printer
+
+	// sessions are the worktrees shown, grouped by project
+	sessions []sessionDetail
+	cursor   int
+	height   int
+
+	// creating is set while the branch of a new worktree is typed in input
+	creating bool
+	input    string
+	// confirming is set while deleting the worktree under the cursor waits for confirmation
+	confirming bool
+
+	// status is the outcome of the last action, shown under the worktrees
+	status    string
+	statusErr bool
+}
+
+// uiSessionsMsg carries the sessions reloaded by load
+type uiSessionsMsg struct {
+	sessions []sessionDetail
+	err      error
+}
+
+// uiTickMsg triggers the periodic reload of the sessions
+type uiTickMsg struct{}
+
+// uiDoneMsg reports the outcome of an action, and a summary of its output
+type uiDoneMsg struct {
+	action string
+	output string
+	err    error
+}
+
+func (m *uiModel) Init() tea.Cmd {
+	return tea.Batch(m.load(), uiTick())
+}
+
+func (m *uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case uiTickMsg:
+		return m, tea.Batch(m.load(), uiTick())
+	case uiSessionsMsg:
+		if msg.err != nil {
+			m.setStatus(msg.err.Error(), true)
+			return m, nil
+		}
+		m.sessions = msg.sessions
+		m.cursor = min(m.cursor, max(len(m.sessions)-1, 0))
+	case uiDoneMsg:
+		if msg.err != nil {
+			m.setStatus(fmt.Sprintf("%s failed: %s", msg.action, cmp.Or(msg.output, msg.err.Error())), true)
+		} else {
+			m.setStatus(cmp.Or(msg.output, msg.action+" done"), false)
+		}
+		return m, m.load()
+	case tea.KeyMsg:
+		return m, m.handleKey(msg)
+	}
+	return m, nil
+}
+
+// handleKey runs the action of a key, depending on whether a branch is being typed or a deletion
+// confirmed
+func (m *uiModel) handleKey(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() == "ctrl+c" {
+		return tea.Quit
+	}
+
+	// Keys typed faster than they're read come as one message, e.g. "jj"
+	if msg.Type == tea.KeyRunes && len(msg.Runes) > 1 && !m.creating {
+		var cmds []tea.Cmd
+		for _, r := range msg.Runes {
+			cmds = append(cmds, m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}))
+		}
+		return tea.Batch(cmds...)
+	}
+
+	sess, selected := m.selected()
+	switch {
+	case m.creating:
+		switch msg.Type {
+		case tea.KeyEnter:
+			m.creating = false
+			if m.input == "" || !selected {
+				return nil
+			}
+			return uiExec("switch", m.input, "-p", sess.ProjectName)
+		case tea.KeyEsc:
+			m.creating = false
+		case tea.KeyBackspace:
+			if m.input != "" {
+				runes := []rune(m.input)
+				m.input = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes:
+			m.input += string(msg.Runes)
+		}
+		return nil
+
+	case m.confirming:
+		m.confirming = false
+		if msg.String() != "y" || !selected {
+			m.setStatus("Not deleted", false)
+			return nil
+		}
+		m.setStatus("Deleting "+sess.Branch+"...", false)
+		// The deletion is confirmed, and sesh delete can't prompt in the background
+		return uiRun("delete", sess.Branch, "-p", sess.ProjectName, "--force")
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		return tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(m.sessions)-1, 0))
+	case "r":
+		return m.load()
+	case "enter":
+		if selected {
+			return uiExec(uiSwitchArgs(sess)...)
+		}
+	case "n":
+		if selected {
+			m.creating, m.input = true, ""
+		}
+	case "d":
+		switch {
+		case selected && sess.Subproject != "":
+			m.setStatus("Sub-project sessions are deleted with the worktree of their branch", false)
+		case selected:
+			m.confirming = true
+		}
+	case "f":
+		if selected {
+			m.setStatus("Fetching "+sess.ProjectName+"...", false)
+			return uiRun("fetch", "-p", sess.ProjectName)
+		}
+	case "o":
+		if selected {
+			return uiRun("browse", "--pr", sess.Branch, "-p", sess.ProjectName)
+		}
+	}
+	return nil
+}
+
+func (m *uiModel) View() string {
+	disp := m.disp
+	var lines []string
+	cursorLine := 0
+
+	lines = append(lines, disp.Bold("sesh"), "")
+	if len(m.sessions) == 0 {
+		lines = append(lines, disp.Faint("No worktrees found. Clone a repository with: sesh clone <remote-url>"))
+	}
+	for i, sess := range m.sessions {
+		if i == 0 || m.sessions[i-1].ProjectName != sess.ProjectName {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, disp.Bold(sess.ProjectName))
+		}
+		if i == m.cursor {
+			cursorLine = len(lines)
+		}
+		lines = append(lines, m.sessionLine(sess, i == m.cursor))
+	}
+
+	var footer []string
+	switch {
+	case m.creating:
+		footer = append(footer, "New branch: "+m.input+"█", disp.Faint("enter create · esc cancel"))
+	case m.confirming:
+		sess, _ := m.selected()
+		footer = append(footer, disp.WarningText(
+			fmt.Sprintf("Delete the worktree and session of %s? (y/n)", sess.Branch),
+		))
+	default:
+		if m.status != "" {
+			if m.statusErr {
+				footer = append(footer, disp.ErrorText(m.status))
+			} else {
+				footer = append(footer, m.status)
+			}
+		}
+		footer = append(footer, disp.Faint(
+			"enter attach · n new branch · d delete · f fetch · o open PR · r refresh · q quit",
+		))
+	}
+
+	// Only the lines around the cursor are shown when they don't all fit
+	if available := m.height - len(footer) - 1; available > 0 && len(lines) > available {
+		start := min(max(cursorLine-available/2, 0), len(lines)-available)
+		lines = lines[start : start+available]
+	}
+	return strings.Join(lines, "\n") + "\n\n" + strings.Join(footer, "\n")
+}
+
+// sessionLine renders the line of a worktree: its branch, and the state of its session
+func (m *uiModel) sessionLine(sess sessionDetail, selected bool) string {
+	disp := m.disp
+	cursor := "  "
+	branch := disp.InfoText(sess.Branch)
+	if selected {
+		cursor = disp.Bold("› ")
+		branch = disp.Bold(sess.Branch)
+	}
+	if sess.Subproject != "" {
+		branch += " " + disp.Faint("("+sess.Subproject+")")
+	}
+	if sess.Pinned {
+		branch += " " + disp.WarningText("★")
+	}
+
+	status := disp.Faint("○ stopped")
+	if sess.IsRunning {
+		status = disp.SuccessText("● running")
+		if sess.Attached {
+			status = disp.SuccessText("● attached")
+		}
+	}
+	return fmt.Sprintf("%s%s %s %s", cursor, branch, status, disp.Faint(formatTimeAgo(sess.LastUsed)))
+}
+
+// selected returns the worktree under the cursor, if there are any
+func (m *uiModel) selected() (sessionDetail, bool) {
+	if m.cursor >= len(m.sessions) {
+		return sessionDetail{}, false
+	}
+	return m.sessions[m.cursor], true
+}
+
+func (m *uiModel) setStatus(status string, isErr bool) {
+	m.status, m.statusErr = status, isErr
+}
+
+// load reloads the worktrees of all projects and the state of their sessions
+func (m *uiModel) load() tea.Cmd {
+	return func() tea.Msg {
+		projects, err := state.DiscoverProjects(m.cfg.WorkspaceDir)
+		if err != nil {
+			return uiSessionsMsg{err: eris.Wrap(err, "failed to discover projects")}
+		}
+		runningSessions, err := state.DiscoverSessions(m.sessionMgr)
+		if err != nil {
+			return uiSessionsMsg{err: eris.Wrap(err, "failed to discover sessions")}
+		}
+
+		sessions := collectSessionDetails(projects, runningSessions, "", false)
+		sortPinnedFirst(sessions, pinnedSessions())
+		return uiSessionsMsg{sessions: groupSessionsByProject(sessions)}
+	}
+}
+
+func uiTick() tea.Cmd {
+	return tea.Tick(uiRefreshInterval, func(time.Time) tea.Msg { return uiTickMsg{} })
+}
+
+// uiSwitchArgs returns the arguments of the sesh command attaching to the session of a worktree
+func uiSwitchArgs(sess sessionDetail) []string {
+	args := []string{"switch", sess.Branch, "-p", sess.ProjectName}
+	if sess.Subproject != "" {
+		args = append(args, "--subproject", sess.Subproject)
+	}
+	return args
+}
+
+// uiExec runs a sesh command in the terminal, suspending the dashboard until it exits, for
+// commands that attach to sessions or prompt
+func uiExec(args ...string) tea.Cmd {
+	var output bytes.Buffer
+	cmd := exec.Command(seshBin(), args...)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return uiDoneMsg{action: "sesh " + args[0], output: outputSummary(output.String()), err: err}
+	})
+}
+
+// uiRun runs a sesh command in the background of the dashboard
+var uiRun = func(args ...string) tea.Cmd {
+	return func() tea.Msg {
+		output, err := exec.Command(seshBin(), args...).CombinedOutput()
+		return uiDoneMsg{action: "sesh " + args[0], output: outputSummary(string(output)), err: err}
+	}
+}
+
+// groupSessionsByProject moves the sessions of each project together, in the order the projects
+// first appear, keeping the order of the sessions of each project
+func groupSessionsByProject(sessions []sessionDetail) []sessionDetail {
+	var projectOrder []string
+	byProject := make(map[string][]sessionDetail)
+	for _, sess := range sessions {
+		if _, ok := byProject[sess.ProjectName]; !ok {
+			projectOrder = append(projectOrder, sess.ProjectName)
+		}
+		byProject[sess.ProjectName] = append(byProject[sess.ProjectName], sess)
+	}
+
+	grouped := make([]sessionDetail, 0, len(sessions))
+	for _, projectName := range projectOrder {
+		grouped = append(grouped, byProject[projectName]...)
+	}
+	return grouped
+}
+
+// outputSummary returns the line of the output of a sesh command shown in the status of the
+// dashboard: the error it failed with, or the last line it output
+func outputSummary(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines {
+		if msg, ok := strings.CutPrefix(line, "Error: "); ok {
+			return msg
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
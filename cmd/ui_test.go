@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGroupSessionsByProject(t *testing.T) {
+	sessions := []sessionDetail{
+		{ProjectName: "web", Branch: "pinned", Pinned: true},
+		{ProjectName: "api", Branch: "main"},
+		{ProjectName: "web", Branch: "main"},
+		{ProjectName: "api", Branch: "feature"},
+	}
+
+	var got []string
+	for _, sess := range groupSessionsByProject(sessions) {
+		got = append(got, sess.ProjectName+" "+sess.Branch)
+	}
+	want := []string{"web pinned", "web main", "api main", "api feature"}
+	if !slices.Equal(got, want) {
+		t.Errorf("groupSessionsByProject() = %v, want %v", got, want)
+	}
+}
+
+func TestUIModelKeys(t *testing.T) {
+	m := &uiModel{sessions: []sessionDetail{
+		{ProjectName: "api", Branch: "main"},
+		{ProjectName: "api", Branch: "feature"},
+	}}
+	press := func(key string) tea.Cmd {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return m.handleKey(msg)
+	}
+
+	press("j")
+	press("j")
+	if m.cursor != 1 {
+		t.Errorf("cursor after moving past the last worktree = %d, want 1", m.cursor)
+	}
+	press("k")
+	if m.cursor != 0 {
+		t.Errorf("cursor after moving up = %d, want 0", m.cursor)
+	}
+
+	press("n")
+	press("fix")
+	press("q")
+	if !m.creating || m.input != "fixq" {
+		t.Errorf("typing a branch: creating = %v, input = %q, want true, %q", m.creating, m.input, "fixq")
+	}
+	press("esc")
+	if m.creating {
+		t.Error("esc didn't cancel creating a worktree")
+	}
+
+	var ran []string
+	run := uiRun
+	t.Cleanup(func() { uiRun = run })
+	uiRun = func(args ...string) tea.Cmd {
+		ran = args
+		return func() tea.Msg { return nil }
+	}
+
+	press("d")
+	if cmd := press("n"); cmd != nil || m.confirming || ran != nil {
+		t.Error("declining the deletion still deleted the worktree")
+	}
+	press("d")
+	if cmd := press("y"); cmd == nil {
+		t.Error("confirming the deletion didn't delete the worktree")
+	}
+	if want := []string{"delete", "main", "-p", "api", "--force"}; !slices.Equal(ran, want) {
+		t.Errorf("confirming the deletion ran sesh %v, want sesh %v", ran, want)
+	}
+}
+
+func TestUIModelBackspace(t *testing.T) {
+	m := &uiModel{sessions: []sessionDetail{{ProjectName: "api", Branch: "main"}}}
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("fix-ümlaut")})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	m.handleKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.input != "fix-üm" {
+		t.Errorf("input after deleting 4 characters = %q, want %q", m.input, "fix-üm")
+	}
+}
+
+func TestUISwitchArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		sess sessionDetail
+		want []string
+	}{
+		{
+			name: "worktree",
+			sess: sessionDetail{ProjectName: "api", Branch: "main"},
+			want: []string{"switch", "main", "-p", "api"},
+		},
+		{
+			name: "sub-project",
+			sess: sessionDetail{ProjectName: "mono", Branch: "main", Subproject: "web"},
+			want: []string{"switch", "main", "-p", "mono", "--subproject", "web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uiSwitchArgs(tt.sess); !slices.Equal(got, tt.want) {
+				t.Errorf("uiSwitchArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{name: "last line", output: "Fetching api...\nSuccessfully fetched api\n", want: "Successfully fetched api"},
+		{
+			name:   "error",
+			output: "Error: failed to resolve project: no project found\nUsage:\n  sesh switch [flags]\n\nexit status 1\n",
+			want:   "failed to resolve project: no project found",
+		},
+		{name: "no output", output: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputSummary(tt.output); got != tt.want {
+				t.Errorf("outputSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/tty"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+	"github.com/spf13/cobra"
+)
+
+var verifyFix bool
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the workspace for leftover directories, worktrees, and sessions",
+	Long: `Check that the projects, worktrees, and sessions of the workspace fit together.
+
+The problems found are:
+  - directories among the worktrees of a project that aren't worktrees
+  - worktrees whose branch no longer exists
+  - worktrees whose branch was renamed, e.g. with 'git branch -m'
+  - projects without any worktree
+  - sessions whose worktree no longer exists
+
+In a terminal, each problem that can be fixed is offered to be fixed. With
+--fix, all of them are fixed without asking: leftover directories are
+deleted, deleted branches are recreated at the commit last checked out in
+their worktree, the directories and sessions of renamed branches are renamed
+to match, a worktree is created for the default branch of projects
+without one, and sessions are killed.
+
+Examples:
+  sesh verify          # Report problems, and offer to fix each one
+  sesh verify --fix    # Fix all problems without asking`,
+	Args: cobra.NoArgs,
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifyFix, "fix", false, "Fix all problems without asking")
+}
+
+// verifyProblem is something wrong with a project that sesh verify found
+type verifyProblem struct {
+	project     *models.Project
+	description string
+	// fixDescription says what fix does, e.g. "delete the directory"
+	fixDescription string
+	// fix fixes the problem, or is nil when sesh can't
+	fix func() error
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	disp := display.NewStderr()
+	out := display.NewStdout()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return eris.Wrap(err, "failed to load configuration")
+	}
+
+	sessionMgr, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	projects, err := state.DiscoverProjects(cfg.WorkspaceDir)
+	if err != nil {
+		return eris.Wrap(err, "failed to discover projects")
+	}
+
+	problems := findVerifyProblems(cmd.Context(), cfg, projects, sessionMgr, disp)
+	if len(problems) == 0 {
+		disp.Println("No problems found.")
+		return nil
+	}
+
+	// Without --fix, each fix is confirmed in a terminal, and nothing is fixed elsewhere
+	ask := !verifyFix && tty.IsInteractive()
+	reader := bufio.NewReader(os.Stdin)
+
+	fixed := 0
+	for _, problem := range problems {
+		out.Printf("%s %s: %s\n", out.ErrorText("✗"), out.Bold(problem.project.Name), problem.description)
+		if problem.fix == nil || (!verifyFix && !ask) {
+			continue
+		}
+
+		if ask {
+			disp.Printf("  Fix: %s? (yes/no): ", problem.fixDescription)
+			response, err := reader.ReadString('\n')
+			if err != nil {
+				return eris.Wrap(err, "failed to read confirmation")
+			}
+			response = strings.TrimSpace(strings.ToLower(response))
+			if response != "yes" && response != "y" {
+				continue
+			}
+		}
+
+		if err := problem.fix(); err != nil {
+			disp.Warningf("Failed to %s: %v", problem.fixDescription, err)
+			continue
+		}
+		disp.Printf("  %s %s\n", disp.SuccessText("Fixed:"), problem.fixDescription)
+		fixed++
+	}
+
+	switch {
+	case fixed > 0:
+		disp.Successf("Fixed %d of %d problem(s)", fixed, len(problems))
+	case !ask && !verifyFix:
+		disp.Printf("Found %d problem(s). Run 'sesh verify --fix' to fix them.\n", len(problems))
+	default:
+		disp.Printf("Found %d problem(s).\n", len(problems))
+	}
+	return nil
+}
+
+// findVerifyProblems checks each project for the problems sesh verify reports
+func findVerifyProblems(
+	ctx context.Context,
+	cfg *config.Config,
+	projects []*models.Project,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) []verifyProblem {
+	// Directories that hold other projects aren't leftovers, e.g. github.com/user/repo/sub.git
+	projectDirs := map[string]bool{}
+	for _, proj := range projects {
+		workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+		for dir := filepath.Dir(proj.LocalPath); len(dir) > len(workspaceDir); dir = filepath.Dir(dir) {
+			projectDirs[dir] = true
+		}
+	}
+
+	var problems []verifyProblem
+	for _, proj := range projects {
+		worktrees, err := state.DiscoverWorktrees(proj)
+		if err != nil {
+			disp.Warningf("Failed to check %s: %v", proj.Name, err)
+			continue
+		}
+
+		problems = append(problems, verifyLeftoverDirs(proj, worktrees, projectDirs)...)
+		problems = append(problems, verifyWorktreeBranches(cfg, proj, worktrees, disp)...)
+		problems = append(problems, verifyRenamedBranches(cfg, proj, worktrees, sessionMgr, disp)...)
+
+		// The first worktree is the bare repository itself
+		if len(worktrees) <= 1 {
+			problems = append(problems, verifyProblem{
+				project:        proj,
+				description:    "the project has no worktrees",
+				fixDescription: "create a worktree for the default branch",
+				fix: func() error {
+					return createDefaultWorktree(ctx, cfg, proj, disp)
+				},
+			})
+		}
+
+		sessions, err := findOrphanedSessions(proj, sessionMgr)
+		if err != nil {
+			disp.Warningf("Failed to check the sessions of %s: %v", proj.Name, err)
+		}
+		for _, sessionName := range sessions {
+			problems = append(problems, verifyProblem{
+				project:        proj,
+				description:    fmt.Sprintf("the worktree of session %s no longer exists", sessionName),
+				fixDescription: "kill the session",
+				fix: func() error {
+					err := killSession(sessionMgr, sessionName)
+					recordOperation("kill-orphaned-session", proj.Name, sessionName, err)
+					return err
+				},
+			})
+		}
+	}
+	return problems
+}
+
+// verifyLeftoverDirs finds directories among the worktrees of a project that aren't worktrees,
+// e.g. what is left after a worktree was removed while a program had files open in it
+func verifyLeftoverDirs(proj *models.Project, worktrees []*models.Worktree, projectDirs map[string]bool) []verifyProblem {
+	workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	parent := workspace.GetWorktreeParentPath(workspaceDir, proj.Name)
+	if parent == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return nil
+	}
+
+	// git reports worktree paths with symlinks resolved
+	registered := map[string]bool{}
+	for _, wt := range worktrees {
+		registered[filepath.Clean(wt.Path)] = true
+	}
+
+	var problems []verifyProblem
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(parent, entry.Name())
+		if projectDirs[path] || registered[path] {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && registered[resolved] {
+			continue
+		}
+
+		// Worktrees whose links are broken are fixed by sesh repair instead
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+			problems = append(problems, verifyProblem{
+				project:     proj,
+				description: fmt.Sprintf("%s isn't linked to the repository (run 'sesh repair')", path),
+			})
+			continue
+		}
+
+		problems = append(problems, verifyProblem{
+			project:        proj,
+			description:    fmt.Sprintf("%s isn't a worktree", path),
+			fixDescription: "delete " + path,
+			fix: func() error {
+				return os.RemoveAll(path)
+			},
+		})
+	}
+	return problems
+}
+
+// verifyWorktreeBranches finds worktrees whose branch was deleted while it was checked out
+func verifyWorktreeBranches(
+	cfg *config.Config,
+	proj *models.Project,
+	worktrees []*models.Worktree,
+	disp display.Printer,
+) []verifyProblem {
+	branches, err := git.ListLocalBranches(proj.LocalPath)
+	if err != nil {
+		disp.Warningf("Failed to check the branches of %s: %v", proj.Name, err)
+		return nil
+	}
+	exists := map[string]bool{}
+	for _, branch := range branches {
+		exists[branch] = true
+	}
+
+	var problems []verifyProblem
+	for _, wt := range worktrees {
+		if wt.IsMain || wt.Branch == "" || wt.Branch == "(detached)" || exists[wt.Branch] {
+			continue
+		}
+
+		problem := verifyProblem{
+			project:     proj,
+			description: fmt.Sprintf("branch %s of worktree %s no longer exists", wt.Branch, wt.Path),
+		}
+		// The commit is only known from the worktree's history
+		if commit, err := git.LastHeadCommit(wt.Path); err == nil {
+			problem.fixDescription = fmt.Sprintf("recreate branch %s at %s", wt.Branch, commit[:min(len(commit), 7)])
+			problem.fix = func() error {
+				unlock, err := lockProject(cfg, proj.Name, disp)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+
+				err = git.CreateBranch(proj.LocalPath, wt.Branch, commit)
+				recordOperation("create-branch", proj.Name, wt.Branch, err)
+				return err
+			}
+		} else {
+			problem.description += " (delete the worktree with 'sesh delete')"
+		}
+		problems = append(problems, problem)
+	}
+	return problems
+}
+
+// verifyRenamedBranches finds worktrees whose branch was renamed after sesh created them, so
+// their directory and session still have the old name
+func verifyRenamedBranches(
+	cfg *config.Config,
+	proj *models.Project,
+	worktrees []*models.Worktree,
+	sessionMgr session.SessionManager,
+	disp display.Printer,
+) []verifyProblem {
+	var problems []verifyProblem
+	for _, wt := range worktrees {
+		oldName := renamedFrom(proj, wt)
+		if oldName == "" {
+			continue
+		}
+		problems = append(problems, verifyProblem{
+			project:        proj,
+			description:    fmt.Sprintf("branch %s of worktree %s was renamed from %s", wt.Branch, wt.Path, oldName),
+			fixDescription: "rename the worktree directory and session to " + workspace.SanitizeBranchName(wt.Branch),
+			fix: func() error {
+				unlock, err := lockProject(cfg, proj.Name, disp)
+				if err != nil {
+					return err
+				}
+				defer unlock()
+
+				return followBranchRename(cfg, proj, wt, oldName, sessionMgr, disp)
+			},
+		})
+	}
+	return problems
+}
+
+// createDefaultWorktree creates a worktree for the default branch of a project, like sesh clone
+func createDefaultWorktree(ctx context.Context, cfg *config.Config, proj *models.Project, disp display.Printer) error {
+	unlock, err := lockProject(cfg, proj.Name, disp)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	defaultBranch, err := git.GetDefaultBranch(proj.LocalPath)
+	if err != nil {
+		return eris.Wrap(err, "failed to get default branch")
+	}
+
+	workspaceDir := workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name)
+	worktreePath := workspace.ProjectWorktreePath(workspaceDir, proj.Name, defaultBranch)
+	err = git.CreateWorktree(ctx, proj.LocalPath, cfg.Remote.For(proj.Name), defaultBranch, worktreePath)
+	recordOperation("create-worktree", proj.Name, defaultBranch, err)
+	if err != nil {
+		return eris.Wrap(err, "failed to create worktree")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+func TestVerifyLeftoverDirs(t *testing.T) {
+	ws := t.TempDir()
+	proj := &models.Project{Name: "github.com/user/repo", LocalPath: filepath.Join(ws, "github.com/user/repo.git")}
+	parent := filepath.Join(ws, "github.com/user/repo")
+
+	for _, dir := range []string{"main", "leftover", "unlinked", ".hidden", "sub"} {
+		if err := os.MkdirAll(filepath.Join(parent, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(parent, "unlinked", ".git"), []byte("gitdir: /gone\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(parent, "file.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	worktrees := []*models.Worktree{
+		{Path: proj.LocalPath, IsMain: true},
+		{Branch: "main", Path: filepath.Join(parent, "main")},
+	}
+	// sub holds the bare repository of github.com/user/repo/sub
+	projectDirs := map[string]bool{filepath.Join(parent, "sub"): true}
+
+	problems := verifyLeftoverDirs(proj, worktrees, projectDirs)
+	fixable := map[string]bool{}
+	for _, problem := range problems {
+		fixable[problem.description] = problem.fix != nil
+	}
+	want := map[string]bool{
+		filepath.Join(parent, "leftover") + " isn't a worktree":                                   true,
+		filepath.Join(parent, "unlinked") + " isn't linked to the repository (run 'sesh repair')": false,
+	}
+	if len(fixable) != len(want) {
+		t.Fatalf("verifyLeftoverDirs() found %v, want %v", fixable, want)
+	}
+	for description, canFix := range want {
+		if got, ok := fixable[description]; !ok || got != canFix {
+			t.Errorf("verifyLeftoverDirs() problem %q: found %v, fixable %v; want fixable %v", description, ok, got, canFix)
+		}
+	}
+
+	for _, problem := range problems {
+		if problem.fix != nil {
+			if err := problem.fix(); err != nil {
+				t.Fatalf("fix() returned error: %v", err)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(parent, "leftover")); !os.IsNotExist(err) {
+		t.Errorf("leftover directory still exists after the fix: %v", err)
+	}
+}
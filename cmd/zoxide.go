@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/zoxide"
+)
+
+// zoxideRemove removes a worktree from zoxide when the integration is enabled
+func zoxideRemove(cfg *config.Config, path string, disp display.Printer) {
+	if !cfg.Zoxide || !zoxide.IsInstalled() {
+		return
+	}
+	if err := zoxide.Remove(path); err != nil {
+		disp.Warning(err.Error())
+	}
+}
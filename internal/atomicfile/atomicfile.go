@@ -0,0 +1,38 @@
+// Package atomicfile replaces files so concurrent readers see either the old or the new
+// contents, never a partly written file
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/rotisserie/eris"
+)
+
+// WriteFile replaces the file at path with data, by writing it to a temporary file in the same
+// directory and renaming that over it. The temporary file has a unique name, so writers running
+// at the same time don't write into each other's file; the last rename wins.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return eris.Wrapf(err, "failed to create temporary file for: %s", path)
+	}
+	//nolint:errcheck // Fails once the file has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		//nolint:errcheck // Close in error path
+		tmp.Close()
+		return eris.Wrapf(err, "failed to write temporary file for: %s", path)
+	}
+	if err := tmp.Close(); err != nil {
+		return eris.Wrapf(err, "failed to write temporary file for: %s", path)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return eris.Wrapf(err, "failed to set permissions of temporary file for: %s", path)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return eris.Wrapf(err, "failed to replace file: %s", path)
+	}
+	return nil
+}
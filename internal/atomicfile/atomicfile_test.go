@@ -0,0 +1,40 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatalf("WriteFile(%q) failed: %v", data, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil || string(got) != data {
+			t.Errorf("ReadFile() = %q, %v, want %q", got, err, data)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("permissions = %v, want %v", perm, os.FileMode(0o600))
+	}
+
+	// The temporary files are renamed away, so only the file itself is left
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("ReadDir() = %v, %v, want only cache.json", entries, err)
+	}
+
+	if err := WriteFile(filepath.Join(dir, "missing", "cache.json"), []byte("data"), 0o644); err == nil {
+		t.Error("WriteFile() into a missing directory returned no error")
+	}
+}
@@ -0,0 +1,138 @@
+// Package browse builds the web URLs of repositories, branches, and pull requests from git
+// remote URLs, and opens them in the default browser
+package browse
+
+import (
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// Host is the kind of web interface a remote is hosted on
+type Host string
+
+const (
+	HostGitHub    Host = "github"
+	HostGitLab    Host = "gitlab"
+	HostBitbucket Host = "bitbucket"
+)
+
+// DetectHost detects the web interface of a host name
+// Self-hosted GitLab and Bitbucket are recognized by their name; anything else is
+// assumed to be GitHub or GitHub Enterprise.
+func DetectHost(hostname string) Host {
+	switch {
+	case strings.Contains(hostname, "gitlab"):
+		return HostGitLab
+	case strings.Contains(hostname, "bitbucket"):
+		return HostBitbucket
+	default:
+		return HostGitHub
+	}
+}
+
+// Repo is the web location of a repository
+type Repo struct {
+	// URL is the repository's home page, e.g. https://github.com/user/repo
+	URL  string
+	Host Host
+}
+
+// ParseRemote derives the web location of a repository from its remote URL
+// SSH remotes (ssh:// and git@host:path) are served over HTTPS without the SSH port.
+// Examples:
+//   - git@github.com:user/repo.git -> https://github.com/user/repo
+//   - ssh://git@example.com:2222/user/repo.git -> https://example.com/user/repo
+//   - http://example.com:8080/user/repo.git -> http://example.com:8080/user/repo
+func ParseRemote(remoteURL string) (*Repo, error) {
+	scheme, host, hostname, path := "https", "", "", ""
+
+	switch {
+	case strings.Contains(remoteURL, "://"):
+		parsedURL, err := url.Parse(remoteURL)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to parse remote URL")
+		}
+		if parsedURL.Scheme == "file" {
+			return nil, eris.Errorf("local remote has no web page: %s", remoteURL)
+		}
+		hostname = parsedURL.Hostname()
+		host = parsedURL.Host
+		if parsedURL.Scheme == "http" || parsedURL.Scheme == "https" {
+			scheme = parsedURL.Scheme
+		} else {
+			host = hostname
+		}
+		path = parsedURL.Path
+	case strings.Contains(remoteURL, ":"):
+		// SCP-style SSH URL (git@host:path)
+		parts := strings.SplitN(remoteURL, ":", 2)
+		hostname = parts[0][strings.LastIndex(parts[0], "@")+1:]
+		host = hostname
+		path = parts[1]
+	default:
+		return nil, eris.Errorf("unsupported remote URL: %s", remoteURL)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if hostname == "" || !strings.Contains(path, "/") {
+		return nil, eris.Errorf("invalid remote URL: %s", remoteURL)
+	}
+
+	return &Repo{URL: scheme + "://" + host + "/" + path, Host: DetectHost(hostname)}, nil
+}
+
+// BranchURL returns the URL of a branch's files
+func (r *Repo) BranchURL(branch string) string {
+	switch r.Host {
+	case HostGitLab:
+		return r.URL + "/-/tree/" + escapeBranch(branch)
+	case HostBitbucket:
+		return r.URL + "/src/" + escapeBranch(branch)
+	default:
+		return r.URL + "/tree/" + escapeBranch(branch)
+	}
+}
+
+// NewPullRequestURL returns the URL of the page that opens a pull request for a branch
+func (r *Repo) NewPullRequestURL(branch string) string {
+	switch r.Host {
+	case HostGitLab:
+		return r.URL + "/-/merge_requests/new?" + url.Values{"merge_request[source_branch]": {branch}}.Encode()
+	case HostBitbucket:
+		return r.URL + "/pull-requests/new?" + url.Values{"source": {branch}}.Encode()
+	default:
+		return r.URL + "/compare/" + escapeBranch(branch) + "?expand=1"
+	}
+}
+
+// escapeBranch escapes a branch name for a URL path, keeping the slashes between its parts
+func escapeBranch(branch string) string {
+	parts := strings.Split(branch, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Open opens a URL in the default browser
+func Open(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		cmd = exec.Command("xdg-open", target)
+	}
+
+	// The openers hand the URL to the browser and exit without waiting for it
+	if err := cmd.Run(); err != nil {
+		return eris.Wrapf(err, "failed to open browser (%s)", cmd.Args[0])
+	}
+	return nil
+}
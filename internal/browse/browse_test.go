@@ -0,0 +1,127 @@
+package browse
+
+import (
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		wantURL   string
+		wantHost  Host
+		wantErr   bool
+	}{
+		{
+			name:      "SCP-style SSH URL",
+			remoteURL: "git@github.com:user/repo.git",
+			wantURL:   "https://github.com/user/repo",
+			wantHost:  HostGitHub,
+		},
+		{
+			name:      "SSH URL with port",
+			remoteURL: "ssh://git@example.com:2222/user/repo.git",
+			wantURL:   "https://example.com/user/repo",
+			wantHost:  HostGitHub,
+		},
+		{
+			name:      "HTTPS URL with credentials",
+			remoteURL: "https://token@github.com/user/repo.git",
+			wantURL:   "https://github.com/user/repo",
+			wantHost:  HostGitHub,
+		},
+		{
+			name:      "HTTP URL with port",
+			remoteURL: "http://example.com:8080/user/repo",
+			wantURL:   "http://example.com:8080/user/repo",
+			wantHost:  HostGitHub,
+		},
+		{
+			name:      "GitLab subgroup",
+			remoteURL: "git@gitlab.com:org/subgroup/project.git",
+			wantURL:   "https://gitlab.com/org/subgroup/project",
+			wantHost:  HostGitLab,
+		},
+		{
+			name:      "self-hosted GitLab",
+			remoteURL: "https://gitlab.example.com/org/project.git",
+			wantURL:   "https://gitlab.example.com/org/project",
+			wantHost:  HostGitLab,
+		},
+		{
+			name:      "Bitbucket",
+			remoteURL: "git@bitbucket.org:team/repo.git",
+			wantURL:   "https://bitbucket.org/team/repo",
+			wantHost:  HostBitbucket,
+		},
+		{
+			name:      "local remote",
+			remoteURL: "file:///srv/git/repo.git",
+			wantErr:   true,
+		},
+		{
+			name:      "missing organization",
+			remoteURL: "https://github.com/repo.git",
+			wantErr:   true,
+		},
+		{
+			name:      "not a URL",
+			remoteURL: "repo",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := ParseRemote(tt.remoteURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRemote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if repo.URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", repo.URL, tt.wantURL)
+			}
+			if repo.Host != tt.wantHost {
+				t.Errorf("Host = %q, want %q", repo.Host, tt.wantHost)
+			}
+		})
+	}
+}
+
+func TestRepoURLs(t *testing.T) {
+	tests := []struct {
+		host       Host
+		wantBranch string
+		wantNewPR  string
+	}{
+		{
+			host:       HostGitHub,
+			wantBranch: "https://example.com/o/r/tree/feature/a%20b",
+			wantNewPR:  "https://example.com/o/r/compare/feature/a%20b?expand=1",
+		},
+		{
+			host:       HostGitLab,
+			wantBranch: "https://example.com/o/r/-/tree/feature/a%20b",
+			wantNewPR:  "https://example.com/o/r/-/merge_requests/new?merge_request%5Bsource_branch%5D=feature%2Fa+b",
+		},
+		{
+			host:       HostBitbucket,
+			wantBranch: "https://example.com/o/r/src/feature/a%20b",
+			wantNewPR:  "https://example.com/o/r/pull-requests/new?source=feature%2Fa+b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.host), func(t *testing.T) {
+			repo := &Repo{URL: "https://example.com/o/r", Host: tt.host}
+			if got := repo.BranchURL("feature/a b"); got != tt.wantBranch {
+				t.Errorf("BranchURL() = %q, want %q", got, tt.wantBranch)
+			}
+			if got := repo.NewPullRequestURL("feature/a b"); got != tt.wantNewPR {
+				t.Errorf("NewPullRequestURL() = %q, want %q", got, tt.wantNewPR)
+			}
+		})
+	}
+}
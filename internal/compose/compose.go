@@ -0,0 +1,133 @@
+// Package compose runs the Docker Compose services of worktrees, each worktree under a compose
+// project of its own so the services of different branches don't collide
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// invalidProjectChars matches what compose doesn't allow in project names
+var invalidProjectChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// ServiceStatus is the state of a service of a compose project
+type ServiceStatus struct {
+	Name string `json:"name"`
+	// State is compose's state of the service's container, e.g. "running" or "exited"
+	State string `json:"state"`
+	// ExitCode is what the container exited with, once it isn't running
+	ExitCode int `json:"exit_code,omitempty"`
+}
+
+// Running reports whether the container of the service is running
+func (s ServiceStatus) Running() bool {
+	return s.State == "running"
+}
+
+// ProjectName returns the compose project name of a worktree's services, from the name of its
+// session, e.g. "api-feature-login" for the session "api-feature/login"
+func ProjectName(sessionName string) string {
+	name := invalidProjectChars.ReplaceAllString(strings.ToLower(sessionName), "-")
+	return strings.TrimLeft(name, "-_")
+}
+
+// Up creates and starts the services of a compose file in the background, under the given
+// project name. env is added to the environment compose interpolates the file with.
+func Up(ctx context.Context, worktreePath, file, project string, env map[string]string) error {
+	cmd, err := command(ctx, "-f", file, "-p", project, "up", "-d")
+	if err != nil {
+		return err
+	}
+	cmd.Dir = worktreePath
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return eris.Wrapf(err, "failed to start compose services: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Down stops and removes the containers and networks of a compose project. It doesn't need
+// the compose file, so it also works once the worktree is gone.
+func Down(ctx context.Context, project string) error {
+	cmd, err := command(ctx, "-p", project, "down")
+	if err != nil {
+		return err
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return eris.Wrapf(err, "failed to stop compose services: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Status returns the state of the services of a compose project, including stopped ones
+func Status(ctx context.Context, project string) ([]ServiceStatus, error) {
+	cmd, err := command(ctx, "-p", project, "ps", "--all", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list compose services")
+	}
+	return parseStatus(output)
+}
+
+// command returns the compose command with the given arguments: the compose plugin of docker,
+// or the standalone docker-compose
+func command(ctx context.Context, args ...string) (*exec.Cmd, error) {
+	if _, err := exec.LookPath("docker"); err == nil {
+		return exec.CommandContext(ctx, "docker", append([]string{"compose"}, args...)...), nil
+	}
+	if _, err := exec.LookPath("docker-compose"); err == nil {
+		return exec.CommandContext(ctx, "docker-compose", args...), nil
+	}
+	return nil, eris.New("docker compose not found. Install Docker from https://docs.docker.com/get-docker/")
+}
+
+// parseStatus parses the output of 'compose ps --format json': a JSON object per line, or a
+// JSON array with older versions of compose
+func parseStatus(output []byte) ([]ServiceStatus, error) {
+	type container struct {
+		Service  string `json:"Service"`
+		State    string `json:"State"`
+		ExitCode int    `json:"ExitCode"`
+	}
+
+	var containers []container
+	trimmed := strings.TrimSpace(string(output))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal([]byte(trimmed), &containers); err != nil {
+			return nil, eris.Wrap(err, "failed to parse compose output")
+		}
+	} else {
+		for _, line := range strings.Split(trimmed, "\n") {
+			if line == "" {
+				continue
+			}
+			var c container
+			if err := json.Unmarshal([]byte(line), &c); err != nil {
+				return nil, eris.Wrap(err, "failed to parse compose output")
+			}
+			containers = append(containers, c)
+		}
+	}
+
+	statuses := make([]ServiceStatus, 0, len(containers))
+	for _, c := range containers {
+		status := ServiceStatus{Name: c.Service, State: c.State}
+		if !status.Running() {
+			status.ExitCode = c.ExitCode
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
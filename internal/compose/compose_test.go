@@ -0,0 +1,62 @@
+package compose
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		sessionName string
+		want        string
+	}{
+		{sessionName: "api-main", want: "api-main"},
+		{sessionName: "API-feature/Login", want: "api-feature-login"},
+		{sessionName: ".dotfiles-main", want: "dotfiles-main"},
+		{sessionName: "acme-api-fix_452", want: "acme-api-fix_452"},
+	}
+
+	for _, tt := range tests {
+		if got := ProjectName(tt.sessionName); got != tt.want {
+			t.Errorf("ProjectName(%q) = %q, want %q", tt.sessionName, got, tt.want)
+		}
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	want := []ServiceStatus{
+		{Name: "db", State: "running"},
+		{Name: "migrate", State: "exited", ExitCode: 1},
+	}
+
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name: "object per line",
+			output: `{"Service":"db","State":"running","ExitCode":0}` + "\n" +
+				`{"Service":"migrate","State":"exited","ExitCode":1}` + "\n",
+		},
+		{
+			name:   "array",
+			output: `[{"Service":"db","State":"running"},{"Service":"migrate","State":"exited","ExitCode":1}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatus([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("parseStatus() error = %v", err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("parseStatus() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	if got, err := parseStatus([]byte("\n")); err != nil || len(got) != 0 {
+		t.Errorf("parseStatus() of no services = %v, %v, want none", got, err)
+	}
+}
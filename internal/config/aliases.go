@@ -0,0 +1,41 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rotisserie/eris"
+)
+
+// GetProjectAliases returns the aliases of projects, e.g. "api" for
+// "github.com/acme/platform-api", by alias
+func GetProjectAliases() (map[string]string, error) {
+	// Without a config file, there are no aliases
+	config, err := loadConfigFile()
+	if err != nil {
+		return nil, nil
+	}
+	if err := validateProjectAliases(config.ProjectAliases); err != nil {
+		return nil, err
+	}
+	return config.ProjectAliases, nil
+}
+
+// validateProjectAliases checks that aliases can't be taken for full project names, and that
+// each names a project
+func validateProjectAliases(aliases map[string]string) error {
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		key := "project_aliases." + alias
+		if alias == "" || strings.Contains(alias, "/") || strings.ContainsFunc(alias, unicode.IsSpace) {
+			return &FieldError{Key: key, Err: eris.Errorf(
+				"invalid project alias: %q (must be a single word without '/')", alias,
+			)}
+		}
+		if strings.TrimSpace(aliases[alias]) == "" {
+			return &FieldError{Key: key, Err: eris.Errorf("project alias %s names no project", alias)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// branchTypePattern matches the types of new branches, which become part of their names
+var branchTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// GetBranchTemplate returns how 'sesh new' names branches, with configuration hierarchy.
+// An empty template uses the name given to 'sesh new' as it is.
+func GetBranchTemplate() (string, error) {
+	template := ""
+
+	// 1. Environment variable (highest priority)
+	if envTemplate := os.Getenv("SESH_BRANCH_TEMPLATE"); envTemplate != "" {
+		template = envTemplate
+	} else if config, err := loadConfigFile(); err == nil {
+		// 2. Config file
+		template = config.BranchTemplate
+	}
+
+	if template == "" {
+		return "", nil
+	}
+	if _, err := workspace.ParseBranchTemplate(template); err != nil {
+		return "", eris.Wrapf(err, "invalid branch_template: %s", template)
+	}
+	return template, nil
+}
+
+// GetBranchTypes returns the types 'sesh new' offers for new branches, with configuration
+// hierarchy
+func GetBranchTypes() ([]string, error) {
+	// 1. Environment variable (highest priority)
+	if envTypes := os.Getenv("SESH_BRANCH_TYPES"); envTypes != "" {
+		types := strings.Split(envTypes, ",")
+		if err := validateBranchTypes(types); err != nil {
+			return nil, eris.Wrapf(err, "invalid SESH_BRANCH_TYPES: %s", envTypes)
+		}
+		return types, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && len(config.BranchTypes) > 0 {
+		if err := validateBranchTypes(config.BranchTypes); err != nil {
+			return nil, err
+		}
+		return config.BranchTypes, nil
+	}
+
+	// 3. Default (lowest priority)
+	return slices.Clone(workspace.DefaultBranchTypes), nil
+}
+
+// validateBranchTemplate checks the template of new branch names
+func validateBranchTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	if _, err := workspace.ParseBranchTemplate(template); err != nil {
+		return &FieldError{Key: "branch_template", Err: eris.Wrap(err, "invalid branch_template")}
+	}
+	return nil
+}
+
+// validateBranchTypes checks the types of new branches
+func validateBranchTypes(types []string) error {
+	for _, branchType := range types {
+		if !branchTypePattern.MatchString(branchType) {
+			return &FieldError{
+				Key: "branch_types",
+				Err: eris.Errorf(
+					"invalid branch type: %q (must be lowercase letters, digits, '.', '_', or '-')",
+					branchType,
+				),
+			}
+		}
+	}
+	return nil
+}
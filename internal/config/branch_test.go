@@ -0,0 +1,43 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+func TestGetBranchTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "default", want: workspace.DefaultBranchTypes},
+		{name: "config file", file: "branch_types: [feature, bugfix]\n", want: []string{"feature", "bugfix"}},
+		{name: "environment wins", file: "branch_types: [feature]\n", env: "feat,fix", want: []string{"feat", "fix"}},
+		{name: "invalid config file", file: "branch_types: [Feature]\n", wantErr: true},
+		{name: "invalid environment", env: "feat,with/slash", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("SESH_BRANCH_TYPES", tt.env)
+			if tt.file != "" {
+				writeConfigFile(t, tt.file)
+			}
+
+			got, err := GetBranchTypes()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetBranchTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
+				t.Errorf("GetBranchTypes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/rotisserie/eris"
+)
+
+// CloneOptions make new clones shallow or partial, for repositories too large to clone in full
+type CloneOptions struct {
+	// Number of commits of history to clone; 0 clones all of it
+	Depth int `yaml:"depth,omitempty"`
+	// Objects to leave out until they are needed, e.g. "blob:none"
+	Filter string `yaml:"filter,omitempty"`
+}
+
+// CloneConfig holds the default clone options, and those of projects matching a pattern
+type CloneConfig struct {
+	Depth  int    `yaml:"depth,omitempty"`
+	Filter string `yaml:"filter,omitempty"`
+	// Options of the projects matching a pattern like "github.com/bigcorp/*", which replace
+	// the defaults they set. When several patterns match, the longest one wins.
+	Projects map[string]CloneOptions `yaml:"projects,omitempty"`
+	// Directories searched for an existing clone of a project, whose objects a new clone reuses
+	ReferenceDirs []string `yaml:"reference_dirs,omitempty"`
+}
+
+// cloneFilterPattern matches the object filters git accepts
+var cloneFilterPattern = regexp.MustCompile(`^(blob:none|blob:limit=\d+[kmg]?|tree:\d+|object:type=(blob|tree|commit|tag)|sparse:oid=\S+|combine:\S+)$`)
+
+// GetCloneConfig returns the clone options from the config file
+func GetCloneConfig() (CloneConfig, error) {
+	// 1. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		if err := validateClone(config.Clone); err != nil {
+			return CloneConfig{}, err
+		}
+		return config.Clone, nil
+	}
+
+	// 2. Default (lowest priority)
+	return CloneConfig{}, nil
+}
+
+// For returns the clone options of a project, with those of the longest matching pattern
+// replacing the defaults they set
+func (c CloneConfig) For(projectName string) CloneOptions {
+	opts := CloneOptions{Depth: c.Depth, Filter: c.Filter}
+
+	best := longestMatch(c.Projects, projectName)
+	if best == "" {
+		return opts
+	}
+
+	if override := c.Projects[best]; override.Depth > 0 {
+		opts.Depth = override.Depth
+	}
+	if override := c.Projects[best]; override.Filter != "" {
+		opts.Filter = override.Filter
+	}
+	return opts
+}
+
+// longestMatch returns the longest of the patterns that matches a project name, or "" when none do
+func longestMatch[V any](patterns map[string]V, projectName string) string {
+	best := ""
+	for _, pattern := range slices.Sorted(maps.Keys(patterns)) {
+		if matched, _ := path.Match(pattern, projectName); matched && len(pattern) > len(best) {
+			best = pattern
+		}
+	}
+	return best
+}
+
+// ReferencePaths returns the directories searched for existing clones, with ~ expanded
+func (c CloneConfig) ReferencePaths() ([]string, error) {
+	paths := make([]string, 0, len(c.ReferenceDirs))
+	for _, dir := range c.ReferenceDirs {
+		path, err := expandHome(dir)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ValidateCloneFilter checks that a filter is one git accepts
+func ValidateCloneFilter(filter string) error {
+	if !cloneFilterPattern.MatchString(filter) {
+		return eris.Errorf(
+			"invalid filter: %s (must be like blob:none, blob:limit=1m, tree:0, or object:type=commit)", filter,
+		)
+	}
+	return nil
+}
+
+// validateClone checks the depths, filters, project patterns, and reference directories of
+// the clone options
+func validateClone(clone CloneConfig) error {
+	if _, err := clone.ReferencePaths(); err != nil {
+		return &FieldError{Key: "clone.reference_dirs", Err: eris.Wrap(err, "invalid clone.reference_dirs")}
+	}
+
+	options := map[string]CloneOptions{"clone": {Depth: clone.Depth, Filter: clone.Filter}}
+	for pattern, opts := range clone.Projects {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &FieldError{
+				Key: "clone.projects",
+				Err: eris.Errorf("invalid clone.projects pattern: %s", pattern),
+			}
+		}
+		options["clone.projects."+pattern] = opts
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(options)) {
+		opts := options[key]
+		if opts.Depth < 0 {
+			return &FieldError{
+				Key: key + ".depth",
+				Err: eris.Errorf("invalid %s.depth: %d (must be 0 or greater)", key, opts.Depth),
+			}
+		}
+		if opts.Filter != "" {
+			if err := ValidateCloneFilter(opts.Filter); err != nil {
+				return &FieldError{Key: key + ".filter", Err: eris.Wrapf(err, "invalid %s.filter", key)}
+			}
+		}
+	}
+	return nil
+}
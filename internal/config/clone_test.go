@@ -0,0 +1,72 @@
+package config
+
+import "testing"
+
+func TestCloneConfigFor(t *testing.T) {
+	clone := CloneConfig{
+		Filter: "blob:none",
+		Projects: map[string]CloneOptions{
+			"github.com/bigcorp/*":        {Depth: 50},
+			"github.com/bigcorp/monorepo": {Depth: 1, Filter: "tree:0"},
+		},
+	}
+
+	tests := []struct {
+		project string
+		want    CloneOptions
+	}{
+		{project: "github.com/user/repo", want: CloneOptions{Filter: "blob:none"}},
+		{project: "github.com/bigcorp/api", want: CloneOptions{Depth: 50, Filter: "blob:none"}},
+		{project: "github.com/bigcorp/monorepo", want: CloneOptions{Depth: 1, Filter: "tree:0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			if got := clone.For(tt.project); got != tt.want {
+				t.Errorf("For(%q) = %+v, want %+v", tt.project, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateClone(t *testing.T) {
+	tests := []struct {
+		name    string
+		clone   CloneConfig
+		wantKey string
+	}{
+		{name: "unset", clone: CloneConfig{}},
+		{name: "valid", clone: CloneConfig{Depth: 1, Filter: "blob:limit=1m"}},
+		{name: "negative depth", clone: CloneConfig{Depth: -1}, wantKey: "clone.depth"},
+		{name: "unknown filter", clone: CloneConfig{Filter: "blobs"}, wantKey: "clone.filter"},
+		{
+			name:    "project filter",
+			clone:   CloneConfig{Projects: map[string]CloneOptions{"github.com/*": {Filter: "none"}}},
+			wantKey: "clone.projects.github.com/*.filter",
+		},
+		{
+			name:    "bad pattern",
+			clone:   CloneConfig{Projects: map[string]CloneOptions{"github.com/[": {Depth: 1}}},
+			wantKey: "clone.projects",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateClone(tt.clone)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Errorf("validateClone() returned error: %v", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok {
+				t.Fatalf("validateClone() error = %v, want a *FieldError", err)
+			}
+			if fieldErr.Key != tt.wantKey {
+				t.Errorf("validateClone() error key = %q, want %q", fieldErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
@@ -1,39 +1,215 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
-
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/atomicfile"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/logging"
+	"github.com/benoctopus/sesh/internal/pr"
+	"github.com/benoctopus/sesh/internal/ready"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/workspace"
 	"github.com/rotisserie/eris"
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds the application configuration
 type Config struct {
-	WorkspaceDir   string `yaml:"workspace_dir"`
-	SessionBackend string `yaml:"session_backend"` // "tmux", "zellij", "screen", "auto", or editor backends like "code:open", "cursor:replace"
-	StartupCommand string `yaml:"startup_command"` // Command to run on session creation
-	FuzzyFinder    string `yaml:"fuzzy_finder"`    // "fzf", "peco", "auto"
+	// The primary workspace, which new projects are cloned into by default
+	WorkspaceDir string `yaml:"workspace_dir"`
+	// All workspaces, primary first; projects are discovered in each of them
+	Workspaces     []Workspace `yaml:"-"`
+	SessionBackend string      `yaml:"session_backend"` // "tmux", "zellij", "screen", "auto", or editor backends like "code:open", "cursor:replace"
+	StartupCommand string      `yaml:"startup_command"` // Command to run on session creation
+	FuzzyFinder    string      `yaml:"fuzzy_finder"`    // "fzf", "peco", "auto"
+	// Days removed worktrees are kept in the trash before being purged (0 disables the trash)
+	TrashRetentionDays int `yaml:"trash_retention_days"`
+	// How many directories below the workspace root are searched for projects (0 is unlimited)
+	DiscoveryMaxDepth int `yaml:"discovery_max_depth"`
+	// How long ago a project must have been fetched before switching to a branch fetches it
+	// again (0 fetches every time)
+	FetchIfOlderThan time.Duration `yaml:"fetch_if_older_than"`
+	// Whether fetches remove remote-tracking branches deleted on the remote
+	FetchPrune bool `yaml:"fetch_prune"`
+	// Longest a git command that can reach the remote or check out a worktree may run before
+	// it is killed, e.g. a fetch over a hung SSH connection (0 is unlimited)
+	GitTimeout time.Duration `yaml:"git_timeout"`
+	// How many times clones, fetches, and remote branch listings are retried after a transient
+	// network failure, like a connection reset or a DNS failure (0 never retries)
+	GitRetries int `yaml:"git_retries"`
+	// Whether worktrees are registered with zoxide, so 'z <branch>' jumps into them
+	Zoxide bool `yaml:"zoxide"`
+	// Whether switching to the worktree of the default branch fast-forwards it to the remote
+	// when it is clean
+	FastForwardDefault bool `yaml:"fast_forward_default"`
+	// Whether submodules are checked out in new worktrees, so they can be built right away
+	Submodules bool `yaml:"submodules"`
+	// Whether the Git LFS files of new worktrees are downloaded, instead of left as pointers
+	LFS bool `yaml:"lfs"`
+	// Whether the sessions of worktrees with a .devcontainer run inside their dev container
+	Devcontainer bool `yaml:"devcontainer"`
+	// Whether new worktrees are cloned from the checkout of the default branch with reflinks,
+	// on filesystems that support them, instead of checked out file by file
+	ReflinkWorktrees bool `yaml:"reflink_worktrees"`
+	// How many worktrees removed by 'sesh clean' and 'sesh delete' each project keeps to recycle
+	// for new branches, instead of deleting them (0 deletes them)
+	WorktreePool int `yaml:"worktree_pool"`
+	// Go template for the 'sesh info' preview; empty uses the built-in layout
+	PreviewTemplate string `yaml:"preview_template"`
+	// Where new worktrees are created, e.g. "~/worktrees/{repo}/{branch}"
+	WorktreePathTemplate string `yaml:"worktree_path_template"`
+	// Go template naming the branches 'sesh new' creates, e.g. "{{.User}}/{{.Type}}/{{.Slug}}";
+	// empty uses the given name as it is
+	BranchTemplate string `yaml:"branch_template"`
+	// Types offered for new branches, e.g. "feat" and "fix"
+	BranchTypes []string `yaml:"branch_types"`
+	// Name of the branches 'sesh switch --issue' creates, e.g. "{type}/{number}-{title}"
+	IssueBranchTemplate string `yaml:"issue_branch_template"`
+	// Terminal emulator 'sesh switch --new-window' opens sessions in, e.g. "kitty" or
+	// "foot {command}"
+	TerminalCommand string `yaml:"terminal_command"`
+	// Shallow and partial clone options, by default and per project
+	Clone CloneConfig `yaml:"clone"`
+	// Remote whose branches are listed and tracked, by default and per project
+	Remote RemoteConfig `yaml:"remote"`
+	// Short names of projects accepted wherever a project is given, e.g. "api" for
+	// "github.com/acme/platform-api"
+	ProjectAliases map[string]string `yaml:"project_aliases"`
+	// Tags of the projects matching a pattern, e.g. "backend", besides those of 'sesh project tag'
+	ProjectTags ProjectTags `yaml:"project_tags"`
+	// Names of the keyring secrets holding the API tokens of git hosts, e.g. "gitlab-work" for
+	// "gitlab.example.com"; see 'sesh token'
+	ProviderTokens map[string]string `yaml:"provider_tokens"`
+	// When sessions whose worktree no longer exists are killed: "never", "daemon", or "always"
+	ReapSessions string `yaml:"reap_sessions"`
+	// How long a session may go without a client attached before it is killed (0 keeps them)
+	SessionIdleTTL time.Duration `yaml:"session_idle_ttl"`
+	// Keys and popup size of the keybindings 'sesh tmux install' adds
+	Tmux TmuxConfig `yaml:"tmux"`
+	// Range of the blocks of ports assigned to worktrees, exposed to their sessions
+	Ports PortsConfig `yaml:"ports"`
+	// Colors of the message types; empty styles keep the defaults
+	Theme Theme `yaml:"theme"`
+	// Lowest level of diagnostics printed to stderr: "debug", "info", "warn", or "error"
+	LogLevel string `yaml:"log_level"`
+	// Whether all diagnostics, including debug ones, are appended to sesh.log in the config directory
+	LogFile bool `yaml:"log_file"`
+	// Name of the active profile, empty when none is
+	Profile string `yaml:"profile"`
+}
+
+// Theme holds the styles of the message types, e.g. "blue" or "bold bright-red"
+type Theme struct {
+	Success string `yaml:"success,omitempty"`
+	Info    string `yaml:"info,omitempty"`
+	Warn    string `yaml:"warn,omitempty"`
+	Error   string `yaml:"error,omitempty"`
+	Faint   string `yaml:"faint,omitempty"`
 }
 
 // configFile represents the YAML config file structure
 type configFile struct {
-	Version        string `yaml:"version"`
-	WorkspaceDir   string `yaml:"workspace_dir"`
-	SessionBackend string `yaml:"session_backend"`
-	StartupCommand string `yaml:"startup_command"`
-	FuzzyFinder    string `yaml:"fuzzy_finder"`
+	Version        string        `yaml:"version"`
+	WorkspaceDir   WorkspaceDirs `yaml:"workspace_dir"`
+	SessionBackend string        `yaml:"session_backend"`
+	StartupCommand string        `yaml:"startup_command"`
+	FuzzyFinder    string        `yaml:"fuzzy_finder"`
+	// Pointer so an explicit 0 (trash disabled) can be told apart from an unset value
+	TrashRetentionDays *int `yaml:"trash_retention_days,omitempty"`
+	// Pointer so an explicit 0 (unlimited) can be told apart from an unset value
+	DiscoveryMaxDepth *int `yaml:"discovery_max_depth,omitempty"`
+	// Pointer so an explicit 0 (always fetch) can be told apart from an unset value
+	FetchIfOlderThan *time.Duration `yaml:"fetch_if_older_than,omitempty"`
+	// Pointer so an explicit false can be told apart from an unset value, which prunes
+	FetchPrune *bool `yaml:"fetch_prune,omitempty"`
+	// Pointer so an explicit 0 (unlimited) can be told apart from an unset value
+	GitTimeout *time.Duration `yaml:"git_timeout,omitempty"`
+	// Pointer so an explicit 0 (never retry) can be told apart from an unset value
+	GitRetries *int `yaml:"git_retries,omitempty"`
+	Zoxide     bool `yaml:"zoxide,omitempty"`
+	// FastForwardDefault is off unless enabled, like zoxide
+	FastForwardDefault bool `yaml:"fast_forward_default,omitempty"`
+	// Pointer so an explicit false can be told apart from an unset value, which enables them
+	Submodules   *bool `yaml:"submodules,omitempty"`
+	LFS          bool  `yaml:"lfs,omitempty"`
+	Devcontainer bool  `yaml:"devcontainer,omitempty"`
+	// ReflinkWorktrees is off unless enabled, since it only helps on some filesystems
+	ReflinkWorktrees bool   `yaml:"reflink_worktrees,omitempty"`
+	WorktreePool     int    `yaml:"worktree_pool,omitempty"`
+	PreviewTemplate  string `yaml:"preview_template,omitempty"`
+	// WorktreePathTemplate is kept unexpanded, like workspace_dir
+	WorktreePathTemplate string            `yaml:"worktree_path_template,omitempty"`
+	BranchTemplate       string            `yaml:"branch_template,omitempty"`
+	BranchTypes          []string          `yaml:"branch_types,omitempty"`
+	IssueBranchTemplate  string            `yaml:"issue_branch_template,omitempty"`
+	TerminalCommand      string            `yaml:"terminal_command,omitempty"`
+	Clone                CloneConfig       `yaml:"clone,omitempty"`
+	Remote               RemoteConfig      `yaml:"remote,omitempty"`
+	ProjectAliases       map[string]string `yaml:"project_aliases,omitempty"`
+	ProjectTags          ProjectTags       `yaml:"project_tags,omitempty"`
+	ProviderTokens       map[string]string `yaml:"provider_tokens,omitempty"`
+	ReapSessions         string            `yaml:"reap_sessions,omitempty"`
+	SessionIdleTTL       time.Duration     `yaml:"session_idle_ttl,omitempty"`
+	Tmux                 TmuxConfig        `yaml:"tmux,omitempty"`
+	Ports                PortsConfig       `yaml:"ports,omitempty"`
+	Theme                Theme             `yaml:"theme,omitempty"`
+	LogLevel             string            `yaml:"log_level,omitempty"`
+	LogFile              bool              `yaml:"log_file,omitempty"`
+	// Profile is used when neither --profile nor SESH_PROFILE select one
+	Profile  string             `yaml:"profile,omitempty"`
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
 }
 
 const (
 	// CurrentConfigVersion is the current version of the config file format
 	CurrentConfigVersion = "1"
+
+	// DefaultTrashRetentionDays is how long removed worktrees are kept in the trash by default
+	DefaultTrashRetentionDays = 7
+
+	// DefaultDiscoveryMaxDepth is how deep the workspace is searched for projects by default
+	// It leaves room for nested groups beyond the usual host/owner/repo layout
+	DefaultDiscoveryMaxDepth = 6
+
+	// DefaultFetchIfOlderThan is how long a fetch is considered fresh by default
+	DefaultFetchIfOlderThan = 15 * time.Minute
+
+	// DefaultGitTimeout is how long a git command may run by default; clones of large
+	// repositories can legitimately take minutes
+	DefaultGitTimeout = 10 * time.Minute
+
+	// DefaultGitRetries is how many times a network git command is retried by default
+	DefaultGitRetries = 2
 )
 
 // ProjectConfig holds project-specific configuration
 type ProjectConfig struct {
 	StartupCommand string `yaml:"startup_command"`
+	// Pointer so the project can turn submodules off while they are on globally, or back on
+	Submodules *bool `yaml:"submodules,omitempty"`
+	// Pointer so the project can opt in to Git LFS, or out of it while it is on globally
+	LFS *bool `yaml:"lfs,omitempty"`
+	// Pointer so the project can opt in to its dev container, or out of it while it is on globally
+	Devcontainer *bool `yaml:"devcontainer,omitempty"`
+	// StatusStyle colors the status bar of the project's tmux sessions, e.g. "bg=blue"
+	StatusStyle string `yaml:"status_style,omitempty"`
+	// WaitForStartup waits for the startup command of new sessions to finish before attaching
+	WaitForStartup bool `yaml:"wait_for_startup,omitempty"`
+	// ReadyCheck tells when the services the startup command starts, like a dev server, are ready
+	ReadyCheck *ready.Check `yaml:"ready_check,omitempty"`
+	// Processes are long-running processes, like a dev server or a watcher, started with new sessions
+	Processes []session.Process `yaml:"processes,omitempty"`
+	// ComposeFile is a Docker Compose file whose services are started with new sessions, e.g. "compose.yaml"
+	ComposeFile string `yaml:"compose_file,omitempty"`
+	// Subprojects are directories of a monorepo with sessions of their own, by name
+	Subprojects map[string]Subproject `yaml:"subprojects,omitempty"`
 }
 
 // GetConfigDir returns the OS-specific config directory for sesh
@@ -48,11 +224,15 @@ func GetConfigDir() (string, error) {
 		}
 		baseDir = filepath.Join(home, "Library", "Application Support")
 	case "windows":
-		appData := os.Getenv("APPDATA")
-		if appData == "" {
-			return "", eris.New("APPDATA environment variable not set")
+		// APPDATA is the roaming profile, which is where it lives by default when it isn't set
+		baseDir = os.Getenv("APPDATA")
+		if baseDir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", eris.Wrap(err, "APPDATA environment variable not set")
+			}
+			baseDir = filepath.Join(home, "AppData", "Roaming")
 		}
-		baseDir = appData
 	default: // linux and others
 		xdgConfigHome := os.Getenv("XDG_CONFIG_HOME")
 		if xdgConfigHome != "" {
@@ -69,26 +249,13 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(baseDir, "sesh"), nil
 }
 
-// GetWorkspaceDir returns the workspace directory with configuration hierarchy
+// GetWorkspaceDir returns the primary workspace directory with configuration hierarchy
 func GetWorkspaceDir() (string, error) {
-	// 1. Environment variable (highest priority)
-	if envDir := os.Getenv("SESH_WORKSPACE"); envDir != "" {
-		return expandHome(envDir)
-	}
-
-	// 2. Config file
-	config, err := loadConfigFile()
-	if err == nil && config.WorkspaceDir != "" {
-		return expandHome(config.WorkspaceDir)
-	}
-
-	// 3. Default (lowest priority)
-	home, err := os.UserHomeDir()
+	workspaces, err := GetWorkspaces()
 	if err != nil {
-		return "", eris.Wrap(err, "failed to get user home directory")
+		return "", err
 	}
-
-	return filepath.Join(home, ".sesh"), nil
+	return workspaces[0].Path, nil
 }
 
 // GetSessionBackend returns the session backend with configuration hierarchy
@@ -125,14 +292,523 @@ func GetFuzzyFinder() (string, error) {
 	return "auto", nil
 }
 
+// GetTrashRetentionDays returns the trash retention period in days with configuration hierarchy
+// A value of 0 disables the trash, so removed worktrees are deleted immediately
+func GetTrashRetentionDays() (int, error) {
+	// 1. Environment variable (highest priority)
+	if envDays := os.Getenv("SESH_TRASH_RETENTION_DAYS"); envDays != "" {
+		days, err := strconv.Atoi(envDays)
+		if err != nil || days < 0 {
+			return 0, eris.Errorf("invalid SESH_TRASH_RETENTION_DAYS: %s (must be a non-negative integer)", envDays)
+		}
+		return days, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.TrashRetentionDays != nil {
+		return *config.TrashRetentionDays, nil
+	}
+
+	// 3. Default (lowest priority)
+	return DefaultTrashRetentionDays, nil
+}
+
+// GetDiscoveryMaxDepth returns how many directories below the workspace root are searched for
+// projects, with configuration hierarchy. A value of 0 removes the limit.
+func GetDiscoveryMaxDepth() (int, error) {
+	// 1. Environment variable (highest priority)
+	if envDepth := os.Getenv("SESH_DISCOVERY_MAX_DEPTH"); envDepth != "" {
+		depth, err := strconv.Atoi(envDepth)
+		if err != nil || depth < 0 {
+			return 0, eris.Errorf("invalid SESH_DISCOVERY_MAX_DEPTH: %s (must be a non-negative integer)", envDepth)
+		}
+		return depth, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.DiscoveryMaxDepth != nil {
+		return *config.DiscoveryMaxDepth, nil
+	}
+
+	// 3. Default (lowest priority)
+	return DefaultDiscoveryMaxDepth, nil
+}
+
+// GetFetchIfOlderThan returns how long ago a project must have been fetched before it is
+// fetched again, with configuration hierarchy. A value of 0 fetches every time.
+func GetFetchIfOlderThan() (time.Duration, error) {
+	// 1. Environment variable (highest priority)
+	if envAge := os.Getenv("SESH_FETCH_IF_OLDER_THAN"); envAge != "" {
+		age, err := time.ParseDuration(envAge)
+		if err != nil || age < 0 {
+			return 0, eris.Errorf("invalid SESH_FETCH_IF_OLDER_THAN: %s (must be a duration like 15m)", envAge)
+		}
+		return age, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.FetchIfOlderThan != nil {
+		return *config.FetchIfOlderThan, nil
+	}
+
+	// 3. Default (lowest priority)
+	return DefaultFetchIfOlderThan, nil
+}
+
+// GetFetchPrune returns whether fetches prune remote-tracking branches that were deleted on
+// the remote, with configuration hierarchy
+func GetFetchPrune() (bool, error) {
+	// 1. Environment variable (highest priority)
+	if envPrune := os.Getenv("SESH_FETCH_PRUNE"); envPrune != "" {
+		enabled, err := strconv.ParseBool(envPrune)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_FETCH_PRUNE: %s (must be true or false)", envPrune)
+		}
+		return enabled, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.FetchPrune != nil {
+		return *config.FetchPrune, nil
+	}
+
+	// 3. Default (lowest priority)
+	return true, nil
+}
+
+// GetGitTimeout returns how long a git command that can reach the remote may run before it is
+// killed, with configuration hierarchy. A value of 0 means there is no limit.
+func GetGitTimeout() (time.Duration, error) {
+	// 1. Environment variable (highest priority)
+	if envTimeout := os.Getenv("SESH_GIT_TIMEOUT"); envTimeout != "" {
+		timeout, err := time.ParseDuration(envTimeout)
+		if err != nil || timeout < 0 {
+			return 0, eris.Errorf("invalid SESH_GIT_TIMEOUT: %s (must be a duration like 10m)", envTimeout)
+		}
+		return timeout, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.GitTimeout != nil {
+		return *config.GitTimeout, nil
+	}
+
+	// 3. Default (lowest priority)
+	return DefaultGitTimeout, nil
+}
+
+// GetGitRetries returns how many times a git command reaching the remote is retried after a
+// transient network failure, with configuration hierarchy
+func GetGitRetries() (int, error) {
+	// 1. Environment variable (highest priority)
+	if envRetries := os.Getenv("SESH_GIT_RETRIES"); envRetries != "" {
+		retries, err := strconv.Atoi(envRetries)
+		if err != nil || retries < 0 {
+			return 0, eris.Errorf("invalid SESH_GIT_RETRIES: %s (must be a non-negative integer)", envRetries)
+		}
+		return retries, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.GitRetries != nil {
+		return *config.GitRetries, nil
+	}
+
+	// 3. Default (lowest priority)
+	return DefaultGitRetries, nil
+}
+
+// GetWorktreePool returns how many removed worktrees each project keeps to recycle, with
+// configuration hierarchy
+func GetWorktreePool() (int, error) {
+	// 1. Environment variable (highest priority)
+	if envPool := os.Getenv("SESH_WORKTREE_POOL"); envPool != "" {
+		size, err := strconv.Atoi(envPool)
+		if err != nil || size < 0 {
+			return 0, eris.Errorf("invalid SESH_WORKTREE_POOL: %s (must be a non-negative integer)", envPool)
+		}
+		return size, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.WorktreePool, nil
+	}
+
+	// 3. Default (lowest priority)
+	return 0, nil
+}
+
+// GetZoxide returns whether worktrees are registered with zoxide, with configuration hierarchy
+func GetZoxide() (bool, error) {
+	// 1. Environment variable (highest priority)
+	if envZoxide := os.Getenv("SESH_ZOXIDE"); envZoxide != "" {
+		enabled, err := strconv.ParseBool(envZoxide)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_ZOXIDE: %s (must be true or false)", envZoxide)
+		}
+		return enabled, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.Zoxide, nil
+	}
+
+	// 3. Default (lowest priority)
+	return false, nil
+}
+
+// GetReflinkWorktrees returns whether new worktrees are cloned with reflinks, with configuration
+// hierarchy
+func GetReflinkWorktrees() (bool, error) {
+	// 1. Environment variable (highest priority)
+	if envReflink := os.Getenv("SESH_REFLINK_WORKTREES"); envReflink != "" {
+		enabled, err := strconv.ParseBool(envReflink)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_REFLINK_WORKTREES: %s (must be true or false)", envReflink)
+		}
+		return enabled, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.ReflinkWorktrees, nil
+	}
+
+	// 3. Default (lowest priority)
+	return false, nil
+}
+
+// GetFastForwardDefault returns whether switching to the worktree of the default branch
+// fast-forwards it, with configuration hierarchy
+func GetFastForwardDefault() (bool, error) {
+	// 1. Environment variable (highest priority)
+	if envFastForward := os.Getenv("SESH_FAST_FORWARD_DEFAULT"); envFastForward != "" {
+		enabled, err := strconv.ParseBool(envFastForward)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_FAST_FORWARD_DEFAULT: %s (must be true or false)", envFastForward)
+		}
+		return enabled, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.FastForwardDefault, nil
+	}
+
+	// 3. Default (lowest priority)
+	return false, nil
+}
+
+// GetSubmodules returns whether submodules are checked out in new worktrees, with configuration
+// hierarchy
+// Priority: per-project config > SESH_SUBMODULES > global config > enabled
+func GetSubmodules(projectPath string) (bool, error) {
+	// 1. Check per-project config (highest priority)
+	if projectPath != "" {
+		projectConfig, err := LoadProjectConfig(projectPath)
+		if err == nil && projectConfig.Submodules != nil {
+			return *projectConfig.Submodules, nil
+		}
+	}
+
+	// 2. Environment variable
+	if envSubmodules := os.Getenv("SESH_SUBMODULES"); envSubmodules != "" {
+		enabled, err := strconv.ParseBool(envSubmodules)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_SUBMODULES: %s (must be true or false)", envSubmodules)
+		}
+		return enabled, nil
+	}
+
+	// 3. Global config file
+	config, err := loadConfigFile()
+	if err == nil && config.Submodules != nil {
+		return *config.Submodules, nil
+	}
+
+	// 4. Default (lowest priority)
+	return true, nil
+}
+
+// GetLFS returns whether the Git LFS files of new worktrees are downloaded, with configuration
+// hierarchy
+// Priority: per-project config > SESH_LFS > global config > disabled
+func GetLFS(projectPath string) (bool, error) {
+	// 1. Check per-project config (highest priority)
+	if projectPath != "" {
+		projectConfig, err := LoadProjectConfig(projectPath)
+		if err == nil && projectConfig.LFS != nil {
+			return *projectConfig.LFS, nil
+		}
+	}
+
+	// 2. Environment variable
+	if envLFS := os.Getenv("SESH_LFS"); envLFS != "" {
+		enabled, err := strconv.ParseBool(envLFS)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_LFS: %s (must be true or false)", envLFS)
+		}
+		return enabled, nil
+	}
+
+	// 3. Global config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.LFS, nil
+	}
+
+	// 4. Default (lowest priority)
+	return false, nil
+}
+
+// GetDevcontainer returns whether the sessions of worktrees with a dev container configuration
+// run inside their container, with configuration hierarchy
+// Priority: per-project config > SESH_DEVCONTAINER > global config > disabled
+func GetDevcontainer(projectPath string) (bool, error) {
+	// 1. Check per-project config (highest priority)
+	if projectPath != "" {
+		projectConfig, err := LoadProjectConfig(projectPath)
+		if err == nil && projectConfig.Devcontainer != nil {
+			return *projectConfig.Devcontainer, nil
+		}
+	}
+
+	// 2. Environment variable
+	if envDevcontainer := os.Getenv("SESH_DEVCONTAINER"); envDevcontainer != "" {
+		enabled, err := strconv.ParseBool(envDevcontainer)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_DEVCONTAINER: %s (must be true or false)", envDevcontainer)
+		}
+		return enabled, nil
+	}
+
+	// 3. Global config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.Devcontainer, nil
+	}
+
+	// 4. Default (lowest priority)
+	return false, nil
+}
+
+// GetPreviewTemplate returns the template of the 'sesh info' preview, with configuration hierarchy
+// An empty template means the built-in layout.
+func GetPreviewTemplate() (string, error) {
+	// 1. Environment variable (highest priority)
+	if envTemplate := os.Getenv("SESH_PREVIEW_TEMPLATE"); envTemplate != "" {
+		return envTemplate, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.PreviewTemplate, nil
+	}
+
+	// 3. Default (lowest priority)
+	return "", nil
+}
+
+// GetWorktreePathTemplate returns where new worktrees are created, with configuration hierarchy
+// A leading ~ is expanded, and the placeholders are checked here, since the template is applied
+// before the full configuration is loaded.
+func GetWorktreePathTemplate() (string, error) {
+	template := workspace.DefaultWorktreePathTemplate
+
+	// 1. Environment variable (highest priority)
+	if envTemplate := os.Getenv("SESH_WORKTREE_PATH_TEMPLATE"); envTemplate != "" {
+		template = envTemplate
+	} else if config, err := loadConfigFile(); err == nil && config.WorktreePathTemplate != "" {
+		// 2. Config file
+		template = config.WorktreePathTemplate
+	}
+
+	if err := workspace.ValidateWorktreePathTemplate(template); err != nil {
+		return "", eris.Wrapf(err, "invalid worktree_path_template: %s", template)
+	}
+	return expandHome(template)
+}
+
+// GetIssueBranchTemplate returns the name of the branches created for issues, with
+// configuration hierarchy
+func GetIssueBranchTemplate() (string, error) {
+	template := pr.DefaultIssueBranchTemplate
+
+	// 1. Environment variable (highest priority)
+	if envTemplate := os.Getenv("SESH_ISSUE_BRANCH_TEMPLATE"); envTemplate != "" {
+		template = envTemplate
+	} else if config, err := loadConfigFile(); err == nil && config.IssueBranchTemplate != "" {
+		// 2. Config file
+		template = config.IssueBranchTemplate
+	}
+
+	if err := pr.ValidateIssueBranchTemplate(template); err != nil {
+		return "", eris.Wrapf(err, "invalid issue_branch_template: %s", template)
+	}
+	return template, nil
+}
+
+// GetTerminalCommand returns the terminal emulator sessions are opened in with --new-window,
+// with configuration hierarchy. Empty when none is set.
+func GetTerminalCommand() (string, error) {
+	terminal := ""
+
+	// 1. Environment variable (highest priority)
+	if envTerminal := os.Getenv("SESH_TERMINAL_COMMAND"); envTerminal != "" {
+		terminal = envTerminal
+	} else if config, err := loadConfigFile(); err == nil {
+		// 2. Config file
+		terminal = config.TerminalCommand
+	}
+
+	if err := session.ValidateTerminalCommand(terminal); err != nil {
+		return "", eris.Wrapf(err, "invalid terminal_command: %s", terminal)
+	}
+	return terminal, nil
+}
+
+// GetTheme returns the configured theme
+// Styles are checked here, since the theme is applied before the full configuration is loaded.
+func GetTheme() (Theme, error) {
+	// 1. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		if err := validateTheme(config.Theme); err != nil {
+			return Theme{}, err
+		}
+		return config.Theme, nil
+	}
+
+	// 2. Default (lowest priority)
+	return Theme{}, nil
+}
+
+// DisplayTheme converts the theme to the display package's theme
+func (t Theme) DisplayTheme() display.Theme {
+	return display.Theme{Success: t.Success, Info: t.Info, Warning: t.Warn, Error: t.Error, Faint: t.Faint}
+}
+
+// validateTheme checks that every style of a theme is valid
+func validateTheme(theme Theme) error {
+	styles := []struct{ key, style string }{
+		{"success", theme.Success},
+		{"info", theme.Info},
+		{"warn", theme.Warn},
+		{"error", theme.Error},
+		{"faint", theme.Faint},
+	}
+	for _, s := range styles {
+		if s.style == "" {
+			continue
+		}
+		if err := display.ValidateStyle(s.style); err != nil {
+			return &FieldError{Key: "theme." + s.key, Err: eris.Wrapf(err, "invalid theme.%s", s.key)}
+		}
+	}
+	return nil
+}
+
+// GetLogLevel returns the lowest level of diagnostics printed to stderr, with configuration hierarchy
+func GetLogLevel() (string, error) {
+	// 1. Environment variable (highest priority)
+	if envLevel := os.Getenv("SESH_LOG_LEVEL"); envLevel != "" {
+		if _, err := logging.ParseLevel(envLevel); err != nil {
+			return "", eris.Wrap(err, "invalid SESH_LOG_LEVEL")
+		}
+		return envLevel, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.LogLevel != "" {
+		if _, err := logging.ParseLevel(config.LogLevel); err != nil {
+			return "", eris.Wrap(err, "invalid log_level")
+		}
+		return config.LogLevel, nil
+	}
+
+	// 3. Default (lowest priority)
+	return logging.DefaultLevel, nil
+}
+
+// GetLogFile returns whether diagnostics are appended to the log file, with configuration hierarchy
+func GetLogFile() (bool, error) {
+	// 1. Environment variable (highest priority)
+	if envLogFile := os.Getenv("SESH_LOG_FILE"); envLogFile != "" {
+		enabled, err := strconv.ParseBool(envLogFile)
+		if err != nil {
+			return false, eris.Errorf("invalid SESH_LOG_FILE: %s (must be true or false)", envLogFile)
+		}
+		return enabled, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		return config.LogFile, nil
+	}
+
+	// 3. Default (lowest priority)
+	return false, nil
+}
+
+// GetLogPath returns the path of the log file
+func GetLogPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", eris.Wrap(err, "failed to get config directory")
+	}
+
+	return filepath.Join(configDir, "sesh.log"), nil
+}
+
 // GetDBPath returns the full path to the SQLite database
+// Each profile has its own database.
 func GetDBPath() (string, error) {
 	configDir, err := GetConfigDir()
 	if err != nil {
 		return "", eris.Wrap(err, "failed to get config directory")
 	}
 
-	return filepath.Join(configDir, "sesh.db"), nil
+	return filepath.Join(configDir, profileFileName("sesh", ".db")), nil
+}
+
+// GetSocketPath returns the path of the unix socket 'sesh serve --socket' listens on
+// Each profile has its own socket.
+func GetSocketPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", eris.Wrap(err, "failed to get config directory")
+	}
+
+	return filepath.Join(configDir, profileFileName("sesh", ".sock")), nil
+}
+
+// GetCacheDir returns the OS-specific cache directory for sesh
+// Everything in it can be safely deleted; it is rebuilt on demand. Each profile has its own.
+func GetCacheDir() (string, error) {
+	baseDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", eris.Wrap(err, "failed to get user cache directory")
+	}
+
+	if profile := activeProfileName(); profile != "" {
+		return filepath.Join(baseDir, "sesh", "profiles", profile), nil
+	}
+	return filepath.Join(baseDir, "sesh"), nil
 }
 
 // EnsureConfigDir creates the config directory if it doesn't exist
@@ -165,7 +841,7 @@ func EnsureWorkspaceDir() error {
 
 // LoadConfig loads the full configuration with all settings resolved
 func LoadConfig() (*Config, error) {
-	workspaceDir, err := GetWorkspaceDir()
+	workspaces, err := GetWorkspaces()
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to get workspace directory")
 	}
@@ -185,11 +861,205 @@ func LoadConfig() (*Config, error) {
 		return nil, eris.Wrap(err, "failed to get fuzzy finder")
 	}
 
+	trashRetentionDays, err := GetTrashRetentionDays()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get trash retention")
+	}
+
+	discoveryMaxDepth, err := GetDiscoveryMaxDepth()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get discovery max depth")
+	}
+
+	fetchIfOlderThan, err := GetFetchIfOlderThan()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get fetch policy")
+	}
+
+	fetchPrune, err := GetFetchPrune()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get fetch pruning")
+	}
+
+	gitTimeout, err := GetGitTimeout()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get git timeout")
+	}
+
+	gitRetries, err := GetGitRetries()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get git retries")
+	}
+
+	zoxide, err := GetZoxide()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get zoxide integration")
+	}
+
+	reflinkWorktrees, err := GetReflinkWorktrees()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get reflink worktrees")
+	}
+
+	worktreePool, err := GetWorktreePool()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get worktree pool")
+	}
+
+	fastForwardDefault, err := GetFastForwardDefault()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get default branch fast-forwarding")
+	}
+
+	submodules, err := GetSubmodules("")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get submodules")
+	}
+
+	lfs, err := GetLFS("")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get Git LFS")
+	}
+
+	devcontainer, err := GetDevcontainer("")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get devcontainer")
+	}
+
+	previewTemplate, err := GetPreviewTemplate()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get preview template")
+	}
+
+	worktreePathTemplate, err := GetWorktreePathTemplate()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get worktree path template")
+	}
+
+	branchTemplate, err := GetBranchTemplate()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get branch template")
+	}
+
+	branchTypes, err := GetBranchTypes()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get branch types")
+	}
+
+	issueBranchTemplate, err := GetIssueBranchTemplate()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get issue branch template")
+	}
+
+	terminalCommand, err := GetTerminalCommand()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get terminal command")
+	}
+
+	clone, err := GetCloneConfig()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get clone options")
+	}
+
+	remote, err := GetRemoteConfig()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get remote")
+	}
+
+	reapSessions, err := GetReapSessions()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get session reaping policy")
+	}
+
+	sessionIdleTTL, err := GetSessionIdleTTL()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get session idle TTL")
+	}
+
+	tmux, err := GetTmuxConfig()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get tmux keybindings")
+	}
+
+	ports, err := GetPortsConfig()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get ports")
+	}
+
+	theme, err := GetTheme()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get theme")
+	}
+
+	logLevel, err := GetLogLevel()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get log level")
+	}
+
+	logFile, err := GetLogFile()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get log file")
+	}
+
+	projectAliases, err := GetProjectAliases()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get project aliases")
+	}
+
+	projectTags, err := GetProjectTags()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get project tags")
+	}
+
+	providerTokens, err := GetProviderTokens()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get provider tokens")
+	}
+
+	// The other settings silently ignore a profile that doesn't exist
+	profile, err := GetProfile()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get profile")
+	}
+
 	return &Config{
-		WorkspaceDir:   workspaceDir,
-		SessionBackend: sessionBackend,
-		StartupCommand: startupCommand,
-		FuzzyFinder:    fuzzyFinder,
+		WorkspaceDir:         workspaces[0].Path,
+		Workspaces:           workspaces,
+		SessionBackend:       sessionBackend,
+		StartupCommand:       startupCommand,
+		FuzzyFinder:          fuzzyFinder,
+		TrashRetentionDays:   trashRetentionDays,
+		DiscoveryMaxDepth:    discoveryMaxDepth,
+		FetchIfOlderThan:     fetchIfOlderThan,
+		FetchPrune:           fetchPrune,
+		GitTimeout:           gitTimeout,
+		GitRetries:           gitRetries,
+		Zoxide:               zoxide,
+		FastForwardDefault:   fastForwardDefault,
+		Submodules:           submodules,
+		LFS:                  lfs,
+		Devcontainer:         devcontainer,
+		ReflinkWorktrees:     reflinkWorktrees,
+		WorktreePool:         worktreePool,
+		PreviewTemplate:      previewTemplate,
+		WorktreePathTemplate: worktreePathTemplate,
+		BranchTemplate:       branchTemplate,
+		BranchTypes:          branchTypes,
+		IssueBranchTemplate:  issueBranchTemplate,
+		TerminalCommand:      terminalCommand,
+		Clone:                clone,
+		Remote:               remote,
+		ProjectAliases:       projectAliases,
+		ProjectTags:          projectTags,
+		ProviderTokens:       providerTokens,
+		ReapSessions:         reapSessions,
+		SessionIdleTTL:       sessionIdleTTL,
+		Tmux:                 tmux,
+		Ports:                ports,
+		Theme:                theme,
+		LogLevel:             logLevel,
+		LogFile:              logFile,
+		Profile:              profile,
 	}, nil
 }
 
@@ -232,12 +1102,36 @@ func LoadProjectConfig(projectPath string) (*ProjectConfig, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, eris.Wrapf(err, "failed to parse project config file: %s", configPath)
 	}
+	if config.ReadyCheck != nil {
+		if err := config.ReadyCheck.Validate(); err != nil {
+			return nil, eris.Wrapf(err, "invalid project config file: %s", configPath)
+		}
+	}
+	if err := session.ValidateProcesses(config.Processes); err != nil {
+		return nil, eris.Wrapf(err, "invalid project config file: %s", configPath)
+	}
+	if err := validateSubprojects(config.Subprojects); err != nil {
+		return nil, eris.Wrapf(err, "invalid project config file: %s", configPath)
+	}
 
 	return &config, nil
 }
 
-// loadConfigFile loads the config file from disk (internal helper)
+// loadConfigFile loads the config file from disk with the active profile applied (internal helper)
 func loadConfigFile() (*configFile, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyProfile(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readConfigFile reads the config file from disk as it is written (internal helper)
+func readConfigFile() (*configFile, error) {
 	configDir, err := GetConfigDir()
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to get config directory")
@@ -307,13 +1201,65 @@ func SaveConfig(config *Config) error {
 		return eris.Wrap(err, "failed to ensure config directory")
 	}
 
+	// The default layout is left out of the file, like in files written by hand
+	worktreePathTemplate := config.WorktreePathTemplate
+	if worktreePathTemplate == workspace.DefaultWorktreePathTemplate {
+		worktreePathTemplate = ""
+	}
+
+	issueBranchTemplate := config.IssueBranchTemplate
+	if issueBranchTemplate == pr.DefaultIssueBranchTemplate {
+		issueBranchTemplate = ""
+	}
+
+	branchTypes := config.BranchTypes
+	if slices.Equal(branchTypes, workspace.DefaultBranchTypes) {
+		branchTypes = nil
+	}
+
+	workspaceDirs := WorkspaceDirs{{Path: config.WorkspaceDir}}
+	if len(config.Workspaces) > 1 {
+		workspaceDirs = config.Workspaces
+	}
+
 	// Convert to configFile structure with version
 	cf := configFile{
-		Version:        CurrentConfigVersion,
-		WorkspaceDir:   config.WorkspaceDir,
-		SessionBackend: config.SessionBackend,
-		StartupCommand: config.StartupCommand,
-		FuzzyFinder:    config.FuzzyFinder,
+		Version:              CurrentConfigVersion,
+		WorkspaceDir:         workspaceDirs,
+		SessionBackend:       config.SessionBackend,
+		StartupCommand:       config.StartupCommand,
+		FuzzyFinder:          config.FuzzyFinder,
+		TrashRetentionDays:   &config.TrashRetentionDays,
+		DiscoveryMaxDepth:    &config.DiscoveryMaxDepth,
+		FetchIfOlderThan:     &config.FetchIfOlderThan,
+		FetchPrune:           &config.FetchPrune,
+		GitTimeout:           &config.GitTimeout,
+		GitRetries:           &config.GitRetries,
+		Zoxide:               config.Zoxide,
+		FastForwardDefault:   config.FastForwardDefault,
+		Submodules:           &config.Submodules,
+		LFS:                  config.LFS,
+		Devcontainer:         config.Devcontainer,
+		ReflinkWorktrees:     config.ReflinkWorktrees,
+		WorktreePool:         config.WorktreePool,
+		PreviewTemplate:      config.PreviewTemplate,
+		WorktreePathTemplate: worktreePathTemplate,
+		BranchTemplate:       config.BranchTemplate,
+		BranchTypes:          branchTypes,
+		IssueBranchTemplate:  issueBranchTemplate,
+		TerminalCommand:      config.TerminalCommand,
+		Clone:                config.Clone,
+		Remote:               config.Remote,
+		ProjectAliases:       config.ProjectAliases,
+		ProjectTags:          config.ProjectTags,
+		ProviderTokens:       config.ProviderTokens,
+		ReapSessions:         config.ReapSessions,
+		SessionIdleTTL:       config.SessionIdleTTL,
+		Tmux:                 config.Tmux,
+		Ports:                config.Ports,
+		Theme:                config.Theme,
+		LogLevel:             config.LogLevel,
+		LogFile:              config.LogFile,
 	}
 
 	// Marshal to YAML
@@ -322,11 +1268,9 @@ func SaveConfig(config *Config) error {
 		return eris.Wrap(err, "failed to marshal config to YAML")
 	}
 
-	// Write to file
-	if err := os.WriteFile(configPath, data, 0o644); err != nil {
+	if err := atomicfile.WriteFile(configPath, data, 0o644); err != nil {
 		return eris.Wrapf(err, "failed to write config file: %s", configPath)
 	}
-
 	return nil
 }
 
@@ -343,7 +1287,10 @@ func ValidateConfig(config *configFile) error {
 			}
 		}
 		if !valid {
-			return eris.Errorf("invalid fuzzy_finder: %s (must be one of: auto, fzf, peco)", config.FuzzyFinder)
+			return &FieldError{
+				Key: "fuzzy_finder",
+				Err: eris.Errorf("invalid fuzzy_finder: %s (must be one of: auto, fzf, peco)", config.FuzzyFinder),
+			}
 		}
 	}
 
@@ -363,33 +1310,172 @@ func ValidateConfig(config *configFile) error {
 			}
 		}
 		if !valid {
-			return eris.Errorf(
+			return &FieldError{Key: "session_backend", Err: eris.Errorf(
 				"invalid session_backend: %s (must be one of: auto, tmux, zellij, screen, code:open, code:workspace, code:replace, cursor:open, cursor:workspace, cursor:replace)",
 				config.SessionBackend,
-			)
+			)}
 		}
 	}
 
-	// Validate workspace directory (if provided, it should be expandable)
-	if config.WorkspaceDir != "" {
-		_, err := expandHome(config.WorkspaceDir)
-		if err != nil {
-			return eris.Wrap(err, "invalid workspace_dir")
+	// Validate trash retention
+	if config.TrashRetentionDays != nil && *config.TrashRetentionDays < 0 {
+		return &FieldError{
+			Key: "trash_retention_days",
+			Err: eris.Errorf("invalid trash_retention_days: %d (must be 0 or greater)", *config.TrashRetentionDays),
+		}
+	}
+
+	// Validate discovery depth
+	if config.DiscoveryMaxDepth != nil && *config.DiscoveryMaxDepth < 0 {
+		return &FieldError{
+			Key: "discovery_max_depth",
+			Err: eris.Errorf("invalid discovery_max_depth: %d (must be 0 or greater)", *config.DiscoveryMaxDepth),
+		}
+	}
+
+	// Validate git timeout
+	if config.GitTimeout != nil && *config.GitTimeout < 0 {
+		return &FieldError{
+			Key: "git_timeout",
+			Err: eris.Errorf("invalid git_timeout: %s (must be 0 or greater)", *config.GitTimeout),
+		}
+	}
+
+	// Validate git retries
+	if config.GitRetries != nil && *config.GitRetries < 0 {
+		return &FieldError{
+			Key: "git_retries",
+			Err: eris.Errorf("invalid git_retries: %d (must be 0 or greater)", *config.GitRetries),
+		}
+	}
+
+	// Validate worktree pool
+	if config.WorktreePool < 0 {
+		return &FieldError{
+			Key: "worktree_pool",
+			Err: eris.Errorf("invalid worktree_pool: %d (must be 0 or greater)", config.WorktreePool),
+		}
+	}
+
+	// Validate fetch policy
+	if config.FetchIfOlderThan != nil && *config.FetchIfOlderThan < 0 {
+		return &FieldError{
+			Key: "fetch_if_older_than",
+			Err: eris.Errorf("invalid fetch_if_older_than: %s (must be 0 or greater)", *config.FetchIfOlderThan),
+		}
+	}
+
+	// Validate log level
+	if config.LogLevel != "" {
+		if _, err := logging.ParseLevel(config.LogLevel); err != nil {
+			return &FieldError{Key: "log_level", Err: eris.Wrap(err, "invalid log_level")}
+		}
+	}
+
+	// Validate worktree path template
+	if config.WorktreePathTemplate != "" {
+		if err := workspace.ValidateWorktreePathTemplate(config.WorktreePathTemplate); err != nil {
+			return &FieldError{Key: "worktree_path_template", Err: eris.Wrap(err, "invalid worktree_path_template")}
+		}
+	}
+
+	// Validate new branch names
+	if err := validateBranchTemplate(config.BranchTemplate); err != nil {
+		return err
+	}
+	if err := validateBranchTypes(config.BranchTypes); err != nil {
+		return err
+	}
+
+	// Validate issue branch template
+	if config.IssueBranchTemplate != "" {
+		if err := pr.ValidateIssueBranchTemplate(config.IssueBranchTemplate); err != nil {
+			return &FieldError{Key: "issue_branch_template", Err: eris.Wrap(err, "invalid issue_branch_template")}
 		}
 	}
 
+	// Validate terminal command
+	if err := session.ValidateTerminalCommand(config.TerminalCommand); err != nil {
+		return &FieldError{Key: "terminal_command", Err: eris.Wrap(err, "invalid terminal_command")}
+	}
+
+	// Validate clone options
+	if err := validateClone(config.Clone); err != nil {
+		return err
+	}
+
+	// Validate remotes
+	if err := validateRemote(config.Remote); err != nil {
+		return err
+	}
+
+	// Validate project aliases
+	if err := validateProjectAliases(config.ProjectAliases); err != nil {
+		return err
+	}
+
+	// Validate project tags
+	if err := validateProjectTags(config.ProjectTags); err != nil {
+		return err
+	}
+
+	// Validate provider tokens
+	if err := validateProviderTokens(config.ProviderTokens); err != nil {
+		return err
+	}
+
+	// Validate session reaping
+	if err := validateReapSessions(config.ReapSessions); err != nil {
+		return err
+	}
+	if err := validateSessionIdleTTL(config.SessionIdleTTL); err != nil {
+		return err
+	}
+
+	// Validate tmux keybindings
+	if err := validateTmux(config.Tmux); err != nil {
+		return err
+	}
+
+	// Validate ports
+	if err := validatePorts(config.Ports); err != nil {
+		return err
+	}
+
+	// Validate theme
+	if err := validateTheme(config.Theme); err != nil {
+		return err
+	}
+
+	// Validate profiles
+	if err := validateProfiles(config); err != nil {
+		return err
+	}
+
+	// Validate workspace directories (if provided, they should be expandable and have unique names)
+	if err := validateWorkspaces(config.WorkspaceDir); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // ValidateConfigFile validates a config file at the given path
+// Errors about a setting are *FieldError values with the line of the setting.
 func ValidateConfigFile(configPath string) error {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return eris.Wrapf(err, "failed to read config file: %s", configPath)
 	}
 
+	// Decoding a node keeps the positions of the settings for reporting errors
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return eris.Wrapf(err, "failed to parse config file: %s", configPath)
+	}
+
 	var config configFile
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := doc.Decode(&config); err != nil {
 		return eris.Wrapf(err, "failed to parse config file: %s", configPath)
 	}
 
@@ -400,5 +1486,12 @@ func ValidateConfigFile(configPath string) error {
 		// For now, we'll just warn but not fail
 	}
 
-	return ValidateConfig(&config)
+	err = ValidateConfig(&config)
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		if node := lookupNode(&doc, fieldErr.Key); node != nil {
+			fieldErr.Line = node.Line
+		}
+	}
+	return err
 }
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestExpandHome(t *testing.T) {
@@ -397,7 +398,7 @@ func TestValidateConfig(t *testing.T) {
 			name: "valid config with all fields",
 			config: configFile{
 				Version:        "1",
-				WorkspaceDir:   "~/projects",
+				WorkspaceDir:   WorkspaceDirs{{Path: "~/projects"}},
 				SessionBackend: "tmux",
 				FuzzyFinder:    "fzf",
 				StartupCommand: "echo hello",
@@ -408,7 +409,7 @@ func TestValidateConfig(t *testing.T) {
 			name: "valid config with auto values",
 			config: configFile{
 				Version:        "1",
-				WorkspaceDir:   "~/.sesh",
+				WorkspaceDir:   WorkspaceDirs{{Path: "~/.sesh"}},
 				SessionBackend: "auto",
 				FuzzyFinder:    "auto",
 			},
@@ -466,10 +467,16 @@ func TestSaveAndLoadConfig(t *testing.T) {
 
 	// Create test config
 	testConfig := &Config{
-		WorkspaceDir:   "~/test-projects",
-		SessionBackend: "tmux",
-		FuzzyFinder:    "fzf",
-		StartupCommand: "echo test",
+		WorkspaceDir:       "~/test-projects",
+		SessionBackend:     "tmux",
+		FuzzyFinder:        "fzf",
+		StartupCommand:     "echo test",
+		TrashRetentionDays: 14,
+		DiscoveryMaxDepth:  4,
+		PreviewTemplate:    "{{ .Branch }}\n{{ template \"commit\" . }}\n",
+		Theme:              Theme{Info: "blue", Warn: "bold 208"},
+		LogLevel:           "debug",
+		LogFile:            true,
 	}
 
 	// Save config
@@ -498,8 +505,8 @@ func TestSaveAndLoadConfig(t *testing.T) {
 		t.Errorf("Version = %q, want %q", loadedConfig.Version, CurrentConfigVersion)
 	}
 
-	if loadedConfig.WorkspaceDir != testConfig.WorkspaceDir {
-		t.Errorf("WorkspaceDir = %q, want %q", loadedConfig.WorkspaceDir, testConfig.WorkspaceDir)
+	if len(loadedConfig.WorkspaceDir) != 1 || loadedConfig.WorkspaceDir[0].Path != testConfig.WorkspaceDir {
+		t.Errorf("WorkspaceDir = %v, want %q", loadedConfig.WorkspaceDir, testConfig.WorkspaceDir)
 	}
 
 	if loadedConfig.SessionBackend != testConfig.SessionBackend {
@@ -513,4 +520,654 @@ func TestSaveAndLoadConfig(t *testing.T) {
 	if loadedConfig.StartupCommand != testConfig.StartupCommand {
 		t.Errorf("StartupCommand = %q, want %q", loadedConfig.StartupCommand, testConfig.StartupCommand)
 	}
+
+	if loadedConfig.TrashRetentionDays == nil || *loadedConfig.TrashRetentionDays != testConfig.TrashRetentionDays {
+		t.Errorf("TrashRetentionDays = %v, want %d", loadedConfig.TrashRetentionDays, testConfig.TrashRetentionDays)
+	}
+
+	if loadedConfig.DiscoveryMaxDepth == nil || *loadedConfig.DiscoveryMaxDepth != testConfig.DiscoveryMaxDepth {
+		t.Errorf("DiscoveryMaxDepth = %v, want %d", loadedConfig.DiscoveryMaxDepth, testConfig.DiscoveryMaxDepth)
+	}
+
+	if loadedConfig.PreviewTemplate != testConfig.PreviewTemplate {
+		t.Errorf("PreviewTemplate = %q, want %q", loadedConfig.PreviewTemplate, testConfig.PreviewTemplate)
+	}
+
+	if loadedConfig.Theme != testConfig.Theme {
+		t.Errorf("Theme = %+v, want %+v", loadedConfig.Theme, testConfig.Theme)
+	}
+
+	if loadedConfig.LogLevel != testConfig.LogLevel || loadedConfig.LogFile != testConfig.LogFile {
+		t.Errorf("LogLevel, LogFile = %q, %v, want %q, %v",
+			loadedConfig.LogLevel, loadedConfig.LogFile, testConfig.LogLevel, testConfig.LogFile)
+	}
+}
+
+func TestGetTrashRetentionDays(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: "", want: DefaultTrashRetentionDays},
+		{name: "from environment", env: "30", want: 30},
+		{name: "disabled", env: "0", want: 0},
+		{name: "negative", env: "-1", wantErr: true},
+		{name: "not a number", env: "week", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_TRASH_RETENTION_DAYS", tt.env)
+
+			got, err := GetTrashRetentionDays()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTrashRetentionDays() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetTrashRetentionDays() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDiscoveryMaxDepth(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: "", want: DefaultDiscoveryMaxDepth},
+		{name: "from environment", env: "3", want: 3},
+		{name: "unlimited", env: "0", want: 0},
+		{name: "negative", env: "-2", wantErr: true},
+		{name: "not a number", env: "deep", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_DISCOVERY_MAX_DEPTH", tt.env)
+
+			got, err := GetDiscoveryMaxDepth()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetDiscoveryMaxDepth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetDiscoveryMaxDepth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWorktreePathTemplate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", env: "", want: "{workspace}/{project}/{branch}"},
+		{name: "home is expanded", env: "~/worktrees/{repo}/{branch}", want: filepath.Join(home, "worktrees/{repo}/{branch}")},
+		{name: "without branch", env: "~/worktrees/{repo}", wantErr: true},
+		{name: "unknown placeholder", env: "~/worktrees/{name}/{branch}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_WORKTREE_PATH_TEMPLATE", tt.env)
+
+			got, err := GetWorktreePathTemplate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetWorktreePathTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetWorktreePathTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetZoxide(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		env     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default", env: "", want: false},
+		{name: "enabled", env: "true", want: true},
+		{name: "enabled as number", env: "1", want: true},
+		{name: "disabled", env: "false", want: false},
+		{name: "invalid", env: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_ZOXIDE", tt.env)
+
+			got, err := GetZoxide()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetZoxide() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetZoxide() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetReflinkWorktrees(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	writeConfigFile(t, "reflink_worktrees: true\n")
+
+	tests := []struct {
+		name    string
+		env     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "config file", env: "", want: true},
+		{name: "env overrides config file", env: "false", want: false},
+		{name: "invalid", env: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_REFLINK_WORKTREES", tt.env)
+
+			got, err := GetReflinkWorktrees()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetReflinkWorktrees() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetReflinkWorktrees() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSubmodules(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name          string
+		config        string
+		env           string
+		projectConfig string
+		want          bool
+		wantErr       bool
+	}{
+		{name: "default", want: true},
+		{name: "disabled in config file", config: "submodules: false\n", want: false},
+		{name: "environment overrides config file", config: "submodules: false\n", env: "true", want: true},
+		{name: "project overrides environment", env: "true", projectConfig: "submodules: false\n", want: false},
+		{name: "project enables", config: "submodules: false\n", projectConfig: "submodules: true\n", want: true},
+		{name: "invalid environment", env: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_SUBMODULES", tt.env)
+			projectPath := t.TempDir()
+			if tt.projectConfig != "" {
+				if err := os.WriteFile(filepath.Join(projectPath, ".sesh.yaml"), []byte(tt.projectConfig), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := GetSubmodules(projectPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSubmodules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetSubmodules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLFS(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name          string
+		config        string
+		env           string
+		projectConfig string
+		want          bool
+		wantErr       bool
+	}{
+		{name: "default", want: false},
+		{name: "project opts in", projectConfig: "lfs: true\n", want: true},
+		{name: "enabled in config file", config: "lfs: true\n", want: true},
+		{name: "project opts out", config: "lfs: true\n", projectConfig: "lfs: false\n", want: false},
+		{name: "environment overrides config file", config: "lfs: true\n", env: "false", want: false},
+		{name: "invalid environment", env: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_LFS", tt.env)
+			projectPath := t.TempDir()
+			if tt.projectConfig != "" {
+				if err := os.WriteFile(filepath.Join(projectPath, ".sesh.yaml"), []byte(tt.projectConfig), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := GetLFS(projectPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetLFS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetLFS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDevcontainer(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name          string
+		config        string
+		env           string
+		projectConfig string
+		want          bool
+		wantErr       bool
+	}{
+		{name: "default", want: false},
+		{name: "project opts in", projectConfig: "devcontainer: true\n", want: true},
+		{name: "enabled in config file", config: "devcontainer: true\n", want: true},
+		{name: "project opts out", config: "devcontainer: true\n", projectConfig: "devcontainer: false\n", want: false},
+		{name: "environment overrides config file", config: "devcontainer: false\n", env: "true", want: true},
+		{name: "invalid environment", env: "maybe", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_DEVCONTAINER", tt.env)
+			projectPath := t.TempDir()
+			if tt.projectConfig != "" {
+				if err := os.WriteFile(filepath.Join(projectPath, ".sesh.yaml"), []byte(tt.projectConfig), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := GetDevcontainer(projectPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetDevcontainer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetDevcontainer() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFetchIfOlderThan(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: DefaultFetchIfOlderThan},
+		{name: "config file", config: "fetch_if_older_than: 1h\n", want: time.Hour},
+		{name: "always fetch", config: "fetch_if_older_than: 0s\n", want: 0},
+		{name: "environment overrides config file", config: "fetch_if_older_than: 1h\n", env: "5m", want: 5 * time.Minute},
+		{name: "invalid environment", env: "soon", wantErr: true},
+		{name: "negative environment", env: "-5m", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_FETCH_IF_OLDER_THAN", tt.env)
+
+			got, err := GetFetchIfOlderThan()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFetchIfOlderThan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetFetchIfOlderThan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGitTimeout(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: DefaultGitTimeout},
+		{name: "config file", config: "git_timeout: 2m\n", want: 2 * time.Minute},
+		{name: "unlimited", config: "git_timeout: 0s\n", want: 0},
+		{name: "environment overrides config file", config: "git_timeout: 2m\n", env: "30s", want: 30 * time.Second},
+		{name: "invalid environment", env: "forever", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_GIT_TIMEOUT", tt.env)
+
+			got, err := GetGitTimeout()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetGitTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetGitTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGitRetries(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: DefaultGitRetries},
+		{name: "config file", config: "git_retries: 5\n", want: 5},
+		{name: "never", config: "git_retries: 0\n", want: 0},
+		{name: "environment overrides config file", config: "git_retries: 5\n", env: "1", want: 1},
+		{name: "invalid environment", env: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_GIT_RETRIES", tt.env)
+
+			got, err := GetGitRetries()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetGitRetries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetGitRetries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetWorktreePool(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 0},
+		{name: "config file", config: "worktree_pool: 3\n", want: 3},
+		{name: "environment overrides config file", config: "worktree_pool: 3\n", env: "0", want: 0},
+		{name: "invalid environment", env: "some", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_WORKTREE_POOL", tt.env)
+
+			got, err := GetWorktreePool()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetWorktreePool() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetWorktreePool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFetchPrune(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default", want: true},
+		{name: "disabled in config file", config: "fetch_prune: false\n", want: false},
+		{name: "environment overrides config file", config: "fetch_prune: false\n", env: "true", want: true},
+		{name: "invalid environment", env: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_FETCH_PRUNE", tt.env)
+
+			got, err := GetFetchPrune()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetFetchPrune() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetFetchPrune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTheme(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		want    Theme
+		wantErr bool
+	}{
+		{name: "default", config: "", want: Theme{}},
+		{
+			name:   "light terminal",
+			config: "theme:\n  info: blue\n  warn: bold 208\n  faint: bright-black\n",
+			want:   Theme{Info: "blue", Warn: "bold 208", Faint: "bright-black"},
+		},
+		{name: "unknown color", config: "theme:\n  error: crimson\n", wantErr: true},
+		{name: "color out of range", config: "theme:\n  success: \"256\"\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath, err := GetConfigPath()
+			if err != nil {
+				t.Fatalf("GetConfigPath() returned error: %v", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+				t.Fatalf("MkdirAll() failed: %v", err)
+			}
+			if err := os.WriteFile(configPath, []byte(tt.config), 0o644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			got, err := GetTheme()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetTheme() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetTheme() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLogLevel(t *testing.T) {
+	// Set HOME to temp directory to isolate from real config
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", env: "", want: "warn"},
+		{name: "debug", env: "debug", want: "debug"},
+		{name: "error", env: "error", want: "error"},
+		{name: "invalid", env: "loud", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_LOG_LEVEL", tt.env)
+
+			got, err := GetLogLevel()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetLogLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("GetLogLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProjectConfigReadyCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		projectConfig string
+		wantPort      int
+		wantTimeout   time.Duration
+		wantErr       bool
+	}{
+		{name: "none", projectConfig: "startup_command: make dev\n"},
+		{name: "port", projectConfig: "ready_check:\n  port: 3000\n  timeout: 2m\n", wantPort: 3000, wantTimeout: 2 * time.Minute},
+		{name: "neither port nor url", projectConfig: "ready_check:\n  timeout: 2m\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(projectPath, ".sesh.yaml"), []byte(tt.projectConfig), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadProjectConfig(projectPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadProjectConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantPort == 0 {
+				if got.ReadyCheck != nil {
+					t.Errorf("ReadyCheck = %+v, want nil", got.ReadyCheck)
+				}
+				return
+			}
+			if got.ReadyCheck == nil || got.ReadyCheck.Port != tt.wantPort || got.ReadyCheck.Timeout != tt.wantTimeout {
+				t.Errorf("ReadyCheck = %+v, want port %d and timeout %s", got.ReadyCheck, tt.wantPort, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadProjectConfigSubprojects(t *testing.T) {
+	tests := []struct {
+		name          string
+		projectConfig string
+		wantErr       bool
+	}{
+		{name: "valid", projectConfig: "subprojects:\n  api:\n    path: services/api\n    session_name: api\n"},
+		{name: "missing path", projectConfig: "subprojects:\n  api:\n    startup_command: make dev\n", wantErr: true},
+		{name: "path outside", projectConfig: "subprojects:\n  api:\n    path: ../api\n", wantErr: true},
+		{name: "absolute path", projectConfig: "subprojects:\n  api:\n    path: /srv/api\n", wantErr: true},
+		{name: "invalid name", projectConfig: "subprojects:\n  api.v2:\n    path: api\n", wantErr: true},
+		{
+			name:          "invalid session name",
+			projectConfig: "subprojects:\n  api:\n    path: api\n    session_name: a:b\n",
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(projectPath, ".sesh.yaml"), []byte(tt.projectConfig), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadProjectConfig(projectPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadProjectConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			sub, err := got.Subproject("api")
+			if err != nil {
+				t.Fatalf("Subproject(api) error = %v", err)
+			}
+			if sub.Path != "services/api" || sub.SessionName != "api" {
+				t.Errorf("Subproject(api) = %+v, want path services/api and session name api", sub)
+			}
+			if _, err := got.Subproject("web"); err == nil {
+				t.Error("Subproject(web) succeeded, want an error")
+			}
+		})
+	}
 }
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"strconv"
+
+	"github.com/rotisserie/eris"
+)
+
+// Default range of the ports sesh assigns to worktrees
+const (
+	DefaultPortsStart     = 20000
+	DefaultPortsBlockSize = 10
+
+	// maxPortsBlockSize keeps a worktree from claiming a large part of the port range
+	maxPortsBlockSize = 100
+)
+
+// PortsConfig holds where the blocks of ports assigned to worktrees start, and how many ports
+// each has; zero values keep the defaults
+type PortsConfig struct {
+	// First port assigned, e.g. 20000
+	Start int `yaml:"start,omitempty"`
+	// Number of ports in the block of each worktree, exposed as SESH_PORT, SESH_PORT_2, ...
+	BlockSize int `yaml:"block_size,omitempty"`
+}
+
+// GetPortsConfig returns the port settings from the config file
+func GetPortsConfig() (PortsConfig, error) {
+	// 1. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		if err := validatePorts(config.Ports); err != nil {
+			return PortsConfig{}, err
+		}
+		return config.Ports, nil
+	}
+
+	// 2. Default (lowest priority)
+	return PortsConfig{}, nil
+}
+
+// WithDefaults returns the settings with the defaults in place of the empty ones
+func (c PortsConfig) WithDefaults() PortsConfig {
+	if c.Start == 0 {
+		c.Start = DefaultPortsStart
+	}
+	if c.BlockSize == 0 {
+		c.BlockSize = DefaultPortsBlockSize
+	}
+	return c
+}
+
+// Env returns the environment variables of the block of ports starting at basePort: SESH_PORT
+// for the first port, and SESH_PORT_2, SESH_PORT_3, ... for the next ones
+func (c PortsConfig) Env(basePort int) map[string]string {
+	env := map[string]string{"SESH_PORT": strconv.Itoa(basePort)}
+	for i := 2; i <= c.WithDefaults().BlockSize; i++ {
+		env["SESH_PORT_"+strconv.Itoa(i)] = strconv.Itoa(basePort + i - 1)
+	}
+	return env
+}
+
+// validatePorts checks that the blocks of ports are unprivileged ports that exist
+func validatePorts(ports PortsConfig) error {
+	if ports.Start != 0 && (ports.Start < 1024 || ports.Start > 65535) {
+		return &FieldError{
+			Key: "ports.start",
+			Err: eris.Errorf("invalid ports.start: %d (must be between 1024 and 65535)", ports.Start),
+		}
+	}
+	if ports.BlockSize < 0 || ports.BlockSize > maxPortsBlockSize {
+		return &FieldError{
+			Key: "ports.block_size",
+			Err: eris.Errorf("invalid ports.block_size: %d (must be between 1 and %d)", ports.BlockSize, maxPortsBlockSize),
+		}
+	}
+	if ports := ports.WithDefaults(); ports.Start+ports.BlockSize > 65536 {
+		return &FieldError{Key: "ports", Err: eris.New("the first block of ports must end before port 65536")}
+	}
+	return nil
+}
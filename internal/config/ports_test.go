@@ -0,0 +1,52 @@
+package config
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestValidatePorts(t *testing.T) {
+	tests := []struct {
+		name    string
+		ports   PortsConfig
+		wantKey string
+	}{
+		{name: "unset", ports: PortsConfig{}},
+		{name: "valid", ports: PortsConfig{Start: 30000, BlockSize: 4}},
+		{name: "privileged port", ports: PortsConfig{Start: 80}, wantKey: "ports.start"},
+		{name: "negative block size", ports: PortsConfig{BlockSize: -1}, wantKey: "ports.block_size"},
+		{name: "block size too large", ports: PortsConfig{BlockSize: 1000}, wantKey: "ports.block_size"},
+		{name: "block past the last port", ports: PortsConfig{Start: 65530}, wantKey: "ports"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePorts(tt.ports)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Errorf("validatePorts() returned error: %v", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok {
+				t.Fatalf("validatePorts() error = %v, want a *FieldError", err)
+			}
+			if fieldErr.Key != tt.wantKey {
+				t.Errorf("validatePorts() error key = %q, want %q", fieldErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestPortsConfigEnv(t *testing.T) {
+	env := PortsConfig{BlockSize: 3}.Env(20010)
+	want := map[string]string{"SESH_PORT": "20010", "SESH_PORT_2": "20011", "SESH_PORT_3": "20012"}
+	if !maps.Equal(env, want) {
+		t.Errorf("Env() = %v, want %v", env, want)
+	}
+
+	if env := (PortsConfig{}).Env(20000); len(env) != DefaultPortsBlockSize || env["SESH_PORT_10"] != "20009" {
+		t.Errorf("Env() with the default block size = %v, want SESH_PORT to SESH_PORT_10", env)
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rotisserie/eris"
+)
+
+// Profile is a named set of settings that replace the top-level ones when it is active,
+// e.g. to keep the workspaces and credentials of clients apart
+type Profile struct {
+	WorkspaceDir   WorkspaceDirs `yaml:"workspace_dir,omitempty"`
+	SessionBackend string        `yaml:"session_backend,omitempty"`
+	StartupCommand string        `yaml:"startup_command,omitempty"`
+	// Environment variables set for sesh and the git and gh commands it runs, e.g.
+	// GIT_SSH_COMMAND to use another SSH key or GH_CONFIG_DIR to use another GitHub account
+	Env map[string]string `yaml:"env,omitempty"`
+	// Host is the SSH destination the workspace, git, and sessions of the profile live on, e.g.
+	// "devbox" or "me@devbox"; sesh then runs its commands there with ssh
+	Host string `yaml:"host,omitempty"`
+	// RemoteCommand starts sesh on the host, e.g. "~/go/bin/sesh" when it isn't on the PATH of
+	// ssh's shell; "sesh" by default
+	RemoteCommand string `yaml:"remote_command,omitempty"`
+}
+
+// DefaultRemoteCommand starts sesh on the host of a remote profile
+const DefaultRemoteCommand = "sesh"
+
+// profileNamePattern limits profile names to what can be used in file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// GetProfile returns the name of the active profile, with configuration hierarchy
+// An empty name means no profile is active. Selecting a profile that isn't defined is an error.
+func GetProfile() (string, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		config = &configFile{}
+	}
+
+	name := profileName(config)
+	if name == "" {
+		return "", nil
+	}
+	if _, ok := config.Profiles[name]; !ok {
+		return "", unknownProfileError(name, config)
+	}
+	return name, nil
+}
+
+// ListProfiles returns the names of the profiles defined in the config file, sorted
+func ListProfiles() ([]string, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(config.Profiles)), nil
+}
+
+// GetProfileEnv returns the environment variables of the active profile, with ~ expanded
+func GetProfileEnv() (map[string]string, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	name := profileName(config)
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return nil, unknownProfileError(name, config)
+	}
+
+	// Values are often paths, like in the rest of the config file
+	env := make(map[string]string, len(profile.Env))
+	for key, value := range profile.Env {
+		expanded, err := expandHome(value)
+		if err != nil {
+			return nil, err
+		}
+		env[key] = expanded
+	}
+	return env, nil
+}
+
+// GetProfileHost returns the SSH destination of the active profile and the command starting sesh
+// there, or an empty destination when the profile, or no profile, is local
+func GetProfileHost() (string, string, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return "", "", err
+	}
+
+	name := profileName(config)
+	if name == "" {
+		return "", "", nil
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return "", "", unknownProfileError(name, config)
+	}
+
+	command := profile.RemoteCommand
+	if command == "" {
+		command = DefaultRemoteCommand
+	}
+	return profile.Host, command, nil
+}
+
+// profileName returns the name of the selected profile
+// Priority: SESH_PROFILE (also set by --profile) > profile in the config file
+func profileName(config *configFile) string {
+	if envProfile := os.Getenv("SESH_PROFILE"); envProfile != "" {
+		return envProfile
+	}
+	return config.Profile
+}
+
+// activeProfileName returns the name of the selected profile if it can be used in file names
+func activeProfileName() string {
+	config, err := readConfigFile()
+	if err != nil {
+		config = &configFile{}
+	}
+
+	name := profileName(config)
+	if !profileNamePattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// applyProfile replaces the settings of the config file with those of the active profile
+func applyProfile(config *configFile) error {
+	name := profileName(config)
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return unknownProfileError(name, config)
+	}
+
+	if len(profile.WorkspaceDir) > 0 {
+		config.WorkspaceDir = profile.WorkspaceDir
+	}
+	if profile.SessionBackend != "" {
+		config.SessionBackend = profile.SessionBackend
+	}
+	if profile.StartupCommand != "" {
+		config.StartupCommand = profile.StartupCommand
+	}
+	return nil
+}
+
+// validateProfiles checks the profile names, the default profile, and the settings of each profile
+func validateProfiles(config *configFile) error {
+	for _, name := range slices.Sorted(maps.Keys(config.Profiles)) {
+		key := "profiles." + name
+		if !profileNamePattern.MatchString(name) {
+			return &FieldError{Key: key, Err: eris.Errorf(
+				"invalid profile name: %s (must be letters, digits, '-', and '_')", name,
+			)}
+		}
+
+		profile := config.Profiles[name]
+		err := ValidateConfig(&configFile{WorkspaceDir: profile.WorkspaceDir, SessionBackend: profile.SessionBackend})
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			return &FieldError{Key: key + "." + fieldErr.Key, Err: eris.Wrapf(fieldErr.Err, "invalid profile %s", name)}
+		}
+		if err != nil {
+			return err
+		}
+
+		// ssh would take a destination starting with '-' for an option
+		if strings.HasPrefix(profile.Host, "-") || strings.ContainsFunc(profile.Host, unicode.IsSpace) {
+			return &FieldError{Key: key + ".host", Err: eris.Errorf(
+				"invalid host of profile %s: %q (must be an SSH destination, e.g. devbox or me@devbox)", name, profile.Host,
+			)}
+		}
+		if profile.RemoteCommand != "" && profile.Host == "" {
+			return &FieldError{Key: key + ".remote_command", Err: eris.Errorf(
+				"remote_command of profile %s requires a host", name,
+			)}
+		}
+	}
+
+	if config.Profile != "" {
+		if _, ok := config.Profiles[config.Profile]; !ok {
+			return &FieldError{Key: "profile", Err: unknownProfileError(config.Profile, config)}
+		}
+	}
+
+	return nil
+}
+
+// unknownProfileError lists the defined profiles for a profile that isn't
+func unknownProfileError(name string, config *configFile) error {
+	names := slices.Sorted(maps.Keys(config.Profiles))
+	if len(names) == 0 {
+		return eris.Errorf("unknown profile: %s (no profiles are defined in the config file)", name)
+	}
+	return eris.Errorf("unknown profile: %s (must be one of: %s)", name, strings.Join(names, ", "))
+}
+
+// profileFileName returns the name of a per-profile file, e.g. sesh-work.db for the work profile
+func profileFileName(base, ext string) string {
+	if profile := activeProfileName(); profile != "" {
+		return base + "-" + profile + ext
+	}
+	return base + ext
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const profilesConfig = `version: "1"
+workspace_dir: ~/code
+session_backend: tmux
+profile: personal
+profiles:
+  personal:
+    workspace_dir: ~/personal
+  acme:
+    workspace_dir: ~/clients/acme
+    session_backend: zellij
+    env:
+      GH_CONFIG_DIR: ~/.config/gh-acme
+  devbox:
+    host: me@devbox
+`
+
+func TestProfiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("SESH_WORKSPACE", "")
+	t.Setenv("SESH_SESSION_BACKEND", "")
+	writeConfigFile(t, profilesConfig)
+
+	tests := []struct {
+		name          string
+		env           string
+		wantProfile   string
+		wantWorkspace string
+		wantBackend   string
+		wantDB        string
+		wantErr       bool
+	}{
+		{
+			name:          "default profile",
+			wantProfile:   "personal",
+			wantWorkspace: filepath.Join(home, "personal"),
+			wantBackend:   "tmux",
+			wantDB:        "sesh-personal.db",
+		},
+		{
+			name:          "selected profile",
+			env:           "acme",
+			wantProfile:   "acme",
+			wantWorkspace: filepath.Join(home, "clients", "acme"),
+			wantBackend:   "zellij",
+			wantDB:        "sesh-acme.db",
+		},
+		{name: "unknown profile", env: "globex", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SESH_PROFILE", tt.env)
+
+			cfg, err := LoadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "must be one of: acme, devbox, personal") {
+					t.Errorf("error = %q, want it to list the profiles", err.Error())
+				}
+				return
+			}
+
+			if cfg.Profile != tt.wantProfile {
+				t.Errorf("Profile = %q, want %q", cfg.Profile, tt.wantProfile)
+			}
+			if cfg.WorkspaceDir != tt.wantWorkspace {
+				t.Errorf("WorkspaceDir = %q, want %q", cfg.WorkspaceDir, tt.wantWorkspace)
+			}
+			if cfg.SessionBackend != tt.wantBackend {
+				t.Errorf("SessionBackend = %q, want %q", cfg.SessionBackend, tt.wantBackend)
+			}
+
+			dbPath, err := GetDBPath()
+			if err != nil || filepath.Base(dbPath) != tt.wantDB {
+				t.Errorf("GetDBPath() = %q, %v; want %q", dbPath, err, tt.wantDB)
+			}
+			cacheDir, err := GetCacheDir()
+			if err != nil || filepath.Base(cacheDir) != tt.wantProfile {
+				t.Errorf("GetCacheDir() = %q, %v; want a directory of the profile", cacheDir, err)
+			}
+		})
+	}
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("SESH_PROFILE", "acme")
+		env, err := GetProfileEnv()
+		if err != nil {
+			t.Fatalf("GetProfileEnv() failed: %v", err)
+		}
+		if env["GH_CONFIG_DIR"] != filepath.Join(home, ".config", "gh-acme") {
+			t.Errorf("GetProfileEnv() = %v, want GH_CONFIG_DIR", env)
+		}
+	})
+
+	t.Run("host", func(t *testing.T) {
+		for profile, want := range map[string]string{"acme": "", "devbox": "me@devbox"} {
+			t.Setenv("SESH_PROFILE", profile)
+			host, command, err := GetProfileHost()
+			if err != nil || host != want || command != DefaultRemoteCommand {
+				t.Errorf("GetProfileHost() for %s = %q, %q, %v; want %q, %q",
+					profile, host, command, err, want, DefaultRemoteCommand)
+			}
+		}
+	})
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  configFile
+		wantKey string
+	}{
+		{
+			name:   "valid",
+			config: configFile{Profile: "work", Profiles: map[string]Profile{"work": {SessionBackend: "tmux"}}},
+		},
+		{
+			name:    "unknown default profile",
+			config:  configFile{Profile: "home", Profiles: map[string]Profile{"work": {}}},
+			wantKey: "profile",
+		},
+		{
+			name:    "invalid name",
+			config:  configFile{Profiles: map[string]Profile{"../work": {}}},
+			wantKey: "profiles.../work",
+		},
+		{
+			name:    "invalid host",
+			config:  configFile{Profiles: map[string]Profile{"devbox": {Host: "-oProxyCommand=sh"}}},
+			wantKey: "profiles.devbox.host",
+		},
+		{
+			name:    "remote command without host",
+			config:  configFile{Profiles: map[string]Profile{"devbox": {RemoteCommand: "~/go/bin/sesh"}}},
+			wantKey: "profiles.devbox.remote_command",
+		},
+		{
+			name:    "invalid setting",
+			config:  configFile{Profiles: map[string]Profile{"work": {SessionBackend: "kitty"}}},
+			wantKey: "profiles.work.session_backend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConfig(&tt.config)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Errorf("ValidateConfig() = %v, want nil", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok || fieldErr.Key != tt.wantKey {
+				t.Errorf("ValidateConfig() = %v, want an error for %s", err, tt.wantKey)
+			}
+		})
+	}
+}
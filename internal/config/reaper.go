@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// Policies for killing sessions whose worktree no longer exists, in every project
+const (
+	// ReapSessionsNever leaves them to 'sesh clean' and the other commands that clean up after
+	// one project
+	ReapSessionsNever = "never"
+	// ReapSessionsDaemon has 'sesh daemon' kill them every interval
+	ReapSessionsDaemon = "daemon"
+	// ReapSessionsAlways also kills them in the background after every command
+	ReapSessionsAlways = "always"
+)
+
+// reapSessionsPolicies are the valid values of reap_sessions
+var reapSessionsPolicies = []string{ReapSessionsNever, ReapSessionsDaemon, ReapSessionsAlways}
+
+// GetReapSessions returns when sessions whose worktree no longer exists are killed, with
+// configuration hierarchy
+func GetReapSessions() (string, error) {
+	// 1. Environment variable (highest priority)
+	if envPolicy := os.Getenv("SESH_REAP_SESSIONS"); envPolicy != "" {
+		if !slices.Contains(reapSessionsPolicies, envPolicy) {
+			return "", eris.Errorf("invalid SESH_REAP_SESSIONS: %s (must be one of: never, daemon, always)", envPolicy)
+		}
+		return envPolicy, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && config.ReapSessions != "" {
+		if err := validateReapSessions(config.ReapSessions); err != nil {
+			return "", err
+		}
+		return config.ReapSessions, nil
+	}
+
+	// 3. Default (lowest priority)
+	return ReapSessionsDaemon, nil
+}
+
+// validateReapSessions checks the session reaping policy
+func validateReapSessions(policy string) error {
+	if policy != "" && !slices.Contains(reapSessionsPolicies, policy) {
+		return &FieldError{
+			Key: "reap_sessions",
+			Err: eris.Errorf("invalid reap_sessions: %s (must be one of: never, daemon, always)", policy),
+		}
+	}
+	return nil
+}
+
+// GetSessionIdleTTL returns how long a session may go without a client attached before it is
+// killed, with configuration hierarchy. 0 keeps idle sessions.
+func GetSessionIdleTTL() (time.Duration, error) {
+	// 1. Environment variable (highest priority)
+	if envTTL := os.Getenv("SESH_SESSION_IDLE_TTL"); envTTL != "" {
+		ttl, err := time.ParseDuration(envTTL)
+		if err != nil || ttl < 0 {
+			return 0, eris.Errorf("invalid SESH_SESSION_IDLE_TTL: %s (must be a duration like 12h, or 0s)", envTTL)
+		}
+		return ttl, nil
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		if err := validateSessionIdleTTL(config.SessionIdleTTL); err != nil {
+			return 0, err
+		}
+		return config.SessionIdleTTL, nil
+	}
+
+	// 3. Default (lowest priority)
+	return 0, nil
+}
+
+// validateSessionIdleTTL checks the idle time after which sessions are killed
+func validateSessionIdleTTL(ttl time.Duration) error {
+	if ttl < 0 {
+		return &FieldError{
+			Key: "session_idle_ttl",
+			Err: eris.Errorf("invalid session_idle_ttl: %s (must be 0 or greater)", ttl),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetReapSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: ReapSessionsDaemon},
+		{name: "config file", file: "reap_sessions: always\n", want: ReapSessionsAlways},
+		{name: "environment wins", file: "reap_sessions: always\n", env: "never", want: ReapSessionsNever},
+		{name: "invalid config file", file: "reap_sessions: sometimes\n", wantErr: true},
+		{name: "invalid environment", env: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("SESH_REAP_SESSIONS", tt.env)
+			if tt.file != "" {
+				writeConfigFile(t, tt.file)
+			}
+
+			got, err := GetReapSessions()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetReapSessions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetReapSessions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSessionIdleTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", want: 0},
+		{name: "config file", file: "session_idle_ttl: 12h\n", want: 12 * time.Hour},
+		{name: "environment wins", file: "session_idle_ttl: 12h\n", env: "30m", want: 30 * time.Minute},
+		{name: "negative config file", file: "session_idle_ttl: -1h\n", wantErr: true},
+		{name: "invalid environment", env: "a while", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv("XDG_CONFIG_HOME", "")
+			t.Setenv("SESH_SESSION_IDLE_TTL", tt.env)
+			if tt.file != "" {
+				writeConfigFile(t, tt.file)
+			}
+
+			got, err := GetSessionIdleTTL()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSessionIdleTTL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetSessionIdleTTL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/rotisserie/eris"
+)
+
+// DefaultRemote is the remote sesh lists branches from and tracks when none is configured
+const DefaultRemote = "origin"
+
+// RemoteConfig holds the primary remote, by default and per project. The primary remote is
+// the one whose branches sesh offers and that new branches track, e.g. "upstream" for a fork
+// whose origin is your own copy.
+type RemoteConfig struct {
+	Name string `yaml:"name,omitempty"`
+	// Primary remotes of the projects matching a pattern like "github.com/me/*". When several
+	// patterns match, the longest one wins.
+	Projects map[string]string `yaml:"projects,omitempty"`
+}
+
+// remoteNamePattern matches the remote names sesh accepts, leaving out ones git would take as
+// part of a branch name
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// GetRemoteConfig returns the primary remote settings
+// Priority: SESH_REMOTE (for the default) > config file > "origin"
+func GetRemoteConfig() (RemoteConfig, error) {
+	var remote RemoteConfig
+
+	// 1. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		if err := validateRemote(config.Remote); err != nil {
+			return RemoteConfig{}, err
+		}
+		remote = config.Remote
+	}
+
+	// 2. Environment variable (highest priority)
+	if name := os.Getenv("SESH_REMOTE"); name != "" {
+		if !remoteNamePattern.MatchString(name) {
+			return RemoteConfig{}, eris.Errorf("invalid SESH_REMOTE: %s", name)
+		}
+		remote.Name = name
+	}
+
+	return remote, nil
+}
+
+// For returns the primary remote of a project: that of the longest matching pattern, or the
+// default one
+func (c RemoteConfig) For(projectName string) string {
+	if best := longestMatch(c.Projects, projectName); best != "" {
+		return c.Projects[best]
+	}
+	if c.Name != "" {
+		return c.Name
+	}
+	return DefaultRemote
+}
+
+// validateRemote checks the remote names and project patterns of the remote settings
+func validateRemote(remote RemoteConfig) error {
+	if remote.Name != "" && !remoteNamePattern.MatchString(remote.Name) {
+		return &FieldError{Key: "remote.name", Err: eris.Errorf("invalid remote.name: %s", remote.Name)}
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(remote.Projects)) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &FieldError{
+				Key: "remote.projects",
+				Err: eris.Errorf("invalid remote.projects pattern: %s", pattern),
+			}
+		}
+		if name := remote.Projects[pattern]; !remoteNamePattern.MatchString(name) {
+			return &FieldError{
+				Key: "remote.projects." + pattern,
+				Err: eris.Errorf("invalid remote.projects.%s: %s", pattern, name),
+			}
+		}
+	}
+	return nil
+}
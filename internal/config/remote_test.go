@@ -0,0 +1,107 @@
+package config
+
+import "testing"
+
+func TestRemoteConfigFor(t *testing.T) {
+	remote := RemoteConfig{
+		Projects: map[string]string{
+			"github.com/me/*":    "upstream",
+			"github.com/me/sesh": "fork",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		remote  RemoteConfig
+		project string
+		want    string
+	}{
+		{name: "default", remote: RemoteConfig{}, project: "github.com/user/repo", want: "origin"},
+		{name: "configured default", remote: RemoteConfig{Name: "upstream"}, project: "github.com/user/repo", want: "upstream"},
+		{name: "no match", remote: remote, project: "github.com/user/repo", want: "origin"},
+		{name: "pattern", remote: remote, project: "github.com/me/api", want: "upstream"},
+		{name: "longest pattern", remote: remote, project: "github.com/me/sesh", want: "fork"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.remote.For(tt.project); got != tt.want {
+				t.Errorf("For(%q) = %q, want %q", tt.project, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRemoteConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	writeConfigFile(t, "remote:\n  name: upstream\n  projects:\n    github.com/me/*: fork\n")
+
+	t.Setenv("SESH_REMOTE", "")
+	remote, err := GetRemoteConfig()
+	if err != nil {
+		t.Fatalf("GetRemoteConfig() returned error: %v", err)
+	}
+	if got := remote.For("github.com/user/repo"); got != "upstream" {
+		t.Errorf("For(github.com/user/repo) = %q, want upstream", got)
+	}
+
+	// The environment replaces the default, but not the remotes of matching projects
+	t.Setenv("SESH_REMOTE", "mirror")
+	remote, err = GetRemoteConfig()
+	if err != nil {
+		t.Fatalf("GetRemoteConfig() returned error: %v", err)
+	}
+	if got := remote.For("github.com/user/repo"); got != "mirror" {
+		t.Errorf("For(github.com/user/repo) = %q, want mirror", got)
+	}
+	if got := remote.For("github.com/me/repo"); got != "fork" {
+		t.Errorf("For(github.com/me/repo) = %q, want fork", got)
+	}
+
+	t.Setenv("SESH_REMOTE", "my remote")
+	if _, err := GetRemoteConfig(); err == nil {
+		t.Error("GetRemoteConfig() with an invalid SESH_REMOTE returned no error")
+	}
+}
+
+func TestValidateRemote(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  RemoteConfig
+		wantKey string
+	}{
+		{name: "unset", remote: RemoteConfig{}},
+		{name: "valid", remote: RemoteConfig{Name: "upstream", Projects: map[string]string{"github.com/*": "fork"}}},
+		{name: "invalid name", remote: RemoteConfig{Name: "up stream"}, wantKey: "remote.name"},
+		{
+			name:    "project remote",
+			remote:  RemoteConfig{Projects: map[string]string{"github.com/*": ""}},
+			wantKey: "remote.projects.github.com/*",
+		},
+		{
+			name:    "bad pattern",
+			remote:  RemoteConfig{Projects: map[string]string{"github.com/[": "fork"}},
+			wantKey: "remote.projects",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRemote(tt.remote)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Errorf("validateRemote() returned error: %v", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok {
+				t.Fatalf("validateRemote() error = %v, want a *FieldError", err)
+			}
+			if fieldErr.Key != tt.wantKey {
+				t.Errorf("validateRemote() error key = %q, want %q", fieldErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/atomicfile"
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a validation error of a single setting
+type FieldError struct {
+	// Key is the dotted name of the setting, e.g. "theme.info"
+	Key string
+	// Line is the line of the setting in the config file, or 0 when unknown
+	Line int
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+	}
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Keys returns the dotted names of the settings 'sesh config get/set' accept, sorted
+func Keys() []string {
+	keys := make([]string, 0, len(settingKinds()))
+	for key := range settingKinds() {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// settingKinds maps the dotted name of every setting to the kind of its value
+func settingKinds() map[string]reflect.Kind {
+	kinds := map[string]reflect.Kind{}
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			if field.Type.Kind() == reflect.Struct {
+				walk(field.Type, prefix+name+".")
+				continue
+			}
+			kinds[prefix+name] = field.Type.Kind()
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return kinds
+}
+
+// GetValue returns the value of a setting after applying environment variables and defaults
+// Without a key, the whole configuration is returned as YAML.
+func GetValue(key string) (string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return "", eris.Wrap(err, "failed to load configuration")
+	}
+
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return "", eris.Wrap(err, "failed to encode configuration")
+	}
+
+	node := &doc
+	if key != "" {
+		if _, ok := settingKinds()[key]; !ok && !isSection(key) {
+			return "", unknownKeyError(key)
+		}
+		node = lookupNode(&doc, key)
+		if node == nil {
+			// Settings left empty are omitted, like the styles of the theme
+			return "", nil
+		}
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return "", eris.Wrap(err, "failed to encode configuration")
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// SetValue sets a setting in the config file, keeping its other settings and comments
+// The value is checked against the setting's type and validated along with the rest of
+// the file before anything is written.
+func SetValue(key, value string) error {
+	kind, ok := settingKinds()[key]
+	if !ok {
+		return unknownKeyError(key)
+	}
+
+	if kind == reflect.Map {
+		return eris.Errorf("%s can't be set from the command line (use 'sesh config edit')", key)
+	}
+
+	node, err := scalarNode(key, kind, value)
+	if err != nil {
+		return err
+	}
+
+	configPath, err := GetConfigPath()
+	if err != nil {
+		return eris.Wrap(err, "failed to get config path")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return eris.Wrapf(err, "failed to read config file: %s", configPath)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return eris.Wrapf(err, "failed to parse config file: %s", configPath)
+	}
+	if doc.Kind == 0 {
+		// A new or empty file starts with the version, like the ones SaveConfig writes
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+		setNode(doc.Content[0], []string{"version"}, &yaml.Node{
+			Kind: yaml.ScalarNode, Tag: "!!str", Value: CurrentConfigVersion, Style: yaml.DoubleQuotedStyle,
+		})
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return eris.Errorf("failed to parse config file: %s (not a mapping of settings)", configPath)
+	}
+	setNode(doc.Content[0], strings.Split(key, "."), node)
+
+	var config configFile
+	if err := doc.Decode(&config); err != nil {
+		return eris.Wrapf(err, "failed to parse config file: %s", configPath)
+	}
+	if err := ValidateConfig(&config); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return eris.Wrap(err, "failed to marshal config to YAML")
+	}
+
+	if err := EnsureConfigDir(); err != nil {
+		return eris.Wrap(err, "failed to ensure config directory")
+	}
+	if err := atomicfile.WriteFile(configPath, out, 0o644); err != nil {
+		return eris.Wrapf(err, "failed to write config file: %s", configPath)
+	}
+	return nil
+}
+
+// UnknownKeys returns the settings in a config file that sesh doesn't know, with their lines
+// They are ignored, so they are usually typos or settings of a newer version.
+func UnknownKeys(configPath string) ([]*FieldError, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read config file: %s", configPath)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, eris.Wrapf(err, "failed to parse config file: %s", configPath)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	kinds := settingKinds()
+	var unknown []*FieldError
+	var walk func(mapping *yaml.Node, prefix string)
+	walk = func(mapping *yaml.Node, prefix string) {
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			keyNode, valueNode := mapping.Content[i], mapping.Content[i+1]
+			key := prefix + keyNode.Value
+			if _, ok := kinds[key]; ok || key == "version" {
+				continue
+			}
+			if isSection(key) && valueNode.Kind == yaml.MappingNode {
+				walk(valueNode, key+".")
+				continue
+			}
+			unknown = append(unknown, &FieldError{
+				Key:  key,
+				Line: keyNode.Line,
+				Err:  eris.Errorf("unknown setting: %s", key),
+			})
+		}
+	}
+	walk(doc.Content[0], "")
+
+	return unknown, nil
+}
+
+// isSection reports whether key names a group of settings, like "theme"
+func isSection(key string) bool {
+	for name := range settingKinds() {
+		if strings.HasPrefix(name, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownKeyError lists the valid keys for an unknown one
+func unknownKeyError(key string) error {
+	return eris.Errorf("unknown setting: %s (must be one of: %s)", key, strings.Join(Keys(), ", "))
+}
+
+// scalarNode converts a value given on the command line to a node of the setting's type
+func scalarNode(key string, kind reflect.Kind, value string) (*yaml.Node, error) {
+	switch kind {
+	case reflect.Bool:
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, eris.Errorf("invalid %s: %s (must be true or false)", key, value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(enabled)}, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, eris.Errorf("invalid %s: %s (must be an integer)", key, value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(n)}, nil
+	case reflect.Slice:
+		// Lists are separated like PATH, e.g. "~/src:~/work". workspace_dir is also
+		// accepted as a single path, which is how older config files have it.
+		paths := filepath.SplitList(value)
+		if len(paths) == 1 && key == "workspace_dir" {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, path := range paths {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path})
+		}
+		return node, nil
+	default:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		if strings.Contains(value, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+		return node, nil
+	}
+}
+
+// lookupNode finds the value node of a dotted key in a YAML document, or nil
+func lookupNode(doc *yaml.Node, key string) *yaml.Node {
+	node := doc
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+
+	for _, name := range strings.Split(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// setNode sets the value of a key path in a mapping, adding missing mappings along the way
+func setNode(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			// Keep the comments of the replaced value
+			value.LineComment = mapping.Content[i+1].LineComment
+			mapping.Content[i+1] = value
+			return
+		}
+		child := mapping.Content[i+1]
+		if child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			mapping.Content[i+1] = child
+		}
+		setNode(child, path[1:], value)
+		return
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, keyNode, value)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, keyNode, child)
+	setNode(child, path[1:], value)
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes config.yaml in the isolated config directory of a test
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatalf("GetConfigPath() returned error: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	return configPath
+}
+
+func TestSetValue(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	configPath := writeConfigFile(t, "version: \"1\"\n# Where projects live\nworkspace_dir: ~/code # home\n")
+
+	for _, kv := range [][2]string{
+		{"workspace_dir", "~/src"},
+		{"zoxide", "1"},
+		{"trash_retention_days", "3"},
+		{"theme.info", "blue"},
+		{"clone.reference_dirs", "~/src" + string(filepath.ListSeparator) + "~/work"},
+	} {
+		if err := SetValue(kv[0], kv[1]); err != nil {
+			t.Fatalf("SetValue(%q, %q) failed: %v", kv[0], kv[1], err)
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	for _, want := range []string{
+		"# Where projects live\nworkspace_dir: ~/src # home\n",
+		"zoxide: true\n",
+		"trash_retention_days: 3\n",
+		"theme:\n    info: blue\n",
+		"clone:\n    reference_dirs: [~/src, ~/work]\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("config file = %q, want to contain %q", data, want)
+		}
+	}
+
+	if got, err := GetValue("theme.info"); err != nil || got != "blue" {
+		t.Errorf("GetValue(theme.info) = %q, %v; want %q", got, err, "blue")
+	}
+	if got, err := GetValue("theme.error"); err != nil || got != "" {
+		t.Errorf("GetValue(theme.error) = %q, %v; want an empty value", got, err)
+	}
+
+	// Invalid values and keys leave the file alone
+	for _, kv := range [][2]string{
+		{"fuzzy_finder", "skim"},
+		{"discovery_max_depth", "deep"},
+		{"theme.info", "crimson"},
+		{"workspace", "~/src"},
+	} {
+		if err := SetValue(kv[0], kv[1]); err == nil {
+			t.Errorf("SetValue(%q, %q) should fail", kv[0], kv[1])
+		}
+	}
+	if after, _ := os.ReadFile(configPath); string(after) != string(data) {
+		t.Errorf("config file changed after failed updates: %q", after)
+	}
+}
+
+func TestSetValueCreatesFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	if err := SetValue("fuzzy_finder", "fzf"); err != nil {
+		t.Fatalf("SetValue() failed: %v", err)
+	}
+
+	configPath, err := GetConfigPath()
+	if err != nil {
+		t.Fatalf("GetConfigPath() returned error: %v", err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	if want := "version: \"1\"\nfuzzy_finder: fzf\n"; string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+}
+
+func TestValidateConfigFileLines(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	configPath := writeConfigFile(t, "version: \"1\"\nfuzzy_finder: fzf\ntheme:\n  info: blue\n  error: crimson\n")
+
+	err := ValidateConfigFile(configPath)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("ValidateConfigFile() error = %v, want a *FieldError", err)
+	}
+	if fieldErr.Key != "theme.error" || fieldErr.Line != 5 {
+		t.Errorf("FieldError key, line = %q, %d; want %q, %d", fieldErr.Key, fieldErr.Line, "theme.error", 5)
+	}
+	if !strings.HasPrefix(err.Error(), "line 5: invalid theme.error") {
+		t.Errorf("error = %q, want it to start with the line", err.Error())
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	configPath := writeConfigFile(t, "version: \"1\"\nfuzy_finder: fzf\ntheme:\n  info: blue\n  warning: red\n")
+
+	unknown, err := UnknownKeys(configPath)
+	if err != nil {
+		t.Fatalf("UnknownKeys() failed: %v", err)
+	}
+
+	var got []string
+	for _, fieldErr := range unknown {
+		got = append(got, fieldErr.Error())
+	}
+	want := []string{"line 2: unknown setting: fuzy_finder", "line 5: unknown setting: theme.warning"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("UnknownKeys() = %q, want %q", got, want)
+	}
+}
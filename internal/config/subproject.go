@@ -0,0 +1,58 @@
+package config
+
+import (
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// Subproject is a directory of a monorepo with sessions of its own, declared under subprojects
+// in .sesh.yaml, e.g. a service of the repository
+type Subproject struct {
+	// Path is the directory of the sub-project, relative to the root of the worktree
+	Path string `yaml:"path"`
+	// StartupCommand runs in new sessions of the sub-project, instead of the project's
+	StartupCommand string `yaml:"startup_command,omitempty"`
+	// SessionName replaces "<repo>-<name>" at the start of the names of the sub-project's sessions
+	SessionName string `yaml:"session_name,omitempty"`
+}
+
+// subprojectNamePattern matches names of sub-projects and their sessions, which go into the
+// names of tmux sessions, where '.' and ':' aren't allowed
+var subprojectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Subproject returns the sub-project with the given name
+func (c *ProjectConfig) Subproject(name string) (*Subproject, error) {
+	sub, ok := c.Subprojects[name]
+	if !ok {
+		if len(c.Subprojects) == 0 {
+			return nil, eris.Errorf("unknown sub-project: %s (.sesh.yaml declares no subprojects)", name)
+		}
+		names := slices.Sorted(maps.Keys(c.Subprojects))
+		return nil, eris.Errorf("unknown sub-project: %s (must be one of: %s)", name, strings.Join(names, ", "))
+	}
+	return &sub, nil
+}
+
+// validateSubprojects checks the names and directories of the sub-projects of a project config
+func validateSubprojects(subprojects map[string]Subproject) error {
+	for _, name := range slices.Sorted(maps.Keys(subprojects)) {
+		sub := subprojects[name]
+		if !subprojectNamePattern.MatchString(name) {
+			return eris.Errorf("invalid sub-project name: %q (must be letters, digits, '-', and '_')", name)
+		}
+		if sub.Path == "" || !filepath.IsLocal(sub.Path) {
+			return eris.Errorf("invalid path of sub-project %s: %q (must be a directory inside the repository)", name, sub.Path)
+		}
+		if sub.SessionName != "" && !subprojectNamePattern.MatchString(sub.SessionName) {
+			return eris.Errorf(
+				"invalid session_name of sub-project %s: %q (must be letters, digits, '-', and '_')", name, sub.SessionName,
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+
+	"github.com/rotisserie/eris"
+)
+
+// ProjectTags holds the tags of the projects matching a pattern like "github.com/acme/*", e.g.
+// "backend", to list the projects of a large workspace by topic
+type ProjectTags map[string][]string
+
+// tagPattern matches the tags sesh accepts, which are single words
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// GetProjectTags returns the tags of projects in the config file, by project pattern
+func GetProjectTags() (ProjectTags, error) {
+	// Without a config file, no project has tags
+	config, err := loadConfigFile()
+	if err != nil {
+		return nil, nil
+	}
+	if err := validateProjectTags(config.ProjectTags); err != nil {
+		return nil, err
+	}
+	return config.ProjectTags, nil
+}
+
+// For returns the tags of a project: those of every pattern it matches, sorted
+func (t ProjectTags) For(projectName string) []string {
+	var tags []string
+	for pattern, patternTags := range t {
+		if matched, _ := path.Match(pattern, projectName); matched {
+			tags = append(tags, patternTags...)
+		}
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// ValidateTag checks that a tag is a single word
+func ValidateTag(tag string) error {
+	if !tagPattern.MatchString(tag) {
+		return eris.Errorf("invalid tag: %q (must be letters, digits, '.', '-', and '_')", tag)
+	}
+	return nil
+}
+
+// validateProjectTags checks the project patterns and tags of the project_tags setting
+func validateProjectTags(tags ProjectTags) error {
+	for _, pattern := range slices.Sorted(maps.Keys(tags)) {
+		key := "project_tags." + pattern
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &FieldError{Key: key, Err: eris.Errorf("invalid project_tags pattern: %s", pattern)}
+		}
+		for _, tag := range tags[pattern] {
+			if err := ValidateTag(tag); err != nil {
+				return &FieldError{Key: key, Err: err}
+			}
+		}
+	}
+	return nil
+}
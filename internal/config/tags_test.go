@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestProjectTagsFor(t *testing.T) {
+	tags := ProjectTags{
+		"github.com/acme/*":   {"work", "backend"},
+		"github.com/acme/web": {"frontend", "work"},
+	}
+
+	tests := []struct {
+		project string
+		want    []string
+	}{
+		{project: "github.com/me/dotfiles", want: nil},
+		{project: "github.com/acme/api", want: []string{"backend", "work"}},
+		{project: "github.com/acme/web", want: []string{"backend", "frontend", "work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			if got := tags.For(tt.project); !slices.Equal(got, tt.want) {
+				t.Errorf("For(%q) = %v, want %v", tt.project, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateProjectTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    ProjectTags
+		wantErr bool
+	}{
+		{name: "valid", tags: ProjectTags{"github.com/acme/*": {"backend", "go1.22"}}},
+		{name: "invalid pattern", tags: ProjectTags{"github.com/[acme": {"backend"}}, wantErr: true},
+		{name: "tag with space", tags: ProjectTags{"github.com/acme/*": {"back end"}}, wantErr: true},
+		{name: "empty tag", tags: ProjectTags{"github.com/acme/*": {""}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProjectTags(tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateProjectTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var fieldErr *FieldError
+			if err != nil && !errors.As(err, &fieldErr) {
+				t.Errorf("validateProjectTags() error = %v, want a *FieldError", err)
+			}
+		})
+	}
+}
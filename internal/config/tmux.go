@@ -0,0 +1,127 @@
+package config
+
+import (
+	"regexp"
+
+	"github.com/rotisserie/eris"
+)
+
+// Default keys and popup size of the keybindings 'sesh tmux install' adds
+const (
+	DefaultTmuxSwitcherKey = "f"
+	DefaultTmuxPRKey       = "F"
+	DefaultTmuxLastKey     = "L"
+	DefaultTmuxPopupWidth  = "80%"
+	DefaultTmuxPopupHeight = "60%"
+)
+
+// TmuxConfig holds the keys and popup size of the tmux keybindings, whose empty values keep
+// the defaults, and how sesh names tmux windows
+type TmuxConfig struct {
+	// Key of the branch switcher popup, e.g. "f" or "M-s"
+	SwitcherKey string `yaml:"switcher_key,omitempty"`
+	// Key of the pull request switcher popup
+	PRKey string `yaml:"pr_key,omitempty"`
+	// Key switching to the previous session
+	LastKey string `yaml:"last_key,omitempty"`
+	// Size of the popups, in columns and lines or as a percentage of the terminal, e.g. "80%"
+	PopupWidth  string `yaml:"popup_width,omitempty"`
+	PopupHeight string `yaml:"popup_height,omitempty"`
+	// WindowTitles names the window and pane of sessions "<repo>:<branch>" on every switch
+	WindowTitles bool `yaml:"window_titles,omitempty"`
+}
+
+var (
+	// tmuxKeyPattern matches tmux key names like "f", "M-s", "C-Space", or "F5", leaving out
+	// characters that would break the generated configuration
+	tmuxKeyPattern = regexp.MustCompile(`^[^\s"'\\#;]+$`)
+
+	// tmuxPopupSizePattern matches popup sizes tmux accepts, in cells or as a percentage
+	tmuxPopupSizePattern = regexp.MustCompile(`^[1-9][0-9]*%?$`)
+)
+
+// GetTmuxConfig returns the tmux settings from the config file
+func GetTmuxConfig() (TmuxConfig, error) {
+	// 1. Config file
+	config, err := loadConfigFile()
+	if err == nil {
+		if err := validateTmux(config.Tmux); err != nil {
+			return TmuxConfig{}, err
+		}
+		return config.Tmux, nil
+	}
+
+	// 2. Default (lowest priority)
+	return TmuxConfig{}, nil
+}
+
+// WithDefaults returns the settings with the defaults in place of the empty ones
+func (c TmuxConfig) WithDefaults() TmuxConfig {
+	if c.SwitcherKey == "" {
+		c.SwitcherKey = DefaultTmuxSwitcherKey
+	}
+	if c.PRKey == "" {
+		c.PRKey = DefaultTmuxPRKey
+	}
+	if c.LastKey == "" {
+		c.LastKey = DefaultTmuxLastKey
+	}
+	if c.PopupWidth == "" {
+		c.PopupWidth = DefaultTmuxPopupWidth
+	}
+	if c.PopupHeight == "" {
+		c.PopupHeight = DefaultTmuxPopupHeight
+	}
+	return c
+}
+
+// ValidateTmuxKey checks that a key can be bound in the generated configuration
+func ValidateTmuxKey(key string) error {
+	if !tmuxKeyPattern.MatchString(key) {
+		return eris.Errorf("invalid key: %q (must be a tmux key name like f, M-s, or C-Space)", key)
+	}
+	return nil
+}
+
+// ValidateTmuxPopupSize checks a popup width or height, like 120 or 80%
+func ValidateTmuxPopupSize(size string) error {
+	if !tmuxPopupSizePattern.MatchString(size) {
+		return eris.Errorf("invalid popup size: %q (must be a number of cells like 120, or a percentage like 80%%)", size)
+	}
+	return nil
+}
+
+// validateTmux checks the keys and popup size of the tmux keybindings, which must bind
+// different keys
+func validateTmux(tmux TmuxConfig) error {
+	for _, key := range []struct{ field, value string }{
+		{"tmux.switcher_key", tmux.SwitcherKey},
+		{"tmux.pr_key", tmux.PRKey},
+		{"tmux.last_key", tmux.LastKey},
+	} {
+		if key.value == "" {
+			continue
+		}
+		if err := ValidateTmuxKey(key.value); err != nil {
+			return &FieldError{Key: key.field, Err: eris.Wrapf(err, "invalid %s", key.field)}
+		}
+	}
+
+	for _, size := range []struct{ field, value string }{
+		{"tmux.popup_width", tmux.PopupWidth},
+		{"tmux.popup_height", tmux.PopupHeight},
+	} {
+		if size.value == "" {
+			continue
+		}
+		if err := ValidateTmuxPopupSize(size.value); err != nil {
+			return &FieldError{Key: size.field, Err: eris.Wrapf(err, "invalid %s", size.field)}
+		}
+	}
+
+	keys := tmux.WithDefaults()
+	if keys.SwitcherKey == keys.PRKey || keys.SwitcherKey == keys.LastKey || keys.PRKey == keys.LastKey {
+		return &FieldError{Key: "tmux", Err: eris.New("the tmux keybindings must use different keys")}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import "testing"
+
+func TestValidateTmux(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmux    TmuxConfig
+		wantKey string
+	}{
+		{name: "unset", tmux: TmuxConfig{}},
+		{name: "valid", tmux: TmuxConfig{SwitcherKey: "M-s", LastKey: "C-Space", PopupWidth: "120", PopupHeight: "50%"}},
+		{name: "key with space", tmux: TmuxConfig{PRKey: "C- p"}, wantKey: "tmux.pr_key"},
+		{name: "key with quote", tmux: TmuxConfig{LastKey: `"`}, wantKey: "tmux.last_key"},
+		{name: "percentage without number", tmux: TmuxConfig{PopupWidth: "%"}, wantKey: "tmux.popup_width"},
+		{name: "zero height", tmux: TmuxConfig{PopupHeight: "0"}, wantKey: "tmux.popup_height"},
+		{name: "same key as a default", tmux: TmuxConfig{SwitcherKey: "L"}, wantKey: "tmux"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTmux(tt.tmux)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Errorf("validateTmux() returned error: %v", err)
+				}
+				return
+			}
+			fieldErr, ok := err.(*FieldError)
+			if !ok {
+				t.Fatalf("validateTmux() error = %v, want a *FieldError", err)
+			}
+			if fieldErr.Key != tt.wantKey {
+				t.Errorf("validateTmux() error key = %q, want %q", fieldErr.Key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestTmuxConfigWithDefaults(t *testing.T) {
+	got := TmuxConfig{SwitcherKey: "s", PopupHeight: "40"}.WithDefaults()
+	want := TmuxConfig{SwitcherKey: "s", PRKey: "F", LastKey: "L", PopupWidth: "80%", PopupHeight: "40"}
+	if got != want {
+		t.Errorf("WithDefaults() = %+v, want %+v", got, want)
+	}
+}
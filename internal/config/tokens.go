@@ -0,0 +1,38 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/secrets"
+	"github.com/rotisserie/eris"
+)
+
+// GetProviderTokens returns the names of the keyring secrets holding the API tokens of git
+// hosts, e.g. "gitlab-work" for "gitlab.example.com", by host
+func GetProviderTokens() (map[string]string, error) {
+	// Without a config file, tokens are left to the CLIs of the providers
+	config, err := loadConfigFile()
+	if err != nil {
+		return nil, nil
+	}
+	if err := validateProviderTokens(config.ProviderTokens); err != nil {
+		return nil, err
+	}
+	return config.ProviderTokens, nil
+}
+
+// validateProviderTokens checks that tokens are given for host names, by the name of a secret
+func validateProviderTokens(tokens map[string]string) error {
+	for _, host := range slices.Sorted(maps.Keys(tokens)) {
+		key := "provider_tokens." + host
+		if host == "" || strings.ContainsAny(host, "/: ") {
+			return &FieldError{Key: key, Err: eris.Errorf("invalid host: %q (must be a host name, like github.com)", host)}
+		}
+		if err := secrets.ValidateName(tokens[host]); err != nil {
+			return &FieldError{Key: key, Err: err}
+		}
+	}
+	return nil
+}
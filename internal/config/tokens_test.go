@@ -0,0 +1,33 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateProviderTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  map[string]string
+		wantErr bool
+	}{
+		{name: "valid", tokens: map[string]string{"github.com": "github-work", "gitlab.example.com": "gitlab"}},
+		{name: "url instead of host", tokens: map[string]string{"https://github.com": "github"}, wantErr: true},
+		{name: "empty host", tokens: map[string]string{"": "github"}, wantErr: true},
+		{name: "no secret name", tokens: map[string]string{"github.com": ""}, wantErr: true},
+		{name: "secret name with space", tokens: map[string]string{"github.com": "my token"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProviderTokens(tt.tokens)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateProviderTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var fieldErr *FieldError
+			if err != nil && !errors.As(err, &fieldErr) {
+				t.Errorf("validateProviderTokens() error = %v, want a *FieldError", err)
+			}
+		})
+	}
+}
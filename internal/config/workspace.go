@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// Workspace is a directory projects are cloned into
+type Workspace struct {
+	// Name selects the workspace with 'sesh clone --workspace'; it defaults to the directory's name
+	Name string `yaml:"name,omitempty"`
+	Path string `yaml:"path"`
+}
+
+// WorkspaceDirs is the workspace_dir setting: a single directory, or a list of directories
+// and named workspaces, e.g. to keep open source and work projects on different disks.
+// The first one is the primary workspace, which new projects are cloned into by default.
+type WorkspaceDirs []Workspace
+
+// UnmarshalYAML accepts a path, or a list of paths and name/path mappings
+func (w *WorkspaceDirs) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Value == "" {
+			*w = nil
+			return nil
+		}
+		*w = WorkspaceDirs{{Path: node.Value}}
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		return eris.Errorf("line %d: workspace_dir must be a path or a list of workspaces", node.Line)
+	}
+
+	dirs := make(WorkspaceDirs, 0, len(node.Content))
+	for _, item := range node.Content {
+		var ws Workspace
+		switch item.Kind {
+		case yaml.ScalarNode:
+			ws.Path = item.Value
+		case yaml.MappingNode:
+			if err := item.Decode(&ws); err != nil {
+				return err
+			}
+		default:
+			return eris.Errorf("line %d: a workspace must be a path or have a name and a path", item.Line)
+		}
+		dirs = append(dirs, ws)
+	}
+	*w = dirs
+	return nil
+}
+
+// MarshalYAML writes a single unnamed workspace as a plain path, like older config files
+func (w WorkspaceDirs) MarshalYAML() (interface{}, error) {
+	if len(w) == 1 && w[0].Name == "" {
+		return w[0].Path, nil
+	}
+
+	items := make([]interface{}, 0, len(w))
+	for _, ws := range w {
+		if ws.Name == "" {
+			items = append(items, ws.Path)
+		} else {
+			items = append(items, ws)
+		}
+	}
+	return items, nil
+}
+
+// GetWorkspaces returns all workspace directories with configuration hierarchy, primary first
+// SESH_WORKSPACE may list several directories, separated like PATH.
+func GetWorkspaces() ([]Workspace, error) {
+	// 1. Environment variable (highest priority)
+	if envDir := os.Getenv("SESH_WORKSPACE"); envDir != "" {
+		var dirs WorkspaceDirs
+		for _, path := range filepath.SplitList(envDir) {
+			if path != "" {
+				dirs = append(dirs, Workspace{Path: path})
+			}
+		}
+		return resolveWorkspaces(dirs)
+	}
+
+	// 2. Config file
+	config, err := loadConfigFile()
+	if err == nil && len(config.WorkspaceDir) > 0 {
+		return resolveWorkspaces(config.WorkspaceDir)
+	}
+
+	// 3. Default (lowest priority)
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get user home directory")
+	}
+
+	return resolveWorkspaces(WorkspaceDirs{{Path: filepath.Join(home, ".sesh")}})
+}
+
+// GetWorkspace returns the workspace with the given name
+func GetWorkspace(name string) (Workspace, error) {
+	workspaces, err := GetWorkspaces()
+	if err != nil {
+		return Workspace{}, err
+	}
+
+	names := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		if ws.Name == name {
+			return ws, nil
+		}
+		names = append(names, ws.Name)
+	}
+	return Workspace{}, eris.Errorf("unknown workspace: %s (must be one of: %s)", name, strings.Join(names, ", "))
+}
+
+// WorkspacePaths returns the directories of workspaces
+func WorkspacePaths(workspaces []Workspace) []string {
+	paths := make([]string, 0, len(workspaces))
+	for _, ws := range workspaces {
+		paths = append(paths, ws.Path)
+	}
+	return paths
+}
+
+// resolveWorkspaces expands the paths of workspaces and fills in their default names
+func resolveWorkspaces(dirs WorkspaceDirs) ([]Workspace, error) {
+	workspaces := make([]Workspace, 0, len(dirs))
+	for _, ws := range dirs {
+		path, err := expandHome(ws.Path)
+		if err != nil {
+			return nil, err
+		}
+		name := ws.Name
+		if name == "" {
+			name = filepath.Base(path)
+		}
+		workspaces = append(workspaces, Workspace{Name: name, Path: path})
+	}
+	return workspaces, nil
+}
+
+// validateWorkspaces checks that every workspace has an expandable path and a unique name
+func validateWorkspaces(dirs WorkspaceDirs) error {
+	for _, ws := range dirs {
+		if ws.Path == "" {
+			return &FieldError{Key: "workspace_dir", Err: eris.Errorf("invalid workspace_dir: workspace %s has no path", ws.Name)}
+		}
+	}
+
+	workspaces, err := resolveWorkspaces(dirs)
+	if err != nil {
+		return &FieldError{Key: "workspace_dir", Err: eris.Wrap(err, "invalid workspace_dir")}
+	}
+	seen := map[string]bool{}
+	for _, ws := range workspaces {
+		if seen[ws.Name] {
+			return &FieldError{Key: "workspace_dir", Err: eris.Errorf(
+				"invalid workspace_dir: more than one workspace is named %s (set a name for each)", ws.Name,
+			)}
+		}
+		seen[ws.Name] = true
+	}
+	return nil
+}
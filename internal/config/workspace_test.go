@@ -0,0 +1,139 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGetWorkspaces(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		want    []Workspace
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: []Workspace{{Name: ".sesh", Path: filepath.Join(home, ".sesh")}},
+		},
+		{
+			name:   "single directory",
+			config: "workspace_dir: ~/code\n",
+			want:   []Workspace{{Name: "code", Path: filepath.Join(home, "code")}},
+		},
+		{
+			name:   "list of directories and named workspaces",
+			config: "workspace_dir:\n  - ~/code\n  - name: work\n    path: /mnt/disk/code\n",
+			want: []Workspace{
+				{Name: "code", Path: filepath.Join(home, "code")},
+				{Name: "work", Path: "/mnt/disk/code"},
+			},
+		},
+		{
+			name:   "environment list overrides config file",
+			config: "workspace_dir: ~/code\n",
+			env:    "~/oss" + string(filepath.ListSeparator) + "/mnt/work",
+			want: []Workspace{
+				{Name: "oss", Path: filepath.Join(home, "oss")},
+				{Name: "work", Path: "/mnt/work"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfigFile(t, tt.config)
+			t.Setenv("SESH_WORKSPACE", tt.env)
+
+			got, err := GetWorkspaces()
+			if err != nil {
+				t.Fatalf("GetWorkspaces() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetWorkspaces() = %v, want %v", got, tt.want)
+			}
+
+			// The primary workspace is the first one
+			dir, err := GetWorkspaceDir()
+			if err != nil {
+				t.Fatalf("GetWorkspaceDir() returned error: %v", err)
+			}
+			if dir != tt.want[0].Path {
+				t.Errorf("GetWorkspaceDir() = %q, want %q", dir, tt.want[0].Path)
+			}
+		})
+	}
+}
+
+func TestGetWorkspace(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SESH_WORKSPACE", "")
+	writeConfigFile(t, "workspace_dir:\n  - ~/oss\n  - name: work\n    path: ~/disk/code\n")
+
+	ws, err := GetWorkspace("work")
+	if err != nil {
+		t.Fatalf("GetWorkspace() returned error: %v", err)
+	}
+	if ws.Path != filepath.Join(home, "disk", "code") {
+		t.Errorf("GetWorkspace(work).Path = %q, want %q", ws.Path, filepath.Join(home, "disk", "code"))
+	}
+
+	if _, err := GetWorkspace("personal"); err == nil {
+		t.Error("GetWorkspace() of an unknown workspace returned no error")
+	}
+}
+
+func TestValidateWorkspaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		dirs    WorkspaceDirs
+		wantErr bool
+	}{
+		{name: "unset", dirs: nil},
+		{name: "distinct names", dirs: WorkspaceDirs{{Path: "~/code"}, {Path: "/mnt/code", Name: "work"}}},
+		{name: "same default names", dirs: WorkspaceDirs{{Path: "~/code"}, {Path: "/mnt/code"}}, wantErr: true},
+		{name: "no path", dirs: WorkspaceDirs{{Name: "work"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkspaces(tt.dirs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWorkspaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkspaceDirsRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SESH_WORKSPACE", "")
+
+	for _, value := range []string{"~/code", "[~/oss, /mnt/work]"} {
+		writeConfigFile(t, "workspace_dir: "+value+"\n")
+
+		config, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig() returned error: %v", err)
+		}
+		if err := SaveConfig(config); err != nil {
+			t.Fatalf("SaveConfig() returned error: %v", err)
+		}
+		saved, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig() returned error: %v", err)
+		}
+		if !reflect.DeepEqual(saved.Workspaces, config.Workspaces) {
+			t.Errorf("workspaces after saving %q = %v, want %v", value, saved.Workspaces, config.Workspaces)
+		}
+	}
+}
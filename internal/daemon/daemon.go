@@ -0,0 +1,117 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/atomicfile"
+	"github.com/benoctopus/sesh/internal/lock"
+	"github.com/rotisserie/eris"
+)
+
+// DefaultInterval is how often the daemon refreshes the workspace by default
+const DefaultInterval = 5 * time.Minute
+
+// Status is what the daemon publishes about itself for 'sesh daemon status'
+type Status struct {
+	PID          int             `json:"pid"`
+	StartedAt    time.Time       `json:"started_at"`
+	Interval     time.Duration   `json:"interval"`
+	LastRun      time.Time       `json:"last_run"`
+	LastDuration time.Duration   `json:"last_duration"`
+	NextRun      time.Time       `json:"next_run"`
+	Projects     []ProjectStatus `json:"projects"`
+	// Sessions killed because their worktree no longer exists, over the daemon's lifetime
+	PrunedSessions int `json:"pruned_sessions"`
+	// Sessions killed because no client was attached for session_idle_ttl, over the daemon's lifetime
+	IdleSessions int `json:"idle_sessions"`
+}
+
+// ProjectStatus is the outcome of the daemon's last refresh of one project
+type ProjectStatus struct {
+	Name        string    `json:"name"`
+	LastFetched time.Time `json:"last_fetched"`
+	FetchError  string    `json:"fetch_error,omitempty"`
+	// Number of open pull requests cached, or -1 when the provider is unsupported or unavailable
+	OpenPRs int `json:"open_prs"`
+}
+
+// LastFetched returns when the daemon last fetched a project successfully
+func (s *Status) LastFetched(projectName string) (time.Time, bool) {
+	for _, proj := range s.Projects {
+		if proj.Name == projectName && !proj.LastFetched.IsZero() {
+			return proj.LastFetched, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// GetStatusPath returns the path of the status file the daemon writes after every refresh
+func GetStatusPath(cacheDir string) string {
+	return filepath.Join(cacheDir, "daemon.json")
+}
+
+// GetLockPath returns the path of the lock the running daemon holds
+func GetLockPath(cacheDir string) string {
+	return filepath.Join(cacheDir, "daemon.lock")
+}
+
+// Lock takes the daemon lock, returning lock.ErrLocked if a daemon is already running
+func Lock(cacheDir string) (*lock.FileLock, error) {
+	return lock.TryAcquire(GetLockPath(cacheDir))
+}
+
+// IsRunning reports whether a daemon currently holds the daemon lock
+func IsRunning(cacheDir string) bool {
+	l, err := Lock(cacheDir)
+	if err != nil {
+		return errors.Is(err, lock.ErrLocked)
+	}
+	//nolint:errcheck // Only probing; the lock is released by the OS on exit anyway
+	l.Release()
+	return false
+}
+
+// ReadStatus reads the status last written by the daemon
+// Returns nil without an error if the daemon has never run
+func ReadStatus(cacheDir string) (*Status, error) {
+	path := GetStatusPath(cacheDir)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to read daemon status: %s", path)
+	}
+
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, eris.Wrapf(err, "failed to parse daemon status: %s", path)
+	}
+
+	return &status, nil
+}
+
+// WriteStatus replaces the daemon status file
+func WriteStatus(cacheDir string, status *Status) error {
+	path := GetStatusPath(cacheDir)
+
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return eris.Wrap(err, "failed to encode daemon status")
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create cache directory: %s", cacheDir)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0o644); err != nil {
+		return eris.Wrap(err, "failed to write daemon status")
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/lock"
+)
+
+func TestStatusRoundTrip(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	status, err := ReadStatus(cacheDir)
+	if err != nil {
+		t.Fatalf("ReadStatus() failed: %v", err)
+	}
+	if status != nil {
+		t.Fatalf("ReadStatus() = %+v before the daemon ever ran, want nil", status)
+	}
+
+	fetched := time.Now().Truncate(time.Second)
+	want := &Status{
+		PID:      1234,
+		Interval: DefaultInterval,
+		LastRun:  fetched,
+		Projects: []ProjectStatus{
+			{Name: "github.com/user/repo", LastFetched: fetched, OpenPRs: 3},
+			{Name: "github.com/user/broken", FetchError: "network unreachable", OpenPRs: -1},
+		},
+		PrunedSessions: 2,
+	}
+	if err := WriteStatus(cacheDir, want); err != nil {
+		t.Fatalf("WriteStatus() failed: %v", err)
+	}
+
+	got, err := ReadStatus(cacheDir)
+	if err != nil {
+		t.Fatalf("ReadStatus() failed: %v", err)
+	}
+	if got.PID != want.PID || got.Interval != want.Interval || got.PrunedSessions != want.PrunedSessions {
+		t.Errorf("ReadStatus() = %+v, want %+v", got, want)
+	}
+	if len(got.Projects) != len(want.Projects) {
+		t.Fatalf("ReadStatus() has %d projects, want %d", len(got.Projects), len(want.Projects))
+	}
+
+	tests := []struct {
+		project string
+		wantOK  bool
+	}{
+		{project: "github.com/user/repo", wantOK: true},
+		{project: "github.com/user/broken", wantOK: false},
+		{project: "github.com/user/unknown", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			at, ok := got.LastFetched(tt.project)
+			if ok != tt.wantOK {
+				t.Fatalf("LastFetched() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !at.Equal(fetched) {
+				t.Errorf("LastFetched() = %v, want %v", at, fetched)
+			}
+		})
+	}
+}
+
+func TestIsRunning(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	if IsRunning(cacheDir) {
+		t.Fatal("IsRunning() = true with no daemon")
+	}
+
+	l, err := Lock(cacheDir)
+	if err != nil {
+		t.Fatalf("Lock() failed: %v", err)
+	}
+	if !IsRunning(cacheDir) {
+		t.Error("IsRunning() = false while the daemon lock is held")
+	}
+	if _, err := Lock(cacheDir); !errors.Is(err, lock.ErrLocked) {
+		t.Errorf("second Lock() error = %v, want ErrLocked", err)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+	if IsRunning(cacheDir) {
+		t.Error("IsRunning() = true after the daemon lock was released")
+	}
+}
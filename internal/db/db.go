@@ -2,6 +2,8 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
+	"net/url"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -10,32 +12,59 @@ import (
 	"github.com/rotisserie/eris"
 )
 
-// InitDB initializes a new database connection and runs migrations
+// BusyTimeout is how long a connection waits for a lock held by another sesh process
+// (e.g. shell completions running while switching) before failing with SQLITE_BUSY
+const BusyTimeout = 5 * time.Second
+
+// connectionParams configure each new connection with these pragmas:
+//   - foreign_keys enforces ON DELETE CASCADE between projects, worktrees, and sessions
+//   - journal_mode=WAL lets readers proceed while another process writes
+//   - busy_timeout retries instead of failing immediately when the database is locked
+//   - synchronous=NORMAL is durable enough in WAL mode and avoids an fsync per transaction
+//
+// Transactions use BEGIN IMMEDIATE so a writer waits for the lock up front (honoring
+// busy_timeout) instead of failing when upgrading a read lock mid-transaction.
+var connectionParams = url.Values{
+	"_txlock": []string{"immediate"},
+	"_pragma": []string{
+		"foreign_keys(1)",
+		"journal_mode(WAL)",
+		fmt.Sprintf("busy_timeout(%d)", BusyTimeout.Milliseconds()),
+		"synchronous(NORMAL)",
+	},
+}
+
+// InitDB initializes a new database connection and applies any pending migrations
 func InitDB(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := Connect(dbPath)
 	if err != nil {
-		return nil, eris.Wrapf(err, "failed to open database: %s", dbPath)
+		return nil, err
 	}
 
-	// Enable foreign key constraints
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+	// Run migrations
+	if err := RunMigrations(db); err != nil {
 		//nolint:errcheck // Close in error path
 		db.Close()
-		return nil, eris.Wrap(err, "failed to enable foreign keys")
+		return nil, eris.Wrap(err, "failed to run migrations")
 	}
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
-		//nolint:errcheck // Close in error path
-		db.Close()
-		return nil, eris.Wrap(err, "failed to ping database")
+	return db, nil
+}
+
+// Connect opens a database connection without running migrations
+// This is used by the db commands, which manage migrations explicitly
+func Connect(dbPath string) (*sql.DB, error) {
+	// Pragmas are passed in the DSN so they apply to every pooled connection, not just the first
+	db, err := sql.Open("sqlite", dbPath+"?"+connectionParams.Encode())
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open database: %s", dbPath)
 	}
 
-	// Run migrations
-	if err := RunMigrations(db); err != nil {
+	// Test the connection (this also applies the pragmas)
+	if err := db.Ping(); err != nil {
 		//nolint:errcheck // Close in error path
 		db.Close()
-		return nil, eris.Wrap(err, "failed to run migrations")
+		return nil, eris.Wrap(err, "failed to ping database")
 	}
 
 	return db, nil
@@ -44,8 +73,8 @@ func InitDB(dbPath string) (*sql.DB, error) {
 // ==================== Project CRUD Operations ====================
 
 // CreateProject creates a new project in the database
-func CreateProject(db *sql.DB, project *models.Project) error {
-	result, err := db.Exec(
+func (s *SQLiteStore) CreateProject(project *models.Project) error {
+	result, err := s.db.Exec(
 		"INSERT INTO projects (name, remote_url, local_path, created_at) VALUES (?, ?, ?, ?)",
 		project.Name, project.RemoteURL, project.LocalPath, time.Now(),
 	)
@@ -63,11 +92,11 @@ func CreateProject(db *sql.DB, project *models.Project) error {
 }
 
 // GetProject retrieves a project by name
-func GetProject(db *sql.DB, name string) (*models.Project, error) {
+func (s *SQLiteStore) GetProject(name string) (*models.Project, error) {
 	project := &models.Project{}
 	var lastFetched sql.NullTime
 
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, name, remote_url, local_path, created_at, last_fetched FROM projects WHERE name = ?",
 		name,
 	).Scan(&project.ID, &project.Name, &project.RemoteURL, &project.LocalPath, &project.CreatedAt, &lastFetched)
@@ -87,11 +116,11 @@ func GetProject(db *sql.DB, name string) (*models.Project, error) {
 }
 
 // GetProjectByID retrieves a project by ID
-func GetProjectByID(db *sql.DB, id int) (*models.Project, error) {
+func (s *SQLiteStore) GetProjectByID(id int) (*models.Project, error) {
 	project := &models.Project{}
 	var lastFetched sql.NullTime
 
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, name, remote_url, local_path, created_at, last_fetched FROM projects WHERE id = ?",
 		id,
 	).Scan(&project.ID, &project.Name, &project.RemoteURL, &project.LocalPath, &project.CreatedAt, &lastFetched)
@@ -111,11 +140,11 @@ func GetProjectByID(db *sql.DB, id int) (*models.Project, error) {
 }
 
 // GetProjectByRemote retrieves a project by remote URL
-func GetProjectByRemote(db *sql.DB, remoteURL string) (*models.Project, error) {
+func (s *SQLiteStore) GetProjectByRemote(remoteURL string) (*models.Project, error) {
 	project := &models.Project{}
 	var lastFetched sql.NullTime
 
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, name, remote_url, local_path, created_at, last_fetched FROM projects WHERE remote_url = ?",
 		remoteURL,
 	).Scan(&project.ID, &project.Name, &project.RemoteURL, &project.LocalPath, &project.CreatedAt, &lastFetched)
@@ -135,8 +164,8 @@ func GetProjectByRemote(db *sql.DB, remoteURL string) (*models.Project, error) {
 }
 
 // GetAllProjects retrieves all projects
-func GetAllProjects(db *sql.DB) ([]*models.Project, error) {
-	rows, err := db.Query(
+func (s *SQLiteStore) GetAllProjects() ([]*models.Project, error) {
+	rows, err := s.db.Query(
 		"SELECT id, name, remote_url, local_path, created_at, last_fetched FROM projects ORDER BY created_at DESC",
 	)
 	if err != nil {
@@ -177,8 +206,8 @@ func GetAllProjects(db *sql.DB) ([]*models.Project, error) {
 }
 
 // UpdateProjectFetchTime updates the last_fetched timestamp for a project
-func UpdateProjectFetchTime(db *sql.DB, id int) error {
-	_, err := db.Exec(
+func (s *SQLiteStore) UpdateProjectFetchTime(id int) error {
+	_, err := s.db.Exec(
 		"UPDATE projects SET last_fetched = ? WHERE id = ?",
 		time.Now(), id,
 	)
@@ -189,9 +218,9 @@ func UpdateProjectFetchTime(db *sql.DB, id int) error {
 }
 
 // DeleteProject deletes a project and all associated worktrees and sessions
-func DeleteProject(db *sql.DB, id int) error {
+func (s *SQLiteStore) DeleteProject(id int) error {
 	// Foreign key constraints will cascade delete worktrees and sessions
-	result, err := db.Exec("DELETE FROM projects WHERE id = ?", id)
+	result, err := s.db.Exec("DELETE FROM projects WHERE id = ?", id)
 	if err != nil {
 		return eris.Wrapf(err, "failed to delete project with id: %d", id)
 	}
@@ -211,9 +240,9 @@ func DeleteProject(db *sql.DB, id int) error {
 // ==================== Worktree CRUD Operations ====================
 
 // CreateWorktree creates a new worktree in the database
-func CreateWorktree(db *sql.DB, worktree *models.Worktree) error {
+func (s *SQLiteStore) CreateWorktree(worktree *models.Worktree) error {
 	now := time.Now()
-	result, err := db.Exec(
+	result, err := s.db.Exec(
 		"INSERT INTO worktrees (project_id, branch, path, is_main, created_at, last_used) VALUES (?, ?, ?, ?, ?, ?)",
 		worktree.ProjectID, worktree.Branch, worktree.Path, worktree.IsMain, now, now,
 	)
@@ -233,9 +262,9 @@ func CreateWorktree(db *sql.DB, worktree *models.Worktree) error {
 }
 
 // GetWorktree retrieves a worktree by project ID and branch
-func GetWorktree(db *sql.DB, projectID int, branch string) (*models.Worktree, error) {
+func (s *SQLiteStore) GetWorktree(projectID int, branch string) (*models.Worktree, error) {
 	worktree := &models.Worktree{}
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, project_id, branch, path, is_main, created_at, last_used FROM worktrees WHERE project_id = ? AND branch = ?",
 		projectID,
 		branch,
@@ -252,9 +281,9 @@ func GetWorktree(db *sql.DB, projectID int, branch string) (*models.Worktree, er
 }
 
 // GetWorktreeByID retrieves a worktree by ID
-func GetWorktreeByID(db *sql.DB, id int) (*models.Worktree, error) {
+func (s *SQLiteStore) GetWorktreeByID(id int) (*models.Worktree, error) {
 	worktree := &models.Worktree{}
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, project_id, branch, path, is_main, created_at, last_used FROM worktrees WHERE id = ?",
 		id,
 	).Scan(&worktree.ID, &worktree.ProjectID, &worktree.Branch, &worktree.Path, &worktree.IsMain, &worktree.CreatedAt, &worktree.LastUsed)
@@ -270,9 +299,9 @@ func GetWorktreeByID(db *sql.DB, id int) (*models.Worktree, error) {
 }
 
 // GetWorktreeByPath retrieves a worktree by path
-func GetWorktreeByPath(db *sql.DB, path string) (*models.Worktree, error) {
+func (s *SQLiteStore) GetWorktreeByPath(path string) (*models.Worktree, error) {
 	worktree := &models.Worktree{}
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, project_id, branch, path, is_main, created_at, last_used FROM worktrees WHERE path = ?",
 		path,
 	).Scan(&worktree.ID, &worktree.ProjectID, &worktree.Branch, &worktree.Path, &worktree.IsMain, &worktree.CreatedAt, &worktree.LastUsed)
@@ -288,8 +317,8 @@ func GetWorktreeByPath(db *sql.DB, path string) (*models.Worktree, error) {
 }
 
 // GetWorktreesByProject retrieves all worktrees for a project
-func GetWorktreesByProject(db *sql.DB, projectID int) ([]*models.Worktree, error) {
-	rows, err := db.Query(
+func (s *SQLiteStore) GetWorktreesByProject(projectID int) ([]*models.Worktree, error) {
+	rows, err := s.db.Query(
 		"SELECT id, project_id, branch, path, is_main, created_at, last_used FROM worktrees WHERE project_id = ? ORDER BY last_used DESC",
 		projectID,
 	)
@@ -325,8 +354,8 @@ func GetWorktreesByProject(db *sql.DB, projectID int) ([]*models.Worktree, error
 }
 
 // UpdateWorktreeLastUsed updates the last_used timestamp for a worktree
-func UpdateWorktreeLastUsed(db *sql.DB, id int) error {
-	_, err := db.Exec(
+func (s *SQLiteStore) UpdateWorktreeLastUsed(id int) error {
+	_, err := s.db.Exec(
 		"UPDATE worktrees SET last_used = ? WHERE id = ?",
 		time.Now(), id,
 	)
@@ -337,9 +366,9 @@ func UpdateWorktreeLastUsed(db *sql.DB, id int) error {
 }
 
 // DeleteWorktree deletes a worktree and its associated session
-func DeleteWorktree(db *sql.DB, id int) error {
+func (s *SQLiteStore) DeleteWorktree(id int) error {
 	// Foreign key constraints will cascade delete sessions
-	result, err := db.Exec("DELETE FROM worktrees WHERE id = ?", id)
+	result, err := s.db.Exec("DELETE FROM worktrees WHERE id = ?", id)
 	if err != nil {
 		return eris.Wrapf(err, "failed to delete worktree with id: %d", id)
 	}
@@ -359,9 +388,9 @@ func DeleteWorktree(db *sql.DB, id int) error {
 // ==================== Session CRUD Operations ====================
 
 // CreateSession creates a new session in the database
-func CreateSession(db *sql.DB, session *models.Session) error {
+func (s *SQLiteStore) CreateSession(session *models.Session) error {
 	now := time.Now()
-	result, err := db.Exec(
+	result, err := s.db.Exec(
 		"INSERT INTO sessions (worktree_id, tmux_session_name, created_at, last_attached) VALUES (?, ?, ?, ?)",
 		session.WorktreeID, session.TmuxSessionName, now, now,
 	)
@@ -381,9 +410,9 @@ func CreateSession(db *sql.DB, session *models.Session) error {
 }
 
 // GetSessionByWorktree retrieves a session by worktree ID
-func GetSessionByWorktree(db *sql.DB, worktreeID int) (*models.Session, error) {
+func (s *SQLiteStore) GetSessionByWorktree(worktreeID int) (*models.Session, error) {
 	session := &models.Session{}
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, worktree_id, tmux_session_name, created_at, last_attached FROM sessions WHERE worktree_id = ?",
 		worktreeID,
 	).Scan(&session.ID, &session.WorktreeID, &session.TmuxSessionName, &session.CreatedAt, &session.LastAttached)
@@ -399,9 +428,9 @@ func GetSessionByWorktree(db *sql.DB, worktreeID int) (*models.Session, error) {
 }
 
 // GetSessionByTmuxName retrieves a session by tmux session name
-func GetSessionByTmuxName(db *sql.DB, tmuxName string) (*models.Session, error) {
+func (s *SQLiteStore) GetSessionByTmuxName(tmuxName string) (*models.Session, error) {
 	session := &models.Session{}
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, worktree_id, tmux_session_name, created_at, last_attached FROM sessions WHERE tmux_session_name = ?",
 		tmuxName,
 	).Scan(&session.ID, &session.WorktreeID, &session.TmuxSessionName, &session.CreatedAt, &session.LastAttached)
@@ -417,8 +446,8 @@ func GetSessionByTmuxName(db *sql.DB, tmuxName string) (*models.Session, error)
 }
 
 // GetAllSessions retrieves all sessions
-func GetAllSessions(db *sql.DB) ([]*models.Session, error) {
-	rows, err := db.Query(
+func (s *SQLiteStore) GetAllSessions() ([]*models.Session, error) {
+	rows, err := s.db.Query(
 		"SELECT id, worktree_id, tmux_session_name, created_at, last_attached FROM sessions ORDER BY last_attached DESC",
 	)
 	if err != nil {
@@ -451,7 +480,7 @@ func GetAllSessions(db *sql.DB) ([]*models.Session, error) {
 }
 
 // GetAllSessionDetails retrieves all sessions with their worktree and project information
-func GetAllSessionDetails(db *sql.DB) ([]*models.SessionDetails, error) {
+func (s *SQLiteStore) GetAllSessionDetails() ([]*models.SessionDetails, error) {
 	query := `
 		SELECT
 			s.id, s.worktree_id, s.tmux_session_name, s.created_at, s.last_attached,
@@ -463,7 +492,7 @@ func GetAllSessionDetails(db *sql.DB) ([]*models.SessionDetails, error) {
 		ORDER BY s.last_attached DESC
 	`
 
-	rows, err := db.Query(query)
+	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to query session details")
 	}
@@ -520,8 +549,8 @@ func GetAllSessionDetails(db *sql.DB) ([]*models.SessionDetails, error) {
 }
 
 // UpdateSessionLastAttached updates the last_attached timestamp for a session
-func UpdateSessionLastAttached(db *sql.DB, id int) error {
-	_, err := db.Exec(
+func (s *SQLiteStore) UpdateSessionLastAttached(id int) error {
+	_, err := s.db.Exec(
 		"UPDATE sessions SET last_attached = ? WHERE id = ?",
 		time.Now(), id,
 	)
@@ -532,8 +561,8 @@ func UpdateSessionLastAttached(db *sql.DB, id int) error {
 }
 
 // DeleteSession deletes a session
-func DeleteSession(db *sql.DB, id int) error {
-	result, err := db.Exec("DELETE FROM sessions WHERE id = ?", id)
+func (s *SQLiteStore) DeleteSession(id int) error {
+	result, err := s.db.Exec("DELETE FROM sessions WHERE id = ?", id)
 	if err != nil {
 		return eris.Wrapf(err, "failed to delete session with id: %d", id)
 	}
@@ -553,8 +582,8 @@ func DeleteSession(db *sql.DB, id int) error {
 // ==================== Session History Operations ====================
 
 // AddSessionHistory records a session access in the history stack
-func AddSessionHistory(db *sql.DB, sessionName, projectName, branch string) error {
-	_, err := db.Exec(
+func (s *SQLiteStore) AddSessionHistory(sessionName, projectName, branch string) error {
+	_, err := s.db.Exec(
 		"INSERT INTO session_history (session_name, project_name, branch, accessed_at) VALUES (?, ?, ?, ?)",
 		sessionName, projectName, branch, time.Now(),
 	)
@@ -565,8 +594,8 @@ func AddSessionHistory(db *sql.DB, sessionName, projectName, branch string) erro
 }
 
 // GetRecentSessionHistory retrieves recent session history (most recent first)
-func GetRecentSessionHistory(db *sql.DB, limit int) ([]*models.SessionHistory, error) {
-	rows, err := db.Query(
+func (s *SQLiteStore) GetRecentSessionHistory(limit int) ([]*models.SessionHistory, error) {
+	rows, err := s.db.Query(
 		"SELECT id, session_name, project_name, branch, accessed_at FROM session_history ORDER BY accessed_at DESC LIMIT ?",
 		limit,
 	)
@@ -600,9 +629,9 @@ func GetRecentSessionHistory(db *sql.DB, limit int) ([]*models.SessionHistory, e
 
 // GetPreviousSession retrieves the previous session from history (excluding the current session)
 // If currentSessionName is provided, it will skip entries with that name and return the most recent different session
-func GetPreviousSession(db *sql.DB, currentSessionName string) (*models.SessionHistory, error) {
+func (s *SQLiteStore) GetPreviousSession(currentSessionName string) (*models.SessionHistory, error) {
 	var entry models.SessionHistory
-	err := db.QueryRow(
+	err := s.db.QueryRow(
 		"SELECT id, session_name, project_name, branch, accessed_at FROM session_history WHERE session_name != ? ORDER BY accessed_at DESC LIMIT 1",
 		currentSessionName,
 	).Scan(&entry.ID, &entry.SessionName, &entry.ProjectName, &entry.Branch, &entry.AccessedAt)
@@ -618,9 +647,9 @@ func GetPreviousSession(db *sql.DB, currentSessionName string) (*models.SessionH
 }
 
 // ClearOldSessionHistory removes session history entries older than the specified number of days
-func ClearOldSessionHistory(db *sql.DB, daysToKeep int) error {
+func (s *SQLiteStore) ClearOldSessionHistory(daysToKeep int) error {
 	cutoffDate := time.Now().AddDate(0, 0, -daysToKeep)
-	_, err := db.Exec(
+	_, err := s.db.Exec(
 		"DELETE FROM session_history WHERE accessed_at < ?",
 		cutoffDate,
 	)
@@ -629,3 +658,450 @@ func ClearOldSessionHistory(db *sql.DB, daysToKeep int) error {
 	}
 	return nil
 }
+
+// ==================== Trash Operations ====================
+
+// AddTrashEntry records a worktree that was moved to the trash
+func (s *SQLiteStore) AddTrashEntry(entry *models.TrashEntry) error {
+	now := time.Now()
+	result, err := s.db.Exec(
+		`INSERT INTO trash_entries (operation_id, project_name, branch, commit_sha, original_path, trash_path, trashed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.OperationID, entry.ProjectName, entry.Branch, entry.CommitSHA,
+		entry.OriginalPath, entry.TrashPath, now,
+	)
+	if err != nil {
+		return eris.Wrap(err, "failed to insert trash entry")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return eris.Wrap(err, "failed to get last insert id")
+	}
+
+	entry.ID = int(id)
+	entry.TrashedAt = now
+	return nil
+}
+
+// GetTrashEntries retrieves all trash entries (most recent first)
+func (s *SQLiteStore) GetTrashEntries() ([]*models.TrashEntry, error) {
+	return s.queryTrashEntries("ORDER BY trashed_at DESC, id DESC")
+}
+
+// GetTrashEntriesByOperation retrieves all trash entries recorded by a single operation
+func (s *SQLiteStore) GetTrashEntriesByOperation(operationID string) ([]*models.TrashEntry, error) {
+	return s.queryTrashEntries("WHERE operation_id = ? ORDER BY id", operationID)
+}
+
+// GetLastTrashOperation retrieves the entries of the most recent operation that moved worktrees to the trash
+func (s *SQLiteStore) GetLastTrashOperation() ([]*models.TrashEntry, error) {
+	var operationID string
+	err := s.db.QueryRow(
+		"SELECT operation_id FROM trash_entries ORDER BY trashed_at DESC, id DESC LIMIT 1",
+	).Scan(&operationID)
+
+	if err == sql.ErrNoRows {
+		return nil, eris.New("trash is empty")
+	}
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query last trash operation")
+	}
+
+	return s.GetTrashEntriesByOperation(operationID)
+}
+
+// GetTrashEntriesOlderThan retrieves trash entries that were trashed before the cutoff
+func (s *SQLiteStore) GetTrashEntriesOlderThan(cutoff time.Time) ([]*models.TrashEntry, error) {
+	return s.queryTrashEntries("WHERE trashed_at < ? ORDER BY trashed_at", cutoff)
+}
+
+// DeleteTrashEntry removes a trash entry by ID
+func (s *SQLiteStore) DeleteTrashEntry(id int) error {
+	result, err := s.db.Exec("DELETE FROM trash_entries WHERE id = ?", id)
+	if err != nil {
+		return eris.Wrap(err, "failed to delete trash entry")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return eris.Wrap(err, "failed to get rows affected")
+	}
+
+	if rows == 0 {
+		return eris.Errorf("trash entry not found with id: %d", id)
+	}
+
+	return nil
+}
+
+// queryTrashEntries runs a trash entry query with the given WHERE/ORDER clause
+func (s *SQLiteStore) queryTrashEntries(clause string, args ...any) ([]*models.TrashEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT id, operation_id, project_name, branch, commit_sha, original_path, trash_path, trashed_at
+		FROM trash_entries `+clause,
+		args...,
+	)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query trash entries")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	var entries []*models.TrashEntry
+	for rows.Next() {
+		entry := &models.TrashEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.OperationID,
+			&entry.ProjectName,
+			&entry.Branch,
+			&entry.CommitSHA,
+			&entry.OriginalPath,
+			&entry.TrashPath,
+			&entry.TrashedAt,
+		)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to scan trash entry row")
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "error iterating trash entry rows")
+	}
+
+	return entries, nil
+}
+
+// ==================== Operation Log Operations ====================
+
+// AddOperationLog records a mutating operation in the audit log
+func (s *SQLiteStore) AddOperationLog(entry *models.OperationLogEntry) error {
+	now := time.Now()
+	result, err := s.db.Exec(
+		`INSERT INTO operation_log (operation, project_name, branch, args, outcome, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.Operation, entry.ProjectName, entry.Branch, entry.Args, entry.Outcome, entry.Error, now,
+	)
+	if err != nil {
+		return eris.Wrap(err, "failed to insert operation log entry")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return eris.Wrap(err, "failed to get last insert id")
+	}
+
+	entry.ID = int(id)
+	entry.CreatedAt = now
+	return nil
+}
+
+// GetOperationLog retrieves recent audit log entries (most recent first)
+// Empty projectName or branch match all entries
+func (s *SQLiteStore) GetOperationLog(projectName, branch string, limit int) ([]*models.OperationLogEntry, error) {
+	rows, err := s.db.Query(
+		`SELECT id, operation, project_name, branch, args, outcome, error, created_at
+		FROM operation_log
+		WHERE (? = '' OR project_name = ?) AND (? = '' OR branch = ?)
+		ORDER BY created_at DESC, id DESC LIMIT ?`,
+		projectName, projectName, branch, branch, limit,
+	)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query operation log")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	var entries []*models.OperationLogEntry
+	for rows.Next() {
+		entry := &models.OperationLogEntry{}
+		var projectName, branch, args, errMsg sql.NullString
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Operation,
+			&projectName,
+			&branch,
+			&args,
+			&entry.Outcome,
+			&errMsg,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to scan operation log row")
+		}
+		entry.ProjectName = projectName.String
+		entry.Branch = branch.String
+		entry.Args = args.String
+		entry.Error = errMsg.String
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "error iterating operation log rows")
+	}
+
+	return entries, nil
+}
+
+// ClearOldOperationLog removes audit log entries older than the specified number of days
+func (s *SQLiteStore) ClearOldOperationLog(daysToKeep int) error {
+	cutoffDate := time.Now().AddDate(0, 0, -daysToKeep)
+	_, err := s.db.Exec("DELETE FROM operation_log WHERE created_at < ?", cutoffDate)
+	if err != nil {
+		return eris.Wrap(err, "failed to clear old operation log")
+	}
+	return nil
+}
+
+// DeleteSessionHistoryByProject removes all session history entries for a project
+func (s *SQLiteStore) DeleteSessionHistoryByProject(projectName string) error {
+	_, err := s.db.Exec("DELETE FROM session_history WHERE project_name = ?", projectName)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete session history for project: %s", projectName)
+	}
+	return nil
+}
+
+// GetTrashEntriesByProject retrieves all trash entries for a project
+func (s *SQLiteStore) GetTrashEntriesByProject(projectName string) ([]*models.TrashEntry, error) {
+	return s.queryTrashEntries("WHERE project_name = ? ORDER BY trashed_at DESC, id DESC", projectName)
+}
+
+// AllocatePortBlock returns the first port of the block of ports assigned to a worktree,
+// assigning it the lowest free block of size ports from start on when it has none yet.
+// A block of another size, from before the size was changed, is assigned again.
+func (s *SQLiteStore) AllocatePortBlock(projectName, branch string, start, size int) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to begin transaction")
+	}
+	//nolint:errcheck // Rollback after commit is a no-op
+	defer tx.Rollback()
+
+	var basePort, blockSize int
+	err = tx.QueryRow(
+		"SELECT base_port, size FROM port_blocks WHERE project_name = ? AND branch = ?",
+		projectName, branch,
+	).Scan(&basePort, &blockSize)
+	if err == nil && blockSize == size {
+		return basePort, nil
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return 0, eris.Wrap(err, "failed to query port block")
+	}
+
+	rows, err := tx.Query(
+		"SELECT base_port, size FROM port_blocks WHERE NOT (project_name = ? AND branch = ?) ORDER BY base_port",
+		projectName, branch,
+	)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to query port blocks")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	// The taken blocks are sorted, so the first gap large enough is the lowest free block
+	basePort = start
+	for rows.Next() {
+		var takenBase, takenSize int
+		if err := rows.Scan(&takenBase, &takenSize); err != nil {
+			return 0, eris.Wrap(err, "failed to scan port block row")
+		}
+		if basePort+size <= takenBase {
+			break
+		}
+		basePort = max(basePort, takenBase+takenSize)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, eris.Wrap(err, "error iterating port block rows")
+	}
+	if basePort+size > 65536 {
+		return 0, eris.Errorf("no free block of %d ports from port %d on", size, start)
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO port_blocks (project_name, branch, base_port, size, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (project_name, branch) DO UPDATE SET base_port = excluded.base_port, size = excluded.size`,
+		projectName, branch, basePort, size, time.Now(),
+	)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to insert port block")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, eris.Wrap(err, "failed to commit port block")
+	}
+	return basePort, nil
+}
+
+// DeletePortBlock releases the block of ports assigned to a worktree
+func (s *SQLiteStore) DeletePortBlock(projectName, branch string) error {
+	_, err := s.db.Exec("DELETE FROM port_blocks WHERE project_name = ? AND branch = ?", projectName, branch)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete port block of branch: %s", branch)
+	}
+	return nil
+}
+
+// DeletePortBlocksByProject releases the blocks of ports assigned to the worktrees of a project
+func (s *SQLiteStore) DeletePortBlocksByProject(projectName string) error {
+	_, err := s.db.Exec("DELETE FROM port_blocks WHERE project_name = ?", projectName)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete port blocks for project: %s", projectName)
+	}
+	return nil
+}
+
+// AddProjectTag tags a project; a tag it already has is kept
+func (s *SQLiteStore) AddProjectTag(projectName, tag string) error {
+	_, err := s.db.Exec(
+		"INSERT INTO project_tags (project_name, tag, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		projectName, tag, time.Now(),
+	)
+	if err != nil {
+		return eris.Wrapf(err, "failed to tag project %s with %s", projectName, tag)
+	}
+	return nil
+}
+
+// RemoveProjectTag removes a tag from a project
+func (s *SQLiteStore) RemoveProjectTag(projectName, tag string) error {
+	_, err := s.db.Exec("DELETE FROM project_tags WHERE project_name = ? AND tag = ?", projectName, tag)
+	if err != nil {
+		return eris.Wrapf(err, "failed to remove tag %s from project %s", tag, projectName)
+	}
+	return nil
+}
+
+// GetProjectTags returns the tags of each tagged project, sorted, by project name
+func (s *SQLiteStore) GetProjectTags() (map[string][]string, error) {
+	rows, err := s.db.Query("SELECT project_name, tag FROM project_tags ORDER BY project_name, tag")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query project tags")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var projectName, tag string
+		if err := rows.Scan(&projectName, &tag); err != nil {
+			return nil, eris.Wrap(err, "failed to scan project tag row")
+		}
+		tags[projectName] = append(tags[projectName], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "error iterating project tag rows")
+	}
+	return tags, nil
+}
+
+// DeleteProjectTagsByProject removes the tags of a project
+func (s *SQLiteStore) DeleteProjectTagsByProject(projectName string) error {
+	_, err := s.db.Exec("DELETE FROM project_tags WHERE project_name = ?", projectName)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete tags for project: %s", projectName)
+	}
+	return nil
+}
+
+// PinSession pins the session of a branch; pinning it again keeps when it was first pinned
+func (s *SQLiteStore) PinSession(sessionName, projectName, branch string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO pinned_sessions (project_name, branch, session_name, pinned_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (project_name, branch) DO UPDATE SET session_name = excluded.session_name`,
+		projectName, branch, sessionName, time.Now(),
+	)
+	if err != nil {
+		return eris.Wrapf(err, "failed to pin session %s", sessionName)
+	}
+	return nil
+}
+
+// UnpinSession unpins the session of a branch
+func (s *SQLiteStore) UnpinSession(projectName, branch string) error {
+	_, err := s.db.Exec("DELETE FROM pinned_sessions WHERE project_name = ? AND branch = ?", projectName, branch)
+	if err != nil {
+		return eris.Wrapf(err, "failed to unpin branch %s of %s", branch, projectName)
+	}
+	return nil
+}
+
+// GetPinnedSessions returns the pinned sessions, in the order they were pinned
+func (s *SQLiteStore) GetPinnedSessions() ([]*models.PinnedSession, error) {
+	rows, err := s.db.Query(
+		"SELECT session_name, project_name, branch, pinned_at FROM pinned_sessions ORDER BY pinned_at, session_name",
+	)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query pinned sessions")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	var pins []*models.PinnedSession
+	for rows.Next() {
+		pin := &models.PinnedSession{}
+		if err := rows.Scan(&pin.SessionName, &pin.ProjectName, &pin.Branch, &pin.PinnedAt); err != nil {
+			return nil, eris.Wrap(err, "failed to scan pinned session row")
+		}
+		pins = append(pins, pin)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "error iterating pinned session rows")
+	}
+	return pins, nil
+}
+
+// DeletePinnedSessionsByProject unpins the sessions of a project
+func (s *SQLiteStore) DeletePinnedSessionsByProject(projectName string) error {
+	_, err := s.db.Exec("DELETE FROM pinned_sessions WHERE project_name = ?", projectName)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete pinned sessions for project: %s", projectName)
+	}
+	return nil
+}
+
+// SetSessionPrefix keeps the session prefix of a project; the prefix it was first given is kept
+func (s *SQLiteStore) SetSessionPrefix(projectName, prefix string) error {
+	_, err := s.db.Exec(
+		"INSERT INTO session_prefixes (project_name, prefix, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING",
+		projectName, prefix, time.Now(),
+	)
+	if err != nil {
+		return eris.Wrapf(err, "failed to set session prefix of project %s", projectName)
+	}
+	return nil
+}
+
+// GetSessionPrefixes returns the kept session prefixes, by project name
+func (s *SQLiteStore) GetSessionPrefixes() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT project_name, prefix FROM session_prefixes")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query session prefixes")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	prefixes := make(map[string]string)
+	for rows.Next() {
+		var projectName, prefix string
+		if err := rows.Scan(&projectName, &prefix); err != nil {
+			return nil, eris.Wrap(err, "failed to scan session prefix row")
+		}
+		prefixes[projectName] = prefix
+	}
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "error iterating session prefix rows")
+	}
+	return prefixes, nil
+}
+
+// DeleteSessionPrefix forgets the session prefix of a project
+func (s *SQLiteStore) DeleteSessionPrefix(projectName string) error {
+	_, err := s.db.Exec("DELETE FROM session_prefixes WHERE project_name = ?", projectName)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete session prefix of project: %s", projectName)
+	}
+	return nil
+}
@@ -1,8 +1,9 @@
 package db
 
 import (
-	"database/sql"
+	"fmt"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -10,14 +11,14 @@ import (
 )
 
 // setupTestDB creates an in-memory SQLite database for testing
-func setupTestDB(t *testing.T) *sql.DB {
+func setupTestDB(t *testing.T) *SQLiteStore {
 	t.Helper()
 
 	// Create a temporary database file
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test.db")
 
-	db, err := InitDB(dbPath)
+	db, err := Open(dbPath)
 	if err != nil {
 		t.Fatalf("Failed to initialize test database: %v", err)
 	}
@@ -52,6 +53,31 @@ func TestInitDB(t *testing.T) {
 		t.Errorf("Foreign keys not enabled: got %d, want 1", foreignKeys)
 	}
 
+	// Verify concurrency pragmas are set
+	var journalMode string
+	if err := db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		t.Errorf("Failed to query journal_mode pragma: %v", err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("journal_mode = %q, want %q", journalMode, "wal")
+	}
+
+	var busyTimeout int64
+	if err := db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Errorf("Failed to query busy_timeout pragma: %v", err)
+	}
+	if busyTimeout != BusyTimeout.Milliseconds() {
+		t.Errorf("busy_timeout = %d, want %d", busyTimeout, BusyTimeout.Milliseconds())
+	}
+
+	var synchronous int
+	if err := db.QueryRow("PRAGMA synchronous").Scan(&synchronous); err != nil {
+		t.Errorf("Failed to query synchronous pragma: %v", err)
+	}
+	if synchronous != 1 {
+		t.Errorf("synchronous = %d, want 1 (NORMAL)", synchronous)
+	}
+
 	// Verify migrations were run (check if tables exist)
 	tables := []string{"projects", "worktrees", "sessions", "schema_migrations"}
 	for _, table := range tables {
@@ -66,6 +92,39 @@ func TestInitDB(t *testing.T) {
 	}
 }
 
+func TestInitDB_ConcurrentConnections(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	// Separate connections simulate separate sesh processes
+	const workers = 8
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			store, err := Open(dbPath)
+			if err != nil {
+				errs <- err
+				return
+			}
+			//nolint:errcheck // Test cleanup
+			defer store.Close()
+
+			for j := 0; j < 10; j++ {
+				if err := store.AddSessionHistory(fmt.Sprintf("session-%d-%d", i, j), "project", "branch"); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}(i)
+	}
+
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("concurrent access failed: %v", err)
+		}
+	}
+}
+
 func TestInitDB_InvalidPath(t *testing.T) {
 	// Use an invalid path that should fail
 	invalidPath := "/nonexistent/directory/test.db"
@@ -92,7 +151,7 @@ func TestCreateProject(t *testing.T) {
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
 
-	err := CreateProject(db, project)
+	err := db.CreateProject(project)
 	if err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
@@ -102,7 +161,7 @@ func TestCreateProject(t *testing.T) {
 	}
 
 	// Verify project was inserted
-	retrieved, err := GetProject(db, project.Name)
+	retrieved, err := db.GetProject(project.Name)
 	if err != nil {
 		t.Fatalf("GetProject() failed: %v", err)
 	}
@@ -130,7 +189,7 @@ func TestCreateProject_Duplicate(t *testing.T) {
 	}
 
 	// First insert should succeed
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("First CreateProject() failed: %v", err)
 	}
 
@@ -141,7 +200,7 @@ func TestCreateProject_Duplicate(t *testing.T) {
 		LocalPath: "/different/path",
 	}
 
-	err := CreateProject(db, duplicate)
+	err := db.CreateProject(duplicate)
 	if err == nil {
 		t.Error("CreateProject() should fail for duplicate name")
 	}
@@ -152,7 +211,7 @@ func TestGetProject_NotFound(t *testing.T) {
 	//nolint:errcheck // Test cleanup
 	defer db.Close()
 
-	_, err := GetProject(db, "nonexistent")
+	_, err := db.GetProject("nonexistent")
 	if err == nil {
 		t.Error("GetProject() should return error for nonexistent project")
 	}
@@ -169,11 +228,11 @@ func TestGetProjectByID(t *testing.T) {
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
 
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
-	retrieved, err := GetProjectByID(db, project.ID)
+	retrieved, err := db.GetProjectByID(project.ID)
 	if err != nil {
 		t.Fatalf("GetProjectByID() failed: %v", err)
 	}
@@ -194,11 +253,11 @@ func TestGetProjectByRemote(t *testing.T) {
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
 
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
-	retrieved, err := GetProjectByRemote(db, project.RemoteURL)
+	retrieved, err := db.GetProjectByRemote(project.RemoteURL)
 	if err != nil {
 		t.Fatalf("GetProjectByRemote() failed: %v", err)
 	}
@@ -228,12 +287,12 @@ func TestGetAllProjects(t *testing.T) {
 	}
 
 	for _, p := range projects {
-		if err := CreateProject(db, p); err != nil {
+		if err := db.CreateProject(p); err != nil {
 			t.Fatalf("CreateProject() failed: %v", err)
 		}
 	}
 
-	retrieved, err := GetAllProjects(db)
+	retrieved, err := db.GetAllProjects()
 	if err != nil {
 		t.Fatalf("GetAllProjects() failed: %v", err)
 	}
@@ -254,12 +313,12 @@ func TestUpdateProjectFetchTime(t *testing.T) {
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
 
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
 	// Initially, LastFetched should be nil
-	retrieved, err := GetProject(db, project.Name)
+	retrieved, err := db.GetProject(project.Name)
 	if err != nil {
 		t.Fatalf("GetProject() failed: %v", err)
 	}
@@ -269,12 +328,12 @@ func TestUpdateProjectFetchTime(t *testing.T) {
 
 	// Update fetch time
 	time.Sleep(10 * time.Millisecond) // Small delay to ensure timestamp difference
-	if err := UpdateProjectFetchTime(db, project.ID); err != nil {
+	if err := db.UpdateProjectFetchTime(project.ID); err != nil {
 		t.Fatalf("UpdateProjectFetchTime() failed: %v", err)
 	}
 
 	// Verify LastFetched was updated
-	retrieved, err = GetProject(db, project.Name)
+	retrieved, err = db.GetProject(project.Name)
 	if err != nil {
 		t.Fatalf("GetProject() failed: %v", err)
 	}
@@ -294,17 +353,17 @@ func TestDeleteProject(t *testing.T) {
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
 
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
 	// Delete the project
-	if err := DeleteProject(db, project.ID); err != nil {
+	if err := db.DeleteProject(project.ID); err != nil {
 		t.Fatalf("DeleteProject() failed: %v", err)
 	}
 
 	// Verify project was deleted
-	_, err := GetProject(db, project.Name)
+	_, err := db.GetProject(project.Name)
 	if err == nil {
 		t.Error("GetProject() should fail for deleted project")
 	}
@@ -323,7 +382,7 @@ func TestCreateWorktree(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -334,7 +393,7 @@ func TestCreateWorktree(t *testing.T) {
 		IsMain:    true,
 	}
 
-	err := CreateWorktree(db, worktree)
+	err := db.CreateWorktree(worktree)
 	if err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
@@ -344,7 +403,7 @@ func TestCreateWorktree(t *testing.T) {
 	}
 
 	// Verify worktree was inserted
-	retrieved, err := GetWorktree(db, project.ID, "main")
+	retrieved, err := db.GetWorktree(project.ID, "main")
 	if err != nil {
 		t.Fatalf("GetWorktree() failed: %v", err)
 	}
@@ -370,7 +429,7 @@ func TestGetWorktreeByPath(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -380,11 +439,11 @@ func TestGetWorktreeByPath(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
-	retrieved, err := GetWorktreeByPath(db, worktree.Path)
+	retrieved, err := db.GetWorktreeByPath(worktree.Path)
 	if err != nil {
 		t.Fatalf("GetWorktreeByPath() failed: %v", err)
 	}
@@ -404,7 +463,7 @@ func TestGetWorktreesByProject(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -425,12 +484,12 @@ func TestGetWorktreesByProject(t *testing.T) {
 	}
 
 	for _, w := range worktrees {
-		if err := CreateWorktree(db, w); err != nil {
+		if err := db.CreateWorktree(w); err != nil {
 			t.Fatalf("CreateWorktree() failed: %v", err)
 		}
 	}
 
-	retrieved, err := GetWorktreesByProject(db, project.ID)
+	retrieved, err := db.GetWorktreesByProject(project.ID)
 	if err != nil {
 		t.Fatalf("GetWorktreesByProject() failed: %v", err)
 	}
@@ -450,7 +509,7 @@ func TestUpdateWorktreeLastUsed(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -460,7 +519,7 @@ func TestUpdateWorktreeLastUsed(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -468,12 +527,12 @@ func TestUpdateWorktreeLastUsed(t *testing.T) {
 
 	// Update last used time
 	time.Sleep(10 * time.Millisecond)
-	if err := UpdateWorktreeLastUsed(db, worktree.ID); err != nil {
+	if err := db.UpdateWorktreeLastUsed(worktree.ID); err != nil {
 		t.Fatalf("UpdateWorktreeLastUsed() failed: %v", err)
 	}
 
 	// Verify LastUsed was updated
-	retrieved, err := GetWorktree(db, project.ID, "main")
+	retrieved, err := db.GetWorktree(project.ID, "main")
 	if err != nil {
 		t.Fatalf("GetWorktree() failed: %v", err)
 	}
@@ -493,7 +552,7 @@ func TestDeleteWorktree(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -503,17 +562,17 @@ func TestDeleteWorktree(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
 	// Delete the worktree
-	if err := DeleteWorktree(db, worktree.ID); err != nil {
+	if err := db.DeleteWorktree(worktree.ID); err != nil {
 		t.Fatalf("DeleteWorktree() failed: %v", err)
 	}
 
 	// Verify worktree was deleted
-	_, err := GetWorktree(db, project.ID, "main")
+	_, err := db.GetWorktree(project.ID, "main")
 	if err == nil {
 		t.Error("GetWorktree() should fail for deleted worktree")
 	}
@@ -532,7 +591,7 @@ func TestCreateSession(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -542,7 +601,7 @@ func TestCreateSession(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -551,7 +610,7 @@ func TestCreateSession(t *testing.T) {
 		TmuxSessionName: "repo:main",
 	}
 
-	err := CreateSession(db, session)
+	err := db.CreateSession(session)
 	if err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
@@ -561,7 +620,7 @@ func TestCreateSession(t *testing.T) {
 	}
 
 	// Verify session was inserted
-	retrieved, err := GetSessionByWorktree(db, worktree.ID)
+	retrieved, err := db.GetSessionByWorktree(worktree.ID)
 	if err != nil {
 		t.Fatalf("GetSessionByWorktree() failed: %v", err)
 	}
@@ -581,7 +640,7 @@ func TestGetSessionByTmuxName(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -591,7 +650,7 @@ func TestGetSessionByTmuxName(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -599,11 +658,11 @@ func TestGetSessionByTmuxName(t *testing.T) {
 		WorktreeID:      worktree.ID,
 		TmuxSessionName: "repo:main",
 	}
-	if err := CreateSession(db, session); err != nil {
+	if err := db.CreateSession(session); err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
 
-	retrieved, err := GetSessionByTmuxName(db, "repo:main")
+	retrieved, err := db.GetSessionByTmuxName("repo:main")
 	if err != nil {
 		t.Fatalf("GetSessionByTmuxName() failed: %v", err)
 	}
@@ -623,7 +682,7 @@ func TestGetAllSessions(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -636,7 +695,7 @@ func TestGetAllSessions(t *testing.T) {
 			Path:      "/home/user/.sesh/github.com/test/repo/" + branch,
 			IsMain:    branch == "main",
 		}
-		if err := CreateWorktree(db, worktree); err != nil {
+		if err := db.CreateWorktree(worktree); err != nil {
 			t.Fatalf("CreateWorktree() failed: %v", err)
 		}
 
@@ -644,12 +703,12 @@ func TestGetAllSessions(t *testing.T) {
 			WorktreeID:      worktree.ID,
 			TmuxSessionName: "repo:" + branch,
 		}
-		if err := CreateSession(db, session); err != nil {
+		if err := db.CreateSession(session); err != nil {
 			t.Fatalf("CreateSession() failed: %v", err)
 		}
 	}
 
-	sessions, err := GetAllSessions(db)
+	sessions, err := db.GetAllSessions()
 	if err != nil {
 		t.Fatalf("GetAllSessions() failed: %v", err)
 	}
@@ -669,7 +728,7 @@ func TestGetAllSessionDetails(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -679,7 +738,7 @@ func TestGetAllSessionDetails(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -687,11 +746,11 @@ func TestGetAllSessionDetails(t *testing.T) {
 		WorktreeID:      worktree.ID,
 		TmuxSessionName: "repo:main",
 	}
-	if err := CreateSession(db, session); err != nil {
+	if err := db.CreateSession(session); err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
 
-	details, err := GetAllSessionDetails(db)
+	details, err := db.GetAllSessionDetails()
 	if err != nil {
 		t.Fatalf("GetAllSessionDetails() failed: %v", err)
 	}
@@ -726,7 +785,7 @@ func TestUpdateSessionLastAttached(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -736,7 +795,7 @@ func TestUpdateSessionLastAttached(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -744,7 +803,7 @@ func TestUpdateSessionLastAttached(t *testing.T) {
 		WorktreeID:      worktree.ID,
 		TmuxSessionName: "repo:main",
 	}
-	if err := CreateSession(db, session); err != nil {
+	if err := db.CreateSession(session); err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
 
@@ -752,12 +811,12 @@ func TestUpdateSessionLastAttached(t *testing.T) {
 
 	// Update last attached time
 	time.Sleep(10 * time.Millisecond)
-	if err := UpdateSessionLastAttached(db, session.ID); err != nil {
+	if err := db.UpdateSessionLastAttached(session.ID); err != nil {
 		t.Fatalf("UpdateSessionLastAttached() failed: %v", err)
 	}
 
 	// Verify LastAttached was updated
-	retrieved, err := GetSessionByWorktree(db, worktree.ID)
+	retrieved, err := db.GetSessionByWorktree(worktree.ID)
 	if err != nil {
 		t.Fatalf("GetSessionByWorktree() failed: %v", err)
 	}
@@ -777,7 +836,7 @@ func TestDeleteSession(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -787,7 +846,7 @@ func TestDeleteSession(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -795,17 +854,17 @@ func TestDeleteSession(t *testing.T) {
 		WorktreeID:      worktree.ID,
 		TmuxSessionName: "repo:main",
 	}
-	if err := CreateSession(db, session); err != nil {
+	if err := db.CreateSession(session); err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
 
 	// Delete the session
-	if err := DeleteSession(db, session.ID); err != nil {
+	if err := db.DeleteSession(session.ID); err != nil {
 		t.Fatalf("DeleteSession() failed: %v", err)
 	}
 
 	// Verify session was deleted
-	_, err := GetSessionByWorktree(db, worktree.ID)
+	_, err := db.GetSessionByWorktree(worktree.ID)
 	if err == nil {
 		t.Error("GetSessionByWorktree() should fail for deleted session")
 	}
@@ -824,7 +883,7 @@ func TestDeleteProject_CascadesWorktreesAndSessions(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -834,7 +893,7 @@ func TestDeleteProject_CascadesWorktreesAndSessions(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -842,23 +901,23 @@ func TestDeleteProject_CascadesWorktreesAndSessions(t *testing.T) {
 		WorktreeID:      worktree.ID,
 		TmuxSessionName: "repo:main",
 	}
-	if err := CreateSession(db, session); err != nil {
+	if err := db.CreateSession(session); err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
 
 	// Delete the project
-	if err := DeleteProject(db, project.ID); err != nil {
+	if err := db.DeleteProject(project.ID); err != nil {
 		t.Fatalf("DeleteProject() failed: %v", err)
 	}
 
 	// Verify worktree was cascade deleted
-	_, err := GetWorktree(db, project.ID, "main")
+	_, err := db.GetWorktree(project.ID, "main")
 	if err == nil {
 		t.Error("Worktree should be cascade deleted when project is deleted")
 	}
 
 	// Verify session was cascade deleted
-	_, err = GetSessionByWorktree(db, worktree.ID)
+	_, err = db.GetSessionByWorktree(worktree.ID)
 	if err == nil {
 		t.Error("Session should be cascade deleted when project is deleted")
 	}
@@ -875,7 +934,7 @@ func TestDeleteWorktree_CascadesSessions(t *testing.T) {
 		RemoteURL: "git@github.com:test/repo.git",
 		LocalPath: "/home/user/.sesh/github.com/test/repo.git",
 	}
-	if err := CreateProject(db, project); err != nil {
+	if err := db.CreateProject(project); err != nil {
 		t.Fatalf("CreateProject() failed: %v", err)
 	}
 
@@ -885,7 +944,7 @@ func TestDeleteWorktree_CascadesSessions(t *testing.T) {
 		Path:      "/home/user/.sesh/github.com/test/repo/main",
 		IsMain:    true,
 	}
-	if err := CreateWorktree(db, worktree); err != nil {
+	if err := db.CreateWorktree(worktree); err != nil {
 		t.Fatalf("CreateWorktree() failed: %v", err)
 	}
 
@@ -893,24 +952,359 @@ func TestDeleteWorktree_CascadesSessions(t *testing.T) {
 		WorktreeID:      worktree.ID,
 		TmuxSessionName: "repo:main",
 	}
-	if err := CreateSession(db, session); err != nil {
+	if err := db.CreateSession(session); err != nil {
 		t.Fatalf("CreateSession() failed: %v", err)
 	}
 
 	// Delete the worktree
-	if err := DeleteWorktree(db, worktree.ID); err != nil {
+	if err := db.DeleteWorktree(worktree.ID); err != nil {
 		t.Fatalf("DeleteWorktree() failed: %v", err)
 	}
 
 	// Verify session was cascade deleted
-	_, err := GetSessionByWorktree(db, worktree.ID)
+	_, err := db.GetSessionByWorktree(worktree.ID)
 	if err == nil {
 		t.Error("Session should be cascade deleted when worktree is deleted")
 	}
 
 	// Verify project still exists
-	_, err = GetProject(db, project.Name)
+	_, err = db.GetProject(project.Name)
 	if err != nil {
 		t.Error("Project should still exist after worktree deletion")
 	}
 }
+
+func TestTrashEntries(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	entries := []*models.TrashEntry{
+		{OperationID: "op1", ProjectName: "github.com/test/repo", Branch: "feature-a", CommitSHA: "aaa"},
+		{OperationID: "op2", ProjectName: "github.com/test/repo", Branch: "feature-b", CommitSHA: "bbb"},
+		{OperationID: "op2", ProjectName: "github.com/test/repo", Branch: "feature-c", CommitSHA: "ccc"},
+	}
+	for _, entry := range entries {
+		entry.OriginalPath = "/home/user/.sesh/github.com/test/repo/" + entry.Branch
+		entry.TrashPath = "/home/user/.sesh/.trash/" + entry.Branch
+		if err := db.AddTrashEntry(entry); err != nil {
+			t.Fatalf("AddTrashEntry() failed: %v", err)
+		}
+		if entry.ID == 0 {
+			t.Error("AddTrashEntry() should set entry ID")
+		}
+	}
+
+	all, err := db.GetTrashEntries()
+	if err != nil {
+		t.Fatalf("GetTrashEntries() failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("GetTrashEntries() returned %d entries, want 3", len(all))
+	}
+	if all[0].Branch != "feature-c" {
+		t.Errorf("GetTrashEntries()[0].Branch = %q, want most recent %q", all[0].Branch, "feature-c")
+	}
+
+	last, err := db.GetLastTrashOperation()
+	if err != nil {
+		t.Fatalf("GetLastTrashOperation() failed: %v", err)
+	}
+	if len(last) != 2 || last[0].OperationID != "op2" {
+		t.Errorf("GetLastTrashOperation() = %d entries, want 2 entries of op2", len(last))
+	}
+
+	old, err := db.GetTrashEntriesOlderThan(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetTrashEntriesOlderThan() failed: %v", err)
+	}
+	if len(old) != 3 {
+		t.Errorf("GetTrashEntriesOlderThan(future) returned %d entries, want 3", len(old))
+	}
+
+	old, err = db.GetTrashEntriesOlderThan(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetTrashEntriesOlderThan() failed: %v", err)
+	}
+	if len(old) != 0 {
+		t.Errorf("GetTrashEntriesOlderThan(past) returned %d entries, want 0", len(old))
+	}
+
+	for _, entry := range last {
+		if err := db.DeleteTrashEntry(entry.ID); err != nil {
+			t.Fatalf("DeleteTrashEntry() failed: %v", err)
+		}
+	}
+
+	last, err = db.GetLastTrashOperation()
+	if err != nil {
+		t.Fatalf("GetLastTrashOperation() failed: %v", err)
+	}
+	if len(last) != 1 || last[0].OperationID != "op1" {
+		t.Errorf("GetLastTrashOperation() after delete = %d entries, want 1 entry of op1", len(last))
+	}
+
+	if err := db.DeleteTrashEntry(9999); err == nil {
+		t.Error("DeleteTrashEntry() should fail for a missing entry")
+	}
+}
+
+func TestGetLastTrashOperation_Empty(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	if _, err := db.GetLastTrashOperation(); err == nil {
+		t.Error("GetLastTrashOperation() should fail when the trash is empty")
+	}
+}
+
+func TestOperationLog(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	entries := []*models.OperationLogEntry{
+		{Operation: "clone", ProjectName: "github.com/test/repo", Args: "clone git@github.com:test/repo.git", Outcome: "ok"},
+		{Operation: "create-worktree", ProjectName: "github.com/test/repo", Branch: "feature", Outcome: "ok"},
+		{Operation: "remove-worktree", ProjectName: "github.com/test/repo", Branch: "feature", Outcome: "failed", Error: "dirty"},
+		{Operation: "create-worktree", ProjectName: "github.com/test/other", Branch: "main", Outcome: "ok"},
+	}
+	for _, entry := range entries {
+		if err := db.AddOperationLog(entry); err != nil {
+			t.Fatalf("AddOperationLog() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		projectName string
+		branch      string
+		limit       int
+		wantCount   int
+		wantFirstOp string
+	}{
+		{name: "all entries", limit: 50, wantCount: 4, wantFirstOp: "create-worktree"},
+		{name: "limited", limit: 2, wantCount: 2, wantFirstOp: "create-worktree"},
+		{name: "by project", projectName: "github.com/test/repo", limit: 50, wantCount: 3, wantFirstOp: "remove-worktree"},
+		{
+			name:        "by project and branch",
+			projectName: "github.com/test/repo",
+			branch:      "feature",
+			limit:       50,
+			wantCount:   2,
+			wantFirstOp: "remove-worktree",
+		},
+		{name: "no match", branch: "missing", limit: 50, wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.GetOperationLog(tt.projectName, tt.branch, tt.limit)
+			if err != nil {
+				t.Fatalf("GetOperationLog() failed: %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("GetOperationLog() returned %d entries, want %d", len(got), tt.wantCount)
+			}
+			if tt.wantCount > 0 && got[0].Operation != tt.wantFirstOp {
+				t.Errorf("GetOperationLog()[0].Operation = %q, want %q", got[0].Operation, tt.wantFirstOp)
+			}
+		})
+	}
+
+	failed, err := db.GetOperationLog("github.com/test/repo", "feature", 1)
+	if err != nil {
+		t.Fatalf("GetOperationLog() failed: %v", err)
+	}
+	if failed[0].Outcome != "failed" || failed[0].Error != "dirty" {
+		t.Errorf("GetOperationLog() outcome = %q, error = %q, want failed/dirty", failed[0].Outcome, failed[0].Error)
+	}
+}
+
+func TestDeleteSessionHistoryByProject(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	if err := db.AddSessionHistory("repo-main", "github.com/test/repo", "main"); err != nil {
+		t.Fatalf("AddSessionHistory() failed: %v", err)
+	}
+	if err := db.AddSessionHistory("other-main", "github.com/test/other", "main"); err != nil {
+		t.Fatalf("AddSessionHistory() failed: %v", err)
+	}
+
+	if err := db.DeleteSessionHistoryByProject("github.com/test/repo"); err != nil {
+		t.Fatalf("DeleteSessionHistoryByProject() failed: %v", err)
+	}
+
+	history, err := db.GetRecentSessionHistory(10)
+	if err != nil {
+		t.Fatalf("GetRecentSessionHistory() failed: %v", err)
+	}
+	if len(history) != 1 || history[0].ProjectName != "github.com/test/other" {
+		t.Errorf("GetRecentSessionHistory() = %d entries, want only the other project's entry", len(history))
+	}
+}
+
+func TestAllocatePortBlock(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	allocate := func(project, branch string, size int) int {
+		t.Helper()
+		port, err := db.AllocatePortBlock(project, branch, 20000, size)
+		if err != nil {
+			t.Fatalf("AllocatePortBlock(%s, %s) failed: %v", project, branch, err)
+		}
+		return port
+	}
+
+	if got := allocate("github.com/test/repo", "main", 10); got != 20000 {
+		t.Errorf("first block = %d, want 20000", got)
+	}
+	if got := allocate("github.com/test/repo", "feature", 10); got != 20010 {
+		t.Errorf("second block = %d, want 20010", got)
+	}
+	if got := allocate("github.com/test/repo", "main", 10); got != 20000 {
+		t.Errorf("block of main again = %d, want the same 20000", got)
+	}
+
+	// The block of a removed worktree is reused by the next one
+	if err := db.DeletePortBlock("github.com/test/repo", "main"); err != nil {
+		t.Fatalf("DeletePortBlock() failed: %v", err)
+	}
+	if got := allocate("github.com/test/other", "main", 10); got != 20000 {
+		t.Errorf("block after release = %d, want 20000", got)
+	}
+
+	// A block of another size is assigned again, where it fits
+	if got := allocate("github.com/test/repo", "feature", 20); got != 20010 {
+		t.Errorf("resized block = %d, want 20010", got)
+	}
+	if got := allocate("github.com/test/repo", "fix", 10); got != 20030 {
+		t.Errorf("block after the resized one = %d, want 20030", got)
+	}
+
+	if err := db.DeletePortBlocksByProject("github.com/test/repo"); err != nil {
+		t.Fatalf("DeletePortBlocksByProject() failed: %v", err)
+	}
+	if got := allocate("github.com/test/repo", "docs", 5); got != 20010 {
+		t.Errorf("block after project release = %d, want 20010", got)
+	}
+
+	if _, err := db.AllocatePortBlock("github.com/test/repo", "last", 65530, 10); err == nil {
+		t.Error("AllocatePortBlock() past the last port succeeded, want an error")
+	}
+}
+
+func TestProjectTags(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	for _, tag := range []string{"backend", "go", "backend"} {
+		if err := db.AddProjectTag("github.com/test/repo", tag); err != nil {
+			t.Fatalf("AddProjectTag(%s) failed: %v", tag, err)
+		}
+	}
+	if err := db.AddProjectTag("github.com/test/other", "frontend"); err != nil {
+		t.Fatalf("AddProjectTag() failed: %v", err)
+	}
+	if err := db.RemoveProjectTag("github.com/test/repo", "go"); err != nil {
+		t.Fatalf("RemoveProjectTag() failed: %v", err)
+	}
+
+	tags, err := db.GetProjectTags()
+	if err != nil {
+		t.Fatalf("GetProjectTags() failed: %v", err)
+	}
+	want := map[string][]string{
+		"github.com/test/repo":  {"backend"},
+		"github.com/test/other": {"frontend"},
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("GetProjectTags() = %v, want %v", tags, want)
+	}
+
+	if err := db.DeleteProjectTagsByProject("github.com/test/repo"); err != nil {
+		t.Fatalf("DeleteProjectTagsByProject() failed: %v", err)
+	}
+	if tags, _ := db.GetProjectTags(); len(tags) != 1 {
+		t.Errorf("GetProjectTags() after delete = %v, want only the other project's tags", tags)
+	}
+}
+
+func TestPinnedSessions(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	pin := func(projectName, branch string) {
+		t.Helper()
+		if err := db.PinSession("repo-"+branch, projectName, branch); err != nil {
+			t.Fatalf("PinSession(%s, %s) failed: %v", projectName, branch, err)
+		}
+	}
+	pin("github.com/test/repo", "main")
+	pin("github.com/test/repo", "feature")
+	pin("github.com/test/other", "main")
+	pin("github.com/test/repo", "main") // Pinning again is a no-op
+
+	if err := db.UnpinSession("github.com/test/repo", "feature"); err != nil {
+		t.Fatalf("UnpinSession() failed: %v", err)
+	}
+
+	pins, err := db.GetPinnedSessions()
+	if err != nil {
+		t.Fatalf("GetPinnedSessions() failed: %v", err)
+	}
+	if len(pins) != 2 {
+		t.Fatalf("GetPinnedSessions() = %d pins, want 2", len(pins))
+	}
+	if pins[0].ProjectName != "github.com/test/repo" || pins[0].Branch != "main" || pins[0].SessionName != "repo-main" {
+		t.Errorf("first pin = %+v, want main of github.com/test/repo, pinned first", pins[0])
+	}
+
+	if err := db.DeletePinnedSessionsByProject("github.com/test/repo"); err != nil {
+		t.Fatalf("DeletePinnedSessionsByProject() failed: %v", err)
+	}
+	if pins, _ := db.GetPinnedSessions(); len(pins) != 1 || pins[0].ProjectName != "github.com/test/other" {
+		t.Errorf("GetPinnedSessions() after delete = %v, want only the other project's pin", pins)
+	}
+}
+
+func TestSessionPrefixes(t *testing.T) {
+	db := setupTestDB(t)
+	//nolint:errcheck // Test cleanup
+	defer db.Close()
+
+	for _, p := range []struct{ projectName, prefix string }{
+		{"github.com/acme/api", "api"},
+		{"github.com/other/api", "other-api"},
+		// A project keeps the prefix it was first given
+		{"github.com/acme/api", "acme-api"},
+	} {
+		if err := db.SetSessionPrefix(p.projectName, p.prefix); err != nil {
+			t.Fatalf("SetSessionPrefix(%s, %s) failed: %v", p.projectName, p.prefix, err)
+		}
+	}
+
+	prefixes, err := db.GetSessionPrefixes()
+	if err != nil {
+		t.Fatalf("GetSessionPrefixes() failed: %v", err)
+	}
+	want := map[string]string{"github.com/acme/api": "api", "github.com/other/api": "other-api"}
+	if !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("GetSessionPrefixes() = %v, want %v", prefixes, want)
+	}
+
+	if err := db.DeleteSessionPrefix("github.com/acme/api"); err != nil {
+		t.Fatalf("DeleteSessionPrefix() failed: %v", err)
+	}
+	want = map[string]string{"github.com/other/api": "other-api"}
+	if prefixes, _ := db.GetSessionPrefixes(); !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("GetSessionPrefixes() after delete = %v, want %v", prefixes, want)
+	}
+}
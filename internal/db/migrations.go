@@ -1,27 +1,338 @@
 package db
 
 import (
+	"crypto/sha256"
 	"database/sql"
 	_ "embed"
+	"encoding/hex"
+	"sort"
+	"time"
 
 	"github.com/rotisserie/eris"
 )
 
 // Embed migration files
 //
-//go:embed migrations/001_initial_schema.sql
-var migration001 string
+//go:embed migrations/001_initial_schema.up.sql
+var migration001Up string
 
-//go:embed migrations/002_session_history.sql
-var migration002 string
+//go:embed migrations/001_initial_schema.down.sql
+var migration001Down string
+
+//go:embed migrations/002_session_history.up.sql
+var migration002Up string
+
+//go:embed migrations/002_session_history.down.sql
+var migration002Down string
+
+//go:embed migrations/003_trash.up.sql
+var migration003Up string
+
+//go:embed migrations/003_trash.down.sql
+var migration003Down string
+
+//go:embed migrations/004_operation_log.up.sql
+var migration004Up string
+
+//go:embed migrations/004_operation_log.down.sql
+var migration004Down string
+
+//go:embed migrations/005_port_blocks.up.sql
+var migration005Up string
+
+//go:embed migrations/005_port_blocks.down.sql
+var migration005Down string
+
+//go:embed migrations/006_project_tags.up.sql
+var migration006Up string
+
+//go:embed migrations/006_project_tags.down.sql
+var migration006Down string
+
+//go:embed migrations/007_pinned_sessions.up.sql
+var migration007Up string
+
+//go:embed migrations/007_pinned_sessions.down.sql
+var migration007Down string
+
+//go:embed migrations/008_session_prefixes.up.sql
+var migration008Up string
+
+//go:embed migrations/008_session_prefixes.down.sql
+var migration008Down string
+
+// Migration is a versioned schema change with scripts to apply and roll it back
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum returns the SHA-256 of the up script, recorded when the migration is applied
+// so that later edits to an already applied migration can be detected
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// registry lists all migrations in the order they are applied
+// New migrations must be appended with the next version number
+var registry = []Migration{
+	{Version: 1, Name: "initial_schema", Up: migration001Up, Down: migration001Down},
+	{Version: 2, Name: "session_history", Up: migration002Up, Down: migration002Down},
+	{Version: 3, Name: "trash", Up: migration003Up, Down: migration003Down},
+	{Version: 4, Name: "operation_log", Up: migration004Up, Down: migration004Down},
+	{Version: 5, Name: "port_blocks", Up: migration005Up, Down: migration005Down},
+	{Version: 6, Name: "project_tags", Up: migration006Up, Down: migration006Down},
+	{Version: 7, Name: "pinned_sessions", Up: migration007Up, Down: migration007Down},
+	{Version: 8, Name: "session_prefixes", Up: migration008Up, Down: migration008Down},
+}
+
+// Migrations returns all registered migrations in version order
+func Migrations() []Migration {
+	return append([]Migration(nil), registry...)
+}
+
+// LatestVersion returns the version of the newest registered migration
+func LatestVersion() int {
+	return registry[len(registry)-1].Version
+}
+
+// MigrationStatus describes the state of a migration in a database
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // Applied, but the registered up script no longer matches its checksum
+	Unknown   bool // Applied, but not in the registry (e.g. created by a newer sesh)
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
 
 // RunMigrations executes all pending migrations
 func RunMigrations(db *sql.DB) error {
-	// Create schema_migrations table if it doesn't exist
-	// Note: We need to check if it exists first since the migration itself creates it
+	_, err := MigrateTo(db, LatestVersion())
+	return err
+}
+
+// MigrateTo applies or rolls back migrations until the database is at the target version
+// It returns the migrations that were applied (when moving up) or rolled back (when moving down),
+// in the order they ran. Each migration runs in its own transaction.
+func MigrateTo(db *sql.DB, target int) ([]Migration, error) {
+	if target < 0 || target > LatestVersion() {
+		return nil, eris.Errorf("invalid migration version %d (latest is %d)", target, LatestVersion())
+	}
+
+	applied, err := loadAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyChecksums(applied); err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+
+	// Roll back applied migrations above the target, newest first
+	for i := len(registry) - 1; i >= 0; i-- {
+		m := registry[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		ok, err := runMigration(db, m, false)
+		if err != nil {
+			return ran, err
+		}
+		if ok {
+			ran = append(ran, m)
+		}
+	}
+
+	// Apply pending migrations up to the target, oldest first
+	for _, m := range registry {
+		if m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		ok, err := runMigration(db, m, true)
+		if err != nil {
+			return ran, err
+		}
+		if ok {
+			ran = append(ran, m)
+		}
+	}
+
+	return ran, nil
+}
+
+// CurrentVersion returns the highest applied migration version, or 0 for an empty database
+func CurrentVersion(db *sql.DB) (int, error) {
+	applied, err := loadAppliedMigrations(db)
+	if err != nil {
+		return 0, err
+	}
+
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// GetMigrationStatus reports every registered migration, plus any applied migrations
+// that are not in the registry, in version order
+func GetMigrationStatus(db *sql.DB) ([]*MigrationStatus, error) {
+	applied, err := loadAppliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []*MigrationStatus
+	known := make(map[int]bool)
+	for _, m := range registry {
+		known[m.Version] = true
+		status := &MigrationStatus{Version: m.Version, Name: m.Name}
+		if row, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.appliedAt
+			status.Modified = row.checksum != m.Checksum()
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, row := range applied {
+		if known[version] {
+			continue
+		}
+		statuses = append(statuses, &MigrationStatus{
+			Version:   version,
+			Name:      row.name,
+			Applied:   true,
+			AppliedAt: row.appliedAt,
+			Unknown:   true,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// runMigration executes a migration's up or down script and updates schema_migrations
+// It reports false if another process already did so after the caller loaded the applied migrations
+func runMigration(db *sql.DB, m Migration, up bool) (bool, error) {
+	direction := "apply"
+	script := m.Up
+	if !up {
+		direction = "roll back"
+		script = m.Down
+	}
+
+	// Transactions take the write lock immediately (see connectionParams), so this
+	// check can't race with a concurrent sesh process migrating the same database
+	tx, err := db.Begin()
+	if err != nil {
+		return false, eris.Wrapf(err, "failed to begin transaction to %s migration %d", direction, m.Version)
+	}
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", m.Version).Scan(&count)
+	if err != nil {
+		//nolint:errcheck // Rollback in error path
+		tx.Rollback()
+		return false, eris.Wrapf(err, "failed to check migration %d", m.Version)
+	}
+	if (count > 0) == up {
+		return false, tx.Rollback()
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		//nolint:errcheck // Rollback in error path
+		tx.Rollback()
+		return false, eris.Wrapf(err, "failed to %s migration %d (%s)", direction, m.Version, m.Name)
+	}
+
+	if up {
+		_, err = tx.Exec(
+			"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+			m.Version, m.Name, m.Checksum(), time.Now(),
+		)
+	} else {
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+	}
+	if err != nil {
+		//nolint:errcheck // Rollback in error path
+		tx.Rollback()
+		return false, eris.Wrapf(err, "failed to record migration %d", m.Version)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, eris.Wrapf(err, "failed to commit migration %d", m.Version)
+	}
+
+	return true, nil
+}
+
+// loadAppliedMigrations ensures the schema_migrations table exists and returns its rows by version
+func loadAppliedMigrations(db *sql.DB) (map[int]*appliedMigration, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to query schema_migrations")
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	applied := make(map[int]*appliedMigration)
+	for rows.Next() {
+		row := &appliedMigration{}
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &appliedAt); err != nil {
+			return nil, eris.Wrap(err, "failed to scan schema_migrations row")
+		}
+		if appliedAt.Valid {
+			row.appliedAt = appliedAt.Time
+		}
+		applied[row.version] = row
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrap(err, "error iterating schema_migrations rows")
+	}
+
+	return applied, nil
+}
+
+// ensureMigrationsTable creates schema_migrations, upgrading the table from older versions of sesh
+// which only recorded the version. Checksums of those rows are backfilled from the registry.
+func ensureMigrationsTable(db *sql.DB) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			checksum TEXT NOT NULL DEFAULT '',
 			applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
@@ -29,60 +340,73 @@ func RunMigrations(db *sql.DB) error {
 		return eris.Wrap(err, "failed to create schema_migrations table")
 	}
 
-	// Define all migrations
-	migrations := []struct {
-		version int
-		sql     string
-	}{
-		{version: 1, sql: migration001},
-		{version: 2, sql: migration002},
+	columns, err := tableColumns(db, "schema_migrations")
+	if err != nil {
+		return err
 	}
 
-	// Apply each migration if not already applied
-	for _, m := range migrations {
-		applied, err := isMigrationApplied(db, m.version)
-		if err != nil {
-			return eris.Wrapf(err, "failed to check migration %d", m.version)
-		}
-
-		if applied {
+	for _, column := range []string{"name", "checksum"} {
+		if columns[column] {
 			continue
 		}
-
-		// Execute migration in a transaction
-		tx, err := db.Begin()
+		_, err := db.Exec("ALTER TABLE schema_migrations ADD COLUMN " + column + " TEXT NOT NULL DEFAULT ''")
 		if err != nil {
-			return eris.Wrapf(err, "failed to begin transaction for migration %d", m.version)
+			return eris.Wrapf(err, "failed to add %s column to schema_migrations", column)
 		}
+	}
 
-		// Execute the migration SQL
-		if _, err := tx.Exec(m.sql); err != nil {
-			//nolint:errcheck // Rollback in error path
-			tx.Rollback()
-			return eris.Wrapf(err, "failed to execute migration %d", m.version)
+	for _, m := range registry {
+		_, err := db.Exec(
+			"UPDATE schema_migrations SET name = ?, checksum = ? WHERE version = ? AND checksum = ''",
+			m.Name, m.Checksum(), m.Version,
+		)
+		if err != nil {
+			return eris.Wrapf(err, "failed to backfill checksum of migration %d", m.Version)
 		}
+	}
 
-		// Record migration as applied
-		if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", m.version); err != nil {
-			//nolint:errcheck // Rollback in error path
-			tx.Rollback()
-			return eris.Wrapf(err, "failed to record migration %d", m.version)
-		}
+	return nil
+}
 
-		if err := tx.Commit(); err != nil {
-			return eris.Wrapf(err, "failed to commit migration %d", m.version)
+// tableColumns returns the set of column names of a table
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to query columns of %s", table)
+	}
+	//nolint:errcheck // Defer close on rows
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, eris.Wrapf(err, "failed to scan column of %s", table)
 		}
+		columns[name] = true
 	}
 
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, eris.Wrapf(err, "error iterating columns of %s", table)
+	}
+
+	return columns, nil
 }
 
-// isMigrationApplied checks if a migration version has been applied
-func isMigrationApplied(db *sql.DB, version int) (bool, error) {
-	var count int
-	err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE version = ?", version).Scan(&count)
-	if err != nil {
-		return false, eris.Wrap(err, "failed to query schema_migrations")
+// verifyChecksums fails if an applied migration's up script has changed since it was applied
+func verifyChecksums(applied map[int]*appliedMigration) error {
+	for _, m := range registry {
+		row, ok := applied[m.Version]
+		if !ok {
+			continue
+		}
+		if row.checksum != m.Checksum() {
+			return eris.Errorf(
+				"migration %d (%s) has been modified since it was applied (run 'sesh db status' for details)",
+				m.Version,
+				m.Name,
+			)
+		}
 	}
-	return count > 0, nil
+	return nil
 }
@@ -0,0 +1,5 @@
+-- Drop the initial schema in reverse dependency order
+-- schema_migrations is managed by the migration runner and is never dropped
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS worktrees;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,2 @@
+-- Trashed worktree directories are left on disk; only their records are dropped
+DROP TABLE IF EXISTS trash_entries;
@@ -0,0 +1,15 @@
+-- trash_entries table for worktrees moved to the workspace trash instead of being deleted
+-- This enables the "undo" and "trash restore" commands
+CREATE TABLE IF NOT EXISTS trash_entries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    operation_id TEXT NOT NULL,          -- Groups entries removed by the same command
+    project_name TEXT NOT NULL,          -- Project the worktree belonged to
+    branch TEXT NOT NULL,                -- Branch checked out in the worktree
+    commit_sha TEXT NOT NULL,            -- HEAD commit, used to recreate a deleted branch
+    original_path TEXT NOT NULL,         -- Where the worktree lived before removal
+    trash_path TEXT NOT NULL,            -- Where the worktree contents were moved
+    trashed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_trash_entries_trashed_at ON trash_entries(trashed_at DESC);
+CREATE INDEX idx_trash_entries_operation_id ON trash_entries(operation_id);
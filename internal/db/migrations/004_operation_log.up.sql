@@ -0,0 +1,15 @@
+-- operation_log table for auditing mutating operations (clone, worktree/session creation, removal)
+-- This enables the "log" command to answer "what happened to my worktree"
+CREATE TABLE IF NOT EXISTS operation_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    operation TEXT NOT NULL,             -- e.g., "clone", "create-worktree", "remove-worktree"
+    project_name TEXT,                   -- Project the operation applied to
+    branch TEXT,                         -- Branch the operation applied to (if any)
+    args TEXT,                           -- Command line the operation was run from
+    outcome TEXT NOT NULL,               -- "ok" or "failed"
+    error TEXT,                          -- Error message when the operation failed
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_operation_log_created_at ON operation_log(created_at DESC);
+CREATE INDEX idx_operation_log_project_branch ON operation_log(project_name, branch);
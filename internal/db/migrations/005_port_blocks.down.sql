@@ -0,0 +1 @@
+DROP TABLE IF EXISTS port_blocks;
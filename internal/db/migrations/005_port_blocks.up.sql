@@ -0,0 +1,10 @@
+-- port_blocks table for the blocks of ports assigned to worktrees
+-- Each worktree keeps its block across sessions, so dev servers of different worktrees don't collide
+CREATE TABLE IF NOT EXISTS port_blocks (
+    project_name TEXT NOT NULL,
+    branch TEXT NOT NULL,
+    base_port INTEGER NOT NULL UNIQUE,   -- First port of the block, exposed as SESH_PORT
+    size INTEGER NOT NULL,               -- Number of ports in the block
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_name, branch)
+);
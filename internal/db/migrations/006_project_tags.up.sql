@@ -0,0 +1,8 @@
+-- project_tags table for the tags given to projects with 'sesh project tag'
+-- Tags of the config file aren't stored, so editing the config file is enough to change them
+CREATE TABLE IF NOT EXISTS project_tags (
+    project_name TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_name, tag)
+);
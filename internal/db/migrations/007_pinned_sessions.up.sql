@@ -0,0 +1,9 @@
+-- pinned_sessions table for the sessions pinned with 'sesh pin', which are listed first
+-- Pins are kept by project and branch, so they outlive the worktree of a branch
+CREATE TABLE IF NOT EXISTS pinned_sessions (
+    project_name TEXT NOT NULL,
+    branch TEXT NOT NULL,
+    session_name TEXT NOT NULL,
+    pinned_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_name, branch)
+);
@@ -0,0 +1,7 @@
+-- session_prefixes table for the part of session names before the branch, kept once a project
+-- shares its repository name with another, so adding or removing projects never renames sessions
+CREATE TABLE IF NOT EXISTS session_prefixes (
+    project_name TEXT PRIMARY KEY,
+    prefix TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupEmptyDB opens a database connection without running any migrations
+func setupEmptyDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := Connect(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() {
+		//nolint:errcheck // Test cleanup
+		db.Close()
+	})
+
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, table string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query table %s: %v", table, err)
+	}
+	return count == 1
+}
+
+func TestRegistry(t *testing.T) {
+	for i, m := range Migrations() {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d, want %d", i, m.Version, i+1)
+		}
+		if m.Name == "" {
+			t.Errorf("migration %d has no name", m.Version)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d is missing an up or down script", m.Version)
+		}
+	}
+}
+
+func TestMigrateTo(t *testing.T) {
+	db := setupEmptyDB(t)
+
+	ran, err := MigrateTo(db, LatestVersion())
+	if err != nil {
+		t.Fatalf("MigrateTo(latest) failed: %v", err)
+	}
+	if len(ran) != LatestVersion() {
+		t.Errorf("applied %d migrations, want %d", len(ran), LatestVersion())
+	}
+
+	// Roll back to the initial schema
+	ran, err = MigrateTo(db, 1)
+	if err != nil {
+		t.Fatalf("MigrateTo(1) failed: %v", err)
+	}
+	if len(ran) != LatestVersion()-1 || ran[0].Version != LatestVersion() {
+		t.Errorf("rolled back %d migrations starting at %d, want %d starting at %d",
+			len(ran), ran[0].Version, LatestVersion()-1, LatestVersion())
+	}
+
+	version, err := CurrentVersion(db)
+	if err != nil {
+		t.Fatalf("CurrentVersion() failed: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("CurrentVersion() = %d, want 1", version)
+	}
+	if !tableExists(t, db, "projects") {
+		t.Error("projects table should still exist after rolling back to 1")
+	}
+	if tableExists(t, db, "session_history") {
+		t.Error("session_history table should be dropped after rolling back to 1")
+	}
+
+	// Roll back everything, then re-apply
+	if _, err := MigrateTo(db, 0); err != nil {
+		t.Fatalf("MigrateTo(0) failed: %v", err)
+	}
+	if tableExists(t, db, "projects") {
+		t.Error("projects table should be dropped after rolling back to 0")
+	}
+	if !tableExists(t, db, "schema_migrations") {
+		t.Error("schema_migrations table should never be dropped")
+	}
+
+	if err := RunMigrations(db); err != nil {
+		t.Fatalf("RunMigrations() after rollback failed: %v", err)
+	}
+	if !tableExists(t, db, "operation_log") {
+		t.Error("operation_log table should exist after re-applying migrations")
+	}
+
+	// Migrating to the current version is a no-op
+	ran, err = MigrateTo(db, LatestVersion())
+	if err != nil {
+		t.Fatalf("MigrateTo(latest) failed: %v", err)
+	}
+	if len(ran) != 0 {
+		t.Errorf("ran %d migrations on an up-to-date database, want 0", len(ran))
+	}
+}
+
+func TestMigrateTo_InvalidVersion(t *testing.T) {
+	db := setupEmptyDB(t)
+
+	for _, target := range []int{-1, LatestVersion() + 1} {
+		if _, err := MigrateTo(db, target); err == nil {
+			t.Errorf("MigrateTo(%d) should fail", target)
+		}
+	}
+}
+
+func TestMigrations_LegacyTable(t *testing.T) {
+	db := setupEmptyDB(t)
+
+	// Older versions of sesh applied migrations without recording checksums
+	_, err := db.Exec(`CREATE TABLE schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+	if _, err := db.Exec(registry[0].Up); err != nil {
+		t.Fatalf("Failed to apply initial schema: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_migrations (version) VALUES (1)"); err != nil {
+		t.Fatalf("Failed to record legacy migration: %v", err)
+	}
+
+	ran, err := MigrateTo(db, LatestVersion())
+	if err != nil {
+		t.Fatalf("MigrateTo() on legacy database failed: %v", err)
+	}
+	if len(ran) != LatestVersion()-1 {
+		t.Errorf("applied %d migrations, want %d", len(ran), LatestVersion()-1)
+	}
+
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() failed: %v", err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.Modified || status.Unknown {
+			t.Errorf("migration %d: applied=%v modified=%v unknown=%v, want applied only",
+				status.Version, status.Applied, status.Modified, status.Unknown)
+		}
+	}
+	if statuses[0].Name != registry[0].Name {
+		t.Errorf("legacy migration name = %q, want %q", statuses[0].Name, registry[0].Name)
+	}
+}
+
+func TestMigrations_ChecksumMismatch(t *testing.T) {
+	db := setupEmptyDB(t)
+
+	if err := RunMigrations(db); err != nil {
+		t.Fatalf("RunMigrations() failed: %v", err)
+	}
+
+	// Simulate an applied migration whose script has since been edited
+	if _, err := db.Exec("UPDATE schema_migrations SET checksum = 'stale' WHERE version = 2"); err != nil {
+		t.Fatalf("Failed to update checksum: %v", err)
+	}
+
+	if err := RunMigrations(db); err == nil {
+		t.Error("RunMigrations() should fail when an applied migration was modified")
+	}
+
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() failed: %v", err)
+	}
+	for _, status := range statuses {
+		if status.Modified != (status.Version == 2) {
+			t.Errorf("migration %d: modified=%v", status.Version, status.Modified)
+		}
+	}
+}
+
+func TestGetMigrationStatus(t *testing.T) {
+	db := setupEmptyDB(t)
+
+	if _, err := MigrateTo(db, 2); err != nil {
+		t.Fatalf("MigrateTo(2) failed: %v", err)
+	}
+
+	// A migration recorded by a newer version of sesh
+	_, err := db.Exec(
+		"INSERT INTO schema_migrations (version, name, checksum) VALUES (?, 'future', 'abc')",
+		LatestVersion()+1,
+	)
+	if err != nil {
+		t.Fatalf("Failed to record unknown migration: %v", err)
+	}
+
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("GetMigrationStatus() failed: %v", err)
+	}
+	if len(statuses) != LatestVersion()+1 {
+		t.Fatalf("got %d statuses, want %d", len(statuses), LatestVersion()+1)
+	}
+
+	for _, status := range statuses {
+		switch {
+		case status.Version <= 2:
+			if !status.Applied || status.AppliedAt.IsZero() {
+				t.Errorf("migration %d should be applied with a timestamp", status.Version)
+			}
+		case status.Version <= LatestVersion():
+			if status.Applied {
+				t.Errorf("migration %d should be pending", status.Version)
+			}
+		default:
+			if !status.Unknown || status.Name != "future" {
+				t.Errorf("migration %d should be unknown, got %+v", status.Version, status)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/rotisserie/eris"
+)
+
+// Store is the persistence interface used by sesh commands
+// SQLiteStore is the default implementation; tests can substitute a mock
+type Store interface {
+	// Projects
+	CreateProject(project *models.Project) error
+	GetProject(name string) (*models.Project, error)
+	GetProjectByID(id int) (*models.Project, error)
+	GetProjectByRemote(remoteURL string) (*models.Project, error)
+	GetAllProjects() ([]*models.Project, error)
+	UpdateProjectFetchTime(id int) error
+	DeleteProject(id int) error
+
+	// Worktrees
+	CreateWorktree(worktree *models.Worktree) error
+	GetWorktree(projectID int, branch string) (*models.Worktree, error)
+	GetWorktreeByID(id int) (*models.Worktree, error)
+	GetWorktreeByPath(path string) (*models.Worktree, error)
+	GetWorktreesByProject(projectID int) ([]*models.Worktree, error)
+	UpdateWorktreeLastUsed(id int) error
+	DeleteWorktree(id int) error
+
+	// Sessions
+	CreateSession(session *models.Session) error
+	GetSessionByWorktree(worktreeID int) (*models.Session, error)
+	GetSessionByTmuxName(tmuxName string) (*models.Session, error)
+	GetAllSessions() ([]*models.Session, error)
+	GetAllSessionDetails() ([]*models.SessionDetails, error)
+	UpdateSessionLastAttached(id int) error
+	DeleteSession(id int) error
+
+	// Session history
+	AddSessionHistory(sessionName, projectName, branch string) error
+	GetRecentSessionHistory(limit int) ([]*models.SessionHistory, error)
+	GetPreviousSession(currentSessionName string) (*models.SessionHistory, error)
+	ClearOldSessionHistory(daysToKeep int) error
+	DeleteSessionHistoryByProject(projectName string) error
+
+	// Trash
+	AddTrashEntry(entry *models.TrashEntry) error
+	GetTrashEntries() ([]*models.TrashEntry, error)
+	GetTrashEntriesByOperation(operationID string) ([]*models.TrashEntry, error)
+	GetTrashEntriesByProject(projectName string) ([]*models.TrashEntry, error)
+	GetLastTrashOperation() ([]*models.TrashEntry, error)
+	GetTrashEntriesOlderThan(cutoff time.Time) ([]*models.TrashEntry, error)
+	DeleteTrashEntry(id int) error
+
+	// Operation log
+	AddOperationLog(entry *models.OperationLogEntry) error
+	GetOperationLog(projectName, branch string, limit int) ([]*models.OperationLogEntry, error)
+	ClearOldOperationLog(daysToKeep int) error
+
+	// Port blocks
+	AllocatePortBlock(projectName, branch string, start, size int) (int, error)
+	DeletePortBlock(projectName, branch string) error
+	DeletePortBlocksByProject(projectName string) error
+
+	// Project tags
+	AddProjectTag(projectName, tag string) error
+	RemoveProjectTag(projectName, tag string) error
+	GetProjectTags() (map[string][]string, error)
+	DeleteProjectTagsByProject(projectName string) error
+
+	// Pinned sessions
+	PinSession(sessionName, projectName, branch string) error
+	UnpinSession(projectName, branch string) error
+	GetPinnedSessions() ([]*models.PinnedSession, error)
+	DeletePinnedSessionsByProject(projectName string) error
+
+	// Session prefixes
+	SetSessionPrefix(projectName, prefix string) error
+	GetSessionPrefixes() (map[string]string, error)
+	DeleteSessionPrefix(projectName string) error
+
+	// Close releases the underlying resources
+	Close() error
+}
+
+// SQLiteStore implements Store on top of a SQLite database
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore wraps an already initialized database connection
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+// Open initializes the SQLite database at dbPath and returns a store for it
+func Open(dbPath string) (*SQLiteStore, error) {
+	db, err := InitDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQLiteStore(db), nil
+}
+
+// DB returns the underlying database connection
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the underlying database connection
+func (s *SQLiteStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return eris.Wrap(err, "failed to close database")
+	}
+	return nil
+}
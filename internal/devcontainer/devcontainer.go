@@ -0,0 +1,124 @@
+// Package devcontainer runs the sessions of worktrees with a dev container configuration inside
+// their container, with the devcontainer CLI (https://github.com/devcontainers/cli)
+package devcontainer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/rotisserie/eris"
+)
+
+// maxErrorLines is how many of the last lines of the CLI's log go into error messages
+const maxErrorLines = 10
+
+// configPaths are where the devcontainer CLI looks for the configuration of a workspace folder
+var configPaths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// loginShell starts bash when the container has it, and sh otherwise
+const loginShell = "if command -v bash >/dev/null; then exec bash -l; fi; exec sh -l"
+
+// Detect reports whether a worktree has a dev container configuration
+func Detect(worktreePath string) bool {
+	for _, path := range configPaths {
+		if _, err := os.Stat(filepath.Join(worktreePath, path)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCLI checks that the devcontainer CLI is installed
+func CheckCLI() error {
+	if _, err := exec.LookPath("devcontainer"); err != nil {
+		return eris.New(
+			"devcontainer CLI not found. Install it with: npm install -g @devcontainers/cli",
+		)
+	}
+	return nil
+}
+
+// Up builds and starts the dev container of a worktree, or reuses the one already running,
+// passing each line of the CLI's log to report
+func Up(ctx context.Context, worktreePath string, report func(string)) error {
+	cmd := exec.CommandContext(ctx, "devcontainer", "up", "--workspace-folder", worktreePath)
+	var stdout bytes.Buffer
+	log := &logWriter{report: report}
+	cmd.Stdout = &stdout
+	cmd.Stderr = log
+	err := cmd.Run()
+	log.flush()
+
+	// The result is a line of JSON on stdout, also when the container failed to start
+	var result struct {
+		Outcome     string `json:"outcome"`
+		Message     string `json:"message"`
+		Description string `json:"description"`
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if jsonErr := json.Unmarshal([]byte(lines[len(lines)-1]), &result); jsonErr == nil && result.Outcome == "error" {
+		return eris.Errorf("failed to start dev container: %s %s", result.Message, result.Description)
+	}
+	if err != nil {
+		return eris.Wrapf(err, "failed to start dev container: %s", strings.Join(log.last, "\n"))
+	}
+	return nil
+}
+
+// ShellCommand returns the shell command opening a login shell in the dev container of a
+// worktree, for the windows of its session
+func ShellCommand(worktreePath string) string {
+	return ExecCommand(worktreePath, loginShell)
+}
+
+// ExecCommand returns the shell command running command with sh in the dev container of a
+// worktree, in the worktree's folder in the container
+func ExecCommand(worktreePath, command string) string {
+	return "devcontainer exec --workspace-folder " + session.ShellQuote(worktreePath) +
+		" sh -c " + session.ShellQuote(command)
+}
+
+// logWriter passes the lines of the CLI's log to report, and keeps the last ones for error
+// messages
+type logWriter struct {
+	report func(string)
+	line   []byte
+	last   []string
+}
+
+// Write splits the log into lines
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\n' || b == '\r' {
+			w.flush()
+			continue
+		}
+		w.line = append(w.line, b)
+	}
+	return len(p), nil
+}
+
+// flush handles the line written so far
+func (w *logWriter) flush() {
+	line := strings.TrimSpace(string(w.line))
+	w.line = w.line[:0]
+	if line == "" {
+		return
+	}
+	if w.report != nil {
+		w.report(line)
+	}
+	w.last = append(w.last, line)
+	if len(w.last) > maxErrorLines {
+		w.last = w.last[1:]
+	}
+}
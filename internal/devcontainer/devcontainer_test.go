@@ -0,0 +1,67 @@
+package devcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want bool
+	}{
+		{name: "none", want: false},
+		{name: "folder", file: filepath.Join(".devcontainer", "devcontainer.json"), want: true},
+		{name: "file at the root", file: ".devcontainer.json", want: true},
+		{name: "other file", file: filepath.Join(".devcontainer", "Dockerfile"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worktreePath := t.TempDir()
+			if tt.file != "" {
+				path := filepath.Join(worktreePath, tt.file)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := Detect(worktreePath); got != tt.want {
+				t.Errorf("Detect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecCommand(t *testing.T) {
+	got := ExecCommand("/home/user/my repo/main", "npm run dev")
+	want := `devcontainer exec --workspace-folder '/home/user/my repo/main' sh -c 'npm run dev'`
+	if got != want {
+		t.Errorf("ExecCommand() = %q, want %q", got, want)
+	}
+}
+
+func TestLogWriter(t *testing.T) {
+	var reported []string
+	w := &logWriter{report: func(line string) { reported = append(reported, line) }}
+	for i := range maxErrorLines + 2 {
+		_, _ = w.Write([]byte("step " + string(rune('a'+i)) + "\r\n"))
+	}
+	_, _ = w.Write([]byte("last"))
+	w.flush()
+
+	if len(reported) != maxErrorLines+3 {
+		t.Errorf("reported %d lines, want %d", len(reported), maxErrorLines+3)
+	}
+	if len(w.last) != maxErrorLines || w.last[len(w.last)-1] != "last" {
+		t.Errorf("last = %q, want the last %d lines", w.last, maxErrorLines)
+	}
+	if slices.Contains(w.last, "step a") {
+		t.Errorf("last = %q, want the first lines dropped", w.last)
+	}
+}
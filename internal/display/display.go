@@ -52,15 +52,16 @@ type writer struct {
 }
 
 // New creates a new Printer that writes to the given io.Writer.
+// It uses the theme set with SetTheme.
 func New(w io.Writer) Printer {
 	return &writer{
 		out:          w,
-		successColor: color.New(color.FgGreen).SprintFunc(),
-		errorColor:   color.New(color.FgRed).SprintFunc(),
-		warningColor: color.New(color.FgYellow).SprintFunc(),
-		infoColor:    color.New(color.FgCyan).SprintFunc(),
+		successColor: color.New(current.success...).SprintFunc(),
+		errorColor:   color.New(current.errors...).SprintFunc(),
+		warningColor: color.New(current.warning...).SprintFunc(),
+		infoColor:    color.New(current.info...).SprintFunc(),
 		boldStyle:    color.New(color.Bold).SprintFunc(),
-		faintStyle:   color.New(color.Faint).SprintFunc(),
+		faintStyle:   color.New(current.faint...).SprintFunc(),
 	}
 }
 
@@ -91,45 +92,45 @@ func (w *writer) Printf(format string, a ...interface{}) {
 	_, _ = fmt.Fprintf(w.out, format, a...)
 }
 
-// Success prints a success message with a green checkmark icon.
+// Success prints a success message with a checkmark icon.
 func (w *writer) Success(msg string) {
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.successColor("✓"), msg)
 }
 
-// Error prints an error message with a red X icon.
+// Error prints an error message with an X icon.
 func (w *writer) Error(msg string) {
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.errorColor("✗"), msg)
 }
 
-// Warning prints a warning message with a yellow warning icon.
+// Warning prints a warning message with a warning icon.
 func (w *writer) Warning(msg string) {
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.warningColor("⚠"), msg)
 }
 
-// Info prints an info message with a cyan info icon.
+// Info prints an info message with an info icon.
 func (w *writer) Info(msg string) {
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.infoColor("ℹ"), msg)
 }
 
-// Successf prints a formatted success message with a green checkmark icon.
+// Successf prints a formatted success message with a checkmark icon.
 func (w *writer) Successf(format string, a ...interface{}) {
 	msg := fmt.Sprintf(format, a...)
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.successColor("✓"), msg)
 }
 
-// Errorf prints a formatted error message with a red X icon.
+// Errorf prints a formatted error message with an X icon.
 func (w *writer) Errorf(format string, a ...interface{}) {
 	msg := fmt.Sprintf(format, a...)
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.errorColor("✗"), msg)
 }
 
-// Warningf prints a formatted warning message with a yellow warning icon.
+// Warningf prints a formatted warning message with a warning icon.
 func (w *writer) Warningf(format string, a ...interface{}) {
 	msg := fmt.Sprintf(format, a...)
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.warningColor("⚠"), msg)
 }
 
-// Infof prints a formatted info message with a cyan info icon.
+// Infof prints a formatted info message with an info icon.
 func (w *writer) Infof(format string, a ...interface{}) {
 	msg := fmt.Sprintf(format, a...)
 	_, _ = fmt.Fprintf(w.out, "%s %s\n", w.infoColor("ℹ"), msg)
@@ -140,27 +141,27 @@ func (w *writer) Bold(text string) string {
 	return w.boldStyle(text)
 }
 
-// Faint returns the text formatted as faint/dim.
+// Faint returns the text formatted in the faint style (dim by default).
 func (w *writer) Faint(text string) string {
 	return w.faintStyle(text)
 }
 
-// SuccessText returns the text formatted in green (success color).
+// SuccessText returns the text formatted in the success color.
 func (w *writer) SuccessText(text string) string {
 	return w.successColor(text)
 }
 
-// ErrorText returns the text formatted in red (error color).
+// ErrorText returns the text formatted in the error color.
 func (w *writer) ErrorText(text string) string {
 	return w.errorColor(text)
 }
 
-// WarningText returns the text formatted in yellow (warning color).
+// WarningText returns the text formatted in the warning color.
 func (w *writer) WarningText(text string) string {
 	return w.warningColor(text)
 }
 
-// InfoText returns the text formatted in cyan (info color).
+// InfoText returns the text formatted in the info color.
 func (w *writer) InfoText(text string) string {
 	return w.infoColor(text)
 }
@@ -0,0 +1,120 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// spinnerFrames are the frames of the spinner shown while an operation runs
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner moves while there is no new progress
+const spinnerInterval = 100 * time.Millisecond
+
+// Progress shows the progress of a long-running operation, like a clone, on a single line
+// with a spinner. Nothing is shown when the output isn't a terminal, so logs and pipes only
+// get the messages printed around it.
+type Progress struct {
+	out   io.Writer
+	label string
+	width int
+
+	mu     sync.Mutex
+	frame  int
+	status string
+	done   chan struct{}
+	closed bool
+}
+
+// NewProgress starts showing the progress of an operation described by label
+func NewProgress(w io.Writer, label string) *Progress {
+	p := &Progress{out: w, label: label, done: make(chan struct{})}
+
+	file, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(int(file.Fd())) {
+		p.closed = true
+		return p
+	}
+	if width, _, err := term.GetSize(int(file.Fd())); err == nil {
+		p.width = width
+	}
+
+	go p.spin()
+	return p
+}
+
+// Update shows a new progress message, e.g. a line of git's progress output like
+// "Receiving objects:  45% (450/1000), 1.20 MiB | 2.00 MiB/s"
+func (p *Progress) Update(status string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.status = strings.TrimSpace(strings.TrimPrefix(status, "remote:"))
+	p.render()
+}
+
+// Stop clears the progress line, so the result of the operation can be printed in its place
+func (p *Progress) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.done)
+	_, _ = fmt.Fprint(p.out, "\r\033[K")
+}
+
+// spin moves the spinner until the progress is stopped, so waiting for a slow remote
+// doesn't look like a hang
+func (p *Progress) spin() {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			if !p.closed {
+				p.frame = (p.frame + 1) % len(spinnerFrames)
+				p.render()
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// render redraws the progress line, cut to the width of the terminal
+func (p *Progress) render() {
+	frame := color.New(current.info...).Sprint(spinnerFrames[p.frame])
+	// The spinner and the space after it take two columns
+	_, _ = fmt.Fprintf(p.out, "\r\033[K%s %s", frame, formatProgress(p.label, p.status, p.width-2))
+}
+
+// formatProgress returns the text of a progress line, cut to width runes when width is positive
+func formatProgress(label, status string, width int) string {
+	line := label
+	if status != "" {
+		line += " " + status
+	}
+
+	runes := []rune(line)
+	if width > 0 && len(runes) >= width {
+		// The last column is left empty, so the cursor doesn't wrap to a new line
+		runes = runes[:width-1]
+	}
+	return string(runes)
+}
@@ -0,0 +1,41 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatProgress(t *testing.T) {
+	tests := []struct {
+		name   string
+		label  string
+		status string
+		width  int
+		want   string
+	}{
+		{name: "label only", label: "Cloning", want: "Cloning"},
+		{name: "with status", label: "Cloning", status: "Receiving objects:  45%", want: "Cloning Receiving objects:  45%"},
+		{name: "cut to width", label: "Cloning", status: "Receiving objects:  45%", width: 12, want: "Cloning Rec"},
+		{name: "fits width", label: "Cloning", width: 12, want: "Cloning"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatProgress(tt.label, tt.status, tt.width); got != tt.want {
+				t.Errorf("formatProgress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressNotTerminal(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	p := NewProgress(buf, "Cloning")
+	p.Update("Receiving objects:  45% (450/1000)")
+	p.Stop()
+
+	if buf.Len() != 0 {
+		t.Errorf("progress written to a non-terminal = %q, want nothing", buf.String())
+	}
+}
@@ -0,0 +1,160 @@
+package display
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/rotisserie/eris"
+)
+
+// Theme names the styles of the semantic message types
+// Each style is a space-separated list of color names, 256-color numbers, and attributes,
+// e.g. "blue", "bold bright-red", or "244". Empty styles keep the default.
+type Theme struct {
+	Success string
+	Info    string
+	Warning string
+	Error   string
+	Faint   string
+}
+
+// DefaultTheme is the theme used when none is configured
+var DefaultTheme = Theme{
+	Success: "green",
+	Info:    "cyan",
+	Warning: "yellow",
+	Error:   "red",
+	Faint:   "faint",
+}
+
+// styles are the parsed styles of a theme
+type styles struct {
+	success []color.Attribute
+	info    []color.Attribute
+	warning []color.Attribute
+	errors  []color.Attribute
+	faint   []color.Attribute
+}
+
+// current holds the styles new Printers use
+var current = mustParseTheme(DefaultTheme)
+
+var styleColors = map[string]color.Attribute{
+	"black":   color.FgBlack,
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+}
+
+var styleAttributes = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+}
+
+// SetTheme makes Printers created afterwards use the theme
+func SetTheme(theme Theme) error {
+	parsed, err := parseTheme(theme)
+	if err != nil {
+		return err
+	}
+	current = parsed
+	return nil
+}
+
+// SetNoColor disables colors and styles in all Printers
+// Colors are already disabled when NO_COLOR is set or stdout isn't a terminal.
+func SetNoColor(disabled bool) {
+	if disabled {
+		color.NoColor = true
+	}
+}
+
+// ValidateStyle reports whether a style can be used in a theme
+func ValidateStyle(style string) error {
+	_, err := parseStyle(style)
+	return err
+}
+
+// parseTheme parses the styles of a theme, falling back to the default for empty ones
+func parseTheme(theme Theme) (styles, error) {
+	var parsed styles
+	fields := []struct {
+		name  string
+		style string
+		def   string
+		dest  *[]color.Attribute
+	}{
+		{"success", theme.Success, DefaultTheme.Success, &parsed.success},
+		{"info", theme.Info, DefaultTheme.Info, &parsed.info},
+		{"warning", theme.Warning, DefaultTheme.Warning, &parsed.warning},
+		{"error", theme.Error, DefaultTheme.Error, &parsed.errors},
+		{"faint", theme.Faint, DefaultTheme.Faint, &parsed.faint},
+	}
+
+	for _, field := range fields {
+		style := field.style
+		if style == "" {
+			style = field.def
+		}
+		attrs, err := parseStyle(style)
+		if err != nil {
+			return styles{}, eris.Wrapf(err, "invalid %s style", field.name)
+		}
+		*field.dest = attrs
+	}
+
+	return parsed, nil
+}
+
+// mustParseTheme parses a built-in theme
+func mustParseTheme(theme Theme) styles {
+	parsed, err := parseTheme(theme)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// parseStyle converts a style like "bold bright-red" to color attributes
+func parseStyle(style string) ([]color.Attribute, error) {
+	words := strings.Fields(strings.ToLower(style))
+	if len(words) == 0 {
+		return nil, eris.New("style is empty")
+	}
+
+	var attrs []color.Attribute
+	for _, word := range words {
+		if attr, ok := styleAttributes[word]; ok {
+			attrs = append(attrs, attr)
+			continue
+		}
+		if fg, ok := styleColors[word]; ok {
+			attrs = append(attrs, fg)
+			continue
+		}
+		if name, ok := strings.CutPrefix(word, "bright-"); ok {
+			if fg, ok := styleColors[name]; ok {
+				// The bright colors are 60 above the normal ones
+				attrs = append(attrs, fg+60)
+				continue
+			}
+		}
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && n <= 255 {
+			attrs = append(attrs, 38, 5, color.Attribute(n))
+			continue
+		}
+		return nil, eris.Errorf(
+			"unknown color %q (use black, red, green, yellow, blue, magenta, cyan, white, bright-<color>, 0-255, bold, faint, italic, or underline)",
+			word,
+		)
+	}
+
+	return attrs, nil
+}
@@ -0,0 +1,79 @@
+package display
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := []struct {
+		style   string
+		want    []color.Attribute
+		wantErr bool
+	}{
+		{style: "green", want: []color.Attribute{color.FgGreen}},
+		{style: "bold bright-red", want: []color.Attribute{color.Bold, color.FgHiRed}},
+		{style: "Blue Underline", want: []color.Attribute{color.FgBlue, color.Underline}},
+		{style: "244", want: []color.Attribute{38, 5, 244}},
+		{style: "", wantErr: true},
+		{style: "bright-bold", wantErr: true},
+		{style: "256", wantErr: true},
+		{style: "crimson", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			got, err := parseStyle(tt.style)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStyle(%q) error = %v, wantErr %v", tt.style, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseStyle(%q) = %v, want %v", tt.style, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseStyle(%q) = %v, want %v", tt.style, got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSetTheme(t *testing.T) {
+	// Force colors on, since the test output isn't a terminal
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() {
+		color.NoColor = noColor
+		current = mustParseTheme(DefaultTheme)
+	})
+
+	if err := SetTheme(Theme{Info: "crimson"}); err == nil {
+		t.Error("SetTheme() with an unknown color should fail")
+	}
+
+	if err := SetTheme(Theme{Info: "blue"}); err != nil {
+		t.Fatalf("SetTheme() failed: %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	p := New(buf)
+	if got, want := p.InfoText("x"), "\x1b[34mx\x1b[0m"; got != want {
+		t.Errorf("InfoText() = %q, want %q", got, want)
+	}
+	// Styles left empty keep the default
+	if got, want := p.SuccessText("x"), "\x1b[32mx\x1b[0m"; got != want {
+		t.Errorf("SuccessText() = %q, want %q", got, want)
+	}
+
+	SetNoColor(true)
+	if got := New(buf).InfoText("x"); got != "x" {
+		t.Errorf("InfoText() with colors disabled = %q, want %q", got, "x")
+	}
+}
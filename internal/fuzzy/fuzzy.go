@@ -2,13 +2,13 @@ package fuzzy
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/lookpath"
 	"github.com/benoctopus/sesh/internal/tty"
 	"github.com/rotisserie/eris"
 )
@@ -52,19 +52,19 @@ func DetectFuzzyFinder() (Finder, error) {
 	configuredFinder, err := config.GetFuzzyFinder()
 	if err == nil && configuredFinder != "" && configuredFinder != "auto" {
 		// Verify the configured finder is actually available
-		if _, err := exec.LookPath(configuredFinder); err == nil {
+		if _, err := lookpath.Find(configuredFinder); err == nil {
 			return Finder(configuredFinder), nil
 		}
 		// If configured finder not found, fall back to auto-detect
 	}
 
 	// 2. Auto-detect: Check for fzf
-	if _, err := exec.LookPath("fzf"); err == nil {
+	if _, err := lookpath.Find("fzf"); err == nil {
 		return FinderFzf, nil
 	}
 
 	// 3. Auto-detect: Check for peco
-	if _, err := exec.LookPath("peco"); err == nil {
+	if _, err := lookpath.Find("peco"); err == nil {
 		return FinderPeco, nil
 	}
 
@@ -79,10 +79,10 @@ func createFinderCommand(finder string, previewCmd string) (*exec.Cmd, error) {
 		if previewCmd != "" {
 			args = append(args, "--preview", previewCmd)
 		}
-		return exec.Command("fzf", args...), nil
+		return exec.Command(lookpath.Resolve("fzf"), args...), nil
 	case FinderPeco:
 		// Peco doesn't support preview
-		return exec.Command("peco"), nil
+		return exec.Command(lookpath.Resolve("peco")), nil
 	default:
 		return nil, eris.Errorf("unknown fuzzy finder: %s", finder)
 	}
@@ -131,8 +131,19 @@ func MultiSelect(items []string, prompt string) ([]string, error) {
 		return nil, eris.New("no items available to select")
 	}
 
+	reader := io.NopCloser(strings.NewReader(strings.Join(items, "\n")))
+	return MultiSelectFromReaderWithPreview(reader, prompt, "")
+}
+
+// MultiSelectFromReaderWithPreview presents a multi-select fuzzy finder (fzf only) fed from a reader
+// The reader should output one item per line and is closed when the function returns
+// If previewCmd is non-empty it is passed to fzf as the preview command
+func MultiSelectFromReaderWithPreview(reader io.ReadCloser, prompt string, previewCmd string) ([]string, error) {
+	defer reader.Close() //nolint:errcheck
+
 	// Check if fzf is available (peco doesn't support multi-select)
-	if _, err := exec.LookPath("fzf"); err != nil {
+	fzf, err := lookpath.Find("fzf")
+	if err != nil {
 		return nil, eris.New("fzf required for multi-select (install fzf)")
 	}
 
@@ -145,40 +156,28 @@ func MultiSelect(items []string, prompt string) ([]string, error) {
 	if prompt != "" {
 		args = append(args, "--prompt", prompt)
 	}
-
-	cmd := exec.Command("fzf", args...)
-
-	// Create pipe to send items to fuzzy finder
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, eris.Wrap(err, "failed to create stdin pipe")
+	if previewCmd != "" {
+		args = append(args, "--preview", previewCmd)
 	}
 
-	// Capture stdout for the selections
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, eris.Wrap(err, "failed to create stdout pipe")
-	}
+	cmd := exec.Command(fzf, args...)
 
-	// Set stderr to show errors
+	// Pipe the reader directly to fzf's stdin
+	cmd.Stdin = reader
 	cmd.Stderr = os.Stderr
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return nil, eris.Wrap(err, "failed to start fuzzy finder")
-	}
-
-	// Write items to stdin in a goroutine
-	go func() {
-		defer stdin.Close() //nolint:errcheck // Defer close in cleanup
-		for _, item := range items {
-			fmt.Fprintln(stdin, item) //nolint:errcheck // Write errors will be caught by command execution
+	out, err := cmd.Output()
+	if err != nil {
+		// User might have cancelled (Ctrl+C)
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 130 {
+			return nil, eris.New("selection cancelled")
 		}
-	}()
+		return nil, eris.Wrap(err, "fuzzy finder failed")
+	}
 
 	// Read all selected items
 	var selected []string
-	scanner := bufio.NewScanner(stdout)
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line != "" {
@@ -186,15 +185,6 @@ func MultiSelect(items []string, prompt string) ([]string, error) {
 		}
 	}
 
-	// Wait for the command to finish
-	if err := cmd.Wait(); err != nil {
-		// User might have cancelled (Ctrl+C)
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 130 {
-			return nil, eris.New("selection cancelled")
-		}
-		return nil, eris.Wrap(err, "fuzzy finder failed")
-	}
-
 	if len(selected) == 0 {
 		return nil, eris.New("no selection made")
 	}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// sshAuthFailures are signs in git's output of an SSH key that wasn't offered or accepted
+var sshAuthFailures = []string{"permission denied (publickey", "no supported authentication methods"}
+
+// httpsAuthFailures are signs in git's output of HTTPS credentials that are missing or refused
+var httpsAuthFailures = []string{
+	"authentication failed for",
+	"could not read username",
+	"could not read password",
+	"terminal prompts disabled",
+	"invalid username or password",
+	"the requested url returned error: 401",
+	"the requested url returned error: 403",
+}
+
+// explainAuthFailure adds guidance to the error of a git command reaching remoteURL that
+// failed to authenticate: an SSH key the host didn't accept, an unknown host key, or HTTPS
+// credentials that are missing or refused. Other errors are returned as they are.
+func explainAuthFailure(remoteURL string, err error) error {
+	if err == nil {
+		return nil
+	}
+	message := strings.ToLower(err.Error())
+	host := remoteURL
+	if parsed, _, _, parseErr := ParseRemoteURL(remoteURL); parseErr == nil {
+		host = parsed
+	}
+
+	switch {
+	case strings.Contains(message, "remote host identification has changed"):
+		return eris.Wrapf(err, "the SSH host key of %s changed; if that's expected, remove the old one with "+
+			"'ssh-keygen -R %s' and connect again", host, host)
+	case strings.Contains(message, "host key verification failed"):
+		return eris.Wrapf(err, "the SSH host key of %s isn't trusted yet; check and accept it by connecting "+
+			"once with 'ssh -T git@%s'", host, host)
+	case containsAny(message, sshAuthFailures):
+		return eris.Wrapf(err, "SSH authentication to %s failed; load your key into the agent with 'ssh-add', "+
+			"check that the host accepts it with 'ssh -T git@%s', or use an HTTPS remote", host, host)
+	case containsAny(message, httpsAuthFailures):
+		return eris.Wrap(err, httpsAuthGuidance(host, strings.Contains(message, "error: 403")))
+	default:
+		return err
+	}
+}
+
+// httpsAuthGuidance tells how to give git credentials for a host over HTTPS
+func httpsAuthGuidance(host string, refused bool) string {
+	problem := "HTTPS authentication to " + host + " failed"
+	if refused {
+		problem = host + " refused access (HTTP 403): the account or token has no access to the repository, " +
+			"or the token lacks the scope to read it"
+	}
+
+	switch {
+	case host == "github.com":
+		return problem + "; log in with 'gh auth login', and let git use it with 'gh auth setup-git'"
+	case strings.Contains(host, "gitlab"):
+		return problem + "; log in with 'glab auth login', or store a personal access token with a credential helper"
+	default:
+		return problem + "; store a token with a credential helper ('git config --global credential.helper " +
+			"store', or your OS keychain), or use an SSH remote"
+	}
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings []string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExplainAuthFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		remoteURL string
+		output    string
+		want      string
+	}{
+		{
+			name:      "ssh key",
+			remoteURL: "git@github.com:acme/api.git",
+			output:    "git@github.com: Permission denied (publickey).\nfatal: Could not read from remote repository.",
+			want:      "ssh-add",
+		},
+		{
+			name:      "unknown host key",
+			remoteURL: "git@git.example.com:acme/api.git",
+			output:    "Host key verification failed.",
+			want:      "ssh -T git@git.example.com",
+		},
+		{
+			name:      "changed host key",
+			remoteURL: "git@github.com:acme/api.git",
+			output:    "WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!\nHost key verification failed.",
+			want:      "ssh-keygen -R github.com",
+		},
+		{
+			name:      "github https",
+			remoteURL: "https://github.com/acme/api.git",
+			output:    "fatal: could not read Username for 'https://github.com': terminal prompts disabled",
+			want:      "gh auth login",
+		},
+		{
+			name:      "gitlab https refused",
+			remoteURL: "https://gitlab.example.com/acme/api.git",
+			output: "fatal: unable to access 'https://gitlab.example.com/acme/api.git/': " +
+				"The requested URL returned error: 403",
+			want: "refused access (HTTP 403)",
+		},
+		{
+			name:      "other https",
+			remoteURL: "https://git.example.com/acme/api.git",
+			output:    "fatal: Authentication failed for 'https://git.example.com/acme/api.git/'",
+			want:      "credential helper",
+		},
+		{
+			name:      "not an auth failure",
+			remoteURL: "https://github.com/acme/api.git",
+			output:    "fatal: couldn't find remote ref feature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := errors.New("failed to fetch from remote: " + tt.output)
+			err := explainAuthFailure(tt.remoteURL, original)
+			if tt.want == "" {
+				if err != original {
+					t.Errorf("explainAuthFailure() = %v, want the error unchanged", err)
+				}
+				return
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), tt.output) {
+				t.Errorf("explainAuthFailure() = %v, want guidance with %q and the output of git", err, tt.want)
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/rotisserie/eris"
@@ -25,72 +26,30 @@ var branchListSpecialChars = regexp.MustCompile(`[+*]`)
 
 // ListLocalBranches lists all local branches in a repository
 func ListLocalBranches(repoPath string) ([]string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "branch", "--format=%(refname:short)")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, eris.Wrap(err, "failed to list local branches")
-	}
-
-	return parseGitBranchList(string(output)), nil
+	return listLocalBranches(repoPath)
 }
 
 // ListRemoteBranches lists all remote branches in a repository
 // Returns branch names without the "origin/" prefix (e.g., "main" instead of "origin/main")
 // For bare repositories, lists branches from refs/heads/
 func ListRemoteBranches(repoPath string) ([]string, error) {
-	// Try listing branches using for-each-ref which works for both bare and normal repos
-	cmd := exec.Command(
-		"git",
-		"-C",
-		repoPath,
-		"for-each-ref",
-		"--format=%(refname:short)",
-		"refs/heads/",
-	)
-	output, err := cmd.Output()
-	if err != nil {
-		// Fallback to branch -r for normal repos
-		cmd = exec.Command("git", "-C", repoPath, "branch", "-r", "--format=%(refname:short)")
-		output, err = cmd.Output()
-		if err != nil {
-			return nil, eris.Wrap(err, "failed to list remote branches")
-		}
-
-		branches := parseGitBranchList(string(output))
-
-		// Remove "origin/" prefix and filter out HEAD
-		var result []string
-		for _, branch := range branches {
-			if strings.Contains(branch, "HEAD") {
-				continue
-			}
-			// Remove "origin/" prefix
-			if trimmed, ok := strings.CutPrefix(branch, "origin/"); ok {
-				result = append(result, trimmed)
-			} else {
-				result = append(result, branch)
-			}
-		}
-
-		return result, nil
-	}
-
-	// For bare repos, branches are directly under refs/heads/
-	return parseGitBranchList(string(output)), nil
+	return listRemoteBranches(repoPath)
 }
 
 // ListActualRemoteBranches queries the remote server to get the actual list of branches
 // that exist on the remote. This is useful for checking if branches have been deleted remotely.
 // Returns branch names without the "refs/heads/" prefix.
-func ListActualRemoteBranches(repoPath string) ([]string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "ls-remote", "--heads", "origin")
-	output, err := cmd.Output()
+func ListActualRemoteBranches(ctx context.Context, repoPath, remote string) ([]string, error) {
+	out, err := retryRemote(ctx, func() ([]byte, error) {
+		return output(ctx, "-C", repoPath, "ls-remote", "--heads", remote)
+	})
 	if err != nil {
-		return nil, eris.Wrap(err, "failed to list remote branches from origin")
+		urls, _ := ListRemoteURLs(repoPath)
+		return nil, explainAuthFailure(urls[remote], eris.Wrapf(err, "failed to list remote branches from %s", remote))
 	}
 
 	var branches []string
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
@@ -117,10 +76,63 @@ func ListActualRemoteBranches(repoPath string) ([]string, error) {
 	return branches, nil
 }
 
-// StreamRemoteBranches returns a reader that streams branch names and the cleanup function
+// ListMergedBranches lists local branches whose tips are reachable from the given target branch.
+// The remote-tracking ref (<remote>/<target>) is preferred so the check reflects the latest fetch.
+// The target branch itself and branches pointing at the same commit as the target (freshly
+// created branches with no work yet) are excluded from the result.
+func ListMergedBranches(repoPath, remote, target string) ([]string, error) {
+	ref := "refs/heads/" + target
+	if exists, _ := doesRefExist(repoPath, "refs/remotes/"+remote+"/"+target); exists {
+		ref = "refs/remotes/" + remote + "/" + target
+	}
+
+	cmd := exec.Command("git", "-C", repoPath, "rev-parse", ref)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to resolve %s", ref)
+	}
+	targetCommit := strings.TrimSpace(string(output))
+
+	cmd = exec.Command(
+		"git",
+		"-C",
+		repoPath,
+		"for-each-ref",
+		"--format=%(objectname) %(refname:short)",
+		"--merged",
+		ref,
+		"refs/heads/",
+	)
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to list branches merged into %s", target)
+	}
+
+	var merged []string
+	for _, line := range parseGitBranchList(string(output)) {
+		commit, branch, ok := strings.Cut(line, " ")
+		if !ok || branch == target || commit == targetCommit {
+			continue
+		}
+		merged = append(merged, branch)
+	}
+
+	return merged, nil
+}
+
+// IsBranchInCommit reports whether the tip of a local branch is a commit or one of its ancestors,
+// so the branch has no commits the commit doesn't have. It is false when the commit isn't in the
+// repository, like the head of a pull request that was never fetched.
+func IsBranchInCommit(repoPath, branch, commit string) bool {
+	cmd := exec.Command("git", "-C", repoPath, "merge-base", "--is-ancestor", "refs/heads/"+branch, commit)
+	return cmd.Run() == nil
+}
+
+// StreamRemoteBranches returns a reader that streams the first branches, e.g. pinned ones, then
+// the other local branches, then the branches of the given remote that don't exist locally
 // The reader will output one branch name per line as git produces them
 // The caller must call cleanup() when done to ensure the process terminates
-func StreamRemoteBranches(ctx context.Context, repoPath string) (io.ReadCloser, error) {
+func StreamRemoteBranches(ctx context.Context, repoPath, remote string, first []string) (io.ReadCloser, error) {
 	// Use for-each-ref which works for both bare and normal repos
 
 	cmd := exec.CommandContext(
@@ -131,6 +143,7 @@ func StreamRemoteBranches(ctx context.Context, repoPath string) (io.ReadCloser,
 		"ls-remote",
 		"--branches",
 		"--tags",
+		remote,
 	)
 
 	stdout, err := cmd.StdoutPipe()
@@ -156,7 +169,10 @@ func StreamRemoteBranches(ctx context.Context, repoPath string) (io.ReadCloser,
 		}
 
 		localSet := make(map[string]struct{})
-		for _, branch := range local {
+		for _, branch := range append(slices.Clone(first), local...) {
+			if _, exists := localSet[branch]; exists {
+				continue
+			}
 			localSet[branch] = struct{}{}
 			fmt.Fprintln(writer, branch) //nolint:errcheck
 		}
@@ -199,8 +215,8 @@ func StreamRemoteBranches(ctx context.Context, repoPath string) (io.ReadCloser,
 
 			branch := strings.TrimPrefix(strings.TrimSpace(fields[1]), "refs/heads/")
 			if branch != "" && !strings.Contains(branch, "HEAD") {
-				// Remove "origin/" prefix if present
-				if after, ok := strings.CutPrefix(branch, "origin/"); ok {
+				// Remove the remote prefix if present
+				if after, ok := strings.CutPrefix(branch, remote+"/"); ok {
 					branch = after
 				}
 
@@ -295,10 +311,10 @@ func DoesBranchExist(repoPath, branch string) (bool, bool, error) {
 	return false, false, eris.Wrap(err, "failed to check branch existence")
 }
 
-// DoesBranchExistRemotely checks if a branch exists at refs/remotes/origin/<branch>
-// This is useful for bare repositories where remote branches are fetched to refs/remotes/origin/*
-func DoesBranchExistRemotely(repoPath, branch string) (bool, error) {
-	// Check if branch exists at refs/remotes/origin/<branch>
+// DoesBranchExistRemotely checks if a branch exists at refs/remotes/<remote>/<branch>
+// This is useful for bare repositories where remote branches are fetched to refs/remotes/<remote>/*
+func DoesBranchExistRemotely(repoPath, remote, branch string) (bool, error) {
+	// Check if branch exists at refs/remotes/<remote>/<branch>
 	cmd := exec.Command(
 		"git",
 		"-C",
@@ -306,12 +322,12 @@ func DoesBranchExistRemotely(repoPath, branch string) (bool, error) {
 		"show-ref",
 		"--verify",
 		"--quiet",
-		"refs/remotes/origin/"+branch,
+		"refs/remotes/"+remote+"/"+branch,
 	)
 
 	err := cmd.Run()
 	if err == nil {
-		// Branch exists at refs/remotes/origin/<branch>
+		// Branch exists at refs/remotes/<remote>/<branch>
 		return true, nil
 	}
 
@@ -327,6 +343,164 @@ func DoesBranchExistRemotely(repoPath, branch string) (bool, error) {
 	return false, eris.Wrap(err, "failed to check remote branch existence")
 }
 
+// GetBranchRenames returns the branches that were renamed to a branch, e.g. with 'git branch -m',
+// most recent first, from its reflog. ok is false when the branch has no reflog, like when it was
+// only ever updated in a bare repository, where reflogs are off by default, so renames can't be
+// told apart from other changes.
+func GetBranchRenames(repoPath, branch string) (oldBranches []string, ok bool, err error) {
+	ref := "refs/heads/" + branch
+	err = exec.Command("git", "-C", repoPath, "reflog", "exists", ref).Run()
+	if exitErr, isExit := err.(*exec.ExitError); isExit && exitErr.ExitCode() == 1 {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, eris.Wrapf(err, "failed to check reflog of branch %s", branch)
+	}
+
+	output, err := exec.Command("git", "-C", repoPath, "reflog", "show", "--format=%gs", ref, "--").Output()
+	if err != nil {
+		return nil, false, eris.Wrapf(err, "failed to read reflog of branch %s", branch)
+	}
+	// git branch -m logs "Branch: renamed refs/heads/<old> to refs/heads/<new>"
+	for _, line := range strings.Split(string(output), "\n") {
+		rest, found := strings.CutPrefix(line, "Branch: renamed refs/heads/")
+		if !found {
+			continue
+		}
+		if old, _, found := strings.Cut(rest, " to refs/heads/"); found {
+			oldBranches = append(oldBranches, old)
+		}
+	}
+	return oldBranches, true, nil
+}
+
+// CreateBranch creates a local branch pointing at the given start point without checking it out.
+// The branch doesn't track the start point, even when it is a remote branch like origin/main.
+func CreateBranch(repoPath, branch, startPoint string) error {
+	cmd := exec.Command("git", "-C", repoPath, "branch", "--no-track", branch, startPoint)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to create branch %s: %s", branch, string(output))
+	}
+	return nil
+}
+
+// DeleteBranch deletes a local branch from the repository
+// The branch is force-deleted, so callers are expected to have checked for unpushed work first
+func DeleteBranch(repoPath, branch string) error {
+	cmd := exec.Command("git", "-C", repoPath, "branch", "-D", branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete branch %s: %s", branch, string(output))
+	}
+	return nil
+}
+
+// DeleteRemoteBranch deletes a branch on a remote
+// The corresponding remote-tracking ref is removed by git as part of the push
+func DeleteRemoteBranch(ctx context.Context, repoPath, remote, branch string) error {
+	output, err := combinedOutput(ctx, "-C", repoPath, "push", remote, "--delete", branch)
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete remote branch %s: %s", branch, string(output))
+	}
+	return nil
+}
+
+// PushOptions change how a branch is pushed
+type PushOptions struct {
+	// ForceWithLease overwrites the remote branch, but only if it is still where it was last
+	// fetched, so commits pushed by someone else aren't lost
+	ForceWithLease bool
+	// Progress is passed each progress line of git, e.g. "Writing objects:  45% (450/1000)"
+	Progress func(string)
+}
+
+// Push pushes the branch checked out in a worktree to the branch of the same name on a remote,
+// and makes that its upstream, so branches created by sesh can be pushed without -u
+func Push(ctx context.Context, worktreePath, remote string, opts PushOptions) error {
+	args := []string{"-C", worktreePath, "push", "--set-upstream"}
+	if opts.ForceWithLease {
+		args = append(args, "--force-with-lease")
+	}
+	if opts.Progress != nil {
+		args = append(args, "--progress")
+	}
+	output, err := progressOutput(ctx, opts.Progress, append(args, remote, "HEAD")...)
+	if err != nil {
+		return eris.Wrapf(err, "failed to push to %s: %s", remote, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ListRemotes lists the names of the remotes of a repository
+func ListRemotes(repoPath string) ([]string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "remote")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list remotes")
+	}
+	return parseGitBranchList(string(output)), nil
+}
+
+// ListRemoteURLs returns the URL of each remote of a repository, by remote name
+func ListRemoteURLs(repoPath string) (map[string]string, error) {
+	remotes, err := ListRemotes(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	urls := make(map[string]string, len(remotes))
+	for _, remote := range remotes {
+		output, err := exec.Command("git", "-C", repoPath, "remote", "get-url", remote).Output()
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to get the URL of remote %s", remote)
+		}
+		urls[remote] = strings.TrimSpace(string(output))
+	}
+	return urls, nil
+}
+
+// AddRemote adds a remote to a repository, with remote-tracking branches like those of origin
+// in sesh's bare repositories. Its branches are fetched with the next fetch.
+func AddRemote(repoPath, name, url string) error {
+	cmd := exec.Command("git", "-C", repoPath, "remote", "add", name, url)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to add remote %s: %s", name, string(output))
+	}
+	return nil
+}
+
+// SplitRemoteBranch splits a name like "upstream/main" into a remote of the repository and a
+// branch of it that has been fetched. ok is false when the name doesn't start with a remote,
+// or the remote has no such branch.
+func SplitRemoteBranch(repoPath, name string) (remote, branch string, ok bool) {
+	remotes, err := ListRemotes(repoPath)
+	if err != nil {
+		return "", "", false
+	}
+	for _, r := range remotes {
+		b, found := strings.CutPrefix(name, r+"/")
+		if !found || b == "" {
+			continue
+		}
+		if exists, err := DoesBranchExistRemotely(repoPath, r, b); err == nil && exists {
+			return r, b, true
+		}
+	}
+	return "", "", false
+}
+
+// CreateTrackingBranch creates a local branch from the branch of the same name on a remote,
+// with the remote branch as its upstream
+func CreateTrackingBranch(repoPath, remote, branch string) error {
+	cmd := exec.Command("git", "-C", repoPath, "branch", "--track", branch, remote+"/"+branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to create branch %s from %s/%s: %s", branch, remote, branch, string(output))
+	}
+	return nil
+}
+
 // GetCurrentBranch retrieves the current branch name in a git repository
 func GetCurrentBranch(repoPath string) (string, error) {
 	cmd := exec.Command("git", "-C", repoPath, "branch", "--show-current")
@@ -341,6 +515,24 @@ func GetCurrentBranch(repoPath string) (string, error) {
 	return branch, nil
 }
 
+// CheckBranchName checks that a name can be used for a new branch, e.g. that it has no spaces
+func CheckBranchName(branch string) error {
+	if err := exec.Command("git", "check-ref-format", "--branch", branch).Run(); err != nil {
+		return eris.Errorf("invalid branch name: %s", branch)
+	}
+	return nil
+}
+
+// GetUserEmail returns the email address commits of the repository are made with, or ""
+// when none is configured
+func GetUserEmail(repoPath string) string {
+	output, err := exec.Command("git", "-C", repoPath, "config", "--get", "user.email").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
 // parseGitBranchList parses the output of git branch commands
 func parseGitBranchList(output string) []string {
 	var branches []string
@@ -1,6 +1,9 @@
 package git
 
 import (
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -73,3 +76,158 @@ func TestParseBranchInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestIsBranchInCommit(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "sesh")
+	t.Setenv("GIT_AUTHOR_EMAIL", "sesh@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "sesh")
+	t.Setenv("GIT_COMMITTER_EMAIL", "sesh@example.com")
+
+	repo := filepath.Join(t.TempDir(), "repo")
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	if err := exec.Command("git", "init", "--quiet", "--initial-branch", "main", repo).Run(); err != nil {
+		t.Fatalf("git init failed: %v", err)
+	}
+	run("commit", "--quiet", "--allow-empty", "-m", "initial")
+	for _, branch := range []string{"merged", "unmerged"} {
+		run("switch", "--quiet", "-c", branch, "main")
+		run("commit", "--quiet", "--allow-empty", "-m", branch)
+	}
+	run("switch", "--quiet", "main")
+	run("merge", "--quiet", "--no-ff", "-m", "merge", "merged")
+	head := run("rev-parse", "HEAD")
+
+	tests := []struct {
+		branch string
+		commit string
+		want   bool
+	}{
+		{branch: "merged", commit: head, want: true},
+		{branch: "main", commit: head, want: true},
+		{branch: "unmerged", commit: head, want: false},
+		{branch: "missing", commit: head, want: false},
+		{branch: "merged", commit: "0123456789abcdef0123456789abcdef01234567", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := IsBranchInCommit(repo, tt.branch, tt.commit); got != tt.want {
+				t.Errorf("IsBranchInCommit(%s, %s) = %v, want %v", tt.branch, tt.commit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRemoteBranch(t *testing.T) {
+	clone := setupDivergedClone(t)
+	upstream := filepath.Join(t.TempDir(), "upstream")
+	runGit(t, "init", "--quiet", "--initial-branch", "develop", upstream)
+	runGit(t, "-C", upstream, "commit", "--quiet", "--allow-empty", "-m", "upstream")
+	runGit(t, "-C", clone, "remote", "add", "upstream", upstream)
+	runGit(t, "-C", clone, "fetch", "--quiet", "upstream")
+
+	tests := []struct {
+		name       string
+		wantRemote string
+		wantBranch string
+		wantOK     bool
+	}{
+		{name: "upstream/develop", wantRemote: "upstream", wantBranch: "develop", wantOK: true},
+		{name: "origin/main", wantRemote: "origin", wantBranch: "main", wantOK: true},
+		{name: "upstream/main"},
+		{name: "feature/login"},
+		{name: "upstream/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, branch, ok := SplitRemoteBranch(clone, tt.name)
+			if remote != tt.wantRemote || branch != tt.wantBranch || ok != tt.wantOK {
+				t.Errorf("SplitRemoteBranch(%q) = %q, %q, %v, want %q, %q, %v",
+					tt.name, remote, branch, ok, tt.wantRemote, tt.wantBranch, tt.wantOK)
+			}
+		})
+	}
+
+	urls, err := ListRemoteURLs(clone)
+	if err != nil || urls["upstream"] != upstream || len(urls) != 2 {
+		t.Errorf("ListRemoteURLs() = %v, %v, want origin and upstream", urls, err)
+	}
+
+	if err := CreateTrackingBranch(clone, "upstream", "develop"); err != nil {
+		t.Fatalf("CreateTrackingBranch() returned error: %v", err)
+	}
+	if got := GetUpstreamRemote(clone, "develop"); got != "upstream" {
+		t.Errorf("GetUpstreamRemote() = %q, want upstream", got)
+	}
+}
+
+func TestPush(t *testing.T) {
+	bare, linked := setupWorktreeRepo(t, 1, 1)
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	runGit(t, "init", "--quiet", "--bare", remote)
+	runGit(t, "-C", bare, "remote", "add", "mirror", remote)
+
+	worktree := linked[0]
+	runGit(t, "-C", worktree, "commit", "--quiet", "--allow-empty", "-m", "work")
+	if err := Push(t.Context(), worktree, "mirror", PushOptions{}); err != nil {
+		t.Fatalf("Push() returned error: %v", err)
+	}
+	if got := GetUpstreamRemote(worktree, "feature-000"); got != "mirror" {
+		t.Errorf("GetUpstreamRemote() after Push() = %q, want mirror", got)
+	}
+
+	// Rewriting the pushed commit needs a force push
+	runGit(t, "-C", worktree, "commit", "--quiet", "--amend", "--allow-empty", "-m", "reworked")
+	if err := Push(t.Context(), worktree, "mirror", PushOptions{}); err == nil {
+		t.Error("Push() of a rewritten branch returned no error")
+	}
+	if err := Push(t.Context(), worktree, "mirror", PushOptions{ForceWithLease: true}); err != nil {
+		t.Errorf("Push() with ForceWithLease returned error: %v", err)
+	}
+}
+
+func TestCreateBranch(t *testing.T) {
+	clone := setupDivergedClone(t)
+
+	if err := CreateBranch(clone, "feature", "origin/main"); err != nil {
+		t.Fatalf("CreateBranch() returned error: %v", err)
+	}
+
+	revParse := func(rev string) string {
+		out, err := exec.Command("git", "-C", clone, "rev-parse", rev).Output()
+		if err != nil {
+			t.Fatalf("git rev-parse %s failed: %v", rev, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if got, want := revParse("feature"), revParse("origin/main"); got != want {
+		t.Errorf("feature is at %s, want origin/main at %s", got, want)
+	}
+
+	// The new branch doesn't track the default branch, so pushing it doesn't push to the default branch
+	if got := GetUpstreamRemote(clone, "feature"); got != "" {
+		t.Errorf("GetUpstreamRemote() = %q, want no upstream", got)
+	}
+
+	// Branches recreated by sesh undo and sesh verify start at a commit
+	commit := revParse("main~1")
+	if err := CreateBranch(clone, "restored", commit); err != nil {
+		t.Fatalf("CreateBranch() at a commit returned error: %v", err)
+	}
+	if got := revParse("restored"); got != commit {
+		t.Errorf("restored is at %s, want %s", got, commit)
+	}
+
+	if err := CreateBranch(clone, "feature", "origin/main"); err == nil {
+		t.Error("CreateBranch() of an existing branch returned no error")
+	}
+}
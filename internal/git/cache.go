@@ -0,0 +1,70 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/atomicfile"
+	"github.com/rotisserie/eris"
+)
+
+// RemoteBranchCacheMaxAge is how long cached remote branches are used before they are listed again
+const RemoteBranchCacheMaxAge = 10 * time.Minute
+
+// remoteBranchCache is the on-disk form of a repository's cached remote branches
+type remoteBranchCache struct {
+	RepoPath  string    `json:"repo_path"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Branches  []string  `json:"branches"`
+}
+
+// RemoteBranchCachePath returns the file the remote branches of a repository are cached in
+func RemoteBranchCachePath(cacheDir, repoPath string) string {
+	sum := sha256.Sum256([]byte(repoPath))
+	return filepath.Join(cacheDir, "branches", hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadCachedRemoteBranches returns the cached remote branches of a repository, and whether
+// the cache is younger than maxAge. Stale branches are still returned, so callers that can't
+// wait for the remote can use them while the cache is refreshed.
+func LoadCachedRemoteBranches(cacheDir, repoPath string, maxAge time.Duration) ([]string, bool) {
+	data, err := os.ReadFile(RemoteBranchCachePath(cacheDir, repoPath))
+	if err != nil {
+		return nil, false
+	}
+
+	var cache remoteBranchCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.RepoPath != repoPath {
+		return nil, false
+	}
+
+	return cache.Branches, time.Since(cache.UpdatedAt) <= maxAge
+}
+
+// SaveCachedRemoteBranches caches the remote branches of a repository
+func SaveCachedRemoteBranches(cacheDir, repoPath string, branches []string) error {
+	path := RemoteBranchCachePath(cacheDir, repoPath)
+
+	data, err := json.Marshal(remoteBranchCache{
+		RepoPath:  repoPath,
+		UpdatedAt: time.Now(),
+		Branches:  branches,
+	})
+	if err != nil {
+		return eris.Wrap(err, "failed to encode remote branch cache")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create cache directory: %s", filepath.Dir(path))
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0o644); err != nil {
+		return eris.Wrap(err, "failed to write remote branch cache")
+	}
+
+	return nil
+}
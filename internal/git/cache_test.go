@@ -0,0 +1,45 @@
+package git
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRemoteBranchCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	repoPath := "/workspace/github.com/user/repo.git"
+
+	if got, fresh := LoadCachedRemoteBranches(cacheDir, repoPath, RemoteBranchCacheMaxAge); got != nil || fresh {
+		t.Fatalf("LoadCachedRemoteBranches() on an empty cache = %v, %v", got, fresh)
+	}
+
+	branches := []string{"main", "feature/foo"}
+	if err := SaveCachedRemoteBranches(cacheDir, repoPath, branches); err != nil {
+		t.Fatalf("SaveCachedRemoteBranches() failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		repoPath     string
+		maxAge       time.Duration
+		wantBranches []string
+		wantFresh    bool
+	}{
+		{name: "fresh", repoPath: repoPath, maxAge: RemoteBranchCacheMaxAge, wantBranches: branches, wantFresh: true},
+		{name: "stale branches are still returned", repoPath: repoPath, maxAge: -time.Second, wantBranches: branches},
+		{name: "other repository", repoPath: "/workspace/github.com/user/other.git", maxAge: RemoteBranchCacheMaxAge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fresh := LoadCachedRemoteBranches(cacheDir, tt.repoPath, tt.maxAge)
+			if fresh != tt.wantFresh {
+				t.Errorf("LoadCachedRemoteBranches() fresh = %v, want %v", fresh, tt.wantFresh)
+			}
+			if !slices.Equal(got, tt.wantBranches) {
+				t.Errorf("LoadCachedRemoteBranches() = %v, want %v", got, tt.wantBranches)
+			}
+		})
+	}
+}
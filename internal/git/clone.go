@@ -1,42 +1,133 @@
 package git
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/rotisserie/eris"
 )
 
+// CloneOptions make a clone shallow or partial, for repositories too large to clone in full,
+// or reuse the objects of a local clone
+type CloneOptions struct {
+	// Depth limits the history to the last Depth commits of each branch; 0 clones all of it
+	Depth int
+	// Filter leaves out objects until they are needed, e.g. "blob:none" for file contents
+	Filter string
+	// Reference is an existing clone of the same repository whose objects are reused instead
+	// of being downloaded again
+	Reference string
+	// Dissociate copies the objects of Reference into the clone, so it keeps working when
+	// Reference is deleted; otherwise they are borrowed through objects/info/alternates
+	Dissociate bool
+	// Progress is passed each progress line of git, e.g. "Receiving objects:  45% (450/1000)"
+	Progress func(string)
+}
+
+// args returns the git arguments of the options
+func (o CloneOptions) args() []string {
+	var args []string
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.Filter != "" {
+		args = append(args, "--filter", o.Filter)
+	}
+	if o.Reference != "" {
+		args = append(args, "--reference", o.Reference)
+		if o.Dissociate {
+			args = append(args, "--dissociate")
+		}
+	}
+	return args
+}
+
 // Clone clones a git repository as a bare repository to the specified destination path
-func Clone(remoteURL, destPath string) error {
-	cmd := exec.Command("git", "clone", "--bare", remoteURL, destPath)
-	output, err := cmd.CombinedOutput()
+func Clone(ctx context.Context, remoteURL, destPath string, opts CloneOptions) error {
+	args := append([]string{"clone", "--bare"}, opts.args()...)
+	if opts.Progress != nil {
+		args = append(args, "--progress")
+	}
+	output, err := retryRemote(ctx, func() ([]byte, error) {
+		return progressOutput(ctx, opts.Progress, append(args, remoteURL, destPath)...)
+	})
 	if err != nil {
-		return eris.Wrapf(err, "failed to clone repository: %s", string(output))
+		return explainAuthFailure(remoteURL, eris.Wrapf(err, "failed to clone repository: %s", string(output)))
 	}
 
 	// Configure the bare repo to create remote-tracking branches (refs/remotes/origin/*)
 	// This is necessary for git status to show ahead/behind tracking information in worktrees
 	// By default, bare repos don't have a fetch refspec configured
-	cmd = exec.Command("git", "-C", destPath, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
-	output, err = cmd.CombinedOutput()
+	output, err = combinedOutput(ctx, "-C", destPath, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
 	if err != nil {
 		return eris.Wrapf(err, "failed to configure remote fetch: %s", string(output))
 	}
 
 	// Fetch to populate the remote-tracking branches
-	cmd = exec.Command("git", "-C", destPath, "fetch", "origin")
-	output, err = cmd.CombinedOutput()
+	// A shallow clone only has the default branch, so the others are fetched with the same depth.
+	// The filter of a partial clone is already saved in the repository config.
+	fetchArgs := []string{"-C", destPath, "fetch", "origin"}
+	if opts.Depth > 0 {
+		fetchArgs = append(fetchArgs, "--depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Progress != nil {
+		fetchArgs = append(fetchArgs, "--progress")
+	}
+	output, err = retryRemote(ctx, func() ([]byte, error) {
+		return progressOutput(ctx, opts.Progress, fetchArgs...)
+	})
 	if err != nil {
-		return eris.Wrapf(err, "failed to fetch remote branches: %s", string(output))
+		return explainAuthFailure(remoteURL, eris.Wrapf(err, "failed to fetch remote branches: %s", string(output)))
 	}
 
 	return nil
 }
 
+// IsShallow reports whether a repository was cloned with a limited history depth
+func IsShallow(repoPath string) bool {
+	output, err := exec.Command("git", "-C", repoPath, "rev-parse", "--is-shallow-repository").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// IsPartialClone reports whether a repository was cloned with a filter, so objects are
+// downloaded from origin when they are first needed
+func IsPartialClone(repoPath string) bool {
+	output, err := exec.Command("git", "-C", repoPath, "config", "--bool", "remote.origin.promisor").Output()
+	return err == nil && strings.TrimSpace(string(output)) == "true"
+}
+
+// explainMissingObjects adds a hint to worktree errors caused by objects a shallow or partial
+// clone doesn't have, which git fails to download when origin can't be reached
+func explainMissingObjects(repoPath string, err error) error {
+	message := err.Error()
+	missing := false
+	for _, sign := range []string{"promisor remote", "unable to read", "missing blob", "bad object", "not a valid object"} {
+		if strings.Contains(message, sign) {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return err
+	}
+
+	switch {
+	case IsPartialClone(repoPath):
+		return eris.Wrap(err, "the repository is a partial clone and the objects of this worktree couldn't be "+
+			"downloaded; check that origin can be reached and try again")
+	case IsShallow(repoPath):
+		return eris.Wrapf(err, "the repository is a shallow clone without the commits this worktree needs; "+
+			"fetch more history with 'git -C %s fetch --deepen <n>' or '--unshallow'", repoPath)
+	default:
+		return err
+	}
+}
+
 // GetRemoteURL retrieves the remote URL from a git repository
 func GetRemoteURL(repoPath string) (string, error) {
 	cmd := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin")
@@ -149,12 +240,37 @@ func IsGitURL(str string) bool {
 	return err == nil
 }
 
-// Fetch fetches the latest changes from the remote repository
-func Fetch(repoPath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "fetch", "origin")
-	output, err := cmd.CombinedOutput()
+// fetchPrune is whether Fetch removes remote-tracking branches deleted on the remote
+var fetchPrune = true
+
+// SetFetchPrune sets whether Fetch prunes remote-tracking branches deleted on the remote, so
+// branch listings stop offering them
+func SetFetchPrune(prune bool) {
+	fetchPrune = prune
+}
+
+// Fetch fetches the latest changes from all remotes of the repository, so branches of remotes
+// other than origin, like a fork's upstream, can be checked out too
+func Fetch(ctx context.Context, repoPath string) error {
+	return FetchProgress(ctx, repoPath, nil)
+}
+
+// FetchProgress fetches like Fetch, passing each progress line of git to report
+func FetchProgress(ctx context.Context, repoPath string, report func(string)) error {
+	args := []string{"-C", repoPath, "fetch", "--all"}
+	if fetchPrune {
+		args = append(args, "--prune")
+	}
+	if report != nil {
+		args = append(args, "--progress")
+	}
+	output, err := retryRemote(ctx, func() ([]byte, error) {
+		return progressOutput(ctx, report, args...)
+	})
 	if err != nil {
-		return eris.Wrapf(err, "failed to fetch from remote: %s", string(output))
+		// Remotes other than origin may have failed too, but origin is the one that usually matters
+		remoteURL, _ := GetRemoteURL(repoPath)
+		return explainAuthFailure(remoteURL, eris.Wrapf(err, "failed to fetch from remote: %s", string(output)))
 	}
 	return nil
 }
@@ -1,6 +1,9 @@
 package git
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -257,3 +260,143 @@ func TestParseGitBranchList(t *testing.T) {
 		})
 	}
 }
+
+func TestCloneShallowAndPartial(t *testing.T) {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	runGit(t, "init", "--quiet", "--initial-branch", "main", src)
+	// Partial clones need the server to allow filters
+	runGit(t, "-C", src, "config", "uploadpack.allowFilter", "true")
+	for _, message := range []string{"one", "two", "three"} {
+		runGit(t, "-C", src, "commit", "--quiet", "--allow-empty", "-m", message)
+	}
+	runGit(t, "-C", src, "branch", "feature")
+	// Depth is ignored for local paths, so the remote is a file:// URL
+	remote := "file://" + filepath.ToSlash(src)
+
+	tests := []struct {
+		name        string
+		opts        CloneOptions
+		wantShallow bool
+		wantPartial bool
+	}{
+		{name: "full", opts: CloneOptions{}},
+		{name: "shallow", opts: CloneOptions{Depth: 1}, wantShallow: true},
+		{name: "partial", opts: CloneOptions{Filter: "blob:none"}, wantPartial: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "repo.git")
+			if err := Clone(t.Context(), remote, dest, tt.opts); err != nil {
+				t.Fatalf("Clone() returned error: %v", err)
+			}
+
+			if shallow := IsShallow(dest); shallow != tt.wantShallow {
+				t.Errorf("IsShallow() = %v, want %v", shallow, tt.wantShallow)
+			}
+			if partial := IsPartialClone(dest); partial != tt.wantPartial {
+				t.Errorf("IsPartialClone() = %v, want %v", partial, tt.wantPartial)
+			}
+
+			// Every branch is fetched, even into a shallow clone
+			out, err := exec.Command("git", "-C", dest, "rev-parse", "--verify", "--quiet", "origin/feature").Output()
+			if err != nil || len(out) == 0 {
+				t.Errorf("origin/feature is missing from the clone: %v", err)
+			}
+		})
+	}
+}
+
+func TestCloneReference(t *testing.T) {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	runGit(t, "init", "--quiet", "--initial-branch", "main", src)
+	runGit(t, "-C", src, "commit", "--quiet", "--allow-empty", "-m", "one")
+	reference := filepath.Join(dir, "reference")
+	runGit(t, "clone", "--quiet", src, reference)
+	remote := "file://" + filepath.ToSlash(src)
+
+	tests := []struct {
+		name           string
+		dissociate     bool
+		wantAlternates bool
+	}{
+		{name: "borrowed objects", wantAlternates: true},
+		{name: "dissociated", dissociate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "repo.git")
+			opts := CloneOptions{Reference: reference, Dissociate: tt.dissociate}
+			if err := Clone(t.Context(), remote, dest, opts); err != nil {
+				t.Fatalf("Clone() returned error: %v", err)
+			}
+
+			_, err := os.Stat(filepath.Join(dest, "objects", "info", "alternates"))
+			if hasAlternates := err == nil; hasAlternates != tt.wantAlternates {
+				t.Errorf("clone has alternates = %v, want %v", hasAlternates, tt.wantAlternates)
+			}
+		})
+	}
+}
+
+func TestFetchPrune(t *testing.T) {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+	t.Cleanup(func() { SetFetchPrune(true) })
+
+	tests := []struct {
+		name       string
+		prune      bool
+		wantBranch bool
+	}{
+		{name: "pruned", prune: true, wantBranch: false},
+		{name: "kept", prune: false, wantBranch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			runGit(t, "init", "--quiet", "--initial-branch", "main", src)
+			runGit(t, "-C", src, "commit", "--quiet", "--allow-empty", "-m", "one")
+			runGit(t, "-C", src, "branch", "feature")
+
+			repo := filepath.Join(dir, "repo.git")
+			if err := Clone(t.Context(), "file://"+filepath.ToSlash(src), repo, CloneOptions{}); err != nil {
+				t.Fatalf("Clone() returned error: %v", err)
+			}
+			runGit(t, "-C", src, "branch", "--quiet", "-D", "feature")
+
+			SetFetchPrune(tt.prune)
+			if err := Fetch(t.Context(), repo); err != nil {
+				t.Fatalf("Fetch() returned error: %v", err)
+			}
+
+			exists, _ := doesRefExist(repo, "refs/remotes/origin/feature")
+			if exists != tt.wantBranch {
+				t.Errorf("origin/feature exists after Fetch() = %v, want %v", exists, tt.wantBranch)
+			}
+		})
+	}
+}
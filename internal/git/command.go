@@ -0,0 +1,217 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// timeout is the longest a git command run with a context may take, e.g. a fetch over an SSH
+// connection that hangs. A value of 0 means there is no limit.
+var timeout time.Duration
+
+// waitDelay is how long a killed git command's output is read for, since the ssh it started
+// can keep it open
+const waitDelay = time.Second
+
+// SetTimeout limits how long git commands that can reach the remote, or check out a worktree,
+// may run, or removes the limit when d is 0
+func SetTimeout(d time.Duration) {
+	timeout = d
+}
+
+// timeoutKey is the context key of the timeout set with WithTimeout
+type timeoutKey struct{}
+
+// WithTimeout returns a context whose git commands are limited to d instead of the timeout set
+// with SetTimeout, or aren't limited when d is 0. Tools embedding sesh use it to apply their own
+// git_timeout without changing the limit of the whole process.
+func WithTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, d)
+}
+
+// commandTimeout returns the limit of the git commands run with ctx
+func commandTimeout(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+		return d
+	}
+	return timeout
+}
+
+// retries is how many times a git command reaching the remote is retried after a transient
+// network failure
+var retries int
+
+// retryDelay is how long the first retry waits, doubled for each one after it
+var retryDelay = time.Second
+
+// SetRetries sets how many times clones, fetches, and remote branch listings are retried after
+// a transient network failure, or turns retries off when n is 0
+func SetRetries(n int) {
+	retries = n
+}
+
+// transientFailures are signs in git's output of network failures that may pass, unlike
+// authentication failures or missing repositories
+var transientFailures = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"connection reset",
+	"connection timed out",
+	"operation timed out",
+	"network is unreachable",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"rpc failed",
+	"gnutls_handshake() failed",
+	"ssl_error_syscall",
+	"kex_exchange_identification",
+	"the requested url returned error: 502",
+	"the requested url returned error: 503",
+	"the requested url returned error: 504",
+}
+
+// transientFailure returns the sign of a network error worth retrying in the output and error
+// of a failed git command, or "" when it failed for another reason
+func transientFailure(out []byte, err error) string {
+	message := string(out) + err.Error()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		message += string(exitErr.Stderr)
+	}
+	message = strings.ToLower(message)
+	for _, sign := range transientFailures {
+		if strings.Contains(message, sign) {
+			return sign
+		}
+	}
+	return ""
+}
+
+// retryRemote runs a git command reaching the remote, retrying it with exponential backoff
+// while it fails with a transient network error. The last failure says how often it was tried.
+func retryRemote(ctx context.Context, runGit func() ([]byte, error)) ([]byte, error) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		out, err := runGit()
+		if err == nil {
+			return out, nil
+		}
+		failure := transientFailure(out, err)
+		if failure == "" {
+			return out, err
+		}
+		if attempt > retries || ctx.Err() != nil {
+			if attempt > 1 {
+				err = eris.Wrapf(err, "network error persisted after %d attempts", attempt)
+			}
+			return out, err
+		}
+
+		slog.Warn("git hit a network error, retrying", "error", failure, "attempt", attempt, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return out, err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// combinedOutput runs git and returns its standard output and standard error. It is killed when
+// ctx is done or the timeout passes.
+func combinedOutput(ctx context.Context, args ...string) ([]byte, error) {
+	return run(ctx, (*exec.Cmd).CombinedOutput, args)
+}
+
+// output runs git and returns its standard output. It is killed when ctx is done or the
+// timeout passes.
+func output(ctx context.Context, args ...string) ([]byte, error) {
+	return run(ctx, (*exec.Cmd).Output, args)
+}
+
+// progressOutput runs git like combinedOutput, but passes the progress lines git prints with
+// --progress to report instead of returning them. Without report, it is combinedOutput.
+func progressOutput(ctx context.Context, report func(string), args ...string) ([]byte, error) {
+	if report == nil {
+		return combinedOutput(ctx, args...)
+	}
+	return run(ctx, func(cmd *exec.Cmd) ([]byte, error) {
+		w := &progressWriter{report: report}
+		cmd.Stdout = w
+		cmd.Stderr = w
+		err := cmd.Run()
+		w.flush()
+		return w.output.Bytes(), err
+	}, args)
+}
+
+// progressLine matches the progress lines of git, e.g. "Receiving objects:  45% (450/1000)"
+// or "remote: Counting objects: 12, done."
+var progressLine = regexp.MustCompile(`^(remote: )?[A-Z][A-Za-z ]+: +\d+`)
+
+// progressWriter passes the progress lines of git's output to report, and keeps the other
+// lines for error messages. Progress lines end in \r while they are being updated.
+type progressWriter struct {
+	report func(string)
+	line   []byte
+	output bytes.Buffer
+}
+
+// Write splits the output into lines
+func (w *progressWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b == '\r' || b == '\n' {
+			w.flush()
+			continue
+		}
+		w.line = append(w.line, b)
+	}
+	return len(p), nil
+}
+
+// flush handles the line written so far
+func (w *progressWriter) flush() {
+	line := string(w.line)
+	w.line = w.line[:0]
+	switch {
+	case line == "":
+	case progressLine.MatchString(line):
+		w.report(line)
+	default:
+		w.output.WriteString(line + "\n")
+	}
+}
+
+// run runs git with the given method of exec.Cmd, explaining why it was killed
+func run(ctx context.Context, method func(*exec.Cmd) ([]byte, error), args []string) ([]byte, error) {
+	timeout := commandTimeout(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.WaitDelay = waitDelay
+	out, err := method(cmd)
+	if err == nil {
+		return out, nil
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) && timeout > 0:
+		return out, eris.Errorf("git timed out after %s (raise git_timeout if the remote is just slow)", timeout)
+	case ctx.Err() != nil:
+		return out, eris.Wrap(ctx.Err(), "git was interrupted")
+	default:
+		return out, err
+	}
+}
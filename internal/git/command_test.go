@@ -0,0 +1,146 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	t.Cleanup(func() { SetTimeout(0) })
+
+	canceled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		timeout time.Duration
+		wantErr string
+	}{
+		{name: "timed out", ctx: t.Context(), timeout: 100 * time.Millisecond, wantErr: "timed out after 100ms"},
+		{name: "canceled", ctx: canceled, wantErr: "interrupted"},
+		{
+			name:    "timed out by the context",
+			ctx:     WithTimeout(t.Context(), 100*time.Millisecond),
+			timeout: time.Hour,
+			wantErr: "timed out after 100ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTimeout(tt.timeout)
+
+			// A shell alias stands in for a fetch over a hung connection
+			_, err := combinedOutput(tt.ctx, "-c", "alias.hang=!sleep 5", "hang")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("combinedOutput() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	var reported []string
+	w := &progressWriter{report: func(line string) { reported = append(reported, line) }}
+
+	output := "Cloning into bare repository 'repo.git'...\n" +
+		"remote: Counting objects: 50% (1/2)\rremote: Counting objects: 100% (2/2), done.\n" +
+		"Receiving objects:  45% (450/1000)\rReceiving objects: 100% (1000/1000), done.\n" +
+		"fatal: the remote end hung up unexpectedly\n"
+	// Git writes in pieces that don't line up with lines
+	for _, chunk := range []string{output[:30], output[30:90], output[90:]} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() returned error: %v", err)
+		}
+	}
+	w.flush()
+
+	wantReported := []string{
+		"remote: Counting objects: 50% (1/2)",
+		"remote: Counting objects: 100% (2/2), done.",
+		"Receiving objects:  45% (450/1000)",
+		"Receiving objects: 100% (1000/1000), done.",
+	}
+	if !slices.Equal(reported, wantReported) {
+		t.Errorf("reported lines = %q, want %q", reported, wantReported)
+	}
+
+	wantOutput := "Cloning into bare repository 'repo.git'...\nfatal: the remote end hung up unexpectedly\n"
+	if got := w.output.String(); got != wantOutput {
+		t.Errorf("kept output = %q, want %q", got, wantOutput)
+	}
+}
+
+func TestRetryRemote(t *testing.T) {
+	t.Cleanup(func() { SetRetries(0) })
+	retryDelay = time.Millisecond
+	t.Cleanup(func() { retryDelay = time.Second })
+
+	blip := errors.New("exit status 128")
+	tests := []struct {
+		name         string
+		retries      int
+		outputs      []string
+		wantAttempts int
+		wantErr      string
+	}{
+		{name: "success", retries: 2, outputs: []string{""}, wantAttempts: 1},
+		{
+			name:         "recovers from a blip",
+			retries:      2,
+			outputs:      []string{"fatal: unable to access: Could not resolve host: github.com", ""},
+			wantAttempts: 2,
+		},
+		{
+			name:         "gives up",
+			retries:      2,
+			outputs:      []string{"error: RPC failed; curl 56 Connection reset by peer"},
+			wantAttempts: 3,
+			wantErr:      "after 3 attempts",
+		},
+		{
+			name:         "permanent failure",
+			retries:      2,
+			outputs:      []string{"fatal: Authentication failed"},
+			wantAttempts: 1,
+			wantErr:      "exit status 128",
+		},
+		{
+			name:         "retries off",
+			retries:      0,
+			outputs:      []string{"fatal: the remote end hung up unexpectedly"},
+			wantAttempts: 1,
+			wantErr:      "exit status 128",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRetries(tt.retries)
+
+			attempts := 0
+			_, err := retryRemote(t.Context(), func() ([]byte, error) {
+				out := tt.outputs[min(attempts, len(tt.outputs)-1)]
+				attempts++
+				if out == "" {
+					return nil, nil
+				}
+				return []byte(out), blip
+			})
+			if attempts != tt.wantAttempts {
+				t.Errorf("retryRemote() ran %d times, want %d", attempts, tt.wantAttempts)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("retryRemote() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("retryRemote() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
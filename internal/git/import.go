@@ -0,0 +1,115 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// IsWorkingClone reports whether path is the top of a regular clone, with a .git directory
+// Linked worktrees and submodules, whose .git is a file, are not.
+func IsWorkingClone(path string) bool {
+	info, err := os.Stat(filepath.Join(path, ".git"))
+	return err == nil && info.IsDir()
+}
+
+// ConvertToBare moves the .git directory of a regular clone to bareRepoPath and makes it a
+// bare repository like the ones sesh clones. The files of the clone are left where they are;
+// AttachWorktree makes them a worktree again. Linked worktrees of the clone are repaired to
+// point at the new location.
+func ConvertToBare(ctx context.Context, clonePath, bareRepoPath string) error {
+	linked, err := ListWorktrees(ctx, clonePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(bareRepoPath), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create directory: %s", filepath.Dir(bareRepoPath))
+	}
+	if err := os.Rename(filepath.Join(clonePath, ".git"), bareRepoPath); err != nil {
+		return eris.Wrapf(err, "failed to move the git directory of %s", clonePath)
+	}
+
+	cmd := exec.Command("git", "-C", bareRepoPath, "config", "core.bare", "true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to make repository bare: %s", string(output))
+	}
+
+	// Clones made by git already have the refspec sesh sets up in Clone, but not all clones do
+	if err := exec.Command("git", "-C", bareRepoPath, "config", "--get", "remote.origin.fetch").Run(); err != nil {
+		cmd = exec.Command("git", "-C", bareRepoPath, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*")
+		output, err = cmd.CombinedOutput()
+		if err != nil {
+			return eris.Wrapf(err, "failed to configure remote fetch: %s", string(output))
+		}
+	}
+
+	// HEAD of a bare repository is the default branch, not the branch that was checked out
+	output, err = exec.Command("git", "-C", bareRepoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD").Output()
+	if err == nil {
+		defaultBranch := strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/")
+		if exists, _ := doesRefExist(bareRepoPath, "refs/heads/"+defaultBranch); exists {
+			cmd = exec.Command("git", "-C", bareRepoPath, "symbolic-ref", "HEAD", "refs/heads/"+defaultBranch)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return eris.Wrapf(err, "failed to set default branch: %s", string(output))
+			}
+		}
+	}
+
+	// The first entry is the clone itself
+	if len(linked) > 1 {
+		args := []string{"-C", bareRepoPath, "worktree", "repair"}
+		for _, wt := range linked[1:] {
+			args = append(args, wt.Path)
+		}
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			return eris.Wrapf(err, "failed to repair worktrees: %s", string(output))
+		}
+	}
+
+	return nil
+}
+
+// AttachWorktree makes a directory holding a checkout of branch, without a .git, a worktree of
+// a bare repository. Its files are kept as they are. The index left in the bare repository by
+// ConvertToBare becomes the index of the worktree, so staged changes stay staged.
+func AttachWorktree(bareRepoPath, branch, dir string) error {
+	tmpDir, err := os.MkdirTemp("", "sesh-attach-")
+	if err != nil {
+		return eris.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tmpDir) //nolint:errcheck // Best-effort cleanup
+
+	// The worktree is created elsewhere with the same name, since git refuses to add one to a
+	// directory that isn't empty, and its .git file is moved over
+	tmpWorktree := filepath.Join(tmpDir, filepath.Base(dir))
+	if err := CreateWorktreeNoCheckout(bareRepoPath, branch, tmpWorktree); err != nil {
+		return err
+	}
+	gitFile, err := os.ReadFile(filepath.Join(tmpWorktree, ".git"))
+	if err != nil {
+		return eris.Wrap(err, "failed to read worktree .git file")
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".git"), gitFile, 0o644); err != nil {
+		return eris.Wrap(err, "failed to write worktree .git file")
+	}
+
+	cmd := exec.Command("git", "-C", bareRepoPath, "worktree", "repair", dir)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to repair worktree: %s", string(output))
+	}
+
+	adminDir := strings.TrimPrefix(strings.TrimSpace(string(gitFile)), "gitdir: ")
+	index := filepath.Join(bareRepoPath, "index")
+	if err := os.Rename(index, filepath.Join(adminDir, "index")); err != nil {
+		// Without the old index, it is rebuilt from HEAD and staged changes become unstaged
+		return ResetIndex(dir)
+	}
+	return nil
+}
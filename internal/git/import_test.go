@@ -0,0 +1,81 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConvertToBareAndAttachWorktree(t *testing.T) {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	dir := t.TempDir()
+	origin := filepath.Join(dir, "origin")
+	runGit(t, "init", "--quiet", "--initial-branch", "main", origin)
+	runGit(t, "-C", origin, "commit", "--quiet", "--allow-empty", "-m", "one")
+
+	// A clone on a feature branch with a staged file, an untracked file, and a linked worktree
+	clone := filepath.Join(dir, "clone")
+	runGit(t, "clone", "--quiet", origin, clone)
+	runGit(t, "-C", clone, "checkout", "--quiet", "-b", "feature")
+	for _, name := range []string{"staged", "untracked"} {
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, "-C", clone, "add", "staged")
+	linked := filepath.Join(dir, "linked")
+	runGit(t, "-C", clone, "worktree", "add", "--quiet", "-b", "other", linked)
+
+	if !IsWorkingClone(clone) {
+		t.Fatal("IsWorkingClone() = false, want true")
+	}
+	if IsWorkingClone(linked) {
+		t.Error("IsWorkingClone() of a linked worktree = true, want false")
+	}
+
+	bare := filepath.Join(dir, "workspace", "repo.git")
+	if err := ConvertToBare(t.Context(), clone, bare); err != nil {
+		t.Fatalf("ConvertToBare() returned error: %v", err)
+	}
+	if err := AttachWorktree(bare, "feature", clone); err != nil {
+		t.Fatalf("AttachWorktree() returned error: %v", err)
+	}
+
+	// The default branch is HEAD of the bare repository, not the branch that was checked out
+	if branch, err := GetDefaultBranch(bare); err != nil || branch != "main" {
+		t.Errorf("GetDefaultBranch() = %q, %v; want %q", branch, err, "main")
+	}
+
+	status, err := exec.Command("git", "-C", clone, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatalf("git status failed: %v", err)
+	}
+	if want := "A  staged\n?? untracked\n"; string(status) != want {
+		t.Errorf("status after attaching = %q, want %q", status, want)
+	}
+
+	worktrees, err := ListWorktrees(t.Context(), bare)
+	if err != nil {
+		t.Fatalf("ListWorktrees() returned error: %v", err)
+	}
+	var branches []string
+	for _, wt := range worktrees[1:] {
+		branches = append(branches, wt.Branch)
+	}
+	slices.Sort(branches)
+	if got := strings.Join(branches, ","); got != "feature,other" {
+		t.Errorf("worktree branches = %q, want %q", got, "feature,other")
+	}
+	if err := exec.Command("git", "-C", linked, "status").Run(); err != nil {
+		t.Errorf("linked worktree is broken after converting: %v", err)
+	}
+}
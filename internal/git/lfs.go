@@ -0,0 +1,64 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// UsesLFS reports whether the checkout of a worktree stores files in Git LFS, according to
+// its top-level .gitattributes
+func UsesLFS(worktreePath string) bool {
+	file, err := os.Open(filepath.Join(worktreePath, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, attr := range strings.Fields(line) {
+			if attr == "filter=lfs" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsLFSInstalled reports whether the git-lfs command is available
+func IsLFSInstalled() bool {
+	_, err := exec.LookPath("git-lfs")
+	return err == nil
+}
+
+// IsLFSConfigured reports whether git replaces LFS pointers with file contents on checkout in
+// a repository, because 'git lfs install' was run globally or in the repository
+func IsLFSConfigured(repoPath string) bool {
+	output, err := exec.Command("git", "-C", repoPath, "config", "--get", "filter.lfs.smudge").Output()
+	return err == nil && strings.TrimSpace(string(output)) != ""
+}
+
+// PullLFS sets up Git LFS in the repository of a worktree and downloads the contents of the
+// files checked out as pointers
+func PullLFS(ctx context.Context, worktreePath string) error {
+	output, err := combinedOutput(ctx, "-C", worktreePath, "lfs", "install", "--local")
+	if err != nil {
+		return eris.Wrapf(err, "failed to install Git LFS: %s", string(output))
+	}
+
+	output, err = combinedOutput(ctx, "-C", worktreePath, "lfs", "pull")
+	if err != nil {
+		return eris.Wrapf(err, "failed to pull Git LFS files: %s", string(output))
+	}
+	return nil
+}
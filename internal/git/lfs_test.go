@@ -0,0 +1,34 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsesLFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes string
+		want       bool
+	}{
+		{name: "no attributes"},
+		{name: "LFS patterns", attributes: "*.psd filter=lfs diff=lfs merge=lfs -text\n", want: true},
+		{name: "other attributes", attributes: "*.go text eol=lf\n*.png binary\n"},
+		{name: "commented out", attributes: "# *.psd filter=lfs diff=lfs merge=lfs -text\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.attributes != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".gitattributes"), []byte(tt.attributes), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := UsesLFS(dir); got != tt.want {
+				t.Errorf("UsesLFS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// RecycleWorktree detaches the HEAD of a worktree, freeing its branch, and moves the worktree to
+// poolPath, where ReuseWorktree can check out another branch in it later. The worktree is expected
+// to have no uncommitted changes.
+func RecycleWorktree(repoPath, worktreePath, poolPath string) error {
+	if err := os.MkdirAll(filepath.Dir(poolPath), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create pool directory: %s", filepath.Dir(poolPath))
+	}
+	// Worktrees with submodules can't be moved, so the worktree is only detached once it's moved
+	if err := MoveWorktree(repoPath, worktreePath, poolPath); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("git", "-C", poolPath, "checkout", "--quiet", "--detach")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if moveErr := MoveWorktree(repoPath, poolPath, worktreePath); moveErr != nil {
+			return eris.Wrapf(moveErr, "failed to move worktree back after: %s", string(output))
+		}
+		return eris.Wrapf(err, "failed to detach worktree: %s", string(output))
+	}
+	return nil
+}
+
+// ReuseWorktree moves a recycled worktree from poolPath to worktreePath and checks out a branch
+// in it, like CreateWorktreeForBranch would have created it from the given source. Only the files
+// that differ are written, and ignored files left by its last branch, like installed
+// dependencies, are kept. The worktree goes back to the pool when the branch can't be checked out.
+func ReuseWorktree(
+	ctx context.Context,
+	repoPath, remote, branch, poolPath, worktreePath string,
+	source BranchSource,
+) error {
+	if err := os.MkdirAll(filepath.Dir(worktreePath), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create worktree directory: %s", filepath.Dir(worktreePath))
+	}
+	if err := MoveWorktree(repoPath, poolPath, worktreePath); err != nil {
+		return err
+	}
+
+	err := checkoutBranch(ctx, repoPath, remote, branch, worktreePath, source)
+	if err != nil {
+		if moveErr := MoveWorktree(repoPath, worktreePath, poolPath); moveErr != nil {
+			return eris.Wrapf(moveErr, "failed to return worktree to the pool after: %v", err)
+		}
+		return err
+	}
+	return trackBranch(worktreePath, remote, branch, source)
+}
+
+// checkoutBranch checks out a branch in a worktree that has the files of another checkout, from
+// the given source: new branches start from HEAD of the repository, like CreateWorktreeForBranch
+// creates them
+func checkoutBranch(
+	ctx context.Context,
+	repoPath, remote, branch, worktreePath string,
+	source BranchSource,
+) error {
+	args := []string{"checkout", "--quiet", branch}
+	switch source {
+	case BranchRemote:
+		args = []string{"checkout", "--quiet", "-b", branch, remote + "/" + branch}
+	case BranchNew:
+		out, err := output(ctx, "-C", repoPath, "rev-parse", "HEAD")
+		if err != nil {
+			return eris.Wrap(err, "failed to resolve HEAD")
+		}
+		args = []string{"checkout", "--quiet", "-b", branch, strings.TrimSpace(string(out))}
+	}
+
+	if out, err := inReusedWorktree(ctx, worktreePath, args...); err != nil {
+		return eris.Wrapf(err, "failed to check out %s: %s", branch, string(out))
+	}
+	return nil
+}
+
+// trackBranch makes <remote>/<branch> the upstream of a branch checked out by checkoutBranch, like
+// CreateWorktreeForBranch does
+func trackBranch(worktreePath, remote, branch string, source BranchSource) error {
+	// A branch created from another remote, like upstream/main, keeps tracking it
+	if source == BranchLocal && HasUpstream(worktreePath, branch) {
+		return nil
+	}
+	return SetUpstream(worktreePath, remote, branch)
+}
+
+// inReusedWorktree runs git in a worktree whose files came from another checkout, trusting their
+// modification time and size: reflinked clones keep those but not their inode, so the files
+// aren't all read again to be compared
+func inReusedWorktree(ctx context.Context, worktreePath string, args ...string) ([]byte, error) {
+	return combinedOutput(ctx, append([]string{"-c", "core.checkStat=minimal", "-C", worktreePath}, args...)...)
+}
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecycleAndReuseWorktree(t *testing.T) {
+	bare, linked := setupWorktreeRepo(t, 2, 1)
+	dir := filepath.Dir(bare)
+	runGit(t, "-C", bare, "config", "core.excludesFile", filepath.Join(dir, "exclude"))
+	if err := os.WriteFile(filepath.Join(dir, "exclude"), []byte("deps/\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(linked[0], "deps"), 0o755); err != nil {
+		t.Fatalf("MkdirAll() failed: %v", err)
+	}
+
+	poolPath := filepath.Join(dir, ".pool", "repo", "1")
+	if err := RecycleWorktree(bare, linked[0], poolPath); err != nil {
+		t.Fatalf("RecycleWorktree() failed: %v", err)
+	}
+	if _, err := os.Stat(linked[0]); !os.IsNotExist(err) {
+		t.Errorf("recycled worktree still at %s", linked[0])
+	}
+	// The branch of a recycled worktree is free to be checked out elsewhere
+	if branch, err := GetWorktreeBranch(poolPath); err != nil || branch != "(detached)" {
+		t.Errorf("GetWorktreeBranch() of recycled worktree = %q, %v; want detached", branch, err)
+	}
+
+	tests := []struct {
+		branch string
+		source BranchSource
+	}{
+		{branch: "feature-001", source: BranchLocal},
+		{branch: "fresh", source: BranchNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			path := filepath.Join(dir, "worktrees", tt.branch)
+			if err := ReuseWorktree(t.Context(), bare, "origin", tt.branch, poolPath, path, tt.source); err != nil {
+				t.Fatalf("ReuseWorktree() failed: %v", err)
+			}
+
+			if branch, err := GetWorktreeBranch(path); err != nil || branch != tt.branch {
+				t.Errorf("GetWorktreeBranch() = %q, %v; want %q", branch, err, tt.branch)
+			}
+			if _, err := os.Stat(filepath.Join(path, "deps")); err != nil {
+				t.Errorf("ignored files of recycled worktree are gone: %v", err)
+			}
+			if !HasUpstream(path, tt.branch) {
+				t.Errorf("branch %s has no upstream", tt.branch)
+			}
+
+			if err := RecycleWorktree(bare, path, poolPath); err != nil {
+				t.Fatalf("RecycleWorktree() failed: %v", err)
+			}
+		})
+	}
+
+	// A branch that can't be checked out leaves the worktree in the pool
+	path := filepath.Join(dir, "worktrees", "missing")
+	if err := ReuseWorktree(t.Context(), bare, "origin", "missing", poolPath, path, BranchLocal); err == nil {
+		t.Fatal("ReuseWorktree() of a missing branch succeeded")
+	}
+	if _, err := os.Stat(poolPath); err != nil {
+		t.Errorf("worktree didn't go back to the pool: %v", err)
+	}
+}
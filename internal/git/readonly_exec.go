@@ -0,0 +1,88 @@
+//go:build !gogit
+
+package git
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// The read-only operations that run often, like listing branches and worktrees, run git by
+// default. Building with the gogit tag reads the repository with go-git instead.
+
+// listLocalBranches lists the local branches of a repository with git branch
+func listLocalBranches(repoPath string) ([]string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "branch", "--format=%(refname:short)")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list local branches")
+	}
+
+	return parseGitBranchList(string(output)), nil
+}
+
+// listRemoteBranches lists the branches of a repository with git for-each-ref
+func listRemoteBranches(repoPath string) ([]string, error) {
+	// Try listing branches using for-each-ref which works for both bare and normal repos
+	cmd := exec.Command(
+		"git",
+		"-C",
+		repoPath,
+		"for-each-ref",
+		"--format=%(refname:short)",
+		"refs/heads/",
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		// Fallback to branch -r for normal repos
+		cmd = exec.Command("git", "-C", repoPath, "branch", "-r", "--format=%(refname:short)")
+		output, err = cmd.Output()
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to list remote branches")
+		}
+
+		branches := parseGitBranchList(string(output))
+
+		// Remove "origin/" prefix and filter out HEAD
+		var result []string
+		for _, branch := range branches {
+			if strings.Contains(branch, "HEAD") {
+				continue
+			}
+			// Remove "origin/" prefix
+			if trimmed, ok := strings.CutPrefix(branch, "origin/"); ok {
+				result = append(result, trimmed)
+			} else {
+				result = append(result, branch)
+			}
+		}
+
+		return result, nil
+	}
+
+	// For bare repos, branches are directly under refs/heads/
+	return parseGitBranchList(string(output)), nil
+}
+
+// listWorktrees lists the worktrees of a repository with git worktree list
+func listWorktrees(ctx context.Context, repoPath string) ([]WorktreeInfo, error) {
+	out, err := output(ctx, "-C", repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list worktrees")
+	}
+
+	return parseWorktreeList(string(out))
+}
+
+// uncommittedFiles returns the lines of git status --porcelain for a worktree
+func uncommittedFiles(worktreePath string) ([]string, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "status", "--porcelain")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to get status of worktree: %s", worktreePath)
+	}
+	return parseGitBranchList(string(output)), nil
+}
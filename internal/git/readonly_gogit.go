@@ -0,0 +1,215 @@
+//go:build gogit
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/rotisserie/eris"
+)
+
+// The read-only operations that run often, like listing branches and worktrees, read the
+// repository with go-git in this build, instead of starting a git process for each of them.
+
+// openOptions read the refs of a linked worktree from its main repository
+var openOptions = &gogit.PlainOpenOptions{EnableDotGitCommonDir: true}
+
+// listLocalBranches lists the local branches of a repository with go-git
+func listLocalBranches(repoPath string) ([]string, error) {
+	branches, err := branchNames(repoPath)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list local branches")
+	}
+	return branches, nil
+}
+
+// listRemoteBranches lists the branches of a repository with go-git. Like git for-each-ref
+// refs/heads/, these are the branches of a bare clone, which mirror those of the remote.
+func listRemoteBranches(repoPath string) ([]string, error) {
+	branches, err := branchNames(repoPath)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list remote branches")
+	}
+	return branches, nil
+}
+
+// branchNames returns the names of the branches under refs/heads/, sorted like git sorts them
+func branchNames(repoPath string) ([]string, error) {
+	repo, err := gogit.PlainOpenWithOptions(repoPath, openOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		branches = append(branches, ref.Name().Short())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(branches)
+	return branches, nil
+}
+
+// listWorktrees lists the worktrees of a repository from the administrative files git keeps
+// for them, in the order of git worktree list: the main worktree, then the others by path
+func listWorktrees(ctx context.Context, repoPath string) ([]WorktreeInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, eris.Wrap(err, "failed to list worktrees")
+	}
+
+	commonDir, mainPath, err := repositoryDirs(repoPath)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list worktrees")
+	}
+	repo, err := gogit.PlainOpen(commonDir)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list worktrees")
+	}
+
+	// A bare repository is listed without a branch, like git lists it
+	main := WorktreeInfo{Path: commonDir}
+	if mainPath != "" {
+		if main, err = readWorktreeHead(repo, mainPath, commonDir); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(commonDir, "worktrees"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, eris.Wrap(err, "failed to list worktrees")
+	}
+	var linked []WorktreeInfo
+	for _, entry := range entries {
+		adminDir := filepath.Join(commonDir, "worktrees", entry.Name())
+		gitdir, err := os.ReadFile(filepath.Join(adminDir, "gitdir"))
+		if err != nil {
+			// Not a worktree, or one git is still creating
+			continue
+		}
+		// gitdir holds the path of the worktree's .git file
+		path := filepath.Dir(strings.TrimSpace(string(gitdir)))
+
+		wt, err := readWorktreeHead(repo, path, adminDir)
+		if err != nil {
+			return nil, err
+		}
+		linked = append(linked, wt)
+	}
+	slices.SortFunc(linked, func(a, b WorktreeInfo) int { return strings.Compare(a.Path, b.Path) })
+
+	return append([]WorktreeInfo{main}, linked...), nil
+}
+
+// repositoryDirs returns the git directory holding the refs and worktrees of the repository at
+// repoPath, and the path of its main worktree, or "" when the repository is bare
+func repositoryDirs(repoPath string) (commonDir, mainPath string, err error) {
+	repoPath, err = filepath.Abs(repoPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	dotGit := filepath.Join(repoPath, ".git")
+	info, err := os.Stat(dotGit)
+	switch {
+	case os.IsNotExist(err):
+		return repoPath, "", nil
+	case err != nil:
+		return "", "", err
+	case info.IsDir():
+		return dotGit, repoPath, nil
+	}
+
+	// A linked worktree has a .git file pointing to its directory in the main repository,
+	// which names the main repository in its commondir file
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", "", err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return "", "", eris.Errorf("invalid .git file: %s", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repoPath, gitDir)
+	}
+	content, err = os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return "", "", err
+	}
+	commonDir = strings.TrimSpace(string(content))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	commonDir = filepath.Clean(commonDir)
+
+	if filepath.Base(commonDir) != ".git" {
+		return commonDir, "", nil
+	}
+	return commonDir, filepath.Dir(commonDir), nil
+}
+
+// readWorktreeHead reads the branch and commit checked out in a worktree from the HEAD file in
+// its git directory
+func readWorktreeHead(repo *gogit.Repository, path, gitDir string) (WorktreeInfo, error) {
+	content, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return WorktreeInfo{}, eris.Wrapf(err, "failed to read HEAD of worktree: %s", path)
+	}
+	head := strings.TrimSpace(string(content))
+
+	target, ok := strings.CutPrefix(head, "ref: ")
+	if !ok {
+		return WorktreeInfo{Path: path, Branch: "(detached)", Commit: head}, nil
+	}
+
+	wt := WorktreeInfo{Path: path, Commit: plumbing.ZeroHash.String()}
+	if branch, ok := strings.CutPrefix(target, "refs/heads/"); ok {
+		wt.Branch = branch
+	}
+	// The branch of a new worktree has no commit until its first one is made
+	if ref, err := repo.Reference(plumbing.ReferenceName(target), true); err == nil {
+		wt.Commit = ref.Hash().String()
+	}
+	return wt, nil
+}
+
+// uncommittedFiles returns the status of a worktree in the format of git status --porcelain
+func uncommittedFiles(worktreePath string) ([]string, error) {
+	repo, err := gogit.PlainOpenWithOptions(worktreePath, openOptions)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to get status of worktree: %s", worktreePath)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to get status of worktree: %s", worktreePath)
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to get status of worktree: %s", worktreePath)
+	}
+
+	var lines []string
+	for _, path := range slices.Sorted(maps.Keys(status)) {
+		file := status[path]
+		if file.Staging == gogit.Unmodified && file.Worktree == gogit.Unmodified {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("%c%c %s", file.Staging, file.Worktree, path)))
+	}
+	return lines, nil
+}
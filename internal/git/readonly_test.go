@@ -0,0 +1,123 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setupWorktreeRepo creates a bare clone like sesh makes, with the given number of extra
+// branches and a linked worktree for each of the first worktrees of them
+func setupWorktreeRepo(tb testing.TB, branches, worktrees int) (bare string, linked []string) {
+	tb.Helper()
+
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		tb.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		tb.Setenv(key, "sesh@example.com")
+	}
+
+	dir := tb.TempDir()
+	src := filepath.Join(dir, "src")
+	bare = filepath.Join(dir, "repo.git")
+
+	runGit(tb, "init", "--quiet", "--initial-branch", "main", src)
+	runGit(tb, "-C", src, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	for i := range branches {
+		runGit(tb, "-C", src, "branch", fmt.Sprintf("feature-%03d", i))
+	}
+	runGit(tb, "clone", "--quiet", "--bare", src, bare)
+
+	for i := range worktrees {
+		path := filepath.Join(dir, "worktrees", fmt.Sprintf("feature-%03d", i))
+		runGit(tb, "-C", bare, "worktree", "add", "--quiet", path, fmt.Sprintf("feature-%03d", i))
+		linked = append(linked, path)
+	}
+	return bare, linked
+}
+
+// TestReadOnlyOperations checks the branches, worktrees, and status read from a repository,
+// which come from git or, when built with the gogit tag, go-git
+func TestReadOnlyOperations(t *testing.T) {
+	bare, linked := setupWorktreeRepo(t, 2, 2)
+	detached := filepath.Join(filepath.Dir(bare), "worktrees", "detached")
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", "--detach", detached, "main")
+
+	wantBranches := []string{"feature-000", "feature-001", "main"}
+	local, err := ListLocalBranches(bare)
+	if err != nil {
+		t.Fatalf("ListLocalBranches() failed: %v", err)
+	}
+	if !reflect.DeepEqual(local, wantBranches) {
+		t.Errorf("ListLocalBranches() = %v, want %v", local, wantBranches)
+	}
+	remote, err := ListRemoteBranches(linked[0])
+	if err != nil {
+		t.Fatalf("ListRemoteBranches() failed: %v", err)
+	}
+	if !reflect.DeepEqual(remote, wantBranches) {
+		t.Errorf("ListRemoteBranches() = %v, want %v", remote, wantBranches)
+	}
+
+	worktrees, err := ListWorktrees(t.Context(), bare)
+	if err != nil {
+		t.Fatalf("ListWorktrees() failed: %v", err)
+	}
+	var got []string
+	for _, wt := range worktrees {
+		got = append(got, filepath.Base(wt.Path)+" "+wt.Branch)
+	}
+	want := []string{"repo.git ", "detached (detached)", "feature-000 feature-000", "feature-001 feature-001"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListWorktrees() = %v, want %v", got, want)
+	}
+	if len(worktrees) == len(want) && len(worktrees[1].Commit) != 40 {
+		t.Errorf("ListWorktrees() commit of detached worktree = %q, want a full hash", worktrees[1].Commit)
+	}
+
+	if err := os.WriteFile(filepath.Join(linked[0], "new.txt"), []byte("new\n"), 0o644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	files, err := uncommittedFiles(linked[0])
+	if err != nil {
+		t.Fatalf("uncommittedFiles() failed: %v", err)
+	}
+	if want := []string{"?? new.txt"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("uncommittedFiles() = %v, want %v", files, want)
+	}
+}
+
+// BenchmarkReadOnlyOperations measures the operations that run for most commands on a large
+// repository. Compare git with go-git by running it with and without the gogit tag:
+// go test -run '^$' -bench ReadOnly ./internal/git
+// go test -tags gogit -run '^$' -bench ReadOnly ./internal/git
+func BenchmarkReadOnlyOperations(b *testing.B) {
+	bare, linked := setupWorktreeRepo(b, 500, 20)
+
+	b.Run("ListLocalBranches", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ListLocalBranches(bare); err != nil {
+				b.Fatalf("ListLocalBranches() failed: %v", err)
+			}
+		}
+	})
+	b.Run("ListWorktrees", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := ListWorktrees(b.Context(), bare); err != nil {
+				b.Fatalf("ListWorktrees() failed: %v", err)
+			}
+		}
+	})
+	b.Run("Status", func(b *testing.B) {
+		for b.Loop() {
+			for _, path := range linked {
+				if _, err := uncommittedFiles(path); err != nil {
+					b.Fatalf("uncommittedFiles() failed: %v", err)
+				}
+			}
+		}
+	})
+}
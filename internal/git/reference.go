@@ -0,0 +1,95 @@
+package git
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// referenceSearchDepth is how many directories deep FindReference looks for repositories,
+// enough for layouts like ~/src/github.com/org/repo
+const referenceSearchDepth = 4
+
+// FindReference looks in dirs for a local clone of a project, whose objects a new clone of it
+// can reuse. Both regular and bare repositories are found; shallow ones are skipped, since
+// git can't use them as a reference. It returns "" when there is no such clone.
+func FindReference(dirs []string, projectName string) string {
+	for _, dir := range dirs {
+		if repo := findReferenceIn(dir, projectName); repo != "" {
+			return repo
+		}
+	}
+	return ""
+}
+
+// findReferenceIn walks a directory for a clone of a project
+func findReferenceIn(root, projectName string) string {
+	root = filepath.Clean(root)
+	found := ""
+	//nolint:errcheck // Unreadable directories are skipped by the walk function
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		gitDir, ok := repositoryGitDir(path)
+		if !ok {
+			rel, _ := filepath.Rel(root, path)
+			if rel != "." && strings.Count(rel, string(filepath.Separator))+1 >= referenceSearchDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isReferenceFor(gitDir, projectName) {
+			found = path
+			return filepath.SkipAll
+		}
+		// Don't look for repositories inside repositories
+		return filepath.SkipDir
+	})
+	return found
+}
+
+// repositoryGitDir returns the git directory of a repository at path, if there is one
+// Linked worktrees, whose .git is a file, are reported without a git directory so the walk
+// doesn't descend into them; their objects are in the repository they belong to.
+func repositoryGitDir(path string) (string, bool) {
+	if info, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		if info.IsDir() {
+			return filepath.Join(path, ".git"), true
+		}
+		return "", true
+	}
+
+	// A bare repository
+	if _, err := os.Stat(filepath.Join(path, "HEAD")); err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(filepath.Join(path, "objects")); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return path, true
+}
+
+// isReferenceFor reports whether the repository of a git directory is a full clone of a project
+func isReferenceFor(gitDir, projectName string) bool {
+	if gitDir == "" {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "shallow")); err == nil {
+		return false
+	}
+
+	output, err := exec.Command("git", "--git-dir", gitDir, "config", "--get", "remote.origin.url").Output()
+	if err != nil {
+		return false
+	}
+	name, err := GenerateProjectName(strings.TrimSpace(string(output)))
+	return err == nil && name == projectName
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindReference(t *testing.T) {
+	root := t.TempDir()
+
+	// A regular clone, a bare clone, a shallow clone, and a clone of another project
+	newRepo := func(path, remoteURL string, bare bool) string {
+		args := []string{"init", "--quiet"}
+		if bare {
+			args = append(args, "--bare")
+		}
+		runGit(t, append(args, path)...)
+		runGit(t, "-C", path, "remote", "add", "origin", remoteURL)
+		return path
+	}
+	regular := newRepo(filepath.Join(root, "src", "api"), "git@github.com:acme/api.git", false)
+	bare := newRepo(filepath.Join(root, "mirrors", "github.com", "acme", "web.git"), "https://github.com/acme/web.git", true)
+	shallow := newRepo(filepath.Join(root, "src", "cli"), "git@github.com:acme/cli.git", false)
+	if err := os.WriteFile(filepath.Join(shallow, ".git", "shallow"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	newRepo(filepath.Join(root, "src", "other"), "git@github.com:other/tool.git", false)
+	// Repositories nested in a repository are not searched
+	newRepo(filepath.Join(regular, "vendor", "lib"), "git@github.com:acme/lib.git", false)
+
+	dirs := []string{filepath.Join(root, "missing"), root}
+	tests := []struct {
+		project string
+		want    string
+	}{
+		{project: "github.com/acme/api", want: regular},
+		{project: "github.com/acme/web", want: bare},
+		{project: "github.com/acme/cli", want: ""},
+		{project: "github.com/acme/lib", want: ""},
+		{project: "github.com/acme/unknown", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.project, func(t *testing.T) {
+			if got := FindReference(dirs, tt.project); got != tt.want {
+				t.Errorf("FindReference(%q) = %q, want %q", tt.project, got, tt.want)
+			}
+		})
+	}
+}
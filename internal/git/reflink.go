@@ -0,0 +1,176 @@
+package git
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// CloneWorktreeForBranch creates the worktree of a branch like CreateWorktreeForBranch, but clones
+// the files of an existing worktree, the template, with reflinks and then checks out the branch
+// over them, so only the files that differ are written. It falls back to CreateWorktreeForBranch
+// when the filesystem doesn't support reflinks, or the template has submodules.
+func CloneWorktreeForBranch(
+	ctx context.Context,
+	repoPath, remote, branch, worktreePath string,
+	source BranchSource,
+	templatePath string,
+) error {
+	// Cloned submodules would keep using the git directories of the template's submodules
+	if _, err := os.Stat(filepath.Join(templatePath, ".gitmodules")); err == nil {
+		slog.Debug("template worktree has submodules, checking out instead", "template", templatePath)
+		return CreateWorktreeForBranch(ctx, repoPath, remote, branch, worktreePath, source)
+	}
+
+	if err := cloneWorktree(ctx, repoPath, remote, branch, worktreePath, source, templatePath); err != nil {
+		slog.Warn("failed to clone worktree with reflinks, checking out instead", "template", templatePath, "error", err)
+		return CreateWorktreeForBranch(ctx, repoPath, remote, branch, worktreePath, source)
+	}
+
+	return trackBranch(worktreePath, remote, branch, source)
+}
+
+// cloneWorktree adds a worktree at the commit of the template, clones the template's files and
+// index into it, and checks out the branch. The worktree is removed again when that fails.
+func cloneWorktree(
+	ctx context.Context,
+	repoPath, remote, branch, worktreePath string,
+	source BranchSource,
+	templatePath string,
+) (err error) {
+	templateCommit, err := GetHeadCommit(templatePath)
+	if err != nil {
+		return err
+	}
+
+	out, err := combinedOutput(
+		ctx,
+		"-C",
+		repoPath,
+		"worktree",
+		"add",
+		"--no-checkout",
+		"--detach",
+		worktreePath,
+		templateCommit,
+	)
+	if err != nil {
+		return explainMissingObjects(repoPath, eris.Wrapf(err, "failed to create worktree: %s", string(out)))
+	}
+	defer func() {
+		if err != nil {
+			if removeErr := RemoveWorktreeForce(repoPath, worktreePath); removeErr != nil {
+				slog.Warn("failed to remove partially cloned worktree", "path", worktreePath, "error", removeErr)
+			}
+		}
+	}()
+
+	if err := reflinkFiles(ctx, templatePath, worktreePath); err != nil {
+		return err
+	}
+	if err := copyIndex(ctx, templatePath, worktreePath); err != nil {
+		return err
+	}
+
+	// Changes and untracked files of the template are undone; ignored files, like build caches,
+	// are kept
+	if out, err := inReusedWorktree(ctx, worktreePath, "reset", "--hard", "--quiet"); err != nil {
+		return eris.Wrapf(err, "failed to reset cloned worktree: %s", string(out))
+	}
+	if out, err := inReusedWorktree(ctx, worktreePath, "clean", "-d", "--force", "--quiet"); err != nil {
+		return eris.Wrapf(err, "failed to clean cloned worktree: %s", string(out))
+	}
+	return checkoutBranch(ctx, repoPath, remote, branch, worktreePath, source)
+}
+
+// reflinkFiles clones every file of a worktree but its .git link into another with reflinks,
+// keeping their modification times
+func reflinkFiles(ctx context.Context, templatePath, worktreePath string) error {
+	entries, err := os.ReadDir(templatePath)
+	if err != nil {
+		return eris.Wrap(err, "failed to read template worktree")
+	}
+	var sources []string
+	for _, entry := range entries {
+		if entry.Name() != ".git" {
+			sources = append(sources, filepath.Join(templatePath, entry.Name()))
+		}
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+
+	args, err := reflinkCopyArgs(runtime.GOOS, sources, worktreePath)
+	if err != nil {
+		return err
+	}
+	//nolint:gosec // The arguments are paths of worktrees
+	out, err := exec.CommandContext(ctx, "cp", args...).CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to clone files with reflinks: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// reflinkCopyArgs returns the arguments of cp cloning files into a directory with reflinks, and
+// nothing else: GNU cp fails rather than copying when the filesystem can't share their blocks,
+// and macOS cp clones them on APFS
+func reflinkCopyArgs(goos string, sources []string, dest string) ([]string, error) {
+	var args []string
+	switch goos {
+	case "linux":
+		args = []string{"-a", "--reflink=always"}
+	case "darwin":
+		args = []string{"-a", "-c"}
+	default:
+		return nil, eris.Errorf("reflinks aren't supported on %s", goos)
+	}
+	return append(append(args, sources...), dest), nil
+}
+
+// copyIndex copies the index of a worktree into another, so the files cloned from it are known
+// to be unchanged without reading them
+func copyIndex(ctx context.Context, templatePath, worktreePath string) error {
+	src, err := gitPath(ctx, templatePath, "index")
+	if err != nil {
+		return err
+	}
+	dst, err := gitPath(ctx, worktreePath, "index")
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return eris.Wrap(err, "failed to open index of template worktree")
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return eris.Wrap(err, "failed to create index")
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return eris.Wrap(err, "failed to copy index")
+	}
+	if err := out.Close(); err != nil {
+		return eris.Wrap(err, "failed to copy index")
+	}
+	return nil
+}
+
+// gitPath returns the absolute path of a file in the git directory of a worktree
+func gitPath(ctx context.Context, worktreePath, name string) (string, error) {
+	out, err := output(ctx, "-C", worktreePath, "rev-parse", "--path-format=absolute", "--git-path", name)
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to find %s of worktree: %s", name, worktreePath)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReflinkCopyArgs(t *testing.T) {
+	tests := []struct {
+		goos    string
+		want    []string
+		wantErr bool
+	}{
+		{goos: "linux", want: []string{"-a", "--reflink=always", "/wt/main/go.mod", "/wt/main/src", "/wt/new"}},
+		{goos: "darwin", want: []string{"-a", "-c", "/wt/main/go.mod", "/wt/main/src", "/wt/new"}},
+		{goos: "windows", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goos, func(t *testing.T) {
+			got, err := reflinkCopyArgs(tt.goos, []string{"/wt/main/go.mod", "/wt/main/src"}, "/wt/new")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reflinkCopyArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("reflinkCopyArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCloneWorktreeForBranch checks the worktrees cloned from a dirty template, which are the
+// same whether the filesystem supports reflinks or they are checked out instead
+func TestCloneWorktreeForBranch(t *testing.T) {
+	bare, _ := setupWorktreeRepo(t, 1, 0)
+	template := filepath.Join(filepath.Dir(bare), "worktrees", "main")
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", template, "main")
+	if err := os.WriteFile(filepath.Join(template, "file.txt"), []byte("main\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	runGit(t, "-C", template, "add", "file.txt")
+	runGit(t, "-C", template, "commit", "--quiet", "-m", "add file")
+	for name, content := range map[string]string{"file.txt": "changed\n", "untracked.txt": "x\n"} {
+		if err := os.WriteFile(filepath.Join(template, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		branch   string
+		source   BranchSource
+		wantFile string
+	}{
+		{branch: "feature-000", source: BranchLocal},
+		{branch: "fresh", source: BranchNew, wantFile: "main\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			path := filepath.Join(filepath.Dir(bare), "worktrees", tt.branch)
+			if err := CloneWorktreeForBranch(t.Context(), bare, "origin", tt.branch, path, tt.source, template); err != nil {
+				t.Fatalf("CloneWorktreeForBranch() failed: %v", err)
+			}
+
+			if branch, err := GetWorktreeBranch(path); err != nil || branch != tt.branch {
+				t.Errorf("GetWorktreeBranch() = %q, %v; want %q", branch, err, tt.branch)
+			}
+			if dirty, err := HasUncommittedChanges(path); err != nil || dirty {
+				t.Errorf("HasUncommittedChanges() = %v, %v; want false, nil", dirty, err)
+			}
+			content, err := os.ReadFile(filepath.Join(path, "file.txt"))
+			if tt.wantFile == "" && !os.IsNotExist(err) {
+				t.Errorf("file.txt of %s = %q, %v; want none", tt.branch, content, err)
+			}
+			if tt.wantFile != "" && string(content) != tt.wantFile {
+				t.Errorf("file.txt of %s = %q, %v; want %q", tt.branch, content, err, tt.wantFile)
+			}
+			if !HasUpstream(path, tt.branch) {
+				t.Errorf("branch %s has no upstream", tt.branch)
+			}
+		})
+	}
+}
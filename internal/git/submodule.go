@@ -0,0 +1,24 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/rotisserie/eris"
+)
+
+// HasSubmodules reports whether the checkout of a worktree declares submodules in .gitmodules
+func HasSubmodules(worktreePath string) bool {
+	_, err := os.Stat(filepath.Join(worktreePath, ".gitmodules"))
+	return err == nil
+}
+
+// UpdateSubmodules checks out the submodules of a worktree, and theirs, at the commits it records
+func UpdateSubmodules(ctx context.Context, worktreePath string) error {
+	output, err := combinedOutput(ctx, "-C", worktreePath, "submodule", "update", "--init", "--recursive")
+	if err != nil {
+		return eris.Wrapf(err, "failed to update submodules: %s", string(output))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdateSubmodules(t *testing.T) {
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+	// Submodules from local paths are refused by default since git 2.38.1
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	runGit(t, "init", "--quiet", "--initial-branch", "main", lib)
+	if err := os.WriteFile(filepath.Join(lib, "lib.go"), []byte("package lib\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, "-C", lib, "add", "lib.go")
+	runGit(t, "-C", lib, "commit", "--quiet", "-m", "lib")
+
+	app := filepath.Join(dir, "app")
+	runGit(t, "init", "--quiet", "--initial-branch", "main", app)
+	runGit(t, "-C", app, "submodule", "--quiet", "add", lib, "lib")
+	runGit(t, "-C", app, "commit", "--quiet", "-m", "add lib")
+
+	// A worktree of a bare clone starts with empty submodule directories
+	bare := filepath.Join(dir, "app.git")
+	runGit(t, "clone", "--quiet", "--bare", app, bare)
+	worktree := filepath.Join(dir, "main")
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", worktree, "main")
+
+	if !HasSubmodules(worktree) {
+		t.Fatal("HasSubmodules() = false, want true")
+	}
+	if HasSubmodules(lib) {
+		t.Error("HasSubmodules() of a repository without submodules = true, want false")
+	}
+
+	if err := UpdateSubmodules(t.Context(), worktree); err != nil {
+		t.Fatalf("UpdateSubmodules() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "lib", "lib.go")); err != nil {
+		t.Errorf("submodule wasn't checked out: %v", err)
+	}
+}
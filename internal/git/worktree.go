@@ -2,7 +2,13 @@ package git
 
 import (
 	"bufio"
+	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/rotisserie/eris"
@@ -15,13 +21,39 @@ type WorktreeInfo struct {
 	Commit string
 }
 
+// LocalChanges describes work in a worktree that would be lost if the worktree was removed
+type LocalChanges struct {
+	UncommittedFiles []string // Lines from git status --porcelain
+	UnpushedCommits  []string // One-line summaries of commits not on any remote
+}
+
+// HasChanges reports whether the worktree has uncommitted changes or unpushed commits
+func (c *LocalChanges) HasChanges() bool {
+	return len(c.UncommittedFiles) > 0 || len(c.UnpushedCommits) > 0
+}
+
+// Summary returns a short human-readable description of the local changes
+func (c *LocalChanges) Summary() string {
+	var parts []string
+	if n := len(c.UncommittedFiles); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d uncommitted file(s)", n))
+	}
+	if n := len(c.UnpushedCommits); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d unpushed commit(s)", n))
+	}
+	if len(parts) == 0 {
+		return "clean"
+	}
+	return strings.Join(parts, ", ")
+}
+
 // CreateWorktree creates a new worktree for a branch that exists in the repository
 // For bare repositories (which sesh uses), branches are stored at refs/heads/<branch>
-// This sets up tracking to origin/<branch> for pushing
-func CreateWorktree(repoPath, branch, worktreePath string) error {
+// This sets up tracking to <remote>/<branch> for pushing, unless the branch already has an upstream
+func CreateWorktree(ctx context.Context, repoPath, remote, branch, worktreePath string) error {
 	// Create the worktree
-	cmd := exec.Command(
-		"git",
+	output, err := combinedOutput(
+		ctx,
 		"-C",
 		repoPath,
 		"worktree",
@@ -29,36 +61,38 @@ func CreateWorktree(repoPath, branch, worktreePath string) error {
 		worktreePath,
 		branch,
 	)
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return eris.Wrapf(err, "failed to create worktree: %s", string(output))
+		return explainMissingObjects(repoPath, eris.Wrapf(err, "failed to create worktree: %s", string(output)))
+	}
+
+	// A branch created from another remote, like upstream/main, keeps tracking it
+	if HasUpstream(worktreePath, branch) {
+		return nil
 	}
 
-	// Set up tracking to origin/<branch>
+	// Set up tracking to <remote>/<branch>
 	// In bare repos, we need to manually configure the tracking since there are no
 	// remote-tracking branches (refs/remotes/origin/*). We set the config directly.
-	cmd = exec.Command(
-		"git",
+	output, err = combinedOutput(
+		ctx,
 		"-C",
 		worktreePath,
 		"config",
 		"branch."+branch+".remote",
-		"origin",
+		remote,
 	)
-	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to set branch remote: %s", string(output))
 	}
 
-	cmd = exec.Command(
-		"git",
+	output, err = combinedOutput(
+		ctx,
 		"-C",
 		worktreePath,
 		"config",
 		"branch."+branch+".merge",
 		"refs/heads/"+branch,
 	)
-	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to set branch merge: %s", string(output))
 	}
@@ -67,9 +101,9 @@ func CreateWorktree(repoPath, branch, worktreePath string) error {
 }
 
 // CreateWorktreeFromLocalBranch creates a new worktree for a branch that already exists locally
-func CreateWorktreeFromLocalBranch(repoPath, branch, worktreePath string) error {
-	cmd := exec.Command(
-		"git",
+func CreateWorktreeFromLocalBranch(ctx context.Context, repoPath, branch, worktreePath string) error {
+	output, err := combinedOutput(
+		ctx,
 		"-C",
 		repoPath,
 		"worktree",
@@ -77,18 +111,17 @@ func CreateWorktreeFromLocalBranch(repoPath, branch, worktreePath string) error
 		worktreePath,
 		branch,
 	)
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return eris.Wrapf(err, "failed to create worktree from local branch: %s", string(output))
+		return explainMissingObjects(repoPath, eris.Wrapf(err, "failed to create worktree from local branch: %s", string(output)))
 	}
 	return nil
 }
 
 // CreateWorktreeNewBranch creates a new worktree with a new branch
 // This is equivalent to: git worktree add -b <branch> <path> <start-point>
-func CreateWorktreeNewBranch(repoPath, branch, worktreePath, startPoint string) error {
-	cmd := exec.Command(
-		"git",
+func CreateWorktreeNewBranch(ctx context.Context, repoPath, remote, branch, worktreePath, startPoint string) error {
+	output, err := combinedOutput(
+		ctx,
 		"-C",
 		repoPath,
 		"worktree",
@@ -98,36 +131,33 @@ func CreateWorktreeNewBranch(repoPath, branch, worktreePath, startPoint string)
 		worktreePath,
 		startPoint,
 	)
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return eris.Wrapf(err, "failed to create worktree with new branch: %s", string(output))
+		return explainMissingObjects(repoPath, eris.Wrapf(err, "failed to create worktree with new branch: %s", string(output)))
 	}
 
-	// Set up tracking to origin/<branch>
+	// Set up tracking to <remote>/<branch>
 	// In bare repos, we need to manually configure the tracking since there are no
 	// remote-tracking branches (refs/remotes/origin/*). We set the config directly.
-	cmd = exec.Command(
-		"git",
+	output, err = combinedOutput(
+		ctx,
 		"-C",
 		worktreePath,
 		"config",
 		"branch."+branch+".remote",
-		"origin",
+		remote,
 	)
-	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to set branch remote: %s", string(output))
 	}
 
-	cmd = exec.Command(
-		"git",
+	output, err = combinedOutput(
+		ctx,
 		"-C",
 		worktreePath,
 		"config",
 		"branch."+branch+".merge",
 		"refs/heads/"+branch,
 	)
-	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to set branch merge: %s", string(output))
 	}
@@ -137,10 +167,10 @@ func CreateWorktreeNewBranch(repoPath, branch, worktreePath, startPoint string)
 
 // CreateWorktreeFromRemoteBranch creates a new worktree for a branch that exists on the remote
 // but not locally. This creates a local branch tracking the remote branch.
-// This is equivalent to: git worktree add -b <branch> <path> origin/<branch>
-func CreateWorktreeFromRemoteBranch(repoPath, branch, worktreePath string) error {
-	cmd := exec.Command(
-		"git",
+// This is equivalent to: git worktree add -b <branch> <path> <remote>/<branch>
+func CreateWorktreeFromRemoteBranch(ctx context.Context, repoPath, remote, branch, worktreePath string) error {
+	output, err := combinedOutput(
+		ctx,
 		"-C",
 		repoPath,
 		"worktree",
@@ -148,37 +178,34 @@ func CreateWorktreeFromRemoteBranch(repoPath, branch, worktreePath string) error
 		"-b",
 		branch,
 		worktreePath,
-		"origin/"+branch,
+		remote+"/"+branch,
 	)
-	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return eris.Wrapf(err, "failed to create worktree from remote branch: %s", string(output))
+		return explainMissingObjects(repoPath, eris.Wrapf(err, "failed to create worktree from remote branch: %s", string(output)))
 	}
 
-	// Set up tracking to origin/<branch>
+	// Set up tracking to <remote>/<branch>
 	// Configure the tracking since git worktree add doesn't always set it up correctly
-	cmd = exec.Command(
-		"git",
+	output, err = combinedOutput(
+		ctx,
 		"-C",
 		worktreePath,
 		"config",
 		"branch."+branch+".remote",
-		"origin",
+		remote,
 	)
-	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to set branch remote: %s", string(output))
 	}
 
-	cmd = exec.Command(
-		"git",
+	output, err = combinedOutput(
+		ctx,
 		"-C",
 		worktreePath,
 		"config",
 		"branch."+branch+".merge",
 		"refs/heads/"+branch,
 	)
-	output, err = cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to set branch merge: %s", string(output))
 	}
@@ -186,10 +213,65 @@ func CreateWorktreeFromRemoteBranch(repoPath, branch, worktreePath string) error
 	return nil
 }
 
+// BranchSource is where the branch of a new worktree comes from
+type BranchSource int
+
+const (
+	// BranchLocal is a branch that exists in the repository
+	BranchLocal BranchSource = iota
+	// BranchRemote is a branch that only exists on the remote
+	BranchRemote
+	// BranchNew is a branch that doesn't exist yet and is created from HEAD
+	BranchNew
+)
+
+// GetBranchSource reports where the worktree of a branch would be created from, looking for
+// remote branches on the given remote
+func GetBranchSource(repoPath, remote, branch string) (BranchSource, error) {
+	exists, _, err := DoesBranchExist(repoPath, branch)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to check branch existence")
+	}
+	if exists {
+		return BranchLocal, nil
+	}
+
+	existsRemotely, err := DoesBranchExistRemotely(repoPath, remote, branch)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to check remote branch existence")
+	}
+	if existsRemotely {
+		return BranchRemote, nil
+	}
+
+	return BranchNew, nil
+}
+
+// CreateWorktreeForBranch creates the worktree of a branch from the given source, tracking the
+// branch of the same name on the given remote
+func CreateWorktreeForBranch(ctx context.Context, repoPath, remote, branch, worktreePath string, source BranchSource) error {
+	switch source {
+	case BranchLocal:
+		// In bare repos (which sesh uses), this automatically sets up tracking to the remote
+		if err := CreateWorktree(ctx, repoPath, remote, branch, worktreePath); err != nil {
+			return eris.Wrap(err, "failed to create worktree from branch")
+		}
+	case BranchRemote:
+		if err := CreateWorktreeFromRemoteBranch(ctx, repoPath, remote, branch, worktreePath); err != nil {
+			return eris.Wrap(err, "failed to create worktree from remote branch")
+		}
+	default:
+		if err := CreateWorktreeNewBranch(ctx, repoPath, remote, branch, worktreePath, "HEAD"); err != nil {
+			return eris.Wrap(err, "failed to create worktree with new branch")
+		}
+	}
+	return nil
+}
+
 // CreateWorktreeFromRef creates a new worktree from a specific ref (commit, tag, etc.)
-func CreateWorktreeFromRef(repoPath, ref, worktreePath string) error {
-	cmd := exec.Command(
-		"git",
+func CreateWorktreeFromRef(ctx context.Context, repoPath, ref, worktreePath string) error {
+	output, err := combinedOutput(
+		ctx,
 		"-C",
 		repoPath,
 		"worktree",
@@ -201,7 +283,6 @@ func CreateWorktreeFromRef(repoPath, ref, worktreePath string) error {
 		"origin/"+ref,
 		"--track",
 	)
-	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to create worktree from ref: %s", string(output))
 	}
@@ -209,14 +290,8 @@ func CreateWorktreeFromRef(repoPath, ref, worktreePath string) error {
 }
 
 // ListWorktrees lists all worktrees for a repository
-func ListWorktrees(repoPath string) ([]WorktreeInfo, error) {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "list", "--porcelain")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, eris.Wrap(err, "failed to list worktrees")
-	}
-
-	return parseWorktreeList(string(output))
+func ListWorktrees(ctx context.Context, repoPath string) ([]WorktreeInfo, error) {
+	return listWorktrees(ctx, repoPath)
 }
 
 // parseWorktreeList parses the output of 'git worktree list --porcelain'
@@ -244,13 +319,8 @@ func parseWorktreeList(output string) ([]WorktreeInfo, error) {
 			continue
 		}
 
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) < 2 {
-			continue
-		}
-
-		key := parts[0]
-		value := parts[1]
+		// Some lines, like "detached", are a key without a value
+		key, value, _ := strings.Cut(line, " ")
 
 		switch key {
 		case "worktree":
@@ -290,6 +360,267 @@ func RemoveWorktree(repoPath, worktreePath string) error {
 	return nil
 }
 
+// GetLocalChanges inspects a worktree for uncommitted changes and commits that haven't been pushed
+// A commit counts as unpushed when it isn't reachable from any remote-tracking branch
+func GetLocalChanges(worktreePath string) (*LocalChanges, error) {
+	uncommitted, err := uncommittedFiles(worktreePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("git", "-C", worktreePath, "log", "--oneline", "HEAD", "--not", "--remotes")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to list unpushed commits of worktree: %s", worktreePath)
+	}
+	unpushed := parseGitBranchList(string(output))
+
+	return &LocalChanges{
+		UncommittedFiles: uncommitted,
+		UnpushedCommits:  unpushed,
+	}, nil
+}
+
+// AheadBehind compares a branch with its upstream branch
+type AheadBehind struct {
+	// Upstream is the remote-tracking branch, e.g. "origin/feature"
+	Upstream string `json:"upstream"`
+	// Ahead is the number of commits on the branch that aren't on the upstream
+	Ahead int `json:"ahead"`
+	// Behind is the number of commits on the upstream that aren't on the branch
+	Behind int `json:"behind"`
+}
+
+// GetAheadBehind compares the branch checked out in a worktree with its upstream branch
+// Returns nil without an error when the branch has no upstream, e.g. before it is first pushed.
+func GetAheadBehind(worktreePath string) (*AheadBehind, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "rev-parse", "--abbrev-ref", "@{upstream}")
+	output, err := cmd.Output()
+	if err != nil {
+		// No upstream is configured, or it hasn't been fetched
+		return nil, nil
+	}
+	upstream := strings.TrimSpace(string(output))
+
+	cmd = exec.Command("git", "-C", worktreePath, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to compare worktree with %s: %s", upstream, worktreePath)
+	}
+
+	var ab AheadBehind
+	if _, err := fmt.Sscan(string(output), &ab.Ahead, &ab.Behind); err != nil {
+		return nil, eris.Wrapf(err, "failed to parse rev-list output: %s", output)
+	}
+	ab.Upstream = upstream
+
+	return &ab, nil
+}
+
+// HasUncommittedChanges reports whether a worktree has changed or untracked files
+func HasUncommittedChanges(worktreePath string) (bool, error) {
+	files, err := uncommittedFiles(worktreePath)
+	return len(files) > 0, err
+}
+
+// FastForward moves the branch checked out in a worktree to its upstream branch, failing
+// when the branch has commits the upstream doesn't
+func FastForward(ctx context.Context, worktreePath string) error {
+	output, err := combinedOutput(ctx, "-C", worktreePath, "merge", "--ff-only", "--quiet", "@{upstream}")
+	if err != nil {
+		return eris.Wrapf(err, "failed to fast-forward worktree: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DiffStat summarizes the changes of a worktree, like git diff --shortstat
+type DiffStat struct {
+	Files      int `json:"files"`
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// shortstatPattern matches one count of git diff --shortstat, e.g. "3 files changed" or "10 insertions(+)"
+var shortstatPattern = regexp.MustCompile(`(\d+) (file|insertion|deletion)`)
+
+// GetDiffStat summarizes the changes of a worktree since it forked from its upstream branch,
+// including uncommitted changes. Changes that only the upstream has are left out.
+// Returns nil without an error when the branch has no upstream.
+func GetDiffStat(worktreePath string) (*DiffStat, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "rev-parse", "--verify", "--quiet", "@{upstream}")
+	if err := cmd.Run(); err != nil {
+		// No upstream is configured, or it hasn't been fetched
+		return nil, nil
+	}
+
+	cmd = exec.Command("git", "-C", worktreePath, "diff", "--shortstat", "--merge-base", "@{upstream}")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to diff worktree with its upstream: %s", worktreePath)
+	}
+
+	var stat DiffStat
+	for _, match := range shortstatPattern.FindAllStringSubmatch(string(output), -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to parse diff stat: %s", output)
+		}
+		switch match[2] {
+		case "file":
+			stat.Files = n
+		case "insertion":
+			stat.Insertions = n
+		case "deletion":
+			stat.Deletions = n
+		}
+	}
+
+	return &stat, nil
+}
+
+// CountStashes counts the stash entries made on a branch
+// Stashes are shared by all worktrees of a repository, so they are matched by the branch
+// recorded in their message.
+func CountStashes(worktreePath, branch string) (int, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "stash", "list", "--format=%gs")
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, eris.Wrapf(err, "failed to list stashes: %s", worktreePath)
+	}
+
+	count := 0
+	for _, subject := range strings.Split(string(output), "\n") {
+		// "WIP on <branch>: ..." without a message, "On <branch>: ..." with one
+		if strings.HasPrefix(subject, "WIP on "+branch+":") || strings.HasPrefix(subject, "On "+branch+":") {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// GetHeadCommit returns the full commit hash checked out in a worktree
+func GetHeadCommit(worktreePath string) (string, error) {
+	cmd := exec.Command("git", "-C", worktreePath, "rev-parse", "HEAD")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to get HEAD commit of worktree: %s", worktreePath)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// LastHeadCommit returns the commit a worktree last had checked out, from the reflog of its
+// HEAD, e.g. to recreate a branch that was deleted while checked out in the worktree
+func LastHeadCommit(worktreePath string) (string, error) {
+	adminDir, err := WorktreeLinkAdminDir(worktreePath)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(adminDir, "logs", "HEAD"))
+	if err != nil {
+		return "", eris.Wrap(err, "failed to read worktree HEAD log")
+	}
+
+	// Format: <old commit> <new commit> <committer> <time> <zone>\t<message>
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 2 || strings.Trim(fields[1], "0") == "" {
+		return "", eris.Errorf("worktree has no HEAD history: %s", worktreePath)
+	}
+	return fields[1], nil
+}
+
+// CreateWorktreeNoCheckout creates a worktree for an existing branch without checking out any files
+// This is used to re-attach previously saved worktree contents, followed by ResetIndex
+func CreateWorktreeNoCheckout(repoPath, branch, worktreePath string) error {
+	cmd := exec.Command(
+		"git",
+		"-C",
+		repoPath,
+		"worktree",
+		"add",
+		"--no-checkout",
+		worktreePath,
+		branch,
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return explainMissingObjects(repoPath, eris.Wrapf(err, "failed to create worktree: %s", string(output)))
+	}
+	return nil
+}
+
+// ResetIndex resets the index of a worktree to HEAD without touching the working tree files
+func ResetIndex(worktreePath string) error {
+	cmd := exec.Command("git", "-C", worktreePath, "reset", "--quiet")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to reset index: %s", string(output))
+	}
+	return nil
+}
+
+// MoveWorktree moves a worktree of a repository to a new path
+func MoveWorktree(repoPath, worktreePath, newPath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "move", worktreePath, newPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to move worktree: %s", string(output))
+	}
+	return nil
+}
+
+// GetWorktreeRoot returns the top directory of the worktree containing path, and the git
+// directory shared by all worktrees of its repository, which is the bare repository for
+// worktrees created by sesh
+func GetWorktreeRoot(path string) (worktreePath, commonDir string, err error) {
+	cmd := exec.Command("git", "-C", path, "rev-parse", "--path-format=absolute", "--show-toplevel", "--git-common-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", "", eris.Wrapf(err, "%s is not inside a git worktree", path)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 2 {
+		return "", "", eris.Errorf("%s is not inside a git worktree", path)
+	}
+	return lines[0], filepath.Clean(lines[1]), nil
+}
+
+// CheckoutNewBranch creates a branch at HEAD of a worktree and checks it out, keeping changes
+func CheckoutNewBranch(worktreePath, branch string) error {
+	cmd := exec.Command("git", "-C", worktreePath, "switch", "--quiet", "-c", branch)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to create branch %s: %s", branch, string(output))
+	}
+	return nil
+}
+
+// HasUpstream reports whether a branch has an upstream branch configured
+func HasUpstream(worktreePath, branch string) bool {
+	return exec.Command("git", "-C", worktreePath, "config", "--get", "branch."+branch+".merge").Run() == nil
+}
+
+// GetUpstreamRemote returns the remote a branch tracks, or "" when it has no upstream
+func GetUpstreamRemote(repoPath, branch string) string {
+	output, err := exec.Command("git", "-C", repoPath, "config", "--get", "branch."+branch+".remote").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// SetUpstream makes <remote>/<branch> the upstream of a branch, like worktrees created by sesh have
+func SetUpstream(worktreePath, remote, branch string) error {
+	for key, value := range map[string]string{"remote": remote, "merge": "refs/heads/" + branch} {
+		cmd := exec.Command("git", "-C", worktreePath, "config", "branch."+branch+"."+key, value)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return eris.Wrapf(err, "failed to set branch %s: %s", key, string(output))
+		}
+	}
+	return nil
+}
+
 // RemoveWorktreeForce forcefully removes a worktree (even if it has uncommitted changes)
 func RemoveWorktreeForce(repoPath, worktreePath string) error {
 	cmd := exec.Command("git", "-C", repoPath, "worktree", "remove", "--force", worktreePath)
@@ -314,6 +645,43 @@ func GetWorktreeBranch(worktreePath string) (string, error) {
 	return branch, nil
 }
 
+// ReadWorktreeBranch reads the branch checked out in a worktree from its git files
+// It is much faster than GetWorktreeBranch for callers that run on every shell prompt.
+func ReadWorktreeBranch(worktreePath string) (string, error) {
+	gitDir, err := WorktreeLinkAdminDir(worktreePath)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return "", eris.Wrap(err, "failed to read worktree HEAD")
+	}
+
+	branch, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: refs/heads/")
+	if !ok {
+		return "(detached)", nil
+	}
+	return branch, nil
+}
+
+// WorktreeLinkAdminDir returns the directory a worktree's .git file points to, which holds
+// its information in the repository, e.g. <repo>.git/worktrees/main
+func WorktreeLinkAdminDir(worktreePath string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(worktreePath, ".git"))
+	if err != nil {
+		return "", eris.Wrap(err, "failed to read worktree .git file")
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", eris.Errorf("not a linked worktree: %s", worktreePath)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(worktreePath, gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
 // PruneWorktrees removes worktree information for directories that no longer exist
 func PruneWorktrees(repoPath string) error {
 	cmd := exec.Command("git", "-C", repoPath, "worktree", "prune")
@@ -323,3 +691,85 @@ func PruneWorktrees(repoPath string) error {
 	}
 	return nil
 }
+
+// PrunableWorktree is the information git keeps about a worktree that it would prune
+type PrunableWorktree struct {
+	// Name is the directory of the worktree information in the repository, e.g. "worktrees/main"
+	Name string
+	// Reason is why git would prune it, e.g. "gitdir file points to non-existent location"
+	Reason string
+}
+
+// ListPrunableWorktrees lists the worktree information PruneWorktrees would remove
+func ListPrunableWorktrees(repoPath string) ([]PrunableWorktree, error) {
+	cmd := exec.Command("git", "-C", repoPath, "worktree", "prune", "--dry-run", "--verbose")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to list prunable worktrees: %s", string(output))
+	}
+
+	var prunable []PrunableWorktree
+	for _, line := range parseGitBranchList(string(output)) {
+		// Format: Removing worktrees/<name>: <reason>
+		name, reason, ok := strings.Cut(strings.TrimPrefix(line, "Removing "), ": ")
+		if !ok {
+			continue
+		}
+		prunable = append(prunable, PrunableWorktree{Name: name, Reason: reason})
+	}
+	return prunable, nil
+}
+
+// RepairWorktrees fixes the links between a repository and its worktrees, e.g. after either
+// was moved, and returns git's description of each repair. Worktrees that moved are only
+// found from worktreePaths, their new locations.
+func RepairWorktrees(repoPath string, worktreePaths []string) ([]string, error) {
+	args := []string{"-C", repoPath, "worktree", "repair"}
+	for _, path := range worktreePaths {
+		// git resolves relative paths from the repository
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to resolve worktree path: %s", path)
+		}
+		args = append(args, abs)
+	}
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	var repairs []string
+	for _, line := range parseGitBranchList(string(output)) {
+		// Format: repair: <what was fixed>: <path>
+		if repair, ok := strings.CutPrefix(line, "repair: "); ok {
+			repairs = append(repairs, repair)
+		}
+	}
+	if err != nil {
+		return repairs, eris.Wrapf(err, "failed to repair worktrees: %s", string(output))
+	}
+	return repairs, nil
+}
+
+// CheckWorktreeLink reports why a worktree and its repository don't point at each other,
+// or returns "" when they do
+func CheckWorktreeLink(worktreePath string) string {
+	adminDir, err := WorktreeLinkAdminDir(worktreePath)
+	if err != nil {
+		return err.Error()
+	}
+	data, err := os.ReadFile(filepath.Join(adminDir, "gitdir"))
+	if err != nil {
+		return "its .git file points to a repository that doesn't exist: " + adminDir
+	}
+
+	// The repository has the path of the worktree's .git file
+	back := filepath.Dir(strings.TrimSpace(string(data)))
+	if back == filepath.Clean(worktreePath) {
+		return ""
+	}
+	// Either path may go through a symlink
+	want, err1 := filepath.EvalSymlinks(worktreePath)
+	got, err2 := filepath.EvalSymlinks(back)
+	if err1 == nil && err2 == nil && want == got {
+		return ""
+	}
+	return "the repository has it at " + back
+}
@@ -0,0 +1,376 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalChangesSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		changes     LocalChanges
+		wantChanges bool
+		wantSummary string
+	}{
+		{
+			name:        "clean worktree",
+			changes:     LocalChanges{},
+			wantChanges: false,
+			wantSummary: "clean",
+		},
+		{
+			name: "uncommitted files only",
+			changes: LocalChanges{
+				UncommittedFiles: []string{"M main.go", "?? notes.txt"},
+			},
+			wantChanges: true,
+			wantSummary: "2 uncommitted file(s)",
+		},
+		{
+			name: "unpushed commits only",
+			changes: LocalChanges{
+				UnpushedCommits: []string{"abc1234 Add feature"},
+			},
+			wantChanges: true,
+			wantSummary: "1 unpushed commit(s)",
+		},
+		{
+			name: "both",
+			changes: LocalChanges{
+				UncommittedFiles: []string{"M main.go"},
+				UnpushedCommits:  []string{"abc1234 Add feature", "def5678 Fix tests"},
+			},
+			wantChanges: true,
+			wantSummary: "1 uncommitted file(s), 2 unpushed commit(s)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.changes.HasChanges(); got != tt.wantChanges {
+				t.Errorf("HasChanges() = %v, want %v", got, tt.wantChanges)
+			}
+			if got := tt.changes.Summary(); got != tt.wantSummary {
+				t.Errorf("Summary() = %q, want %q", got, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestReadWorktreeBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		dotGit  string
+		head    string
+		want    string
+		wantErr bool
+	}{
+		{name: "branch", dotGit: "gitdir: {gitdir}\n", head: "ref: refs/heads/feature/foo\n", want: "feature/foo"},
+		{name: "relative gitdir", dotGit: "gitdir: ../gitdir\n", head: "ref: refs/heads/main\n", want: "main"},
+		{name: "detached", dotGit: "gitdir: {gitdir}\n", head: "0123456789abcdef\n", want: "(detached)"},
+		{name: "not a worktree", dotGit: "something else\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			worktree := filepath.Join(dir, "worktree")
+			gitDir := filepath.Join(dir, "gitdir")
+			for _, d := range []string{worktree, gitDir} {
+				if err := os.Mkdir(d, 0o755); err != nil {
+					t.Fatalf("Mkdir() failed: %v", err)
+				}
+			}
+
+			dotGit := strings.ReplaceAll(tt.dotGit, "{gitdir}", gitDir)
+			if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte(dotGit), 0o644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte(tt.head), 0o644); err != nil {
+				t.Fatalf("WriteFile() failed: %v", err)
+			}
+
+			got, err := ReadWorktreeBranch(worktree)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadWorktreeBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReadWorktreeBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// setupDivergedClone clones a repository and diverges the clone from its upstream: the clone
+// has two commits the remote doesn't, adding a file, and is missing one remote commit
+func setupDivergedClone(t *testing.T) string {
+	t.Helper()
+
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	clone := filepath.Join(dir, "clone")
+
+	runGit(t, "init", "--quiet", "--initial-branch", "main", src)
+	runGit(t, "-C", src, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	runGit(t, "clone", "--quiet", src, clone)
+	if err := os.WriteFile(filepath.Join(clone, "file.txt"), []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	runGit(t, "-C", clone, "add", "file.txt")
+	runGit(t, "-C", clone, "commit", "--quiet", "-m", "local 1")
+	runGit(t, "-C", clone, "commit", "--quiet", "--allow-empty", "-m", "local 2")
+	runGit(t, "-C", src, "commit", "--quiet", "--allow-empty", "-m", "remote")
+	runGit(t, "-C", clone, "fetch", "--quiet")
+
+	return clone
+}
+
+// runGit runs git, failing the test if it fails
+func runGit(t testing.TB, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestGetAheadBehind(t *testing.T) {
+	clone := setupDivergedClone(t)
+
+	got, err := GetAheadBehind(clone)
+	if err != nil {
+		t.Fatalf("GetAheadBehind() failed: %v", err)
+	}
+	want := AheadBehind{Upstream: "origin/main", Ahead: 2, Behind: 1}
+	if got == nil || *got != want {
+		t.Errorf("GetAheadBehind() = %+v, want %+v", got, want)
+	}
+
+	runGit(t, "-C", clone, "switch", "--quiet", "-c", "unpushed")
+	if got, err := GetAheadBehind(clone); err != nil || got != nil {
+		t.Errorf("GetAheadBehind() without upstream = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	clone := setupDivergedClone(t)
+
+	// Diverged branches can't be fast-forwarded
+	if err := FastForward(context.Background(), clone); err == nil {
+		t.Fatal("FastForward() of a diverged branch succeeded")
+	}
+
+	runGit(t, "-C", clone, "reset", "--quiet", "--hard", "HEAD~2")
+	if dirty, err := HasUncommittedChanges(clone); err != nil || dirty {
+		t.Fatalf("HasUncommittedChanges() = %v, %v; want false, nil", dirty, err)
+	}
+	if err := FastForward(context.Background(), clone); err != nil {
+		t.Fatalf("FastForward() failed: %v", err)
+	}
+	got, err := GetAheadBehind(clone)
+	if err != nil {
+		t.Fatalf("GetAheadBehind() failed: %v", err)
+	}
+	if got == nil || got.Ahead != 0 || got.Behind != 0 {
+		t.Errorf("GetAheadBehind() after FastForward() = %+v, want up to date", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(clone, "untracked.txt"), []byte("x\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	if dirty, err := HasUncommittedChanges(clone); err != nil || !dirty {
+		t.Errorf("HasUncommittedChanges() with an untracked file = %v, %v; want true, nil", dirty, err)
+	}
+}
+
+func TestGetDiffStat(t *testing.T) {
+	clone := setupDivergedClone(t)
+
+	// Uncommitted changes count too
+	if err := os.WriteFile(filepath.Join(clone, "other.txt"), []byte("three\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	runGit(t, "-C", clone, "add", "other.txt")
+
+	got, err := GetDiffStat(clone)
+	if err != nil {
+		t.Fatalf("GetDiffStat() failed: %v", err)
+	}
+	want := DiffStat{Files: 2, Insertions: 3}
+	if got == nil || *got != want {
+		t.Errorf("GetDiffStat() = %+v, want %+v", got, want)
+	}
+
+	runGit(t, "-C", clone, "switch", "--quiet", "-c", "unpushed")
+	if got, err := GetDiffStat(clone); err != nil || got != nil {
+		t.Errorf("GetDiffStat() without upstream = %+v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestCountStashes(t *testing.T) {
+	clone := setupDivergedClone(t)
+
+	for _, args := range [][]string{
+		{"stash", "push", "--quiet", "--include-untracked"},
+		{"stash", "push", "--quiet", "--include-untracked", "-m", "named"},
+	} {
+		if err := os.WriteFile(filepath.Join(clone, "stashed.txt"), []byte("x\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+		runGit(t, append([]string{"-C", clone}, args...)...)
+	}
+
+	tests := []struct {
+		branch string
+		want   int
+	}{
+		{branch: "main", want: 2},
+		{branch: "other", want: 0},
+	}
+	for _, tt := range tests {
+		got, err := CountStashes(clone, tt.branch)
+		if err != nil {
+			t.Fatalf("CountStashes() failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("CountStashes(%q) = %d, want %d", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestGetWorktreeRootAndMoveWorktree(t *testing.T) {
+	clone := setupDivergedClone(t)
+	dir := filepath.Dir(clone)
+
+	bare := filepath.Join(dir, "repo.git")
+	runGit(t, "clone", "--quiet", "--bare", clone, bare)
+	external := filepath.Join(dir, "external")
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", "--detach", external, "main")
+	if err := os.Mkdir(filepath.Join(external, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	worktreePath, commonDir, err := GetWorktreeRoot(filepath.Join(external, "sub"))
+	if err != nil {
+		t.Fatalf("GetWorktreeRoot() returned error: %v", err)
+	}
+	resolvedBare, _ := filepath.EvalSymlinks(bare)
+	resolvedExternal, _ := filepath.EvalSymlinks(external)
+	if worktreePath != resolvedExternal || commonDir != resolvedBare {
+		t.Errorf("GetWorktreeRoot() = %q, %q; want %q, %q", worktreePath, commonDir, resolvedExternal, resolvedBare)
+	}
+
+	if err := CheckoutNewBranch(external, "fix"); err != nil {
+		t.Fatalf("CheckoutNewBranch() returned error: %v", err)
+	}
+	if HasUpstream(external, "fix") {
+		t.Error("HasUpstream() of a new branch = true, want false")
+	}
+	if err := SetUpstream(external, "origin", "fix"); err != nil {
+		t.Fatalf("SetUpstream() returned error: %v", err)
+	}
+	if !HasUpstream(external, "fix") {
+		t.Error("HasUpstream() after SetUpstream() = false, want true")
+	}
+
+	moved := filepath.Join(dir, "worktrees", "fix")
+	if err := os.MkdirAll(filepath.Dir(moved), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := MoveWorktree(bare, external, moved); err != nil {
+		t.Fatalf("MoveWorktree() returned error: %v", err)
+	}
+	if branch, err := GetWorktreeBranch(moved); err != nil || branch != "fix" {
+		t.Errorf("GetWorktreeBranch() of the moved worktree = %q, %v; want %q", branch, err, "fix")
+	}
+}
+
+func TestRepairWorktrees(t *testing.T) {
+	clone := setupDivergedClone(t)
+	dir := filepath.Dir(clone)
+
+	workspace := filepath.Join(dir, "workspace")
+	bare := filepath.Join(workspace, "repo.git")
+	runGit(t, "clone", "--quiet", "--bare", clone, bare)
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", filepath.Join(workspace, "repo", "main"), "main")
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", "-b", "gone", filepath.Join(workspace, "repo", "gone"), "main")
+	if err := os.RemoveAll(filepath.Join(workspace, "repo", "gone")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Moving the workspace breaks the links both ways
+	moved := filepath.Join(dir, "moved")
+	if err := os.Rename(workspace, moved); err != nil {
+		t.Fatal(err)
+	}
+	bare = filepath.Join(moved, "repo.git")
+	worktree := filepath.Join(moved, "repo", "main")
+	if problem := CheckWorktreeLink(worktree); problem == "" {
+		t.Error("CheckWorktreeLink() of a moved worktree = \"\", want a problem")
+	}
+
+	prunable, err := ListPrunableWorktrees(bare)
+	if err != nil {
+		t.Fatalf("ListPrunableWorktrees() returned error: %v", err)
+	}
+	names := map[string]bool{}
+	for _, p := range prunable {
+		names[p.Name] = true
+	}
+	if !names["worktrees/gone"] {
+		t.Errorf("ListPrunableWorktrees() = %v, want worktrees/gone", prunable)
+	}
+
+	repairs, err := RepairWorktrees(bare, []string{worktree})
+	if err != nil {
+		t.Fatalf("RepairWorktrees() returned error: %v", err)
+	}
+	if len(repairs) == 0 {
+		t.Error("RepairWorktrees() reported no repairs")
+	}
+	if problem := CheckWorktreeLink(worktree); problem != "" {
+		t.Errorf("CheckWorktreeLink() after RepairWorktrees() = %q, want \"\"", problem)
+	}
+	if branch, err := GetWorktreeBranch(worktree); err != nil || branch != "main" {
+		t.Errorf("GetWorktreeBranch() of the repaired worktree = %q, %v; want %q", branch, err, "main")
+	}
+}
+
+func TestLastHeadCommit(t *testing.T) {
+	clone := setupDivergedClone(t)
+	dir := filepath.Dir(clone)
+
+	bare := filepath.Join(dir, "repo.git")
+	runGit(t, "clone", "--quiet", "--bare", clone, bare)
+	worktree := filepath.Join(dir, "repo", "gone")
+	runGit(t, "-C", bare, "worktree", "add", "--quiet", "-b", "gone", worktree, "main")
+	runGit(t, "-C", worktree, "commit", "--quiet", "--allow-empty", "-m", "work")
+	want, err := GetHeadCommit(worktree)
+	if err != nil {
+		t.Fatalf("GetHeadCommit() returned error: %v", err)
+	}
+
+	// Deleting the ref leaves the worktree on a branch that doesn't exist
+	runGit(t, "-C", bare, "update-ref", "-d", "refs/heads/gone")
+	if _, err := GetHeadCommit(worktree); err == nil {
+		t.Fatal("GetHeadCommit() of a deleted branch succeeded, want an error")
+	}
+
+	got, err := LastHeadCommit(worktree)
+	if err != nil {
+		t.Fatalf("LastHeadCommit() returned error: %v", err)
+	}
+	if got != want {
+		t.Errorf("LastHeadCommit() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+// ErrLocked is returned by TryAcquire when another process holds the lock
+var ErrLocked = eris.New("lock is held by another process")
+
+// pollInterval is how often Acquire retries a lock held by another process
+const pollInterval = 100 * time.Millisecond
+
+// FileLock is an exclusive lock on a file, shared across processes via flock(2), or LockFileEx on Windows
+// The lock is released automatically by the OS if the process exits
+type FileLock struct {
+	file *os.File
+}
+
+// TryAcquire takes the lock at path without waiting, returning ErrLocked if it is held
+// The lock file and its parent directories are created if needed
+func TryAcquire(path string) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, eris.Wrapf(err, "failed to create lock directory for: %s", path)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open lock file: %s", path)
+	}
+
+	if err := lockFile(file); err != nil {
+		//nolint:errcheck // Close in error path
+		file.Close()
+		if errors.Is(err, ErrLocked) {
+			return nil, ErrLocked
+		}
+		return nil, eris.Wrapf(err, "failed to lock file: %s", path)
+	}
+
+	return &FileLock{file: file}, nil
+}
+
+// Acquire takes the lock at path, waiting up to timeout for another process to release it
+func Acquire(path string, timeout time.Duration) (*FileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := TryAcquire(path)
+		if !errors.Is(err, ErrLocked) {
+			return l, err
+		}
+		if time.Now().After(deadline) {
+			return nil, eris.Wrapf(err, "timed out after %s waiting for lock: %s", timeout, path)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Release unlocks and closes the lock file
+// The file itself is left in place, since removing it could let two processes lock different files
+func (l *FileLock) Release() error {
+	if err := unlockFile(l.file); err != nil {
+		//nolint:errcheck // Close in error path
+		l.file.Close()
+		return eris.Wrap(err, "failed to unlock file")
+	}
+	if err := l.file.Close(); err != nil {
+		return eris.Wrap(err, "failed to close lock file")
+	}
+	return nil
+}
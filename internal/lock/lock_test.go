@@ -0,0 +1,66 @@
+package lock
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTryAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "project.lock")
+
+	first, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("TryAcquire() failed: %v", err)
+	}
+
+	// flock locks belong to the open file, so a second open conflicts even within one process
+	if _, err := TryAcquire(path); !errors.Is(err, ErrLocked) {
+		t.Errorf("TryAcquire() on a held lock = %v, want ErrLocked", err)
+	}
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release() failed: %v", err)
+	}
+
+	second, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("TryAcquire() after release failed: %v", err)
+	}
+	//nolint:errcheck // Test cleanup
+	defer second.Release()
+}
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.lock")
+
+	held, err := TryAcquire(path)
+	if err != nil {
+		t.Fatalf("TryAcquire() failed: %v", err)
+	}
+
+	// Times out while the lock is held
+	start := time.Now()
+	if _, err := Acquire(path, 200*time.Millisecond); err == nil {
+		t.Fatal("Acquire() should time out while the lock is held")
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Acquire() returned after %s, want at least 200ms", elapsed)
+	}
+
+	// Succeeds once the holder releases it
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		//nolint:errcheck // Test helper
+		held.Release()
+	}()
+
+	l, err := Acquire(path, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() failed after release: %v", err)
+	}
+	if err := l.Release(); err != nil {
+		t.Errorf("Release() failed: %v", err)
+	}
+}
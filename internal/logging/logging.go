@@ -0,0 +1,186 @@
+// Package logging sets up the slog logger sesh reports diagnostics with. Records at the
+// configured level are printed to stderr like other messages, and every record can also
+// be appended to a log file, so failures in background work can be looked into later.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/rotisserie/eris"
+)
+
+// DefaultLevel is the level printed to stderr when none is configured
+const DefaultLevel = "warn"
+
+// maxFileSize is the size above which the log file is rotated when opened
+const maxFileSize = 5 << 20
+
+// ParseLevel parses a level name: debug, info, warn, or error
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, eris.Errorf("unknown level: %s (must be one of: debug, info, warn, error)", name)
+	}
+}
+
+// Setup makes the default slog logger print records at level and above to w
+// With a path, every record, including debug ones, is also appended to that file.
+func Setup(w io.Writer, level slog.Level, path string) error {
+	handlers := fanoutHandler{newPrinterHandler(w, level)}
+
+	if path != "" {
+		file, err := openLogFile(path)
+		if err != nil {
+			return err
+		}
+		fileHandler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})
+		// Several sesh processes may write to the file at the same time
+		handlers = append(handlers, fileHandler.WithAttrs([]slog.Attr{slog.Int("pid", os.Getpid())}))
+	}
+
+	slog.SetDefault(slog.New(handlers))
+	return nil
+}
+
+// openLogFile opens the log file for appending, first moving it to <path>.1 when it has
+// grown too large
+func openLogFile(path string) (*os.File, error) {
+	if info, err := os.Stat(path); err == nil && info.Size() > maxFileSize {
+		//nolint:errcheck // A failed rotation only lets the file grow
+		os.Rename(path, path+".1")
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open log file: %s", path)
+	}
+	return file, nil
+}
+
+// printerHandler prints records like other sesh messages, e.g. "⚠ message key=value"
+// The attributes are formatted by a text handler that leaves out the time, level, and message.
+type printerHandler struct {
+	level slog.Level
+	disp  display.Printer
+	mu    *sync.Mutex
+	buf   *bytes.Buffer
+	attrs slog.Handler
+}
+
+func newPrinterHandler(w io.Writer, level slog.Level) *printerHandler {
+	buf := &bytes.Buffer{}
+	return &printerHandler{
+		level: level,
+		disp:  display.New(w),
+		mu:    &sync.Mutex{},
+		buf:   buf,
+		attrs: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 &&
+					(a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+	}
+}
+
+func (h *printerHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *printerHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.attrs.Handle(ctx, r); err != nil {
+		return err
+	}
+	msg := r.Message
+	if attrs := strings.TrimSpace(h.buf.String()); attrs != "" {
+		msg += " " + h.disp.Faint(attrs)
+	}
+
+	switch {
+	case r.Level >= slog.LevelError:
+		h.disp.Error(msg)
+	case r.Level >= slog.LevelWarn:
+		h.disp.Warning(msg)
+	case r.Level >= slog.LevelInfo:
+		h.disp.Info(msg)
+	default:
+		h.disp.Printf("%s %s\n", h.disp.Faint("·"), msg)
+	}
+	return nil
+}
+
+func (h *printerHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = h.attrs.WithAttrs(attrs)
+	return &clone
+}
+
+func (h *printerHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.attrs = h.attrs.WithGroup(name)
+	return &clone
+}
+
+// fanoutHandler passes records on to every handler that is enabled for them
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range f {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
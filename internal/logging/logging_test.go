@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "debug", want: slog.LevelDebug},
+		{name: "info", want: slog.LevelInfo},
+		{name: "warn", want: slog.LevelWarn},
+		{name: "WARNING", want: slog.LevelWarn},
+		{name: "error", want: slog.LevelError},
+		{name: "trace", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLevel(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseLevel(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defaultLogger := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	stderr := &bytes.Buffer{}
+	path := filepath.Join(t.TempDir(), "sesh.log")
+	if err := Setup(stderr, slog.LevelWarn, path); err != nil {
+		t.Fatalf("Setup() failed: %v", err)
+	}
+
+	slog.Debug("fetching in background", "project", "github.com/user/repo")
+	slog.With("session", "repo-main").Warn("failed to run startup command", "error", "no server")
+
+	// Only records at the level are printed, without the time or level keys
+	printed := stderr.String()
+	if strings.Contains(printed, "fetching in background") {
+		t.Errorf("stderr = %q, should not contain the debug record", printed)
+	}
+	want := "⚠ failed to run startup command session=repo-main error=\"no server\"\n"
+	if printed != want {
+		t.Errorf("stderr = %q, want %q", printed, want)
+	}
+
+	// The file gets every record
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	for _, want := range []string{
+		`level=DEBUG msg="fetching in background"`,
+		`level=WARN msg="failed to run startup command"`,
+		"session=repo-main",
+		"pid=",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log file = %q, want to contain %q", data, want)
+		}
+	}
+}
+
+func TestOpenLogFileRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sesh.log")
+	if err := os.WriteFile(path, bytes.Repeat([]byte("x"), maxFileSize+1), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	file, err := openLogFile(path)
+	if err != nil {
+		t.Fatalf("openLogFile() failed: %v", err)
+	}
+	//nolint:errcheck // Test cleanup
+	defer file.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("log file should start empty after rotation: %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("rotated log file is missing: %v", err)
+	}
+}
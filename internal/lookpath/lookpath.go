@@ -0,0 +1,60 @@
+// Package lookpath finds the executables of the tools sesh runs, like fzf and gh
+package lookpath
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Find returns the path of the executable name on the PATH. On Windows, it also looks in the
+// shim directories of Scoop and Chocolatey, which processes started before the install, or
+// outside of a shell, may not have on their PATH.
+func Find(name string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err == nil || runtime.GOOS != "windows" {
+		return path, err
+	}
+
+	for _, dir := range packageManagerDirs(os.Getenv) {
+		candidate := filepath.Join(dir, name+".exe")
+		if info, statErr := os.Stat(candidate); statErr == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", err
+}
+
+// Resolve returns the path of the executable name, or name itself when it isn't found, so
+// running it fails the usual way
+func Resolve(name string) string {
+	if path, err := Find(name); err == nil {
+		return path
+	}
+	return name
+}
+
+// packageManagerDirs returns the directories Scoop and Chocolatey put the shims of the tools
+// they install in, for the user and machine-wide
+func packageManagerDirs(getenv func(string) string) []string {
+	var dirs []string
+	if scoop := getenv("SCOOP"); scoop != "" {
+		dirs = append(dirs, filepath.Join(scoop, "shims"))
+	} else if profile := getenv("USERPROFILE"); profile != "" {
+		dirs = append(dirs, filepath.Join(profile, "scoop", "shims"))
+	}
+
+	programData := getenv("ProgramData")
+	if global := getenv("SCOOP_GLOBAL"); global != "" {
+		dirs = append(dirs, filepath.Join(global, "shims"))
+	} else if programData != "" {
+		dirs = append(dirs, filepath.Join(programData, "scoop", "shims"))
+	}
+	if choco := getenv("ChocolateyInstall"); choco != "" {
+		dirs = append(dirs, filepath.Join(choco, "bin"))
+	} else if programData != "" {
+		dirs = append(dirs, filepath.Join(programData, "chocolatey", "bin"))
+	}
+	return dirs
+}
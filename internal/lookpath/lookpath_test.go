@@ -0,0 +1,53 @@
+package lookpath
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPackageManagerDirs(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"USERPROFILE": "home", "ProgramData": "data"},
+			want: []string{
+				filepath.Join("home", "scoop", "shims"),
+				filepath.Join("data", "scoop", "shims"),
+				filepath.Join("data", "chocolatey", "bin"),
+			},
+		},
+		{
+			name: "custom install directories",
+			env: map[string]string{
+				"USERPROFILE":       "home",
+				"ProgramData":       "data",
+				"SCOOP":             "scoop",
+				"SCOOP_GLOBAL":      "global",
+				"ChocolateyInstall": "choco",
+			},
+			want: []string{
+				filepath.Join("scoop", "shims"),
+				filepath.Join("global", "shims"),
+				filepath.Join("choco", "bin"),
+			},
+		},
+		{
+			name: "no environment",
+			env:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := packageManagerDirs(func(key string) string { return tt.env[key] })
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("packageManagerDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package manifest reads and writes workspace manifests, which list the projects of a workspace
+// so they can be cloned again on another machine with 'sesh bootstrap'
+package manifest
+
+import (
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/rotisserie/eris"
+	"gopkg.in/yaml.v3"
+)
+
+// remoteNamePattern limits remote names to the usual ones, which git can't take for options
+var remoteNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Version is the current version of the manifest format
+const Version = "1"
+
+// Manifest is the list of the projects of a workspace
+type Manifest struct {
+	Version  string    `yaml:"version"`
+	Projects []Project `yaml:"projects"`
+}
+
+// Project is a project of a manifest
+type Project struct {
+	// Name is the project's name in the workspace, which is derived from the URL of origin
+	Name string `yaml:"name"`
+	// Workspace is the name of the workspace the project is in, empty for the primary one
+	Workspace string `yaml:"workspace,omitempty"`
+	// Remotes is the URL of each remote, by remote name; origin is the one cloned
+	Remotes map[string]string `yaml:"remotes"`
+	// Branches are those with a worktree, which are created again besides the default branch's
+	Branches []string `yaml:"branches,omitempty"`
+}
+
+// Read reads and validates a manifest
+func Read(r io.Reader) (*Manifest, error) {
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+
+	var m Manifest
+	if err := decoder.Decode(&m); err != nil {
+		if err == io.EOF {
+			return nil, eris.New("the manifest is empty")
+		}
+		return nil, eris.Wrap(err, "failed to parse manifest")
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Write writes a manifest as YAML
+func Write(w io.Writer, m *Manifest) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return eris.Wrap(err, "failed to write manifest")
+	}
+	return eris.Wrap(encoder.Close(), "failed to write manifest")
+}
+
+// Validate checks the version of a manifest and that each project can be cloned under its name.
+// A name that doesn't match origin is refused rather than trusted, since names are paths in
+// the workspace.
+func (m *Manifest) Validate() error {
+	if m.Version != Version {
+		return eris.Errorf("unsupported manifest version: %q (must be %q)", m.Version, Version)
+	}
+
+	seen := make(map[string]bool, len(m.Projects))
+	for i, project := range m.Projects {
+		origin := project.Remotes["origin"]
+		if origin == "" {
+			return eris.Errorf("project %d (%s) has no origin remote", i+1, project.Name)
+		}
+		name, err := git.GenerateProjectName(origin)
+		if err != nil {
+			return eris.Wrapf(err, "invalid origin of project %s", project.Name)
+		}
+		if project.Name != name {
+			return eris.Errorf("project %s doesn't match its origin %s (expected name: %s)", project.Name, origin, name)
+		}
+		if seen[name] {
+			return eris.Errorf("project %s is listed twice", name)
+		}
+		seen[name] = true
+
+		for remote, url := range project.Remotes {
+			if !remoteNamePattern.MatchString(remote) || strings.HasPrefix(url, "-") {
+				return eris.Errorf("invalid remote of project %s: %s %s", name, remote, url)
+			}
+		}
+
+		for _, branch := range project.Branches {
+			if err := git.CheckBranchName(branch); err != nil {
+				return eris.Wrapf(err, "invalid branch of project %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// OtherRemotes returns the names of the remotes of a project other than origin, sorted
+func (p Project) OtherRemotes() []string {
+	names := slices.Sorted(maps.Keys(p.Remotes))
+	return slices.DeleteFunc(names, func(name string) bool { return name == "origin" })
+}
@@ -0,0 +1,88 @@
+package manifest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	m := &Manifest{
+		Version: Version,
+		Projects: []Project{
+			{
+				Name:     "github.com/acme/api",
+				Remotes:  map[string]string{"origin": "git@github.com:acme/api.git"},
+				Branches: []string{"feature/login"},
+			},
+			{
+				Name:      "github.com/me/fork",
+				Workspace: "oss",
+				Remotes: map[string]string{
+					"origin":   "https://github.com/me/fork.git",
+					"upstream": "https://github.com/them/fork.git",
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Read() = %+v, want %+v", got, m)
+	}
+	if remotes := got.Projects[1].OtherRemotes(); !reflect.DeepEqual(remotes, []string{"upstream"}) {
+		t.Errorf("OtherRemotes() = %v, want [upstream]", remotes)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{name: "empty", manifest: "", wantErr: "empty"},
+		{name: "version", manifest: "version: \"2\"\n", wantErr: "unsupported manifest version"},
+		{name: "unknown field", manifest: "version: \"1\"\nproject: []\n", wantErr: "failed to parse"},
+		{
+			name:     "no origin",
+			manifest: "version: \"1\"\nprojects:\n  - name: github.com/acme/api\n    remotes: {}\n",
+			wantErr:  "no origin remote",
+		},
+		{
+			name: "name not matching origin",
+			manifest: "version: \"1\"\nprojects:\n  - name: ../../etc\n" +
+				"    remotes: {origin: \"git@github.com:acme/api.git\"}\n",
+			wantErr: "doesn't match its origin",
+		},
+		{
+			name: "option as remote",
+			manifest: "version: \"1\"\nprojects:\n  - name: github.com/acme/api\n" +
+				"    remotes: {origin: \"git@github.com:acme/api.git\", --mirror: x}\n",
+			wantErr: "invalid remote",
+		},
+		{
+			name: "invalid branch",
+			manifest: "version: \"1\"\nprojects:\n  - name: github.com/acme/api\n" +
+				"    remotes: {origin: \"git@github.com:acme/api.git\"}\n    branches: [\"a b\"]\n",
+			wantErr: "invalid branch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.manifest))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -47,3 +47,35 @@ type SessionHistory struct {
 	Branch      string    `json:"branch"`       // Branch name for reference
 	AccessedAt  time.Time `json:"accessed_at"`  // When the session was accessed
 }
+
+// PinnedSession represents a session pinned with 'sesh pin', which is listed before the others
+type PinnedSession struct {
+	SessionName string    `json:"session_name"` // Name of the session (e.g., "repo-branch")
+	ProjectName string    `json:"project_name"` // Project of the pinned branch
+	Branch      string    `json:"branch"`       // Pinned branch
+	PinnedAt    time.Time `json:"pinned_at"`    // When the session was pinned
+}
+
+// TrashEntry represents a worktree that was moved to the workspace trash (for undo and restore)
+type TrashEntry struct {
+	ID           int       `json:"id"`
+	OperationID  string    `json:"operation_id"`  // Groups entries removed by the same command
+	ProjectName  string    `json:"project_name"`  // Project the worktree belonged to
+	Branch       string    `json:"branch"`        // Branch checked out in the worktree
+	CommitSHA    string    `json:"commit_sha"`    // HEAD commit at the time of removal
+	OriginalPath string    `json:"original_path"` // Where the worktree lived before removal
+	TrashPath    string    `json:"trash_path"`    // Where the worktree contents were moved
+	TrashedAt    time.Time `json:"trashed_at"`    // When the worktree was moved to the trash
+}
+
+// OperationLogEntry represents a mutating operation recorded in the audit log
+type OperationLogEntry struct {
+	ID          int       `json:"id"`
+	Operation   string    `json:"operation"`       // e.g., "clone", "create-worktree", "remove-worktree"
+	ProjectName string    `json:"project_name"`    // Project the operation applied to
+	Branch      string    `json:"branch"`          // Branch the operation applied to (if any)
+	Args        string    `json:"args"`            // Command line the operation was run from
+	Outcome     string    `json:"outcome"`         // "ok" or "failed"
+	Error       string    `json:"error,omitempty"` // Error message when the operation failed
+	CreatedAt   time.Time `json:"created_at"`      // When the operation happened
+}
@@ -0,0 +1,72 @@
+package pr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/atomicfile"
+	"github.com/rotisserie/eris"
+)
+
+// CacheMaxAge is how long cached open pull requests are used before they are listed again
+const CacheMaxAge = 15 * time.Minute
+
+// prCache is the on-disk form of a repository's cached open pull requests
+type prCache struct {
+	RepoPath  string         `json:"repo_path"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	PRs       []*PullRequest `json:"prs"`
+}
+
+// cachePath returns the file the open pull requests of a repository are cached in
+func cachePath(cacheDir, repoPath string) string {
+	sum := sha256.Sum256([]byte(repoPath))
+	return filepath.Join(cacheDir, "prs", hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadCachedOpenPRs returns the cached open pull requests for a repository
+// Returns false if nothing is cached or the cache is older than maxAge
+func LoadCachedOpenPRs(cacheDir, repoPath string, maxAge time.Duration) ([]*PullRequest, bool) {
+	data, err := os.ReadFile(cachePath(cacheDir, repoPath))
+	if err != nil {
+		return nil, false
+	}
+
+	var cache prCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.RepoPath != repoPath {
+		return nil, false
+	}
+	if time.Since(cache.UpdatedAt) > maxAge {
+		return nil, false
+	}
+
+	return cache.PRs, true
+}
+
+// SaveCachedOpenPRs caches the open pull requests of a repository
+func SaveCachedOpenPRs(cacheDir, repoPath string, prs []*PullRequest) error {
+	path := cachePath(cacheDir, repoPath)
+
+	data, err := json.Marshal(prCache{
+		RepoPath:  repoPath,
+		UpdatedAt: time.Now(),
+		PRs:       prs,
+	})
+	if err != nil {
+		return eris.Wrap(err, "failed to encode pull request cache")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return eris.Wrapf(err, "failed to create cache directory: %s", filepath.Dir(path))
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0o644); err != nil {
+		return eris.Wrap(err, "failed to write pull request cache")
+	}
+
+	return nil
+}
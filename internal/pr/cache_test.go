@@ -0,0 +1,71 @@
+package pr
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOpenPRCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	repoPath := "/workspace/github.com/user/repo.git"
+
+	if _, ok := LoadCachedOpenPRs(cacheDir, repoPath, CacheMaxAge); ok {
+		t.Fatal("LoadCachedOpenPRs() hit an empty cache")
+	}
+
+	prs := []*PullRequest{
+		{Number: 1, Title: "Add feature", Branch: "feature", State: "open"},
+		{Number: 2, Title: "Fix bug", Branch: "fix", State: "open"},
+	}
+	if err := SaveCachedOpenPRs(cacheDir, repoPath, prs); err != nil {
+		t.Fatalf("SaveCachedOpenPRs() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		repoPath string
+		maxAge   time.Duration
+		wantHit  bool
+	}{
+		{name: "fresh", repoPath: repoPath, maxAge: CacheMaxAge, wantHit: true},
+		{name: "expired", repoPath: repoPath, maxAge: 0, wantHit: false},
+		{name: "other repository", repoPath: "/workspace/github.com/user/other.git", maxAge: CacheMaxAge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := LoadCachedOpenPRs(cacheDir, tt.repoPath, tt.maxAge)
+			if ok != tt.wantHit {
+				t.Fatalf("LoadCachedOpenPRs() hit = %v, want %v", ok, tt.wantHit)
+			}
+			if !ok {
+				return
+			}
+			if len(got) != len(prs) {
+				t.Fatalf("LoadCachedOpenPRs() returned %d PRs, want %d", len(got), len(prs))
+			}
+			for i := range prs {
+				if got[i].Number != prs[i].Number || got[i].Branch != prs[i].Branch {
+					t.Errorf("PR %d = #%d %s, want #%d %s", i, got[i].Number, got[i].Branch, prs[i].Number, prs[i].Branch)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCachedOpenPRs_Corrupt(t *testing.T) {
+	cacheDir := t.TempDir()
+	repoPath := "/workspace/github.com/user/repo.git"
+
+	if err := SaveCachedOpenPRs(cacheDir, repoPath, nil); err != nil {
+		t.Fatalf("SaveCachedOpenPRs() failed: %v", err)
+	}
+	if err := os.WriteFile(cachePath(cacheDir, repoPath), []byte("{not json"), 0o644); err != nil {
+		t.Fatalf("Failed to corrupt cache: %v", err)
+	}
+
+	if _, ok := LoadCachedOpenPRs(cacheDir, repoPath, CacheMaxAge); ok {
+		t.Error("LoadCachedOpenPRs() hit a corrupt cache")
+	}
+}
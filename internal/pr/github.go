@@ -9,15 +9,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/benoctopus/sesh/internal/lookpath"
 	"github.com/rotisserie/eris"
 )
 
 // GitHubProvider implements the Provider interface for GitHub
-type GitHubProvider struct{}
+type GitHubProvider struct {
+	host string // Host of the repository, e.g. github.com
+}
 
-// NewGitHubProvider creates a new GitHub provider instance
-func NewGitHubProvider() *GitHubProvider {
-	return &GitHubProvider{}
+// NewGitHubProvider creates a new GitHub provider instance for the repositories of a host
+func NewGitHubProvider(host string) *GitHubProvider {
+	return &GitHubProvider{host: host}
 }
 
 // Name returns the provider name
@@ -30,6 +33,7 @@ type ghPullRequest struct {
 	Number      int    `json:"number"`
 	Title       string `json:"title"`
 	HeadRefName string `json:"headRefName"`
+	HeadRefOid  string `json:"headRefOid"`
 	BaseRefName string `json:"baseRefName"`
 	Author      struct {
 		Login string `json:"login"`
@@ -44,17 +48,29 @@ type ghPullRequest struct {
 	} `json:"labels"`
 }
 
+// mergedPRLimit is how many of the most recently merged pull requests ListMergedPRs lists, which
+// covers the branches of a busy repository that still have worktrees
+const mergedPRLimit = 200
+
+// ghPRFields are the fields of the pull requests gh lists and views
+const ghPRFields = "number,title,headRefName,headRefOid,baseRefName,author,state,url,createdAt,updatedAt,body,labels"
+
 // ListOpenPRs lists all open pull requests for the repository
 func (g *GitHubProvider) ListOpenPRs(ctx context.Context, repoPath string) ([]*PullRequest, error) {
-	// Use gh CLI to list open PRs
-	// gh pr list --json number,title,headRefName,baseRefName,author,state,url,createdAt,updatedAt,body,labels
-	cmd := exec.CommandContext(
-		ctx,
-		"gh", "pr", "list",
-		"--json", "number,title,headRefName,baseRefName,author,state,url,createdAt,updatedAt,body,labels",
-		"--state", "open",
-	)
+	return g.listPRs(ctx, repoPath, "--state", "open")
+}
+
+// ListMergedPRs lists recently merged pull requests for the repository
+func (g *GitHubProvider) ListMergedPRs(ctx context.Context, repoPath string) ([]*PullRequest, error) {
+	return g.listPRs(ctx, repoPath, "--state", "merged", "--limit", strconv.Itoa(mergedPRLimit))
+}
+
+// listPRs lists pull requests using the gh CLI, filtered by the given gh pr list flags
+func (g *GitHubProvider) listPRs(ctx context.Context, repoPath string, flags ...string) ([]*PullRequest, error) {
+	args := append([]string{"pr", "list", "--json", ghPRFields}, flags...)
+	cmd := exec.CommandContext(ctx, lookpath.Resolve("gh"), args...)
 	cmd.Dir = repoPath
+	cmd.Env = ghEnv(g.host)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -76,24 +92,7 @@ func (g *GitHubProvider) ListOpenPRs(ctx context.Context, repoPath string) ([]*P
 	// Convert to our PullRequest type
 	prs := make([]*PullRequest, len(ghPRs))
 	for i, ghPR := range ghPRs {
-		labels := make([]string, len(ghPR.Labels))
-		for j, label := range ghPR.Labels {
-			labels[j] = label.Name
-		}
-
-		prs[i] = &PullRequest{
-			Number:      ghPR.Number,
-			Title:       ghPR.Title,
-			Branch:      ghPR.HeadRefName,
-			BaseBranch:  ghPR.BaseRefName,
-			Author:      ghPR.Author.Login,
-			State:       strings.ToLower(ghPR.State),
-			URL:         ghPR.URL,
-			CreatedAt:   ghPR.CreatedAt,
-			UpdatedAt:   ghPR.UpdatedAt,
-			Description: ghPR.Body,
-			Labels:      labels,
-		}
+		prs[i] = ghPR.toPullRequest()
 	}
 
 	return prs, nil
@@ -104,10 +103,11 @@ func (g *GitHubProvider) GetPR(ctx context.Context, repoPath string, number int)
 	// Use gh CLI to view a specific PR
 	cmd := exec.CommandContext(
 		ctx,
-		"gh", "pr", "view", strconv.Itoa(number),
-		"--json", "number,title,headRefName,baseRefName,author,state,url,createdAt,updatedAt,body,labels",
+		lookpath.Resolve("gh"), "pr", "view", strconv.Itoa(number),
+		"--json", ghPRFields,
 	)
 	cmd.Dir = repoPath
+	cmd.Env = ghEnv(g.host)
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -126,24 +126,30 @@ func (g *GitHubProvider) GetPR(ctx context.Context, repoPath string, number int)
 		return nil, eris.Wrap(err, "failed to parse gh output")
 	}
 
-	labels := make([]string, len(ghPR.Labels))
-	for i, label := range ghPR.Labels {
+	return ghPR.toPullRequest(), nil
+}
+
+// toPullRequest converts the gh CLI representation into a PullRequest
+func (p ghPullRequest) toPullRequest() *PullRequest {
+	labels := make([]string, len(p.Labels))
+	for i, label := range p.Labels {
 		labels[i] = label.Name
 	}
 
 	return &PullRequest{
-		Number:      ghPR.Number,
-		Title:       ghPR.Title,
-		Branch:      ghPR.HeadRefName,
-		BaseBranch:  ghPR.BaseRefName,
-		Author:      ghPR.Author.Login,
-		State:       strings.ToLower(ghPR.State),
-		URL:         ghPR.URL,
-		CreatedAt:   ghPR.CreatedAt,
-		UpdatedAt:   ghPR.UpdatedAt,
-		Description: ghPR.Body,
+		Number:      p.Number,
+		Title:       p.Title,
+		Branch:      p.HeadRefName,
+		HeadSHA:     p.HeadRefOid,
+		BaseBranch:  p.BaseRefName,
+		Author:      p.Author.Login,
+		State:       strings.ToLower(p.State),
+		URL:         p.URL,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+		Description: p.Body,
 		Labels:      labels,
-	}, nil
+	}
 }
 
 // GetPRBranch returns the branch name for a given PR number
@@ -155,16 +161,22 @@ func (g *GitHubProvider) GetPRBranch(ctx context.Context, repoPath string, numbe
 	return pr.Branch, nil
 }
 
-// CheckGHCLI checks if the gh CLI is installed and authenticated
-func CheckGHCLI() error {
+// CheckGHCLI checks if the gh CLI is installed and authenticated with the host of a remote URL
+func CheckGHCLI(remoteURL string) error {
 	// Check if gh is installed
-	cmd := exec.Command("gh", "--version")
-	if err := cmd.Run(); err != nil {
+	gh, err := lookpath.Find("gh")
+	if err != nil {
 		return eris.New("gh CLI not found. Install it from https://cli.github.com/")
 	}
 
 	// Check if gh is authenticated
-	cmd = exec.Command("gh", "auth", "status")
+	host := remoteHost(remoteURL)
+	args := []string{"auth", "status"}
+	if host != "" {
+		args = append(args, "--hostname", host)
+	}
+	cmd := exec.Command(gh, args...)
+	cmd.Env = ghEnv(host)
 	if err := cmd.Run(); err != nil {
 		return eris.New("gh CLI not authenticated. Run 'gh auth login' to authenticate")
 	}
@@ -0,0 +1,168 @@
+package pr
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/lookpath"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// Issue represents an issue from any provider
+type Issue struct {
+	Number int      `json:"number"`
+	Title  string   `json:"title"`
+	URL    string   `json:"url"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// DefaultIssueBranchTemplate names the branch of issue 452 "Fix login" feat/452-fix-login
+const DefaultIssueBranchTemplate = "{type}/{number}-{title}"
+
+// issueBranchPlaceholders are the placeholders of issue branch templates
+var issueBranchPlaceholders = []string{"{type}", "{number}", "{title}"}
+
+// GetIssue retrieves an issue of the repository by number, with the gh CLI for GitHub
+// and the glab CLI for GitLab
+func GetIssue(ctx context.Context, remoteURL, repoPath string, number int) (*Issue, error) {
+	switch DetectProvider(remoteURL) {
+	case ProviderTypeGitHub:
+		if err := CheckGHCLI(remoteURL); err != nil {
+			return nil, err
+		}
+		return NewGitHubProvider(remoteHost(remoteURL)).GetIssue(ctx, repoPath, number)
+	case ProviderTypeGitLab:
+		return getGitLabIssue(ctx, remoteHost(remoteURL), repoPath, number)
+	default:
+		return nil, eris.Errorf("unsupported git provider for URL: %s", remoteURL)
+	}
+}
+
+// GetIssue retrieves a specific issue by number
+func (g *GitHubProvider) GetIssue(ctx context.Context, repoPath string, number int) (*Issue, error) {
+	cmd := exec.CommandContext(
+		ctx,
+		lookpath.Resolve("gh"), "issue", "view", strconv.Itoa(number),
+		"--json", "number,title,url,labels",
+	)
+	cmd.Dir = repoPath
+	cmd.Env = ghEnv(g.host)
+
+	output, err := runIssueCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var ghIssue struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		URL    string `json:"url"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	if err := json.Unmarshal(output, &ghIssue); err != nil {
+		return nil, eris.Wrap(err, "failed to parse gh output")
+	}
+
+	issue := &Issue{Number: ghIssue.Number, Title: ghIssue.Title, URL: ghIssue.URL}
+	for _, label := range ghIssue.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	return issue, nil
+}
+
+// getGitLabIssue retrieves a specific issue by number from a GitLab host using the glab CLI
+func getGitLabIssue(ctx context.Context, host, repoPath string, number int) (*Issue, error) {
+	glab, err := lookpath.Find("glab")
+	if err != nil {
+		return nil, eris.New("glab CLI not found. Install it from https://gitlab.com/gitlab-org/cli")
+	}
+
+	cmd := exec.CommandContext(ctx, glab, "issue", "view", strconv.Itoa(number), "--output", "json")
+	cmd.Dir = repoPath
+	cmd.Env = glabEnv(host)
+
+	output, err := runIssueCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// GitLab numbers issues per project with iid; id is unique across the instance
+	var glIssue struct {
+		IID    int      `json:"iid"`
+		Title  string   `json:"title"`
+		WebURL string   `json:"web_url"`
+		Labels []string `json:"labels"`
+	}
+	if err := json.Unmarshal(output, &glIssue); err != nil {
+		return nil, eris.Wrap(err, "failed to parse glab output")
+	}
+
+	return &Issue{Number: glIssue.IID, Title: glIssue.Title, URL: glIssue.WebURL, Labels: glIssue.Labels}, nil
+}
+
+// runIssueCommand runs a provider CLI, with its stderr in the error when it fails
+func runIssueCommand(cmd *exec.Cmd) ([]byte, error) {
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, eris.Wrapf(err, "%s command failed: %s", cmd.Args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, eris.Wrapf(err, "failed to execute %s command", cmd.Args[0])
+	}
+	return output, nil
+}
+
+// ValidateIssueBranchTemplate checks that a template only uses known placeholders and
+// tells apart the branches of different issues
+func ValidateIssueBranchTemplate(template string) error {
+	rest := template
+	for _, placeholder := range issueBranchPlaceholders {
+		rest = strings.ReplaceAll(rest, placeholder, "")
+	}
+	if i := strings.Index(rest, "{"); i >= 0 {
+		end := strings.Index(rest[i:], "}")
+		if end < 0 {
+			end = len(rest) - i - 1
+		}
+		return eris.Errorf(
+			"unknown placeholder: %s (must be one of: %s)",
+			rest[i:i+end+1], strings.Join(issueBranchPlaceholders, ", "),
+		)
+	}
+	if !strings.Contains(template, "{number}") {
+		return eris.New("the template must contain {number}")
+	}
+	return nil
+}
+
+// IssueBranchName fills in the placeholders of an issue branch template: {number}, {title}
+// as a slug like "fix-login", and {type}, which is "fix" for issues labeled as bugs and
+// "feat" for the others
+func IssueBranchName(template string, issue *Issue) string {
+	if template == "" {
+		template = DefaultIssueBranchTemplate
+	}
+
+	issueType := "feat"
+	for _, label := range issue.Labels {
+		if strings.Contains(strings.ToLower(label), "bug") {
+			issueType = "fix"
+			break
+		}
+	}
+
+	name := strings.NewReplacer(
+		"{type}", issueType,
+		"{number}", strconv.Itoa(issue.Number),
+		"{title}", workspace.Slugify(issue.Title),
+	).Replace(template)
+
+	// An empty slug mustn't leave a dangling separator, e.g. "feat/452-"
+	return strings.TrimRight(name, "-_/.")
+}
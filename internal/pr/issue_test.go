@@ -0,0 +1,73 @@
+package pr
+
+import "testing"
+
+func TestIssueBranchName(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		issue    *Issue
+		want     string
+	}{
+		{
+			name:  "default template",
+			issue: &Issue{Number: 452, Title: "Fix login"},
+			want:  "feat/452-fix-login",
+		},
+		{
+			name:  "bug label",
+			issue: &Issue{Number: 452, Title: "Login fails", Labels: []string{"Type: Bug"}},
+			want:  "fix/452-login-fails",
+		},
+		{
+			name:     "custom template",
+			template: "issue-{number}",
+			issue:    &Issue{Number: 7, Title: "Anything"},
+			want:     "issue-7",
+		},
+		{
+			name:  "punctuation and non-ASCII",
+			issue: &Issue{Number: 12, Title: "Crash when “sesh switch” runs: don't panic!"},
+			want:  "feat/12-crash-when-sesh-switch-runs-don-t-panic",
+		},
+		{
+			name:  "long title cut at a word",
+			issue: &Issue{Number: 3, Title: "Support switching between many projects with one keystroke in tmux"},
+			want:  "feat/3-support-switching-between-many-projects",
+		},
+		{
+			name:  "title without words",
+			issue: &Issue{Number: 5, Title: "???"},
+			want:  "feat/5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IssueBranchName(tt.template, tt.issue); got != tt.want {
+				t.Errorf("IssueBranchName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIssueBranchTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{template: DefaultIssueBranchTemplate},
+		{template: "{number}"},
+		{template: "{type}/{title}", wantErr: true},
+		{template: "{number}-{slug}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			err := ValidateIssueBranchTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIssueBranchTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
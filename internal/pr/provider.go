@@ -12,6 +12,7 @@ type PullRequest struct {
 	Number      int       `json:"number"`
 	Title       string    `json:"title"`
 	Branch      string    `json:"branch"`      // Head branch name
+	HeadSHA     string    `json:"head_sha"`    // Commit at the tip of the head branch
 	BaseBranch  string    `json:"base_branch"` // Base/target branch
 	Author      string    `json:"author"`
 	State       string    `json:"state"` // open, closed, merged
@@ -30,6 +31,9 @@ type Provider interface {
 	// ListOpenPRs lists all open pull requests for the repository
 	ListOpenPRs(ctx context.Context, repoPath string) ([]*PullRequest, error)
 
+	// ListMergedPRs lists recently merged pull requests for the repository
+	ListMergedPRs(ctx context.Context, repoPath string) ([]*PullRequest, error)
+
 	// GetPR retrieves a specific pull request by number
 	GetPR(ctx context.Context, repoPath string, number int) (*PullRequest, error)
 
@@ -67,7 +71,7 @@ func NewProvider(remoteURL string) (Provider, error) {
 
 	switch providerType {
 	case ProviderTypeGitHub:
-		return NewGitHubProvider(), nil
+		return NewGitHubProvider(remoteHost(remoteURL)), nil
 	case ProviderTypeGitLab:
 		return nil, eris.New("GitLab provider not yet implemented")
 	default:
@@ -0,0 +1,72 @@
+package pr
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// tokenSource returns the API token of a git host, or "" to leave authentication to the CLI of
+// its provider
+var tokenSource = func(host string) string { return "" }
+
+// SetTokenSource sets where the API tokens of git hosts come from, like the keyring
+func SetTokenSource(source func(host string) string) {
+	tokenSource = source
+}
+
+// cliEnv returns the environment to run the CLI of a provider in for a git host: the host in
+// hostVar, so the CLI talks to it even where it can't tell from the repository, and the token of
+// the host in tokenVar when there is one. Returns nil to inherit the environment as is when the
+// host is unknown.
+func cliEnv(host, hostVar, tokenVar string) []string {
+	if host == "" {
+		return nil
+	}
+	env := append(os.Environ(), hostVar+"="+host)
+	if token := tokenSource(host); token != "" {
+		env = append(env, tokenVar+"="+token)
+	}
+	return env
+}
+
+// ghEnv returns the environment to run gh in for a GitHub host. gh only reads GH_TOKEN for
+// github.com and GHE.com tenants, and GH_ENTERPRISE_TOKEN for GitHub Enterprise Server.
+func ghEnv(host string) []string {
+	tokenVar := "GH_ENTERPRISE_TOKEN"
+	if host == "github.com" || strings.HasSuffix(host, ".ghe.com") {
+		tokenVar = "GH_TOKEN"
+	}
+	return cliEnv(host, "GH_HOST", tokenVar)
+}
+
+// glabEnv returns the environment to run glab in for a GitLab host
+func glabEnv(host string) []string {
+	return cliEnv(host, "GITLAB_HOST", "GITLAB_TOKEN")
+}
+
+// remoteHost returns the host name of a git remote URL, without the user or port, or "" when it
+// isn't a URL or SCP-style address
+// Examples:
+//   - git@github.com:user/repo.git -> github.com
+//   - ssh://git@gitlab.example.com:2222/user/repo.git -> gitlab.example.com
+//   - https://github.com/user/repo.git -> github.com
+func remoteHost(remoteURL string) string {
+	if strings.Contains(remoteURL, "://") {
+		parsed, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		return parsed.Hostname()
+	}
+
+	// SCP-style addresses: [user@]host:path
+	host, _, found := strings.Cut(remoteURL, ":")
+	if !found || strings.Contains(host, "/") {
+		return ""
+	}
+	if _, after, ok := strings.Cut(host, "@"); ok {
+		host = after
+	}
+	return host
+}
@@ -0,0 +1,77 @@
+package prepare
+
+import (
+	"context"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/zoxide"
+)
+
+// UpdateSubmodules checks out the submodules of a new worktree unless they are turned off
+// Failures are only warnings, since the worktree itself was created.
+func UpdateSubmodules(ctx context.Context, worktreePath string, disp display.Printer) {
+	if !git.HasSubmodules(worktreePath) {
+		return
+	}
+	// The project's .sesh.yaml is read from the new checkout
+	enabled, err := config.GetSubmodules(worktreePath)
+	if err != nil {
+		disp.Warning(err.Error())
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	disp.Printf("%s Updating submodules\n", disp.InfoText("↻"))
+	if err := git.UpdateSubmodules(ctx, worktreePath); err != nil {
+		disp.Warningf("%s (run 'git submodule update --init --recursive' in %s to retry)", err.Error(), worktreePath)
+	}
+}
+
+// PullLFS downloads the Git LFS files of a new worktree when the project opted in
+// Without the opt-in, a hint explains why files are pointers if git-lfs isn't set up to
+// replace them. Failures are only warnings, since the worktree itself was created.
+func PullLFS(ctx context.Context, worktreePath string, disp display.Printer) {
+	if !git.UsesLFS(worktreePath) {
+		return
+	}
+	// The project's .sesh.yaml is read from the new checkout
+	enabled, err := config.GetLFS(worktreePath)
+	if err != nil {
+		disp.Warning(err.Error())
+		return
+	}
+	if !enabled {
+		if !git.IsLFSConfigured(worktreePath) {
+			disp.Warning("this project uses Git LFS, so its large files are pointers (set lfs: true in .sesh.yaml or the config file to download them)")
+		}
+		return
+	}
+	if !git.IsLFSInstalled() {
+		disp.Warning("this project uses Git LFS, but git-lfs is not installed")
+		return
+	}
+
+	disp.Printf("%s Downloading Git LFS files\n", disp.InfoText("⬇"))
+	if err := git.PullLFS(ctx, worktreePath); err != nil {
+		disp.Warningf("%s (run 'git lfs pull' in %s to retry)", err.Error(), worktreePath)
+	}
+}
+
+// ZoxideAdd registers a worktree with zoxide when the integration is enabled
+// Failures are only warnings, since zoxide is a convenience.
+func ZoxideAdd(cfg *config.Config, path string, disp display.Printer) {
+	if !cfg.Zoxide {
+		return
+	}
+	if !zoxide.IsInstalled() {
+		disp.Warning("zoxide integration is enabled, but zoxide is not installed")
+		return
+	}
+	if err := zoxide.Add(path); err != nil {
+		disp.Warning(err.Error())
+	}
+}
@@ -0,0 +1,247 @@
+// Package prepare creates the worktrees and sessions of branches. The sesh CLI and pkg/sesh both
+// use it, so a worktree or session is set up the same way whichever of them creates it.
+package prepare
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// operationLogRetentionDays is how long audit log entries are kept
+const operationLogRetentionDays = 90
+
+// Options configure a Preparer
+type Options struct {
+	Config *config.Config
+	// Sessions creates the sessions; it isn't needed to only create worktrees
+	Sessions session.SessionManager
+	// Display receives the progress messages, e.g. "Creating worktree..."
+	Display display.Printer
+	// Progress receives the progress bars of slow steps, like starting a dev container
+	// Nil discards them.
+	Progress io.Writer
+	// OpenDB opens the database keeping the ports of worktrees and the operation log
+	// Nil opens the sesh database.
+	OpenDB func() (db.Store, error)
+
+	// Devcontainer runs new sessions in the dev container of their worktree, even when the
+	// devcontainer setting is off
+	Devcontainer bool
+	// StartupCommand replaces the configured startup command of new sessions
+	StartupCommand string
+	// Wait waits for the startup command of new sessions to exit, even when wait_for_startup
+	// is off
+	Wait bool
+}
+
+// Preparer creates worktrees and sessions for the branches of projects
+// Callers hold the lock of the project while it does.
+type Preparer struct {
+	cfg            *config.Config
+	sessions       session.SessionManager
+	disp           display.Printer
+	progress       io.Writer
+	openDB         func() (db.Store, error)
+	devcontainer   bool
+	startupCommand string
+	wait           bool
+}
+
+// New creates a Preparer
+func New(opts Options) *Preparer {
+	p := &Preparer{
+		cfg:            opts.Config,
+		sessions:       opts.Sessions,
+		disp:           opts.Display,
+		progress:       opts.Progress,
+		openDB:         opts.OpenDB,
+		devcontainer:   opts.Devcontainer,
+		startupCommand: opts.StartupCommand,
+		wait:           opts.Wait,
+	}
+	if p.progress == nil {
+		p.progress = io.Discard
+	}
+	if p.openDB == nil {
+		p.openDB = OpenDB
+	}
+	return p
+}
+
+// OpenDB ensures the config directory exists and opens the sesh database
+func OpenDB() (db.Store, error) {
+	dbPath, err := config.GetDBPath()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get database path")
+	}
+
+	if err := config.EnsureConfigDir(); err != nil {
+		return nil, eris.Wrap(err, "failed to ensure config directory")
+	}
+
+	store, err := db.Open(dbPath)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to initialize database")
+	}
+
+	return store, nil
+}
+
+// EnsureWorktree returns the worktree path of a branch, creating the worktree with
+// CreateWorktree if needed, and whether it was created
+func (p *Preparer) EnsureWorktree(ctx context.Context, proj *models.Project, branch string) (string, bool, error) {
+	if existingWorktree, err := state.GetWorktree(proj, branch); err == nil && existingWorktree != nil {
+		ZoxideAdd(p.cfg, existingWorktree.Path, p.disp)
+		return existingWorktree.Path, false, nil
+	}
+
+	worktreePath, err := p.CreateWorktree(ctx, proj, branch)
+	return worktreePath, err == nil, err
+}
+
+// CreateWorktree creates a worktree for a branch that doesn't have one yet
+// The branch is checked out from the local ref, the remote ref, or created from HEAD, in a
+// recycled worktree of the pool or a clone of the default branch's worktree when enabled. Its
+// submodules and Git LFS files are checked out, and it is registered with zoxide. The operation
+// is logged. Returns the path of the new worktree.
+func (p *Preparer) CreateWorktree(ctx context.Context, proj *models.Project, branch string) (string, error) {
+	worktreePath, err := p.createWorktree(ctx, proj, branch)
+	p.recordOperation("create-worktree", proj.Name, branch, err)
+	return worktreePath, err
+}
+
+// createWorktree creates the worktree of CreateWorktree
+func (p *Preparer) createWorktree(ctx context.Context, proj *models.Project, branch string) (string, error) {
+	remote := p.cfg.Remote.For(proj.Name)
+	source, err := git.GetBranchSource(proj.LocalPath, remote, branch)
+	if err != nil {
+		return "", err
+	}
+
+	worktreePath := workspace.TemplateWorktreePath(
+		p.cfg.WorktreePathTemplate,
+		workspace.GetProjectWorkspaceDir(proj.LocalPath, proj.Name),
+		proj.Name,
+		branch,
+	)
+
+	switch source {
+	case git.BranchLocal:
+		p.disp.Printf("%s Creating worktree for branch: %s\n", p.disp.InfoText("✨"), p.disp.Bold(branch))
+	case git.BranchRemote:
+		p.disp.Printf("%s Creating worktree from remote branch: %s\n", p.disp.InfoText("✨"), p.disp.Bold(branch))
+	default:
+		p.disp.Printf("%s Creating new branch and worktree: %s\n", p.disp.SuccessText("✨"), p.disp.Bold(branch))
+	}
+
+	if !p.reusePooledWorktree(ctx, proj, branch, worktreePath, source) {
+		if template := p.reflinkTemplate(proj, branch); template != "" {
+			err = git.CloneWorktreeForBranch(ctx, proj.LocalPath, remote, branch, worktreePath, source, template)
+		} else {
+			err = git.CreateWorktreeForBranch(ctx, proj.LocalPath, remote, branch, worktreePath, source)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	UpdateSubmodules(ctx, worktreePath, p.disp)
+	PullLFS(ctx, worktreePath, p.disp)
+	ZoxideAdd(p.cfg, worktreePath, p.disp)
+
+	return worktreePath, nil
+}
+
+// reusePooledWorktree moves a recycled worktree of the project to worktreePath and checks out the
+// branch in it. Returns whether a recycled worktree was reused; when none is, or worktreePath is
+// on another filesystem than the pool, the worktree is created as usual.
+func (p *Preparer) reusePooledWorktree(
+	ctx context.Context,
+	proj *models.Project,
+	branch, worktreePath string,
+	source git.BranchSource,
+) bool {
+	if p.cfg.WorktreePool <= 0 {
+		return false
+	}
+	for _, poolPath := range state.DiscoverPooledWorktrees(proj) {
+		if !workspace.SameFilesystem(poolPath, worktreePath) {
+			return false
+		}
+		err := git.ReuseWorktree(ctx, proj.LocalPath, p.cfg.Remote.For(proj.Name), branch, poolPath, worktreePath, source)
+		if err != nil {
+			slog.Warn("failed to reuse recycled worktree", "path", poolPath, "error", err)
+			continue
+		}
+		p.disp.Printf("%s Reused a recycled worktree\n", p.disp.InfoText("♻"))
+		return true
+	}
+	return false
+}
+
+// reflinkTemplate returns the worktree new worktrees of a project are cloned from when
+// reflink_worktrees is on: the worktree of the default branch, which most branches are close to.
+// Returns "" when they are checked out instead.
+func (p *Preparer) reflinkTemplate(proj *models.Project, branch string) string {
+	if !p.cfg.ReflinkWorktrees {
+		return ""
+	}
+	defaultBranch, err := git.GetDefaultBranch(proj.LocalPath)
+	if err != nil || defaultBranch == branch {
+		return ""
+	}
+	wt, err := state.GetWorktree(proj, defaultBranch)
+	if err != nil || wt == nil {
+		slog.Debug("no worktree of the default branch to clone", "project", proj.Name, "branch", defaultBranch)
+		return ""
+	}
+	return wt.Path
+}
+
+// recordOperation logs an operation in the database of the Preparer, see RecordOperation
+func (p *Preparer) recordOperation(operation, projectName, branch string, opErr error) {
+	RecordOperation(p.openDB, operation, projectName, branch, opErr)
+}
+
+// RecordOperation appends a mutating operation to the audit log in the database opened with
+// openDB, along with the command line it was run from. Failures are ignored since the log is not
+// critical.
+func RecordOperation(openDB func() (db.Store, error), operation, projectName, branch string, opErr error) {
+	database, err := openDB()
+	if err != nil {
+		slog.Debug("failed to open database for the operation log", "error", err)
+		return
+	}
+	defer database.Close()
+
+	entry := &models.OperationLogEntry{
+		Operation:   operation,
+		ProjectName: projectName,
+		Branch:      branch,
+		Args:        strings.Join(os.Args[1:], " "),
+		Outcome:     "ok",
+	}
+	if opErr != nil {
+		entry.Outcome = "failed"
+		entry.Error = opErr.Error()
+	}
+
+	if err := database.AddOperationLog(entry); err != nil {
+		slog.Debug("failed to record operation", "operation", operation, "error", err)
+	}
+	if err := database.ClearOldOperationLog(operationLogRetentionDays); err != nil {
+		slog.Debug("failed to clear old operations", "error", err)
+	}
+}
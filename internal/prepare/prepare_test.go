@@ -0,0 +1,121 @@
+package prepare
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/state"
+)
+
+// setupProject creates a workspace holding one project, example.com/user/repo, whose remote
+// has a main and a feature branch. Returns the project and a database to prepare it with.
+func setupProject(t *testing.T) (*models.Project, func() (db.Store, error)) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	src := filepath.Join(t.TempDir(), "src")
+	workspaceDir := t.TempDir()
+	bare := filepath.Join(workspaceDir, "example.com", "user", "repo.git")
+
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main", src},
+		{"-C", src, "commit", "--quiet", "--allow-empty", "-m", "initial"},
+		{"-C", src, "branch", "feature"},
+		{"clone", "--quiet", "--bare", src, bare},
+		{"-C", bare, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"},
+		{"-C", bare, "fetch", "--quiet", "origin"},
+		{"-C", bare, "branch", "--quiet", "-D", "feature"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	proj, err := state.GetProject(workspaceDir, "example.com/user/repo")
+	if err != nil {
+		t.Fatalf("GetProject() failed: %v", err)
+	}
+
+	return proj, tempDB(t)
+}
+
+// tempDB returns an opener of a database of the test's own
+func tempDB(t *testing.T) func() (db.Store, error) {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "sesh.db")
+	return func() (db.Store, error) { return db.Open(dbPath) }
+}
+
+func TestPreparer_EnsureWorktree(t *testing.T) {
+	proj, openDB := setupProject(t)
+	workspaceDir := filepath.Dir(filepath.Dir(filepath.Dir(proj.LocalPath)))
+	p := New(Options{
+		// The template is taken from the config, without setting it for the whole process
+		Config:  &config.Config{WorkspaceDir: workspaceDir, WorktreePathTemplate: "worktrees/{repo}/{branch}"},
+		Display: display.New(io.Discard),
+		OpenDB:  openDB,
+	})
+
+	tests := []struct {
+		name        string
+		branch      string
+		wantCreated bool
+	}{
+		{name: "local branch", branch: "main", wantCreated: true},
+		{name: "existing worktree", branch: "main", wantCreated: false},
+		{name: "remote branch", branch: "feature", wantCreated: true},
+		{name: "new branch", branch: "fresh", wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, created, err := p.EnsureWorktree(t.Context(), proj, tt.branch)
+			if err != nil {
+				t.Fatalf("EnsureWorktree() failed: %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if want := filepath.Join(workspaceDir, "worktrees", "repo", tt.branch); path != want {
+				t.Errorf("path = %q, want %q", path, want)
+			}
+			if _, err := os.Stat(path); err != nil {
+				t.Errorf("worktree is missing: %v", err)
+			}
+		})
+	}
+
+	// Each worktree created is in the operation log
+	store, err := openDB()
+	if err != nil {
+		t.Fatalf("openDB() failed: %v", err)
+	}
+	defer store.Close()
+	entries, err := store.GetOperationLog(proj.Name, "", 10)
+	if err != nil {
+		t.Fatalf("GetOperationLog() failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("logged %d operations, want 3", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Operation != "create-worktree" || entry.Outcome != "ok" {
+			t.Errorf("logged %s %s, want create-worktree ok", entry.Operation, entry.Outcome)
+		}
+	}
+}
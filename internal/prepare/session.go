@@ -0,0 +1,350 @@
+package prepare
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/benoctopus/sesh/internal/compose"
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/devcontainer"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/ready"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// Startup is what is waited for with WaitForStartup once a new session is prepared, without
+// holding the project lock: the startup command, and the services it starts
+type Startup struct {
+	SessionName string
+	// Command is whether the startup command notifies when it exits
+	Command bool
+	// ReadyCheck tells when the services the startup command starts are ready
+	ReadyCheck *ready.Check
+}
+
+// CreateSession creates a detached session for a worktree, inside its dev container when
+// enabled, runs the startup command, and starts the processes and compose services of the
+// project config. The operation is logged. Returns what to wait for before attaching, if
+// anything.
+func (p *Preparer) CreateSession(
+	ctx context.Context,
+	projectName, branch, sessionName, worktreePath string,
+) (*Startup, error) {
+	shell, err := p.DevcontainerShell(ctx, worktreePath)
+	if err != nil {
+		return nil, err
+	}
+
+	WarnSharedRepoName(projectName, sessionName)
+	p.disp.Printf(
+		"%s Creating %s session %s\n",
+		p.disp.InfoText("✨"),
+		p.sessions.Name(),
+		p.disp.Bold(sessionName),
+	)
+	err = p.CreateSessionWithOptions(projectName, branch, sessionName, worktreePath, shell)
+	p.recordOperation("create-session", projectName, branch, err)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to create session")
+	}
+
+	// Execute startup command if configured
+	projectConfig, err := config.LoadProjectConfig(worktreePath)
+	if err != nil {
+		p.disp.Warningf("Failed to load project config: %v", err)
+		projectConfig = &config.ProjectConfig{}
+	}
+	startupCmd := p.StartupCommand(worktreePath)
+	wait := p.wait || projectConfig.WaitForStartup
+	if wait && shell != "" {
+		// The end of the command is signaled from the host, which the container can't reach
+		p.disp.Warningf("Can't wait for the startup command inside the dev container")
+		wait = false
+	}
+	ran, notifies := RunStartupCommand(p.sessions, sessionName, startupCmd, wait, p.disp)
+	p.startProcesses(sessionName, worktreePath, projectConfig.Processes, shell != "")
+	p.startCompose(ctx, projectName, branch, sessionName, worktreePath, projectConfig.ComposeFile)
+	if !ran || (!notifies && projectConfig.ReadyCheck == nil) {
+		return nil, nil
+	}
+	return &Startup{SessionName: sessionName, Command: notifies, ReadyCheck: projectConfig.ReadyCheck}, nil
+}
+
+// WarnSharedRepoName explains why the name of a new session includes the owner of its project
+func WarnSharedRepoName(projectName, sessionName string) {
+	if workspace.HasSharedRepoName(projectName) {
+		slog.Warn(
+			"another project has the same repository name, so the session name includes the owner",
+			"project", projectName,
+			"session", sessionName,
+		)
+	}
+}
+
+// DevcontainerShell brings up the dev container of a worktree when its session should run
+// inside it, with the Devcontainer option or the devcontainer setting, and returns the shell
+// command opening a shell in the container. Returns an empty command when the session runs on
+// the host.
+func (p *Preparer) DevcontainerShell(ctx context.Context, worktreePath string) (string, error) {
+	enabled := p.devcontainer
+	if !enabled {
+		var err error
+		if enabled, err = config.GetDevcontainer(worktreePath); err != nil {
+			p.disp.Warningf("Failed to get devcontainer setting: %v", err)
+		}
+	}
+	if !enabled {
+		return "", nil
+	}
+
+	if !devcontainer.Detect(worktreePath) {
+		if p.devcontainer {
+			return "", eris.Errorf("%s has no dev container configuration (.devcontainer/devcontainer.json)", worktreePath)
+		}
+		return "", nil
+	}
+	if _, ok := p.sessions.(session.OptionsCreator); !ok {
+		p.disp.Warningf("The %s session backend can't open sessions in dev containers", p.sessions.Name())
+		return "", nil
+	}
+	if err := devcontainer.CheckCLI(); err != nil {
+		return "", err
+	}
+
+	p.disp.Printf("%s Starting dev container\n", p.disp.InfoText("🐳"))
+	progress := display.NewProgress(p.progress, "Starting dev container")
+	err := devcontainer.Up(ctx, worktreePath, progress.Update)
+	progress.Stop()
+	if err != nil {
+		return "", err
+	}
+	return devcontainer.ShellCommand(worktreePath), nil
+}
+
+// CreateSessionWithOptions creates a session with SESH_PROJECT, SESH_BRANCH, and SESH_WORKTREE
+// in its environment, so scripts run in it know where they are, with SESH_PORT, SESH_PORT_2, ...
+// set to the worktree's block of ports, and with the status bar style of the project's
+// .sesh.yaml, for backends that support it. A non-empty shell replaces the default shell of
+// its windows.
+func (p *Preparer) CreateSessionWithOptions(projectName, branch, sessionName, worktreePath, shell string) error {
+	creator, ok := p.sessions.(session.OptionsCreator)
+	if !ok {
+		return p.sessions.Create(sessionName, worktreePath)
+	}
+	opts := p.SessionOptions(projectName, branch, worktreePath, shell)
+	return creator.CreateWithOptions(sessionName, worktreePath, opts)
+}
+
+// SessionOptions returns the options of a new session of a worktree, for CreateSessionWithOptions
+func (p *Preparer) SessionOptions(projectName, branch, worktreePath, shell string) session.CreateOptions {
+	opts := session.CreateOptions{
+		Env: map[string]string{
+			"SESH_PROJECT":  projectName,
+			"SESH_BRANCH":   branch,
+			"SESH_WORKTREE": worktreePath,
+		},
+		Shell: shell,
+	}
+	maps.Copy(opts.Env, p.allocatePorts(projectName, branch))
+	if projectConfig, err := config.LoadProjectConfig(worktreePath); err != nil {
+		slog.Warn("failed to load project config", "path", worktreePath, "error", err)
+	} else {
+		opts.StatusStyle = projectConfig.StatusStyle
+	}
+	return opts
+}
+
+// allocatePorts returns the environment variables of the block of ports assigned to a
+// worktree, assigning it one when it has none yet. This is a best-effort operation: without
+// the database, sessions are created without ports.
+func (p *Preparer) allocatePorts(projectName, branch string) map[string]string {
+	database, err := p.openDB()
+	if err != nil {
+		slog.Warn("failed to open database to assign ports", "error", err)
+		return nil
+	}
+	defer database.Close()
+
+	ports := p.cfg.Ports.WithDefaults()
+	basePort, err := database.AllocatePortBlock(projectName, branch, ports.Start, ports.BlockSize)
+	if err != nil {
+		slog.Warn("failed to assign ports", "project", projectName, "branch", branch, "error", err)
+		return nil
+	}
+	return ports.Env(basePort)
+}
+
+// StartupCommand returns the startup command following the priority hierarchy:
+// 1. The StartupCommand option, e.g. a command-line flag (highest priority)
+// 2. Per-project config (.sesh.yaml in worktree)
+// 3. Global config
+// 4. Empty string (no command)
+func (p *Preparer) StartupCommand(worktreePath string) string {
+	// 1. Check the option
+	if p.startupCommand != "" {
+		return p.startupCommand
+	}
+
+	// 2. Check per-project config
+	startupCmd, err := config.GetStartupCommand(worktreePath)
+	if err == nil && startupCmd != "" {
+		return startupCmd
+	}
+
+	// 3. Return global config (already loaded in cfg)
+	return p.cfg.StartupCommand
+}
+
+// startCompose starts the compose services of the project config with a new session, under
+// a compose project of the worktree's own, with its ports in the environment. Failures only
+// warn, like the startup command.
+func (p *Preparer) startCompose(ctx context.Context, projectName, branch, sessionName, worktreePath, file string) {
+	if file == "" {
+		return
+	}
+
+	composeProject := compose.ProjectName(sessionName)
+	progress := display.NewProgress(p.progress, "Starting compose services")
+	err := compose.Up(ctx, worktreePath, file, composeProject, p.allocatePorts(projectName, branch))
+	progress.Stop()
+	if err != nil {
+		p.disp.Warningf("Failed to start compose services: %v", err)
+		return
+	}
+	p.disp.Printf("%s Started compose services: %s\n", p.disp.InfoText("🐳"), p.disp.Bold(composeProject))
+}
+
+// startProcesses starts the processes of the project config in windows of a new session, in
+// the dev container of the worktree when the session runs inside it. Failing processes only
+// warn, like the startup command.
+func (p *Preparer) startProcesses(
+	sessionName, worktreePath string,
+	processes []session.Process,
+	inContainer bool,
+) {
+	if len(processes) == 0 {
+		return
+	}
+	supervisor, ok := p.sessions.(session.ProcessSupervisor)
+	if !ok {
+		p.disp.Warningf("The %s session backend can't run processes, skipping them", p.sessions.Name())
+		return
+	}
+
+	logDir, err := ProcessLogDir(sessionName)
+	if err == nil {
+		err = os.MkdirAll(logDir, 0o755)
+	}
+	if err != nil {
+		p.disp.Warningf("Failed to create the log directory of processes: %v", err)
+		return
+	}
+
+	for _, proc := range processes {
+		logPath := ProcessLogPath(logDir, proc.Name)
+		if inContainer {
+			proc.Command = devcontainer.ExecCommand(worktreePath, proc.Command)
+		}
+		if err := supervisor.StartProcess(sessionName, worktreePath, proc, logPath); err != nil {
+			p.disp.Warningf("Failed to start process %s: %v", proc.Name, err)
+			continue
+		}
+		p.disp.Printf(
+			"%s Started process %s: %s\n",
+			p.disp.InfoText("▶"),
+			p.disp.Bold(proc.Name),
+			p.disp.Faint(proc.Command),
+		)
+	}
+}
+
+// ProcessLogDir returns the directory of the captured output of a session's processes
+func ProcessLogDir(sessionName string) (string, error) {
+	cacheDir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "processes", sessionName), nil
+}
+
+// ProcessLogPath returns the file the output of a process is captured to
+func ProcessLogPath(logDir, processName string) string {
+	return filepath.Join(logDir, processName+".log")
+}
+
+// RunStartupCommand runs the startup command in a new session. Backends that can't run
+// commands in sessions, like editors, skip it. With wait, the command notifies when it exits.
+// Returns whether the command ran, and whether it notifies.
+func RunStartupCommand(
+	sessionMgr session.SessionManager,
+	sessionName, startupCmd string,
+	wait bool,
+	disp display.Printer,
+) (ran, notifies bool) {
+	if startupCmd == "" {
+		return false, false
+	}
+
+	waiter, canWait := sessionMgr.(session.CommandWaiter)
+	if wait && !canWait {
+		disp.Warningf("The %s session backend can't wait for the startup command", sessionMgr.Name())
+	}
+	wait = wait && canWait
+
+	var err error
+	if wait {
+		err = waiter.RunCommandNotify(sessionName, startupCmd)
+	} else {
+		err = sessionMgr.RunCommand(sessionName, startupCmd)
+	}
+	if eris.Is(err, session.ErrNotSupported) {
+		slog.Debug("skipped startup command", "session", sessionName, "error", err)
+		return false, false
+	}
+	disp.Printf("%s Running startup command: %s\n", disp.InfoText("⚙"), disp.Faint(startupCmd))
+	if err != nil {
+		slog.Warn("failed to run startup command", "session", sessionName, "error", err)
+		return false, false
+	}
+	return true, wait
+}
+
+// WaitForStartup waits for the startup command of a new session to exit, and for the services
+// it starts to be ready, so the session is attached to once it is. Services that don't get
+// ready are only reported, since the session is usable anyway.
+func WaitForStartup(
+	ctx context.Context,
+	sessionMgr session.SessionManager,
+	startup *Startup,
+	disp display.Printer,
+) error {
+	if startup == nil {
+		return nil
+	}
+
+	if waiter, ok := sessionMgr.(session.CommandWaiter); ok && startup.Command {
+		disp.Printf(
+			"%s Waiting for the startup command of %s to finish (Ctrl-C to cancel)...\n",
+			disp.InfoText("⏳"),
+			disp.Bold(startup.SessionName),
+		)
+		if err := waiter.WaitForCommand(ctx, startup.SessionName); err != nil {
+			return eris.Wrap(err, "failed to wait for startup command")
+		}
+	}
+
+	if check := startup.ReadyCheck; check != nil {
+		disp.Printf("%s Waiting for %s to be ready...\n", disp.InfoText("⏳"), disp.Bold(check.String()))
+		if err := ready.Wait(ctx, check); err != nil {
+			disp.Warningf("%s isn't ready: %v", check, err)
+		} else {
+			disp.Printf("%s %s is ready\n", disp.SuccessText("✓"), check)
+		}
+	}
+	return nil
+}
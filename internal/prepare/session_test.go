@@ -0,0 +1,158 @@
+package prepare
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/session"
+)
+
+// fakeCommandRunner records the sessions created and the commands run in them
+// Embedding session.SessionManager means any method the test doesn't expect panics
+type fakeCommandRunner struct {
+	session.SessionManager
+	created  map[string]session.CreateOptions
+	commands []string
+}
+
+func (f *fakeCommandRunner) Name() string { return "fake" }
+
+func (f *fakeCommandRunner) CreateWithOptions(name, path string, opts session.CreateOptions) error {
+	if f.created == nil {
+		f.created = make(map[string]session.CreateOptions)
+	}
+	f.created[name] = opts
+	return nil
+}
+
+func (f *fakeCommandRunner) RunCommand(name, command string) error {
+	f.commands = append(f.commands, name+": "+command)
+	return nil
+}
+
+// fakeCommandWaiter records the commands it notifies about, and the sessions waited for
+type fakeCommandWaiter struct {
+	fakeCommandRunner
+	notifying []string
+	waited    []string
+	// failing is the session whose command can't be waited for
+	failing string
+}
+
+func (f *fakeCommandWaiter) RunCommandNotify(name, command string) error {
+	f.notifying = append(f.notifying, name+": "+command)
+	return nil
+}
+
+func (f *fakeCommandWaiter) WaitForCommand(ctx context.Context, name string) error {
+	f.waited = append(f.waited, name)
+	if name == f.failing {
+		return errors.New("no server running")
+	}
+	return nil
+}
+
+func TestPreparer_CreateSession(t *testing.T) {
+	openDB := tempDB(t)
+	waiter := &fakeCommandWaiter{}
+	p := New(Options{
+		Config:   &config.Config{WorkspaceDir: t.TempDir(), StartupCommand: "make dev"},
+		Sessions: waiter,
+		Display:  display.New(io.Discard),
+		OpenDB:   openDB,
+	})
+
+	worktreePath := t.TempDir()
+	projectConfig := filepath.Join(worktreePath, ".sesh.yaml")
+	if err := os.WriteFile(projectConfig, []byte("wait_for_startup: true\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+
+	startup, err := p.CreateSession(t.Context(), "example.com/user/repo", "main", "repo-main", worktreePath)
+	if err != nil {
+		t.Fatalf("CreateSession() failed: %v", err)
+	}
+
+	opts, ok := waiter.created["repo-main"]
+	if !ok {
+		t.Fatalf("sessions created = %v, want repo-main", waiter.created)
+	}
+	env := opts.Env
+	if env["SESH_PROJECT"] != "example.com/user/repo" || env["SESH_BRANCH"] != "main" || env["SESH_PORT"] == "" {
+		t.Errorf("session environment = %v, want the project, branch, and ports", env)
+	}
+
+	// wait_for_startup of the project config makes the startup command notify when it exits
+	if want := []string{"repo-main: make dev"}; !slices.Equal(waiter.notifying, want) {
+		t.Errorf("commands run notifying = %v, want %v", waiter.notifying, want)
+	}
+	if startup == nil || startup.SessionName != "repo-main" || !startup.Command {
+		t.Errorf("CreateSession() = %+v, want to wait for the command of repo-main", startup)
+	}
+
+	store, err := openDB()
+	if err != nil {
+		t.Fatalf("openDB() failed: %v", err)
+	}
+	defer store.Close()
+	entries, err := store.GetOperationLog("example.com/user/repo", "main", 10)
+	if err != nil || len(entries) != 1 || entries[0].Operation != "create-session" {
+		t.Errorf("GetOperationLog() = %v, %v, want a create-session entry", entries, err)
+	}
+}
+
+func TestRunStartupCommand(t *testing.T) {
+	disp := display.New(io.Discard)
+
+	runner := &fakeCommandRunner{}
+	if ran, notifies := RunStartupCommand(runner, "repo-main", "make dev", true, disp); !ran || notifies {
+		t.Errorf("RunStartupCommand() without waiting support = %v, %v; want true, false", ran, notifies)
+	}
+	if want := []string{"repo-main: make dev"}; !slices.Equal(runner.commands, want) {
+		t.Errorf("commands run = %v, want %v", runner.commands, want)
+	}
+
+	waiter := &fakeCommandWaiter{}
+	if ran, notifies := RunStartupCommand(waiter, "repo-main", "make dev", true, disp); !ran || !notifies {
+		t.Errorf("RunStartupCommand() waiting = %v, %v; want true, true", ran, notifies)
+	}
+	if ran, notifies := RunStartupCommand(waiter, "repo-other", "make dev", false, disp); !ran || notifies {
+		t.Errorf("RunStartupCommand() not waiting = %v, %v; want true, false", ran, notifies)
+	}
+	if want := []string{"repo-main: make dev"}; !slices.Equal(waiter.notifying, want) {
+		t.Errorf("commands run notifying = %v, want %v", waiter.notifying, want)
+	}
+	if want := []string{"repo-other: make dev"}; !slices.Equal(waiter.commands, want) {
+		t.Errorf("commands run = %v, want %v", waiter.commands, want)
+	}
+
+	if ran, notifies := RunStartupCommand(waiter, "repo-main", "", true, disp); ran || notifies {
+		t.Errorf("RunStartupCommand() without a command = %v, %v; want false, false", ran, notifies)
+	}
+}
+
+func TestWaitForStartup(t *testing.T) {
+	disp := display.New(io.Discard)
+	waiter := &fakeCommandWaiter{failing: "repo-broken"}
+
+	if err := WaitForStartup(t.Context(), waiter, nil, disp); err != nil || len(waiter.waited) != 0 {
+		t.Errorf("WaitForStartup() without a startup = %v, waited for %v", err, waiter.waited)
+	}
+	if err := WaitForStartup(t.Context(), waiter, &Startup{SessionName: "repo-main", Command: true}, disp); err != nil {
+		t.Errorf("WaitForStartup() failed: %v", err)
+	}
+	broken := &Startup{SessionName: "repo-broken", Command: true}
+	if err := WaitForStartup(t.Context(), waiter, broken, disp); err == nil {
+		t.Error("WaitForStartup() of a failing command returned no error")
+	}
+	if want := []string{"repo-main", "repo-broken"}; !slices.Equal(waiter.waited, want) {
+		t.Errorf("waited for %v, want %v", waiter.waited, want)
+	}
+}
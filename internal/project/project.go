@@ -11,14 +11,37 @@ import (
 	"github.com/rotisserie/eris"
 )
 
+// aliases maps the configured project aliases to the projects they name
+var aliases map[string]string
+
+// SetAliases sets the project aliases ResolveProject accepts, e.g. "api" for
+// "github.com/acme/platform-api"
+func SetAliases(projectAliases map[string]string) {
+	aliases = projectAliases
+}
+
+// Aliases returns the project aliases ResolveProject accepts
+func Aliases() map[string]string {
+	return aliases
+}
+
 // ResolveProject resolves a project from a project name or current working directory
 // If projectName is empty, it will attempt to detect the project from CWD
-// Supports both full project names (github.com/user/repo) and short names (repo)
+// Supports full project names (github.com/user/repo), aliases, and short names (repo)
 // Priority:
-// 1. If projectName is provided, try exact match first, then short name match
+// 1. If projectName is provided, try exact match first, then alias, then short name match
 // 2. If projectName is empty, detect project from CWD
 // 3. Return error if not found
 func ResolveProject(workspaceDir, projectName string, cwd string) (*models.Project, error) {
+	return ResolveProjectWithAliases(workspaceDir, projectName, cwd, aliases)
+}
+
+// ResolveProjectWithAliases resolves a project like ResolveProject, with the given project
+// aliases instead of the ones set with SetAliases
+func ResolveProjectWithAliases(
+	workspaceDir, projectName, cwd string,
+	projectAliases map[string]string,
+) (*models.Project, error) {
 	// If project name is explicitly provided, look it up
 	if projectName != "" {
 		// First try exact match with full name
@@ -27,6 +50,19 @@ func ResolveProject(workspaceDir, projectName string, cwd string) (*models.Proje
 			return project, nil
 		}
 
+		// Aliases come before short names, which may be ambiguous; they name a project by full
+		// or short name
+		if target, ok := projectAliases[projectName]; ok {
+			if project, err := state.GetProject(workspaceDir, target); err == nil {
+				return project, nil
+			}
+			project, err := state.GetProjectByShortName(workspaceDir, target)
+			if err != nil {
+				return nil, eris.Wrapf(err, "project alias %s", projectName)
+			}
+			return project, nil
+		}
+
 		// If not found by exact match, try to find by short name (repo name only)
 		return state.GetProjectByShortName(workspaceDir, projectName)
 	}
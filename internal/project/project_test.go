@@ -1,6 +1,7 @@
 package project
 
 import (
+	"os/exec"
 	"path/filepath"
 	"testing"
 )
@@ -97,3 +98,58 @@ func TestExtractProjectFromRemote(t *testing.T) {
 // TestResolveByShortName has been removed because resolveByShortName
 // is now an internal implementation detail of the state package.
 // Short name resolution is tested through integration tests.
+
+func TestResolveProjectAlias(t *testing.T) {
+	workspaceDir := t.TempDir()
+	for _, name := range []string{"github.com/acme/platform-api", "github.com/acme/web", "github.com/other/web"} {
+		path := filepath.Join(workspaceDir, name+".git")
+		if out, err := exec.Command("git", "init", "--quiet", "--bare", path).CombinedOutput(); err != nil {
+			t.Fatalf("git init failed: %v: %s", err, out)
+		}
+		cmd := exec.Command("git", "-C", path, "remote", "add", "origin", "https://"+name)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git remote add failed: %v: %s", err, out)
+		}
+	}
+
+	projectAliases := map[string]string{
+		"api":   "platform-api",
+		"web":   "github.com/acme/web",
+		"gone":  "github.com/acme/gone",
+		"other": "github.com/other/web",
+	}
+	SetAliases(projectAliases)
+	t.Cleanup(func() { SetAliases(nil) })
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "api", want: "github.com/acme/platform-api"},
+		// Without the alias, web would be ambiguous
+		{name: "web", want: "github.com/acme/web"},
+		{name: "other", want: "github.com/other/web"},
+		{name: "github.com/other/web", want: "github.com/other/web"},
+		{name: "platform-api", want: "github.com/acme/platform-api"},
+		{name: "gone", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proj, err := ResolveProject(workspaceDir, tt.name, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveProject(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if err == nil && proj.Name != tt.want {
+				t.Errorf("ResolveProject(%q) = %s, want %s", tt.name, proj.Name, tt.want)
+			}
+
+			// The aliases can be given instead of being set, with the same result
+			proj, err = ResolveProjectWithAliases(workspaceDir, tt.name, "", projectAliases)
+			if (err != nil) != tt.wantErr || (err == nil && proj.Name != tt.want) {
+				t.Errorf("ResolveProjectWithAliases(%q) = %v, %v, want %s", tt.name, proj, err, tt.want)
+			}
+		})
+	}
+}
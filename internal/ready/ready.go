@@ -0,0 +1,118 @@
+// Package ready tells when a service started in a session, like a dev server, accepts
+// connections, by dialing a TCP port or requesting a URL
+package ready
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rotisserie/eris"
+)
+
+const (
+	// DefaultTimeout is how long Wait waits for a service when the check sets no timeout
+	DefaultTimeout = time.Minute
+
+	// probeTimeout bounds a single probe, so listing the readiness of sessions stays fast
+	probeTimeout = 500 * time.Millisecond
+
+	// pollInterval is how long Wait sleeps between probes
+	pollInterval = 500 * time.Millisecond
+)
+
+// Check is how to tell that a service is ready: a TCP port on localhost accepts connections,
+// or a URL answers without a server error
+type Check struct {
+	// Port on localhost, e.g. 3000
+	Port int `yaml:"port,omitempty"`
+	// URL answering with a status below 500 once ready, e.g. http://localhost:3000/health
+	URL string `yaml:"url,omitempty"`
+	// Timeout is how long to wait for the service after the startup command, a minute by default
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Validate checks that exactly one of the port and URL is set, and that they are valid
+func (c *Check) Validate() error {
+	if (c.Port == 0) == (c.URL == "") {
+		return eris.New("ready_check must set either port or url")
+	}
+	if c.Port != 0 && (c.Port < 1 || c.Port > 65535) {
+		return eris.Errorf("invalid ready_check port: %d (must be between 1 and 65535)", c.Port)
+	}
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return eris.Errorf("invalid ready_check url: %q (must be an http or https URL)", c.URL)
+		}
+	}
+	if c.Timeout < 0 {
+		return eris.Errorf("invalid ready_check timeout: %s (must not be negative)", c.Timeout)
+	}
+	return nil
+}
+
+// String describes what the check probes, e.g. "localhost:3000"
+func (c *Check) String() string {
+	if c.URL != "" {
+		return c.URL
+	}
+	return net.JoinHostPort("localhost", strconv.Itoa(c.Port))
+}
+
+// Probe checks once whether the service is ready
+func Probe(ctx context.Context, c *Check) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	if c.URL == "" {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", c.String())
+		if err != nil {
+			return eris.Wrapf(err, "%s doesn't accept connections", c)
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return eris.Wrapf(err, "invalid ready_check url: %s", c.URL)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return eris.Wrapf(err, "%s doesn't answer", c)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return eris.Errorf("%s answered %s", c, resp.Status)
+	}
+	return nil
+}
+
+// Wait probes the service until it is ready, failing with the last probe's error once the
+// check's timeout passed
+func Wait(ctx context.Context, c *Check) error {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		err := Probe(ctx, c)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return eris.Wrapf(err, "not ready after %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
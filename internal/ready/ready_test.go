@@ -0,0 +1,100 @@
+package ready
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   Check
+		wantErr bool
+	}{
+		{name: "port", check: Check{Port: 3000}},
+		{name: "url", check: Check{URL: "http://localhost:3000/health", Timeout: 2 * time.Minute}},
+		{name: "neither", check: Check{}, wantErr: true},
+		{name: "both", check: Check{Port: 3000, URL: "http://localhost:3000"}, wantErr: true},
+		{name: "port out of range", check: Check{Port: 70000}, wantErr: true},
+		{name: "url without scheme", check: Check{URL: "localhost:3000"}, wantErr: true},
+		{name: "negative timeout", check: Check{Port: 3000, Timeout: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.check.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProbePort(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if err := Probe(context.Background(), &Check{Port: port}); err != nil {
+		t.Errorf("Probe() with a listener = %v, want nil", err)
+	}
+
+	listener.Close()
+	if err := Probe(context.Background(), &Check{Port: port}); err == nil {
+		t.Error("Probe() without a listener = nil, want error")
+	}
+}
+
+func TestProbeURL(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	if err := Probe(context.Background(), &Check{URL: server.URL}); err != nil {
+		t.Errorf("Probe() = %v, want nil", err)
+	}
+
+	status.Store(http.StatusServiceUnavailable)
+	if err := Probe(context.Background(), &Check{URL: server.URL}); err == nil {
+		t.Error("Probe() of a 503 = nil, want error")
+	}
+}
+
+func TestWait(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	// The service starts listening after a few probes failed
+	started := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(700 * time.Millisecond)
+		l, err := net.Listen("tcp", listener.Addr().String())
+		if err != nil {
+			t.Error(err)
+		}
+		started <- l
+	}()
+	if err := Wait(context.Background(), &Check{Port: port, Timeout: 5 * time.Second}); err != nil {
+		t.Errorf("Wait() = %v, want nil", err)
+	}
+	if l := <-started; l != nil {
+		l.Close()
+	}
+
+	if err := Wait(context.Background(), &Check{URL: "http://127.0.0.1:1", Timeout: time.Second}); err == nil {
+		t.Error("Wait() of a service that never starts = nil, want error")
+	}
+}
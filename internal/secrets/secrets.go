@@ -0,0 +1,61 @@
+// Package secrets keeps tokens in the keyring of the OS: the macOS Keychain, the Secret Service
+// on Linux (GNOME Keyring, KWallet), or the Windows Credential Manager. The config file only
+// names them.
+package secrets
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rotisserie/eris"
+	"github.com/zalando/go-keyring"
+)
+
+// service is the service the secrets of sesh are stored under in the keyring
+const service = "sesh"
+
+// ErrNotFound is returned by Get and Delete for a secret that isn't in the keyring
+var ErrNotFound = eris.New("secret not found in keyring")
+
+// Get returns the secret stored in the keyring under name
+func Get(name string) (string, error) {
+	secret, err := keyring.Get(service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", eris.Wrapf(err, "failed to read secret %s from keyring", name)
+	}
+	return secret, nil
+}
+
+// Set stores a secret in the keyring under name, replacing the one stored there before
+func Set(name, secret string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := keyring.Set(service, name, secret); err != nil {
+		return eris.Wrapf(err, "failed to store secret %s in keyring", name)
+	}
+	return nil
+}
+
+// Delete removes the secret stored in the keyring under name
+func Delete(name string) error {
+	err := keyring.Delete(service, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return eris.Wrapf(err, "failed to delete secret %s from keyring", name)
+	}
+	return nil
+}
+
+// ValidateName checks that a secret name is a single word
+func ValidateName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n/") {
+		return eris.Errorf("invalid secret name: %q (must be a single word without '/')", name)
+	}
+	return nil
+}
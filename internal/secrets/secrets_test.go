@@ -0,0 +1,31 @@
+package secrets
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestSecrets(t *testing.T) {
+	keyring.MockInit()
+
+	if _, err := Get("github-work"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() of a missing secret error = %v, want ErrNotFound", err)
+	}
+	if err := Set("github-work", "ghp_123"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got, err := Get("github-work"); err != nil || got != "ghp_123" {
+		t.Fatalf("Get() = %q, %v, want ghp_123", got, err)
+	}
+	if err := Delete("github-work"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := Delete("github-work"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a missing secret error = %v, want ErrNotFound", err)
+	}
+	if err := Set("my token", "ghp_123"); err == nil {
+		t.Error("Set() with an invalid name succeeded")
+	}
+}
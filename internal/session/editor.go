@@ -90,6 +90,21 @@ func (e *EditorManager) GetCurrentSessionName() (string, error) {
 	return "", eris.Errorf("getting current session is not supported with the %s backend", e.Name())
 }
 
+// Rename returns an error as editor backends don't have sessions
+func (e *EditorManager) Rename(oldName, newName string) error {
+	return eris.Wrapf(ErrNotSupported, "renaming sessions is not supported with the %s backend", e.Name())
+}
+
+// AttachedClients returns no clients as editor backends don't have sessions
+func (e *EditorManager) AttachedClients(name string) ([]string, error) {
+	return nil, nil
+}
+
+// RunCommand returns an error as editor backends don't have sessions to run commands in
+func (e *EditorManager) RunCommand(name, command string) error {
+	return eris.Wrapf(ErrNotSupported, "running commands is not supported with the %s backend", e.Name())
+}
+
 // openPath opens the given path in the editor using the configured mode
 func (e *EditorManager) openPath(path string) error {
 	args := e.buildArgs(path)
@@ -2,6 +2,8 @@ package session
 
 import (
 	"testing"
+
+	"github.com/rotisserie/eris"
 )
 
 func TestNewEditorManager(t *testing.T) {
@@ -103,6 +105,14 @@ func TestEditorManager_Delete(t *testing.T) {
 	}
 }
 
+func TestEditorManager_Rename(t *testing.T) {
+	mgr := NewEditorManager("code", EditorModeOpen)
+	err := mgr.Rename("test", "other")
+	if !eris.Is(err, ErrNotSupported) {
+		t.Errorf("Rename() = %v, want ErrNotSupported", err)
+	}
+}
+
 func TestEditorManager_GetCurrentSessionName(t *testing.T) {
 	mgr := NewEditorManager("code", EditorModeOpen)
 	_, err := mgr.GetCurrentSessionName()
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package session
+
+import (
+	"os"
+	"syscall"
+)
+
+// execAttach replaces sesh with the attach command of a multiplexer, so sesh doesn't stay
+// around as the parent of the client
+func execAttach(path string, args []string) error {
+	return syscall.Exec(path, args, os.Environ())
+}
+
+// detachedProcAttr starts a process in a session of its own, so it outlives sesh and the
+// terminal sesh was started from
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
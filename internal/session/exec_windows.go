@@ -0,0 +1,30 @@
+//go:build windows
+
+package session
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// execAttach runs the attach command of a multiplexer until the client exits. Windows can't
+// replace the running process like exec(2) does, so sesh waits for the client instead.
+func execAttach(path string, args []string) error {
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	// Ctrl+C is for the client, which shares the console, so it mustn't stop sesh first
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	return cmd.Run()
+}
+
+// detachedProcAttr starts a process without a console and in a process group of its own, so it
+// outlives sesh and the console sesh was started from
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS}
+}
@@ -1,8 +1,10 @@
 package session
 
 import (
+	"context"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/rotisserie/eris"
 )
@@ -38,6 +40,79 @@ type SessionManager interface {
 
 	// GetCurrentSessionName returns the name of the current session, or empty string if not in a session
 	GetCurrentSessionName() (string, error)
+
+	// Rename renames a session, failing if one already has the new name
+	Rename(oldName, newName string) error
+
+	// AttachedClients returns the clients attached to a session, e.g. terminals showing it
+	AttachedClients(name string) ([]string, error)
+
+	// RunCommand runs a shell command in a session, as if typed into it, e.g. the startup command
+	RunCommand(name, command string) error
+}
+
+// ErrNotSupported is wrapped by the errors of backends that can't do what is asked of them,
+// e.g. renaming a session without a session backend
+var ErrNotSupported = eris.New("not supported by the session backend")
+
+// PathLister is implemented by session backends that know the directory each session was
+// started in
+type PathLister interface {
+	// ListPaths returns the start directory of each active session, by session name
+	ListPaths() (map[string]string, error)
+}
+
+// IdleLister is implemented by session backends that know when sessions were last attached to
+type IdleLister interface {
+	// ListIdle returns the sessions no client is attached to, by session name, with when a client
+	// was last attached, or when the session was created if none ever was
+	ListIdle() (map[string]time.Time, error)
+}
+
+// AttachOptions changes how a client attaches to a session
+type AttachOptions struct {
+	// ReadOnly attaches without input reaching the session, e.g. to follow someone's pairing
+	ReadOnly bool
+	// DetachOthers detaches the other clients of the session, e.g. one left on another monitor
+	DetachOthers bool
+}
+
+// OptionsAttacher is implemented by session backends that can attach with AttachOptions
+type OptionsAttacher interface {
+	// AttachWithOptions attaches to an existing session like Attach, with the given options
+	AttachWithOptions(name string, opts AttachOptions) error
+}
+
+// CreateOptions changes how a session is created
+type CreateOptions struct {
+	// Env is set in the environment of the session, e.g. SESH_BRANCH for scripts run in it
+	Env map[string]string
+	// StatusStyle is the style of the session's status bar, e.g. "bg=blue,fg=white"
+	StatusStyle string
+	// Shell is the shell command the windows of the session start instead of the default
+	// shell, e.g. one opening a shell in a container
+	Shell string
+}
+
+// OptionsCreator is implemented by session backends that can create with CreateOptions
+type OptionsCreator interface {
+	// CreateWithOptions creates a session like Create, with the given options
+	CreateWithOptions(name, path string, opts CreateOptions) error
+}
+
+// Titler is implemented by session backends that can title the window of a session
+type Titler interface {
+	// SetTitle titles the current window and pane of a session, e.g. "repo:main"
+	SetTitle(name, title string) error
+}
+
+// CommandWaiter is implemented by session backends that can wait for a command run in a session
+// to exit, e.g. so the startup command finishes before the session is attached to
+type CommandWaiter interface {
+	// RunCommandNotify runs a command like RunCommand, and notifies WaitForCommand once it exits
+	RunCommandNotify(name, command string) error
+	// WaitForCommand waits for the command run with RunCommandNotify in a session to exit
+	WaitForCommand(ctx context.Context, name string) error
 }
 
 // BackendType represents the type of session backend
@@ -198,6 +273,18 @@ func (n *NoneManager) GetCurrentSessionName() (string, error) {
 	return "", nil
 }
 
+func (n *NoneManager) Rename(oldName, newName string) error {
+	return eris.Wrap(ErrNotSupported, "no session manager available")
+}
+
+func (n *NoneManager) AttachedClients(name string) ([]string, error) {
+	return nil, nil
+}
+
+func (n *NoneManager) RunCommand(name, command string) error {
+	return eris.Wrap(ErrNotSupported, "no session manager available")
+}
+
 // IsInsideTmux checks if the current process is running inside tmux
 func IsInsideTmux() bool {
 	return os.Getenv("TMUX") != ""
@@ -3,6 +3,8 @@ package session
 import (
 	"os"
 	"testing"
+
+	"github.com/rotisserie/eris"
 )
 
 func TestGetBackendName(t *testing.T) {
@@ -111,6 +113,30 @@ func TestNoneManager(t *testing.T) {
 		}
 	})
 
+	t.Run("Rename is not supported", func(t *testing.T) {
+		err := mgr.Rename("test", "other")
+		if !eris.Is(err, ErrNotSupported) {
+			t.Errorf("Rename() = %v, want ErrNotSupported", err)
+		}
+	})
+
+	t.Run("RunCommand is not supported", func(t *testing.T) {
+		err := mgr.RunCommand("test", "make")
+		if !eris.Is(err, ErrNotSupported) {
+			t.Errorf("RunCommand() = %v, want ErrNotSupported", err)
+		}
+	})
+
+	t.Run("AttachedClients returns none", func(t *testing.T) {
+		clients, err := mgr.AttachedClients("test")
+		if err != nil {
+			t.Errorf("AttachedClients() returned error: %v", err)
+		}
+		if len(clients) != 0 {
+			t.Errorf("AttachedClients() returned %d clients, want 0", len(clients))
+		}
+	})
+
 	t.Run("IsRunning returns false", func(t *testing.T) {
 		running, err := mgr.IsRunning()
 		if err != nil {
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"regexp"
+
+	"github.com/rotisserie/eris"
+)
+
+// processNamePattern matches the names of processes, which name their windows
+var processNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Process is a long-running command of a worktree, like a dev server or a watcher, that runs in
+// a window of its own in the worktree's session
+type Process struct {
+	// Name of the process and its window, e.g. "server"
+	Name string `yaml:"name"`
+	// Command run by the shell in the worktree, e.g. "npm run dev"
+	Command string `yaml:"command"`
+}
+
+// ProcessStatus is whether a process of a session is still running
+type ProcessStatus struct {
+	Name    string `json:"name"`
+	Running bool   `json:"running"`
+	// ExitStatus is what the process exited with, once it isn't running
+	ExitStatus int `json:"exit_status,omitempty"`
+}
+
+// ProcessSupervisor is implemented by session backends that can run processes in windows of a
+// session and tell whether they are still running
+type ProcessSupervisor interface {
+	// StartProcess runs a process in a new window of a session, in the given directory, and
+	// captures its output to logPath
+	StartProcess(name, path string, proc Process, logPath string) error
+	// ListProcesses returns the status of the processes started in a session
+	ListProcesses(name string) ([]ProcessStatus, error)
+	// StopProcesses interrupts the processes of a session and closes their windows
+	StopProcesses(name string) error
+}
+
+// ValidateProcesses checks that processes have a command and distinct names that can name
+// windows
+func ValidateProcesses(processes []Process) error {
+	seen := make(map[string]bool)
+	for _, proc := range processes {
+		if !processNamePattern.MatchString(proc.Name) {
+			return eris.Errorf(
+				"invalid process name: %q (must be letters, digits, '.', '_', or '-')",
+				proc.Name,
+			)
+		}
+		if seen[proc.Name] {
+			return eris.Errorf("duplicate process name: %s", proc.Name)
+		}
+		seen[proc.Name] = true
+		if proc.Command == "" {
+			return eris.Errorf("process %s has no command", proc.Name)
+		}
+	}
+	return nil
+}
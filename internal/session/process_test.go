@@ -0,0 +1,25 @@
+package session
+
+import "testing"
+
+func TestValidateProcesses(t *testing.T) {
+	tests := []struct {
+		name      string
+		processes []Process
+		wantErr   bool
+	}{
+		{name: "none"},
+		{name: "valid", processes: []Process{{Name: "server", Command: "npm run dev"}, {Name: "watch", Command: "make"}}},
+		{name: "invalid name", processes: []Process{{Name: "dev server", Command: "npm run dev"}}, wantErr: true},
+		{name: "duplicate name", processes: []Process{{Name: "a", Command: "a"}, {Name: "a", Command: "b"}}, wantErr: true},
+		{name: "no command", processes: []Process{{Name: "server"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateProcesses(tt.processes); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateProcesses() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
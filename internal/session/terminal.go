@@ -0,0 +1,113 @@
+package session
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// AttachCommander is implemented by session backends that can attach from a command run in
+// another terminal
+type AttachCommander interface {
+	// AttachCommand returns the command attaching to a session with the given options
+	AttachCommand(name string, opts AttachOptions) []string
+}
+
+// terminalPresets are the terminal_command of the terminal emulators known by name
+var terminalPresets = map[string]string{
+	"kitty":     "kitty --detach {command}",
+	"alacritty": "alacritty -e {command}",
+	"wezterm":   "wezterm start -- {command}",
+}
+
+// TerminalNames are the terminal emulators terminal_command can name instead of a command
+var TerminalNames = []string{"kitty", "alacritty", "wezterm", "iterm"}
+
+// ValidateTerminalCommand checks a terminal_command: the name of a known terminal emulator, or
+// a command with a {command} argument
+func ValidateTerminalCommand(terminal string) error {
+	if terminal == "" || terminal == "iterm" || terminalPresets[terminal] != "" {
+		return nil
+	}
+	if !strings.Contains(terminal, "{command}") {
+		return eris.Errorf(
+			"the terminal command must contain {command}, or be one of: %s",
+			strings.Join(TerminalNames, ", "),
+		)
+	}
+	if !slices.Contains(strings.Fields(terminal), "{command}") {
+		return eris.New("{command} must be an argument of its own, e.g. \"foot {command}\"")
+	}
+	return nil
+}
+
+// TerminalCommand returns the command opening a new window of the terminal emulator running
+// command. The {command} argument of terminal is replaced with the arguments of command.
+func TerminalCommand(terminal string, command []string) ([]string, error) {
+	if err := ValidateTerminalCommand(terminal); err != nil {
+		return nil, err
+	}
+	if terminal == "" {
+		return nil, eris.Errorf("no terminal command set (one of: %s)", strings.Join(TerminalNames, ", "))
+	}
+
+	// iTerm2 only runs commands through AppleScript, as a single string
+	if terminal == "iterm" {
+		quoted := make([]string, len(command))
+		for i, arg := range command {
+			quoted[i] = ShellQuote(arg)
+		}
+		script := "tell application \"iTerm2\" to create window with default profile command " +
+			strconv.Quote(strings.Join(quoted, " "))
+		return []string{"osascript", "-e", script}, nil
+	}
+
+	if preset, ok := terminalPresets[terminal]; ok {
+		terminal = preset
+	}
+
+	var args []string
+	for _, field := range strings.Fields(terminal) {
+		if field == "{command}" {
+			args = append(args, command...)
+		} else {
+			args = append(args, field)
+		}
+	}
+	return args, nil
+}
+
+// OpenInTerminal starts a new terminal window running command, without waiting for it. The
+// window doesn't inherit the current session, so its multiplexer doesn't refuse to nest.
+func OpenInTerminal(terminal string, command []string) error {
+	args, err := TerminalCommand(terminal, command)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, "TMUX=") && !strings.HasPrefix(env, "ZELLIJ") {
+			cmd.Env = append(cmd.Env, env)
+		}
+	}
+	// The window outlives sesh, and the terminal sesh was started from
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return eris.Wrapf(err, "failed to start terminal: %s", args[0])
+	}
+	return cmd.Process.Release()
+}
+
+// ShellQuote quotes an argument for sh, unless it only has safe characters
+func ShellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@=+") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
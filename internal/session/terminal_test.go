@@ -0,0 +1,66 @@
+package session
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTerminalCommand(t *testing.T) {
+	attach := []string{"tmux", "attach-session", "-t", "repo-main"}
+
+	tests := []struct {
+		name     string
+		terminal string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "known terminal",
+			terminal: "alacritty",
+			want:     []string{"alacritty", "-e", "tmux", "attach-session", "-t", "repo-main"},
+		},
+		{
+			name:     "custom command",
+			terminal: "foot --app-id sesh {command}",
+			want:     []string{"foot", "--app-id", "sesh", "tmux", "attach-session", "-t", "repo-main"},
+		},
+		{
+			name:     "iterm",
+			terminal: "iterm",
+			want: []string{
+				"osascript", "-e",
+				`tell application "iTerm2" to create window with default profile command "tmux attach-session -t repo-main"`,
+			},
+		},
+		{name: "unset", terminal: "", wantErr: true},
+		{name: "no placeholder", terminal: "foot", wantErr: true},
+		{name: "placeholder inside an argument", terminal: "foot -e={command}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TerminalCommand(tt.terminal, attach)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TerminalCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("TerminalCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"repo-main":      "repo-main",
+		"it's":           `'it'\''s'`,
+		"with space":     "'with space'",
+		"":               "''",
+		"feature/x@v1.2": "feature/x@v1.2",
+	}
+	for arg, want := range tests {
+		if got := ShellQuote(arg); got != want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", arg, got, want)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package session
 
 import (
 	"bufio"
+	"context"
+	"maps"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
-	"syscall"
+	"time"
 
 	"github.com/rotisserie/eris"
 )
@@ -20,6 +24,12 @@ func NewTmuxManager() *TmuxManager {
 
 // Create creates a new tmux session with the given name at the specified path
 func (t *TmuxManager) Create(name, path string) error {
+	return t.CreateWithOptions(name, path, CreateOptions{})
+}
+
+// CreateWithOptions creates a new tmux session with the given name at the specified path, with
+// the environment variables of opts (new-session -e) and its status bar style
+func (t *TmuxManager) CreateWithOptions(name, path string, opts CreateOptions) error {
 	// Check if session already exists
 	exists, err := t.Exists(name)
 	if err != nil {
@@ -30,18 +40,74 @@ func (t *TmuxManager) Create(name, path string) error {
 	}
 
 	// Create detached session at the specified path
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", name, "-c", path)
+	cmd := exec.Command("tmux", tmuxNewSessionArgs(name, path, opts)...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to create tmux session: %s", string(output))
 	}
 
+	if opts.StatusStyle != "" {
+		cmd := exec.Command("tmux", "set-option", "-t", name, "status-style", opts.StatusStyle)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return eris.Wrapf(err, "failed to set status-style of tmux session: %s", string(output))
+		}
+	}
+
+	// New windows and panes start the shell too, not only the first one
+	if opts.Shell != "" {
+		cmd := exec.Command("tmux", "set-option", "-t", name, "default-command", opts.Shell)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return eris.Wrapf(err, "failed to set default-command of tmux session: %s", string(output))
+		}
+	}
+
 	return nil
 }
 
+// SetTitle renames the current window of a tmux session, which turns off its automatic
+// renaming, and titles its current pane, so the status line shows the title
+func (t *TmuxManager) SetTitle(name, title string) error {
+	cmd := exec.Command("tmux", tmuxSetTitleArgs(name, title)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to set title of tmux session: %s", string(output))
+	}
+	return nil
+}
+
+// tmuxSetTitleArgs returns the arguments of tmux titling the current window and pane of a
+// session, as one command list so both are set at once
+func tmuxSetTitleArgs(name, title string) []string {
+	target := "=" + name + ":"
+	return []string{"rename-window", "-t", target, title, ";", "select-pane", "-t", target, "-T", title}
+}
+
+// tmuxNewSessionArgs returns the arguments of tmux creating a detached session, with the
+// environment variables in a stable order, and the shell command of its first window
+func tmuxNewSessionArgs(name, path string, opts CreateOptions) []string {
+	args := []string{"new-session", "-d", "-s", name, "-c", path}
+	// sesh run inside the session uses the same profile as the sesh that created it
+	if profile := os.Getenv("SESH_PROFILE"); profile != "" {
+		args = append(args, "-e", "SESH_PROFILE="+profile)
+	}
+	for _, key := range slices.Sorted(maps.Keys(opts.Env)) {
+		args = append(args, "-e", key+"="+opts.Env[key])
+	}
+	if opts.Shell != "" {
+		args = append(args, opts.Shell)
+	}
+	return args
+}
+
 // Attach attaches to an existing tmux session
 // This replaces the current process with tmux attach
 func (t *TmuxManager) Attach(name string) error {
+	return t.AttachWithOptions(name, AttachOptions{})
+}
+
+// AttachWithOptions attaches to an existing tmux session read-only (attach -r) or detaching
+// its other clients (attach -d). This replaces the current process with tmux attach.
+func (t *TmuxManager) AttachWithOptions(name string, opts AttachOptions) error {
 	// Check if session exists
 	exists, err := t.Exists(name)
 	if err != nil {
@@ -53,6 +119,15 @@ func (t *TmuxManager) Attach(name string) error {
 
 	// If we're already inside tmux, use switch instead
 	if t.IsInsideSession() {
+		// switch-client -r toggles the current client, which stays read-only in every session
+		if opts.ReadOnly {
+			return eris.New("attaching read-only needs a new tmux client: run it outside of tmux")
+		}
+		if opts.DetachOthers {
+			if err := t.detachOtherClients(name); err != nil {
+				return err
+			}
+		}
 		return t.Switch(name)
 	}
 
@@ -63,7 +138,7 @@ func (t *TmuxManager) Attach(name string) error {
 		return eris.Wrap(err, "tmux not found in PATH")
 	}
 
-	err = syscall.Exec(tmuxPath, []string{"tmux", "attach-session", "-t", name}, os.Environ())
+	err = execAttach(tmuxPath, t.AttachCommand(name, opts))
 	if err != nil {
 		return eris.Wrap(err, "failed to exec tmux attach")
 	}
@@ -71,6 +146,50 @@ func (t *TmuxManager) Attach(name string) error {
 	return nil
 }
 
+// AttachCommand returns the tmux attach-session command attaching to a session
+func (t *TmuxManager) AttachCommand(name string, opts AttachOptions) []string {
+	args := []string{"tmux", "attach-session", "-t", name}
+	if opts.ReadOnly {
+		args = append(args, "-r")
+	}
+	if opts.DetachOthers {
+		args = append(args, "-d")
+	}
+	return args
+}
+
+// AttachedClients returns the names of the clients attached to a tmux session, e.g. "/dev/pts/3"
+func (t *TmuxManager) AttachedClients(name string) ([]string, error) {
+	output, err := exec.Command("tmux", "list-clients", "-t", "="+name, "-F", "#{client_name}").Output()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list tmux clients")
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// detachOtherClients detaches the clients attached to a session, except the current one
+func (t *TmuxManager) detachOtherClients(name string) error {
+	current, err := exec.Command("tmux", "display-message", "-p", "#{client_name}").Output()
+	if err != nil {
+		return eris.Wrap(err, "failed to get current tmux client")
+	}
+
+	clients, err := t.AttachedClients(name)
+	if err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		if client == strings.TrimSpace(string(current)) {
+			continue
+		}
+		if output, err := exec.Command("tmux", "detach-client", "-t", client).CombinedOutput(); err != nil {
+			return eris.Wrapf(err, "failed to detach tmux client %s: %s", client, string(output))
+		}
+	}
+	return nil
+}
+
 // Switch switches to a different tmux session (when already inside tmux)
 func (t *TmuxManager) Switch(name string) error {
 	// Check if session exists
@@ -112,6 +231,40 @@ func (t *TmuxManager) List() ([]string, error) {
 	return parseTmuxList(string(output)), nil
 }
 
+// ListPaths returns the start directory of each active tmux session
+func (t *TmuxManager) ListPaths() (map[string]string, error) {
+	// Session names can't contain colons, but tmux may print tabs as underscores
+	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}:#{session_path}")
+	output, err := cmd.Output()
+	if err != nil {
+		// If no sessions exist, tmux returns an error
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return map[string]string{}, nil
+		}
+		return nil, eris.Wrap(err, "failed to list tmux session paths")
+	}
+
+	return parseTmuxPaths(string(output)), nil
+}
+
+// ListIdle returns the tmux sessions no client is attached to, with when one last was
+func (t *TmuxManager) ListIdle() (map[string]time.Time, error) {
+	cmd := exec.Command(
+		"tmux", "list-sessions", "-F",
+		"#{session_name}:#{session_attached}:#{session_last_attached}:#{session_created}",
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		// If no sessions exist, tmux returns an error
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return map[string]time.Time{}, nil
+		}
+		return nil, eris.Wrap(err, "failed to list tmux session activity")
+	}
+
+	return parseTmuxIdle(string(output)), nil
+}
+
 // Delete kills a tmux session
 func (t *TmuxManager) Delete(name string) error {
 	// Check if session exists
@@ -190,6 +343,40 @@ func parseTmuxList(output string) []string {
 	return sessions
 }
 
+// parseTmuxPaths parses the output of tmux list-sessions with session names and paths
+// separated by colons
+func parseTmuxPaths(output string) map[string]string {
+	paths := map[string]string{}
+	for _, line := range parseTmuxList(output) {
+		if name, path, ok := strings.Cut(line, ":"); ok {
+			paths[name] = path
+		}
+	}
+	return paths
+}
+
+// parseTmuxIdle parses the output of tmux list-sessions with the format of ListIdle, where
+// times are Unix timestamps and the last attached time is empty for sessions never attached to
+func parseTmuxIdle(output string) map[string]time.Time {
+	idle := map[string]time.Time{}
+	for _, line := range parseTmuxList(output) {
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 || fields[1] != "0" {
+			continue
+		}
+		last := fields[2]
+		if last == "" {
+			last = fields[3]
+		}
+		seconds, err := strconv.ParseInt(last, 10, 64)
+		if err != nil {
+			continue
+		}
+		idle[fields[0]] = time.Unix(seconds, 0)
+	}
+	return idle
+}
+
 // GetCurrentSessionName returns the name of the current tmux session
 // Returns empty string if not inside a session
 func (t *TmuxManager) GetCurrentSessionName() (string, error) {
@@ -253,8 +440,8 @@ func (t *TmuxManager) Rename(oldName, newName string) error {
 	return nil
 }
 
-// SendKeys sends keys/commands to a tmux session
-func (t *TmuxManager) SendKeys(name, command string) error {
+// RunCommand types a command into the current pane of a tmux session and presses enter
+func (t *TmuxManager) RunCommand(name, command string) error {
 	// Check if session exists
 	exists, err := t.Exists(name)
 	if err != nil {
@@ -273,3 +460,137 @@ func (t *TmuxManager) SendKeys(name, command string) error {
 
 	return nil
 }
+
+// RunCommandNotify runs a command in a tmux session like RunCommand, then signals the session's
+// wait-for channel. The signal is kept until WaitForCommand waits for it.
+func (t *TmuxManager) RunCommandNotify(name, command string) error {
+	signal := "tmux wait-for -S " + ShellQuote(tmuxWaitChannel(name))
+	return t.RunCommand(name, strings.TrimRight(command, "\n")+"\n"+signal)
+}
+
+// WaitForCommand waits for the command run with RunCommandNotify in a tmux session to exit
+func (t *TmuxManager) WaitForCommand(ctx context.Context, name string) error {
+	cmd := exec.CommandContext(ctx, "tmux", "wait-for", tmuxWaitChannel(name))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return eris.Wrapf(err, "failed to wait for command in tmux session: %s", string(output))
+	}
+	return nil
+}
+
+// tmuxWaitChannel returns the wait-for channel of the commands sesh waits for in a session
+func tmuxWaitChannel(name string) string {
+	return "sesh-command-" + name
+}
+
+// processStopTimeout is how long the processes of a session get to exit after an interrupt,
+// before their windows are closed
+const processStopTimeout = 5 * time.Second
+
+// tmuxProcessWindow is a window running a process, by window ID like "@3"
+type tmuxProcessWindow struct {
+	id     string
+	status ProcessStatus
+}
+
+// StartProcess runs a process in a new background window of a tmux session, named after the
+// process. The window stays open once the process exited, so its exit status can be shown.
+// The output of the process is piped to logPath, replacing the output of earlier runs.
+func (t *TmuxManager) StartProcess(name, path string, proc Process, logPath string) error {
+	cmd := exec.Command(
+		"tmux", "new-window", "-d", "-P", "-F", "#{window_id}",
+		"-t", "="+name+":", "-n", proc.Name, "-c", path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return eris.Wrapf(err, "failed to create window of process %s", proc.Name)
+	}
+
+	// The window starts with a shell, replaced by the process once remain-on-exit is set and
+	// the output is piped, so processes that exit right away leave their window and output
+	window := strings.TrimSpace(string(output))
+	cmd = exec.Command(
+		"tmux", "set-option", "-w", "-t", window, "remain-on-exit", "on",
+		";", "set-option", "-w", "-t", window, "@sesh-process", proc.Name,
+		";", "pipe-pane", "-t", window, "cat > "+ShellQuote(logPath),
+		";", "respawn-pane", "-k", "-t", window, "-c", path, proc.Command,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return eris.Wrapf(err, "failed to start process %s: %s", proc.Name, string(output))
+	}
+	return nil
+}
+
+// ListProcesses returns the status of the processes started in a tmux session
+func (t *TmuxManager) ListProcesses(name string) ([]ProcessStatus, error) {
+	windows, err := t.listProcessWindows(name)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]ProcessStatus, 0, len(windows))
+	for _, window := range windows {
+		statuses = append(statuses, window.status)
+	}
+	return statuses, nil
+}
+
+// StopProcesses interrupts the processes of a tmux session with Ctrl-C, so they can shut down
+// cleanly, and closes their windows once they exited or processStopTimeout passed
+func (t *TmuxManager) StopProcesses(name string) error {
+	windows, err := t.listProcessWindows(name)
+	if err != nil || len(windows) == 0 {
+		return err
+	}
+
+	for _, window := range windows {
+		if window.status.Running {
+			_ = exec.Command("tmux", "send-keys", "-t", window.id, "C-c").Run()
+		}
+	}
+
+	deadline := time.Now().Add(processStopTimeout)
+	for time.Now().Before(deadline) {
+		statuses, err := t.ListProcesses(name)
+		if err != nil || !slices.ContainsFunc(statuses, func(s ProcessStatus) bool { return s.Running }) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, window := range windows {
+		if output, err := exec.Command("tmux", "kill-window", "-t", window.id).CombinedOutput(); err != nil {
+			return eris.Wrapf(err, "failed to close window of process %s: %s", window.status.Name, string(output))
+		}
+	}
+	return nil
+}
+
+// listProcessWindows returns the windows of a tmux session that run processes
+func (t *TmuxManager) listProcessWindows(name string) ([]tmuxProcessWindow, error) {
+	cmd := exec.Command(
+		"tmux", "list-windows", "-t", "="+name, "-F",
+		"#{window_id}:#{@sesh-process}:#{pane_dead}:#{pane_dead_status}",
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list tmux windows")
+	}
+	return parseTmuxProcessWindows(string(output)), nil
+}
+
+// parseTmuxProcessWindows parses lines of window ID, process name, whether the pane is dead,
+// and its exit status, skipping the windows that don't run processes
+func parseTmuxProcessWindows(output string) []tmuxProcessWindow {
+	var windows []tmuxProcessWindow
+	for _, line := range parseTmuxList(output) {
+		fields := strings.Split(line, ":")
+		if len(fields) != 4 || fields[1] == "" {
+			continue
+		}
+		window := tmuxProcessWindow{id: fields[0], status: ProcessStatus{Name: fields[1], Running: fields[2] != "1"}}
+		if !window.status.Running {
+			window.status.ExitStatus, _ = strconv.Atoi(fields[3])
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
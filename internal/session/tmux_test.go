@@ -0,0 +1,170 @@
+package session
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTmuxPaths(t *testing.T) {
+	output := "repo-main:/home/user/.sesh/github.com/user/repo/main\n" +
+		"scratch:/home/user/with:colon\n" +
+		"\n" +
+		"no-path\n"
+
+	want := map[string]string{
+		"repo-main": "/home/user/.sesh/github.com/user/repo/main",
+		"scratch":   "/home/user/with:colon",
+	}
+	if got := parseTmuxPaths(output); !maps.Equal(got, want) {
+		t.Errorf("parseTmuxPaths() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTmuxIdle(t *testing.T) {
+	output := "repo-main:0:1760000000:1750000000\n" +
+		"repo-feature:0::1750000000\n" +
+		"repo-attached:1:1760000000:1750000000\n" +
+		"repo-broken:0:soon:1750000000\n" +
+		"\n"
+
+	want := map[string]time.Time{
+		"repo-main":    time.Unix(1760000000, 0),
+		"repo-feature": time.Unix(1750000000, 0),
+	}
+	if got := parseTmuxIdle(output); !maps.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("parseTmuxIdle() = %v, want %v", got, want)
+	}
+}
+
+func TestTmuxNewSessionArgs(t *testing.T) {
+	t.Setenv("SESH_PROFILE", "work")
+
+	env := map[string]string{
+		"SESH_WORKTREE": "/home/user/.sesh/repo/main",
+		"SESH_BRANCH":   "main",
+		"SESH_PROJECT":  "github.com/user/repo",
+	}
+	want := []string{
+		"new-session", "-d", "-s", "repo-main", "-c", "/home/user/.sesh/repo/main",
+		"-e", "SESH_PROFILE=work",
+		"-e", "SESH_BRANCH=main",
+		"-e", "SESH_PROJECT=github.com/user/repo",
+		"-e", "SESH_WORKTREE=/home/user/.sesh/repo/main",
+	}
+	opts := CreateOptions{Env: env}
+	if got := tmuxNewSessionArgs("repo-main", "/home/user/.sesh/repo/main", opts); !slices.Equal(got, want) {
+		t.Errorf("tmuxNewSessionArgs() = %v, want %v", got, want)
+	}
+
+	opts.Shell = "devcontainer exec --workspace-folder /home/user/.sesh/repo/main sh"
+	want = append(want, opts.Shell)
+	if got := tmuxNewSessionArgs("repo-main", "/home/user/.sesh/repo/main", opts); !slices.Equal(got, want) {
+		t.Errorf("tmuxNewSessionArgs() with a shell = %v, want %v", got, want)
+	}
+}
+
+func TestParseTmuxProcessWindows(t *testing.T) {
+	output := "@1::0:\n" +
+		"@2:server:0:\n" +
+		"@3:watch:1:2\n" +
+		"\n"
+
+	want := []tmuxProcessWindow{
+		{id: "@2", status: ProcessStatus{Name: "server", Running: true}},
+		{id: "@3", status: ProcessStatus{Name: "watch", ExitStatus: 2}},
+	}
+	if got := parseTmuxProcessWindows(output); !slices.Equal(got, want) {
+		t.Errorf("parseTmuxProcessWindows() = %v, want %v", got, want)
+	}
+}
+
+func TestTmuxAttachCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		opts AttachOptions
+		want []string
+	}{
+		{name: "plain", want: []string{"tmux", "attach-session", "-t", "repo-main"}},
+		{
+			name: "read-only",
+			opts: AttachOptions{ReadOnly: true},
+			want: []string{"tmux", "attach-session", "-t", "repo-main", "-r"},
+		},
+		{
+			name: "detach others",
+			opts: AttachOptions{DetachOthers: true},
+			want: []string{"tmux", "attach-session", "-t", "repo-main", "-d"},
+		},
+		{
+			name: "both",
+			opts: AttachOptions{ReadOnly: true, DetachOthers: true},
+			want: []string{"tmux", "attach-session", "-t", "repo-main", "-r", "-d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewTmuxManager().AttachCommand("repo-main", tt.opts); !slices.Equal(got, tt.want) {
+				t.Errorf("AttachCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTmuxRunCommandNotify(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux is not installed")
+	}
+
+	// A tmux server of the test's own, so no sessions of the user are touched
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	t.Setenv("SHELL", "/bin/sh")
+	t.Cleanup(func() {
+		//nolint:errcheck // The server may have exited already
+		exec.Command("tmux", "kill-server").Run()
+	})
+
+	tmux := NewTmuxManager()
+	if err := tmux.Create("sesh-test", t.TempDir()); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	marker := filepath.Join(t.TempDir(), "done")
+	if err := tmux.RunCommandNotify("sesh-test", "sleep 0.2 && touch "+ShellQuote(marker)); err != nil {
+		t.Fatalf("RunCommandNotify() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	if err := tmux.WaitForCommand(ctx, "sesh-test"); err != nil {
+		t.Fatalf("WaitForCommand() failed: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("WaitForCommand() returned before the command exited: %v", err)
+	}
+
+	// Without a command to signal it, waiting only ends with the context
+	ctx, cancel = context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	if err := tmux.WaitForCommand(ctx, "sesh-test"); err == nil {
+		t.Error("WaitForCommand() without a command returned no error")
+	}
+}
+
+func TestTmuxSetTitleArgs(t *testing.T) {
+	want := []string{
+		"rename-window", "-t", "=repo-main:", "repo:main",
+		";",
+		"select-pane", "-t", "=repo-main:", "-T", "repo:main",
+	}
+	if got := tmuxSetTitleArgs("repo-main", "repo:main"); !slices.Equal(got, want) {
+		t.Errorf("tmuxSetTitleArgs() = %v, want %v", got, want)
+	}
+}
@@ -2,10 +2,12 @@ package session
 
 import (
 	"bufio"
+	"context"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
-	"syscall"
+	"time"
 
 	"github.com/rotisserie/eris"
 )
@@ -20,6 +22,12 @@ func NewZellijManager() *ZellijManager {
 
 // Create creates a new zellij session with the given name at the specified path
 func (z *ZellijManager) Create(name, path string) error {
+	return z.CreateWithOptions(name, path, CreateOptions{})
+}
+
+// CreateWithOptions creates a new zellij session with the environment variables of opts.
+// Zellij has no per-session status bar style, so StatusStyle is ignored.
+func (z *ZellijManager) CreateWithOptions(name, path string, opts CreateOptions) error {
 	// Check if session already exists
 	exists, err := z.Exists(name)
 	if err != nil {
@@ -35,6 +43,10 @@ func (z *ZellijManager) Create(name, path string) error {
 	// Using 'zellij attach <name> --create' with shell backgrounding
 	shellScript := `cd "` + path + `" && (setsid zellij --session "` + name + `" > /dev/null 2>&1 &)`
 	cmd := exec.Command("sh", "-c", shellScript)
+	cmd.Env = os.Environ()
+	for key, value := range opts.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
 
 	if err := cmd.Run(); err != nil {
 		return eris.Wrapf(err, "failed to create zellij session")
@@ -45,6 +57,11 @@ func (z *ZellijManager) Create(name, path string) error {
 	cmd = exec.Command("sleep", "0.5")
 	_ = cmd.Run()
 
+	// zellij's default shell must be a program, so the shell command replaces the shell of the
+	// first pane; other panes start the default shell
+	if opts.Shell != "" {
+		return z.RunCommand(name, "exec "+opts.Shell)
+	}
 	return nil
 }
 
@@ -73,7 +90,7 @@ func (z *ZellijManager) Attach(name string) error {
 		return eris.Wrap(err, "zellij not found in PATH")
 	}
 
-	err = syscall.Exec(zellijPath, []string{"zellij", "attach", name}, os.Environ())
+	err = execAttach(zellijPath, z.AttachCommand(name, AttachOptions{}))
 	if err != nil {
 		return eris.Wrap(err, "failed to exec zellij attach")
 	}
@@ -81,6 +98,12 @@ func (z *ZellijManager) Attach(name string) error {
 	return nil
 }
 
+// AttachCommand returns the zellij attach command attaching to a session. zellij has no
+// attach options, so opts is ignored.
+func (z *ZellijManager) AttachCommand(name string, opts AttachOptions) []string {
+	return []string{"zellij", "attach", name}
+}
+
 // Switch switches to a different zellij session (when already inside zellij)
 func (z *ZellijManager) Switch(name string) error {
 	// Check if session exists
@@ -204,6 +227,59 @@ func parseZellijList(output string) []string {
 	return sessions
 }
 
+// Rename renames a zellij session
+func (z *ZellijManager) Rename(oldName, newName string) error {
+	// Check if old session exists
+	exists, err := z.Exists(oldName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return eris.Errorf("session '%s' does not exist", oldName)
+	}
+
+	// Check if new name is already taken
+	exists, err = z.Exists(newName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return eris.Errorf("session '%s' already exists", newName)
+	}
+
+	cmd := exec.Command("zellij", "--session", oldName, "action", "rename-session", newName)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to rename zellij session: %s", string(output))
+	}
+
+	return nil
+}
+
+// AttachedClients returns the IDs of the clients attached to a zellij session
+func (z *ZellijManager) AttachedClients(name string) ([]string, error) {
+	cmd := exec.Command("zellij", "--session", name, "action", "list-clients")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to list zellij clients")
+	}
+
+	return parseZellijClients(string(output)), nil
+}
+
+// parseZellijClients parses the output of zellij action list-clients, a table of the client
+// IDs with the pane and command each one is focused on, under a CLIENT_ID header
+func parseZellijClients(output string) []string {
+	var clients []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] != "CLIENT_ID" {
+			clients = append(clients, fields[0])
+		}
+	}
+	return clients
+}
+
 // GetCurrentSessionName returns the name of the current zellij session
 // Returns empty string if not inside a session
 func (z *ZellijManager) GetCurrentSessionName() (string, error) {
@@ -251,8 +327,8 @@ func (z *ZellijManager) CreateOrAttach(name, path string) error {
 	return z.Attach(name)
 }
 
-// SendKeys sends commands to a zellij session
-func (z *ZellijManager) SendKeys(name, command string) error {
+// RunCommand types a command into the focused pane of a zellij session and presses enter
+func (z *ZellijManager) RunCommand(name, command string) error {
 	// Check if session exists
 	exists, err := z.Exists(name)
 	if err != nil {
@@ -262,10 +338,8 @@ func (z *ZellijManager) SendKeys(name, command string) error {
 		return eris.Errorf("session '%s' does not exist", name)
 	}
 
-	// Zellij doesn't have a direct equivalent to tmux send-keys
-	// We can use zellij action write to send text to the current pane
-	// Note: This is a limitation compared to tmux
-	cmd := exec.Command("zellij", "action", "write", "27", command) // 27 is the escape key code
+	// Zellij has no send-keys; write-chars types into the focused pane of the session
+	cmd := exec.Command("zellij", "--session", name, "action", "write-chars", command+"\n")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return eris.Wrapf(err, "failed to send keys to zellij session: %s", string(output))
@@ -273,3 +347,35 @@ func (z *ZellijManager) SendKeys(name, command string) error {
 
 	return nil
 }
+
+// RunCommandNotify runs a command in a zellij session like RunCommand, then creates a sentinel
+// file for WaitForCommand, since zellij has nothing like tmux wait-for
+func (z *ZellijManager) RunCommandNotify(name, command string) error {
+	sentinel := zellijSentinelPath(name)
+	if err := os.Remove(sentinel); err != nil && !os.IsNotExist(err) {
+		return eris.Wrap(err, "failed to remove sentinel file")
+	}
+	return z.RunCommand(name, strings.TrimRight(command, "\n")+"\ntouch "+ShellQuote(sentinel))
+}
+
+// WaitForCommand waits for the sentinel file of the command run with RunCommandNotify
+func (z *ZellijManager) WaitForCommand(ctx context.Context, name string) error {
+	sentinel := zellijSentinelPath(name)
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if _, err := os.Stat(sentinel); err == nil {
+			return os.Remove(sentinel)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// zellijSentinelPath returns the sentinel file of the commands sesh waits for in a session
+func zellijSentinelPath(name string) string {
+	return filepath.Join(os.TempDir(), "sesh-command-"+name)
+}
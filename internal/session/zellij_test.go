@@ -2,6 +2,7 @@ package session
 
 import (
 	"os"
+	"slices"
 	"testing"
 )
 
@@ -138,6 +139,20 @@ func TestParseZellijList(t *testing.T) {
 	}
 }
 
+func TestParseZellijClients(t *testing.T) {
+	output := "CLIENT_ID ZELLIJ_PANE_ID RUNNING_COMMAND\n" +
+		"1         terminal_0     zsh\n" +
+		"3         plugin_2       zellij:strider\n"
+
+	want := []string{"1", "3"}
+	if got := parseZellijClients(output); !slices.Equal(got, want) {
+		t.Errorf("parseZellijClients() = %v, want %v", got, want)
+	}
+	if got := parseZellijClients("CLIENT_ID ZELLIJ_PANE_ID RUNNING_COMMAND\n"); len(got) != 0 {
+		t.Errorf("parseZellijClients() = %v, want none", got)
+	}
+}
+
 func TestNewSessionManager_Zellij(t *testing.T) {
 	t.Run("creates zellij manager", func(t *testing.T) {
 		mgr, err := NewSessionManager("zellij")
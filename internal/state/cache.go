@@ -0,0 +1,143 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/atomicfile"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+// cacheDir is where DiscoverProjects caches its results; empty disables the cache
+var cacheDir string
+
+// SetCacheDir enables the project discovery cache in dir, or disables it when dir is empty
+func SetCacheDir(dir string) {
+	cacheDir = dir
+}
+
+// projectCache is the on-disk form of a cached DiscoverProjects result
+// It is valid as long as none of the tracked paths have changed modification time. Adding or
+// removing a project changes the mtime of its parent directory, and changing its remote URL
+// changes the mtime of the repo's config file. Changing the discovery depth limit or the
+// workspace ignore file also invalidates it.
+type projectCache struct {
+	WorkspaceDir string               `json:"workspace_dir"`
+	MaxDepth     int                  `json:"max_depth"`
+	Projects     []*models.Project    `json:"projects"`
+	Mtimes       map[string]time.Time `json:"mtimes"`
+}
+
+// projectCachePath returns the cache file for a workspace, or "" when caching is disabled
+func projectCachePath(workspaceDir string) string {
+	if cacheDir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(workspaceDir))
+	return filepath.Join(cacheDir, "projects-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadCachedProjects returns the cached projects for a workspace if the cache is still valid
+func loadCachedProjects(workspaceDir string) ([]*models.Project, bool) {
+	path := projectCachePath(workspaceDir)
+	if path == "" {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cache projectCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.WorkspaceDir != workspaceDir ||
+		cache.MaxDepth != workspace.MaxDepth() {
+		return nil, false
+	}
+
+	for trackedPath, mtime := range cache.Mtimes {
+		info, err := os.Stat(trackedPath)
+		if err != nil || !info.ModTime().Equal(mtime) {
+			return nil, false
+		}
+	}
+
+	return cache.Projects, true
+}
+
+// saveCachedProjects writes the discovered projects to the cache, tracking the walked directories
+// outside of worktrees and each project's git config. Failures are ignored since the cache is
+// only an optimization.
+func saveCachedProjects(workspaceDir string, projects []*models.Project, dirs []string) {
+	path := projectCachePath(workspaceDir)
+	if path == "" {
+		return
+	}
+
+	worktreeBases := make(map[string]bool, len(projects))
+	for _, proj := range projects {
+		worktreeBases[workspace.GetWorktreeBasePath(workspaceDir, proj.Name)] = true
+		// Worktrees may also be elsewhere in the workspace, following the worktree path template
+		if parent := workspace.GetWorktreeParentPath(workspaceDir, proj.Name); parent != "" {
+			worktreeBases[parent] = true
+		}
+	}
+
+	tracked := make([]string, 0, len(dirs)+len(projects))
+	for _, dir := range dirs {
+		if !isInsideWorktree(workspaceDir, worktreeBases, dir) {
+			tracked = append(tracked, dir)
+		}
+	}
+	for _, proj := range projects {
+		tracked = append(tracked, filepath.Join(proj.LocalPath, "config"))
+	}
+
+	// Creating the ignore file changes the workspace mtime, but editing it does not
+	ignoreFile := workspace.GetIgnoreFilePath(workspaceDir)
+	if _, err := os.Stat(ignoreFile); err == nil {
+		tracked = append(tracked, ignoreFile)
+	}
+
+	cache := projectCache{
+		WorkspaceDir: workspaceDir,
+		MaxDepth:     workspace.MaxDepth(),
+		Projects:     projects,
+		Mtimes:       make(map[string]time.Time, len(tracked)),
+	}
+	for _, trackedPath := range tracked {
+		info, err := os.Stat(trackedPath)
+		if err != nil {
+			return
+		}
+		cache.Mtimes[trackedPath] = info.ModTime()
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return
+	}
+
+	//nolint:errcheck // The cache is best-effort
+	atomicfile.WriteFile(path, data, 0o644)
+}
+
+// isInsideWorktree reports whether dir is below one of the worktree base directories
+// Worktree contents change constantly and never contain projects, so they aren't tracked
+func isInsideWorktree(workspaceDir string, worktreeBases map[string]bool, dir string) bool {
+	for parent := filepath.Dir(dir); len(parent) > len(workspaceDir); parent = filepath.Dir(parent) {
+		if worktreeBases[parent] {
+			return true
+		}
+	}
+	return false
+}
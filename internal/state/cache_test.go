@@ -0,0 +1,162 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+// enableTestCache turns on the discovery cache in a temporary directory for one test
+func enableTestCache(t *testing.T) {
+	t.Helper()
+	SetCacheDir(t.TempDir())
+	t.Cleanup(func() { SetCacheDir("") })
+}
+
+// poisonCache renames every cached project, so a cache hit is distinguishable from a fresh walk
+func poisonCache(t *testing.T, workspaceDir string) {
+	t.Helper()
+
+	path := projectCachePath(workspaceDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read cache: %v", err)
+	}
+
+	var cache projectCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		t.Fatalf("Failed to parse cache: %v", err)
+	}
+	for _, proj := range cache.Projects {
+		proj.Name = "cached/" + proj.Name
+	}
+
+	data, err = json.Marshal(cache)
+	if err != nil {
+		t.Fatalf("Failed to encode cache: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write cache: %v", err)
+	}
+}
+
+// touch bumps the modification time of path into the future so the change is always visible
+func touch(t *testing.T, path string) {
+	t.Helper()
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Failed to touch %s: %v", path, err)
+	}
+}
+
+func TestDiscoverProjects_Cache(t *testing.T) {
+	workspaceDir := t.TempDir()
+	expected := createBareRepos(t, workspaceDir, 3)
+
+	// A worktree of the first project, whose contents are not tracked
+	worktreeDir := filepath.Join(workspaceDir, expected[0], "main", "src")
+	if err := os.MkdirAll(worktreeDir, 0o755); err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		change    func(t *testing.T)
+		wantCache bool
+	}{
+		{
+			name:      "unchanged workspace",
+			change:    func(t *testing.T) {},
+			wantCache: true,
+		},
+		{
+			name:      "worktree contents changed",
+			change:    func(t *testing.T) { touch(t, worktreeDir) },
+			wantCache: true,
+		},
+		{
+			name: "project added",
+			change: func(t *testing.T) {
+				path := filepath.Join(workspaceDir, "example.com", "other", "new.git")
+				if out, err := exec.Command("git", "init", "--quiet", "--bare", path).CombinedOutput(); err != nil {
+					t.Fatalf("git init failed: %v: %s", err, out)
+				}
+			},
+			wantCache: false,
+		},
+		{
+			name:      "project directory changed",
+			change:    func(t *testing.T) { touch(t, filepath.Join(workspaceDir, "example.com", "user")) },
+			wantCache: false,
+		},
+		{
+			name:      "remote changed",
+			change:    func(t *testing.T) { touch(t, filepath.Join(workspaceDir, expected[1]+".git", "config")) },
+			wantCache: false,
+		},
+		{
+			name: "depth limit changed",
+			change: func(t *testing.T) {
+				workspace.SetMaxDepth(5)
+				t.Cleanup(func() { workspace.SetMaxDepth(0) })
+			},
+			wantCache: false,
+		},
+		{
+			name: "ignore file changed",
+			change: func(t *testing.T) {
+				path := workspace.GetIgnoreFilePath(workspaceDir)
+				if err := os.WriteFile(path, []byte("nothing\n"), 0o644); err != nil {
+					t.Fatalf("Failed to write ignore file: %v", err)
+				}
+				//nolint:errcheck // Test cleanup
+				t.Cleanup(func() { os.Remove(path) })
+			},
+			wantCache: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enableTestCache(t)
+
+			if _, err := DiscoverProjects(workspaceDir); err != nil {
+				t.Fatalf("DiscoverProjects() failed: %v", err)
+			}
+			poisonCache(t, workspaceDir)
+			tt.change(t)
+
+			projects, err := DiscoverProjects(workspaceDir)
+			if err != nil {
+				t.Fatalf("DiscoverProjects() failed: %v", err)
+			}
+			if len(projects) == 0 {
+				t.Fatal("DiscoverProjects() returned no projects")
+			}
+
+			fromCache := strings.HasPrefix(projects[0].Name, "cached/")
+			if fromCache != tt.wantCache {
+				t.Errorf("served from cache = %v, want %v", fromCache, tt.wantCache)
+			}
+		})
+	}
+}
+
+func TestDiscoverProjects_CacheDisabled(t *testing.T) {
+	workspaceDir := t.TempDir()
+	createBareRepos(t, workspaceDir, 2)
+
+	SetCacheDir("")
+	if _, err := DiscoverProjects(workspaceDir); err != nil {
+		t.Fatalf("DiscoverProjects() failed: %v", err)
+	}
+	if path := projectCachePath(workspaceDir); path != "" {
+		t.Errorf("projectCachePath() = %q with the cache disabled, want empty", path)
+	}
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"log/slog"
+
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+// openPrefixStore opens the database session prefixes are kept in; nil keeps none
+var openPrefixStore func() (db.Store, error)
+
+// SetPrefixStore sets how DiscoverProjects opens the database it keeps the session prefixes of
+// projects sharing a repository name in. Without one, their prefixes follow the age of the
+// projects on each discovery, which an imported repository can get wrong.
+func SetPrefixStore(open func() (db.Store, error)) {
+	openPrefixStore = open
+}
+
+// keepSessionPrefixes loads the kept session prefixes of the projects sharing a repository name,
+// and keeps the prefixes of those that have none yet, oldest first, so they are never derived
+// again. The database is only opened when repository names are shared.
+func keepSessionPrefixes() {
+	shared := workspace.SharedProjectNames()
+	if len(shared) == 0 || openPrefixStore == nil {
+		return
+	}
+
+	store, err := openPrefixStore()
+	if err != nil {
+		slog.Debug("failed to open database for session prefixes", "error", err)
+		return
+	}
+	//nolint:errcheck // Read and written already
+	defer store.Close()
+
+	prefixes, err := store.GetSessionPrefixes()
+	if err != nil {
+		slog.Debug("failed to get session prefixes", "error", err)
+		return
+	}
+	workspace.SetSessionPrefixes(prefixes)
+
+	for _, projectName := range shared {
+		if _, ok := prefixes[projectName]; ok {
+			continue
+		}
+		prefix := workspace.SessionPrefix(projectName)
+		if err := store.SetSessionPrefix(projectName, prefix); err != nil {
+			slog.Debug("failed to keep session prefix", "project", projectName, "error", err)
+			return
+		}
+		// Projects with a kept prefix count as older, so the next ones are told apart from this one
+		prefixes[projectName] = prefix
+		workspace.SetSessionPrefixes(prefixes)
+	}
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/workspace"
+)
+
+func TestKeepSessionPrefixes(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sesh.db")
+	SetPrefixStore(func() (db.Store, error) { return db.Open(dbPath) })
+	t.Cleanup(func() {
+		SetPrefixStore(nil)
+		workspace.SetProjectNames(nil)
+		workspace.SetSessionPrefixes(nil)
+	})
+
+	// sesh import keeps the prefix of github.com/acme/api before adding github.com/other/api, which
+	// has the age of the clone it came from, so it looks older
+	workspace.SetProjectNames([]string{"github.com/other/api", "github.com/acme/api", "github.com/acme/web"})
+	store, err := db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	if err := store.SetSessionPrefix("github.com/acme/api", "api"); err != nil {
+		t.Fatalf("SetSessionPrefix() failed: %v", err)
+	}
+	//nolint:errcheck // Test cleanup
+	store.Close()
+	keepSessionPrefixes()
+
+	tests := map[string]string{
+		"github.com/acme/api":  "api",
+		"github.com/other/api": "other-api",
+		"github.com/acme/web":  "web",
+	}
+	for projectName, want := range tests {
+		if got := workspace.SessionPrefix(projectName); got != want {
+			t.Errorf("SessionPrefix(%q) = %q, want %q", projectName, got, want)
+		}
+	}
+
+	store, err = db.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	//nolint:errcheck // Test cleanup
+	defer store.Close()
+	prefixes, err := store.GetSessionPrefixes()
+	if err != nil {
+		t.Fatalf("GetSessionPrefixes() failed: %v", err)
+	}
+	if got := prefixes["github.com/other/api"]; got != "other-api" {
+		t.Errorf("kept prefix of github.com/other/api = %q, want other-api", got)
+	}
+	if _, ok := prefixes["github.com/acme/web"]; ok {
+		t.Error("kept a prefix of github.com/acme/web, which shares its repository name with no project")
+	}
+}
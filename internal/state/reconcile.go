@@ -0,0 +1,232 @@
+package state
+
+import (
+	"os"
+
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// Snapshot is the state of a workspace as discovered from the filesystem and session manager
+type Snapshot struct {
+	Projects  []*models.Project
+	Worktrees map[string][]*models.Worktree // Keyed by project name
+	Sessions  []string                      // Running session names, nil if they can't be listed
+}
+
+// ReconcileResult lists the database rows added and removed by Reconcile
+// Projects are identified by name, worktrees by path, and sessions by session name
+type ReconcileResult struct {
+	AddedProjects    []string
+	RemovedProjects  []string
+	AddedWorktrees   []string
+	RemovedWorktrees []string
+	AddedSessions    []string
+	RemovedSessions  []string
+}
+
+// Changed reports whether any rows were added or removed
+func (r *ReconcileResult) Changed() bool {
+	return len(r.AddedProjects)+len(r.RemovedProjects)+
+		len(r.AddedWorktrees)+len(r.RemovedWorktrees)+
+		len(r.AddedSessions)+len(r.RemovedSessions) > 0
+}
+
+// Discover takes a snapshot of all projects, worktrees, and running sessions in the workspace
+func Discover(workspaceDir string, sessionMgr session.SessionManager) (*Snapshot, error) {
+	projects, err := DiscoverProjects(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{
+		Projects:  projects,
+		Worktrees: make(map[string][]*models.Worktree),
+	}
+
+	for _, proj := range projects {
+		worktrees, err := DiscoverWorktrees(proj)
+		if err != nil {
+			return nil, eris.Wrapf(err, "failed to discover worktrees for %s", proj.Name)
+		}
+
+		// git lists the bare repository itself first; it isn't a checked out worktree
+		var checkedOut []*models.Worktree
+		for _, wt := range worktrees {
+			if wt.Path == proj.LocalPath {
+				continue
+			}
+			wt.IsMain = len(checkedOut) == 0
+			checkedOut = append(checkedOut, wt)
+		}
+		snap.Worktrees[proj.Name] = checkedOut
+	}
+
+	// Without a session backend there is no way to tell which sessions are running
+	if sessionMgr.Name() != string(session.BackendNone) {
+		sessions, err := DiscoverSessions(sessionMgr)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to discover sessions")
+		}
+		snap.Sessions = sessions
+	}
+
+	return snap, nil
+}
+
+// Reconcile brings the projects, worktrees, and sessions tables in line with a snapshot
+// Rows whose paths no longer exist (or whose sessions are no longer running) are removed first,
+// then discovered projects, worktrees, and sessions missing from the store are inserted.
+// With dryRun, the result describes the changes without making them.
+func Reconcile(store db.Store, snap *Snapshot, dryRun bool) (*ReconcileResult, error) {
+	result := &ReconcileResult{}
+
+	running := make(map[string]bool)
+	for _, name := range snap.Sessions {
+		running[name] = true
+	}
+
+	if err := removeStaleRows(store, snap, running, dryRun, result); err != nil {
+		return result, err
+	}
+
+	if err := addMissingRows(store, snap, running, dryRun, result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// removeStaleRows deletes projects and worktrees whose paths are gone, and sessions that aren't running
+// Deleting a project or worktree cascades to its worktrees and sessions
+func removeStaleRows(
+	store db.Store,
+	snap *Snapshot,
+	running map[string]bool,
+	dryRun bool,
+	result *ReconcileResult,
+) error {
+	projects, err := store.GetAllProjects()
+	if err != nil {
+		return err
+	}
+
+	for _, proj := range projects {
+		if !pathExists(proj.LocalPath) {
+			if !dryRun {
+				if err := store.DeleteProject(proj.ID); err != nil {
+					return err
+				}
+			}
+			result.RemovedProjects = append(result.RemovedProjects, proj.Name)
+			continue
+		}
+
+		worktrees, err := store.GetWorktreesByProject(proj.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, wt := range worktrees {
+			if !pathExists(wt.Path) {
+				if !dryRun {
+					if err := store.DeleteWorktree(wt.ID); err != nil {
+						return err
+					}
+				}
+				result.RemovedWorktrees = append(result.RemovedWorktrees, wt.Path)
+				continue
+			}
+
+			if snap.Sessions == nil {
+				continue
+			}
+
+			sess, err := store.GetSessionByWorktree(wt.ID)
+			if err != nil || running[sess.TmuxSessionName] {
+				continue
+			}
+			if !dryRun {
+				if err := store.DeleteSession(sess.ID); err != nil {
+					return err
+				}
+			}
+			result.RemovedSessions = append(result.RemovedSessions, sess.TmuxSessionName)
+		}
+	}
+
+	return nil
+}
+
+// addMissingRows inserts discovered projects, worktrees, and running sessions that have no rows yet
+func addMissingRows(
+	store db.Store,
+	snap *Snapshot,
+	running map[string]bool,
+	dryRun bool,
+	result *ReconcileResult,
+) error {
+	for _, proj := range snap.Projects {
+		dbProj, err := store.GetProject(proj.Name)
+		if err != nil {
+			dbProj = &models.Project{
+				Name:      proj.Name,
+				RemoteURL: proj.RemoteURL,
+				LocalPath: proj.LocalPath,
+			}
+			if !dryRun {
+				if err := store.CreateProject(dbProj); err != nil {
+					return eris.Wrapf(err, "failed to add project %s", proj.Name)
+				}
+			}
+			result.AddedProjects = append(result.AddedProjects, proj.Name)
+		}
+
+		for _, wt := range snap.Worktrees[proj.Name] {
+			dbWorktree, err := store.GetWorktreeByPath(wt.Path)
+			if err != nil {
+				dbWorktree = &models.Worktree{
+					ProjectID: dbProj.ID,
+					Branch:    wt.Branch,
+					Path:      wt.Path,
+					IsMain:    wt.IsMain,
+				}
+				if !dryRun {
+					if err := store.CreateWorktree(dbWorktree); err != nil {
+						return eris.Wrapf(err, "failed to add worktree %s", wt.Path)
+					}
+				}
+				result.AddedWorktrees = append(result.AddedWorktrees, wt.Path)
+			}
+
+			sessionName := workspace.GenerateSessionName(proj.Name, wt.Branch)
+			if !running[sessionName] {
+				continue
+			}
+			if _, err := store.GetSessionByTmuxName(sessionName); err == nil {
+				continue
+			}
+			if !dryRun {
+				err := store.CreateSession(&models.Session{
+					WorktreeID:      dbWorktree.ID,
+					TmuxSessionName: sessionName,
+				})
+				if err != nil {
+					return eris.Wrapf(err, "failed to add session %s", sessionName)
+				}
+			}
+			result.AddedSessions = append(result.AddedSessions, sessionName)
+		}
+	}
+
+	return nil
+}
+
+// pathExists reports whether a file or directory exists at path
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,180 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/db"
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+// setupReconcileStore creates a store with one project whose bare repo exists, one stale
+// project, and worktrees for main (exists, with a running session) and gone (deleted)
+func setupReconcileStore(t *testing.T, workspaceDir string) db.Store {
+	t.Helper()
+
+	store, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() {
+		//nolint:errcheck // Test cleanup
+		store.Close()
+	})
+
+	for _, dir := range []string{"repo.git", "repo/main"} {
+		if err := os.MkdirAll(filepath.Join(workspaceDir, dir), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	proj := &models.Project{
+		Name:      "repo",
+		RemoteURL: "https://example.com/repo.git",
+		LocalPath: filepath.Join(workspaceDir, "repo.git"),
+	}
+	stale := &models.Project{
+		Name:      "stale",
+		RemoteURL: "https://example.com/stale.git",
+		LocalPath: filepath.Join(workspaceDir, "stale.git"),
+	}
+	for _, p := range []*models.Project{proj, stale} {
+		if err := store.CreateProject(p); err != nil {
+			t.Fatalf("CreateProject() failed: %v", err)
+		}
+	}
+
+	mainWorktree := &models.Worktree{ProjectID: proj.ID, Branch: "main", Path: filepath.Join(workspaceDir, "repo/main")}
+	goneWorktree := &models.Worktree{ProjectID: proj.ID, Branch: "gone", Path: filepath.Join(workspaceDir, "repo/gone")}
+	for _, wt := range []*models.Worktree{mainWorktree, goneWorktree} {
+		if err := store.CreateWorktree(wt); err != nil {
+			t.Fatalf("CreateWorktree() failed: %v", err)
+		}
+	}
+
+	if err := store.CreateSession(&models.Session{WorktreeID: mainWorktree.ID, TmuxSessionName: "repo-main"}); err != nil {
+		t.Fatalf("CreateSession() failed: %v", err)
+	}
+
+	return store
+}
+
+func TestReconcile(t *testing.T) {
+	workspaceDir := t.TempDir()
+	store := setupReconcileStore(t, workspaceDir)
+
+	// A new worktree and project exist on disk, and the main session is no longer running
+	for _, dir := range []string{"repo/feature", "other.git", "other/main"} {
+		if err := os.MkdirAll(filepath.Join(workspaceDir, dir), 0o755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	snap := &Snapshot{
+		Projects: []*models.Project{
+			{Name: "repo", RemoteURL: "https://example.com/repo.git", LocalPath: filepath.Join(workspaceDir, "repo.git")},
+			{Name: "other", RemoteURL: "https://example.com/other.git", LocalPath: filepath.Join(workspaceDir, "other.git")},
+		},
+		Worktrees: map[string][]*models.Worktree{
+			"repo": {
+				{Branch: "main", Path: filepath.Join(workspaceDir, "repo/main"), IsMain: true},
+				{Branch: "feature", Path: filepath.Join(workspaceDir, "repo/feature")},
+			},
+			"other": {
+				{Branch: "main", Path: filepath.Join(workspaceDir, "other/main"), IsMain: true},
+			},
+		},
+		Sessions: []string{"repo-feature", "other-main"},
+	}
+
+	// Dry run reports changes without making them
+	planned, err := Reconcile(store, snap, true)
+	if err != nil {
+		t.Fatalf("Reconcile(dryRun) failed: %v", err)
+	}
+	if _, err := store.GetProject("stale"); err != nil {
+		t.Error("dry run should not remove the stale project")
+	}
+
+	result, err := Reconcile(store, snap, false)
+	if err != nil {
+		t.Fatalf("Reconcile() failed: %v", err)
+	}
+	if !slices.Equal(planned.AddedWorktrees, result.AddedWorktrees) ||
+		!slices.Equal(planned.RemovedProjects, result.RemovedProjects) {
+		t.Errorf("dry run planned %+v, but reconcile did %+v", planned, result)
+	}
+
+	tests := []struct {
+		name     string
+		got      []string
+		expected []string
+	}{
+		{"added projects", result.AddedProjects, []string{"other"}},
+		{"removed projects", result.RemovedProjects, []string{"stale"}},
+		{"added worktrees", result.AddedWorktrees, []string{
+			filepath.Join(workspaceDir, "repo/feature"),
+			filepath.Join(workspaceDir, "other/main"),
+		}},
+		{"removed worktrees", result.RemovedWorktrees, []string{filepath.Join(workspaceDir, "repo/gone")}},
+		{"added sessions", result.AddedSessions, []string{"repo-feature", "other-main"}},
+		{"removed sessions", result.RemovedSessions, []string{"repo-main"}},
+	}
+	for _, tt := range tests {
+		if !slices.Equal(tt.got, tt.expected) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.expected)
+		}
+	}
+
+	// The store now matches the snapshot
+	if _, err := store.GetProject("stale"); err == nil {
+		t.Error("stale project should have been removed")
+	}
+	if _, err := store.GetWorktreeByPath(filepath.Join(workspaceDir, "repo/gone")); err == nil {
+		t.Error("gone worktree should have been removed")
+	}
+	sess, err := store.GetSessionByTmuxName("other-main")
+	if err != nil {
+		t.Fatalf("other-main session should have been added: %v", err)
+	}
+	wt, err := store.GetWorktreeByID(sess.WorktreeID)
+	if err != nil || wt.Path != filepath.Join(workspaceDir, "other/main") {
+		t.Errorf("other-main session is linked to the wrong worktree: %+v", wt)
+	}
+
+	// Reconciling again is a no-op
+	result, err = Reconcile(store, snap, false)
+	if err != nil {
+		t.Fatalf("second Reconcile() failed: %v", err)
+	}
+	if result.Changed() {
+		t.Errorf("second Reconcile() changed %+v, want no changes", result)
+	}
+}
+
+func TestReconcile_UnknownSessions(t *testing.T) {
+	workspaceDir := t.TempDir()
+	store := setupReconcileStore(t, workspaceDir)
+
+	// Without a session backend, session rows are left alone
+	snap := &Snapshot{
+		Projects: []*models.Project{
+			{Name: "repo", RemoteURL: "https://example.com/repo.git", LocalPath: filepath.Join(workspaceDir, "repo.git")},
+		},
+		Worktrees: map[string][]*models.Worktree{
+			"repo": {{Branch: "main", Path: filepath.Join(workspaceDir, "repo/main"), IsMain: true}},
+		},
+	}
+
+	result, err := Reconcile(store, snap, false)
+	if err != nil {
+		t.Fatalf("Reconcile() failed: %v", err)
+	}
+	if len(result.RemovedSessions) != 0 || len(result.AddedSessions) != 0 {
+		t.Errorf("sessions changed without a session backend: %+v", result)
+	}
+	if _, err := store.GetSessionByTmuxName("repo-main"); err != nil {
+		t.Errorf("repo-main session should be kept: %v", err)
+	}
+}
@@ -1,87 +1,199 @@
 package state
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/benoctopus/sesh/internal/git"
 	"github.com/benoctopus/sesh/internal/models"
 	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/workspace"
 	"github.com/rotisserie/eris"
 )
 
+// discoveryWorkers bounds how many `git remote` lookups DiscoverProjects runs at once
+var discoveryWorkers = max(4, runtime.NumCPU())
+
+// workspaceDirs are the other workspace roots DiscoverProjects searches after the one it is given
+var workspaceDirs []string
+
+// SetWorkspaceDirs sets all workspace roots, so projects are discovered in each of them
+func SetWorkspaceDirs(dirs []string) {
+	workspaceDirs = dirs
+}
+
 // DiscoverProjects scans the workspace directory and discovers all projects
 // A project is identified by a directory with .git suffix (bare repo) in the workspace structure
 // Example: ~/.sesh/github.com/user/repo.git
+// The other workspace roots (see SetWorkspaceDirs) are scanned too. When the same project is
+// in several of them, the first one found wins, so the given workspace takes precedence.
+// Results are cached on disk (see SetCacheDir) until a directory in the workspace changes
 func DiscoverProjects(workspaceDir string) ([]*models.Project, error) {
-	var projects []*models.Project
+	projects, err := discoverWorkspace(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
 
-	// Walk the workspace directory looking for directories ending with .git suffix
-	err := filepath.Walk(workspaceDir, func(path string, info os.FileInfo, err error) error {
+	seen := make(map[string]bool, len(projects))
+	for _, proj := range projects {
+		seen[proj.Name] = true
+	}
+	for _, dir := range workspaceDirs {
+		if dir == workspaceDir {
+			continue
+		}
+		more, err := discoverWorkspace(dir)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		// Skip if not a directory ending with .git
-		if !info.IsDir() || !strings.HasSuffix(info.Name(), ".git") {
-			return nil
+		for _, proj := range more {
+			if !seen[proj.Name] {
+				seen[proj.Name] = true
+				projects = append(projects, proj)
+			}
 		}
+	}
 
-		// Check if it's a valid bare repository
-		configPath := filepath.Join(path, "config")
-		if _, err := os.Stat(configPath); err != nil {
-			return nil // Not a valid git repo
-		}
+	// Session names depend on which older projects share a repository name, unless they are kept
+	workspace.SetProjectNames(projectNamesByAge(projects))
+	keepSessionPrefixes()
 
-		// Extract project name from path relative to workspace (remove .git suffix)
-		relPath, err := filepath.Rel(workspaceDir, path)
-		if err != nil {
-			return nil
-		}
-		projectName := strings.TrimSuffix(relPath, ".git")
+	return projects, nil
+}
 
-		// Get remote URL
-		remoteURL, err := git.GetRemoteURL(path)
-		if err != nil {
-			// If we can't get remote URL, skip this project
-			return nil
+// projectNamesByAge returns the names of projects from the oldest to the newest
+// Their age only orders the projects sharing a repository name that have no kept session prefix yet
+func projectNamesByAge(projects []*models.Project) []string {
+	sorted := slices.Clone(projects)
+	slices.SortStableFunc(sorted, func(a, b *models.Project) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
 		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	names := make([]string, 0, len(sorted))
+	for _, proj := range sorted {
+		names = append(names, proj.Name)
+	}
+	return names
+}
+
+// discoverWorkspace discovers the projects of a single workspace root, using the cache
+func discoverWorkspace(workspaceDir string) ([]*models.Project, error) {
+	if projects, ok := loadCachedProjects(workspaceDir); ok {
+		return projects, nil
+	}
 
-		// Get creation time from bare repo directory
-		gitInfo, _ := os.Stat(path)
-		createdAt := gitInfo.ModTime()
+	projects, dirs, err := discoverProjects(workspaceDir, discoveryWorkers)
+	if err != nil {
+		return nil, err
+	}
 
-		project := &models.Project{
-			Name:      projectName,
-			RemoteURL: remoteURL,
-			LocalPath: path,
-			CreatedAt: createdAt,
-		}
+	saveCachedProjects(workspaceDir, projects, dirs)
+	return projects, nil
+}
 
-		projects = append(projects, project)
+// discoverProjects walks the workspace for bare repositories, then looks up their remote URLs
+// using a pool of workers, since each lookup execs git. Projects are returned in walk order,
+// along with the directories outside of bare repositories that the walk visited.
+func discoverProjects(workspaceDir string, workers int) ([]*models.Project, []string, error) {
+	var repoPaths, dirs []string
 
-		// Don't recurse into .git directories
-		return filepath.SkipDir
+	err := workspace.WalkBareRepos(workspaceDir, func(path string) {
+		repoPaths = append(repoPaths, path)
+	}, func(path string) {
+		dirs = append(dirs, path)
 	})
 	if err != nil {
-		return nil, eris.Wrap(err, "failed to discover projects")
+		return nil, nil, eris.Wrap(err, "failed to discover projects")
 	}
 
-	return projects, nil
+	// Each worker fills in the slots of the repos it picks up, preserving walk order
+	found := make([]*models.Project, len(repoPaths))
+	paths := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(repoPaths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range paths {
+				found[i] = loadProject(workspaceDir, repoPaths[i])
+			}
+		}()
+	}
+	for i := range repoPaths {
+		paths <- i
+	}
+	close(paths)
+	wg.Wait()
+
+	var projects []*models.Project
+	for _, project := range found {
+		if project != nil {
+			projects = append(projects, project)
+		}
+	}
+
+	return projects, dirs, nil
+}
+
+// loadProject builds the project for a bare repository in the workspace
+// Returns nil if the repository has no origin remote
+func loadProject(workspaceDir, path string) *models.Project {
+	// Extract project name from path relative to workspace (remove .git suffix)
+	relPath, err := filepath.Rel(workspaceDir, path)
+	if err != nil {
+		return nil
+	}
+	projectName := strings.TrimSuffix(relPath, ".git")
+
+	// Get remote URL
+	remoteURL, err := git.GetRemoteURL(path)
+	if err != nil {
+		// If we can't get remote URL, skip this project
+		return nil
+	}
+
+	// Get creation time from the description file of the bare repo, which git writes when cloning
+	// and never touches again, unlike the directory itself
+	var createdAt time.Time
+	if info, err := os.Stat(filepath.Join(path, "description")); err == nil {
+		createdAt = info.ModTime()
+	} else if gitInfo, err := os.Stat(path); err == nil {
+		createdAt = gitInfo.ModTime()
+	}
+
+	return &models.Project{
+		Name:      projectName,
+		RemoteURL: remoteURL,
+		LocalPath: path,
+		CreatedAt: createdAt,
+	}
 }
 
 // DiscoverWorktrees discovers all worktrees for a given project
 func DiscoverWorktrees(project *models.Project) ([]*models.Worktree, error) {
-	// Use git worktree list to get all worktrees
-	worktrees, err := git.ListWorktrees(project.LocalPath)
+	// Use git worktree list to get all worktrees; it is local, so only the git timeout bounds it
+	worktrees, err := git.ListWorktrees(context.Background(), project.LocalPath)
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to list worktrees")
 	}
 
+	pool := poolDir(project)
+	resolvedPool, _ := filepath.EvalSymlinks(pool)
 	var result []*models.Worktree
 	for _, wt := range worktrees {
+		// Recycled worktrees wait in the pool for a new branch, and belong to none
+		if isWithin(wt.Path, pool) || (resolvedPool != "" && isWithin(wt.Path, resolvedPool)) {
+			continue
+		}
+
 		// Branch is already provided by ListWorktrees
 		branch := wt.Branch
 
@@ -109,6 +221,34 @@ func DiscoverWorktrees(project *models.Project) ([]*models.Worktree, error) {
 	return result, nil
 }
 
+// DiscoverPooledWorktrees returns the paths of the recycled worktrees of a project, which wait in
+// its pool for a new branch, sorted
+func DiscoverPooledWorktrees(project *models.Project) []string {
+	pool := poolDir(project)
+	entries, err := os.ReadDir(pool)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			paths = append(paths, filepath.Join(pool, entry.Name()))
+		}
+	}
+	return paths
+}
+
+// poolDir returns the directory of the recycled worktrees of a project
+func poolDir(project *models.Project) string {
+	return workspace.GetPoolDir(workspace.GetProjectWorkspaceDir(project.LocalPath, project.Name), project.Name)
+}
+
+// isWithin reports whether path is inside dir
+func isWithin(path, dir string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
 // DiscoverSessions discovers all active sessions using the session manager
 func DiscoverSessions(sessionMgr session.SessionManager) ([]string, error) {
 	return sessionMgr.List()
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/models"
+)
+
+// createBareRepos creates count bare repositories with an origin remote in the workspace
+// Every third one has no remote and should not be discovered as a project
+func createBareRepos(tb testing.TB, workspaceDir string, count int) []string {
+	tb.Helper()
+
+	var expected []string
+	for i := range count {
+		name := fmt.Sprintf("example.com/user/repo%03d", i)
+		path := filepath.Join(workspaceDir, name+".git")
+
+		if out, err := exec.Command("git", "init", "--quiet", "--bare", path).CombinedOutput(); err != nil {
+			tb.Fatalf("git init failed: %v: %s", err, out)
+		}
+
+		if i%3 == 2 {
+			continue
+		}
+
+		remote := "https://" + name + ".git"
+		cmd := exec.Command("git", "-C", path, "remote", "add", "origin", remote)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			tb.Fatalf("git remote add failed: %v: %s", err, out)
+		}
+		expected = append(expected, name)
+	}
+
+	return expected
+}
+
+func TestDiscoverProjects(t *testing.T) {
+	workspaceDir := t.TempDir()
+	expected := createBareRepos(t, workspaceDir, 10)
+
+	// Trashed worktrees are never discovered as projects
+	trashed := filepath.Join(workspaceDir, ".trash", "op", "example.com", "user", "old.git")
+	if err := os.MkdirAll(trashed, 0o755); err != nil {
+		t.Fatalf("Failed to create trash dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(trashed, "config"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to create trash config: %v", err)
+	}
+
+	for _, workers := range []int{1, 4, 32} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			projects, _, err := discoverProjects(workspaceDir, workers)
+			if err != nil {
+				t.Fatalf("discoverProjects() failed: %v", err)
+			}
+
+			if len(projects) != len(expected) {
+				t.Fatalf("discovered %d projects, want %d", len(projects), len(expected))
+			}
+			for i, proj := range projects {
+				if proj.Name != expected[i] {
+					t.Errorf("project %d = %q, want %q", i, proj.Name, expected[i])
+				}
+				if proj.RemoteURL != "https://"+expected[i]+".git" {
+					t.Errorf("project %s has remote %q", proj.Name, proj.RemoteURL)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverProjects_EmptyWorkspace(t *testing.T) {
+	projects, err := DiscoverProjects(t.TempDir())
+	if err != nil {
+		t.Fatalf("DiscoverProjects() failed: %v", err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("discovered %d projects in an empty workspace, want 0", len(projects))
+	}
+}
+
+func TestDiscoverProjects_WorkspaceDirs(t *testing.T) {
+	primary, secondary := t.TempDir(), t.TempDir()
+	inPrimary := createBareRepos(t, primary, 2)
+	// The same names again, plus one only the secondary workspace has
+	inSecondary := createBareRepos(t, secondary, 4)
+
+	SetWorkspaceDirs([]string{primary, secondary})
+	t.Cleanup(func() { SetWorkspaceDirs(nil) })
+
+	projects, err := DiscoverProjects(primary)
+	if err != nil {
+		t.Fatalf("DiscoverProjects() failed: %v", err)
+	}
+
+	want := append(inPrimary, inSecondary[len(inPrimary):]...)
+	if len(projects) != len(want) {
+		t.Fatalf("discovered %d projects, want %d", len(projects), len(want))
+	}
+	for i, proj := range projects {
+		if proj.Name != want[i] {
+			t.Errorf("project %d = %q, want %q", i, proj.Name, want[i])
+		}
+		wantDir := primary
+		if i >= len(inPrimary) {
+			wantDir = secondary
+		}
+		if proj.LocalPath != filepath.Join(wantDir, proj.Name+".git") {
+			t.Errorf("project %s is at %s, want it in %s", proj.Name, proj.LocalPath, wantDir)
+		}
+	}
+}
+
+// BenchmarkDiscoverProjects compares serial remote lookups with the worker pool
+// Run with: go test -bench DiscoverProjects ./internal/state
+func BenchmarkDiscoverProjects(b *testing.B) {
+	workspaceDir := b.TempDir()
+	createBareRepos(b, workspaceDir, 30)
+
+	for _, bm := range []struct {
+		name    string
+		workers int
+	}{
+		{"serial", 1},
+		{"parallel", discoveryWorkers},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, _, err := discoverProjects(workspaceDir, bm.workers); err != nil {
+					b.Fatalf("discoverProjects() failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectNamesByAge(t *testing.T) {
+	now := time.Now()
+	projects := []*models.Project{
+		{Name: "github.com/other/api", CreatedAt: now},
+		{Name: "github.com/acme/web", CreatedAt: now},
+		{Name: "github.com/acme/api", CreatedAt: now.Add(-time.Hour)},
+	}
+
+	want := []string{"github.com/acme/api", "github.com/acme/web", "github.com/other/api"}
+	if got := projectNamesByAge(projects); !slices.Equal(got, want) {
+		t.Errorf("projectNamesByAge() = %v, want %v", got, want)
+	}
+	if projects[0].Name != "github.com/other/api" {
+		t.Error("projectNamesByAge() reordered the projects")
+	}
+}
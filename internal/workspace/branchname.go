@@ -0,0 +1,94 @@
+package workspace
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/rotisserie/eris"
+)
+
+// DefaultBranchTypes are the types offered for new branches when branch_types isn't set
+var DefaultBranchTypes = []string{"feat", "fix", "chore", "docs", "refactor", "test"}
+
+// maxSlugLength is how much of a description goes into a branch name
+const maxSlugLength = 40
+
+// BranchTemplateData is what branch templates are executed with
+type BranchTemplateData struct {
+	// User is the user creating the branch, e.g. "jdoe"
+	User string
+	// Type is the kind of change, e.g. "feat" or "fix"
+	Type string
+	// Slug is the description of the change, e.g. "fix-login"
+	Slug string
+}
+
+// ParseBranchTemplate parses a branch template like "{{.User}}/{{.Type}}/{{.Slug}}" and checks
+// that it tells apart the branches of different descriptions
+func ParseBranchTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("branch").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to parse branch template")
+	}
+
+	var sample strings.Builder
+	if err := tmpl.Execute(&sample, BranchTemplateData{User: "user", Type: "type", Slug: "slug"}); err != nil {
+		return nil, eris.Wrap(err, "failed to execute branch template")
+	}
+	if !strings.Contains(sample.String(), "slug") {
+		return nil, eris.New("the template must contain {{.Slug}}")
+	}
+	return tmpl, nil
+}
+
+// BranchTemplateUses reports whether a branch template refers to a field, e.g. "Type", so the
+// field is only asked for when it is needed
+func BranchTemplateUses(text, field string) bool {
+	return strings.Contains(text, "."+field)
+}
+
+// ExpandBranchTemplate names a new branch following a branch template
+func ExpandBranchTemplate(text string, data BranchTemplateData) (string, error) {
+	tmpl, err := ParseBranchTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", eris.Wrap(err, "failed to execute branch template")
+	}
+
+	// Empty fields mustn't leave empty path components, e.g. "/feat/fix-login"
+	var parts []string
+	for _, part := range strings.Split(name.String(), "/") {
+		if part = strings.Trim(part, "-_."); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// Slugify turns a description into lowercase words joined by dashes, cut at a word boundary
+// after 40 characters, e.g. "Fix login: handle SSO" becomes "fix-login-handle-sso"
+func Slugify(description string) string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return r >= unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+
+	slug := ""
+	for _, word := range words {
+		if slug == "" {
+			slug = word
+		} else if len(slug)+1+len(word) <= maxSlugLength {
+			slug += "-" + word
+		} else {
+			break
+		}
+	}
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+	}
+	return slug
+}
@@ -0,0 +1,55 @@
+package workspace
+
+import "testing"
+
+func TestExpandBranchTemplate(t *testing.T) {
+	data := BranchTemplateData{User: "jdoe", Type: "fix", Slug: "login-fails"}
+
+	tests := []struct {
+		name     string
+		template string
+		data     BranchTemplateData
+		want     string
+		wantErr  bool
+	}{
+		{name: "all fields", template: "{{.User}}/{{.Type}}/{{.Slug}}", data: data, want: "jdoe/fix/login-fails"},
+		{name: "slug only", template: "{{.Slug}}", data: data, want: "login-fails"},
+		{name: "empty user", template: "{{.User}}/{{.Type}}-{{.Slug}}", data: BranchTemplateData{Type: "fix", Slug: "x"}, want: "fix-x"},
+		{name: "without slug", template: "{{.User}}/{{.Type}}", data: data, wantErr: true},
+		{name: "unknown field", template: "{{.Ticket}}/{{.Slug}}", data: data, wantErr: true},
+		{name: "syntax error", template: "{{.Slug", data: data, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandBranchTemplate(tt.template, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandBranchTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandBranchTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{description: "Fix login", want: "fix-login"},
+		{description: "  Crash when “sesh switch” runs: don't panic!", want: "crash-when-sesh-switch-runs-don-t-panic"},
+		{description: "Support switching between many projects with one keystroke", want: "support-switching-between-many-projects"},
+		{description: "averyveryveryveryveryveryveryveryverylongword", want: "averyveryveryveryveryveryveryveryverylon"},
+		{description: "???", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := Slugify(tt.description); got != tt.want {
+				t.Errorf("Slugify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package workspace
+
+import "syscall"
+
+// sameDevice reports whether two existing paths are on the same device
+func sameDevice(a, b string) bool {
+	var statA, statB syscall.Stat_t
+	if syscall.Stat(a, &statA) != nil || syscall.Stat(b, &statB) != nil {
+		return false
+	}
+	return statA.Dev == statB.Dev
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package workspace
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// sameDevice reports whether two existing paths are on the same volume
+func sameDevice(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return strings.EqualFold(filepath.VolumeName(absA), filepath.VolumeName(absB))
+}
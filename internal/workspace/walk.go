@@ -0,0 +1,188 @@
+package workspace
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// IgnoreFileName is the file in the workspace root listing directories that discovery skips
+const IgnoreFileName = ".seshignore"
+
+// defaultIgnorePatterns are directories that never hold projects but can be huge to walk
+var defaultIgnorePatterns = []string{"node_modules"}
+
+// maxDepth is how many directories below the workspace root are searched for projects
+// A value of 0 means there is no limit
+var maxDepth int
+
+// SetMaxDepth limits how deep WalkBareRepos searches the workspace, or removes the limit when depth is 0
+func SetMaxDepth(depth int) {
+	maxDepth = depth
+}
+
+// MaxDepth returns the current discovery depth limit, where 0 means there is no limit
+func MaxDepth() int {
+	return maxDepth
+}
+
+// GetIgnoreFilePath returns the path to the workspace ignore file
+func GetIgnoreFilePath(workspaceDir string) string {
+	return filepath.Join(workspaceDir, IgnoreFileName)
+}
+
+// LoadIgnorePatterns reads the patterns in the workspace ignore file, which uses gitignore syntax
+// Blank lines and lines starting with # are skipped. A missing ignore file is not an error.
+func LoadIgnorePatterns(workspaceDir string) ([]string, error) {
+	path := GetIgnoreFilePath(workspaceDir)
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to open ignore file: %s", path)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, eris.Wrapf(err, "failed to read ignore file: %s", path)
+	}
+
+	return patterns, nil
+}
+
+// ignorePattern is a pattern of the ignore file, split into the globs of its path segments
+type ignorePattern struct {
+	segments []string
+	// negated patterns, starting with !, include again what earlier patterns ignored
+	negated bool
+}
+
+// parseIgnorePatterns parses patterns in gitignore syntax. A pattern without a slash, or with
+// only a trailing one, matches directory names anywhere in the workspace; other patterns match
+// paths relative to the workspace root, where ** matches any number of directories.
+func parseIgnorePatterns(lines []string) []ignorePattern {
+	patterns := make([]ignorePattern, 0, len(lines))
+	for _, line := range lines {
+		var pattern ignorePattern
+		if after, ok := strings.CutPrefix(line, "!"); ok {
+			pattern.negated = true
+			line = after
+		}
+		// Only directories are matched, so a trailing slash changes nothing
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		pattern.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// isIgnored reports whether a directory, given relative to the workspace, is ignored: the last
+// pattern matching it decides, so negated patterns can make exceptions
+func isIgnored(patterns []ignorePattern, relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	ignored := false
+	for _, pattern := range patterns {
+		if matchSegments(pattern.segments, parts) {
+			ignored = !pattern.negated
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches path segments against the globs of a pattern, where ** matches any
+// number of segments
+func matchSegments(globs, parts []string) bool {
+	if len(globs) == 0 {
+		return len(parts) == 0
+	}
+	if globs[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(globs[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	matched, _ := path.Match(globs[0], parts[0])
+	return matched && matchSegments(globs[1:], parts[1:])
+}
+
+// WalkBareRepos walks the workspace calling fn with the path of every bare repository found
+// Other directories that are walked are passed to visitDir, which may be nil. The trash, the
+// lock directory, directories matching the ignore file, and anything deeper than the max depth
+// are skipped, and bare repositories are never descended into.
+func WalkBareRepos(workspaceDir string, fn func(path string), visitDir func(path string)) error {
+	ignored, err := LoadIgnorePatterns(workspaceDir)
+	if err != nil {
+		return err
+	}
+	patterns := parseIgnorePatterns(slices.Concat(defaultIgnorePatterns, ignored))
+
+	return filepath.WalkDir(workspaceDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if path != workspaceDir {
+			relPath, err := filepath.Rel(workspaceDir, path)
+			if err != nil {
+				return err
+			}
+
+			// Removed and recycled worktrees, and lock files, are not part of any project
+			if relPath == TrashDirName || relPath == PoolDirName || relPath == LockDirName {
+				return filepath.SkipDir
+			}
+
+			depth := strings.Count(relPath, string(filepath.Separator)) + 1
+			if (maxDepth > 0 && depth > maxDepth) || isIgnored(patterns, relPath) {
+				return filepath.SkipDir
+			}
+		}
+
+		// A directory with a .git suffix and a config file is a bare repository
+		if strings.HasSuffix(entry.Name(), ".git") {
+			if _, err := os.Stat(filepath.Join(path, "config")); err == nil {
+				fn(path)
+
+				// Don't descend into .git directories
+				return filepath.SkipDir
+			}
+		}
+
+		if visitDir != nil {
+			visitDir(path)
+		}
+		return nil
+	})
+}
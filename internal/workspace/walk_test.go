@@ -0,0 +1,148 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// createFakeRepo creates a directory that looks like a bare repository to WalkBareRepos
+func createFakeRepo(t *testing.T, workspaceDir, name string) {
+	t.Helper()
+	path := filepath.Join(workspaceDir, name+".git")
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("Failed to create repo dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "config"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to create repo config: %v", err)
+	}
+}
+
+func TestListProjects(t *testing.T) {
+	workspaceDir := t.TempDir()
+	for _, name := range []string{
+		"github.com/user/repo",
+		"gitlab.com/group/sub/deep/repo",
+		"github.com/user/archive/old",
+		"github.com/user/web/node_modules/pkg/vendored",
+		".trash/op/github.com/user/removed",
+		"scratch/tmp",
+	} {
+		createFakeRepo(t, workspaceDir, name)
+	}
+
+	// A directory with a .git suffix but no config is not a repository
+	if err := os.MkdirAll(filepath.Join(workspaceDir, "github.com", "user", "broken.git"), 0o755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		maxDepth int
+		ignore   string
+		want     []string
+	}{
+		{
+			name: "unlimited",
+			want: []string{
+				"github.com/user/archive/old",
+				"github.com/user/repo",
+				"gitlab.com/group/sub/deep/repo",
+				"scratch/tmp",
+			},
+		},
+		{
+			name:     "max depth",
+			maxDepth: 3,
+			want: []string{
+				"github.com/user/repo",
+				"scratch/tmp",
+			},
+		},
+		{
+			name:   "negated pattern",
+			ignore: "github.com/user/*\n!github.com/user/archive\n/scratch\n**/deep\n",
+			want: []string{
+				"github.com/user/archive/old",
+			},
+		},
+		{
+			name:   "ignore file",
+			ignore: "# stray checkouts\narchive\n\nscratch/\ngitlab.com/*/sub\n",
+			want: []string{
+				"github.com/user/repo",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMaxDepth(tt.maxDepth)
+			t.Cleanup(func() { SetMaxDepth(0) })
+
+			ignorePath := GetIgnoreFilePath(workspaceDir)
+			if err := os.WriteFile(ignorePath, []byte(tt.ignore), 0o644); err != nil {
+				t.Fatalf("Failed to write ignore file: %v", err)
+			}
+
+			got, err := ListProjects(workspaceDir)
+			if err != nil {
+				t.Fatalf("ListProjects() failed: %v", err)
+			}
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListProjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	tests := []struct {
+		pattern string
+		relPath string
+		want    bool
+	}{
+		{pattern: "archive", relPath: "archive", want: true},
+		{pattern: "archive", relPath: "github.com/user/archive", want: true},
+		{pattern: "archive/", relPath: "github.com/user/archive", want: true},
+		{pattern: "/archive", relPath: "archive", want: true},
+		{pattern: "/archive", relPath: "github.com/archive", want: false},
+		{pattern: "github.com/*/old", relPath: "github.com/user/old", want: true},
+		{pattern: "github.com/*/old", relPath: "gitlab.com/github.com/user/old", want: false},
+		{pattern: "github.com/**/old", relPath: "github.com/old", want: true},
+		{pattern: "github.com/**/old", relPath: "github.com/a/b/old", want: true},
+		{pattern: "**/vendor", relPath: "github.com/user/vendor", want: true},
+		{pattern: "scratch/**", relPath: "scratch/tmp", want: true},
+		{pattern: "*.tmp", relPath: "github.com/user/x.tmp", want: true},
+		{pattern: "\\#notes", relPath: "#notes", want: true},
+	}
+
+	for _, tt := range tests {
+		if got := isIgnored(parseIgnorePatterns([]string{tt.pattern}), tt.relPath); got != tt.want {
+			t.Errorf("isIgnored(%q, %q) = %v, want %v", tt.pattern, tt.relPath, got, tt.want)
+		}
+	}
+
+	// The last matching pattern decides
+	patterns := parseIgnorePatterns([]string{"github.com/user/*", "!github.com/user/keep"})
+	if isIgnored(patterns, "github.com/user/keep") {
+		t.Error("isIgnored() of a negated path = true, want false")
+	}
+	if !isIgnored(patterns, "github.com/user/other") {
+		t.Error("isIgnored() of an ignored path = false, want true")
+	}
+}
+
+func TestLoadIgnorePatterns_MissingFile(t *testing.T) {
+	patterns, err := LoadIgnorePatterns(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadIgnorePatterns() failed: %v", err)
+	}
+	if len(patterns) != 0 {
+		t.Errorf("LoadIgnorePatterns() = %v, want none", patterns)
+	}
+}
@@ -2,10 +2,14 @@ package workspace
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/rotisserie/eris"
 )
@@ -26,6 +30,13 @@ func GetWorktreeBasePath(workspaceDir, projectName string) string {
 	return filepath.Join(workspaceDir, projectName)
 }
 
+// GetProjectWorkspaceDir returns the workspace root a discovered project is in, from the path
+// of its bare repository, since projects may be in any of several workspace roots
+// Example: ~/.sesh for ~/.sesh/github.com/user/repo.git
+func GetProjectWorkspaceDir(bareRepoPath, projectName string) string {
+	return strings.TrimSuffix(filepath.Clean(bareRepoPath), string(filepath.Separator)+filepath.FromSlash(projectName)+".git")
+}
+
 // GetWorktreePath returns the full path to a worktree for a specific branch
 // Format: <worktreeBasePath>/<sanitizedBranch>
 // Example: ~/.sesh/github.com/user/repo/main
@@ -34,6 +45,171 @@ func GetWorktreePath(worktreeBasePath, branch string) string {
 	return filepath.Join(worktreeBasePath, sanitizedBranch)
 }
 
+// DefaultWorktreePathTemplate keeps the worktrees of a project in a directory next to its bare repository
+const DefaultWorktreePathTemplate = "{workspace}/{project}/{branch}"
+
+// worktreePathTemplate is where ProjectWorktreePath puts worktrees
+var worktreePathTemplate = DefaultWorktreePathTemplate
+
+// worktreePlaceholders are the placeholders of worktree path templates
+var worktreePlaceholders = []string{"{workspace}", "{project}", "{owner}", "{repo}", "{branch}"}
+
+// SetWorktreePathTemplate sets where new worktrees are created, e.g. "~/worktrees/{repo}/{branch}"
+// with ~ already expanded; an empty template restores the default layout
+func SetWorktreePathTemplate(template string) {
+	if template == "" {
+		template = DefaultWorktreePathTemplate
+	}
+	worktreePathTemplate = template
+}
+
+// ValidateWorktreePathTemplate checks that a template only uses known placeholders and
+// tells apart the worktrees of different branches
+func ValidateWorktreePathTemplate(template string) error {
+	rest := template
+	for _, placeholder := range worktreePlaceholders {
+		rest = strings.ReplaceAll(rest, placeholder, "")
+	}
+	if i := strings.Index(rest, "{"); i >= 0 {
+		end := strings.Index(rest[i:], "}")
+		if end < 0 {
+			end = len(rest) - i - 1
+		}
+		return eris.Errorf(
+			"unknown placeholder: %s (must be one of: %s)",
+			rest[i:i+end+1], strings.Join(worktreePlaceholders, ", "),
+		)
+	}
+	if !strings.Contains(template, "{branch}") {
+		return eris.New("the template must contain {branch}")
+	}
+	if !strings.Contains(template, "{project}") && !strings.Contains(template, "{repo}") {
+		return eris.New("the template must contain {project} or {repo}")
+	}
+	return nil
+}
+
+// ProjectWorktreePath returns where the worktree of a branch is created, following the worktree
+// path template (see SetWorktreePathTemplate). Relative templates are relative to the workspace.
+// Example: ~/.sesh/github.com/user/repo/feature-foo by default
+func ProjectWorktreePath(workspaceDir, projectName, branch string) string {
+	return expandWorktreePathTemplate(worktreePathTemplate, workspaceDir, projectName, SanitizeBranchName(branch))
+}
+
+// TemplateWorktreePath returns where the worktree of a branch is created following the given
+// template, like ProjectWorktreePath does with the one set with SetWorktreePathTemplate. An
+// empty template is the default layout.
+func TemplateWorktreePath(template, workspaceDir, projectName, branch string) string {
+	if template == "" {
+		template = DefaultWorktreePathTemplate
+	}
+	return expandWorktreePathTemplate(template, workspaceDir, projectName, SanitizeBranchName(branch))
+}
+
+// IsExternalWorktree reports whether a worktree is somewhere sesh wouldn't have created it,
+// like worktrees added with 'git worktree add': it has no branch checked out, or isn't at
+// the path of its branch
+func IsExternalWorktree(workspaceDir, projectName, branch, worktreePath string) bool {
+	if branch == "" || branch == "(detached)" {
+		return true
+	}
+	want := ProjectWorktreePath(workspaceDir, projectName, branch)
+	if filepath.Clean(worktreePath) == want {
+		return false
+	}
+	// git reports paths with symlinks resolved, e.g. /private/tmp for /tmp on macOS
+	resolved, err := filepath.EvalSymlinks(want)
+	if err != nil {
+		return true
+	}
+	actual, err := filepath.EvalSymlinks(worktreePath)
+	return err != nil || actual != resolved
+}
+
+// GetWorktreeParentPath returns the directory all worktrees of a project are created in, or ""
+// when the worktree path template doesn't keep them in a directory of their own
+func GetWorktreeParentPath(workspaceDir, projectName string) string {
+	parent := filepath.Dir(worktreePathTemplate)
+	if strings.Contains(parent, "{branch}") ||
+		(!strings.Contains(parent, "{project}") && !strings.Contains(parent, "{repo}")) {
+		return ""
+	}
+	return filepath.Dir(expandWorktreePathTemplate(worktreePathTemplate, workspaceDir, projectName, "branch"))
+}
+
+// expandWorktreePathTemplate fills in the placeholders of a worktree path template
+func expandWorktreePathTemplate(template, workspaceDir, projectName, sanitizedBranch string) string {
+	owner := ""
+	if dir := filepath.Dir(filepath.FromSlash(projectName)); dir != "." {
+		owner = filepath.Base(dir)
+	}
+
+	path := strings.NewReplacer(
+		"{workspace}", workspaceDir,
+		"{project}", filepath.FromSlash(projectName),
+		"{owner}", owner,
+		"{repo}", GetRepoNameFromProject(projectName),
+		"{branch}", sanitizedBranch,
+	).Replace(template)
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workspaceDir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// TrashDirName is the name of the directory inside the workspace that holds removed worktrees
+const TrashDirName = ".trash"
+
+// GetTrashPath returns the path a removed worktree is moved to inside the workspace trash
+// Format: <workspaceDir>/.trash/<operationID>/<projectName>/<sanitizedBranch>
+// Example: ~/.sesh/.trash/20250101T120000/github.com/user/repo/feature-foo
+func GetTrashPath(workspaceDir, operationID, projectName, branch string) string {
+	return filepath.Join(workspaceDir, TrashDirName, operationID, projectName, SanitizeBranchName(branch))
+}
+
+// PoolDirName is the name of the directory inside the workspace that holds worktrees kept to be
+// recycled for new branches
+const PoolDirName = ".pool"
+
+// GetPoolDir returns the directory holding the recycled worktrees of a project
+// Format: <workspaceDir>/.pool/<projectName>
+// Example: ~/.sesh/.pool/github.com/user/repo
+func GetPoolDir(workspaceDir, projectName string) string {
+	return filepath.Join(workspaceDir, PoolDirName, projectName)
+}
+
+// SameFilesystem reports whether two paths are on the same filesystem, so a worktree can be moved
+// from one to the other, like into the trash or the pool. Paths that don't exist yet are compared
+// by their closest existing parent.
+func SameFilesystem(a, b string) bool {
+	return sameDevice(existingParent(a), existingParent(b))
+}
+
+// existingParent returns the closest path to path, itself included, that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// LockDirName is the name of the directory inside the workspace that holds per-project lock files
+const LockDirName = ".locks"
+
+// GetLockPath returns the path of the lock file that serializes changes to a project
+// Format: <workspaceDir>/.locks/<projectName>.lock
+// Example: ~/.sesh/.locks/github.com/user/repo.lock
+func GetLockPath(workspaceDir, projectName string) string {
+	return filepath.Join(workspaceDir, LockDirName, projectName+".lock")
+}
+
 // EnsureProjectDir creates the project directory if it doesn't exist
 func EnsureProjectDir(projectPath string) error {
 	if err := os.MkdirAll(projectPath, 0o755); err != nil {
@@ -42,14 +218,76 @@ func EnsureProjectDir(projectPath string) error {
 	return nil
 }
 
+// sharedRepoNames maps repository names shared by several projects to the names of those projects,
+// oldest first. keptPrefixes are the session prefixes kept for projects (see SetSessionPrefixes).
+var (
+	sharedRepoNames   map[string][]string
+	keptPrefixes      map[string]string
+	sharedRepoNamesMu sync.RWMutex
+)
+
+// SetProjectNames records the names of all projects, oldest first, so GenerateSessionName can
+// tell apart projects with the same repository name, like github.com/acme/api and
+// github.com/other/api
+func SetProjectNames(names []string) {
+	byRepo := make(map[string][]string)
+	for _, name := range names {
+		repoName := filepath.Base(name)
+		byRepo[repoName] = append(byRepo[repoName], name)
+	}
+
+	shared := make(map[string][]string)
+	for repoName, projects := range byRepo {
+		if len(projects) > 1 {
+			shared[repoName] = projects
+		}
+	}
+
+	sharedRepoNamesMu.Lock()
+	defer sharedRepoNamesMu.Unlock()
+	sharedRepoNames = shared
+}
+
+// SetSessionPrefixes records the session prefixes kept for projects when they were first given one,
+// by project name. SessionPrefix returns them instead of deriving new ones, so adding or removing
+// other projects never renames the sessions of these.
+func SetSessionPrefixes(prefixes map[string]string) {
+	sharedRepoNamesMu.Lock()
+	defer sharedRepoNamesMu.Unlock()
+	keptPrefixes = maps.Clone(prefixes)
+}
+
+// SharedProjectNames returns the names of the projects whose repository name another project has,
+// oldest first for each repository name
+func SharedProjectNames() []string {
+	sharedRepoNamesMu.RLock()
+	defer sharedRepoNamesMu.RUnlock()
+
+	var names []string
+	for _, repoName := range slices.Sorted(maps.Keys(sharedRepoNames)) {
+		names = append(names, sharedRepoNames[repoName]...)
+	}
+	return names
+}
+
+// HasSharedRepoName reports whether an older known project has the same repository name, in
+// which case the session names of this one include the owner (see GenerateSessionName)
+func HasSharedRepoName(projectName string) bool {
+	return SessionPrefix(projectName) != filepath.Base(projectName)
+}
+
 // GenerateSessionName generates a unique session name from project name and branch
 // Format: <repoName>-<branch>
 // Example: "repo-main", "myproject-feature-foo"
+// When older projects have the same repository name (see SetProjectNames), the owner is
+// included, along with as many further segments as it takes to tell them apart. The oldest
+// project keeps the plain name, so cloning another project never renames existing sessions.
+// Example: "api-main" and "other-api-main"
 // Note: We use "-" instead of ":" because ":" is a special character in tmux
 // that separates session names from window names (e.g., "session:window")
 func GenerateSessionName(projectName, branch string) string {
-	// Extract repository name from project path (last component)
-	repoName := filepath.Base(projectName)
+	// Extract repository name from project path (last component), qualified if it is shared
+	repoName := SessionPrefix(projectName)
 
 	// Sanitize branch name for session compatibility
 	sanitizedBranch := SanitizeBranchName(branch)
@@ -57,6 +295,68 @@ func GenerateSessionName(projectName, branch string) string {
 	return fmt.Sprintf("%s-%s", repoName, sanitizedBranch)
 }
 
+// GenerateSubprojectSessionName generates the session name of a sub-project of a monorepo
+// for a branch: the sub-project's name after the repository name, or its own session name
+// instead of both when it has one
+// Example: "monorepo-api-main", or "api-main" with the session name "api"
+func GenerateSubprojectSessionName(projectName, subproject, sessionName, branch string) string {
+	if sessionName == "" {
+		sessionName = SessionPrefix(projectName) + "-" + subproject
+	}
+	return fmt.Sprintf("%s-%s", sessionName, SanitizeBranchName(branch))
+}
+
+// SessionPrefix returns the part of the session names of a project before the branch: its
+// repository name, preceded by as many of the segments before it as it takes to tell it apart
+// from the older projects sharing its name. A prefix kept for the project (see
+// SetSessionPrefixes) is returned as is, and projects with one count as older than any without.
+// Newer projects don't change it either, so cloning one doesn't rename the sessions of the others,
+// unless the new project's whole name is a suffix of this one's, like api and
+// github.com/acme/api, and it can only be told apart this way.
+func SessionPrefix(projectName string) string {
+	segments := strings.Split(filepath.ToSlash(projectName), "/")
+
+	sharedRepoNamesMu.RLock()
+	others := sharedRepoNames[segments[len(segments)-1]]
+	kept, isKept := keptPrefixes[projectName]
+	// Without other projects to tell apart from, there is no need for the kept prefixes
+	var otherPrefixes map[string]string
+	if len(others) > 0 {
+		otherPrefixes = maps.Clone(keptPrefixes)
+	}
+	sharedRepoNamesMu.RUnlock()
+	if isKept {
+		return kept
+	}
+
+	// Projects that aren't known yet, like one being cloned, are the newest
+	i := slices.Index(others, projectName)
+	var older, newer []string
+	for j, other := range others {
+		if _, ok := otherPrefixes[other]; ok || i < 0 || j < i {
+			older = append(older, other)
+		} else if j > i {
+			newer = append(newer, other)
+		}
+	}
+
+	for n := 1; n < len(segments); n++ {
+		suffix := strings.Join(segments[len(segments)-n:], "/")
+		prefix := strings.Join(segments[len(segments)-n:], "-")
+		unique := !slices.Contains(newer, suffix)
+		for _, other := range older {
+			if other == suffix || strings.HasSuffix(other, "/"+suffix) || otherPrefixes[other] == prefix {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			return prefix
+		}
+	}
+	return strings.Join(segments, "-")
+}
+
 // SanitizeBranchName sanitizes a branch name for use in filesystem paths and session names
 // Replaces special characters with safe alternatives
 // Examples:
@@ -88,16 +388,42 @@ func SanitizeBranchName(branch string) string {
 	sanitized = strings.ReplaceAll(sanitized, "@", "-")
 	sanitized = strings.ReplaceAll(sanitized, " ", "-")
 
+	// Control characters are invalid in file names on NTFS
+	sanitized = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, sanitized)
+
 	// Remove multiple consecutive hyphens
 	re := regexp.MustCompile(`-+`)
 	sanitized = re.ReplaceAllString(sanitized, "-")
 
-	// Remove leading/trailing hyphens
-	sanitized = strings.Trim(sanitized, "-")
+	// Remove leading/trailing hyphens, and trailing dots, which Windows drops from file names
+	sanitized = strings.Trim(strings.TrimRight(sanitized, "."), "-")
+
+	// Windows reserves device names, even with an extension, so they get a suffix
+	base, ext, _ := strings.Cut(sanitized, ".")
+	if windowsReservedNames[strings.ToUpper(base)] {
+		sanitized = base + "_"
+		if ext != "" {
+			sanitized += "." + ext
+		}
+	}
 
 	return sanitized
 }
 
+// windowsReservedNames are the device names Windows doesn't allow as file names
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
 // ParseSessionName parses a session name back into repository and branch
 // Format: <repoName>-<branch>
 // Returns: repoName, branch, error
@@ -145,6 +471,70 @@ func GetProjectFromFullPath(workspaceDir, fullPath string) (string, error) {
 	return projectName, nil
 }
 
+// FindWorktree finds the project and worktree that contain path, using only the filesystem
+// Worktrees in the default layout are recognized by the bare repository next to their parent
+// directory, and others by the .git file pointing into the bare repository. Returns empty
+// strings when path isn't in a worktree of a project in the workspace.
+func FindWorktree(workspaceDir, path string) (projectName, worktreePath string) {
+	if projectName, worktreePath = findWorktreeInLayout(workspaceDir, path); projectName != "" {
+		return projectName, worktreePath
+	}
+	// Worktrees created with a worktree path template can be anywhere
+	return findLinkedWorktree(workspaceDir, path)
+}
+
+// findWorktreeInLayout finds the worktree containing path in the default layout
+// A directory is a worktree when its parent is the worktree base path of a project, which is
+// recognized by the bare repository next to it.
+func findWorktreeInLayout(workspaceDir, path string) (projectName, worktreePath string) {
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		base := filepath.Dir(dir)
+		relBase, err := filepath.Rel(workspaceDir, base)
+		if err != nil || relBase == "." || strings.HasPrefix(relBase, "..") {
+			return "", ""
+		}
+
+		if ProjectExists(workspaceDir, relBase) {
+			return filepath.ToSlash(relBase), dir
+		}
+	}
+}
+
+// findLinkedWorktree finds the worktree containing path from its .git file, which points to
+// <workspace>/<project>.git/worktrees/<name> for worktrees of a project in the workspace
+func findLinkedWorktree(workspaceDir, path string) (projectName, worktreePath string) {
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		data, err := os.ReadFile(filepath.Join(dir, ".git"))
+		if err == nil {
+			gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+			if !ok {
+				return "", ""
+			}
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			if filepath.Base(filepath.Dir(gitDir)) != "worktrees" {
+				return "", ""
+			}
+
+			relRepo, err := filepath.Rel(workspaceDir, filepath.Dir(filepath.Dir(gitDir)))
+			if err != nil || strings.HasPrefix(relRepo, "..") {
+				return "", ""
+			}
+			name, ok := strings.CutSuffix(filepath.ToSlash(relRepo), ".git")
+			if !ok {
+				return "", ""
+			}
+			return name, dir
+		}
+
+		// A .git directory is a repository of its own, which is never a sesh worktree
+		if !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			return "", ""
+		}
+	}
+}
+
 // WorkspaceExists checks if the workspace directory exists
 func WorkspaceExists(workspaceDir string) bool {
 	info, err := os.Stat(workspaceDir)
@@ -174,46 +564,27 @@ func WorktreeExists(worktreePath string) bool {
 }
 
 // ListProjects lists all projects in the workspace directory
+// The walk is bounded as described in WalkBareRepos
 // Returns a list of project names (e.g., ["github.com/user/repo1", "github.com/user/repo2"])
 // Projects are identified by bare repositories with a .git suffix (e.g., repo.git)
 func ListProjects(workspaceDir string) ([]string, error) {
 	var projects []string
+	var relErr error
 
-	// Walk the workspace directory looking for directories ending with .git suffix
-	err := filepath.Walk(workspaceDir, func(path string, info os.FileInfo, err error) error {
+	err := WalkBareRepos(workspaceDir, func(path string) {
+		// Get relative path from workspace
+		relPath, err := filepath.Rel(workspaceDir, path)
 		if err != nil {
-			return err
-		}
-
-		// Skip if not a directory
-		if !info.IsDir() {
-			return nil
+			relErr = err
+			return
 		}
 
-		// Check if this is a bare repo directory (ends with .git suffix)
-		if strings.HasSuffix(info.Name(), ".git") {
-			// Verify it's a valid git repo by checking for config file
-			configPath := filepath.Join(path, "config")
-			if _, err := os.Stat(configPath); err != nil {
-				return nil // Not a valid git repo
-			}
-
-			// Get relative path from workspace
-			relPath, err := filepath.Rel(workspaceDir, path)
-			if err != nil {
-				return err
-			}
-
-			// Remove the .git suffix to get the project name
-			projectName := strings.TrimSuffix(relPath, ".git")
-			projects = append(projects, projectName)
-
-			// Don't descend into .git directories
-			return filepath.SkipDir
-		}
-
-		return nil
-	})
+		// Remove the .git suffix to get the project name
+		projects = append(projects, strings.TrimSuffix(relPath, ".git"))
+	}, nil)
+	if err == nil {
+		err = relErr
+	}
 	if err != nil {
 		return nil, eris.Wrap(err, "failed to list projects in workspace")
 	}
@@ -241,7 +612,8 @@ func ExpandPath(path string) (string, error) {
 		return homeDir, nil
 	}
 
-	if strings.HasPrefix(path, "~/") {
+	// "~\" is the home directory too on Windows
+	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
 		return filepath.Join(homeDir, path[2:]), nil
 	}
 
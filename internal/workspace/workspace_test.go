@@ -1,7 +1,10 @@
 package workspace
 
 import (
+	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"testing"
 )
 
@@ -51,6 +54,31 @@ func TestSanitizeBranchName(t *testing.T) {
 			input:    "/feature/",
 			expected: "feature",
 		},
+		{
+			name:     "branch with control characters",
+			input:    "fix\tbug\x01",
+			expected: "fix-bug",
+		},
+		{
+			name:     "branch with trailing dots",
+			input:    "release/v1..",
+			expected: "release-v1",
+		},
+		{
+			name:     "windows reserved name",
+			input:    "con",
+			expected: "con_",
+		},
+		{
+			name:     "windows reserved name with extension",
+			input:    "LPT1.old",
+			expected: "LPT1_.old",
+		},
+		{
+			name:     "reserved name within a branch",
+			input:    "feature/con",
+			expected: "feature-con",
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,6 +134,87 @@ func TestGenerateSessionName(t *testing.T) {
 	}
 }
 
+func TestGenerateSubprojectSessionName(t *testing.T) {
+	tests := []struct {
+		subproject  string
+		sessionName string
+		branch      string
+		expected    string
+	}{
+		{subproject: "api", branch: "main", expected: "mono-api-main"},
+		{subproject: "web", branch: "feature/login", expected: "mono-web-feature-login"},
+		{subproject: "api", sessionName: "svc", branch: "main", expected: "svc-main"},
+	}
+
+	for _, tt := range tests {
+		result := GenerateSubprojectSessionName("github.com/acme/mono", tt.subproject, tt.sessionName, tt.branch)
+		if result != tt.expected {
+			t.Errorf("GenerateSubprojectSessionName(%q, %q, %q) = %q, want %q",
+				tt.subproject, tt.sessionName, tt.branch, result, tt.expected)
+		}
+	}
+}
+
+func TestGenerateSessionName_SharedRepoName(t *testing.T) {
+	SetProjectNames([]string{
+		"github.com/acme/api",
+		"github.com/other/api",
+		"gitlab.com/acme/api",
+		"api",
+		"github.com/acme/web",
+	})
+	t.Cleanup(func() { SetProjectNames(nil) })
+
+	tests := []struct {
+		projectName string
+		expected    string
+		shared      bool
+	}{
+		// The oldest project only needs the owner to be told apart from api, which has nothing else
+		{projectName: "github.com/acme/api", expected: "acme-api-main", shared: true},
+		{projectName: "github.com/other/api", expected: "other-api-main", shared: true},
+		{projectName: "gitlab.com/acme/api", expected: "gitlab.com-acme-api-main", shared: true},
+		{projectName: "api", expected: "api-main", shared: false},
+		{projectName: "github.com/acme/web", expected: "web-main", shared: false},
+		// A project being cloned is newer than all the others
+		{projectName: "bitbucket.org/acme/api", expected: "bitbucket.org-acme-api-main", shared: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.projectName, func(t *testing.T) {
+			result := GenerateSessionName(tt.projectName, "main")
+			if result != tt.expected {
+				t.Errorf("GenerateSessionName(%q, main) = %q, want %q", tt.projectName, result, tt.expected)
+			}
+			if shared := HasSharedRepoName(tt.projectName); shared != tt.shared {
+				t.Errorf("HasSharedRepoName(%q) = %v, want %v", tt.projectName, shared, tt.shared)
+			}
+		})
+	}
+}
+
+func TestSessionPrefix_NewProjectKeepsOthers(t *testing.T) {
+	t.Cleanup(func() { SetProjectNames(nil) })
+
+	projects := []string{"github.com/acme/api"}
+	SetProjectNames(projects)
+	before := SessionPrefix("github.com/acme/api")
+
+	for _, name := range []string{"github.com/other/api", "gitlab.com/other/api"} {
+		projects = append(projects, name)
+		SetProjectNames(projects)
+		if got := SessionPrefix("github.com/acme/api"); got != before {
+			t.Errorf("after cloning %s, SessionPrefix(github.com/acme/api) = %q, want %q", name, got, before)
+		}
+	}
+	if got := SessionPrefix("github.com/other/api"); got != "other-api" {
+		t.Errorf("SessionPrefix(github.com/other/api) = %q, want %q", got, "other-api")
+	}
+	if got := SessionPrefix("gitlab.com/other/api"); got != "gitlab.com-other-api" {
+		t.Errorf("SessionPrefix(gitlab.com/other/api) = %q, want %q", got, "gitlab.com-other-api")
+	}
+}
+
 func TestParseSessionName(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -256,6 +365,37 @@ func TestGetWorktreeBasePath(t *testing.T) {
 	}
 }
 
+func TestGetProjectWorkspaceDir(t *testing.T) {
+	tests := []struct {
+		name         string
+		bareRepoPath string
+		projectName  string
+		expected     string
+	}{
+		{
+			name:         "simple project",
+			bareRepoPath: "/home/user/.sesh/myrepo.git",
+			projectName:  "myrepo",
+			expected:     "/home/user/.sesh",
+		},
+		{
+			name:         "github project in another workspace",
+			bareRepoPath: "/mnt/work/code/github.com/user/repo.git",
+			projectName:  "github.com/user/repo",
+			expected:     "/mnt/work/code",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GetProjectWorkspaceDir(tt.bareRepoPath, tt.projectName)
+			if result != tt.expected {
+				t.Errorf("GetProjectWorkspaceDir(%q, %q) = %q, want %q", tt.bareRepoPath, tt.projectName, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetWorktreePath(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -287,6 +427,144 @@ func TestGetWorktreePath(t *testing.T) {
 	}
 }
 
+func TestProjectWorktreePath(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		wantPath   string
+		wantParent string
+	}{
+		{
+			name:       "default layout",
+			template:   "",
+			wantPath:   "/home/user/.sesh/github.com/user/repo/feature-foo",
+			wantParent: "/home/user/.sesh/github.com/user/repo",
+		},
+		{
+			name:       "separate tree",
+			template:   "/home/user/worktrees/{repo}/{branch}",
+			wantPath:   "/home/user/worktrees/repo/feature-foo",
+			wantParent: "/home/user/worktrees/repo",
+		},
+		{
+			name:       "relative to the workspace",
+			template:   "worktrees/{owner}/{repo}/{branch}",
+			wantPath:   "/home/user/.sesh/worktrees/user/repo/feature-foo",
+			wantParent: "/home/user/.sesh/worktrees/user/repo",
+		},
+		{
+			name:       "flat directory",
+			template:   "/tmp/wt/{repo}-{branch}",
+			wantPath:   "/tmp/wt/repo-feature-foo",
+			wantParent: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetWorktreePathTemplate(tt.template)
+			t.Cleanup(func() { SetWorktreePathTemplate("") })
+
+			path := ProjectWorktreePath("/home/user/.sesh", "github.com/user/repo", "feature/foo")
+			if path != tt.wantPath {
+				t.Errorf("ProjectWorktreePath() = %q, want %q", path, tt.wantPath)
+			}
+			// The template can be given instead of being set, with the same result
+			SetWorktreePathTemplate("")
+			path = TemplateWorktreePath(tt.template, "/home/user/.sesh", "github.com/user/repo", "feature/foo")
+			if path != tt.wantPath {
+				t.Errorf("TemplateWorktreePath() = %q, want %q", path, tt.wantPath)
+			}
+			SetWorktreePathTemplate(tt.template)
+			parent := GetWorktreeParentPath("/home/user/.sesh", "github.com/user/repo")
+			if parent != tt.wantParent {
+				t.Errorf("GetWorktreeParentPath() = %q, want %q", parent, tt.wantParent)
+			}
+		})
+	}
+}
+
+func TestIsExternalWorktree(t *testing.T) {
+	tests := []struct {
+		name         string
+		branch       string
+		worktreePath string
+		want         bool
+	}{
+		{name: "created by sesh", branch: "feature/foo", worktreePath: "/ws/github.com/user/repo/feature-foo"},
+		{name: "trailing slash", branch: "main", worktreePath: "/ws/github.com/user/repo/main/"},
+		{name: "elsewhere", branch: "main", worktreePath: "/home/user/src/repo-main", want: true},
+		{name: "named after another branch", branch: "fix", worktreePath: "/ws/github.com/user/repo/main", want: true},
+		{name: "detached", branch: "(detached)", worktreePath: "/ws/github.com/user/repo/main", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsExternalWorktree("/ws", "github.com/user/repo", tt.branch, tt.worktreePath); got != tt.want {
+				t.Errorf("IsExternalWorktree(%q, %q) = %v, want %v", tt.branch, tt.worktreePath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWorktreePathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{template: DefaultWorktreePathTemplate},
+		{template: "~/worktrees/{repo}/{branch}"},
+		{template: "{workspace}/{owner}-{repo}-{branch}"},
+		{template: "~/worktrees/{repo}", wantErr: true},
+		{template: "~/worktrees/{branch}", wantErr: true},
+		{template: "~/worktrees/{repo}/{branch}/{user}", wantErr: true},
+		{template: "~/worktrees/{repo}/{branch", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			err := ValidateWorktreePathTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateWorktreePathTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetTrashPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		operationID string
+		projectName string
+		branch      string
+		expected    string
+	}{
+		{
+			name:        "simple branch",
+			operationID: "20250101T120000",
+			projectName: "github.com/user/repo",
+			branch:      "main",
+			expected:    "/home/user/.sesh/.trash/20250101T120000/github.com/user/repo/main",
+		},
+		{
+			name:        "branch with slash",
+			operationID: "20250101T120000",
+			projectName: "github.com/user/repo",
+			branch:      "feature/foo",
+			expected:    "/home/user/.sesh/.trash/20250101T120000/github.com/user/repo/feature-foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GetTrashPath("/home/user/.sesh", tt.operationID, tt.projectName, tt.branch)
+			if result != tt.expected {
+				t.Errorf("GetTrashPath() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestExpandPath(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -427,3 +705,159 @@ func TestCleanPath(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLockPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		projectName string
+		expected    string
+	}{
+		{
+			name:        "hosted project",
+			projectName: "github.com/user/repo",
+			expected:    "/home/user/.sesh/.locks/github.com/user/repo.lock",
+		},
+		{
+			name:        "local project",
+			projectName: "tmp/src",
+			expected:    "/home/user/.sesh/.locks/tmp/src.lock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GetLockPath("/home/user/.sesh", tt.projectName)
+			if result != tt.expected {
+				t.Errorf("GetLockPath() = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindWorktree(t *testing.T) {
+	ws := t.TempDir()
+	for _, dir := range []string{
+		"github.com/user/repo.git",
+		"github.com/user/repo/main/src",
+		"gitlab.com/group/sub/app.git",
+		"gitlab.com/group/sub/app/feature-foo",
+		"scratch/notes",
+		"worktrees/repo/feature-bar/src",
+		"worktrees/other/main",
+	} {
+		if err := os.MkdirAll(filepath.Join(ws, dir), 0o755); err != nil {
+			t.Fatalf("MkdirAll() failed: %v", err)
+		}
+	}
+
+	// Worktrees created with a worktree path template are found through their .git file
+	for dir, gitDir := range map[string]string{
+		"worktrees/repo/feature-bar": filepath.Join(ws, "github.com/user/repo.git/worktrees/feature-bar"),
+		"worktrees/other/main":       "/elsewhere/other.git/worktrees/main",
+	} {
+		if err := os.WriteFile(filepath.Join(ws, dir, ".git"), []byte("gitdir: "+gitDir+"\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		wantProject  string
+		wantWorktree string
+	}{
+		{
+			name:         "worktree root",
+			path:         "github.com/user/repo/main",
+			wantProject:  "github.com/user/repo",
+			wantWorktree: "github.com/user/repo/main",
+		},
+		{
+			name:         "inside a worktree",
+			path:         "github.com/user/repo/main/src",
+			wantProject:  "github.com/user/repo",
+			wantWorktree: "github.com/user/repo/main",
+		},
+		{
+			name:         "nested group",
+			path:         "gitlab.com/group/sub/app/feature-foo",
+			wantProject:  "gitlab.com/group/sub/app",
+			wantWorktree: "gitlab.com/group/sub/app/feature-foo",
+		},
+		{
+			name:         "linked worktree elsewhere",
+			path:         "worktrees/repo/feature-bar/src",
+			wantProject:  "github.com/user/repo",
+			wantWorktree: "worktrees/repo/feature-bar",
+		},
+		{name: "worktree of a repository outside the workspace", path: "worktrees/other/main"},
+		{name: "worktree base", path: "github.com/user/repo"},
+		{name: "bare repository", path: "github.com/user/repo.git"},
+		{name: "not a project", path: "scratch/notes"},
+		{name: "workspace root", path: "."},
+		{name: "outside the workspace", path: ".."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, worktree := FindWorktree(ws, filepath.Join(ws, tt.path))
+			if project != tt.wantProject {
+				t.Errorf("project = %q, want %q", project, tt.wantProject)
+			}
+			wantWorktree := ""
+			if tt.wantWorktree != "" {
+				wantWorktree = filepath.Join(ws, tt.wantWorktree)
+			}
+			if worktree != wantWorktree {
+				t.Errorf("worktree = %q, want %q", worktree, wantWorktree)
+			}
+		})
+	}
+}
+
+func TestSameFilesystem(t *testing.T) {
+	dir := t.TempDir()
+
+	if !SameFilesystem(dir, filepath.Join(dir, "missing", "worktree")) {
+		t.Errorf("SameFilesystem(%s, a path under it that doesn't exist yet) = false, want true", dir)
+	}
+	if runtime.GOOS == "linux" && SameFilesystem(dir, "/proc/self") {
+		t.Errorf("SameFilesystem(%s, /proc/self) = true, want false", dir)
+	}
+}
+
+func TestSessionPrefix_KeptPrefixes(t *testing.T) {
+	t.Cleanup(func() {
+		SetProjectNames(nil)
+		SetSessionPrefixes(nil)
+	})
+
+	// An imported repository keeps the age of the clone it came from, so it can look older
+	SetProjectNames([]string{"github.com/other/api", "github.com/acme/api"})
+	SetSessionPrefixes(map[string]string{"github.com/acme/api": "api"})
+
+	tests := []struct {
+		projectName string
+		want        string
+	}{
+		{projectName: "github.com/acme/api", want: "api"},
+		{projectName: "github.com/other/api", want: "other-api"},
+		{projectName: "gitlab.com/acme/api", want: "gitlab.com-acme-api"},
+	}
+	for _, tt := range tests {
+		if got := SessionPrefix(tt.projectName); got != tt.want {
+			t.Errorf("SessionPrefix(%q) = %q, want %q", tt.projectName, got, tt.want)
+		}
+	}
+	want := []string{"github.com/other/api", "github.com/acme/api"}
+	if got := SharedProjectNames(); !slices.Equal(got, want) {
+		t.Errorf("SharedProjectNames() = %v, want %v", got, want)
+	}
+
+	// A kept prefix stays once the project no longer shares its repository name
+	SetProjectNames([]string{"github.com/other/api"})
+	SetSessionPrefixes(map[string]string{"github.com/other/api": "other-api"})
+	if got := SessionPrefix("github.com/other/api"); got != "other-api" {
+		t.Errorf("SessionPrefix(github.com/other/api) alone = %q, want other-api", got)
+	}
+}
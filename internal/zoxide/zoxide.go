@@ -0,0 +1,37 @@
+// Package zoxide registers worktree directories with zoxide, so 'z <branch>' jumps into them
+package zoxide
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/rotisserie/eris"
+)
+
+// IsInstalled reports whether the zoxide binary is on the PATH
+func IsInstalled() bool {
+	_, err := exec.LookPath("zoxide")
+	return err == nil
+}
+
+// Add adds a directory to the zoxide database, or bumps its rank if it is already there
+func Add(path string) error {
+	output, err := exec.Command("zoxide", "add", "--", path).CombinedOutput()
+	if err != nil {
+		return eris.Wrapf(err, "failed to add %s to zoxide: %s", path, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Remove removes a directory from the zoxide database
+// Directories zoxide doesn't know about are not an error, since they may predate the integration.
+func Remove(path string) error {
+	output, err := exec.Command("zoxide", "remove", "--", path).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(output), "not found") {
+			return nil
+		}
+		return eris.Wrapf(err, "failed to remove %s from zoxide: %s", path, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
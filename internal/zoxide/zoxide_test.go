@@ -0,0 +1,69 @@
+package zoxide
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeZoxide puts a zoxide stand-in on the PATH that records its arguments in the returned file
+// and fails to remove directories other than /known, like zoxide does for unknown directories
+func fakeZoxide(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	log := filepath.Join(dir, "log")
+	script := `#!/bin/sh
+echo "$@" >> "` + log + `"
+if [ "$1" = remove ] && [ "$3" != /known ]; then
+  echo "zoxide: path not found in database: $3" >&2
+  exit 1
+fi
+if [ "$3" = /broken ]; then
+  echo "zoxide: database is corrupted" >&2
+  exit 1
+fi
+`
+	if err := os.WriteFile(filepath.Join(dir, "zoxide"), []byte(script), 0o755); err != nil {
+		t.Fatalf("WriteFile() failed: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestZoxide(t *testing.T) {
+	log := fakeZoxide(t)
+
+	if !IsInstalled() {
+		t.Fatal("IsInstalled() = false, want true")
+	}
+
+	tests := []struct {
+		name    string
+		run     func() error
+		wantErr bool
+	}{
+		{name: "add", run: func() error { return Add("/known") }},
+		{name: "add fails", run: func() error { return Add("/broken") }, wantErr: true},
+		{name: "remove", run: func() error { return Remove("/known") }},
+		{name: "remove unknown directory", run: func() error { return Remove("/unknown") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	got, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatalf("ReadFile() failed: %v", err)
+	}
+	want := "add -- /known\nadd -- /broken\nremove -- /known\nremove -- /unknown\n"
+	if string(got) != want {
+		t.Errorf("zoxide calls = %q, want %q", got, want)
+	}
+}
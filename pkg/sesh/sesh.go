@@ -0,0 +1,272 @@
+// Package sesh embeds sesh's workflow in other Go tools: resolve a project in the workspace,
+// ensure a worktree exists for a branch, ensure a session exists for the worktree, and attach
+// to it. It uses the same configuration, workspace layout, and project locks as the sesh CLI,
+// so both can work on the same workspace at the same time.
+//
+//	client, err := sesh.New(sesh.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	_, err = client.Switch("github.com/user/repo", "feature")
+package sesh
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/git"
+	"github.com/benoctopus/sesh/internal/lock"
+	"github.com/benoctopus/sesh/internal/models"
+	"github.com/benoctopus/sesh/internal/prepare"
+	"github.com/benoctopus/sesh/internal/project"
+	"github.com/benoctopus/sesh/internal/session"
+	"github.com/benoctopus/sesh/internal/state"
+	"github.com/benoctopus/sesh/internal/workspace"
+	"github.com/rotisserie/eris"
+)
+
+// lockTimeout is how long to wait for a sesh process working on the same project
+const lockTimeout = 2 * time.Minute
+
+// Options configure a Client; zero values fall back to the user's sesh configuration
+type Options struct {
+	// WorkspaceDir overrides the workspace directory
+	WorkspaceDir string
+	// SessionBackend overrides the session backend, e.g. "tmux", "zellij", or "none"
+	SessionBackend string
+	// Progress receives the progress messages the CLI prints, e.g. "Creating worktree..."
+	// Nil discards them.
+	Progress io.Writer
+}
+
+// Client runs sesh operations against a workspace
+type Client struct {
+	cfg      *config.Config
+	sessions session.SessionManager
+	disp     display.Printer
+	// progress receives the progress bars of slow steps, like starting a dev container
+	progress io.Writer
+}
+
+// Project is a repository in the workspace
+type Project struct {
+	// Name is the full project name, e.g. "github.com/user/repo"
+	Name      string
+	RemoteURL string
+	// Path is the bare repository in the workspace
+	Path string
+}
+
+// Worktree is the checkout of a branch of a project
+type Worktree struct {
+	Project string
+	Branch  string
+	Path    string
+	// Created reports whether the worktree was created, as opposed to already existing
+	Created bool
+}
+
+// Session is the session of a worktree
+type Session struct {
+	Name string
+	Path string
+	// Created reports whether the session was created, as opposed to already running
+	Created bool
+}
+
+// New creates a Client for the configured workspace and session backend
+// The worktree path template, project aliases, and git timeout of the configuration are used by
+// the client alone. Project discovery is shared by the whole process, like the session names
+// derived from it, so New has it search every configured workspace and keep the session names
+// of projects sharing a repository name in the sesh database, like the CLI does.
+func New(opts Options) (*Client, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to load configuration")
+	}
+	if opts.WorkspaceDir != "" {
+		cfg.WorkspaceDir = opts.WorkspaceDir
+	} else {
+		state.SetWorkspaceDirs(config.WorkspacePaths(cfg.Workspaces))
+	}
+	state.SetPrefixStore(prepare.OpenDB)
+	if opts.SessionBackend != "" {
+		cfg.SessionBackend = opts.SessionBackend
+	}
+
+	sessions, err := session.NewSessionManager(cfg.SessionBackend)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to initialize session manager")
+	}
+
+	progress := opts.Progress
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	return &Client{cfg: cfg, sessions: sessions, disp: display.New(progress), progress: progress}, nil
+}
+
+// Projects lists the projects in the workspace
+func (c *Client) Projects() ([]Project, error) {
+	projects, err := state.DiscoverProjects(c.cfg.WorkspaceDir)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to discover projects")
+	}
+
+	result := make([]Project, 0, len(projects))
+	for _, proj := range projects {
+		result = append(result, newProject(proj))
+	}
+	return result, nil
+}
+
+// ResolveProject looks up a project by full name (github.com/user/repo) or short name (repo)
+// An empty name resolves the project of the current directory.
+func (c *Client) ResolveProject(name string) (*Project, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to get current directory")
+	}
+
+	proj, err := project.ResolveProjectWithAliases(c.cfg.WorkspaceDir, name, cwd, c.cfg.ProjectAliases)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newProject(proj)
+	return &result, nil
+}
+
+// EnsureWorktree returns the worktree of a branch, creating it if needed, like 'sesh switch'
+// A new worktree checks out the local branch, the remote branch, or a new branch from HEAD,
+// reusing a recycled worktree or cloning the default branch's worktree when enabled. Its
+// submodules and Git LFS files are checked out like the CLI does, and with the zoxide
+// integration enabled, the worktree is registered with zoxide. Canceling ctx stops the git
+// commands it runs.
+func (c *Client) EnsureWorktree(ctx context.Context, proj *Project, branch string) (*Worktree, error) {
+	if branch == "" {
+		return nil, eris.New("branch is required")
+	}
+
+	unlock, err := c.lockProject(proj.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	path, created, err := c.preparer().EnsureWorktree(c.gitContext(ctx), proj.model(), branch)
+	if err != nil {
+		return nil, err
+	}
+	return &Worktree{Project: proj.Name, Branch: branch, Path: path, Created: created}, nil
+}
+
+// EnsureSession returns the session of a worktree, creating it if it isn't running, like
+// 'sesh switch'. A new session runs the startup command, processes, and compose services of the
+// project, and is returned once the startup command exits and the services are ready when the
+// project waits for them. Canceling ctx stops waiting.
+func (c *Client) EnsureSession(ctx context.Context, wt *Worktree) (*Session, error) {
+	name := workspace.GenerateSessionName(wt.Project, wt.Branch)
+
+	unlock, err := c.lockProject(wt.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := c.sessions.Exists(name)
+	if err != nil {
+		unlock()
+		return nil, eris.Wrap(err, "failed to check session existence")
+	}
+	if exists {
+		unlock()
+		return &Session{Name: name, Path: wt.Path}, nil
+	}
+
+	startup, err := c.preparer().CreateSession(c.gitContext(ctx), wt.Project, wt.Branch, name, wt.Path)
+	// The startup is waited for without holding the lock, like the CLI does
+	unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := prepare.WaitForStartup(ctx, c.sessions, startup, c.disp); err != nil {
+		return nil, err
+	}
+
+	return &Session{Name: name, Path: wt.Path, Created: true}, nil
+}
+
+// Attach attaches the terminal to a session, or switches the client to it when already
+// inside a session
+func (c *Client) Attach(name string) error {
+	if c.sessions.IsInsideSession() {
+		return c.sessions.Switch(name)
+	}
+	return c.sessions.Attach(name)
+}
+
+// Switch resolves a project, ensures the worktree and session of a branch exist, and attaches
+// to the session, like 'sesh switch'
+func (c *Client) Switch(ctx context.Context, projectName, branch string) (*Session, error) {
+	proj, err := c.ResolveProject(projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	wt, err := c.EnsureWorktree(ctx, proj, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := c.EnsureSession(ctx, wt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Attach(sess.Name); err != nil {
+		return nil, eris.Wrap(err, "failed to attach to session")
+	}
+	return sess, nil
+}
+
+// lockProject takes the project lock the CLI holds while changing a project's worktrees
+func (c *Client) lockProject(projectName string) (func(), error) {
+	l, err := lock.Acquire(workspace.GetLockPath(c.cfg.WorkspaceDir, projectName), lockTimeout)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to lock project %s", projectName)
+	}
+	return func() {
+		//nolint:errcheck // The lock is released by the OS on exit anyway
+		l.Release()
+	}, nil
+}
+
+// preparer returns what creates the worktrees and sessions of the client, like the CLI's
+func (c *Client) preparer() *prepare.Preparer {
+	return prepare.New(prepare.Options{
+		Config:   c.cfg,
+		Sessions: c.sessions,
+		Display:  c.disp,
+		Progress: c.progress,
+	})
+}
+
+// gitContext limits the git commands run with ctx to the configured git_timeout
+func (c *Client) gitContext(ctx context.Context) context.Context {
+	return git.WithTimeout(ctx, c.cfg.GitTimeout)
+}
+
+// newProject converts the internal project model
+func newProject(proj *models.Project) Project {
+	return Project{Name: proj.Name, RemoteURL: proj.RemoteURL, Path: proj.LocalPath}
+}
+
+// model converts a project back to the internal project model
+func (p *Project) model() *models.Project {
+	return &models.Project{Name: p.Name, RemoteURL: p.RemoteURL, LocalPath: p.Path}
+}
@@ -0,0 +1,150 @@
+package sesh
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/benoctopus/sesh/internal/config"
+	"github.com/benoctopus/sesh/internal/display"
+	"github.com/benoctopus/sesh/internal/session"
+)
+
+// fakeSessionManager keeps sessions in memory
+// Embedding session.SessionManager means any method the test doesn't expect panics
+type fakeSessionManager struct {
+	session.SessionManager
+	sessions []string
+	attached string
+}
+
+func (f *fakeSessionManager) Name() string { return "fake" }
+
+func (f *fakeSessionManager) Create(name, path string) error {
+	f.sessions = append(f.sessions, name)
+	return nil
+}
+
+func (f *fakeSessionManager) Exists(name string) (bool, error) {
+	return slices.Contains(f.sessions, name), nil
+}
+
+func (f *fakeSessionManager) IsInsideSession() bool { return false }
+
+func (f *fakeSessionManager) Attach(name string) error {
+	f.attached = name
+	return nil
+}
+
+// setupClient creates a client for a workspace holding one project, example.com/user/repo,
+// whose remote has a main and a feature branch
+func setupClient(t *testing.T) (*Client, *fakeSessionManager) {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	for _, key := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(key, "sesh")
+	}
+	for _, key := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(key, "sesh@example.com")
+	}
+
+	src := filepath.Join(t.TempDir(), "src")
+	workspaceDir := t.TempDir()
+	bare := filepath.Join(workspaceDir, "example.com", "user", "repo.git")
+
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main", src},
+		{"-C", src, "commit", "--quiet", "--allow-empty", "-m", "initial"},
+		{"-C", src, "branch", "feature"},
+		{"clone", "--quiet", "--bare", src, bare},
+		{"-C", bare, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"},
+		{"-C", bare, "fetch", "--quiet", "origin"},
+		{"-C", bare, "branch", "--quiet", "-D", "feature"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	sessions := &fakeSessionManager{}
+	return &Client{
+		cfg:      &config.Config{WorkspaceDir: workspaceDir},
+		sessions: sessions,
+		disp:     display.New(io.Discard),
+	}, sessions
+}
+
+func TestClient_EnsureWorktree(t *testing.T) {
+	client, _ := setupClient(t)
+
+	proj, err := client.ResolveProject("repo")
+	if err != nil {
+		t.Fatalf("ResolveProject() failed: %v", err)
+	}
+	if proj.Name != "example.com/user/repo" {
+		t.Errorf("ResolveProject() name = %q, want %q", proj.Name, "example.com/user/repo")
+	}
+
+	tests := []struct {
+		name        string
+		branch      string
+		wantCreated bool
+	}{
+		{name: "local branch", branch: "main", wantCreated: true},
+		{name: "existing worktree", branch: "main", wantCreated: false},
+		{name: "remote branch", branch: "feature", wantCreated: true},
+		{name: "new branch", branch: "fresh", wantCreated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wt, err := client.EnsureWorktree(t.Context(), proj, tt.branch)
+			if err != nil {
+				t.Fatalf("EnsureWorktree() failed: %v", err)
+			}
+
+			if wt.Created != tt.wantCreated {
+				t.Errorf("Created = %v, want %v", wt.Created, tt.wantCreated)
+			}
+			wantPath := filepath.Join(client.cfg.WorkspaceDir, "example.com", "user", "repo", tt.branch)
+			if wt.Path != wantPath {
+				t.Errorf("Path = %q, want %q", wt.Path, wantPath)
+			}
+			if _, err := os.Stat(wt.Path); err != nil {
+				t.Errorf("worktree is missing: %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_Switch(t *testing.T) {
+	client, sessions := setupClient(t)
+
+	sess, err := client.Switch(t.Context(), "example.com/user/repo", "main")
+	if err != nil {
+		t.Fatalf("Switch() failed: %v", err)
+	}
+	if sess.Name != "repo-main" || !sess.Created {
+		t.Errorf("Switch() = %+v, want a new repo-main session", sess)
+	}
+	if sessions.attached != "repo-main" {
+		t.Errorf("attached to %q, want %q", sessions.attached, "repo-main")
+	}
+
+	sess, err = client.Switch(t.Context(), "repo", "main")
+	if err != nil {
+		t.Fatalf("second Switch() failed: %v", err)
+	}
+	if sess.Created {
+		t.Error("second Switch() created the session again")
+	}
+
+	if _, err := client.Switch(t.Context(), "missing", "main"); err == nil {
+		t.Error("Switch() succeeded for a missing project")
+	}
+}